@@ -0,0 +1,182 @@
+// BaseSQL gRPC 服务定义，修改后执行 make proto 重新生成代码
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: api/basesqlpb/basesql.proto
+
+package basesqlpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BaseSQL_QueryStream_FullMethodName = "/basesql.v1.BaseSQL/QueryStream"
+	BaseSQL_Exec_FullMethodName        = "/basesql.v1.BaseSQL/Exec"
+)
+
+// BaseSQLClient is the client API for BaseSQL service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BaseSQLClient interface {
+	// QueryStream 执行 SELECT，按块流式返回结果
+	// 第一个块携带列名，后续块只携带记录
+	QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (BaseSQL_QueryStreamClient, error)
+	// Exec 执行 INSERT、UPDATE、DELETE、CREATE、DROP，返回影响的行数
+	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResponse, error)
+}
+
+type baseSQLClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBaseSQLClient(cc grpc.ClientConnInterface) BaseSQLClient {
+	return &baseSQLClient{cc}
+}
+
+func (c *baseSQLClient) QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (BaseSQL_QueryStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BaseSQL_ServiceDesc.Streams[0], BaseSQL_QueryStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &baseSQLQueryStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BaseSQL_QueryStreamClient interface {
+	Recv() (*QueryChunk, error)
+	grpc.ClientStream
+}
+
+type baseSQLQueryStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *baseSQLQueryStreamClient) Recv() (*QueryChunk, error) {
+	m := new(QueryChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *baseSQLClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResponse, error) {
+	out := new(ExecResponse)
+	err := c.cc.Invoke(ctx, BaseSQL_Exec_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BaseSQLServer is the server API for BaseSQL service.
+// All implementations must embed UnimplementedBaseSQLServer
+// for forward compatibility
+type BaseSQLServer interface {
+	// QueryStream 执行 SELECT，按块流式返回结果
+	// 第一个块携带列名，后续块只携带记录
+	QueryStream(*QueryRequest, BaseSQL_QueryStreamServer) error
+	// Exec 执行 INSERT、UPDATE、DELETE、CREATE、DROP，返回影响的行数
+	Exec(context.Context, *ExecRequest) (*ExecResponse, error)
+	mustEmbedUnimplementedBaseSQLServer()
+}
+
+// UnimplementedBaseSQLServer must be embedded to have forward compatible implementations.
+type UnimplementedBaseSQLServer struct {
+}
+
+func (UnimplementedBaseSQLServer) QueryStream(*QueryRequest, BaseSQL_QueryStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
+}
+func (UnimplementedBaseSQLServer) Exec(context.Context, *ExecRequest) (*ExecResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedBaseSQLServer) mustEmbedUnimplementedBaseSQLServer() {}
+
+// UnsafeBaseSQLServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BaseSQLServer will
+// result in compilation errors.
+type UnsafeBaseSQLServer interface {
+	mustEmbedUnimplementedBaseSQLServer()
+}
+
+func RegisterBaseSQLServer(s grpc.ServiceRegistrar, srv BaseSQLServer) {
+	s.RegisterService(&BaseSQL_ServiceDesc, srv)
+}
+
+func _BaseSQL_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BaseSQLServer).QueryStream(m, &baseSQLQueryStreamServer{stream})
+}
+
+type BaseSQL_QueryStreamServer interface {
+	Send(*QueryChunk) error
+	grpc.ServerStream
+}
+
+type baseSQLQueryStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *baseSQLQueryStreamServer) Send(m *QueryChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BaseSQL_Exec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaseSQLServer).Exec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BaseSQL_Exec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaseSQLServer).Exec(ctx, req.(*ExecRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BaseSQL_ServiceDesc is the grpc.ServiceDesc for BaseSQL service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BaseSQL_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "basesql.v1.BaseSQL",
+	HandlerType: (*BaseSQLServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Exec",
+			Handler:    _BaseSQL_Exec_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryStream",
+			Handler:       _BaseSQL_QueryStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/basesqlpb/basesql.proto",
+}
@@ -0,0 +1,54 @@
+package basesql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// APIClient 方言器访问飞书开放平台使用的接口
+// 回调、迁移器和分页读取只通过该接口发送请求，*Client 是默认实现。
+// 单元测试可以实现该接口，按请求路径返回预置的响应，通过 OpenWithAPI 注入，不需要启动 HTTP 服务：
+//
+//	db, err := gorm.Open(basesql.OpenWithAPI(&basesql.Config{AppToken: "app_token"}, fake), &gorm.Config{})
+//
+// 注入其他实现时不创建 *Client，Dialector.Client 为空；表 ID、字段 ID 的记录和字段改名后的名称转换依赖客户端内部状态，不启用
+type APIClient interface {
+	// DoRequest 发送 API 请求，返回飞书 API 的原始响应，响应体中的业务错误码由调用方检查
+	DoRequest(ctx context.Context, req *APIRequest) (*APIResponse, error)
+	// ResolveTableID 按表名或表 ID 获取表 ID，表不存在时返回 ErrTableNotFound
+	ResolveTableID(ctx context.Context, nameOrID string) (string, error)
+}
+
+// *Client 实现了 APIClient
+var _ APIClient = (*Client)(nil)
+
+// OpenWithAPI 创建通过指定 APIClient 访问飞书 API 的方言器，配置与默认配置的合并方式与 Open 相同
+// 不创建 *Client，也不校验应用凭据，主要用于单元测试
+// 参数:
+//   - config: 用户提供的配置，可以为 nil 或部分配置
+//   - api: 发送请求使用的实现
+//
+// 返回:
+//   - gorm.Dialector: GORM 方言器接口实例
+func OpenWithAPI(config *Config, api APIClient) gorm.Dialector {
+	dialector := Open(config).(*Dialector)
+	dialector.API = api
+	return dialector
+}
+
+// api 返回访问飞书 API 使用的接口，未设置 API 时使用 Client
+func (d *Dialector) api() APIClient {
+	if d.API != nil {
+		return d.API
+	}
+	return d.Client
+}
+
+// baseClient 返回 API 的默认实现 *Client，注入了其他实现时返回 nil
+func (d *Dialector) baseClient() *Client {
+	if client, ok := d.api().(*Client); ok {
+		return client
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// maxDebugBodyBytes 调试日志中请求体和响应体的最大长度，超出部分截断
+const maxDebugBodyBytes = 4096
+
+// requestIDCtxKey 请求 ID 在上下文中的键
+type requestIDCtxKey struct{}
+
+// withRequestID 为一次 DoRequest 调用生成请求 ID，重试时沿用同一个 ID
+func withRequestID(ctx context.Context) (context.Context, error) {
+	id, err := newUUID()
+	if err != nil {
+		return nil, fmt.Errorf("生成请求 ID 失败: %w", err)
+	}
+	return context.WithValue(ctx, requestIDCtxKey{}, id), nil
+}
+
+// requestIDFromContext 获取上下文中的请求 ID
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// logger 获取客户端的日志器
+// 开启 DebugMode 的客户端使用调试级别的日志器副本，只影响本客户端的请求日志，不修改全局日志级别
+func (c *Client) logger() *common.Logger {
+	if c.config.DebugMode {
+		return common.DefaultLogger.WithLevel(common.LogLevelDebug)
+	}
+	return common.DefaultLogger
+}
+
+// apiDebugEnabled 判断是否需要记录请求和响应详情，避免非调试模式下额外的序列化开销
+func apiDebugEnabled(logger *common.Logger) bool {
+	return logger.GetLevel() <= common.LogLevelDebug
+}
+
+// logAPIRequestDetail 以调试级别记录发出的请求，包括查询参数、过滤条件和请求体
+// 敏感信息由日志器统一遮蔽
+func logAPIRequestDetail(ctx context.Context, logger *common.Logger, req *APIRequest, body []byte) {
+	fields := map[string]interface{}{
+		"request_id": requestIDFromContext(ctx),
+		"method":     req.Method,
+		"path":       req.Path,
+	}
+	if len(req.QueryParams) > 0 {
+		fields["query"] = fmt.Sprint(req.QueryParams)
+	}
+	if filter := requestFilter(req, body); filter != "" {
+		fields["filter"] = filter
+	}
+	if len(body) > 0 {
+		fields["body"] = truncateDebugBody(body)
+	}
+	logger.WithContext(ctx).WithFields(fields).Debug("发送 API 请求")
+}
+
+// logAPIResponseDetail 以调试级别记录响应，包括 HTTP 状态码、飞书错误码和响应体
+// 参数:
+//   - ctx: 请求上下文
+//   - logger: 客户端的日志器
+//   - req: API 请求
+//   - statusCode: HTTP 状态码，请求未发出或未收到响应时为 0
+//   - body: 响应体
+//   - logID: 飞书返回的 X-Tt-Logid，用于向飞书排查问题
+//   - duration: 请求耗时
+//   - err: 请求错误
+func logAPIResponseDetail(ctx context.Context, logger *common.Logger, req *APIRequest, statusCode int, body []byte, logID string, duration time.Duration, err error) {
+	fields := map[string]interface{}{
+		"request_id":  requestIDFromContext(ctx),
+		"method":      req.Method,
+		"path":        req.Path,
+		"status_code": statusCode,
+		"duration_ms": float64(duration) / float64(time.Millisecond),
+	}
+	if logID != "" {
+		fields["log_id"] = logID
+	}
+	if len(body) > 0 {
+		var envelope struct {
+			Code *int   `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(body, &envelope) == nil && envelope.Code != nil {
+			fields["code"] = *envelope.Code
+			fields["msg"] = envelope.Msg
+		}
+		fields["body"] = truncateDebugBody(body)
+	}
+
+	entry := logger.WithContext(ctx).WithFields(fields)
+	if err != nil {
+		entry.WithError(err).Debug("API 请求失败")
+		return
+	}
+	entry.Debug("收到 API 响应")
+}
+
+// requestFilter 获取请求中的过滤条件：搜索接口在请求体的 filter 中，列表接口在查询参数 filter 中
+func requestFilter(req *APIRequest, body []byte) string {
+	if filter := req.QueryParams["filter"]; filter != "" {
+		return filter
+	}
+	if len(body) == 0 {
+		return ""
+	}
+	var payload struct {
+		Filter json.RawMessage `json:"filter"`
+	}
+	if json.Unmarshal(body, &payload) != nil || len(payload.Filter) == 0 || string(payload.Filter) == "null" {
+		return ""
+	}
+	return string(payload.Filter)
+}
+
+// truncateDebugBody 截断过长的请求体或响应体
+func truncateDebugBody(body []byte) string {
+	if len(body) <= maxDebugBodyBytes {
+		return string(body)
+	}
+	return fmt.Sprintf("%s...（共 %d 字节，已截断）", body[:maxDebugBodyBytes], len(body))
+}
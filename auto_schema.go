@@ -0,0 +1,191 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm/schema"
+)
+
+// ensureTableSchema 在开启 AutoCreateSchema 时为写入准备表结构
+// 表不存在时按写入的列创建表；表已存在时为不存在的列创建字段
+// 参数:
+//   - ctx: 上下文
+//   - dialector: 方言器
+//   - tableName: 表名
+//   - columns: 写入的列名到字段类型的映射
+//
+// 返回:
+//   - error: 建表或创建字段失败，只读模式下需要修改表结构时返回 ErrReadOnly
+func ensureTableSchema(ctx context.Context, dialector *Dialector, tableName string, columns map[string]FieldType) error {
+	if !dialector.Config.AutoCreateSchema || len(columns) == 0 {
+		return nil
+	}
+
+	tableID, err := getTableID(dialector, tableName)
+	if errors.Is(err, ErrTableNotFound) {
+		return autoCreateTable(ctx, dialector, tableName, columns)
+	}
+	if err != nil {
+		return err
+	}
+
+	tableFields, err := getTableFields(dialector, tableName)
+	if err != nil {
+		return fmt.Errorf("获取表字段信息失败: %w", err)
+	}
+	existing := make(map[string]bool, len(tableFields))
+	for _, field := range tableFields {
+		existing[field.FieldName] = true
+	}
+
+	for _, name := range sortedColumnNames(columns) {
+		if existing[name] {
+			continue
+		}
+		if err := dialector.Config.CheckWritable("ADD FIELD"); err != nil {
+			return err
+		}
+		if err := createFieldRequest(ctx, dialector, tableID, &CreateFieldRequest{FieldName: name, Type: columns[name]}); err != nil {
+			return fmt.Errorf("自动创建字段 '%s' 失败: %w", name, err)
+		}
+		common.DefaultLogger.WithContext(ctx).Infof("已自动创建字段 %s.%s（%s）", tableName, name, GetFieldTypeName(columns[name]))
+	}
+	return nil
+}
+
+// autoCreateTable 按写入的列创建表，列按名称排序，第一列为索引列
+func autoCreateTable(ctx context.Context, dialector *Dialector, tableName string, columns map[string]FieldType) error {
+	if err := dialector.Config.CheckWritable("CREATE TABLE"); err != nil {
+		return err
+	}
+
+	names := sortedColumnNames(columns)
+	fields := make([]*CreateFieldRequest, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, &CreateFieldRequest{FieldName: name, Type: columns[name]})
+	}
+
+	resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", dialector.Config.AppToken),
+		Body: &CreateTableRequest{
+			Table: &TableRequest{
+				Name:            tableName,
+				DefaultViewName: "默认视图",
+				Fields:          fields,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("自动创建表 '%s' 失败: %w", tableName, err)
+	}
+	if err := checkAPIResponse(resp); err != nil {
+		return fmt.Errorf("自动创建表 '%s' 失败: %w", tableName, err)
+	}
+	common.DefaultLogger.WithContext(ctx).Infof("已自动创建表 %s（%d 个字段）", tableName, len(fields))
+	return nil
+}
+
+// createFieldRequest 调用 API 为表创建字段
+func createFieldRequest(ctx context.Context, dialector *Dialector, tableID string, field *CreateFieldRequest) error {
+	resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", dialector.Config.AppToken, tableID),
+		Body:   field,
+	})
+	if err != nil {
+		return err
+	}
+	return checkAPIResponse(resp)
+}
+
+// checkAPIResponse 检查响应的业务错误码
+func checkAPIResponse(resp *APIResponse) error {
+	var apiResp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return fmt.Errorf("解析API响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return fmt.Errorf("API请求失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	return nil
+}
+
+// sortedColumnNames 获取按名称排序的列名
+func sortedColumnNames(columns map[string]FieldType) []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemaFieldType 根据 GORM 字段的数据类型确定飞书字段类型
+func schemaFieldType(field *schema.Field) FieldType {
+	switch field.DataType {
+	case schema.Bool:
+		return FieldTypeCheckbox
+	case schema.Int, schema.Uint, schema.Float:
+		return FieldTypeNumber
+	case schema.Time:
+		return FieldTypeDate
+	case urlDataType:
+		return FieldTypeURL
+	default:
+		return FieldTypeText
+	}
+}
+
+// inferFieldType 根据写入的值推断飞书字段类型
+// 布尔值为复选框，数字为数字，时间为日期，字符串切片为多选，其余为文本
+func inferFieldType(value interface{}) FieldType {
+	switch v := value.(type) {
+	case bool:
+		return FieldTypeCheckbox
+	case json.Number:
+		return FieldTypeNumber
+	case time.Time, *time.Time:
+		return FieldTypeDate
+	case []string:
+		return FieldTypeMultiSelect
+	case nil:
+		return FieldTypeText
+	default:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return FieldTypeNumber
+		}
+		return FieldTypeText
+	}
+}
+
+// modelColumns 获取模型写入的列，跳过主键、自增字段和自动时间字段，与 createCallback 的规则一致
+func modelColumns(sch *schema.Schema) map[string]FieldType {
+	columns := make(map[string]FieldType)
+	if sch == nil {
+		return columns
+	}
+	for _, field := range sch.Fields {
+		if field.PrimaryKey || field.AutoIncrement || field.DBName == "" {
+			continue
+		}
+		if isRecordTimeField(field) {
+			continue
+		}
+		columns[field.DBName] = schemaFieldType(field)
+	}
+	return columns
+}
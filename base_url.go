@@ -0,0 +1,124 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// BaseURL 从多维表格链接中解析出的信息
+// 在浏览器中打开多维表格时地址栏的链接形如
+// https://xxx.feishu.cn/base/<app_token>?table=<table_id>&view=<view_id>；
+// 知识库中的多维表格链接形如 https://xxx.feishu.cn/wiki/<wiki_token>?table=...，
+// 其中的 token 是知识库节点的 token，需要通过知识库 API 换取 app_token，见 Client.ResolveWikiToken
+type BaseURL struct {
+	AppToken  string `json:"app_token,omitempty"`  // 多维表格 app_token，知识库链接为空
+	WikiToken string `json:"wiki_token,omitempty"` // 知识库节点 token，只有知识库链接有
+	TableID   string `json:"table_id,omitempty"`   // 链接中的数据表 ID
+	ViewID    string `json:"view_id,omitempty"`    // 链接中的视图 ID
+}
+
+// IsBaseURL 判断配置值是否为链接而不是 app_token
+func IsBaseURL(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+}
+
+// ParseBaseURL 解析飞书或 Lark 的多维表格链接
+// 支持 /base/<app_token> 和 /wiki/<wiki_token> 两种路径，查询参数中的 table 和 view 分别为数据表和视图 ID
+// 参数:
+//   - raw: 从浏览器复制的链接
+//
+// 返回:
+//   - *BaseURL: 解析结果
+//   - error: 不是多维表格链接
+func ParseBaseURL(raw string) (*BaseURL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("无效的多维表格链接: %s", raw)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	result := &BaseURL{
+		TableID: u.Query().Get("table"),
+		ViewID:  u.Query().Get("view"),
+	}
+	for i := 0; i+1 < len(segments); i++ {
+		switch segments[i] {
+		case "base":
+			result.AppToken = segments[i+1]
+		case "wiki":
+			result.WikiToken = segments[i+1]
+		default:
+			continue
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("链接中没有多维表格 app_token，应形如 https://xxx.feishu.cn/base/<app_token>?table=<table_id>: %s", raw)
+}
+
+// wikiNodeResponse 获取知识库节点接口的 data 部分
+type wikiNodeResponse struct {
+	Node struct {
+		ObjType  string `json:"obj_type"`  // 节点对应的文档类型，多维表格为 bitable
+		ObjToken string `json:"obj_token"` // 节点对应文档的 token，多维表格即 app_token
+	} `json:"node"`
+}
+
+// ResolveWikiToken 通过知识库 API 获取知识库节点对应的多维表格 app_token
+// 应用需要知识库节点的阅读权限
+// 参数:
+//   - ctx: 上下文
+//   - wikiToken: 知识库节点 token，见 BaseURL.WikiToken
+//
+// 返回:
+//   - string: 多维表格 app_token
+//   - error: 请求失败或节点不是多维表格
+func (c *Client) ResolveWikiToken(ctx context.Context, wikiToken string) (string, error) {
+	resp, err := c.DoRequest(ctx, &APIRequest{
+		Method:      "GET",
+		Path:        "/wiki/v2/spaces/get_node",
+		QueryParams: map[string]string{"token": wikiToken},
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取知识库节点失败: %w", err)
+	}
+	var node wikiNodeResponse
+	if err := repoCheckResponse(resp.Body, &node); err != nil {
+		return "", fmt.Errorf("获取知识库节点失败: %w", err)
+	}
+	if node.Node.ObjType != "bitable" || node.Node.ObjToken == "" {
+		return "", fmt.Errorf("知识库节点 %s 不是多维表格（类型: %s）", wikiToken, node.Node.ObjType)
+	}
+	return node.Node.ObjToken, nil
+}
+
+// resolveAppToken 将配置中以链接形式填写的 AppToken 替换为 app_token
+// 多维表格链接直接取出 app_token；知识库链接通过知识库 API 换取，需要在客户端创建之后进行
+// 参数:
+//   - ctx: 上下文
+//   - client: 飞书 API 客户端
+//   - config: 配置
+//
+// 返回:
+//   - error: 链接无效或换取失败
+func resolveAppToken(ctx context.Context, client *Client, config *Config) error {
+	if !IsBaseURL(config.AppToken) {
+		return nil
+	}
+	ref, err := ParseBaseURL(config.AppToken)
+	if err != nil {
+		return ErrInvalidConfig(err.Error())
+	}
+	if ref.AppToken != "" {
+		config.AppToken = ref.AppToken
+		return nil
+	}
+	appToken, err := client.ResolveWikiToken(ctx, ref.WikiToken)
+	if err != nil {
+		return err
+	}
+	config.AppToken = appToken
+	return nil
+}
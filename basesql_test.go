@@ -1,10 +1,553 @@
 package basesql
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
 )
 
+// testConfig 返回以用户身份连接到 baseURL 的测试配置
+func testConfig(baseURL string) *Config {
+	return &Config{
+		AppID:       "cli_test_app_id",
+		AppSecret:   "test_app_secret_0123456789",
+		AppToken:    "app_token",
+		AuthType:    AuthTypeUser,
+		AccessToken: "u-test_access_token",
+		BaseURL:     baseURL,
+	}
+}
+
+// fakeBitable 测试用的内存多维表格服务
+// 表、字段和记录保存在内存中，实现列表、搜索、分页和读写接口；
+// 测试需要检查或改写的请求通过 handle 注册处理函数，先于默认实现匹配
+type fakeBitable struct {
+	mu     sync.Mutex
+	server *httptest.Server
+	tables []*fakeTable
+	routes []fakeRoute
+	nextID int
+	log    []string // 收到的请求，格式为 "方法 路径"
+}
+
+// fakeTable 内存中的一张表
+type fakeTable struct {
+	id       string
+	name     string
+	fields   []map[string]interface{}
+	records  []map[string]interface{} // 记录对象，包含 record_id 和 fields
+	pageSize int                      // 每页最多返回的记录数，0 表示不限制
+}
+
+// fakeRoute 按请求方法和路径后缀匹配的处理函数
+type fakeRoute struct {
+	method string // 为空时匹配任意方法
+	suffix string
+	handle func(*fakeRequest) interface{}
+}
+
+// fakeRequest 传给处理函数的请求，请求体已经读出
+type fakeRequest struct {
+	*http.Request
+	w    http.ResponseWriter
+	body []byte
+}
+
+// decode 把请求体解析到 v
+func (r *fakeRequest) decode(v interface{}) {
+	json.Unmarshal(r.body, v)
+}
+
+// fakeError 处理函数返回后写出飞书业务错误码
+type fakeError struct {
+	code int
+	msg  string
+}
+
+// newFakeBitable 启动内存多维表格服务，测试结束时关闭
+func newFakeBitable(t *testing.T) *fakeBitable {
+	fb := &fakeBitable{}
+	fb.server = httptest.NewServer(fb)
+	t.Cleanup(fb.server.Close)
+	return fb
+}
+
+// fakeFields 按名称和类型交替给出字段定义，如 fakeFields("name", 1, "score", 2)
+func fakeFields(nameTypes ...interface{}) []map[string]interface{} {
+	fields := make([]map[string]interface{}, 0, len(nameTypes)/2)
+	for i := 0; i+1 < len(nameTypes); i += 2 {
+		fields = append(fields, map[string]interface{}{"field_name": nameTypes[i], "type": nameTypes[i+1]})
+	}
+	return fields
+}
+
+// parseFakeFields 解析 JSON 数组格式的字段定义，用于带 property 的字段
+func parseFakeFields(text string) []map[string]interface{} {
+	var fields []map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		panic(err)
+	}
+	return fields
+}
+
+// table 添加一张表
+func (fb *fakeBitable) table(id, name string, fields []map[string]interface{}) *fakeTable {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	table := &fakeTable{id: id, name: name, fields: fields}
+	fb.tables = append(fb.tables, table)
+	return table
+}
+
+// add 向表中添加记录，返回记录 ID；recordID 为空时自动生成
+func (fb *fakeBitable) add(tableID, recordID string, fields map[string]interface{}) string {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.insert(fb.find(tableID), recordID, fields)
+}
+
+// records 返回表中各条记录的字段值
+func (fb *fakeBitable) records(tableID string) []map[string]interface{} {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	var rows []map[string]interface{}
+	for _, record := range fb.find(tableID).records {
+		rows = append(rows, record["fields"].(map[string]interface{}))
+	}
+	return rows
+}
+
+// handle 注册处理函数，pattern 为 "方法 路径后缀" 或只有路径后缀
+// 处理函数返回的值作为 data 写出：字符串按 JSON 原样写出，fakeError 写出错误码，nil 表示已自行写出响应
+func (fb *fakeBitable) handle(pattern string, handle func(*fakeRequest) interface{}) {
+	route := fakeRoute{suffix: pattern, handle: handle}
+	if method, suffix, ok := strings.Cut(pattern, " "); ok {
+		route.method, route.suffix = method, suffix
+	}
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	fb.routes = append(fb.routes, route)
+}
+
+// requests 返回路径包含 part 的请求，格式为 "方法 路径"
+func (fb *fakeBitable) requests(part string) []string {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	var requests []string
+	for _, request := range fb.log {
+		if strings.Contains(request, part) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// next 交给默认实现处理，供只记录请求内容的处理函数使用
+func (fb *fakeBitable) next(r *fakeRequest) interface{} {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.snapshot(fb.serve(r))
+}
+
+// snapshot 在持有锁时把响应编码为 JSON，避免写出响应时读到并发修改的记录
+func (fb *fakeBitable) snapshot(data interface{}) interface{} {
+	if _, ok := data.(fakeError); ok {
+		return data
+	}
+	body, _ := json.Marshal(data)
+	return string(body)
+}
+
+// logFilters 记录每次读取记录时下推的第一个过滤条件，没有过滤条件时记为 none
+func (fb *fakeBitable) logFilters(filters *[]string) {
+	logFilter := func(r *fakeRequest) interface{} {
+		var body ListRecordsRequest
+		r.decode(&body)
+		filter := "none"
+		if body.Filter != nil {
+			filter = fmt.Sprintf("%s %v", body.Filter.Conditions[0].Operator, body.Filter.Conditions[0].Value)
+		}
+		*filters = append(*filters, filter)
+		return fb.next(r)
+	}
+	fb.handle("/records", logFilter)
+	fb.handle("/records/search", logFilter)
+}
+
+// config 返回连接到该服务的测试配置
+func (fb *fakeBitable) config() *Config {
+	return testConfig(fb.server.URL)
+}
+
+// open 打开连接到该服务的数据库，configure 可以在打开前修改配置
+func (fb *fakeBitable) open(t *testing.T, configure func(*Config)) *gorm.DB {
+	t.Helper()
+	config := fb.config()
+	if configure != nil {
+		configure(config)
+	}
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db
+}
+
+// find 按表 ID 查找表，调用方需要持有锁
+func (fb *fakeBitable) find(tableID string) *fakeTable {
+	for _, table := range fb.tables {
+		if table.id == tableID {
+			return table
+		}
+	}
+	return nil
+}
+
+// insert 添加记录，调用方需要持有锁
+func (fb *fakeBitable) insert(table *fakeTable, recordID string, fields map[string]interface{}) string {
+	for recordID == "" {
+		fb.nextID++
+		if _, record := table.record(fmt.Sprintf("rec%d", fb.nextID)); record == nil {
+			recordID = fmt.Sprintf("rec%d", fb.nextID)
+		}
+	}
+	copied := map[string]interface{}{}
+	for name, value := range fields {
+		copied[name] = value
+	}
+	table.records = append(table.records, map[string]interface{}{"record_id": recordID, "fields": copied})
+	return recordID
+}
+
+// record 按记录 ID 查找记录，调用方需要持有锁
+func (table *fakeTable) record(recordID string) (int, map[string]interface{}) {
+	for i, record := range table.records {
+		if record["record_id"] == recordID {
+			return i, record
+		}
+	}
+	return -1, nil
+}
+
+// update 合并记录的字段值，调用方需要持有锁
+func (table *fakeTable) update(recordID string, fields map[string]interface{}) map[string]interface{} {
+	_, record := table.record(recordID)
+	if record == nil {
+		return nil
+	}
+	for name, value := range fields {
+		record["fields"].(map[string]interface{})[name] = value
+	}
+	return record
+}
+
+// remove 删除记录，调用方需要持有锁
+func (table *fakeTable) remove(recordID string) bool {
+	i, _ := table.record(recordID)
+	if i < 0 {
+		return false
+	}
+	table.records = append(table.records[:i], table.records[i+1:]...)
+	return true
+}
+
+// fakeText 返回字段值的文本，富文本按片段拼接
+func fakeText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		var text strings.Builder
+		for _, segment := range value {
+			if segment, ok := segment.(map[string]interface{}); ok {
+				text.WriteString(fmt.Sprint(segment["text"]))
+			}
+		}
+		return text.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// matchFakeCondition 判断记录的字段值是否满足过滤条件，只比较文本和数字
+// 与飞书一致：is 区分大小写，contains 不区分大小写
+func matchFakeCondition(fields map[string]interface{}, condition *FilterCondition) bool {
+	text := fakeText(fields[condition.FieldName])
+	contains := func() bool {
+		return len(condition.Value) > 0 && strings.Contains(strings.ToLower(text), strings.ToLower(fmt.Sprint(condition.Value[0])))
+	}
+	compare := func() int {
+		if len(condition.Value) == 0 {
+			return 0
+		}
+		want := fmt.Sprint(condition.Value[0])
+		a, errA := strconv.ParseFloat(text, 64)
+		b, errB := strconv.ParseFloat(want, 64)
+		if errA == nil && errB == nil {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+		return strings.Compare(text, want)
+	}
+	switch condition.Operator {
+	case "isEmpty":
+		return text == ""
+	case "isNotEmpty":
+		return text != ""
+	case "is", "isAnyOf":
+		for _, want := range condition.Value {
+			if text == fmt.Sprint(want) {
+				return true
+			}
+		}
+		return false
+	case "isNot":
+		return len(condition.Value) > 0 && text != fmt.Sprint(condition.Value[0])
+	case "contains":
+		return contains()
+	case "doesNotContain":
+		return len(condition.Value) > 0 && !contains()
+	case "isGreater":
+		return compare() > 0
+	case "isGreaterEqual":
+		return compare() >= 0
+	case "isLess":
+		return compare() < 0
+	case "isLessEqual":
+		return compare() <= 0
+	}
+	return false
+}
+
+// matchFakeFilter 判断记录是否满足过滤请求
+func matchFakeFilter(record map[string]interface{}, filter *FilterRequest) bool {
+	if filter == nil || len(filter.Conditions) == 0 {
+		return true
+	}
+	fields, _ := record["fields"].(map[string]interface{})
+	for _, condition := range filter.Conditions {
+		matched := matchFakeCondition(fields, condition)
+		if filter.Conjunction == "or" && matched {
+			return true
+		}
+		if filter.Conjunction != "or" && !matched {
+			return false
+		}
+	}
+	return filter.Conjunction != "or"
+}
+
+// listRecords 按过滤条件、排序和分页参数返回一页记录，调用方需要持有锁
+func (fb *fakeBitable) listRecords(table *fakeTable, r *fakeRequest) map[string]interface{} {
+	var req ListRecordsRequest
+	r.decode(&req)
+	items := make([]map[string]interface{}, 0, len(table.records))
+	for _, record := range table.records {
+		if matchFakeFilter(record, req.Filter) {
+			items = append(items, record)
+		}
+	}
+	for i := len(req.Sort) - 1; i >= 0; i-- {
+		name, desc := strings.TrimPrefix(req.Sort[i], "-"), strings.HasPrefix(req.Sort[i], "-")
+		sort.SliceStable(items, func(a, b int) bool {
+			x := fakeText(items[a]["fields"].(map[string]interface{})[name])
+			y := fakeText(items[b]["fields"].(map[string]interface{})[name])
+			if desc {
+				return x > y
+			}
+			return x < y
+		})
+	}
+
+	// page_token 为下一页第一条记录的下标，没有 page_size 时返回全部记录
+	size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if size <= 0 {
+		size = len(items)
+	}
+	if table.pageSize > 0 && (size == 0 || size > table.pageSize) {
+		size = table.pageSize
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("page_token"))
+	start = min(start, len(items))
+	end := min(start+size, len(items))
+	page := map[string]interface{}{"items": items[start:end], "has_more": end < len(items), "total": len(items)}
+	if end < len(items) {
+		page["page_token"] = strconv.Itoa(end)
+	}
+	return page
+}
+
+// serve 默认的表、字段和记录接口，调用方需要持有锁
+func (fb *fakeBitable) serve(r *fakeRequest) interface{} {
+	path := r.URL.Path
+	at := strings.Index(path, "/tables")
+	if at < 0 {
+		return fakeError{code: 404, msg: "not found"}
+	}
+	parts := strings.Split(strings.Trim(path[at+len("/tables"):], "/"), "/")
+	if len(parts) == 1 && parts[0] == "" && r.Method == http.MethodPost {
+		var body CreateTableRequest
+		if r.decode(&body); body.Table == nil {
+			return fakeError{code: 1254001, msg: "table is required"}
+		}
+		table := &fakeTable{id: fmt.Sprintf("tbl%d", len(fb.tables)+1), name: body.Table.Name, fields: []map[string]interface{}{}}
+		for _, field := range body.Table.Fields {
+			table.fields = append(table.fields, map[string]interface{}{"field_name": field.FieldName, "type": field.Type})
+		}
+		fb.tables = append(fb.tables, table)
+		return map[string]interface{}{"table_id": table.id}
+	}
+	if len(parts) == 1 && parts[0] == "" {
+		items := make([]map[string]string, 0, len(fb.tables))
+		for _, table := range fb.tables {
+			items = append(items, map[string]string{"table_id": table.id, "name": table.name})
+		}
+		return map[string]interface{}{"items": items, "has_more": false, "total": len(items)}
+	}
+	table := fb.find(parts[0])
+	if table == nil {
+		return fakeError{code: 1254041, msg: "TableIdNotFound"}
+	}
+
+	var body struct {
+		Fields    map[string]interface{} `json:"fields"`
+		Records   json.RawMessage        `json:"records"`
+		RecordIDs []string               `json:"record_ids"`
+	}
+	r.decode(&body)
+	action := strings.Join(parts[1:], "/")
+	switch {
+	case action == "fields" && r.Method == http.MethodPost:
+		var field map[string]interface{}
+		r.decode(&field)
+		table.fields = append(table.fields, field)
+		return map[string]interface{}{"field": field}
+	case action == "fields":
+		fields := table.fields
+		if fields == nil {
+			fields = []map[string]interface{}{}
+		}
+		return map[string]interface{}{"items": fields, "has_more": false, "total": len(fields)}
+	case action == "records" && r.Method == http.MethodGet, action == "records/search":
+		return fb.listRecords(table, r)
+	case action == "records" && r.Method == http.MethodPost:
+		_, record := table.record(fb.insert(table, "", body.Fields))
+		return map[string]interface{}{"record": record}
+	case action == "records/batch_get":
+		records := []map[string]interface{}{}
+		for _, id := range body.RecordIDs {
+			if _, record := table.record(id); record != nil {
+				records = append(records, record)
+			}
+		}
+		return map[string]interface{}{"records": records}
+	case action == "records/batch_create":
+		var records []*CreateRecordRequest
+		json.Unmarshal(body.Records, &records)
+		created := []map[string]interface{}{}
+		for _, record := range records {
+			_, r := table.record(fb.insert(table, "", record.Fields))
+			created = append(created, r)
+		}
+		return map[string]interface{}{"records": created}
+	case action == "records/batch_update":
+		var records []*BatchUpdateRecord
+		json.Unmarshal(body.Records, &records)
+		updated := []map[string]interface{}{}
+		for _, record := range records {
+			if r := table.update(record.RecordID, record.Fields); r != nil {
+				updated = append(updated, r)
+			}
+		}
+		return map[string]interface{}{"records": updated}
+	case action == "records/batch_delete":
+		var ids []string
+		json.Unmarshal(body.Records, &ids)
+		deleted := []map[string]interface{}{}
+		for _, id := range ids {
+			deleted = append(deleted, map[string]interface{}{"record_id": id, "deleted": table.remove(id)})
+		}
+		return map[string]interface{}{"records": deleted}
+	case len(parts) == 3 && parts[1] == "records":
+		if _, record := table.record(parts[2]); record == nil {
+			return fakeError{code: 1254043, msg: "RecordIdNotFound"}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_, record := table.record(parts[2])
+			return map[string]interface{}{"record": record}
+		case http.MethodPut:
+			return map[string]interface{}{"record": table.update(parts[2], body.Fields)}
+		case http.MethodDelete:
+			return map[string]interface{}{"deleted": table.remove(parts[2]), "record_id": parts[2]}
+		}
+	}
+	return fakeError{code: 404, msg: "not found"}
+}
+
+func (fb *fakeBitable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := &fakeRequest{Request: r, w: w, body: body}
+
+	fb.mu.Lock()
+	fb.log = append(fb.log, r.Method+" "+r.URL.Path)
+	var handle func(*fakeRequest) interface{}
+	for _, route := range fb.routes {
+		if (route.method == "" || route.method == r.Method) && strings.HasSuffix(r.URL.Path, route.suffix) {
+			handle = route.handle
+			break
+		}
+	}
+	var data interface{}
+	if handle == nil {
+		data = fb.snapshot(fb.serve(req))
+	}
+	fb.mu.Unlock()
+	// 处理函数不持有锁，可以调用 add、records 等方法
+	if handle != nil {
+		data = handle(req)
+	}
+
+	switch data := data.(type) {
+	case nil:
+	case fakeError:
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": data.code, "msg": data.msg})
+	case string:
+		fmt.Fprintf(w, `{"code":0,"msg":"success","data":%s}`, data)
+	default:
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success", "data": data})
+	}
+}
+
 func TestConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -257,3 +800,3668 @@ func TestErrorTypes(t *testing.T) {
 		t.Errorf("Expected error details 'test details', got %s", baseErr.Details)
 	}
 }
+
+func TestResultSet_QueryRows(t *testing.T) {
+	rs := NewResultSet([]string{"name", "age", "tags"})
+	rs.AppendRow("张三", 30.0, []string{"a", "b"})
+	rs.AppendRow("李四", nil, nil)
+
+	rows, err := rs.QueryRows(context.Background())
+	if err != nil {
+		t.Fatalf("QueryRows() error = %v", err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var name, tags sql.NullString
+		var age sql.NullInt64
+		if err := rows.Scan(&name, &age, &tags); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s|%d|%s", name.String, age.Int64, tags.String))
+	}
+
+	expected := []string{"张三|30|a, b", "李四|0|"}
+	if strings.Join(got, ";") != strings.Join(expected, ";") {
+		t.Errorf("rows = %v, expected %v", got, expected)
+	}
+
+	var name string
+	if err := NewResultSet([]string{"name"}).QueryRow(context.Background()).Scan(&name); err != sql.ErrNoRows {
+		t.Errorf("QueryRow() on empty result error = %v, expected sql.ErrNoRows", err)
+	}
+}
+
+func TestParseRawSQLWithVars(t *testing.T) {
+	cmd, err := parseRawSQLWithVars("UPDATE users SET age = ?, note = ? WHERE name = ?", []interface{}{30, "a, b = c", "张三"})
+	if err != nil {
+		t.Fatalf("parseRawSQLWithVars() error = %v", err)
+	}
+	if cmd.Values["age"] != 30 {
+		t.Errorf("age = %v, expected 30", cmd.Values["age"])
+	}
+	if cmd.Values["note"] != "a, b = c" {
+		t.Errorf("note = %v, expected 'a, b = c'", cmd.Values["note"])
+	}
+	if cmd.Where != "name = '张三'" {
+		t.Errorf("where = %q, expected %q", cmd.Where, "name = '张三'")
+	}
+
+	if _, err := parseRawSQLWithVars("DELETE FROM users WHERE name = ? AND note = '?'", []interface{}{"a", "b"}); err == nil {
+		t.Error("expected error when placeholder count does not match vars")
+	}
+
+	// 参数中的单引号转义后整体作为一个值，不能拼出其他过滤条件
+	tests := []struct {
+		sql      string
+		value    interface{}
+		operator string
+		want     []interface{}
+	}{
+		{"DELETE FROM users WHERE name = ?", "O'Brien", "is", []interface{}{"O'Brien"}},
+		{"DELETE FROM users WHERE name = ?", "x' OR age > '0", "is", []interface{}{"x' OR age > '0"}},
+		{"DELETE FROM users WHERE name != ?", []byte("it's"), "isNot", []interface{}{"it's"}},
+		{"DELETE FROM users WHERE name IN ('O''Brien', 'a, b')", nil, "isAnyOf", []interface{}{"O'Brien", "a, b"}},
+		// 多个条件时值只取到字面量标记为止
+		{"DELETE FROM users WHERE name = ? AND age = 1", "bob", "is", []interface{}{"bob"}},
+		{"DELETE FROM users WHERE name = 'a AND b = c' AND age = 1", nil, "is", []interface{}{"a AND b = c"}},
+		{"DELETE FROM users WHERE name != ? AND status = 'done' AND age > 3", "bob", "isNot", []interface{}{"bob"}},
+		{"DELETE FROM users WHERE name = bob AND age = 1", nil, "is", []interface{}{"bob"}},
+	}
+	for _, tt := range tests {
+		var vars []interface{}
+		if tt.value != nil {
+			vars = []interface{}{tt.value}
+		}
+		cmd, err := parseRawSQLWithVars(tt.sql, vars)
+		if err != nil {
+			t.Fatalf("parseRawSQLWithVars(%q) error = %v", tt.sql, err)
+		}
+		filter, _ := buildFilterFromWhere(cmd.Where, false)
+		if filter == nil || len(filter.Conditions) != 1 {
+			t.Fatalf("buildFilterFromWhere(%q) = %+v, want one condition", cmd.Where, filter)
+		}
+		condition := filter.Conditions[0]
+		if condition.FieldName != "name" || condition.Operator != tt.operator || !reflect.DeepEqual(condition.Value, tt.want) {
+			t.Errorf("buildFilterFromWhere(%q) = %s %s %v, want name %s %v", cmd.Where, condition.FieldName, condition.Operator, condition.Value, tt.operator, tt.want)
+		}
+	}
+}
+
+func TestEvaluatePermission(t *testing.T) {
+	readonly := []string{ScopeBitableAppReadonly}
+
+	if r := evaluatePermission(PermReadRecords, readonly, nil, true, true); !r.Allowed || !r.Verified {
+		t.Errorf("read with readonly scope = %+v, expected allowed", r)
+	}
+	if r := evaluatePermission(PermWriteRecords, readonly, nil, true, true); r.Allowed || len(r.MissingScopes) != 1 || r.MissingScopes[0] != ScopeBitableApp {
+		t.Errorf("write with readonly scope = %+v, expected missing %s", r, ScopeBitableApp)
+	}
+	if r := evaluatePermission(PermWriteRecords, []string{ScopeBitableApp}, nil, false, true); r.Allowed {
+		t.Errorf("write without edit permission = %+v, expected denied", r)
+	}
+	if r := evaluatePermission(PermManageSchema, nil, nil, true, false); !r.Allowed || r.Verified {
+		t.Errorf("write with unknown scopes = %+v, expected allowed but unverified", r)
+	}
+
+	scopeErr := common.NewAPIError(99991672, "api", "API 错误 99991672: Access denied. One of the following scopes is required: [bitable:app, bitable:app:readonly]", "")
+	r := evaluatePermission(PermReadSchema, nil, scopeErr, true, false)
+	if r.Allowed || strings.Join(r.MissingScopes, ",") != "bitable:app,bitable:app:readonly" {
+		t.Errorf("read with scope error = %+v, expected missing scopes from message", r)
+	}
+
+	report := &PermissionReport{Results: []PermissionResult{r}}
+	if err := report.Err(); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("report.Err() = %v, expected ErrPermissionDenied", err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		method, path string
+		expected     RetryOperation
+	}{
+		{"GET", "/bitable/v1/apps/app/tables/tbl/records", RetryOpRead},
+		{"POST", "/bitable/v1/apps/app/tables/tbl/records/search", RetryOpRead},
+		{"POST", "/bitable/v1/apps/app/tables/tbl/records/batch_create", RetryOpWrite},
+		{"DELETE", "/bitable/v1/apps/app/tables/tbl/records/rec", RetryOpWrite},
+		{"GET", "/bitable/v1/apps/app/tables/tbl/fields", RetryOpMetadata},
+	}
+	for _, tt := range tests {
+		if got := classifyRetryOperation(&APIRequest{Method: tt.method, Path: tt.path}); got != tt.expected {
+			t.Errorf("classifyRetryOperation(%s %s) = %s, expected %s", tt.method, tt.path, got, tt.expected)
+		}
+	}
+
+	config := RetryConfig{MaxRetries: 3, InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2, Jitter: true}
+	for i := 0; i < 100; i++ {
+		if delay := config.CalculateBackoffDelay(2); delay < 0 || delay > 400*time.Millisecond {
+			t.Fatalf("jittered delay = %v, expected within [0, 400ms]", delay)
+		}
+	}
+
+	if DefaultShouldRetry(common.NewAPIError(99991672, "api", "scope missing", ""), 0, RetryOpRead) {
+		t.Error("scope errors should not be retried")
+	}
+	if !DefaultShouldRetry(fmt.Errorf("wrapped: %w", common.NewAPIError(99991400, "api", "rate limited", "")), 0, RetryOpWrite) {
+		t.Error("rate limit errors should be retried")
+	}
+
+	headers := http.Header{}
+	headers.Set("Retry-After", "3")
+	if got := common.ParseRetryAfter(headers, time.Now()); got != 3*time.Second {
+		t.Errorf("ParseRetryAfter() = %v, expected 3s", got)
+	}
+
+	// Retry-After 不超过策略的 MaxDelay
+	limited := common.NewAPIError(429, "http", "rate limited", "")
+	limited.RetryAfter = time.Hour
+	fixed := RetryConfig{MaxRetries: 3, InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond, Multiplier: 1}
+	if got := retryDelay(&fixed, 1, limited); got != 50*time.Millisecond {
+		t.Errorf("retryDelay() with a long Retry-After = %v, expected MaxDelay 50ms", got)
+	}
+	limited.RetryAfter = 30 * time.Millisecond
+	if got := retryDelay(&fixed, 1, limited); got != 30*time.Millisecond {
+		t.Errorf("retryDelay() with a short Retry-After = %v, expected 30ms", got)
+	}
+
+	// 服务端要求等待一小时时按 MaxDelay 重试
+	requests := 0
+	fb := newFakeBitable(t)
+	fb.handle("/tables", func(r *fakeRequest) interface{} {
+		if requests++; requests == 1 {
+			r.w.Header().Set("Retry-After", "3600")
+			r.w.WriteHeader(http.StatusTooManyRequests)
+			return nil
+		}
+		return fb.next(r)
+	})
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.SetRetryConfig(&fixed)
+	start := time.Now()
+	if _, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"}); err != nil {
+		t.Fatalf("DoRequest() after 429 error = %v", err)
+	}
+	if elapsed := time.Since(start); requests != 2 || elapsed > 5*time.Second {
+		t.Errorf("DoRequest() made %d requests in %v, expected 2 requests after waiting MaxDelay", requests, elapsed)
+	}
+
+	// 等待后重试会超过上下文的截止时间时立即返回最后一次的错误
+	requests = 0
+	fb.handle("/fields", func(r *fakeRequest) interface{} {
+		requests++
+		r.w.Header().Set("Retry-After", "10")
+		r.w.WriteHeader(http.StatusServiceUnavailable)
+		return nil
+	})
+	client.SetRetryConfig(&RetryConfig{MaxRetries: 3, InitialDelay: 10 * time.Millisecond, MaxDelay: 30 * time.Second, Multiplier: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start = time.Now()
+	_, err = client.DoRequest(ctx, &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/fields"})
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusServiceUnavailable || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DoRequest() error = %v, expected the 503 before the deadline", err)
+	}
+	if elapsed := time.Since(start); requests != 1 || elapsed > time.Second {
+		t.Errorf("DoRequest() made %d requests in %v, expected one request without waiting", requests, elapsed)
+	}
+}
+
+func TestWithClientToken(t *testing.T) {
+	req := &APIRequest{Method: "POST", Path: "/bitable/v1/apps/app/tables/tbl/records", Body: map[string]interface{}{"fields": map[string]interface{}{"name": "张三"}}}
+	withToken := func(ctx context.Context, req *APIRequest) *APIRequest {
+		t.Helper()
+		got, err := withClientToken(ctx, req)
+		if err != nil {
+			t.Fatalf("withClientToken() error = %v", err)
+		}
+		return got
+	}
+
+	got := withToken(context.Background(), req)
+	token := got.QueryParams[clientTokenParam]
+	if len(token) != 36 || token[14] != '4' {
+		t.Errorf("client_token = %q, expected UUID v4", token)
+	}
+	if req.QueryParams != nil {
+		t.Error("withClientToken should not modify the original request")
+	}
+	if again := withToken(context.Background(), got); again.QueryParams[clientTokenParam] != token {
+		t.Error("existing client_token should be kept")
+	}
+	if get := withToken(context.Background(), &APIRequest{Method: "GET", Path: req.Path}); get.QueryParams != nil {
+		t.Error("non-create requests should not get a client_token")
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "order-1")
+	a := withToken(ctx, req).QueryParams[clientTokenParam]
+	b := withToken(ctx, req).QueryParams[clientTokenParam]
+	other := withToken(ctx, &APIRequest{Method: "POST", Path: req.Path, Body: map[string]interface{}{"fields": map[string]interface{}{"name": "李四"}}})
+	if a != b {
+		t.Errorf("keyed client_token should be deterministic, got %q and %q", a, b)
+	}
+	if other.QueryParams[clientTokenParam] == a {
+		t.Error("different records under the same key should get different client_tokens")
+	}
+
+	// 读取随机数失败时不发出没有 client_token 的创建请求；带幂等键的 client_token 不需要随机数
+	saved := randReader
+	randReader = iotest.ErrReader(errors.New("no entropy"))
+	defer func() { randReader = saved }()
+	if _, err := withClientToken(context.Background(), req); err == nil || !strings.Contains(err.Error(), "no entropy") {
+		t.Errorf("withClientToken() with failing rand error = %v, want the read error", err)
+	}
+	if keyed := withToken(ctx, req).QueryParams[clientTokenParam]; keyed != a {
+		t.Errorf("keyed client_token with failing rand = %q, want %q", keyed, a)
+	}
+
+	fb := newFakeBitable(t)
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	if _, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"}); err == nil {
+		t.Error("DoRequest() with failing rand should fail to generate the request ID")
+	}
+	if sent := fb.requests("/tables"); len(sent) != 0 {
+		t.Errorf("requests sent with failing rand = %v, want none", sent)
+	}
+}
+
+func TestHasTagOption(t *testing.T) {
+	type user struct {
+		ID    string
+		Email string `basesql:"unique"`
+		Name  string `basesql:"required, Unique"`
+		Note  string
+	}
+	sch, err := schema.Parse(&user{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+
+	for name, expected := range map[string]bool{"Email": true, "Name": true, "Note": false} {
+		if got := hasTagOption(sch.LookUpField(name), "unique"); got != expected {
+			t.Errorf("hasTagOption(%s) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	type user struct {
+		ID     string
+		Email  string `basesql:"unique,required,pattern=^[a-z]{1,8}@example\\.com$"`
+		Age    int    `basesql:"min=0,max=150"`
+		Status string `basesql:"enum=active|disabled"`
+	}
+	sch, err := schema.Parse(&user{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+	rules, err := RulesFromSchema(sch)
+	if err != nil {
+		t.Fatalf("RulesFromSchema() error = %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("len(rules) = %d, expected 3", len(rules))
+	}
+
+	valid := map[string]interface{}{"email": "alice@example.com", "age": 30.0, "status": "active"}
+	if err := ValidateRecord(rules, valid, false); err != nil {
+		t.Errorf("ValidateRecord(valid) error = %v", err)
+	}
+
+	invalid := map[string]interface{}{"email": "Alice@test.com", "age": 200, "status": []interface{}{"active", "gone"}}
+	err = ValidateRecord(rules, invalid, false)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("ValidateRecord(invalid) error = %v, expected ValidationErrors", err)
+	}
+	var got []string
+	for _, ve := range verrs {
+		got = append(got, ve.Field+":"+ve.Rule)
+	}
+	if strings.Join(got, ",") != "email:pattern,age:max,status:enum" {
+		t.Errorf("violations = %v", got)
+	}
+
+	// 不是数字的值报告类型错误，而不是超出范围
+	err = ValidateRecord(rules, map[string]interface{}{"email": "bob@example.com", "age": "thirty"}, false)
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Field != "age" || verrs[0].Rule != "type" {
+		t.Errorf("ValidateRecord(non-number age) error = %v, expected a single age:type violation", err)
+	}
+
+	if err := ValidateRecord(rules, map[string]interface{}{"age": 1}, true); err != nil {
+		t.Errorf("partial update without required field error = %v, expected nil", err)
+	}
+	if err := ValidateRecord(rules, map[string]interface{}{"age": 1}, false); err == nil {
+		t.Error("create without required field should fail")
+	}
+}
+
+func TestValueRange(t *testing.T) {
+	type Task struct {
+		ID       string `gorm:"primaryKey"`
+		Progress float64
+		Score    int
+		Effort   float64
+	}
+
+	var written int
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "tasks", parseFakeFields(`[{"field_name":"progress","type":19,"property":{"formatter":"0%"}},`+
+		`{"field_name":"score","type":21,"property":{"min":1,"max":5,"rating":{"symbol":"star"}}},`+
+		`{"field_name":"effort","type":19,"property":{"range_customize":true,"min":0,"max":100}}]`))
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		written++
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	if err := db.Create(&Task{Progress: 0.5, Score: 4, Effort: 80}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	err := db.Create(&Task{Progress: 150, Score: 9, Effort: 80}).Error
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Create() out of range error = %v, expected ValidationErrors", err)
+	}
+	var got []string
+	for _, ve := range verrs {
+		got = append(got, ve.Field+":"+ve.Rule)
+	}
+	if strings.Join(got, ",") != "progress:max,score:max" {
+		t.Errorf("violations = %v", got)
+	}
+	if written != 1 {
+		t.Errorf("written = %d records, expected the out of range record to be rejected before writing", written)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	dialector := &Dialector{Config: &Config{ReadOnly: true}}
+
+	stmt := &gorm.Statement{}
+	stmt.SQL.WriteString("DELETE FROM users WHERE name = '张三'")
+	if err := rawCallback(&gorm.DB{Config: &gorm.Config{}, Statement: stmt}, dialector); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("raw DELETE error = %v, expected ErrReadOnly", err)
+	}
+	if err := (Migrator{Dialector: dialector}).DropTable("users"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DropTable() error = %v, expected ErrReadOnly", err)
+	}
+	if err := (&Config{}).CheckWritable("INSERT"); err != nil {
+		t.Errorf("CheckWritable() without read-only error = %v, expected nil", err)
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	tests := []struct {
+		method, path string
+		read, write  bool
+	}{
+		{"GET", "/bitable/v1/apps/app/tables", true, false},
+		{"GET", "/bitable/v1/apps/app/tables/tbl/fields", true, false},
+		{"GET", "/bitable/v1/apps/app/tables/tbl/records", false, false},
+		{"POST", "/bitable/v1/apps/app/tables", false, true},
+		{"DELETE", "/bitable/v1/apps/app/tables/tbl", false, true},
+		{"PUT", "/bitable/v1/apps/app/tables/tbl/fields/fld", false, true},
+		{"POST", "/bitable/v1/apps/app/tables/tbl/records/batch_create", false, false},
+	}
+	for _, tt := range tests {
+		req := &APIRequest{Method: tt.method, Path: tt.path}
+		if got := isSchemaReadRequest(req); got != tt.read {
+			t.Errorf("isSchemaReadRequest(%s %s) = %v, expected %v", tt.method, tt.path, got, tt.read)
+		}
+		if got := isSchemaWriteRequest(req); got != tt.write {
+			t.Errorf("isSchemaWriteRequest(%s %s) = %v, expected %v", tt.method, tt.path, got, tt.write)
+		}
+	}
+
+	cache := newSchemaCache(time.Minute, "", "cli_test_app_id", "")
+	cache.put("/ok", &APIResponse{Body: []byte(`{"code":0,"data":{}}`)})
+	cache.put("/denied", &APIResponse{Body: []byte(`{"code":91403,"msg":"forbidden"}`)})
+	if _, ok := cache.get("/ok"); !ok {
+		t.Error("successful response should be cached")
+	}
+	if _, ok := cache.get("/denied"); ok {
+		t.Error("responses with a business error code should not be cached")
+	}
+	cache.invalidate()
+	if _, ok := cache.get("/ok"); ok {
+		t.Error("invalidate should drop all entries")
+	}
+
+	expired := newSchemaCache(-time.Second, "", "cli_test_app_id", "")
+	expired.put("/ok", &APIResponse{Body: []byte(`{"code":0}`)})
+	if _, ok := expired.get("/ok"); ok {
+		t.Error("expired entries should not be returned")
+	}
+
+	// 多个进程同时写入同一缓存文件时各自使用临时文件，文件始终完整
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			writer := newSchemaCache(time.Minute, dir, "cli_test_app_id", "")
+			writer.put(fmt.Sprintf("/bitable/v1/apps/app/tables/tbl%d/fields", i), &APIResponse{Body: []byte(`{"code":0}`)})
+		}(i)
+	}
+	wg.Wait()
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || !strings.HasPrefix(filepath.Base(files[0]), "app-") {
+		t.Fatalf("cache dir = %v, expected a single cache file", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil || !json.Valid(data) {
+		t.Errorf("cache file = %s, %v, expected valid JSON", data, err)
+	}
+}
+
+func TestSchemaCacheDir(t *testing.T) {
+	var fetches int
+	fb := newFakeBitable(t)
+	fb.table("tbl1", "tasks", nil)
+	fb.handle("/tables", func(r *fakeRequest) interface{} {
+		fetches++
+		return fb.next(r)
+	})
+
+	dir := t.TempDir()
+	newClient := func() *Client {
+		config := fb.config()
+		config.SchemaCacheTTL, config.CacheDir = time.Minute, dir
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	listTables := func(client *Client) {
+		resp, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"})
+		if err != nil {
+			t.Fatalf("DoRequest() error = %v", err)
+		}
+		if !strings.Contains(string(resp.Body), "tbl1") {
+			t.Fatalf("response body = %s, expected the table list", resp.Body)
+		}
+	}
+
+	listTables(newClient())
+	files, _ := filepath.Glob(filepath.Join(dir, "app_token-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("cache files = %v, expected one file for app_token", files)
+	}
+	cacheFile := files[0]
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	// 之后创建的客户端直接使用缓存文件
+	second := newClient()
+	listTables(second)
+	if fetches != 1 {
+		t.Errorf("fetches = %d, expected 1 (second client should read the cache file)", fetches)
+	}
+
+	second.InvalidateSchemaCache()
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Errorf("InvalidateSchemaCache() should remove the cache file, stat error = %v", err)
+	}
+	listTables(newClient())
+	if fetches != 2 {
+		t.Errorf("fetches = %d, expected 2 after invalidation", fetches)
+	}
+
+	// 损坏的缓存文件当作没有缓存
+	if err := os.WriteFile(cacheFile, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	listTables(newClient())
+	if fetches != 3 {
+		t.Errorf("fetches = %d, expected 3 with a corrupt cache file", fetches)
+	}
+
+	// 其他应用访问同一多维表格时使用单独的缓存文件
+	other := fb.config()
+	other.AppID, other.SchemaCacheTTL, other.CacheDir = "cli_other_app_id", time.Minute, dir
+	otherClient, err := NewClient(other)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer otherClient.Close()
+	listTables(otherClient)
+	if fetches != 4 {
+		t.Errorf("fetches = %d, expected 4 (another app should not read this app's cache)", fetches)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "app_token-*.json")); len(files) != 2 {
+		t.Errorf("cache files = %v, expected one file per app", files)
+	}
+
+	// 未设置 SchemaCacheTTL 时不缓存表结构，CacheEnabled 和 CacheTTL 不影响表结构缓存
+	config := fb.config()
+	config.CacheEnabled, config.CacheTTL, config.CacheDir = true, 5*time.Minute, dir
+	uncached, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer uncached.Close()
+	listTables(uncached)
+	listTables(uncached)
+	if fetches != 6 {
+		t.Errorf("fetches = %d, expected 6 without SchemaCacheTTL", fetches)
+	}
+}
+
+func TestClientRegistry(t *testing.T) {
+	registry := NewClientRegistry(&RegistryConfig{GlobalQPS: 10})
+	config := &Config{
+		AppID:     "cli_test_app_id",
+		AppSecret: "test_app_secret_0123456789",
+		AppToken:  "app_token_a",
+		LazyAuth:  true,
+		// 读请求每秒最多 0.001 次，取走唯一的令牌后不会在测试期间补充
+		RateLimits: &RateLimits{Read: RateLimit{QPS: 0.001, Burst: 1}},
+	}
+
+	first, err := registry.Get(config)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, err := registry.Get(config)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if first != second {
+		t.Error("Get() with the same app_id and app_token should return the same client")
+	}
+	if first.sharedLimiter == nil {
+		t.Error("clients from a registry with GlobalQPS should use the global limiter")
+	}
+
+	// 客户端自身的限流器拒绝的请求不消耗全局令牌
+	first.rateLimits.base.read.Allow()
+	before := first.sharedLimiter.GetTokens()
+	for i := 0; i < 3; i++ {
+		if _, err := first.doSingleRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token_a/tables"}); err == nil {
+			t.Fatal("request over the client rate limit should be rejected")
+		}
+	}
+	if after := first.sharedLimiter.GetTokens(); after < before {
+		t.Errorf("global tokens after client-side rejections = %v, want at least %v", after, before)
+	}
+	if stats := first.sharedLimiter.GetStats(); stats.AllowedRequests != 0 || stats.RejectedRequests != 3 {
+		t.Errorf("global limiter stats = %d allowed, %d rejected, want 0 and 3", stats.AllowedRequests, stats.RejectedRequests)
+	}
+
+	other := *config
+	other.AppToken = "app_token_b"
+	third, err := registry.Get(&other)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if third == first {
+		t.Error("Get() with a different app_token should return a different client")
+	}
+	if registry.Len() != 2 {
+		t.Errorf("Len() = %d, expected 2", registry.Len())
+	}
+
+	// 认证方式或凭据不同的配置不共享已经认证的客户端
+	for name, change := range map[string]func(*Config){
+		"app_secret":   func(c *Config) { c.AppSecret = "wrong_app_secret_0123456789" },
+		"auth_type":    func(c *Config) { c.AuthType, c.AccessToken = AuthTypeUser, "u-token_a" },
+		"access_token": func(c *Config) { c.AuthType, c.AccessToken = AuthTypeUser, "u-token_b" },
+	} {
+		changed := *config
+		change(&changed)
+		client, err := registry.Get(&changed)
+		if err != nil {
+			t.Fatalf("Get() with a different %s error = %v", name, err)
+		}
+		if client == first {
+			t.Errorf("Get() with a different %s returned the existing client", name)
+		}
+	}
+	if registry.Len() != 5 {
+		t.Errorf("Len() = %d, expected 5", registry.Len())
+	}
+
+	// 移除租户时关闭它使用各个凭据创建的客户端
+	if err := registry.Remove(config.AppID, config.AppToken); err != nil {
+		t.Errorf("Remove() error = %v", err)
+	}
+	if registry.Len() != 1 {
+		t.Errorf("Len() after Remove = %d, expected 1", registry.Len())
+	}
+
+	if err := registry.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := registry.Get(config); err == nil {
+		t.Error("Get() after Close should fail")
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	limits := &RateLimits{
+		Read:  RateLimit{QPS: 100, Burst: 100},
+		Write: RateLimit{QPS: 1, Burst: 1},
+		Tables: map[string]*TableRateLimits{
+			"tblHot": {Read: RateLimit{QPS: 1, Burst: 1}},
+		},
+	}
+	limiter := newPartitionedLimiter(limits, nil)
+	ctx := context.Background()
+
+	write := &APIRequest{Method: "POST", Path: "/bitable/v1/apps/app/tables/tblA/records"}
+	if err := limiter.allow(ctx, write); err != nil {
+		t.Fatalf("first write error = %v, expected nil", err)
+	}
+	if err := limiter.allow(ctx, write); err == nil {
+		t.Error("second write should exceed the write burst")
+	}
+	read := &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app/tables/tblA/records"}
+	if err := limiter.allow(ctx, read); err != nil {
+		t.Errorf("reads should not share the write bucket, error = %v", err)
+	}
+
+	hot := &APIRequest{Method: "POST", Path: "/bitable/v1/apps/app/tables/tblHot/records/search"}
+	if err := limiter.allow(ctx, hot); err != nil {
+		t.Fatalf("first read on tblHot error = %v, expected nil", err)
+	}
+	if err := limiter.allow(ctx, hot); err == nil {
+		t.Error("second read on tblHot should exceed the table read burst")
+	}
+
+	if !isWriteRequest(&APIRequest{Method: "DELETE", Path: "/bitable/v1/apps/app/tables/tblA"}) {
+		t.Error("DELETE table should count as a write")
+	}
+	if isWriteRequest(&APIRequest{Method: "GET", Path: "/bitable/v1/apps/app/tables/tblA/fields"}) {
+		t.Error("GET fields should count as a read")
+	}
+
+	if err := (&RateLimits{Write: RateLimit{QPS: -1}}).validate(); err == nil {
+		t.Error("negative QPS should be rejected")
+	}
+
+	// 多维表格级令牌桶拒绝的请求不消耗表级令牌
+	limiter = newPartitionedLimiter(&RateLimits{
+		Write:  RateLimit{QPS: 0.001, Burst: 1},
+		Tables: map[string]*TableRateLimits{"tblHot": {Write: RateLimit{QPS: 0.001, Burst: 2}}},
+	}, nil)
+	hotWrite := &APIRequest{Method: "POST", Path: "/bitable/v1/apps/app/tables/tblHot/records"}
+	if err := limiter.allow(ctx, hotWrite); err != nil {
+		t.Fatalf("first write on tblHot error = %v, expected nil", err)
+	}
+	if err := limiter.allow(ctx, hotWrite); err == nil {
+		t.Error("second write should exceed the base write burst")
+	}
+	if tokens := limiter.tables["tblHot"].write.GetTokens(); tokens < 1 {
+		t.Errorf("tblHot write tokens after a base rejection = %v, want 1", tokens)
+	}
+
+	// 按表名设置的限流在解析到表 ID 后生效，表名只解析一次
+	var resolved [][]string
+	limiter = newPartitionedLimiter(&RateLimits{
+		Tables: map[string]*TableRateLimits{
+			"tasks":   {Read: RateLimit{QPS: 0.001, Burst: 1}},
+			"missing": {Read: RateLimit{QPS: 0.001, Burst: 1}},
+		},
+	}, func(_ context.Context, keys []string) (map[string]string, error) {
+		sort.Strings(keys)
+		resolved = append(resolved, keys)
+		return map[string]string{"tasks": "tblTasks"}, nil
+	})
+	tasks := &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app/tables/tblTasks/records"}
+	if err := limiter.allow(ctx, tasks); err != nil {
+		t.Fatalf("first read on tasks error = %v, expected nil", err)
+	}
+	if err := limiter.allow(ctx, tasks); err == nil {
+		t.Error("second read on tasks should exceed the limit configured by table name")
+	}
+	other := &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app/tables/tblOther/records"}
+	for i := 0; i < 3; i++ {
+		if err := limiter.allow(ctx, other); err != nil {
+			t.Errorf("read on an unlimited table error = %v, expected nil", err)
+		}
+	}
+	if fmt.Sprint(resolved) != "[[missing tasks]]" {
+		t.Errorf("resolved keys = %v, want [[missing tasks]]", resolved)
+	}
+}
+
+func TestRateLimitsByTableName(t *testing.T) {
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "tasks", fakeFields("name", 1))
+	fb.table("tblUsers", "users", fakeFields("name", 1))
+	config := fb.config()
+	config.RateLimits = &RateLimits{Tables: map[string]*TableRateLimits{"tasks": {Read: RateLimit{QPS: 0.001, Burst: 1}}}}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	request := func(tableID string) error {
+		_, err := client.doSingleRequest(context.Background(), &APIRequest{
+			Method: "GET",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", config.AppToken, tableID),
+		})
+		return err
+	}
+	if err := request("tblTasks"); err != nil {
+		t.Fatalf("first read on tasks error = %v", err)
+	}
+	if err := request("tblTasks"); err == nil || !strings.Contains(err.Error(), "tblTasks") {
+		t.Errorf("second read on tasks error = %v, want the table rate limit", err)
+	}
+	if err := request("tblUsers"); err != nil {
+		t.Errorf("read on users error = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerControl(t *testing.T) {
+	client, err := NewClient(&Config{
+		AppID:     "cli_test_app_id",
+		AppSecret: "test_app_secret_0123456789",
+		AppToken:  "app_token",
+		LazyAuth:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if state := client.CircuitBreakerState(); state != CircuitClosed {
+		t.Errorf("initial state = %v, expected CLOSED", state)
+	}
+
+	client.TripCircuitBreaker()
+	if state := client.CircuitBreakerState(); state != CircuitOpen {
+		t.Errorf("state after trip = %v, expected OPEN", state)
+	}
+	_, err = client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/records"})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("request after trip error = %v, expected ErrCircuitOpen", err)
+	}
+
+	client.ResetCircuitBreaker()
+	if state := client.CircuitBreakerState(); state != CircuitClosed {
+		t.Errorf("state after reset = %v, expected CLOSED", state)
+	}
+}
+
+func TestStructuredLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(LoggerConfig{SlowThreshold: time.Millisecond, Format: "json", Output: &buf})
+
+	ctx, _ := withAPICallRecorder(WithTraceID(context.Background(), "trace-123"), "query", "users")
+	recordAPICall(ctx, &APIRequest{Method: "POST", Path: "/bitable/v1/apps/app/tables/tbl/records/search"}, 5*time.Millisecond, nil)
+	log.Trace(ctx, time.Now().Add(-10*time.Millisecond), func() (string, int64) { return "", 3 }, nil)
+
+	var entry struct {
+		Level   int                    `json:"level"`
+		Message string                 `json:"message"`
+		TraceID string                 `json:"trace_id"`
+		Fields  map[string]interface{} `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log output is not JSON: %v (%s)", err, buf.String())
+	}
+	if entry.TraceID != "trace-123" {
+		t.Errorf("trace_id = %q, expected trace-123", entry.TraceID)
+	}
+	if entry.Message != "慢语句" {
+		t.Errorf("message = %q, expected slow statement warning", entry.Message)
+	}
+	calls, _ := entry.Fields["api_calls"].([]interface{})
+	if len(calls) != 1 || !strings.HasPrefix(calls[0].(string), "POST /bitable/v1/apps/app/tables/tbl/records/search") {
+		t.Errorf("api_calls = %v, expected the search request", entry.Fields["api_calls"])
+	}
+	if entry.Fields["table"] != "users" || entry.Fields["operation"] != "query" {
+		t.Errorf("fields = %v, expected operation=query table=users", entry.Fields)
+	}
+
+	buf.Reset()
+	log.Trace(context.Background(), time.Now(), func() (string, int64) { return "", 0 }, nil)
+	if buf.Len() != 0 {
+		t.Errorf("fast statements should not be logged at Warn level, got %s", buf.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "basesql.log")
+	file, err := common.NewRotatingFile(path, &common.LogRotationConfig{MaxSizeMB: 1, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+
+	// 超过 1MB 时自动切割
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 2; i++ {
+		if _, err := file.Write(chunk); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		time.Sleep(2 * time.Millisecond) // 历史文件名精确到毫秒
+		if _, err := file.Write([]byte("line\n")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := file.Rotate(); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "basesql-*.log.gz"))
+	if len(backups) != 2 {
+		entries, _ := os.ReadDir(dir)
+		t.Errorf("expected 2 compressed backups, got %d (%v)", len(backups), entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("current log file should exist and be empty after rotation, err = %v", err)
+	}
+
+	t.Setenv("BASESQL_LOG_MAX_AGE", "1h")
+	t.Setenv("BASESQL_LOG_COMPRESS", "false")
+	config, err := common.LogRotationConfigFromEnv(nil)
+	if err != nil {
+		t.Fatalf("LogRotationConfigFromEnv() error = %v", err)
+	}
+	if config.MaxAge != time.Hour || config.Compress {
+		t.Errorf("LogRotationConfigFromEnv() = %+v, expected MaxAge=1h Compress=false", config)
+	}
+	t.Setenv("BASESQL_LOG_MAX_BACKUPS", "-1")
+	if _, err := common.LogRotationConfigFromEnv(nil); err == nil {
+		t.Error("negative BASESQL_LOG_MAX_BACKUPS should be rejected")
+	}
+}
+
+func TestAPIDebugLogging(t *testing.T) {
+	fb := newFakeBitable(t)
+	fb.table("tbl", "tasks", nil)
+	fb.handle("/records/search", func(r *fakeRequest) interface{} {
+		r.w.Header().Set("X-Tt-Logid", "log-abc")
+		return fb.next(r)
+	})
+
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	var buf bytes.Buffer
+	level := common.DefaultLogger.GetLevel()
+	common.SetLogOutput(&buf)
+	common.SetLogLevel(common.LogLevelDebug)
+	defer func() {
+		common.SetLogOutput(os.Stderr)
+		common.SetLogLevel(level)
+	}()
+
+	_, err = client.DoRequest(context.Background(), &APIRequest{
+		Method: "POST",
+		Path:   "/bitable/v1/apps/app_token/tables/tbl/records/search",
+		Body:   map[string]interface{}{"filter": map[string]interface{}{"conjunction": "and"}, "password": "hunter2-secret"},
+	})
+	if err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"发送 API 请求", "收到 API 响应", `"conjunction":"and"`, "log-abc", "request_id"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("debug output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "hunter2-secret") {
+		t.Errorf("debug output should mask sensitive values:\n%s", output)
+	}
+
+	// DebugMode 只影响本客户端的请求日志，不修改全局日志级别
+	common.SetLogLevel(common.LogLevelWarn)
+	config := fb.config()
+	config.DebugMode = true
+	debugClient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient(DebugMode) error = %v", err)
+	}
+	defer debugClient.Close()
+	if got := common.DefaultLogger.GetLevel(); got != common.LogLevelWarn {
+		t.Errorf("global log level after NewClient(DebugMode) = %v, want %v", got, common.LogLevelWarn)
+	}
+	buf.Reset()
+	if _, err := debugClient.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"}); err != nil {
+		t.Fatalf("DoRequest(DebugMode) error = %v", err)
+	}
+	if _, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/fields"}); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	output = buf.String()
+	if !strings.Contains(output, "发送 API 请求") {
+		t.Errorf("DebugMode client should log its requests:\n%s", output)
+	}
+	if strings.Contains(output, "/fields") {
+		t.Errorf("client without DebugMode should not log requests:\n%s", output)
+	}
+}
+
+func TestTraceIDHeader(t *testing.T) {
+	var received []string
+	fb := newFakeBitable(t)
+	fb.table("tbl", "tasks", nil)
+	fb.handle("/records", func(r *fakeRequest) interface{} {
+		received = append(received, r.Header.Get("X-Request-Id"))
+		return fb.next(r)
+	})
+
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	traceID, err := NewTraceID()
+	if err != nil {
+		t.Fatalf("NewTraceID() error = %v", err)
+	}
+	if other, _ := NewTraceID(); len(traceID) != 32 || traceID == other {
+		t.Errorf("NewTraceID() = %q, expected a unique 32-character ID", traceID)
+	}
+
+	req := &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/records"}
+	if _, err := client.DoRequest(WithTraceID(context.Background(), traceID), req); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if _, err := client.DoRequest(context.Background(), req); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if len(received) != 2 || received[0] != traceID || received[1] != "" {
+		t.Errorf("X-Request-Id headers = %q, expected [%q, \"\"]", received, traceID)
+	}
+}
+
+func TestAutoCreateSchema(t *testing.T) {
+	var created []string
+	fb := newFakeBitable(t)
+	fb.handle("POST /tables", func(r *fakeRequest) interface{} {
+		var body CreateTableRequest
+		r.decode(&body)
+		created = append(created, "table:"+body.Table.Name)
+		return fb.next(r)
+	})
+	fb.handle("POST /fields", func(r *fakeRequest) interface{} {
+		var body map[string]interface{}
+		r.decode(&body)
+		created = append(created, fmt.Sprintf("field:%s:%v", body["field_name"], body["type"]))
+		return fb.next(r)
+	})
+
+	config := fb.config()
+	config.AutoCreateSchema = true
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	dialector := &Dialector{Config: config, Client: client}
+
+	ctx := context.Background()
+	if err := ensureTableSchema(ctx, dialector, "events", map[string]FieldType{"name": FieldTypeText}); err != nil {
+		t.Fatalf("ensureTableSchema() create table error = %v", err)
+	}
+	columns := map[string]FieldType{"name": FieldTypeText, "count": inferFieldType(int64(3)), "ok": inferFieldType(true)}
+	if err := ensureTableSchema(ctx, dialector, "events", columns); err != nil {
+		t.Fatalf("ensureTableSchema() add fields error = %v", err)
+	}
+
+	expected := []string{"table:events", "field:count:2", "field:ok:7"}
+	if fmt.Sprint(created) != fmt.Sprint(expected) {
+		t.Errorf("schema changes = %v, expected %v", created, expected)
+	}
+
+	config.ReadOnly = true
+	err = ensureTableSchema(ctx, dialector, "events", map[string]FieldType{"extra": FieldTypeText})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("ensureTableSchema() in read-only mode error = %v, expected ErrReadOnly", err)
+	}
+}
+
+func TestPreloadBatches(t *testing.T) {
+	type Task struct {
+		ID      string `gorm:"primaryKey"`
+		Title   string
+		OwnerID string
+	}
+	type User struct {
+		ID    string `gorm:"primaryKey"`
+		Name  string
+		Tasks []Task `gorm:"foreignKey:OwnerID"`
+	}
+
+	var mu sync.Mutex
+	var searches, batchGets int
+	fb := newFakeBitable(t)
+	fb.table("tblUsers", "users", fakeFields("name", 1))
+	// 每页最多返回 40 条，检验分页读取
+	fb.table("tblTasks", "tasks", fakeFields("title", 1, "owner_id", 1)).pageSize = 40
+	for i := 0; i < 150; i++ {
+		fb.add("tblUsers", fmt.Sprintf("recU%d", i), map[string]interface{}{"name": fmt.Sprintf("user%d", i)})
+		fb.add("tblTasks", fmt.Sprintf("recT%d", i), map[string]interface{}{"title": "task", "owner_id": fmt.Sprintf("recU%d", i)})
+	}
+	fb.handle("tblTasks/records/search", func(r *fakeRequest) interface{} {
+		mu.Lock()
+		searches++
+		mu.Unlock()
+		return fb.next(r)
+	})
+	fb.handle("tblUsers/records/batch_get", func(r *fakeRequest) interface{} {
+		var body batchGetRecordsRequest
+		r.decode(&body)
+		mu.Lock()
+		batchGets++
+		mu.Unlock()
+		var records []map[string]interface{}
+		for _, id := range body.RecordIDs {
+			records = append(records, map[string]interface{}{"record_id": id, "fields": map[string]interface{}{"name": "name of " + id}})
+		}
+		return map[string]interface{}{"records": records}
+	})
+	db := fb.open(t, nil)
+
+	var users []User
+	if err := db.Preload("Tasks").Find(&users).Error; err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	if len(users) != 150 {
+		t.Fatalf("got %d users, expected 150", len(users))
+	}
+	for _, user := range users {
+		if len(user.Tasks) != 1 || user.Tasks[0].OwnerID != user.ID {
+			t.Fatalf("user %s tasks = %+v, expected one task owned by the user", user.ID, user.Tasks)
+		}
+	}
+	// 150 个负责人拆分为 100 和 50 两批，分别读取 3 页和 2 页
+	if searches != 5 {
+		t.Errorf("search requests = %d, expected 5 pages across 2 batches", searches)
+	}
+
+	ids := make([]interface{}, 0, 250)
+	for i := 0; i < 250; i++ {
+		ids = append(ids, fmt.Sprintf("recU%d", i))
+	}
+	var found []User
+	if err := db.Where(clause.IN{Column: clause.Column{Name: "id"}, Values: ids}).Find(&found).Error; err != nil {
+		t.Fatalf("Find() by record IDs error = %v", err)
+	}
+	if len(found) != 250 || batchGets != 3 {
+		t.Errorf("got %d users in %d batch_get requests, expected 250 in 3", len(found), batchGets)
+	}
+}
+
+func TestFindInConditionPrefersFewestValues(t *testing.T) {
+	wide := clause.IN{Column: clause.Column{Name: "owner_id"}, Values: []interface{}{"u1", "u2", "u3"}}
+	narrow := clause.IN{Column: clause.Column{Name: "status"}, Values: []interface{}{"open"}}
+	eq := clause.Eq{Column: clause.Column{Name: "title"}, Value: "task"}
+
+	in, rest, ok := findInCondition([]clause.Expression{wide, eq, narrow})
+	if !ok {
+		t.Fatalf("findInCondition() found no IN condition")
+	}
+	if column := in.Column.(clause.Column); column.Name != "status" {
+		t.Errorf("findInCondition() chose %s, expected the IN with the fewest values (status)", column.Name)
+	}
+	if !reflect.DeepEqual(rest, []clause.Expression{wide, eq}) {
+		t.Errorf("findInCondition() rest = %v, expected the other IN and the equality condition", rest)
+	}
+
+	if _, _, ok := findInCondition([]clause.Expression{eq}); ok {
+		t.Errorf("findInCondition() found an IN condition in %v", eq)
+	}
+}
+
+func TestLinkAssociation(t *testing.T) {
+	type Task struct {
+		ID    string `gorm:"primaryKey"`
+		Title string
+	}
+	type Project struct {
+		ID    string `gorm:"primaryKey"`
+		Name  string
+		Tasks []Task `gorm:"many2many:project_tasks" basesql:"link"`
+	}
+
+	var mu sync.Mutex
+	var writes [][]interface{}
+	fb := newFakeBitable(t)
+	fb.table("tblProjects", "projects", fakeFields("name", 1))
+	fb.table("tblTasks", "tasks", fakeFields("title", 1))
+	links := []interface{}{map[string]interface{}{"record_ids": []interface{}{"recT1"}, "text": "t1", "type": "text"}}
+	fb.add("tblProjects", "recP1", map[string]interface{}{"name": "p1", "tasks": links})
+	for _, id := range []string{"recT1", "recT2", "recT3"} {
+		fb.add("tblTasks", id, map[string]interface{}{"title": "title of " + id})
+	}
+	fb.handle("PUT tblProjects/records/recP1", func(r *fakeRequest) interface{} {
+		var body CreateRecordRequest
+		r.decode(&body)
+		mu.Lock()
+		writes = append(writes, body.Fields["tasks"].([]interface{}))
+		mu.Unlock()
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	project := &Project{ID: "recP1"}
+	if err := db.Model(project).Association("Tasks").Append(&Task{ID: "recT2"}, &Task{ID: "recT1"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := db.Model(project).Association("Tasks").Delete(&Task{ID: "recT1"}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := db.Model(project).Association("Tasks").Replace(&Task{ID: "recT3"}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	expected := "[[recT1 recT2] [recT2] [recT2 recT3] [recT3]]"
+	if fmt.Sprint(writes) != expected {
+		t.Errorf("link field writes = %v, expected %s", writes, expected)
+	}
+
+	var projects []Project
+	if err := db.Preload("Tasks").Find(&projects).Error; err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	if len(projects) != 1 || len(projects[0].Tasks) != 1 || projects[0].Tasks[0].Title != "title of recT3" {
+		t.Errorf("preloaded projects = %+v, expected one project linked to recT3", projects)
+	}
+
+	if err := db.Model(project).Association("Tasks").Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if links := fb.records("tblProjects")[0]["tasks"]; fmt.Sprint(links) != "[]" {
+		t.Errorf("link field after Clear() = %v, expected empty", links)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	type Member struct {
+		ID    string `gorm:"primaryKey"`
+		Email string
+		Name  string
+	}
+
+	var mu sync.Mutex
+	var batchSizes []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("email", 1, "name", 1))
+	for i := 0; i < 5; i++ {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"email": fmt.Sprintf("m%d@x.com", i), "name": fmt.Sprintf("m%d", i)})
+	}
+	for _, action := range []string{"create", "update"} {
+		action := action
+		fb.handle("/records/batch_"+action, func(r *fakeRequest) interface{} {
+			var body struct {
+				Records []json.RawMessage `json:"records"`
+			}
+			r.decode(&body)
+			mu.Lock()
+			batchSizes = append(batchSizes, fmt.Sprintf("%s:%d", action, len(body.Records)))
+			mu.Unlock()
+			return fb.next(r)
+		})
+	}
+	db := fb.open(t, nil)
+	repo := NewRepo[Member](db)
+	ctx := context.Background()
+
+	found, err := repo.FindByField(ctx, "email", "m3@x.com")
+	if err != nil || len(found) != 1 || found[0].ID != "rec3" || found[0].Name != "m3" {
+		t.Errorf("FindByField() = %+v, %v, expected member rec3", found, err)
+	}
+
+	var pages []int
+	cursor := ""
+	for {
+		page, err := repo.Page(ctx, cursor, 2)
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		pages = append(pages, len(page.Items))
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if fmt.Sprint(pages) != "[2 2 1]" {
+		t.Errorf("page sizes = %v, expected [2 2 1]", pages)
+	}
+
+	members := []Member{{Email: "new@x.com", Name: "new"}}
+	if err := repo.BatchCreate(ctx, members); err != nil || members[0].ID != "rec5" {
+		t.Errorf("BatchCreate() = %+v, %v, expected record ID rec5", members, err)
+	}
+
+	upserts := []Member{{Email: "m1@x.com", Name: "renamed"}, {Email: "other@x.com", Name: "other"}}
+	result, err := repo.Upsert(ctx, "email", upserts)
+	if err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if result.Created != 1 || result.Updated != 1 || upserts[0].ID != "rec1" || upserts[1].ID != "rec6" {
+		t.Errorf("Upsert() = %+v, items %+v, expected 1 created and rec1 updated", result, upserts)
+	}
+	if name := fb.records("tblMembers")[1]["name"]; name != "renamed" {
+		t.Errorf("updated name = %v, expected renamed", name)
+	}
+	if fmt.Sprint(batchSizes) != "[create:1 update:1 create:1]" {
+		t.Errorf("batch requests = %v", batchSizes)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var requests []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	for i := 0; i < 5; i++ {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": fmt.Sprintf("m%d", i)})
+	}
+	logRequest := func(r *fakeRequest) interface{} {
+		requests = append(requests, r.Method+" "+r.URL.Query().Get("page_size")+" "+r.URL.Query().Get("page_token"))
+		return fb.next(r)
+	}
+	fb.handle("/records", logRequest)
+	fb.handle("/records/search", logRequest)
+	db := fb.open(t, nil)
+
+	var names []string
+	cursor := ""
+	for {
+		var members []Member
+		result := Paginate(db, cursor, 2).Find(&members)
+		if result.Error != nil {
+			t.Fatalf("Find() error = %v", result.Error)
+		}
+		for _, member := range members {
+			names = append(names, member.Name)
+		}
+		if cursor = NextCursor(result); cursor == "" {
+			break
+		}
+	}
+	if fmt.Sprint(names) != "[m0 m1 m2 m3 m4]" {
+		t.Errorf("paged names = %v, expected [m0 m1 m2 m3 m4]", names)
+	}
+
+	var members []Member
+	result := Paginate(db.Where("name <> ?", "m4"), "", 2).Find(&members)
+	if result.Error != nil || NextCursor(result) != "2" {
+		t.Errorf("filtered page next cursor = %q, %v, expected \"2\"", NextCursor(result), result.Error)
+	}
+	expected := "[GET 2  GET 2 2 GET 2 4 POST 2 ]"
+	if fmt.Sprint(requests) != expected {
+		t.Errorf("record requests = %v, expected %s", requests, expected)
+	}
+}
+
+func TestLikePushdown(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var filters []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	for i, name := range []string{"abc1", "xabc", "abc", "a-c", "zzz"} {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": []interface{}{map[string]interface{}{"text": name, "type": "text"}}})
+	}
+	fb.logFilters(&filters)
+	db := fb.open(t, nil)
+
+	tests := []struct {
+		pattern  string
+		expected string
+		filter   string
+	}{
+		{"abc%", "[abc1 abc]", "contains [abc]"},
+		{"%abc", "[xabc abc]", "contains [abc]"},
+		{"%abc%", "[abc1 xabc abc]", "contains [abc]"},
+		{"abc", "[abc]", "is [abc]"},
+		{"a_c", "[abc a-c]", "contains [a]"},
+		{"___", "[abc a-c zzz]", "none"},
+	}
+	for _, tt := range tests {
+		filters = nil
+		var members []Member
+		if err := db.Where("name LIKE ?", tt.pattern).Find(&members).Error; err != nil {
+			t.Fatalf("LIKE %q error = %v", tt.pattern, err)
+		}
+		var got []string
+		for _, member := range members {
+			got = append(got, member.Name)
+		}
+		if fmt.Sprint(got) != tt.expected {
+			t.Errorf("LIKE %q = %v, expected %s", tt.pattern, got, tt.expected)
+		}
+		if len(filters) != 1 || filters[0] != tt.filter {
+			t.Errorf("LIKE %q pushed filters %v, expected [%s]", tt.pattern, filters, tt.filter)
+		}
+	}
+
+	var plan []struct {
+		Step   string
+		Detail string
+	}
+	if err := db.Raw("EXPLAIN SELECT * FROM members WHERE name LIKE ?", "abc%").Scan(&plan).Error; err != nil {
+		t.Fatalf("EXPLAIN error = %v", err)
+	}
+	if len(plan) != 3 || plan[0].Step != "request" || !strings.HasPrefix(plan[0].Detail, "POST ") ||
+		plan[2].Step != "like" || !strings.Contains(plan[2].Detail, "前缀匹配") {
+		t.Errorf("EXPLAIN plan = %+v", plan)
+	}
+}
+
+func TestCollation(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var filters []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	for i, name := range []string{"bob", "alice", "张三", "ALICE B", "Alice"} {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": name})
+	}
+	fb.logFilters(&filters)
+
+	open := func(collation Collation) *gorm.DB {
+		return fb.open(t, func(config *Config) { config.Collation = collation })
+	}
+	memberNames := func(members []Member) string {
+		var got []string
+		for _, member := range members {
+			got = append(got, member.Name)
+		}
+		return fmt.Sprint(got)
+	}
+
+	binary, folded := open(""), open(CollationCaseInsensitive)
+	tests := []struct {
+		db       *gorm.DB
+		query    string
+		value    string
+		expected string
+		filter   string
+	}{
+		{binary, "name = ?", "alice", "[alice]", "is [alice]"},
+		{folded, "name = ?", "alice", "[alice Alice]", "contains [alice]"},
+		{folded, "name = ?", "张三", "[张三]", "is [张三]"},
+		{binary, "name LIKE ?", "al%", "[alice]", "contains [al]"},
+		{binary, "name ILIKE ?", "al%", "[alice ALICE B Alice]", "contains [al]"},
+		{folded, "name LIKE ?", "%ALICE%", "[alice ALICE B Alice]", "contains [ALICE]"},
+	}
+	for _, tt := range tests {
+		filters = nil
+		var members []Member
+		if err := tt.db.Where(tt.query, tt.value).Find(&members).Error; err != nil {
+			t.Fatalf("%s %q error = %v", tt.query, tt.value, err)
+		}
+		if got := memberNames(members); got != tt.expected {
+			t.Errorf("%s %q = %s, expected %s", tt.query, tt.value, got, tt.expected)
+		}
+		if len(filters) != 1 || filters[0] != tt.filter {
+			t.Errorf("%s %q pushed filters %v, expected [%s]", tt.query, tt.value, filters, tt.filter)
+		}
+	}
+
+	var members []Member
+	if err := folded.Order("name").Find(&members).Error; err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if got := memberNames(members); got != "[Alice alice ALICE B bob 张三]" {
+		t.Errorf("case-insensitive order = %s", got)
+	}
+
+	config := testConfig("")
+	config.Collation = "utf8mb4_general_ci"
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject unknown collation")
+	}
+}
+
+func TestSortCollation(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	fb := newFakeBitable(t)
+	// 每页 2 条记录，客户端排序需要读取全部分页
+	fb.table("tblMembers", "members", fakeFields("name", 1)).pageSize = 2
+	for i, name := range []string{"张三", "item10", "赵六", "阿强", "item2", "李四"} {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": name})
+	}
+	db := fb.open(t, func(config *Config) { config.SortCollation = SortCollationPinyin })
+
+	tests := []struct {
+		db       *gorm.DB
+		order    interface{}
+		expected string
+	}{
+		{db, "name", "[item2 item10 阿强 李四 张三 赵六]"},
+		{db, clause.OrderByColumn{Column: clause.Column{Name: "name"}, Desc: true}, "[赵六 张三 李四 阿强 item10 item2]"},
+		{WithSortCollation(db, SortCollationUnicode), "name", "[item10 item2 张三 李四 赵六 阿强]"},
+		{WithSortCollation(db, SortCollationNumeric), "name", "[item2 item10 张三 李四 赵六 阿强]"},
+	}
+	for _, tt := range tests {
+		var members []Member
+		if err := tt.db.Order(tt.order).Find(&members).Error; err != nil {
+			t.Fatalf("Order(%v) error = %v", tt.order, err)
+		}
+		var got []string
+		for _, member := range members {
+			got = append(got, member.Name)
+		}
+		if fmt.Sprint(got) != tt.expected {
+			t.Errorf("Order(%v) = %v, expected %s", tt.order, got, tt.expected)
+		}
+	}
+
+	// 排序全部分页后再按 LIMIT 截断
+	var first []Member
+	if err := db.Order("name").Limit(2).Find(&first).Error; err != nil {
+		t.Fatalf("Order(name).Limit(2) error = %v", err)
+	}
+	if len(first) != 2 || first[0].Name != "item2" || first[1].Name != "item10" {
+		t.Errorf("Order(name).Limit(2) = %v", first)
+	}
+	var last Member
+	if err := db.Order("name desc").First(&last).Error; err != nil || last.Name != "赵六" {
+		t.Errorf("Order(name desc).First() = %v, %v", last, err)
+	}
+	var names []string
+	if err := db.Raw("SELECT name FROM members ORDER BY name LIMIT 3").Scan(&names).Error; err != nil {
+		t.Fatalf("raw ORDER BY error = %v", err)
+	}
+	if fmt.Sprint(names) != "[item2 item10 阿强]" {
+		t.Errorf("raw ORDER BY LIMIT 3 = %v", names)
+	}
+
+	// MaxResultRows 在排序后截断
+	capped := fb.open(t, func(config *Config) {
+		config.SortCollation = SortCollationPinyin
+		config.MaxResultRows = 3
+	})
+	var top []Member
+	if err := capped.Order("name desc").Find(&top).Error; err != nil {
+		t.Fatalf("capped Order(name desc) error = %v", err)
+	}
+	if len(top) != 3 || top[0].Name != "赵六" || top[2].Name != "李四" {
+		t.Errorf("capped Order(name desc) = %v", top)
+	}
+
+	// 没有 ORDER BY 时保持服务端返回的顺序
+	var members []Member
+	if err := db.Find(&members).Error; err != nil || members[0].Name != "张三" {
+		t.Errorf("Find() without order = %v, %v", members, err)
+	}
+}
+
+func TestMultiColumnOrder(t *testing.T) {
+	type Task struct {
+		ID    string `gorm:"primaryKey"`
+		Owner string
+		Due   float64
+	}
+
+	var sorts [][]string
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "tasks", fakeFields("owner", 1, "due", 2))
+	for i, row := range []struct {
+		owner string
+		due   float64
+	}{{"b", 1}, {"a", 1}, {"b", 3}, {"a", 2}, {"a", 2}} {
+		fb.add("tblTasks", fmt.Sprintf("rec%d", i), map[string]interface{}{"owner": row.owner, "due": row.due})
+	}
+	logSort := func(r *fakeRequest) interface{} {
+		var body ListRecordsRequest
+		r.decode(&body)
+		sorts = append(sorts, body.Sort)
+		return fb.next(r)
+	}
+	fb.handle("/records", logSort)
+	fb.handle("/records/search", logSort)
+	db := fb.open(t, func(config *Config) { config.SortCollation = SortCollationUnicode })
+
+	// 排序键相同的记录保持服务端返回的顺序
+	var tasks []Task
+	if err := db.Order("owner ASC, due DESC").Find(&tasks).Error; err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.ID)
+	}
+	if fmt.Sprint(got) != "[rec3 rec4 rec1 rec2 rec0]" {
+		t.Errorf("Order(owner ASC, due DESC) = %v", got)
+	}
+	if len(sorts) != 1 || fmt.Sprint(sorts[0]) != "[owner -due]" {
+		t.Errorf("pushed sort = %v, expected [[owner -due]]", sorts)
+	}
+
+	var scanned []Task
+	if err := db.Raw("SELECT * FROM tasks ORDER BY owner, due DESC LIMIT 4").Scan(&scanned).Error; err != nil {
+		t.Fatalf("raw ORDER BY error = %v", err)
+	}
+	got = nil
+	for _, task := range scanned {
+		got = append(got, fmt.Sprintf("%s%v", task.Owner, task.Due))
+	}
+	if fmt.Sprint(got) != "[a2 a2 a1 b3]" {
+		t.Errorf("raw ORDER BY = %v", got)
+	}
+	if fmt.Sprint(sorts[len(sorts)-1]) != "[owner -due]" {
+		t.Errorf("raw pushed sort = %v", sorts[len(sorts)-1])
+	}
+
+	if err := db.Raw("SELECT owner, COUNT(*) FROM tasks GROUP BY owner").Scan(&scanned).Error; err == nil {
+		t.Error("raw GROUP BY error = nil, expected unsupported")
+	}
+}
+
+func TestRawSelectPages(t *testing.T) {
+	fb := newFakeBitable(t)
+	// 每页 2 条记录，5 条记录共 3 页
+	fb.table("tblTasks", "tasks", fakeFields("title", 1, "points", 2)).pageSize = 2
+	for i := 1; i <= 5; i++ {
+		fb.add("tblTasks", fmt.Sprintf("rec%d", i), map[string]interface{}{"title": fmt.Sprintf("t%d", i), "points": i})
+	}
+	db := fb.open(t, nil)
+
+	var count int
+	if err := db.Raw("SELECT COUNT(*) FROM tasks").Scan(&count).Error; err != nil || count != 5 {
+		t.Errorf("raw COUNT(*) = %d, %v, expected 5", count, err)
+	}
+	var sum float64
+	if err := db.Raw("SELECT SUM(points) FROM tasks").Scan(&sum).Error; err != nil || sum != 15 {
+		t.Errorf("raw SUM(points) = %v, %v, expected 15", sum, err)
+	}
+
+	var titles []string
+	if err := db.Raw("SELECT title FROM tasks").Scan(&titles).Error; err != nil {
+		t.Fatalf("raw SELECT error = %v", err)
+	}
+	if fmt.Sprint(titles) != "[t1 t2 t3 t4 t5]" {
+		t.Errorf("raw SELECT without LIMIT = %v, expected all 5 rows", titles)
+	}
+
+	titles = nil
+	if err := db.Raw("SELECT title FROM tasks LIMIT 3").Scan(&titles).Error; err != nil {
+		t.Fatalf("raw SELECT LIMIT 3 error = %v", err)
+	}
+	if fmt.Sprint(titles) != "[t1 t2 t3]" {
+		t.Errorf("raw SELECT LIMIT 3 = %v", titles)
+	}
+}
+
+func TestMaxResultRows(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+		Team string
+	}
+
+	pages := 0
+	fb := newFakeBitable(t)
+	// 每页 2 条，共 3 页
+	fb.table("tblMembers", "members", fakeFields("name", 1, "team", 1)).pageSize = 2
+	for i := 0; i < 6; i++ {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": fmt.Sprintf("a%d", i), "team": "t1"})
+	}
+	fb.handle("/records/search", func(r *fakeRequest) interface{} {
+		pages++
+		return fb.next(r)
+	})
+
+	config := fb.config()
+	config.MaxResultRows = 3
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	// 前缀匹配需要读取全部分页，达到上限后停止翻页
+	var members []Member
+	if err := db.Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(members) != 3 || pages != 2 {
+		t.Errorf("Find() = %d records in %d pages, expected 3 records in 2 pages", len(members), pages)
+	}
+
+	// 按字段查找需要完整的结果，不受上限限制
+	pages = 0
+	found, err := NewRepo[Member](db).FindByField(context.Background(), "team", "t1")
+	if err != nil {
+		t.Fatalf("FindByField() error = %v", err)
+	}
+	if len(found) != 6 || pages != 3 {
+		t.Errorf("FindByField() = %d records in %d pages, expected 6 records in 3 pages", len(found), pages)
+	}
+
+	// 聚合与 Count 统计全部满足条件的记录，不受上限限制
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM members WHERE name LIKE 'a%'").Scan(&count).Error; err != nil || count != 6 {
+		t.Errorf("raw COUNT(*) = %d, %v, expected 6", count, err)
+	}
+	count = 0
+	if err := db.Raw("SELECT COUNT(*) FROM members ORDER BY name").Scan(&count).Error; err != nil || count != 6 {
+		t.Errorf("raw COUNT(*) ORDER BY = %d, %v, expected 6", count, err)
+	}
+	count = 0
+	if err := db.Model(&Member{}).Where("name LIKE ?", "a%").Count(&count).Error; err != nil || count != 6 {
+		t.Errorf("Count() = %d, %v, expected 6", count, err)
+	}
+
+	unlimited := *config
+	unlimited.MaxResultRows = 0
+	if db, err = gorm.Open(Open(&unlimited), &gorm.Config{}); err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	pages = 0
+	members = nil
+	if err := db.Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(members) != 6 || pages != 3 {
+		t.Errorf("Find() without cap = %d records in %d pages, expected 6 records in 3 pages", len(members), pages)
+	}
+
+	config.MaxResultRows = -1
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with negative MaxResultRows error = nil")
+	}
+}
+
+func TestRecordScanner(t *testing.T) {
+	type Member struct {
+		ID    string `gorm:"primaryKey"`
+		Name  string
+		Score int
+	}
+
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1, "score", 2))
+	for i := 0; i < 50; i++ {
+		fb.add("tblMembers", fmt.Sprintf("rec%d", i), map[string]interface{}{"name": fmt.Sprintf("m%d", i), "score": float64(i)})
+	}
+	db := fb.open(t, nil)
+
+	var members []Member
+	if err := db.Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(members) != 50 {
+		t.Fatalf("Find() = %d records, expected 50", len(members))
+	}
+	if m := members[7]; m.ID != "rec7" || m.Name != "m7" || m.Score != 7 {
+		t.Errorf("Find() record = %+v, expected {rec7 m7 7}", m)
+	}
+
+	sch, err := schema.Parse(&Member{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+	if planFor(sch) != planFor(sch) {
+		t.Error("planFor() built the scan plan again for the same schema")
+	}
+}
+
+func TestStreamRecordsPage(t *testing.T) {
+	bodies := []string{
+		`{"code":0,"msg":"success","data":{"has_more":true,"page_token":"p1","total":3,"items":[` +
+			`{"record_id":"rec1","fields":{"name":[{"text":"张三","type":"text"}],"score":12.5,"done":true},"created_time":1700000000000,"created_by":{"id":"ou_1","name":"a"},"extra":{"x":[1,2]}},` +
+			`{"record_id":"rec2","fields":{"name":"李四","tags":["a","b"],"empty":null}},` +
+			`{"record_id":"rec3","fields":null},null]}}`,
+		`{"code":1254005,"msg":"table not found","data":null}`,
+		`{"code":0,"data":{"items":[]}}`,
+		`{"code":0,"data":{"items":null,"has_more":false}}`,
+		`{"code":0,"data":{"items":[{"record_id":"rec1","fields":{"amount":12345678901.123456789,"price":0.1,"big":1e400,"nested":[{"v":-0.12345678901234567}]}}]}}`,
+	}
+	for _, body := range bodies {
+		var expected, actual ListRecordsAPIResponse
+		if err := unmarshalRecordsPage([]byte(body), &expected); err != nil {
+			t.Fatalf("unmarshalRecordsPage() error = %v", err)
+		}
+		if err := streamRecordsPage([]byte(body), &actual); err != nil {
+			t.Fatalf("streamRecordsPage(%s) error = %v", body, err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("streamRecordsPage(%s) = %+v, expected %+v", body, actual, expected)
+		}
+	}
+
+	var resp ListRecordsAPIResponse
+	if err := streamRecordsPage([]byte(`{"code":0,"data":{"items":[{"fields":{"a":1}`), &resp); err == nil {
+		t.Error("streamRecordsPage() with truncated body error = nil")
+	}
+}
+
+func TestConnectionPoolStats(t *testing.T) {
+	fb := newFakeBitable(t)
+	fb.table("tbl", "tasks", nil)
+
+	config := fb.config()
+	config.MaxIdleConns, config.MaxConnsPerHost, config.IdleConnTimeout = 4, 2, time.Minute
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	poolConfig := client.connectionPool.GetConfig()
+	if poolConfig.MaxIdleConnections != 4 || poolConfig.MaxConnsPerHost != 2 || poolConfig.IdleTimeout != time.Minute {
+		t.Errorf("connection pool config = %+v, expected MaxIdleConnections=4, MaxConnsPerHost=2, IdleTimeout=1m", poolConfig)
+	}
+
+	req := &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/records"}
+	for i := 0; i < 3; i++ {
+		if _, err := client.DoRequest(context.Background(), req); err != nil {
+			t.Fatalf("DoRequest() error = %v", err)
+		}
+	}
+
+	stats, ok := client.GetStabilityStats()["connection_pool"].(*common.PoolStats)
+	if !ok {
+		t.Fatalf("GetStabilityStats() connection_pool = %T, expected *common.PoolStats", client.GetStabilityStats()["connection_pool"])
+	}
+	if stats.OpenConnections != 1 || stats.IdleConnections != 1 || stats.InFlightRequests != 0 {
+		t.Errorf("pool stats = open %d, idle %d, in flight %d, expected 1, 1, 0", stats.OpenConnections, stats.IdleConnections, stats.InFlightRequests)
+	}
+	if stats.TotalRequests != 3 || stats.ReusedConnections != 2 {
+		t.Errorf("pool stats = %d requests, %d reused, expected 3 requests, 2 reused", stats.TotalRequests, stats.ReusedConnections)
+	}
+
+	config.MaxConnsPerHost = -1
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with negative MaxConnsPerHost error = nil")
+	}
+}
+
+func TestGzipResponses(t *testing.T) {
+	var acceptEncodings []string
+	payload := `{"code":0,"msg":"success","data":{"items":[` + strings.Repeat(`{"record_id":"rec","fields":{"name":"张三"}},`, 200) + `{}]}}`
+	fb := newFakeBitable(t)
+	fb.handle("/records", func(r *fakeRequest) interface{} {
+		acceptEncodings = append(acceptEncodings, r.Header.Get("Accept-Encoding"))
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			r.w.Write([]byte(payload))
+			return nil
+		}
+		r.w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(r.w)
+		gz.Write([]byte(payload))
+		gz.Close()
+		return nil
+	})
+
+	for _, disable := range []bool{false, true} {
+		acceptEncodings = nil
+		config := fb.config()
+		config.DisableCompression = disable
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		defer client.Close()
+
+		resp, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables/tbl/records"})
+		if err != nil {
+			t.Fatalf("DoRequest() error = %v", err)
+		}
+		if string(resp.Body) != payload {
+			t.Errorf("DoRequest() body = %.40q..., expected the decompressed payload", resp.Body)
+		}
+
+		stats := client.GetStabilityStats()["connection_pool"].(*common.PoolStats)
+		if disable {
+			if len(acceptEncodings) != 1 || acceptEncodings[0] != "" || stats.CompressedResponses != 0 {
+				t.Errorf("DisableCompression: Accept-Encoding = %q, compressed responses = %d, expected none", acceptEncodings, stats.CompressedResponses)
+			}
+			continue
+		}
+		if len(acceptEncodings) != 1 || acceptEncodings[0] != "gzip" {
+			t.Errorf("Accept-Encoding = %q, expected [gzip]", acceptEncodings)
+		}
+		if stats.CompressedResponses != 1 || stats.DecompressedBytes != int64(len(payload)) || stats.CompressedBytes >= stats.DecompressedBytes {
+			t.Errorf("compression stats = %d responses, %d -> %d bytes, expected 1 response decompressed to %d bytes", stats.CompressedResponses, stats.CompressedBytes, stats.DecompressedBytes, len(payload))
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var sizes []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	fb.add("tblMembers", "rec1", map[string]interface{}{"name": "a1"})
+	checkSize := func(r *fakeRequest) interface{} {
+		size := r.URL.Query().Get("page_size")
+		sizes = append(sizes, size)
+		// 超过 100 条时按参数校验失败拒绝
+		if n, _ := strconv.Atoi(size); n > 100 {
+			json.NewEncoder(r.w).Encode(map[string]interface{}{
+				"code": 99992402, "msg": "field validation failed",
+				"error": map[string]interface{}{"field_violations": []map[string]string{{"field": "page_size", "description": "page_size is too large"}}},
+			})
+			return nil
+		}
+		return fb.next(r)
+	}
+	fb.handle("/records", checkSize)
+	fb.handle("/records/search", checkSize)
+
+	config := fb.config()
+	config.DefaultPageSize = 50
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	var members []Member
+	if err := db.Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []string{"50"}) {
+		t.Errorf("Find() page sizes = %v, expected Config.DefaultPageSize [50]", sizes)
+	}
+
+	// WithPageSize 覆盖默认值，API 拒绝时减半重试
+	sizes, members = nil, nil
+	if err := WithPageSize(db, 400).Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("WithPageSize().Find() error = %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []string{"400", "200", "100"}) || len(members) != 1 {
+		t.Errorf("WithPageSize().Find() page sizes = %v, %d records, expected [400 200 100] and 1 record", sizes, len(members))
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	sizes = nil
+	page, used, err := client.ListRecordsPage(context.Background(), "tblMembers", nil, "", 500)
+	if err != nil {
+		t.Fatalf("ListRecordsPage() error = %v", err)
+	}
+	if used != 62 || len(page.Items) != 1 {
+		t.Errorf("ListRecordsPage() = %d records with page size %d, expected 1 record with page size 62", len(page.Items), used)
+	}
+
+	config.DefaultPageSize = 501
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with DefaultPageSize 501 error = nil")
+	}
+}
+
+func TestResolveTable(t *testing.T) {
+	tables := []*Table{
+		{TableID: "tblA", Name: "tasks"},
+		{TableID: "tblB", Name: "tasks"},
+		{TableID: "tblC", Name: "Members"},
+	}
+
+	for nameOrID, expected := range map[string]string{"tblB": "tblB", "Members": "tblC", "members": "tblC"} {
+		table, err := ResolveTable(tables, nameOrID)
+		if err != nil || table.TableID != expected {
+			t.Errorf("ResolveTable(%q) = %v, %v, expected %s", nameOrID, table, err, expected)
+		}
+	}
+
+	_, err := ResolveTable(tables, "tasks")
+	if !errors.Is(err, ErrAmbiguousTable) || !strings.Contains(err.Error(), "tblA, tblB") {
+		t.Errorf("ResolveTable(duplicate name) error = %v, expected ErrAmbiguousTable listing tblA, tblB", err)
+	}
+	if _, err := ResolveTable(tables, "orders"); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("ResolveTable(missing) error = %v, expected ErrTableNotFound", err)
+	}
+
+	fb := newFakeBitable(t)
+	for _, table := range tables {
+		fb.table(table.TableID, table.Name, fakeFields("name", 1))
+	}
+	fb.add("tblB", "rec1", map[string]interface{}{"name": "b"})
+	db := fb.open(t, nil)
+
+	var rows []map[string]interface{}
+	if err := db.Table("tasks").Where("name = ?", "b").Find(&rows).Error; !errors.Is(err, ErrAmbiguousTable) {
+		t.Errorf("Find() on duplicate table name error = %v, expected ErrAmbiguousTable", err)
+	}
+	rows = nil
+	if err := db.Table("tblB").Where("name = ?", "b").Find(&rows).Error; err != nil || len(rows) != 1 {
+		t.Errorf("Find() by table ID = %v, %v, expected 1 row", rows, err)
+	}
+	if !db.Migrator().HasTable("tasks") || !db.Migrator().HasTable("tblC") || db.Migrator().HasTable("orders") {
+		t.Error("HasTable() should accept duplicate names and table IDs and reject missing tables")
+	}
+}
+
+func TestSchemaRenames(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var mu sync.Mutex
+	var filterFields, createdFields []string
+	fb := newFakeBitable(t)
+	fb.table("tblM1", "members", parseFakeFields(`[{"field_id":"fld1","field_name":"name","type":1}]`))
+	fb.add("tblM1", "rec1", map[string]interface{}{"name": "a"})
+	fb.handle("/records/search", func(r *fakeRequest) interface{} {
+		var req ListRecordsRequest
+		r.decode(&req)
+		mu.Lock()
+		for _, condition := range req.Filter.Conditions {
+			filterFields = append(filterFields, condition.FieldName)
+		}
+		mu.Unlock()
+		return fb.next(r)
+	})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		mu.Lock()
+		for name := range req.Fields {
+			createdFields = append(createdFields, name)
+		}
+		mu.Unlock()
+		return fb.next(r)
+	})
+	// rename 修改表 ID、表名和字段名，已有记录随字段改名
+	rename := func(tableID, tableName, fieldName string) {
+		fb.mu.Lock()
+		defer fb.mu.Unlock()
+		table := fb.tables[0]
+		oldName := table.fields[0]["field_name"].(string)
+		table.id, table.name, table.fields[0]["field_name"] = tableID, tableName, fieldName
+		for _, record := range table.records {
+			fields := record["fields"].(map[string]interface{})
+			if value, ok := fields[oldName]; ok && oldName != fieldName {
+				fields[fieldName] = value
+				delete(fields, oldName)
+			}
+		}
+	}
+
+	db := fb.open(t, nil)
+	client := db.Dialector.(*Dialector).Client
+	find := func() []Member {
+		t.Helper()
+		var members []Member
+		if err := db.Where("name = ?", "a").Find(&members).Error; err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		return members
+	}
+	find()
+
+	// 表和字段在飞书界面中改名后，仍按原名称读写
+	rename("tblM1", "people", "full_name")
+	client.InvalidateSchemaCache()
+	if members := find(); len(members) != 1 || members[0].Name != "a" {
+		t.Errorf("Find() after rename = %+v, expected the record with Name a", members)
+	}
+	if err := db.Create(&Member{Name: "b"}).Error; err != nil {
+		t.Fatalf("Create() after rename error = %v", err)
+	}
+	if !reflect.DeepEqual(filterFields, []string{"name", "full_name"}) || !reflect.DeepEqual(createdFields, []string{"full_name"}) {
+		t.Errorf("requests after rename used fields %v and %v, expected the current name full_name", filterFields, createdFields)
+	}
+
+	// 表删除后重建了同名的表，原表 ID 失效时按表名重新解析
+	rename("tblM2", "members", "name")
+	if members := find(); len(members) != 1 {
+		t.Errorf("Find() after the table was recreated = %+v, expected 1 record", members)
+	}
+	if id, err := client.ResolveTableID(context.Background(), "members"); err != nil || id != "tblM2" {
+		t.Errorf("ResolveTableID() = %q, %v, expected tblM2", id, err)
+	}
+
+	// RefreshSchema 之后按新名称解析
+	rename("tblM3", "people", "name")
+	client.RefreshSchema()
+	if _, err := client.ResolveTableID(context.Background(), "members"); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("ResolveTableID() after RefreshSchema error = %v, expected ErrTableNotFound", err)
+	}
+}
+
+func TestFindByRecordIDs(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var paths []string
+	var batchIDs []string
+	var filterFields []string
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	for _, id := range []string{"recA", "recC", "recB"} {
+		fb.add("tblMembers", id, map[string]interface{}{"name": "a"})
+	}
+	fb.handle("/records/batch_get", func(r *fakeRequest) interface{} {
+		paths = append(paths, "batch_get")
+		var req batchGetRecordsRequest
+		r.decode(&req)
+		batchIDs = append(batchIDs, req.RecordIDs...)
+		return fb.next(r)
+	})
+	fb.handle("/records/search", func(r *fakeRequest) interface{} {
+		paths = append(paths, "search")
+		var req ListRecordsRequest
+		r.decode(&req)
+		if req.Filter != nil {
+			for _, condition := range req.Filter.Conditions {
+				filterFields = append(filterFields, condition.FieldName)
+			}
+		}
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	var members []Member
+	if err := db.Find(&members, []string{"recA", "recB", "recA"}).Error; err != nil {
+		t.Fatalf("Find() by record IDs error = %v", err)
+	}
+	if len(members) != 2 || !reflect.DeepEqual(paths, []string{"batch_get"}) || !reflect.DeepEqual(batchIDs, []string{"recA", "recB"}) {
+		t.Errorf("Find() by record IDs = %d records via %v with IDs %v, expected 2 records from one batch_get", len(members), paths, batchIDs)
+	}
+
+	// 有其他条件时按其余条件查询，只保留指定的记录
+	paths, members = nil, nil
+	if err := db.Where("name = ?", "a").Find(&members, []string{"recA", "recB"}).Error; err != nil {
+		t.Fatalf("Where().Find() by record IDs error = %v", err)
+	}
+	if len(members) != 2 || members[0].ID != "recA" || members[1].ID != "recB" {
+		t.Errorf("Where().Find() by record IDs = %+v, expected recA and recB", members)
+	}
+	if !reflect.DeepEqual(paths, []string{"search"}) || !reflect.DeepEqual(filterFields, []string{"name"}) {
+		t.Errorf("Where().Find() by record IDs sent %v filtering %v, expected one search filtering name", paths, filterFields)
+	}
+}
+
+func TestBatchDeleteUpdate(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	var paths []string
+	var deleteIDs []string
+	var updates []*BatchUpdateRecord
+	fb := newFakeBitable(t)
+	fb.table("tblMembers", "members", fakeFields("name", 1))
+	// recX 不存在，不会被删除
+	for _, id := range []string{"recA", "recB", "recC", "recD"} {
+		fb.add("tblMembers", id, map[string]interface{}{"name": "x"})
+	}
+	fb.handle("/records/batch_delete", func(r *fakeRequest) interface{} {
+		paths = append(paths, "batch_delete")
+		var req BatchDeleteRecordsRequest
+		r.decode(&req)
+		deleteIDs = append(deleteIDs, req.Records...)
+		return fb.next(r)
+	})
+	fb.handle("/records/batch_update", func(r *fakeRequest) interface{} {
+		paths = append(paths, "batch_update")
+		var req BatchUpdateRecordsRequest
+		r.decode(&req)
+		updates = append(updates, req.Records...)
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	result := db.Delete(&Member{}, []string{"recA", "recB", "recX", "recA"})
+	if result.Error != nil {
+		t.Fatalf("Delete() by record IDs error = %v", result.Error)
+	}
+	if result.RowsAffected != 2 || !reflect.DeepEqual(paths, []string{"batch_delete"}) || !reflect.DeepEqual(deleteIDs, []string{"recA", "recB", "recX"}) {
+		t.Errorf("Delete() by record IDs = %d rows via %v with IDs %v, expected 2 rows from one batch_delete", result.RowsAffected, paths, deleteIDs)
+	}
+
+	// 模型切片中的主键同样批量删除
+	paths, deleteIDs = nil, nil
+	result = db.Delete(&[]Member{{ID: "recC"}, {ID: "recD"}})
+	if result.Error != nil || result.RowsAffected != 2 || !reflect.DeepEqual(deleteIDs, []string{"recC", "recD"}) {
+		t.Errorf("Delete() slice = %d rows with IDs %v, error %v, expected 2 rows", result.RowsAffected, deleteIDs, result.Error)
+	}
+
+	// 对模型切片 Update 时每条记录写入相同的字段
+	paths = nil
+	fb.add("tblMembers", "recA", map[string]interface{}{"name": "x"})
+	fb.add("tblMembers", "recB", map[string]interface{}{"name": "x"})
+	members := []Member{{ID: "recA", Name: "a"}, {ID: "recB", Name: "b"}}
+	result = db.Model(&members).Update("name", "z")
+	if result.Error != nil {
+		t.Fatalf("Update() slice error = %v", result.Error)
+	}
+	if result.RowsAffected != 2 || !reflect.DeepEqual(paths, []string{"batch_update"}) || len(updates) != 2 {
+		t.Fatalf("Update() slice = %d rows via %v, expected 2 rows from one batch_update", result.RowsAffected, paths)
+	}
+	for _, record := range updates {
+		if record.Fields["name"] != "z" {
+			t.Errorf("Update() slice wrote %v to %s, expected name z", record.Fields, record.RecordID)
+		}
+	}
+
+	// 没有指定字段时写入各元素自己的字段值
+	updates = nil
+	result = db.Updates(&members)
+	if result.Error != nil || result.RowsAffected != 2 || len(updates) != 2 {
+		t.Fatalf("Updates() slice = %d rows, error %v, expected 2 rows", result.RowsAffected, result.Error)
+	}
+	if updates[0].RecordID != "recA" || updates[0].Fields["name"] != "a" || updates[1].RecordID != "recB" || updates[1].Fields["name"] != "b" {
+		t.Errorf("Updates() slice wrote %+v %+v, expected each member's own name", updates[0], updates[1])
+	}
+}
+
+func TestReturnRecordAfterWrite(t *testing.T) {
+	type Order struct {
+		ID    string `gorm:"primaryKey"`
+		Name  string
+		Total float64
+	}
+
+	var batchIDs []string
+	fb := newFakeBitable(t)
+	fb.table("tblOrders", "orders", fakeFields("name", 1, "total", 20))
+	fb.handle("/records/batch_get", func(r *fakeRequest) interface{} {
+		var req batchGetRecordsRequest
+		r.decode(&req)
+		batchIDs = append(batchIDs, req.RecordIDs...)
+		items := []map[string]interface{}{}
+		for _, id := range req.RecordIDs {
+			// total 是公式字段，由服务端计算
+			items = append(items, map[string]interface{}{"record_id": id, "fields": map[string]interface{}{"name": "server " + id, "total": 42}})
+		}
+		return map[string]interface{}{"records": items}
+	})
+	fb.handle("/records/batch_update", func(r *fakeRequest) interface{} {
+		var req BatchUpdateRecordsRequest
+		r.decode(&req)
+		return map[string]interface{}{"records": req.Records}
+	})
+	newRecord := func(*fakeRequest) interface{} {
+		return map[string]interface{}{"record": map[string]interface{}{"record_id": "recNew"}}
+	}
+	fb.handle("POST /records", newRecord)
+	fb.handle("PUT /records/recNew", newRecord)
+
+	open := func(returnRecord bool) *gorm.DB {
+		return fb.open(t, func(config *Config) { config.ReturnRecordAfterWrite = returnRecord })
+	}
+
+	// 未开启时不重新读取
+	order := &Order{Name: "a"}
+	if err := open(false).Create(order).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if order.Total != 0 || len(batchIDs) != 0 {
+		t.Errorf("Create() without ReturnRecordAfterWrite = %+v after reading %v, expected no re-read", order, batchIDs)
+	}
+
+	db := open(true)
+	order = &Order{Name: "a"}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if order.ID != "recNew" || order.Total != 42 || order.Name != "server recNew" {
+		t.Errorf("Create() = %+v, expected the record read back from the server", order)
+	}
+
+	order.Total = 0
+	if err := db.Model(order).Update("name", "b").Error; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if order.Total != 42 {
+		t.Errorf("Update() = %+v, expected the formula field read back from the server", order)
+	}
+
+	orders := []Order{{ID: "recA"}, {ID: "recB"}}
+	if err := db.Model(&orders).Update("name", "c").Error; err != nil {
+		t.Fatalf("Update() slice error = %v", err)
+	}
+	if orders[0].Name != "server recA" || orders[1].Name != "server recB" || orders[1].Total != 42 {
+		t.Errorf("Update() slice = %+v, expected each record read back from the server", orders)
+	}
+}
+
+// hookedTask 实现全部标准钩子的模型，调用记录在 calls 中
+type hookedTask struct {
+	ID    string `gorm:"primaryKey"`
+	Name  string
+	calls *[]string `gorm:"-"`
+	fail  string    `gorm:"-"` // 返回错误的钩子
+}
+
+func (t *hookedTask) record(hook string) error {
+	if t.calls != nil {
+		*t.calls = append(*t.calls, hook)
+	}
+	if hook == t.fail {
+		return fmt.Errorf("%s rejected", hook)
+	}
+	return nil
+}
+
+func (t *hookedTask) BeforeSave(*gorm.DB) error   { return t.record("BeforeSave") }
+func (t *hookedTask) AfterSave(*gorm.DB) error    { return t.record("AfterSave") }
+func (t *hookedTask) BeforeCreate(*gorm.DB) error { return t.record("BeforeCreate") }
+func (t *hookedTask) AfterCreate(*gorm.DB) error  { return t.record("AfterCreate") }
+func (t *hookedTask) BeforeUpdate(*gorm.DB) error { return t.record("BeforeUpdate") }
+func (t *hookedTask) AfterUpdate(*gorm.DB) error  { return t.record("AfterUpdate") }
+func (t *hookedTask) BeforeDelete(*gorm.DB) error { return t.record("BeforeDelete") }
+func (t *hookedTask) AfterDelete(*gorm.DB) error  { return t.record("AfterDelete") }
+func (t *hookedTask) AfterFind(tx *gorm.DB) error {
+	if calls, ok := tx.Get("test:calls"); ok {
+		t.calls = calls.(*[]string)
+	}
+	return t.record("AfterFind")
+}
+
+func TestModelHooks(t *testing.T) {
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "hooked_tasks", fakeFields("name", 1))
+	db := fb.open(t, nil)
+
+	var calls []string
+	task := &hookedTask{Name: "a", calls: &calls}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if expected := []string{"BeforeSave", "BeforeCreate", "AfterCreate", "AfterSave"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Create() called hooks %v, expected %v", calls, expected)
+	}
+
+	calls = nil
+	if err := db.Model(task).Update("name", "b").Error; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if expected := []string{"BeforeSave", "BeforeUpdate", "AfterUpdate", "AfterSave"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Update() called hooks %v, expected %v", calls, expected)
+	}
+
+	calls = nil
+	var found []hookedTask
+	if err := db.Set("test:calls", &calls).Find(&found).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if expected := []string{"AfterFind"}; len(found) != 1 || !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Find() called hooks %v for %d records, expected %v", calls, len(found), expected)
+	}
+
+	calls = nil
+	if err := db.Delete(task).Error; err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if expected := []string{"BeforeDelete", "AfterDelete"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Delete() called hooks %v, expected %v", calls, expected)
+	}
+
+	// 钩子返回错误时不发送写入请求
+	calls = nil
+	sent := len(fb.requests("/records"))
+	rejected := &hookedTask{Name: "c", calls: &calls, fail: "BeforeCreate"}
+	if err := db.Create(rejected).Error; err == nil || !strings.Contains(err.Error(), "BeforeCreate rejected") {
+		t.Errorf("Create() with failing hook error = %v, expected the hook's error", err)
+	}
+	if requests := fb.requests("/records")[sent:]; len(requests) != 0 || rejected.ID != "" {
+		t.Errorf("Create() with failing hook sent %v, expected no request", requests)
+	}
+}
+
+func TestAutoTimeFields(t *testing.T) {
+	type Note struct {
+		ID          string `gorm:"primaryKey"`
+		Title       string
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		CreatedUnix int64 `gorm:"autoCreateTime"`
+		UpdatedMs   int64 `gorm:"autoUpdateTime:milli"`
+	}
+
+	const created, modified = int64(1700000000000), int64(1700000123456)
+	var automatic []bool
+	var written []map[string]interface{}
+	record := map[string]interface{}{
+		"record_id": "recN1", "fields": map[string]interface{}{"title": "a"},
+		"created_time": created, "last_modified_time": modified,
+	}
+	fb := newFakeBitable(t)
+	fb.table("tblNotes", "notes", fakeFields("title", 1))
+	fb.handle("GET /records", func(r *fakeRequest) interface{} {
+		automatic = append(automatic, r.URL.Query().Get("automatic_fields") == "true")
+		return map[string]interface{}{"items": []interface{}{record}, "total": 1}
+	})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return map[string]interface{}{"record": record}
+	})
+	db := fb.open(t, nil)
+
+	// 自动时间字段不写入
+	if err := db.Create(&Note{Title: "a", CreatedUnix: 1}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(written) != 1 || !reflect.DeepEqual(written[0], map[string]interface{}{"title": "a"}) {
+		t.Errorf("Create() wrote %v, expected only title", written)
+	}
+
+	var notes []Note
+	if err := db.Find(&notes).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !reflect.DeepEqual(automatic, []bool{true}) {
+		t.Errorf("Find() requested automatic fields %v, expected true", automatic)
+	}
+	if len(notes) != 1 {
+		t.Fatalf("Find() returned %d notes, expected 1", len(notes))
+	}
+	note := notes[0]
+	if !note.CreatedAt.Equal(time.UnixMilli(created)) || !note.UpdatedAt.Equal(time.UnixMilli(modified)) {
+		t.Errorf("Find() CreatedAt = %v, UpdatedAt = %v, expected the record's created and modified time", note.CreatedAt, note.UpdatedAt)
+	}
+	if note.CreatedUnix != created/1000 || note.UpdatedMs != modified {
+		t.Errorf("Find() CreatedUnix = %d, UpdatedMs = %d, expected %d and %d", note.CreatedUnix, note.UpdatedMs, created/1000, modified)
+	}
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	type Address struct {
+		City   string
+		Street string
+	}
+	type Audit struct {
+		Owner string
+	}
+	type Customer struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+		Home Address `gorm:"embedded;embeddedPrefix:home_"`
+		Work Address `gorm:"embedded;embeddedPrefix:work_"`
+		Audit
+	}
+
+	var created []string
+	var written []map[string]interface{}
+	fb := newFakeBitable(t)
+	fb.handle("POST /tables", func(r *fakeRequest) interface{} {
+		var req CreateTableRequest
+		r.decode(&req)
+		for _, field := range req.Table.Fields {
+			created = append(created, field.FieldName)
+		}
+		return fb.next(r)
+	})
+	recordWrite := func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return fb.next(r)
+	}
+	fb.handle("POST /records", recordWrite)
+	fb.handle("PUT ", recordWrite)
+	db := fb.open(t, nil)
+
+	if err := db.Migrator().CreateTable(&Customer{}); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+	if expected := []string{"id", "name", "home_city", "home_street", "work_city", "work_street", "owner"}; !reflect.DeepEqual(created, expected) {
+		t.Errorf("CreateTable() created fields %v, expected %v", created, expected)
+	}
+	fb.add(fb.tables[0].id, "recC1", map[string]interface{}{"name": "a", "home_city": "杭州", "home_street": "文一路", "work_city": "上海", "work_street": "世纪大道", "owner": "ops"})
+
+	customer := &Customer{Name: "a", Home: Address{City: "杭州"}, Work: Address{City: "上海", Street: "世纪大道"}, Audit: Audit{Owner: "ops"}}
+	if err := db.Create(customer).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expected := map[string]interface{}{"name": "a", "home_city": "杭州", "home_street": "", "work_city": "上海", "work_street": "世纪大道", "owner": "ops"}
+	if len(written) != 1 || !reflect.DeepEqual(written[0], expected) {
+		t.Errorf("Create() wrote %v, expected %v", written, expected)
+	}
+
+	var found Customer
+	if err := db.First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Home != (Address{City: "杭州", Street: "文一路"}) || found.Work != (Address{City: "上海", Street: "世纪大道"}) || found.Owner != "ops" {
+		t.Errorf("First() = %+v, expected embedded fields read by prefix", found)
+	}
+
+	written = nil
+	found.Work.City = "北京"
+	if err := db.Save(&found).Error; err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(written) != 1 || written[0]["work_city"] != "北京" || written[0]["home_city"] != "杭州" {
+		t.Errorf("Save() wrote %v, expected work_city 北京 and home_city 杭州", written)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	type Meta struct {
+		Source string `json:"source"`
+		Retry  int    `json:"retry"`
+	}
+	type Event struct {
+		ID      string `gorm:"primaryKey"`
+		Name    string
+		Payload map[string]interface{} `gorm:"type:json"`
+		Meta    Meta                   `gorm:"type:json"`
+		Labels  []string               `gorm:"type:text" basesql:"json"`
+	}
+
+	var written []map[string]interface{}
+	fb := newFakeBitable(t)
+	fb.table("tblEvents", "events", fakeFields("name", 1, "payload", 1, "meta", 1, "labels", 1))
+	fb.add("tblEvents", "recE1", map[string]interface{}{
+		"name":    "login",
+		"payload": []interface{}{map[string]interface{}{"type": "text", "text": `{"user":"u1","ok":true}`}},
+		"meta":    `{"source":"web","retry":2}`,
+		"labels":  `["a","b"]`,
+	})
+	recordWrite := func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return fb.next(r)
+	}
+	fb.handle("POST /records", recordWrite)
+	fb.handle("PUT ", recordWrite)
+	db := fb.open(t, nil)
+
+	event := &Event{Name: "login", Payload: map[string]interface{}{"user": "u1"}, Meta: Meta{Source: "web"}, Labels: []string{"a"}}
+	if err := db.Create(event).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expected := map[string]interface{}{"name": "login", "payload": `{"user":"u1"}`, "meta": `{"source":"web","retry":0}`, "labels": `["a"]`}
+	if len(written) != 1 || !reflect.DeepEqual(written[0], expected) {
+		t.Errorf("Create() wrote %v, expected %v", written, expected)
+	}
+
+	var found Event
+	if err := db.First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Payload["user"] != "u1" || found.Payload["ok"] != true || found.Meta != (Meta{Source: "web", Retry: 2}) || !reflect.DeepEqual(found.Labels, []string{"a", "b"}) {
+		t.Errorf("First() = %+v, expected JSON fields decoded", found)
+	}
+
+	written = nil
+	if err := db.Model(&found).Update("meta", Meta{Source: "api"}).Error; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(written) != 1 || written[0]["meta"] != `{"source":"api","retry":0}` {
+		t.Errorf("Update() wrote %v, expected meta as JSON text", written)
+	}
+}
+
+// testCents 以分为单位的金额，通过 driver.Valuer 和 sql.Scanner 读写为元
+type testCents int64
+
+func (c testCents) Value() (driver.Value, error) { return float64(c) / 100, nil }
+
+func (c *testCents) Scan(src interface{}) error {
+	yuan, ok := src.(float64)
+	if !ok {
+		return fmt.Errorf("unexpected %T", src)
+	}
+	*c = testCents(yuan*100 + 0.5)
+	return nil
+}
+
+// testCode 包装字符串的结构体，类似 uuid.UUID
+type testCode struct{ value string }
+
+func (c testCode) Value() (driver.Value, error) { return []byte("C-" + c.value), nil }
+
+func (c *testCode) Scan(src interface{}) error {
+	text, ok := src.(string)
+	if !ok || !strings.HasPrefix(text, "C-") {
+		return fmt.Errorf("invalid code %v", src)
+	}
+	c.value = strings.TrimPrefix(text, "C-")
+	return nil
+}
+
+// testLevel 通过 encoding.TextMarshaler 读写为文本的枚举
+type testLevel int
+
+func (l testLevel) MarshalText() ([]byte, error) { return []byte([]string{"low", "high"}[l]), nil }
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return fmt.Errorf("invalid level %q", text)
+	}
+	return nil
+}
+
+func TestCustomValueTypes(t *testing.T) {
+	type Product struct {
+		ID    string `gorm:"primaryKey"`
+		Price testCents
+		Code  testCode
+		Level testLevel
+		Spare *testCode
+	}
+
+	var written []map[string]interface{}
+	fb := newFakeBitable(t)
+	fb.table("tblProducts", "products", fakeFields("price", 2, "code", 1, "level", 3, "spare", 1))
+	fb.add("tblProducts", "recP1", map[string]interface{}{"price": 12.34, "code": "C-x1", "level": "high", "spare": "C-x2"})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	if err := db.Create(&Product{Price: 1999, Code: testCode{"a7"}, Level: 1}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// spare 为 nil 指针，不写入
+	expected := map[string]interface{}{"price": 19.99, "code": "C-a7", "level": map[string]interface{}{"text": "high"}}
+	if len(written) != 1 || !reflect.DeepEqual(written[0], expected) {
+		t.Errorf("Create() wrote %v, expected %v", written, expected)
+	}
+
+	var found Product
+	if err := db.First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Price != 1234 || found.Code != (testCode{"x1"}) || found.Level != 1 || found.Spare == nil || *found.Spare != (testCode{"x2"}) {
+		t.Errorf("First() = %+v, expected values parsed by Scan and UnmarshalText", found)
+	}
+}
+
+// testDecimal 以十进制文本保存的金额，类似 decimal.Decimal
+type testDecimal string
+
+func (d testDecimal) Value() (driver.Value, error) { return string(d), nil }
+
+func (d *testDecimal) Scan(src interface{}) error {
+	text, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unexpected %T", src)
+	}
+	*d = testDecimal(text)
+	return nil
+}
+
+func TestDecimalNumbers(t *testing.T) {
+	type Invoice struct {
+		ID     string `gorm:"primaryKey"`
+		Amount testDecimal
+		Rate   *big.Rat `gorm:"type:decimal"`
+		Total  string
+		Tax    float64
+	}
+
+	var written []string
+	fb := newFakeBitable(t)
+	fb.table("tblInvoices", "invoices", fakeFields("amount", 20, "rate", 2, "total", 2, "tax", 2))
+	fb.handle("GET /records", func(*fakeRequest) interface{} {
+		return `{"items":[{"record_id":"recI1","fields":{"amount":12345678901.123456789,"rate":0.125,"total":98765432109876543.21,"tax":0.1}}],"total":1}`
+	})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		written = append(written, string(r.body))
+		return `{"record":{"record_id":"recI1"}}`
+	})
+
+	open := func(decimalPlaces int) *gorm.DB {
+		return fb.open(t, func(config *Config) { config.DecimalPlaces = decimalPlaces })
+	}
+
+	invoice := &Invoice{Amount: "12345678901.123456789", Rate: big.NewRat(1, 8), Total: "0.30", Tax: 0.1}
+	if err := open(0).Create(invoice).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expected := `{"fields":{"amount":12345678901.123456789,"rate":0.125,"tax":0.1,"total":0.30}}`
+	if len(written) != 1 || strings.TrimSpace(written[0]) != expected {
+		t.Errorf("Create() wrote %v, expected %s", written, expected)
+	}
+
+	written = nil
+	invoice = &Invoice{Amount: "12345678901.125", Rate: big.NewRat(1, 3)}
+	if err := open(2).Create(invoice).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expected = `{"fields":{"amount":12345678901.13,"rate":0.33,"tax":0,"total":0}}`
+	if len(written) != 1 || strings.TrimSpace(written[0]) != expected {
+		t.Errorf("Create() with DecimalPlaces wrote %v, expected %s", written, expected)
+	}
+
+	var found Invoice
+	if err := open(0).First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Amount != "12345678901.123456789" || found.Total != "98765432109876543.21" || found.Tax != 0.1 {
+		t.Errorf("First() = %+v, expected high precision numbers kept as text", found)
+	}
+	if found.Rate == nil || found.Rate.Cmp(big.NewRat(1, 8)) != 0 {
+		t.Errorf("First() rate = %v, expected 1/8", found.Rate)
+	}
+}
+
+func TestURLFields(t *testing.T) {
+	type Site struct {
+		ID   string `gorm:"primaryKey"`
+		Home Link
+		Docs *Link
+		Repo string
+	}
+
+	var written []map[string]interface{}
+	fb := newFakeBitable(t)
+	fb.table("tblSites", "sites", fakeFields("home", 15, "docs", 15, "repo", 15))
+	fb.add("tblSites", "recS1", map[string]interface{}{
+		"home": map[string]interface{}{"text": "官网", "link": "https://example.com"},
+		"docs": map[string]interface{}{"text": "文档", "link": "https://example.com/docs"},
+		"repo": map[string]interface{}{"text": "代码", "link": "https://example.com/repo"},
+	})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return fb.next(r)
+	})
+	db := fb.open(t, nil)
+
+	var found Site
+	if err := db.First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Home != (Link{Text: "官网", Link: "https://example.com"}) || found.Docs == nil || found.Docs.Link != "https://example.com/docs" || found.Repo != "代码" {
+		t.Errorf("First() = %+v, expected links with both text and address", found)
+	}
+
+	if err := db.Create(&Site{Home: Link{Text: "官网", Link: "https://example.com"}, Repo: "https://example.com/repo"}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expected := map[string]interface{}{
+		"home": map[string]interface{}{"text": "官网", "link": "https://example.com"},
+		"repo": map[string]interface{}{"text": "https://example.com/repo", "link": "https://example.com/repo"},
+	}
+	if len(written) != 1 || !reflect.DeepEqual(written[0], expected) {
+		t.Errorf("Create() wrote %v, expected %v", written, expected)
+	}
+
+	sch, err := schema.Parse(&Site{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+	if columns := modelColumns(sch); columns["home"] != FieldTypeURL || columns["docs"] != FieldTypeURL {
+		t.Errorf("modelColumns() = %v, expected Link fields as URL fields", columns)
+	}
+}
+
+func TestAutoNumberFields(t *testing.T) {
+	type Ticket struct {
+		ID     string `gorm:"primaryKey"`
+		Title  string
+		Seq    int64
+		Number string
+	}
+
+	var written []map[string]interface{}
+	fb := newFakeBitable(t)
+	fb.table("tblTickets", "tickets", fakeFields("title", 1, "seq", 1005, "number", 1005))
+	fb.add("tblTickets", "recT1", map[string]interface{}{"title": "登录失败", "seq": "0012", "number": "NO-20240101-007"})
+	recordWrite := func(r *fakeRequest) interface{} {
+		var req CreateRecordRequest
+		r.decode(&req)
+		written = append(written, req.Fields)
+		return fb.next(r)
+	}
+	fb.handle("POST /records", recordWrite)
+	fb.handle("PUT ", recordWrite)
+	db := fb.open(t, nil)
+
+	var found Ticket
+	if err := db.First(&found).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if found.Seq != 12 || found.Number != "NO-20240101-007" {
+		t.Errorf("First() = %+v, expected auto numbers 12 and NO-20240101-007", found)
+	}
+
+	if err := db.Create(&Ticket{Title: "超时", Seq: 99, Number: "NO-1"}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	found.Title = "登录失败（已复现）"
+	if err := db.Save(&found).Error; err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	expected := []map[string]interface{}{{"title": "超时"}, {"title": "登录失败（已复现）"}}
+	if !reflect.DeepEqual(written, expected) {
+		t.Errorf("writes = %v, expected auto number fields left out", written)
+	}
+}
+
+func TestParseBaseURL(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected BaseURL
+	}{
+		{"https://example.feishu.cn/base/bascnAbc123?table=tblXyz&view=vewQwe", BaseURL{AppToken: "bascnAbc123", TableID: "tblXyz", ViewID: "vewQwe"}},
+		{" https://example.larksuite.com/base/bascnAbc123 ", BaseURL{AppToken: "bascnAbc123"}},
+		{"https://example.feishu.cn/wiki/wikcnDef456?table=tblXyz", BaseURL{WikiToken: "wikcnDef456", TableID: "tblXyz"}},
+	}
+	for _, tt := range tests {
+		ref, err := ParseBaseURL(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseBaseURL(%q) error = %v", tt.raw, err)
+		}
+		if *ref != tt.expected {
+			t.Errorf("ParseBaseURL(%q) = %+v, expected %+v", tt.raw, *ref, tt.expected)
+		}
+	}
+	for _, raw := range []string{"bascnAbc123", "https://example.feishu.cn/docx/doxcn1", "ftp://example.feishu.cn/base/bascn1"} {
+		if _, err := ParseBaseURL(raw); err == nil {
+			t.Errorf("ParseBaseURL(%q) error = nil", raw)
+		}
+	}
+
+	dialector := Open(&Config{AppToken: "https://example.feishu.cn/base/bascnAbc123?table=tblXyz"}).(*Dialector)
+	if dialector.Config.AppToken != "bascnAbc123" {
+		t.Errorf("Open() AppToken = %q, expected the token from the link", dialector.Config.AppToken)
+	}
+
+	fb := newFakeBitable(t)
+	fb.table("tblXyz", "tasks", nil)
+	fb.handle("/wiki/v2/spaces/get_node", func(r *fakeRequest) interface{} {
+		if r.URL.Query().Get("token") != "wikcnDef456" {
+			return fakeError{code: 131005, msg: "not found"}
+		}
+		return `{"node":{"obj_type":"bitable","obj_token":"bascnResolved"}}`
+	})
+	fb.handle("/tables", func(r *fakeRequest) interface{} {
+		if !strings.Contains(r.URL.Path, "/apps/bascnResolved/") {
+			return fakeError{code: 91402, msg: "NOTEXIST"}
+		}
+		return fb.next(r)
+	})
+
+	config := fb.config()
+	config.AppToken = "https://example.feishu.cn/wiki/wikcnDef456?table=tblXyz"
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() with wiki link error = %v", err)
+	}
+	if !db.Migrator().HasTable("tasks") {
+		t.Error("HasTable() = false, expected tables listed with the app_token behind the wiki node")
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	vars := ConfigEnvVars()
+	names := make(map[string]ConfigEnvVar, len(vars))
+	for _, envVar := range vars {
+		if envVar.Description == "" {
+			t.Errorf("%s (%s) has no description", envVar.Name, envVar.Field)
+		}
+		names[envVar.Name] = envVar
+	}
+	if _, ok := names["BASESQL_REGISTRY"]; ok {
+		t.Error("Registry should not be configurable via env")
+	}
+	if v := names["BASESQL_TIMEOUT"]; v.Type != "duration" || v.Default != "30s" {
+		t.Errorf("BASESQL_TIMEOUT = %+v", v)
+	}
+	if !names["BASESQL_APP_SECRET"].Sensitive {
+		t.Error("BASESQL_APP_SECRET should be sensitive")
+	}
+
+	t.Setenv("BASESQL_TIMEOUT", "45s")
+	t.Setenv("BASESQL_RETRY_INTERVAL", "2")
+	t.Setenv("BASESQL_RATE_LIMIT_QPS", "20")
+	t.Setenv("BASESQL_READ_ONLY", "true")
+	t.Setenv("BASESQL_APP_TOKEN", "env_app_token")
+	t.Setenv("BASESQL_RATE_LIMITS", `{"write":{"qps":5}}`)
+
+	config := DefaultConfig()
+	config.AppToken = "code_app_token"
+	if err := config.LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv error: %v", err)
+	}
+	if config.Timeout != 45*time.Second || config.RetryInterval != 2*time.Second || config.RateLimitQPS != 20 {
+		t.Errorf("durations and ints not loaded: %v %v %d", config.Timeout, config.RetryInterval, config.RateLimitQPS)
+	}
+	if !config.ReadOnly || config.AppToken != "env_app_token" {
+		t.Errorf("ReadOnly = %v, AppToken = %q", config.ReadOnly, config.AppToken)
+	}
+	if config.RateLimits == nil || config.RateLimits.Write.QPS != 5 {
+		t.Errorf("RateLimits = %+v", config.RateLimits)
+	}
+	if config.MaxRetries != 3 {
+		t.Errorf("unset env changed MaxRetries to %d", config.MaxRetries)
+	}
+
+	t.Setenv("BASESQL_MAX_RETRIES", "many")
+	if err := DefaultConfig().LoadEnv(); err == nil || !strings.Contains(err.Error(), "BASESQL_MAX_RETRIES") {
+		t.Errorf("invalid env error = %v", err)
+	}
+	t.Setenv("BASESQL_MAX_RETRIES", "")
+
+	// gorm.Open 初始化时应用环境变量
+	db, err := gorm.Open(Open(testConfig("http://127.0.0.1:0")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	dialector := db.Dialector.(*Dialector)
+	if !dialector.Config.ReadOnly || dialector.Config.AppToken != "env_app_token" {
+		t.Errorf("env not applied on open: ReadOnly = %v, AppToken = %q", dialector.Config.ReadOnly, dialector.Config.AppToken)
+	}
+}
+
+func TestSlowQueryHook(t *testing.T) {
+	type Member struct {
+		ID   string `gorm:"primaryKey"`
+		Name string
+	}
+
+	fb := newFakeBitable(t)
+	// 共 2 页，每页 1 条
+	fb.table("tblMembers", "members", fakeFields("name", 1)).pageSize = 1
+	fb.add("tblMembers", "rec0", map[string]interface{}{"name": "a0"})
+	fb.add("tblMembers", "rec1", map[string]interface{}{"name": "a1"})
+
+	var events []SlowQueryEvent
+	config := fb.config()
+	config.SlowQueryThreshold = time.Nanosecond
+	config.SlowQueryHook = func(event SlowQueryEvent) { events = append(events, event) }
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	var members []Member
+	ctx := WithTraceID(context.Background(), "trace-slow")
+	if err := db.WithContext(ctx).Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("SlowQueryHook called %d times, expected 1", len(events))
+	}
+	event := events[0]
+	if event.Operation != "query" || event.Table != "members" || event.TraceID != "trace-slow" {
+		t.Errorf("event = %+v, expected query on members with trace ID", event)
+	}
+	if event.Pages != 2 || event.Rows != 2 || !strings.Contains(event.Filter, "a") {
+		t.Errorf("event pages = %d, rows = %d, filter = %q, expected 2 pages, 2 rows and a filter", event.Pages, event.Rows, event.Filter)
+	}
+	searches := 0
+	var apiTime time.Duration
+	for _, call := range event.APICalls {
+		if strings.HasSuffix(call.Path, "/records/search") {
+			searches++
+		}
+		apiTime += call.Duration
+	}
+	if searches != 2 || apiTime != event.APITime || event.APITime > event.Duration {
+		t.Errorf("event API calls = %+v, APITime = %v, Duration = %v", event.APICalls, event.APITime, event.Duration)
+	}
+
+	// 未超过阈值时不调用
+	fast := *config
+	fast.SlowQueryThreshold = time.Hour
+	if db, err = gorm.Open(Open(&fast), &gorm.Config{}); err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	events = nil
+	if err := db.Where("name LIKE ?", "a%").Find(&members).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("SlowQueryHook called below threshold: %+v", events)
+	}
+
+	config.SlowQueryThreshold = -time.Second
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with negative SlowQueryThreshold error = nil")
+	}
+}
+
+func TestChaosInjection(t *testing.T) {
+	requests := 0
+	fb := newFakeBitable(t)
+	fb.handle("/tables", func(r *fakeRequest) interface{} {
+		requests++
+		return fb.next(r)
+	})
+
+	config := fb.config()
+	config.ChaosRate = 1
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.SetRetryConfig(&RetryConfig{MaxRetries: 0})
+
+	// 全部请求失败，且不会发出
+	_, err = client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"})
+	if !IsChaosError(err) || !DefaultShouldRetry(err, 0, RetryOpRead) {
+		t.Errorf("DoRequest() error = %v, expected retryable chaos error", err)
+	}
+	if requests != 0 {
+		t.Errorf("chaos-failed request reached the server %d times", requests)
+	}
+	if IsChaosError(errors.New("other")) {
+		t.Error("IsChaosError() = true for an unrelated error")
+	}
+
+	// 生产环境不注入
+	t.Setenv("BASESQL_ENV", "production")
+	if client, err = NewClient(config); err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.DoRequest(context.Background(), &APIRequest{Method: "GET", Path: "/bitable/v1/apps/app_token/tables"}); err != nil || requests != 1 {
+		t.Errorf("DoRequest() in production error = %v, requests = %d", err, requests)
+	}
+
+	// 环境变量设置比例
+	t.Setenv("BASESQL_CHAOS_RATE", "0.25")
+	env := DefaultConfig()
+	if err := env.LoadEnv(); err != nil || env.ChaosRate != 0.25 {
+		t.Errorf("LoadEnv() ChaosRate = %v, error = %v", env.ChaosRate, err)
+	}
+
+	config.ChaosRate = 1.5
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with ChaosRate > 1 error = nil")
+	}
+}
+
+func TestRequiredFields(t *testing.T) {
+	type Ticket struct {
+		ID       string `gorm:"primaryKey"`
+		Title    string `basesql:"required"`
+		Assignee string
+	}
+
+	var written int
+	fb := newFakeBitable(t)
+	fb.table("tblTickets", "tickets", fakeFields("title", 1, "assignee", 1, "priority", 2))
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		written++
+		return fb.next(r)
+	})
+	db := fb.open(t, func(config *Config) {
+		config.ValidationRules = map[string][]ValidationRule{
+			"tickets": {{Field: "priority", Required: true}},
+		}
+	})
+
+	// 标签和配置中声明的必填字段都未填写，逐个报告
+	err := db.Create(&Ticket{Assignee: "alice"}).Error
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Create() error = %v, expected ValidationErrors", err)
+	}
+	var got []string
+	for _, ve := range verrs {
+		got = append(got, ve.Field+":"+ve.Message)
+	}
+	if strings.Join(got, ",") != "priority:缺少必填字段,title:不能为空" {
+		t.Errorf("violations = %v", got)
+	}
+
+	// 原生 INSERT 按配置中的规则检查
+	err = db.Exec("INSERT INTO tickets (title) VALUES (?)", "bug").Error
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Field != "priority" {
+		t.Errorf("INSERT without priority error = %v, expected priority 缺少必填字段", err)
+	}
+	if written != 0 {
+		t.Errorf("written = %d records, expected records missing required fields to be rejected before writing", written)
+	}
+
+	if err := db.Create(&Ticket{Title: "bug"}).Error; !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Create() without priority error = %v, expected ErrValidationFailed", err)
+	}
+	if err := db.Exec("INSERT INTO tickets (title, priority) VALUES (?, ?)", "bug", 1).Error; err != nil {
+		t.Errorf("INSERT with required fields error = %v", err)
+	}
+	if written != 1 {
+		t.Errorf("written = %d records, expected 1", written)
+	}
+}
+
+func TestTxWriteBuffer(t *testing.T) {
+	type Task struct {
+		ID    string `gorm:"primaryKey"`
+		Title string
+	}
+
+	var requests []string
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "tasks", fakeFields("title", 1))
+	fb.handle("/records/batch_create", func(r *fakeRequest) interface{} {
+		var body BatchCreateRecordsRequest
+		r.decode(&body)
+		requests = append(requests, fmt.Sprintf("batch_create:%d", len(body.Records)))
+		return fb.next(r)
+	})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		requests = append(requests, "create")
+		return fb.next(r)
+	})
+	fb.handle("PUT ", func(r *fakeRequest) interface{} {
+		requests = append(requests, "update")
+		return fb.next(r)
+	})
+	db := fb.open(t, func(config *Config) { config.TxWriteBuffer = true })
+
+	// 事务中的多个创建在提交时合并为一次 batch_create
+	tasks := []*Task{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			if err := tx.Create(task).Error; err != nil {
+				return err
+			}
+		}
+		if len(requests) != 0 {
+			t.Errorf("requests before commit = %v, expected creates to be buffered", requests)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if strings.Join(requests, ",") != "batch_create:3" {
+		t.Errorf("requests = %v, expected one batch_create", requests)
+	}
+	if tasks[0].ID != "rec1" || tasks[2].ID != "rec3" {
+		t.Errorf("IDs = %s, %s, expected record IDs set after commit", tasks[0].ID, tasks[2].ID)
+	}
+
+	// 回滚时丢弃缓冲的创建
+	requests = nil
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		tx.Create(&Task{Title: "discarded"})
+		return errors.New("abort")
+	})
+	if len(requests) != 0 {
+		t.Errorf("requests after rollback = %v, expected none", requests)
+	}
+
+	// 其他语句执行前先写入缓冲的创建
+	requests = nil
+	err = db.Transaction(func(tx *gorm.DB) error {
+		task := &Task{Title: "d"}
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Task{ID: "rec1"}).Update("title", "e").Error
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if strings.Join(requests, ",") != "batch_create:1,update" {
+		t.Errorf("requests = %v, expected buffered create to be flushed before update", requests)
+	}
+
+	// 事务外的创建立即写入
+	requests = nil
+	if err := db.Create(&Task{Title: "f"}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if strings.Join(requests, ",") != "create" {
+		t.Errorf("requests = %v, expected single create outside transaction", requests)
+	}
+}
+
+func TestBufferedWriter(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	release := make(chan struct{})
+	fb := newFakeBitable(t)
+	fb.table("tblLogs", "logs", nil)
+	fb.handle("/records/batch_create", func(r *fakeRequest) interface{} {
+		var body BatchCreateRecordsRequest
+		r.decode(&body)
+		switch body.Records[0].Fields["message"] {
+		case "bad":
+			return fakeError{code: 1254001, msg: "WrongRequestBody"}
+		case "slow":
+			<-release
+		}
+		mu.Lock()
+		batches = append(batches, len(body.Records))
+		mu.Unlock()
+		return fb.next(r)
+	})
+
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// 按数量分批写入，关闭时写入剩余的记录
+	w := client.NewBufferedWriter("logs", BufferedWriterOptions{BatchSize: 2})
+	for i := 0; i < 5; i++ {
+		if err := w.Add(ctx, map[string]interface{}{"message": fmt.Sprintf("line %d", i)}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	total := 0
+	for _, n := range batches {
+		if n > 2 {
+			t.Errorf("batch size = %d, expected at most 2", n)
+		}
+		total += n
+	}
+	if stats := w.Stats(); total != 5 || stats.Written != 5 || stats.Pending != 0 {
+		t.Errorf("written %d records, stats = %+v, expected 5", total, stats)
+	}
+	if err := w.Add(ctx, map[string]interface{}{"message": "late"}); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Add() after Close error = %v, expected ErrWriterClosed", err)
+	}
+
+	// 写入失败时调用 OnError，Flush 返回错误
+	var failed []map[string]interface{}
+	w = client.NewBufferedWriter("logs", BufferedWriterOptions{
+		OnError: func(err error, records []map[string]interface{}) { failed = records },
+	})
+	w.Add(ctx, map[string]interface{}{"message": "bad"})
+	if err := w.Flush(ctx); err == nil {
+		t.Error("Flush() error = nil, expected write error")
+	}
+	if len(failed) != 1 || w.Stats().Failed != 1 {
+		t.Errorf("failed = %v, stats = %+v, expected OnError with the failed record", failed, w.Stats())
+	}
+	w.Close(ctx)
+
+	// 缓冲和写入中的记录达到 MaxPending 时 Add 阻塞，直到上下文取消
+	w = client.NewBufferedWriter("logs", BufferedWriterOptions{BatchSize: 1, MaxPending: 1})
+	w.Add(ctx, map[string]interface{}{"message": "slow"})
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := w.Add(timeout, map[string]interface{}{"message": "next"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Add() on full buffer error = %v, expected context.DeadlineExceeded", err)
+	}
+	close(release)
+	if err := w.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestBufferedWriterDedup(t *testing.T) {
+	var mu sync.Mutex
+	var written []string
+	fb := newFakeBitable(t)
+	fb.table("tblEvents", "events", nil)
+	fb.handle("/records/batch_create", func(r *fakeRequest) interface{} {
+		var body BatchCreateRecordsRequest
+		r.decode(&body)
+		mu.Lock()
+		for _, record := range body.Records {
+			written = append(written, record.Fields["event_id"].(string))
+		}
+		mu.Unlock()
+		return fb.next(r)
+	})
+
+	client, err := NewClient(fb.config())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	opts := BufferedWriterOptions{
+		DedupKey:        func(fields map[string]interface{}) string { return fields["event_id"].(string) },
+		DedupWindowSize: 3,
+		DedupFile:       filepath.Join(t.TempDir(), "events.dedup"),
+	}
+	add := func(w *BufferedWriter, ids ...string) {
+		for _, id := range ids {
+			if err := w.Add(ctx, map[string]interface{}{"event_id": id}); err != nil {
+				t.Fatalf("Add(%s) error = %v", id, err)
+			}
+		}
+	}
+
+	// 缓冲中和已写入的重复键都被跳过
+	w := client.NewBufferedWriter("events", opts)
+	add(w, "e1", "e1", "e2")
+	if err := w.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	add(w, "e2", "e3")
+	w.Close(ctx)
+	if strings.Join(written, ",") != "e1,e2,e3" || w.Stats().Skipped != 2 {
+		t.Errorf("written = %v, stats = %+v, expected duplicates skipped", written, w.Stats())
+	}
+
+	// 重启后从文件恢复窗口；窗口只保留最近的 3 个键
+	w = client.NewBufferedWriter("events", opts)
+	add(w, "e3", "e4", "e5")
+	w.Flush(ctx)
+	add(w, "e1", "e5")
+	w.Close(ctx)
+	if strings.Join(written, ",") != "e1,e2,e3,e4,e5,e1" {
+		t.Errorf("written = %v, expected e3 and e5 skipped and evicted e1 written again", written)
+	}
+
+	data, err := os.ReadFile(opts.DedupFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 2*opts.DedupWindowSize {
+		t.Errorf("dedup file has %d lines, expected it to be compacted", lines)
+	}
+}
+
+func TestBusinessKey(t *testing.T) {
+	type Order struct {
+		ID      string `gorm:"primaryKey"`
+		OrderNo string `basesql:"business_key"`
+		Amount  int
+	}
+
+	var requests []string
+	fb := newFakeBitable(t)
+	fb.table("tblOrders", "orders", fakeFields("order_no", 1, "amount", 2))
+	fb.add("tblOrders", "recExisting", map[string]interface{}{"order_no": "ORD-1", "amount": 1})
+	fb.handle("POST /records", func(r *fakeRequest) interface{} {
+		var body CreateRecordRequest
+		r.decode(&body)
+		requests = append(requests, fmt.Sprintf("create:%v", body.Fields["order_no"]))
+		return `{"record":{"record_id":"recNew"}}`
+	})
+	fb.handle("PUT ", func(r *fakeRequest) interface{} {
+		var body CreateRecordRequest
+		r.decode(&body)
+		requests = append(requests, fmt.Sprintf("update:%v", body.Fields["amount"]))
+		return fb.next(r)
+	})
+
+	config := fb.config()
+	db, err := gorm.Open(Open(config), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	// 业务主键为空时默认生成 UUID，记录 ID 仍然是模型的主键
+	order := &Order{Amount: 1}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(order.OrderNo) != 36 || order.ID != "recNew" {
+		t.Errorf("order = %+v, expected generated UUID business key and record ID primary key", order)
+	}
+
+	// 业务主键不允许重复
+	if err := db.Create(&Order{OrderNo: "ORD-1"}).Error; !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("Create() duplicate business key error = %v, expected ErrUniqueViolation", err)
+	}
+
+	// ON CONFLICT 默认按业务主键判断冲突
+	requests = nil
+	order = &Order{OrderNo: "ORD-1", Amount: 5}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(order).Error; err != nil {
+		t.Fatalf("Create() on conflict update error = %v", err)
+	}
+	order = &Order{OrderNo: "ORD-1", Amount: 7}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(order)
+	if result.Error != nil || result.RowsAffected != 0 || order.ID != "recExisting" {
+		t.Errorf("Create() on conflict do nothing = %v, rows %d, ID %s", result.Error, result.RowsAffected, order.ID)
+	}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&Order{OrderNo: "ORD-2"}).Error; err != nil {
+		t.Fatalf("Create() on conflict without existing record error = %v", err)
+	}
+	if strings.Join(requests, ",") != "update:5,create:ORD-2" {
+		t.Errorf("requests = %v, expected update of the conflicting record and create of the new one", requests)
+	}
+
+	found, err := NewRepo[Order](db).FindByBusinessKey(context.Background(), "ORD-1")
+	if err != nil || found.ID != "recExisting" {
+		t.Errorf("FindByBusinessKey() = %+v, %v, expected recExisting", found, err)
+	}
+	if _, err := NewRepo[Order](db).FindByBusinessKey(context.Background(), "ORD-9"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindByBusinessKey() missing error = %v, expected gorm.ErrRecordNotFound", err)
+	}
+
+	// 自定义生成策略
+	generated := *config
+	generated.BusinessKeyGenerator = func(table string) string { return table + "-42" }
+	db, err = gorm.Open(Open(&generated), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	order = &Order{Amount: 3}
+	if err := db.Create(order).Error; err != nil || order.OrderNo != "orders-42" {
+		t.Errorf("Create() with generator = %v, order no %q, expected orders-42", err, order.OrderNo)
+	}
+}
+
+func TestDateMacros(t *testing.T) {
+	type Task struct {
+		ID      string `gorm:"primaryKey"`
+		DueDate time.Time
+	}
+
+	var filters []string
+	fb := newFakeBitable(t)
+	fb.table("tblTasks", "tasks", fakeFields("due_date", 5))
+	logFilter := func(r *fakeRequest) interface{} {
+		var body ListRecordsRequest
+		r.decode(&body)
+		filter := "none"
+		if body.Filter != nil {
+			condition := body.Filter.Conditions[0]
+			filter = fmt.Sprintf("%s %s %v", condition.FieldName, condition.Operator, condition.Value)
+		}
+		filters = append(filters, filter)
+		return fb.next(r)
+	}
+	fb.handle("/records", logFilter)
+	fb.handle("/records/search", logFilter)
+	db := fb.open(t, nil)
+
+	tests := []struct {
+		query    func() error
+		expected string
+	}{
+		{func() error { return db.Where("due_date = TODAY()").Find(&[]Task{}).Error }, "due_date is [Today]"},
+		{func() error { return db.Where("due_date < yesterday()").Find(&[]Task{}).Error }, "due_date isLess [Yesterday]"},
+		{func() error { return db.Where("due_date IN THIS_WEEK").Find(&[]Task{}).Error }, "due_date is [CurrentWeek]"},
+		{func() error { return db.Where("due_date = ?", Past30Days).Find(&[]Task{}).Error }, "due_date is [TheLastMonth]"},
+		{func() error { return db.Raw("SELECT * FROM tasks WHERE due_date IN LAST_MONTH").Scan(&[]Task{}).Error }, "due_date is [LastMonth]"},
+		{func() error {
+			return db.Raw("SELECT * FROM tasks WHERE `due_date` != TOMORROW()").Scan(&[]Task{}).Error
+		}, "due_date isNot [Tomorrow]"},
+	}
+	for i, tt := range tests {
+		filters = nil
+		if err := tt.query(); err != nil {
+			t.Fatalf("query %d error = %v", i, err)
+		}
+		if len(filters) != 1 || filters[0] != tt.expected {
+			t.Errorf("query %d pushed filters %v, expected [%s]", i, filters, tt.expected)
+		}
+	}
+
+	filters = nil
+	if err := db.Where("due_date >= NOW() - INTERVAL 7 DAY").Find(&[]Task{}).Error; err != nil {
+		t.Fatalf("relative time query error = %v", err)
+	}
+	if err := db.Raw("SELECT * FROM tasks WHERE due_date < NOW() + INTERVAL 1 WEEK").Scan(&[]Task{}).Error; err != nil {
+		t.Fatalf("raw relative time query error = %v", err)
+	}
+	if len(filters) != 2 || !strings.HasPrefix(filters[0], "due_date isGreaterEqual [ExactDate ") ||
+		!strings.HasPrefix(filters[1], "due_date isLess [ExactDate ") {
+		t.Errorf("relative time queries pushed filters %v", filters)
+	}
+}
+
+func TestRelativeTimeCondition(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		sql      string
+		operator string
+		expected time.Time
+	}{
+		{"modified_at > NOW() - INTERVAL 7 DAY", "isGreater", now.AddDate(0, 0, -7)},
+		{"modified_at < now() + interval '2' hours", "isLess", now.Add(2 * time.Hour)},
+		{"`modified_at` <= NOW() - INTERVAL 1 MONTH", "isLessEqual", now.AddDate(0, -1, 0)},
+		{"modified_at = NOW()", "is", now},
+	}
+	for _, tt := range tests {
+		condition := buildRelativeTimeCondition(tt.sql, now)
+		if condition == nil {
+			t.Fatalf("buildRelativeTimeCondition(%q) = nil", tt.sql)
+		}
+		expected := []interface{}{"ExactDate", fmt.Sprint(tt.expected.UnixMilli())}
+		if condition.FieldName != "modified_at" || condition.Operator != tt.operator || !reflect.DeepEqual(condition.Value, expected) {
+			t.Errorf("buildRelativeTimeCondition(%q) = %+v, expected %s %v", tt.sql, condition, tt.operator, expected)
+		}
+	}
+	if condition := buildRelativeTimeCondition("modified_at > '2024-01-01'", now); condition != nil {
+		t.Errorf("literal date parsed as relative time: %+v", condition)
+	}
+}
+
+// TestConcurrentStatements 同一个 *gorm.DB 在多个协程中并发执行各类语句
+// 使用 make test-race（go test -race）运行时检查方言器、客户端和各类缓存的共享状态
+func TestConcurrentStatements(t *testing.T) {
+	type Task struct {
+		ID     string `gorm:"primaryKey"`
+		Title  string
+		Points int
+		Status string
+	}
+
+	fb := newFakeBitable(t)
+	fb.table("tbl1", "tasks", fakeFields("title", 1, "points", 2, "status", 1))
+	config := fb.config()
+	config.ValidationRules = map[string][]ValidationRule{"tasks": {{Field: "title", Pattern: `^[a-z0-9-]+$`}}}
+	config.ReturnRecordAfterWrite = true
+	config.DecimalPlaces = 2
+	config.Collation = CollationCaseInsensitive
+	config.SlowQueryThreshold = time.Nanosecond
+	config.TxWriteBuffer = true
+	config.AutoCreateSchema = true
+	db, err := gorm.Open(Open(config), &gorm.Config{Logger: NewLogger(LoggerConfig{LogLevel: gormlogger.Info, Format: "json", Output: io.Discard})})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	client := db.Dialector.(*Dialector).Client
+	unlimited := &common.RateLimiterConfig{Rate: 100000, Burst: 100000, Window: time.Second}
+	if err := client.UpdateRateLimiterConfig(unlimited); err != nil {
+		t.Fatalf("UpdateRateLimiterConfig() error = %v", err)
+	}
+
+	// 所有协程共用同一个更新映射，回调转换字段值时不能修改调用方的映射
+	shared := map[string]interface{}{"status": "done", "points": 1}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				task := Task{Title: fmt.Sprintf("task-%d-%d", g, i), Points: i, Status: "todo"}
+				steps := []func() error{
+					func() error { return db.Create(&task).Error },
+					func() error { return db.Model(&task).Updates(shared).Error },
+					func() error {
+						var tasks []Task
+						return db.Where("status = ?", "done").Order("points desc").Limit(3).Find(&tasks).Error
+					},
+					func() error { var n int64; return db.Model(&Task{}).Where("points >= ?", 1).Count(&n).Error },
+					func() error {
+						var tasks []Task
+						return db.Raw("SELECT title, points FROM tasks WHERE status = ?", "done").Scan(&tasks).Error
+					},
+					func() error {
+						return db.Exec("UPDATE tasks SET status = ? WHERE title = ?", "archived", task.Title).Error
+					},
+					func() error { var first Task; return db.First(&first, "id = ?", task.ID).Error },
+					func() error {
+						rows, err := db.Model(&Task{}).Where("status = ?", "archived").Rows()
+						if err != nil {
+							return err
+						}
+						defer rows.Close()
+						for rows.Next() {
+						}
+						return rows.Err()
+					},
+					func() error {
+						var page []Task
+						paged := Paginate(db.Model(&Task{}), "", 2)
+						if err := paged.Find(&page).Error; err != nil {
+							return err
+						}
+						NextCursor(paged)
+						return nil
+					},
+					func() error {
+						return db.Transaction(func(tx *gorm.DB) error {
+							return tx.Create(&Task{Title: "in-tx"}).Error
+						})
+					},
+					func() error {
+						// 统计、限流和表结构的管理操作与语句并发执行
+						client.RequestStats()
+						client.GetStabilityStats()
+						client.RefreshSchema()
+						return client.UpdateRateLimiterConfig(unlimited)
+					},
+					func() error { return db.Delete(&task).Error },
+				}
+				for n, step := range steps {
+					if err := step(); err != nil {
+						errs <- fmt.Errorf("goroutine %d step %d: %w", g, n, err)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if len(shared) != 2 || shared["status"] != "done" || shared["points"] != 1 {
+		t.Errorf("shared update map = %v, expected it to be left unchanged", shared)
+	}
+}
+
+// fakeAPI 不发起 HTTP 请求的 APIClient 替身，按 "方法 路径" 返回预置的响应体
+type fakeAPI struct {
+	mu        sync.Mutex
+	tables    map[string]string // 表名 -> 表 ID
+	responses map[string]string // "方法 路径" -> 响应体
+	requests  []*APIRequest
+}
+
+func (f *fakeAPI) DoRequest(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	body, ok := f.responses[req.Method+" "+req.Path]
+	if !ok {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Path)
+	}
+	return &APIResponse{StatusCode: http.StatusOK, Body: []byte(body)}, nil
+}
+
+func (f *fakeAPI) ResolveTableID(ctx context.Context, nameOrID string) (string, error) {
+	if id, ok := f.tables[nameOrID]; ok {
+		return id, nil
+	}
+	return "", ErrTableNotFound
+}
+
+func TestOpenWithAPI(t *testing.T) {
+	type Task struct {
+		ID     string `gorm:"primaryKey"`
+		Title  string
+		Points int
+	}
+
+	const records = "/bitable/v1/apps/app_token/tables/tbl1/records"
+	api := &fakeAPI{
+		tables: map[string]string{"tasks": "tbl1"},
+		responses: map[string]string{
+			"GET /bitable/v1/apps/app_token/tables/tbl1/fields": `{"code":0,"data":{"items":[{"field_name":"title","type":1},{"field_name":"points","type":2}]}}`,
+			"POST " + records:             `{"code":0,"data":{"record":{"record_id":"rec1","fields":{"title":"login","points":3}}}}`,
+			"POST " + records + "/search": `{"code":0,"data":{"items":[{"record_id":"rec1","fields":{"title":"login","points":3}}],"total":1}}`,
+			"PUT " + records + "/rec1":    `{"code":0,"data":{"record":{"record_id":"rec1","fields":{"title":"login","points":5}}}}`,
+			"DELETE " + records + "/rec1": `{"code":0,"data":{"deleted":true,"record_id":"rec1"}}`,
+		},
+	}
+	db, err := gorm.Open(OpenWithAPI(&Config{AppToken: "app_token"}, api), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if dialector := db.Dialector.(*Dialector); dialector.Client != nil || dialector.API != api {
+		t.Fatalf("dialector should use the injected API without creating a Client")
+	}
+
+	task := Task{Title: "login", Points: 3}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if task.ID != "rec1" {
+		t.Errorf("created ID = %q, expected rec1", task.ID)
+	}
+
+	var found []Task
+	if err := db.Where("title = ?", "login").Find(&found).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(found) != 1 || found[0].Points != 3 {
+		t.Errorf("Find() = %+v, expected one task with 3 points", found)
+	}
+
+	if err := db.Model(&task).Update("points", 5).Error; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := db.Delete(&task).Error; err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if err := db.Table("missing").Create(&Task{Title: "x"}).Error; !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("Create() on missing table error = %v, expected ErrTableNotFound", err)
+	}
+
+	var update *UpdateRecordRequest
+	for _, req := range api.requests {
+		if req.Method == "PUT" {
+			update, _ = req.Body.(*UpdateRecordRequest)
+		}
+	}
+	if update == nil || update.Fields["points"] != float64(5) {
+		t.Errorf("update request body = %+v, expected points = 5", update)
+	}
+}
@@ -0,0 +1,266 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// batchDeleteRecordsResponse 批量删除记录接口的 data 部分
+type batchDeleteRecordsResponse struct {
+	Records []struct {
+		Deleted  bool   `json:"deleted"`   // 是否已删除
+		RecordID string `json:"record_id"` // 记录 ID
+	} `json:"records"`
+}
+
+// statementRecordIDs 获取语句按主键指定的多条记录
+// 支持两种写法：WHERE 中只有主键上的 IN 条件，如 db.Delete(&User{}, []string{"recA", "recB"})；
+// 以及模型切片，如 db.Delete(&users)，切片中主键为空的元素被忽略
+// 参数:
+//   - db: GORM 数据库实例
+//
+// 返回:
+//   - []string: 去重后的记录 ID
+//   - bool: 语句是否按主键指定了多条记录，为 false 时按单条记录处理
+func statementRecordIDs(db *gorm.DB) ([]string, bool) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, false
+	}
+
+	var values []interface{}
+	if rv, ok := modelSlice(stmt); ok {
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			if value, isZero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, elem); !isZero {
+				values = append(values, value)
+			}
+		}
+	} else {
+		whereClause, ok := stmt.Clauses["WHERE"]
+		if !ok {
+			return nil, false
+		}
+		where, ok := whereClause.Expression.(clause.Where)
+		if !ok || len(where.Exprs) != 1 {
+			return nil, false
+		}
+		in, ok := where.Exprs[0].(clause.IN)
+		if !ok {
+			return nil, false
+		}
+		if column, ok := in.Column.(clause.Column); !ok || !isRecordIDColumn(stmt, column.Name) {
+			return nil, false
+		}
+		values = in.Values
+	}
+
+	recordIDs := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if id := common.FormatValue(value); id != "" && !seen[id] {
+			seen[id] = true
+			recordIDs = append(recordIDs, id)
+		}
+	}
+	return recordIDs, true
+}
+
+// modelSlice 获取语句中的模型切片
+// Update/Updates 传入映射时 ReflectValue 是映射本身，模型切片只能从 Model 中获取
+func modelSlice(stmt *gorm.Statement) (reflect.Value, bool) {
+	for _, rv := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Model)} {
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			return rv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// batchDeleteRecords 按记录 ID 批量删除记录，每次请求最多 common.MaxBatchSize 个 ID
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - recordIDs: 记录 ID 列表
+//
+// 返回:
+//   - int64: API 确认删除的记录数
+//   - error: 请求过程中的错误，已完成批次删除的记录数仍然返回
+func batchDeleteRecords(ctx context.Context, dialector *Dialector, tableID string, recordIDs []string) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(recordIDs); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(recordIDs))
+		resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_delete", dialector.Config.AppToken, tableID),
+			Body:   &BatchDeleteRecordsRequest{Records: recordIDs[start:end]},
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("批量删除记录失败: %w", err)
+		}
+		var result batchDeleteRecordsResponse
+		if err := repoCheckResponse(resp.Body, &result); err != nil {
+			return deleted, fmt.Errorf("批量删除记录失败: %w", err)
+		}
+		for _, record := range result.Records {
+			if record.Deleted {
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// batchUpdateCallback 按记录 ID 批量更新记录
+// 通过 Update/Updates 指定的字段写入每一条记录；没有指定时写入模型切片中各元素自己的字段值
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - recordIDs: 记录 ID 列表，见 statementRecordIDs
+//
+// 返回:
+//   - error: 校验或请求过程中的错误
+func batchUpdateCallback(db *gorm.DB, dialector *Dialector, tableID string, recordIDs []string) error {
+	stmt := db.Statement
+	tableName := stmt.Table
+
+	tableFields := make(map[string]*Field)
+	if tableFieldsList, err := getTableFields(dialector, tableName); err == nil {
+		for _, tableField := range tableFieldsList {
+			tableFields[tableField.FieldName] = tableField
+		}
+	}
+	convert := func(fields map[string]interface{}) map[string]interface{} {
+		for fieldName, value := range fields {
+			if tableField, exists := tableFields[fieldName]; exists {
+				if tableField.IsReadOnly() {
+					delete(fields, fieldName)
+					continue
+				}
+				fields[fieldName] = tableField.ConvertFromGoValue(value)
+			}
+		}
+		return fields
+	}
+
+	var updates []*BatchUpdateRecord
+	if shared := assignedFields(stmt); len(shared) > 0 {
+		if shared = convert(shared); len(shared) == 0 {
+			return nil
+		}
+		if err := dialector.validateWrite(tableName, stmt.Schema, tableFields, shared, true); err != nil {
+			return err
+		}
+		for _, recordID := range recordIDs {
+			fields := make(map[string]interface{}, len(shared))
+			for name, value := range shared {
+				fields[name] = value
+			}
+			updates = append(updates, &BatchUpdateRecord{RecordID: recordID, Fields: fields})
+		}
+	} else if rv, ok := modelSlice(stmt); ok {
+		primaryField := stmt.Schema.PrioritizedPrimaryField
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			value, isZero := primaryField.ValueOf(stmt.Context, elem)
+			if isZero {
+				continue
+			}
+			fields := convert(structUpdateFields(stmt.Context, stmt.Schema, elem))
+			if len(fields) == 0 {
+				continue
+			}
+			if err := dialector.validateWrite(tableName, stmt.Schema, tableFields, fields, true); err != nil {
+				return fmt.Errorf("第 %d 条记录: %w", i+1, err)
+			}
+			updates = append(updates, &BatchUpdateRecord{RecordID: common.FormatValue(value), Fields: fields})
+		}
+	}
+
+	ctx := statementContext(db)
+	for start := 0; start < len(updates); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(updates))
+		resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_update", dialector.Config.AppToken, tableID),
+			Body:   &BatchUpdateRecordsRequest{Records: updates[start:end]},
+		})
+		if err != nil {
+			return fmt.Errorf("批量更新记录失败: %w", err)
+		}
+		var result BatchUpdateRecordsResponse
+		if err := repoCheckResponse(resp.Body, &result); err != nil {
+			return fmt.Errorf("批量更新记录失败: %w", err)
+		}
+		db.RowsAffected += int64(len(result.Records))
+	}
+
+	returnRecords(db, dialector, tableID, sliceWriteTargets(stmt))
+	return nil
+}
+
+// assignedFields 获取 Update/Updates 指定的字段值，键为表字段名
+// Dest 为映射时取映射中的字段，为结构体时取其中的非零值字段；Dest 就是模型本身时返回 nil
+func assignedFields(stmt *gorm.Statement) map[string]interface{} {
+	fields := make(map[string]interface{})
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		for name, value := range dest {
+			if field := stmt.Schema.LookUpField(name); field != nil {
+				if field.PrimaryKey || field.DBName == "" {
+					continue
+				}
+				name, value = field.DBName, fieldWriteValue(field, value)
+			}
+			fields[name] = value
+		}
+	default:
+		if stmt.Dest == stmt.Model {
+			return nil
+		}
+		rv := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+		if rv.Kind() != reflect.Struct || rv.Type() != stmt.Schema.ModelType {
+			return nil
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.PrimaryKey || field.AutoIncrement || field.DBName == "" || isRecordTimeField(field) {
+				continue
+			}
+			if value, isZero := field.ValueOf(stmt.Context, rv); !isZero {
+				fields[field.DBName] = fieldWriteValue(field, value)
+			}
+		}
+	}
+	return fields
+}
+
+// structUpdateFields 获取结构体中需要更新的字段值，主键、自增字段、关系字段和自动时间字段不更新
+func structUpdateFields(ctx context.Context, sch *schema.Schema, structValue reflect.Value) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, field := range sch.Fields {
+		// 关系字段没有列名，不是表中的字段
+		if field.PrimaryKey || field.AutoIncrement || field.DBName == "" || isRecordTimeField(field) {
+			continue
+		}
+		value, _ := field.ValueOf(ctx, structValue)
+		fields[field.DBName] = fieldWriteValue(field, value)
+	}
+	return fields
+}
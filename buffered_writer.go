@@ -0,0 +1,302 @@
+package basesql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// ErrWriterClosed 向已关闭的 BufferedWriter 添加记录
+var ErrWriterClosed = errors.New("basesql: buffered writer closed")
+
+// BufferedWriterOptions 缓冲写入器选项
+type BufferedWriterOptions struct {
+	BatchSize     int                                               // 缓冲达到该记录数时写入一批，0 或超过 500 时为 500
+	FlushInterval time.Duration                                     // 定时写入缓冲中的记录，0 表示只按数量和 Flush 写入
+	MaxPending    int                                               // 缓冲和写入中的最大记录数，达到后 Add 阻塞等待，0 表示 BatchSize 的 10 倍
+	OnError       func(err error, records []map[string]interface{}) // 一批记录写入失败时调用，为空时记录警告日志；后台写入的错误只能通过它获取
+
+	// 去重：至少一次投递的数据管道重试时会重复投递同一条数据，按去重键跳过最近已写入或正在缓冲的记录
+	DedupKey        func(fields map[string]interface{}) string // 从记录中提取去重键，为空时不去重；返回空字符串的记录不参与去重
+	DedupWindowSize int                                        // 保留最近写入的去重键数，0 表示 100000
+	DedupFile       string                                     // 去重键的持久化文件（JSON Lines），进程重启后恢复窗口，为空时只保存在内存中
+}
+
+// BufferedWriterStats 缓冲写入器的统计
+type BufferedWriterStats struct {
+	Written int64 // 已写入的记录数
+	Failed  int64 // 写入失败的记录数
+	Skipped int64 // 去重键重复而跳过的记录数
+	Pending int   // 缓冲和写入中的记录数
+}
+
+// BufferedWriter 缓冲写入器，将记录积攒成批后通过 batch_create 写入，用于日志、监控数据等大量写入的场景
+// 缓冲达到 BatchSize 或到达 FlushInterval 时在后台写入；缓冲和写入中的记录达到 MaxPending 时 Add 阻塞，
+// 避免写入慢于产生速度时内存无限增长。可以在多个协程中并发调用
+type BufferedWriter struct {
+	client *Client
+	table  string
+	opts   BufferedWriterOptions
+
+	mu          sync.Mutex
+	buffer      []bufferedRecord
+	pendingKeys map[string]struct{} // 缓冲和写入中的记录的去重键
+	closed      bool
+	dedup       *dedupWindow // 最近写入的去重键，未设置 DedupKey 时为空
+
+	slots   chan struct{} // 背压信号量，每条缓冲或写入中的记录占用一个
+	kick    chan struct{} // 缓冲达到 BatchSize 时通知后台写入
+	stop    chan struct{}
+	done    chan struct{}
+	writeMu sync.Mutex // 保证各批按添加顺序写入
+
+	written atomic.Int64
+	failed  atomic.Int64
+	skipped atomic.Int64
+}
+
+// bufferedRecord 缓冲中的一条记录
+type bufferedRecord struct {
+	fields map[string]interface{}
+	key    string // 去重键，不去重时为空
+}
+
+// NewBufferedWriter 创建写入指定表的缓冲写入器
+// 使用完毕后需要调用 Close 写入剩余的记录并停止后台写入
+// 参数:
+//   - table: 表名或表 ID
+//   - opts: 缓冲写入器选项
+//
+// 返回:
+//   - *BufferedWriter: 缓冲写入器
+func (c *Client) NewBufferedWriter(table string, opts BufferedWriterOptions) *BufferedWriter {
+	if opts.BatchSize <= 0 || opts.BatchSize > common.MaxBatchSize {
+		opts.BatchSize = common.MaxBatchSize
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = opts.BatchSize * 10
+	}
+	opts.MaxPending = max(opts.MaxPending, opts.BatchSize)
+
+	w := &BufferedWriter{
+		client: c,
+		table:  table,
+		opts:   opts,
+		slots:  make(chan struct{}, opts.MaxPending),
+		kick:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+
+		pendingKeys: make(map[string]struct{}),
+	}
+	if opts.DedupKey != nil {
+		// 去重文件读取失败时从空窗口开始，不影响写入
+		var err error
+		if w.dedup, err = newDedupWindow(opts.DedupWindowSize, opts.DedupFile); err != nil {
+			common.Warnf("缓冲写入表 %s 的去重窗口恢复失败: %v", table, err)
+		}
+	}
+	go w.loop()
+	return w
+}
+
+// Add 添加一条记录，缓冲已满时阻塞直到有记录写入完成或上下文取消
+// 参数:
+//   - ctx: 上下文
+//   - fields: 字段值，使用飞书 API 的写入格式，键为字段名
+//
+// 返回:
+//   - error: 上下文取消、只读模式或写入器已关闭时返回错误；写入错误通过 OnError 和 Flush 返回，
+//     去重键重复的记录被跳过，不返回错误
+func (w *BufferedWriter) Add(ctx context.Context, fields map[string]interface{}) error {
+	if err := w.client.config.CheckWritable("INSERT"); err != nil {
+		return err
+	}
+	key := ""
+	if w.dedup != nil {
+		key = w.opts.DedupKey(fields)
+	}
+	select {
+	case w.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		<-w.slots
+		return ErrWriterClosed
+	}
+	if key != "" {
+		_, pending := w.pendingKeys[key]
+		if pending || w.dedup.contains(key) {
+			w.mu.Unlock()
+			<-w.slots
+			w.skipped.Add(1)
+			return nil
+		}
+		w.pendingKeys[key] = struct{}{}
+	}
+	w.buffer = append(w.buffer, bufferedRecord{fields: fields, key: key})
+	full := len(w.buffer) >= w.opts.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush 立即写入缓冲中的全部记录，并等待写入完成
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - error: 写入失败的批次的错误
+func (w *BufferedWriter) Flush(ctx context.Context) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	w.mu.Lock()
+	records := w.buffer
+	w.buffer = nil
+	w.mu.Unlock()
+	return w.write(ctx, records)
+}
+
+// Close 停止后台写入，写入缓冲中剩余的记录；关闭后 Add 返回 ErrWriterClosed
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - error: 写入剩余记录时的错误
+func (w *BufferedWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return w.Flush(ctx)
+}
+
+// Stats 获取缓冲写入器的统计
+// 返回:
+//   - BufferedWriterStats: 已写入、写入失败和缓冲中的记录数
+func (w *BufferedWriter) Stats() BufferedWriterStats {
+	return BufferedWriterStats{
+		Written: w.written.Load(),
+		Failed:  w.failed.Load(),
+		Skipped: w.skipped.Load(),
+		Pending: len(w.slots),
+	}
+}
+
+// loop 后台写入：缓冲达到 BatchSize 或到达 FlushInterval 时写入
+func (w *BufferedWriter) loop() {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if w.opts.FlushInterval > 0 {
+		ticker := time.NewTicker(w.opts.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.kick:
+		case <-tick:
+		}
+		// 后台写入的错误已通过 OnError 报告
+		_ = w.Flush(context.Background())
+	}
+}
+
+// write 按 BatchSize 分批写入记录，每批写入后释放占用的缓冲，调用方需持有 writeMu
+func (w *BufferedWriter) write(ctx context.Context, records []bufferedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for start := 0; start < len(records); start += w.opts.BatchSize {
+		entries := records[start:min(start+w.opts.BatchSize, len(records))]
+		batch := make([]map[string]interface{}, len(entries))
+		var keys []string
+		for i, entry := range entries {
+			batch[i] = entry.fields
+			if entry.key != "" {
+				keys = append(keys, entry.key)
+			}
+		}
+
+		err := w.writeBatch(ctx, batch)
+		// 写入成功的键进入去重窗口；写入失败的键同样移出缓冲，重试投递的记录可以重新添加
+		if err == nil && len(keys) > 0 {
+			if err := w.dedup.record(keys); err != nil {
+				common.DefaultLogger.WithContext(ctx).Warnf("缓冲写入表 %s 的去重键持久化失败: %v", w.table, err)
+			}
+		}
+		w.mu.Lock()
+		for _, key := range keys {
+			delete(w.pendingKeys, key)
+		}
+		w.mu.Unlock()
+		for range entries {
+			<-w.slots
+		}
+		if err == nil {
+			w.written.Add(int64(len(batch)))
+			continue
+		}
+
+		w.failed.Add(int64(len(batch)))
+		errs = append(errs, err)
+		if w.opts.OnError != nil {
+			w.opts.OnError(err, batch)
+		} else {
+			common.DefaultLogger.WithContext(ctx).Warnf("缓冲写入表 %s 的 %d 条记录失败: %v", w.table, len(batch), err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeBatch 通过一次 batch_create 写入一批记录
+func (w *BufferedWriter) writeBatch(ctx context.Context, batch []map[string]interface{}) error {
+	tableID, err := w.client.ResolveTableID(ctx, w.table)
+	if err != nil {
+		return err
+	}
+	req := &BatchCreateRecordsRequest{Records: make([]*CreateRecordRequest, 0, len(batch))}
+	for _, fields := range batch {
+		req.Records = append(req.Records, &CreateRecordRequest{Fields: fields})
+	}
+	resp, err := w.client.DoRequest(ctx, &APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", w.client.config.AppToken, tableID),
+		Body:   req,
+	})
+	if err != nil {
+		return fmt.Errorf("批量创建记录失败: %w", err)
+	}
+	if err := repoCheckResponse(resp.Body, nil); err != nil {
+		return fmt.Errorf("批量创建记录失败: %w", err)
+	}
+	return nil
+}
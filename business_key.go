@@ -0,0 +1,201 @@
+package basesql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// businessKeyTagOption 声明业务主键的标签选项，如 `basesql:"business_key"`
+// 记录 ID 仍然是模型的主键，业务主键保存在普通的文本字段中，由客户端生成，
+// 用于按业务含义查找记录（Repo.FindByBusinessKey）和创建时的冲突判断（clause.OnConflict、Repo.Upsert）
+const businessKeyTagOption = "business_key"
+
+// businessKeyField 获取模型中声明为业务主键的字段，没有时返回 nil
+func businessKeyField(sch *schema.Schema) *schema.Field {
+	if sch == nil {
+		return nil
+	}
+	for _, field := range sch.Fields {
+		if field.DBName != "" && hasTagOption(field, businessKeyTagOption) {
+			return field
+		}
+	}
+	return nil
+}
+
+// newBusinessKey 生成随机的 UUID（版本 4）作为默认的业务主键
+func newBusinessKey(string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("生成业务主键失败: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	s := hex.EncodeToString(b[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// fillBusinessKey 业务主键为空时使用 Config.BusinessKeyGenerator 生成并设置到模型
+// 参数:
+//   - ctx: 上下文
+//   - config: 方言器的配置
+//   - sch: 模型结构
+//   - rv: 模型结构体的反射值，必须可设置
+//
+// 返回:
+//   - error: 设置字段值失败时的错误
+func fillBusinessKey(ctx context.Context, config *Config, sch *schema.Schema, rv reflect.Value) error {
+	field := businessKeyField(sch)
+	if field == nil || rv.Kind() != reflect.Struct || !rv.CanSet() {
+		return nil
+	}
+	if _, zero := field.ValueOf(ctx, rv); !zero {
+		return nil
+	}
+	generate := newBusinessKey
+	if config != nil && config.BusinessKeyGenerator != nil {
+		generate = config.BusinessKeyGenerator
+	}
+	if err := field.Set(ctx, rv, generate(sch.Table)); err != nil {
+		return fmt.Errorf("设置业务主键 %s 失败: %w", field.DBName, err)
+	}
+	return nil
+}
+
+// findByKey 按键字段查找记录，键为空或没有匹配的记录时返回 nil
+func findByKey(ctx context.Context, dialector *Dialector, tableID, column string, value interface{}) (*Record, error) {
+	text := common.FormatValue(value)
+	if text == "" {
+		return nil, nil
+	}
+	resp, err := searchRecords(ctx, dialector, tableID, &ListRecordsRequest{
+		Filter: &FilterRequest{
+			Conjunction: "and",
+			Conditions:  []*FilterCondition{{FieldName: column, Operator: "is", Value: []interface{}{text}}},
+		},
+		PageSize: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("按 %s 查找记录失败: %w", column, err)
+	}
+	if len(resp.Items) == 0 {
+		return nil, nil
+	}
+	return resp.Items[0], nil
+}
+
+// onConflictTarget 获取创建语句中 ON CONFLICT 子句的冲突列
+// 子句指定了列时使用第一列，否则使用模型的业务主键
+// 返回:
+//   - clause.OnConflict: ON CONFLICT 子句
+//   - string: 冲突列名
+//   - bool: 语句是否带有可以处理的 ON CONFLICT 子句
+func onConflictTarget(stmt *gorm.Statement) (clause.OnConflict, string, bool) {
+	c, ok := stmt.Clauses["ON CONFLICT"]
+	if !ok {
+		return clause.OnConflict{}, "", false
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok {
+		return clause.OnConflict{}, "", false
+	}
+	if len(onConflict.Columns) > 0 {
+		return onConflict, onConflict.Columns[0].Name, true
+	}
+	if field := businessKeyField(stmt.Schema); field != nil {
+		return onConflict, field.DBName, true
+	}
+	return onConflict, "", false
+}
+
+// onConflictFields 获取冲突时需要更新的字段值
+// UpdateAll 时更新全部字段；DoUpdates 指定了列时只更新这些列，未指定时不更新
+func onConflictFields(onConflict clause.OnConflict, fieldMap map[string]*Field, fields map[string]interface{}) map[string]interface{} {
+	if onConflict.DoNothing {
+		return nil
+	}
+	if onConflict.UpdateAll {
+		return fields
+	}
+	updates := make(map[string]interface{})
+	for _, assignment := range onConflict.DoUpdates {
+		// clause.AssignmentColumns 的值引用写入的值（excluded.列名），clause.Assignments 的值为字面量
+		if _, ok := assignment.Value.(clause.Column); !ok {
+			value := assignment.Value
+			if field, ok := fieldMap[assignment.Column.Name]; ok {
+				value = field.ConvertFromGoValue(value)
+			}
+			updates[assignment.Column.Name] = value
+		} else if value, ok := fields[assignment.Column.Name]; ok {
+			updates[assignment.Column.Name] = value
+		}
+	}
+	return updates
+}
+
+// createOnConflict 处理带 ON CONFLICT 子句的创建：按冲突列查找已有记录，存在时按子句更新或跳过
+// 参数:
+//   - ctx: 上下文
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - fieldMap: 表字段，键为字段名
+//   - fields: 即将写入的字段值
+//
+// 返回:
+//   - bool: 是否找到已有记录（找到时不再创建）
+//   - error: 查找或更新过程中的错误
+func createOnConflict(ctx context.Context, db *gorm.DB, dialector *Dialector, tableID string, fieldMap map[string]*Field, fields map[string]interface{}) (bool, error) {
+	onConflict, column, ok := onConflictTarget(db.Statement)
+	if !ok {
+		return false, nil
+	}
+	record, err := findByKey(ctx, dialector, tableID, column, fields[column])
+	if err != nil || record == nil {
+		return false, err
+	}
+
+	if primary := db.Statement.Schema.PrioritizedPrimaryField; primary != nil {
+		if err := primary.Set(ctx, db.Statement.ReflectValue, record.RecordID); err != nil {
+			return true, fmt.Errorf("设置主键值失败: %w", err)
+		}
+	}
+	updates := onConflictFields(onConflict, fieldMap, fields)
+	delete(updates, column)
+	if len(updates) == 0 {
+		db.RowsAffected = 0
+		return true, nil
+	}
+
+	if err := dialector.Config.CheckWritable("UPDATE"); err != nil {
+		return true, err
+	}
+	if err := dialector.validateWrite(db.Statement.Table, db.Statement.Schema, fieldMap, updates, true); err != nil {
+		return true, err
+	}
+	resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+		Method: "PUT",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
+		Body:   &UpdateRecordRequest{Fields: updates},
+	})
+	if err != nil {
+		return true, fmt.Errorf("更新冲突的记录失败: %w", err)
+	}
+	if err := repoCheckResponse(resp.Body, nil); err != nil {
+		return true, fmt.Errorf("更新冲突的记录失败: %w", err)
+	}
+	db.RowsAffected = 1
+
+	if target, ok := writeTarget(db.Statement); ok {
+		returnRecords(db, dialector, tableID, map[string]reflect.Value{record.RecordID: target})
+	}
+	return true, nil
+}
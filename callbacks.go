@@ -2,6 +2,7 @@ package basesql
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ag9920/basesql/internal/common"
 	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 )
@@ -21,10 +23,11 @@ func parseValue(value string) interface{} {
 }
 
 // getTableID 通过表名获取表 ID
-// 这个函数会调用飞书多维表格 API 获取应用下的所有表，然后根据表名查找对应的表 ID
+// 这个函数会调用飞书多维表格 API 获取应用下的所有表，然后按 ResolveTable 查找对应的表 ID；
+// 表名解析一次后记住表 ID，表在飞书界面中改名后仍能按原名称访问，见 Client.ResolveTableID
 // 参数:
 //   - dialector: BaseSQL 的方言器实例，包含客户端和配置信息
-//   - tableName: 要查找的表名
+//   - tableName: 要查找的表名，也可以直接使用表 ID
 //
 // 返回:
 //   - string: 表 ID
@@ -41,51 +44,10 @@ func getTableID(dialector *Dialector, tableName string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// 调用飞书 API 获取表列表
-	apiReq := &APIRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", dialector.Config.AppToken),
-	}
-
-	resp, err := dialector.Client.DoRequest(ctx, apiReq)
-	if err != nil {
-		return "", fmt.Errorf("获取表列表失败: %w", err)
-	}
-
-	// 解析飞书API的完整响应结构
-	var apiResp ListTablesAPIResponse
-	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
-		return "", fmt.Errorf("解析API响应失败: %w", err)
-	}
-
-	// 检查API响应码
-	if apiResp.Code != 0 {
-		return "", fmt.Errorf("API请求失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
-	}
-
-	// 检查数据是否存在
-	if apiResp.Data == nil {
-		return "", fmt.Errorf("API响应中没有数据")
-	}
-
-	listResp := apiResp.Data
-
-	// 查找表名对应的 ID（精确匹配）
-	for _, table := range listResp.Items {
-		if table.Name == tableName {
-			return table.TableID, nil
-		}
-	}
-
-	// 如果精确匹配失败，尝试不区分大小写的匹配
-	for _, table := range listResp.Items {
-		if strings.EqualFold(table.Name, tableName) {
-
-			return table.TableID, nil
-		}
+	if client := dialector.baseClient(); client != nil {
+		return client.resolveTableID(ctx, dialector.Config.AppToken, tableName)
 	}
-
-	return "", fmt.Errorf("未找到表 '%s'，请检查表名是否正确", tableName)
+	return dialector.api().ResolveTableID(ctx, tableName)
 }
 
 // getTableFields 获取表的所有字段信息
@@ -121,7 +83,7 @@ func getTableFields(dialector *Dialector, tableName string) ([]*Field, error) {
 		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", dialector.Config.AppToken, tableID),
 	}
 
-	resp, err := dialector.Client.DoRequest(ctx, apiReq)
+	resp, err := dialector.api().DoRequest(ctx, apiReq)
 	if err != nil {
 
 		return nil, fmt.Errorf("获取字段列表失败: %w", err)
@@ -143,6 +105,13 @@ func getTableFields(dialector *Dialector, tableName string) ([]*Field, error) {
 		return nil, fmt.Errorf("API响应数据为空")
 	}
 
+	// 按字段 ID 记录字段名，找出在飞书界面中改过名的字段
+	if client := dialector.baseClient(); client != nil {
+		client.schemaIDs.rememberFields(tableID, apiResp.Data.Items)
+	}
+	for _, field := range apiResp.Data.Items {
+		field.decimalPlaces = dialector.Config.DecimalPlaces
+	}
 	return apiResp.Data.Items, nil
 }
 
@@ -161,21 +130,24 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 	// 替换查询处理器 - 处理 SELECT 语句
 	db.Callback().Query().Replace("gorm:query", func(db *gorm.DB) {
-		if err := queryCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "query", queryCallback); err != nil {
 			// 在事务中的查询失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的查询操作失败，由于飞书多维表格不支持回滚，可能导致数据不一致: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的查询操作失败，由于飞书多维表格不支持回滚，可能导致数据不一致: %v", err)
 			}
 			db.AddError(fmt.Errorf("查询操作失败: %w", err))
 		}
 	})
 
+	// 注册预加载处理器 - 查询完成后按关联批量加载，每个关联只发起一次 IN 查询
+	db.Callback().Query().After("gorm:query").Register("gorm:preload", callbacks.Preload)
+
 	// 替换行查询处理器 - 处理单行查询
 	db.Callback().Row().Replace("gorm:row", func(db *gorm.DB) {
-		if err := queryCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "row", rowCallback); err != nil {
 			// 在事务中的查询失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的行查询操作失败，由于飞书多维表格不支持回滚，可能导致数据不一致: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的行查询操作失败，由于飞书多维表格不支持回滚，可能导致数据不一致: %v", err)
 			}
 			db.AddError(fmt.Errorf("行查询操作失败: %w", err))
 		}
@@ -183,10 +155,10 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 	// 替换原始查询处理器 - 处理原生 SQL 语句
 	db.Callback().Raw().Replace("gorm:raw", func(db *gorm.DB) {
-		if err := rawCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "raw", rawCallback); err != nil {
 			// 在事务中的原生SQL失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的原生SQL操作失败，由于飞书多维表格不支持回滚，已执行的操作无法撤销: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的原生SQL操作失败，由于飞书多维表格不支持回滚，已执行的操作无法撤销: %v", err)
 			}
 			db.AddError(fmt.Errorf("原生 SQL 操作失败: %w", err))
 		}
@@ -194,10 +166,10 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 	// 替换创建回调 - 处理 INSERT 语句
 	db.Callback().Create().Replace("gorm:create", func(db *gorm.DB) {
-		if err := createCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "create", createCallback); err != nil {
 			// 在事务中的创建失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的创建操作失败，由于飞书多维表格不支持回滚，已创建的数据无法撤销: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的创建操作失败，由于飞书多维表格不支持回滚，已创建的数据无法撤销: %v", err)
 			}
 			db.AddError(fmt.Errorf("创建操作失败: %w", err))
 		}
@@ -205,10 +177,10 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 	// 替换更新回调 - 处理 UPDATE 语句
 	db.Callback().Update().Replace("gorm:update", func(db *gorm.DB) {
-		if err := updateCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "update", updateCallback); err != nil {
 			// 在事务中的更新失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的更新操作失败，由于飞书多维表格不支持回滚，已更新的数据无法撤销: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的更新操作失败，由于飞书多维表格不支持回滚，已更新的数据无法撤销: %v", err)
 			}
 			db.AddError(fmt.Errorf("更新操作失败: %w", err))
 		}
@@ -216,7 +188,7 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 	// 替换删除回调 - 处理 DELETE 语句
 	db.Callback().Delete().Replace("gorm:delete", func(db *gorm.DB) {
-		if err := deleteCallback(db, dialector); err != nil {
+		if err := runTraced(db, dialector, "delete", deleteCallback); err != nil {
 			// 检查是否是'record ID not found'错误
 			if err.Error() == "record ID not found" {
 				// 对于记录不存在的情况，静默处理，不显示警告
@@ -226,11 +198,36 @@ func RegisterCallbacks(db *gorm.DB, dialector *Dialector) {
 
 			// 在事务中的删除失败会影响整个事务
 			if db.Statement.ConnPool != nil {
-				common.Warnf("事务中的删除操作失败，由于飞书多维表格不支持回滚，已删除的数据无法恢复: %v", err)
+				common.DefaultLogger.WithContext(statementContext(db)).Warnf("事务中的删除操作失败，由于飞书多维表格不支持回滚，已删除的数据无法恢复: %v", err)
 			}
 			db.AddError(fmt.Errorf("删除操作失败: %w", err))
 		}
 	})
+
+	registerHookCallbacks(db)
+}
+
+// registerHookCallbacks 注册模型钩子的回调
+// 方言器没有注册 GORM 的默认回调，BeforeCreate、AfterFind 等钩子需要单独注册，调用顺序与 GORM 相同：
+//   - 创建：BeforeSave、BeforeCreate、创建记录、AfterCreate、AfterSave
+//   - 更新：BeforeSave、BeforeUpdate、更新记录、AfterUpdate、AfterSave
+//   - 删除：BeforeDelete、删除记录、AfterDelete
+//   - 查询：查询记录、预加载、AfterFind
+//
+// 钩子返回错误时后续回调不再执行，记录不会被写入
+func registerHookCallbacks(db *gorm.DB) {
+	db.Callback().Create().Before("gorm:create").Register("gorm:before_create", callbacks.BeforeCreate)
+	db.Callback().Create().After("gorm:create").Register("gorm:after_create", callbacks.AfterCreate)
+
+	// 对 Model 调用 Update/Updates 时 ReflectValue 是传入的映射，先指向模型，钩子才能作用于模型
+	db.Callback().Update().Before("gorm:update").Register("gorm:setup_reflect_value", callbacks.SetupUpdateReflectValue)
+	db.Callback().Update().After("gorm:setup_reflect_value").Before("gorm:update").Register("gorm:before_update", callbacks.BeforeUpdate)
+	db.Callback().Update().After("gorm:update").Register("gorm:after_update", callbacks.AfterUpdate)
+
+	db.Callback().Delete().Before("gorm:delete").Register("gorm:before_delete", callbacks.BeforeDelete)
+	db.Callback().Delete().After("gorm:delete").Register("gorm:after_delete", callbacks.AfterDelete)
+
+	db.Callback().Query().After("gorm:preload").Register("gorm:after_query", callbacks.AfterQuery)
 }
 
 // createCallback 创建回调函数
@@ -248,6 +245,11 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 		return db.Error
 	}
 
+	// 只读模式下拒绝写入
+	if err := dialector.Config.CheckWritable("INSERT"); err != nil {
+		return err
+	}
+
 	// 检查模式是否存在
 	if db.Statement.Schema == nil {
 
@@ -263,6 +265,13 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 	// 获取表名和表 ID
 	tableName := db.Statement.Table
 
+	// 开启 AutoCreateSchema 时先创建不存在的表和字段
+	if dialector.Config.AutoCreateSchema {
+		if err := ensureTableSchema(statementContext(db), dialector, tableName, modelColumns(db.Statement.Schema)); err != nil {
+			return err
+		}
+	}
+
 	tableID, err := getTableID(dialector, tableName)
 	if err != nil {
 
@@ -282,47 +291,17 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 		fieldMap[f.FieldName] = f
 	}
 
-	// 获取字段值并进行类型转换
-	fields := make(map[string]interface{})
-	for _, field := range db.Statement.Schema.Fields {
-		// 跳过主键、自增字段和自动时间字段
-		if field.PrimaryKey || field.AutoIncrement {
-			continue
-		}
-
-		// 跳过自动时间字段，这些字段应该由飞书系统自动处理
-		if field.AutoCreateTime == schema.UnixTime || field.AutoUpdateTime == schema.UnixTime {
-			continue
-		}
-
-		// 尝试获取字段值
-		value, ok := field.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
-
-		// 如果 ValueOf 失败，尝试直接从反射值获取
-		if !ok || value == nil {
-			value, ok = extractFieldValueByReflection(db.Statement.ReflectValue, field)
-		}
-
-		// 如果仍然无法获取值，跳过该字段
-		if !ok || value == nil {
-			continue
-		}
+	// 业务主键为空时由客户端生成
+	if err := fillBusinessKey(db.Statement.Context, dialector.Config, db.Statement.Schema, db.Statement.ReflectValue); err != nil {
+		return err
+	}
 
-		// 对于时间类型，如果是零值时间，跳过该字段
-		if t, ok := value.(time.Time); ok && t.IsZero() {
-			continue
-		}
+	// 获取字段值并进行类型转换
+	fields := modelFieldValues(db.Statement.Context, db.Statement.Schema, db.Statement.ReflectValue, fieldMap)
 
-		// 使用字段的转换方法进行类型转换
-		if tableField, exists := fieldMap[field.DBName]; exists {
-			convertedValue := tableField.ConvertFromGoValue(value)
-			if convertedValue != nil {
-				fields[field.DBName] = convertedValue
-			}
-		} else {
-			// 如果找不到字段信息，直接使用原值（向后兼容）
-			fields[field.DBName] = value
-		}
+	// 检查校验规则，先于空记录检查，零值字段被跳过时逐个报告缺少的必填字段
+	if err := dialector.validateWrite(tableName, db.Statement.Schema, fieldMap, fields, false); err != nil {
+		return err
 	}
 
 	// 检查是否有字段需要创建
@@ -339,6 +318,21 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 	ctx, cancel := context.WithTimeout(db.Statement.Context, 30*time.Second)
 	defer cancel()
 
+	// ON CONFLICT：冲突列（默认为业务主键）已存在时按子句更新或跳过已有记录，不再创建
+	if found, err := createOnConflict(ctx, db, dialector, tableID, fieldMap, fields); found || err != nil {
+		return err
+	}
+
+	// 检查声明为 unique 的字段
+	if err := checkUniqueFields(ctx, dialector, tableID, db.Statement.Schema, fields); err != nil {
+		return err
+	}
+
+	// 事务中开启写入缓冲时，提交事务时再批量写入
+	if buffered, err := bufferCreate(db, dialector, tableID, fields); buffered || err != nil {
+		return err
+	}
+
 	// 调用飞书 API
 	apiReq := &APIRequest{
 		Method: "POST",
@@ -346,7 +340,7 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 		Body:   req,
 	}
 
-	resp, err := dialector.Client.DoRequest(ctx, apiReq)
+	resp, err := dialector.api().DoRequest(ctx, apiReq)
 	if err != nil {
 
 		return fmt.Errorf("创建记录 API 调用失败: %w", err)
@@ -392,45 +386,62 @@ func createCallback(db *gorm.DB, dialector *Dialector) error {
 	// 设置影响的行数
 	db.RowsAffected = 1
 
+	if target, ok := writeTarget(db.Statement); ok {
+		returnRecords(db, dialector, tableID, map[string]reflect.Value{createResp.Record.RecordID: target})
+	}
+
 	return nil
 }
 
-// extractFieldValueByReflection 通过反射提取字段值
-// 这是一个辅助函数，当 GORM 的 ValueOf 方法失败时使用
+// modelFieldValues 获取模型中需要写入的字段值，并按表字段的类型转换
+// 主键、自增字段、关系字段、自动时间字段和空值不写入
 // 参数:
-//   - reflectValue: 反射值
-//   - field: GORM 字段定义
+//   - ctx: 上下文
+//   - sch: 模型结构
+//   - reflectValue: 模型的反射值
+//   - fieldMap: 表字段名到字段信息的映射
 //
 // 返回:
-//   - interface{}: 字段值
-//   - bool: 是否成功获取值
-func extractFieldValueByReflection(reflectValue reflect.Value, field *schema.Field) (interface{}, bool) {
-	var structValue reflect.Value
-
-	// 处理指针类型
-	if reflectValue.Kind() == reflect.Ptr {
-		if reflectValue.IsNil() {
-			return nil, false
-		}
-		structValue = reflectValue.Elem()
-	} else if reflectValue.Kind() == reflect.Struct {
-		structValue = reflectValue
-	} else {
-		return nil, false
-	}
+//   - map[string]interface{}: 字段名到写入值的映射
+func modelFieldValues(ctx context.Context, sch *schema.Schema, reflectValue reflect.Value, fieldMap map[string]*Field) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, field := range sch.Fields {
+		// 跳过主键、自增字段和关系字段
+		if field.PrimaryKey || field.AutoIncrement || field.DBName == "" {
+			continue
+		}
 
-	// 检查结构体是否有效
-	if !structValue.IsValid() || structValue.Kind() != reflect.Struct {
-		return nil, false
-	}
+		// 跳过自动时间字段，这些字段应该由飞书系统自动处理
+		if isRecordTimeField(field) {
+			continue
+		}
 
-	// 通过字段名获取字段值
-	fieldValue := structValue.FieldByName(field.Name)
-	if !fieldValue.IsValid() || !fieldValue.CanInterface() {
-		return nil, false
-	}
+		// 获取字段值，嵌入结构体中的字段由 ValueOf 按字段路径读取，字段名带有 embeddedPrefix
+		value, _ := field.ValueOf(ctx, reflectValue)
+		if value = fieldWriteValue(field, value); value == nil {
+			continue
+		}
+
+		// 对于时间类型，如果是零值时间，跳过该字段
+		if t, ok := value.(time.Time); ok && t.IsZero() {
+			continue
+		}
 
-	return fieldValue.Interface(), true
+		// 使用字段的转换方法进行类型转换，自动编号、公式等只读字段由飞书计算，不写入
+		if tableField, exists := fieldMap[field.DBName]; exists {
+			if tableField.IsReadOnly() {
+				continue
+			}
+			convertedValue := tableField.ConvertFromGoValue(value)
+			if convertedValue != nil {
+				fields[field.DBName] = convertedValue
+			}
+		} else {
+			// 如果找不到字段信息，直接使用原值（向后兼容）
+			fields[field.DBName] = value
+		}
+	}
+	return fields
 }
 
 // 使用公共的 SQLCommand 类型
@@ -464,25 +475,32 @@ func rawCallback(db *gorm.DB, dialector *Dialector) error {
 		return fmt.Errorf("SQL 语句不能为空")
 	}
 
-	// 解析 SQL 语句
-	cmd, err := parseRawSQL(rawSQL)
+	// 解析 SQL 语句并绑定占位符参数
+	cmd, err := parseRawSQLWithVars(rawSQL, db.Statement.Vars)
 	if err != nil {
 		return common.FormatError("解析 SQL 语句失败", err)
 	}
 
+	// 只读模式下只允许 SELECT
+	if cmd.Type != "SELECT" {
+		if err := dialector.Config.CheckWritable(string(cmd.Type)); err != nil {
+			return err
+		}
+	}
+
 	// 根据命令类型执行相应操作
 	switch cmd.Type {
 	case "SELECT":
-		// SELECT查询由Row回调处理，这里只做验证
-		if cmd.Table == "" {
-			return fmt.Errorf("表名不能为空")
-		}
-		// 验证表是否存在
-		_, err := getTableID(dialector, cmd.Table)
+		rs, err := executeRawSelect(db, dialector, cmd)
 		if err != nil {
-			return fmt.Errorf("表不存在: %w", err)
+			return err
 		}
-		return nil
+		db.RowsAffected = int64(len(rs.Rows))
+		// db.Exec 没有扫描目标时只返回影响行数
+		if db.Statement.Dest == nil {
+			return nil
+		}
+		return scanResultSet(db, rs)
 	case "UPDATE":
 		return executeRawUpdate(db, dialector, cmd)
 	case "INSERT":
@@ -511,6 +529,19 @@ func parseRawSQL(sql string) (*SQLCommand, error) {
 	// 转换为大写进行匹配
 	upperSQL := strings.ToUpper(sql)
 
+	// EXPLAIN SELECT 只返回执行计划，不读取记录
+	if strings.HasPrefix(upperSQL, "EXPLAIN ") {
+		explained, err := parseRawSQL(strings.TrimSpace(sql[len("EXPLAIN "):]))
+		if err != nil {
+			return nil, err
+		}
+		if explained.Type != "SELECT" {
+			return nil, fmt.Errorf("EXPLAIN 只支持 SELECT 语句，当前为: %s", explained.Type)
+		}
+		explained.Explain = true
+		return explained, nil
+	}
+
 	switch {
 	case strings.HasPrefix(upperSQL, "SELECT"):
 		cmd.Type = "SELECT"
@@ -529,6 +560,166 @@ func parseRawSQL(sql string) (*SQLCommand, error) {
 	}
 }
 
+// rawVarTokenPrefix 占位符替换标记的前缀
+// 解析前先把 ? 替换为唯一标记，解析后再把标记还原为参数值，避免参数中的逗号、等号干扰 SQL 解析
+const rawVarTokenPrefix = "__basesql_var_"
+
+// parseRawSQLWithVars 解析带 ? 占位符的原生 SQL 语句
+// SET 与 VALUES 中的占位符绑定为原始类型的参数值，WHERE 中的占位符替换为 SQL 字面量
+// 参数:
+//   - sql: 原生 SQL 语句
+//   - vars: 占位符参数，按出现顺序绑定
+//
+// 返回:
+//   - *SQLCommand: 解析并绑定参数后的命令
+//   - error: 解析错误或参数数量不匹配
+func parseRawSQLWithVars(sql string, vars []interface{}) (*SQLCommand, error) {
+	if len(vars) == 0 {
+		return parseRawSQL(sql)
+	}
+
+	tokenized, tokens, err := replacePlaceholders(sql, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := parseRawSQL(tokenized)
+	if err != nil {
+		return nil, err
+	}
+
+	bindValue := func(value interface{}) interface{} {
+		if str, ok := value.(string); ok {
+			if bound, exists := tokens[str]; exists {
+				return bound
+			}
+		}
+		return value
+	}
+	for _, values := range []map[string]interface{}{cmd.Values, cmd.UpdateFields, cmd.Condition} {
+		for key, value := range values {
+			values[key] = bindValue(value)
+		}
+	}
+
+	bindLiterals := func(text string) string {
+		for token, value := range tokens {
+			text = strings.ReplaceAll(text, token, formatSQLLiteral(value))
+		}
+		return text
+	}
+	cmd.Where = bindLiterals(cmd.Where)
+	cmd.RawSQL = bindLiterals(cmd.RawSQL)
+
+	return cmd, nil
+}
+
+// replacePlaceholders 将引号外的 ? 占位符替换为唯一标记
+// 返回替换后的 SQL 以及标记到参数值的映射
+func replacePlaceholders(sql string, vars []interface{}) (string, map[string]interface{}, error) {
+	var builder strings.Builder
+	tokens := make(map[string]interface{}, len(vars))
+	var quote rune
+	index := 0
+
+	for _, char := range sql {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		case char == '?':
+			if index >= len(vars) {
+				return "", nil, fmt.Errorf("SQL 占位符数量多于参数数量(%d)", len(vars))
+			}
+			token := fmt.Sprintf("%s%d__", rawVarTokenPrefix, index)
+			tokens[token] = normalizeRawVar(vars[index])
+			builder.WriteString(token)
+			index++
+			continue
+		}
+		builder.WriteRune(char)
+	}
+
+	if index != len(vars) {
+		return "", nil, fmt.Errorf("SQL 占位符数量(%d)与参数数量(%d)不匹配", index, len(vars))
+	}
+	return builder.String(), tokens, nil
+}
+
+// normalizeRawVar 规范化占位符参数，driver.Valuer 会先取出其底层值
+func normalizeRawVar(value interface{}) interface{} {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// formatSQLLiteral 将参数值格式化为 SQL 字面量
+func formatSQLLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return common.QuoteSQLString(v)
+	case []byte:
+		return common.QuoteSQLString(string(v))
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// whereLiteralPrefix WHERE 子句中字符串字面量替换标记的前缀
+const whereLiteralPrefix = "__basesql_lit_"
+
+// whereValuePattern WHERE 条件中的值：完整的字面量标记，或不含空白和引号的未加引号的值
+const whereValuePattern = `(` + whereLiteralPrefix + `\d+__|[^\s'"]+)`
+
+// maskWhereLiterals 将 WHERE 子句中的引号字面量替换为唯一标记
+// 匹配操作符前先替换，字面量中的运算符、引号和关键字不会被当作条件解析；单引号字面量中连续的两个单引号表示一个单引号
+// 返回:
+//   - string: 替换后的 WHERE 子句
+//   - map[string]string: 标记到去掉引号后的字面量的映射
+func maskWhereLiterals(where string) (string, map[string]string) {
+	var builder strings.Builder
+	literals := make(map[string]string)
+	runes := []rune(where)
+	for i := 0; i < len(runes); i++ {
+		quote := runes[i]
+		if quote != '\'' && quote != '"' {
+			builder.WriteRune(quote)
+			continue
+		}
+		var literal strings.Builder
+		for i++; i < len(runes); i++ {
+			if runes[i] == quote {
+				if quote == '\'' && i+1 < len(runes) && runes[i+1] == '\'' {
+					literal.WriteRune('\'')
+					i++
+					continue
+				}
+				break
+			}
+			literal.WriteRune(runes[i])
+		}
+		token := fmt.Sprintf("%s%d__", whereLiteralPrefix, len(literals))
+		literals[token] = literal.String()
+		builder.WriteString(token)
+	}
+	return builder.String(), literals
+}
+
 // parseUpdateSQL 解析 UPDATE 命令
 func parseUpdateSQL(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 	cmd.Type = "UPDATE"
@@ -567,7 +758,7 @@ func parseUpdateSQL(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 		}
 		field := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
-		value = strings.Trim(value, "'\"") // 移除引号
+		value, _ = common.UnquoteSQLString(value) // 移除引号
 		cmd.Values[field] = parseValue(value)
 	}
 
@@ -609,7 +800,7 @@ func executeRawUpdate(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 	}
 
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(statementContext(db), 30*time.Second)
 	defer cancel()
 
 	// 获取表 ID
@@ -620,9 +811,9 @@ func executeRawUpdate(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 
 	// 获取表字段信息并转换字段值
 	tableFieldsList, err := getTableFields(dialector, cmd.Table)
+	tableFields := make(map[string]*Field)
 	if err == nil {
 		// 将字段列表转换为map以便查找
-		tableFields := make(map[string]*Field)
 		for _, tableField := range tableFieldsList {
 			tableFields[tableField.FieldName] = tableField
 		}
@@ -630,7 +821,7 @@ func executeRawUpdate(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 		// 转换字段值
 		for fieldName, value := range cmd.Values {
 			if tableField, exists := tableFields[fieldName]; exists {
-				cmd.Values[fieldName] = fmt.Sprintf("%v", tableField.ConvertFromGoValue(value))
+				cmd.Values[fieldName] = tableField.ConvertFromGoValue(value)
 			}
 		}
 	} else {
@@ -644,127 +835,107 @@ func executeRawUpdate(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 		fields[field] = value
 	}
 
-	if cmd.Where == "" {
-		// 没有 WHERE 条件，更新所有记录（符合 SQL 标准）
-
-		// 首先获取所有记录
-		apiReq := &APIRequest{
-			Method: "GET",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", dialector.Config.AppToken, tableID),
-		}
-
-		resp, err := dialector.Client.DoRequest(ctx, apiReq)
-		if err != nil {
-			return fmt.Errorf("获取所有记录失败: %w", err)
-		}
-
-		var listResp ListRecordsResponse
-		if err := json.Unmarshal(resp.Body, &listResp); err != nil {
-			return fmt.Errorf("解析记录列表响应失败: %w", err)
-		}
-
-		if len(listResp.Items) == 0 {
-			db.RowsAffected = 0
-			return nil
-		}
-
-		// 逐条更新所有记录（避免批量更新 API 格式问题）
-		updateReq := &UpdateRecordRequest{
-			Fields: fields,
-		}
-
-		var successCount int64
-		for _, record := range listResp.Items {
-			apiReq := &APIRequest{
-				Method: "PUT",
-				Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
-				Body:   updateReq,
-			}
-
-			_, err = dialector.Client.DoRequest(ctx, apiReq)
-			if err != nil {
-				return fmt.Errorf("更新记录 %s 失败: %w", record.RecordID, err)
-			}
-			successCount++
-		}
+	// 检查校验规则
+	if err := dialector.validateWrite(cmd.Table, nil, tableFields, fields, true); err != nil {
+		return err
+	}
 
-		db.RowsAffected = successCount
-	} else {
-		// 有 WHERE 条件，先查询符合条件的记录，然后更新
-		filter := buildFilterFromWhere(cmd.Where)
+	// 查询需要更新的记录，没有 WHERE 条件时更新所有记录（符合 SQL 标准）
+	records, err := findRawTargetRecords(ctx, dialector, tableID, cmd.Where)
+	if err != nil {
+		return err
+	}
 
-		// 构建查询请求体
-		listReq := &ListRecordsRequest{
-			Filter: filter,
-		}
+	// 逐条更新记录（避免批量更新 API 格式问题）
+	updateReq := &UpdateRecordRequest{
+		Fields: fields,
+	}
 
+	var successCount int64
+	for _, record := range records {
 		apiReq := &APIRequest{
-			Method: "POST",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/search", dialector.Config.AppToken, tableID),
-			Body:   listReq,
+			Method: "PUT",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
+			Body:   updateReq,
 		}
 
-		resp, err := dialector.Client.DoRequest(ctx, apiReq)
+		_, err = dialector.api().DoRequest(ctx, apiReq)
 		if err != nil {
-			return fmt.Errorf("查询符合条件的记录失败: %w", err)
-		}
-
-		var listResp ListRecordsResponse
-		if err := json.Unmarshal(resp.Body, &listResp); err != nil {
-			return fmt.Errorf("解析查询响应失败: %w", err)
-		}
-
-		if len(listResp.Items) == 0 {
-			db.RowsAffected = 0
-			return nil
+			return fmt.Errorf("更新记录 %s 失败: %w", record.RecordID, err)
 		}
+		successCount++
+	}
 
-		// 逐条更新符合条件的记录
-		updateReq := &UpdateRecordRequest{
-			Fields: fields,
-		}
+	db.RowsAffected = successCount
+	return nil
+}
 
-		var successCount int64
-		for _, record := range listResp.Items {
-			apiReq := &APIRequest{
-				Method: "PUT",
-				Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
-				Body:   updateReq,
-			}
+// findRawTargetRecords 查询原生 UPDATE/DELETE 语句作用的记录
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 方言实例
+//   - tableID: 表 ID
+//   - where: WHERE 条件字符串，为空时返回所有记录
+//
+// 返回:
+//   - []*Record: 符合条件的记录
+//   - error: 查询错误；WHERE 条件无法解析时返回错误，避免误操作全表
+func findRawTargetRecords(ctx context.Context, dialector *Dialector, tableID, where string) ([]*Record, error) {
+	filter, like := buildFilterFromWhere(where, dialector.Config.caseInsensitive())
+	if where != "" && filter == nil && like == nil {
+		return nil, fmt.Errorf("无法解析 WHERE 条件: %s", where)
+	}
 
-			_, err = dialector.Client.DoRequest(ctx, apiReq)
-			if err != nil {
-				return fmt.Errorf("更新记录 %s 失败: %w", record.RecordID, err)
-			}
-			successCount++
+	if like != nil && !like.exact {
+		records, err := searchAllRecords(ctx, dialector, tableID, &ListRecordsRequest{Filter: filter})
+		if err != nil {
+			return nil, fmt.Errorf("查询符合条件的记录失败: %w", err)
 		}
-
-		db.RowsAffected = successCount
+		return filterLikeRecords(records, []*likeMatcher{like}), nil
 	}
 
-	return nil
+	listResp, err := searchRecords(ctx, dialector, tableID, &ListRecordsRequest{Filter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("查询符合条件的记录失败: %w", err)
+	}
+	return listResp.Items, nil
 }
 
 // buildFilterFromWhere 从 WHERE 条件构建过滤器
 // 这个函数负责将 SQL WHERE 子句转换为飞书多维表格的过滤条件格式
-// 支持的操作符：=、!=、>、>=、<、<=、LIKE
+// 支持的操作符：=、!=、>、>=、<、<=、LIKE，LIKE 的下推方式见 likeMatcher，日期宏和相对时间见 buildDateCondition
 // 参数:
 //   - whereClause: WHERE 子句字符串
+//   - fold: 字符串比较是否不区分大小写
 //
 // 返回:
-//   - *FilterRequest: 飞书多维表格的过滤请求，如果无法解析则返回 nil
-func buildFilterFromWhere(whereClause string) *FilterRequest {
+//   - *FilterRequest: 飞书多维表格的过滤请求，如果无法解析或没有可下推的条件则返回 nil
+//   - *likeMatcher: 条件为 LIKE、ILIKE 或不区分大小写的等于时的执行计划，其他条件为 nil
+func buildFilterFromWhere(whereClause string, fold bool) (*FilterRequest, *likeMatcher) {
 	if whereClause == "" {
-		return nil
+		return nil, nil
 	}
 
 	// 清理 WHERE 子句
 	whereClause = strings.TrimSpace(whereClause)
 	if whereClause == "" {
-		return nil
+		return nil, nil
+	}
+	whereClause, literals := maskWhereLiterals(whereClause)
+	unquote := func(value string) string {
+		if literal, ok := literals[value]; ok {
+			return literal
+		}
+		return value
+	}
+
+	// 以日期宏或相对时间为值的条件，如 due_date = TODAY()、modified_at > NOW() - INTERVAL 7 DAY，按飞书的日期筛选下推
+	if condition := buildDateCondition(whereClause); condition != nil {
+		return &FilterRequest{Conjunction: "and", Conditions: []*FilterCondition{condition}}, nil
 	}
 
 	var conditions []*FilterCondition
+	var like *likeMatcher
 
 	// 支持多种操作符的正则表达式
 	operatorPatterns := []struct {
@@ -773,13 +944,14 @@ func buildFilterFromWhere(whereClause string) *FilterRequest {
 	}{
 		{`(?i)(\w+)\s+IS\s+NOT\s+NULL`, "isNotEmpty"},
 		{`(?i)(\w+)\s+IS\s+NULL`, "isEmpty"},
-		{`(?i)(\w+)\s*!=\s*['"]*([^'"]+)['"]*`, "isNot"},
-		{`(?i)(\w+)\s*>=\s*['"]*([^'"]+)['"]*`, "isGreaterEqual"},
-		{`(?i)(\w+)\s*<=\s*['"]*([^'"]+)['"]*`, "isLessEqual"},
-		{`(?i)(\w+)\s*>\s*['"]*([^'"]+)['"]*`, "isGreater"},
-		{`(?i)(\w+)\s*<\s*['"]*([^'"]+)['"]*`, "isLess"},
-		{`(?i)(\w+)\s*=\s*['"]*([^'"]+)['"]*`, "is"},
-		{`(?i)(\w+)\s+LIKE\s+['"]*([^'"]+)['"]*`, "contains"},
+		{`(?i)(\w+)\s*!=\s*` + whereValuePattern, "isNot"},
+		{`(?i)(\w+)\s*>=\s*` + whereValuePattern, "isGreaterEqual"},
+		{`(?i)(\w+)\s*<=\s*` + whereValuePattern, "isLessEqual"},
+		{`(?i)(\w+)\s*>\s*` + whereValuePattern, "isGreater"},
+		{`(?i)(\w+)\s*<\s*` + whereValuePattern, "isLess"},
+		{`(?i)(\w+)\s*=\s*` + whereValuePattern, "is"},
+		{`(?i)(\w+)\s+ILIKE\s+` + whereValuePattern, "ILIKE"},
+		{`(?i)(\w+)\s+LIKE\s+` + whereValuePattern, "LIKE"},
 		{`(?i)(\w+)\s+IN\s*\(([^)]+)\)`, "isAnyOf"},
 	}
 
@@ -815,6 +987,23 @@ func buildFilterFromWhere(whereClause string) *FilterRequest {
 			if value == "" {
 				continue
 			}
+			if op.operator != "isAnyOf" {
+				value = unquote(value)
+			}
+
+			// LIKE、ILIKE 按通配符位置下推，不区分大小写时字符串的等于条件同样处理，无法完全下推时由客户端校验
+			switch {
+			case op.operator == "LIKE" || op.operator == "ILIKE":
+				like = newLikeMatcher(op.operator, field, value, fold || op.operator == "ILIKE")
+			case op.operator == "is" && fold && !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false"):
+				like = newEqualFoldMatcher(field, value)
+			}
+			if like != nil {
+				if like.pushdown != nil {
+					conditions = append(conditions, like.pushdown)
+				}
+				break
+			}
 
 			// 处理不同操作符的值
 			var values []interface{}
@@ -824,9 +1013,7 @@ func buildFilterFromWhere(whereClause string) *FilterRequest {
 				// 分割逗号分隔的值列表
 				valueList := strings.Split(value, ",")
 				for _, v := range valueList {
-					v = strings.TrimSpace(v)
-					// 移除引号
-					v = strings.Trim(v, "'\"")
+					v = unquote(strings.TrimSpace(v))
 					if v != "" {
 						// 转换值类型
 						if strings.ToLower(v) == "true" {
@@ -840,12 +1027,6 @@ func buildFilterFromWhere(whereClause string) *FilterRequest {
 				}
 			} else {
 				// 处理单个值的操作符
-				if op.operator == "contains" {
-					// 移除 SQL LIKE 的通配符 % 和 _
-					value = strings.ReplaceAll(value, "%", "")
-					value = strings.ReplaceAll(value, "_", "")
-				}
-
 				// 转换值类型，特别处理布尔值
 				var convertedValue interface{}
 				if strings.ToLower(value) == "true" {
@@ -873,13 +1054,13 @@ func buildFilterFromWhere(whereClause string) *FilterRequest {
 	}
 
 	if len(conditions) == 0 {
-		return nil
+		return nil, like
 	}
 
 	return &FilterRequest{
 		Conjunction: "and",
 		Conditions:  conditions,
-	}
+	}, like
 }
 
 // parseSelectSQL 解析SELECT语句
@@ -897,22 +1078,226 @@ func parseDeleteSQL(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 	return common.DefaultSQLParser.ParseDeleteSQL(sql, cmd)
 }
 
-func executeRawSelect(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error {
-	if cmd.Table == "" {
-		return fmt.Errorf("表名不能为空")
-	}
-
+// executeRawSelect 执行原生 SELECT 命令
+// 解析后的字段列表决定结果列，WHERE 条件转换为飞书过滤条件，LIMIT 在客户端截断
+// 支持 COUNT、SUM、AVG、MIN、MAX 单个聚合函数
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 方言实例
+//   - cmd: 解析后的 SQL 命令
+//
+// 返回:
+//   - *ResultSet: 查询结果集
+//   - error: 执行过程中的错误
+func executeRawSelect(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) (*ResultSet, error) {
+	if cmd.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+
 	// 验证表是否存在
-	_, err := getTableID(dialector, cmd.Table)
+	tableID, err := getTableID(dialector, cmd.Table)
+	if err != nil {
+		return nil, fmt.Errorf("表不存在: %w", err)
+	}
+
+	if len(cmd.GroupBy) > 0 {
+		return nil, fmt.Errorf("原生 SELECT 不支持 GROUP BY，请使用 CLI 执行分组查询")
+	}
+
+	filter, like := buildFilterFromWhere(cmd.Where, dialector.Config.caseInsensitive())
+	req := &ListRecordsRequest{Filter: filter}
+	if len(cmd.OrderBy) > 0 {
+		req.Sort = cmd.OrderBy
+	}
+	// 配置了排序规则或不区分大小写时在客户端排序，需要先读取全部分页
+	var compare textComparer
+	if len(req.Sort) > 0 {
+		compare = sortComparer(db, dialector.Config)
+	}
+	if cmd.Explain {
+		return explainRawSelect(dialector, tableID, cmd, req, like, compare != nil)
+	}
+
+	var matchers []*likeMatcher
+	if like != nil && !like.exact {
+		matchers = []*likeMatcher{like}
+	}
+	var records []*Record
+	switch {
+	case compare != nil && !cmd.IsAggregate:
+		records, err = sortedResultRecords(statementContext(db), dialector, tableID, req, matchers, compare, cmd.Limit)
+	case cmd.IsAggregate:
+		// 聚合需要全部满足条件的记录，不受 MaxResultRows 限制，截断输入会得到错误的统计值
+		records, err = readAllPages(statementContext(db), dialector, tableID, req, matchers, 0)
+	case cmd.Limit == 0 || len(matchers) > 0:
+		// 没有 LIMIT 或客户端校验 LIKE 时读取全部分页，避免只返回第一页
+		records, err = searchResultRecords(statementContext(db), dialector, tableID, req, matchers)
+	default:
+		// 有 LIMIT 时读取到满足数量为止
+		records, err = searchLimitedRecords(statementContext(db), dialector, tableID, req, cmd.Limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.IsAggregate {
+		return aggregateResultSet(dialector, cmd, records)
+	}
+	if cmd.Limit > 0 && len(records) > cmd.Limit {
+		records = records[:cmd.Limit]
+	}
+
+	var columns []string
+	for _, field := range cmd.Fields {
+		if field != "*" {
+			columns = append(columns, field)
+		}
+	}
+	return recordsToResultSet(db.Statement, dialector, cmd.Table, columns, records)
+}
+
+// explainRawSelect 返回原生 SELECT 的执行计划，不读取记录
+// 结果集每行为一个步骤：request 为发出的 API 请求，filter 为下推到服务端的过滤条件，
+// like 为 LIKE 条件的执行方式（服务端下推或客户端校验），sort 为排序条件及排序位置，limit 为客户端截断的行数
+// 参数:
+//   - dialector: BaseSQL 方言实例
+//   - tableID: 表 ID
+//   - cmd: 解析后的 SQL 命令
+//   - req: 将要发出的查询请求
+//   - like: LIKE 条件的执行计划，没有 LIKE 条件时为 nil
+//   - clientSort: 是否在客户端排序
+//
+// 返回:
+//   - *ResultSet: 列为 step、detail 的执行计划
+//   - error: 序列化过滤条件时的错误
+func explainRawSelect(dialector *Dialector, tableID string, cmd *SQLCommand, req *ListRecordsRequest, like *likeMatcher, clientSort bool) (*ResultSet, error) {
+	rs := NewResultSet([]string{"step", "detail"})
+	path := fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", dialector.Config.AppToken, tableID)
+	switch {
+	case clientSort || cmd.IsAggregate || cmd.Limit == 0 || (like != nil && !like.exact):
+		rs.AppendRow("request", "POST "+path+"/search（读取全部分页）")
+	case req.Filter != nil || len(req.Sort) > 0:
+		rs.AppendRow("request", "POST "+path+"/search")
+	default:
+		rs.AppendRow("request", "GET "+path)
+	}
+	if req.Filter != nil {
+		filter, err := json.Marshal(req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		rs.AppendRow("filter", string(filter))
+	}
+	if like != nil {
+		rs.AppendRow("like", like.explain())
+	}
+	if len(req.Sort) > 0 {
+		detail := strings.Join(req.Sort, ", ")
+		if clientSort {
+			detail += "（客户端排序）"
+		}
+		rs.AppendRow("sort", detail)
+	}
+	if cmd.Limit > 0 {
+		rs.AppendRow("limit", fmt.Sprintf("客户端截断为 %d 行", cmd.Limit))
+	}
+	return rs, nil
+}
+
+// rawSelectResultSet 解析语句中的原生 SELECT 并返回结果集
+// 用于 db.Raw("SELECT ...") 配合 Scan、Find、Rows 等方法读取数据
+func rawSelectResultSet(db *gorm.DB, dialector *Dialector) (*ResultSet, error) {
+	cmd, err := parseRawSQLWithVars(db.Statement.SQL.String(), db.Statement.Vars)
 	if err != nil {
-		return fmt.Errorf("表不存在: %w", err)
+		return nil, common.FormatError("解析 SQL 语句失败", err)
+	}
+	if cmd.Type != "SELECT" {
+		return nil, fmt.Errorf("查询方法只支持 SELECT 语句，当前为: %s", cmd.Type)
 	}
+	return executeRawSelect(db, dialector, cmd)
+}
 
-	// Raw SELECT 查询暂不支持，建议使用 GORM 的标准查询方法
-	return fmt.Errorf("Raw SELECT 查询暂不支持，请使用 db.Where().Find() 等 GORM 标准查询方法")
+// aggregateResultSet 计算聚合函数并返回单行结果集
+func aggregateResultSet(dialector *Dialector, cmd *SQLCommand, records []*Record) (*ResultSet, error) {
+	column := fmt.Sprintf("%s(%s)", strings.ToLower(cmd.AggregateFunction), cmd.AggregateField)
+	rs := NewResultSet([]string{column})
+
+	if cmd.AggregateFunction == "COUNT" {
+		count := int64(0)
+		for _, record := range records {
+			if cmd.AggregateField == "*" || record.Fields[cmd.AggregateField] != nil {
+				count++
+			}
+		}
+		rs.AppendRow(count)
+		return rs, nil
+	}
+
+	if cmd.AggregateField == "*" {
+		return nil, fmt.Errorf("%s 函数不支持 * 参数", cmd.AggregateFunction)
+	}
+
+	var tableField *Field
+	if fields, err := getTableFields(dialector, cmd.Table); err == nil {
+		for _, field := range fields {
+			if field.FieldName == cmd.AggregateField {
+				tableField = field
+				break
+			}
+		}
+	}
+	if tableField == nil {
+		return nil, fmt.Errorf("字段 %s 不存在", cmd.AggregateField)
+	}
+
+	var values []float64
+	for _, record := range records {
+		value, ok := tableField.ConvertToGoValue(record.Fields[cmd.AggregateField]).(float64)
+		if ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		rs.AppendRow(nil)
+		return rs, nil
+	}
+
+	result := values[0]
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+		switch {
+		case cmd.AggregateFunction == "MIN" && value < result:
+			result = value
+		case cmd.AggregateFunction == "MAX" && value > result:
+			result = value
+		}
+	}
+	switch cmd.AggregateFunction {
+	case "SUM":
+		result = sum
+	case "AVG":
+		result = sum / float64(len(values))
+	case "MIN", "MAX":
+	default:
+		return nil, fmt.Errorf("不支持的聚合函数: %s", cmd.AggregateFunction)
+	}
+	rs.AppendRow(result)
+	return rs, nil
 }
 
 func executeRawInsert(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error {
+	// 开启 AutoCreateSchema 时先创建不存在的表和字段
+	if dialector.Config.AutoCreateSchema {
+		columns := make(map[string]FieldType, len(cmd.Values))
+		for name, value := range cmd.Values {
+			columns[name] = inferFieldType(value)
+		}
+		if err := ensureTableSchema(statementContext(db), dialector, cmd.Table, columns); err != nil {
+			return err
+		}
+	}
+
 	// 获取表 ID
 	tableID, err := getTableID(dialector, cmd.Table)
 	if err != nil {
@@ -921,9 +1306,9 @@ func executeRawInsert(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 
 	// 获取表字段信息并转换字段值
 	tableFieldsList, err := getTableFields(dialector, cmd.Table)
+	tableFields := make(map[string]*Field)
 	if err == nil {
 		// 将字段列表转换为map以便查找
-		tableFields := make(map[string]*Field)
 		for _, tableField := range tableFieldsList {
 			tableFields[tableField.FieldName] = tableField
 		}
@@ -943,6 +1328,11 @@ func executeRawInsert(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 		fields[field] = value
 	}
 
+	// 检查校验规则
+	if err := dialector.validateWrite(cmd.Table, nil, tableFields, fields, false); err != nil {
+		return err
+	}
+
 	// 创建记录
 	createReq := &CreateRecordRequest{
 		Fields: fields,
@@ -954,7 +1344,7 @@ func executeRawInsert(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 		Body:   createReq,
 	}
 
-	_, err = dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err = dialector.api().DoRequest(statementContext(db), apiReq)
 	if err != nil {
 		return err
 	}
@@ -970,95 +1360,65 @@ func executeRawDelete(db *gorm.DB, dialector *Dialector, cmd *SQLCommand) error
 		return err
 	}
 
-	if cmd.Where == "" {
-		// 没有 WHERE 条件，删除所有记录（符合 SQL 标准）
+	// 查询需要删除的记录，没有 WHERE 条件时删除所有记录（符合 SQL 标准）
+	records, err := findRawTargetRecords(db.Statement.Context, dialector, tableID, cmd.Where)
+	if err != nil {
+		return err
+	}
 
-		// 首先获取所有记录
+	// 逐条删除记录
+	for _, record := range records {
 		apiReq := &APIRequest{
-			Method: "GET",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", dialector.Config.AppToken, tableID),
+			Method: "DELETE",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
 		}
 
-		resp, err := dialector.Client.DoRequest(context.Background(), apiReq)
+		_, err = dialector.api().DoRequest(db.Statement.Context, apiReq)
 		if err != nil {
 			return err
 		}
+	}
 
-		var listResp ListRecordsResponse
-		if err := json.Unmarshal(resp.Body, &listResp); err != nil {
-			return err
-		}
-
-		if len(listResp.Items) == 0 {
-			db.RowsAffected = 0
-			return nil
-		}
+	db.RowsAffected = int64(len(records))
+	return nil
+}
 
-		// 逐条删除所有记录
-		for _, record := range listResp.Items {
-			apiReq := &APIRequest{
-				Method: "DELETE",
-				Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
-			}
+// orderColumnKeys 将 GORM 的排序列转换为飞书 API 的排序条件，降序的字段名前加 -
+// Order("owner ASC, due_date DESC") 这样的字符串会作为一个原始列传入，按逗号拆分为多个排序条件
+func orderColumnKeys(column clause.OrderByColumn) []string {
+	columnName := column.Column.Name
+	if columnName == "" {
+		return nil
+	}
+	items := []string{columnName}
+	if column.Column.Raw {
+		items = strings.Split(columnName, ",")
+	}
 
-			_, err = dialector.Client.DoRequest(context.Background(), apiReq)
-			if err != nil {
-				return err
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		name, desc := strings.TrimSpace(item), column.Desc
+		if i := strings.LastIndexAny(name, " \t"); i > 0 && column.Column.Raw {
+			switch strings.ToUpper(name[i+1:]) {
+			case "DESC":
+				name, desc = strings.TrimSpace(name[:i]), true
+			case "ASC":
+				name, desc = strings.TrimSpace(name[:i]), false
 			}
 		}
-
-		db.RowsAffected = int64(len(listResp.Items))
-	} else {
-		// 有 WHERE 条件，先查询符合条件的记录，然后删除
-		filter := buildFilterFromWhere(cmd.Where)
-
-		reqBody := &ListRecordsRequest{
-			Filter: filter,
-		}
-
-		bodyData, err := json.Marshal(reqBody)
-		if err != nil {
-			return err
-		}
-
-		apiReq := &APIRequest{
-			Method: "POST",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/search", dialector.Config.AppToken, tableID),
-			Body:   bodyData,
-		}
-
-		resp, err := dialector.Client.DoRequest(context.Background(), apiReq)
-		if err != nil {
-			return err
-		}
-
-		var listResp ListRecordsResponse
-		if err := json.Unmarshal(resp.Body, &listResp); err != nil {
-			return err
-		}
-
-		if len(listResp.Items) == 0 {
-			db.RowsAffected = 0
+		name = strings.Trim(name, "`\"")
+		// 验证字段名有效性，过滤掉无效的字段名
+		if !isValidFieldName(name) {
+			// 记录无效的字段名用于调试
+			common.Debugf("跳过无效的排序字段名: %q", columnName)
 			return nil
 		}
-
-		// 逐条删除符合条件的记录
-		for _, record := range listResp.Items {
-			apiReq := &APIRequest{
-				Method: "DELETE",
-				Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
-			}
-
-			_, err = dialector.Client.DoRequest(context.Background(), apiReq)
-			if err != nil {
-				return err
-			}
+		if desc {
+			name = "-" + name
 		}
-
-		db.RowsAffected = int64(len(listResp.Items))
+		keys = append(keys, name)
 	}
-
-	return nil
+	return keys
 }
 
 // isValidFieldName 验证字段名是否有效
@@ -1089,30 +1449,153 @@ func isValidFieldName(fieldName string) bool {
 }
 
 // queryCallback 查询回调
+// 结构体目标使用模型字段映射赋值，map、基础类型切片等其他目标通过内存结果集交给 gorm.Scan 处理
 func queryCallback(db *gorm.DB, dialector *Dialector) error {
 	if db.Error != nil {
 
 		return db.Error
 	}
 
-	if db.Statement.Schema == nil {
+	// db.Raw("SELECT ...").Find(&dest)
+	if db.Statement.SQL.Len() > 0 {
+		rs, err := rawSelectResultSet(db, dialector)
+		if err != nil {
+			return err
+		}
+		return scanResultSet(db, rs)
+	}
 
-		return fmt.Errorf("schema not found")
+	// Preload 读取关联字段的中间表时，由所属记录的关联字段展开
+	registerLinkRelations(db.Statement.Schema)
+	if link := lookupLinkRelation(db.Statement.Table); link != nil {
+		return queryLinkRecords(db, dialector, link)
+	}
+
+	listResp, err := fetchQueryRecords(db, dialector)
+	if err != nil {
+		return err
+	}
+
+	if !isModelDestination(db.Statement) {
+		rs, err := buildResultSet(db.Statement, dialector, listResp)
+		if err != nil {
+			return err
+		}
+		return scanResultSet(db, rs)
+	}
+
+	// 设置结果
+	if len(listResp.Items) > 0 {
+		// 判断是否是查询单个记录
+		if db.Statement.ReflectValue.Kind() == reflect.Slice {
+			// 查询多个记录
+			elemType := db.Statement.ReflectValue.Type().Elem()
+			sliceValue := reflect.MakeSlice(db.Statement.ReflectValue.Type(), 0, len(listResp.Items))
+			scanner, err := newRecordScanner(db.Statement.Schema, dialector)
+			if err != nil {
+				return err
+			}
+			for _, record := range listResp.Items {
+				elemValue := reflect.New(db.Statement.Schema.ModelType).Elem()
+				if err := scanner.scan(elemValue, record); err != nil {
+					return err
+				}
+				if elemType.Kind() == reflect.Ptr {
+					elemValue = elemValue.Addr()
+				}
+				sliceValue = reflect.Append(sliceValue, elemValue)
+			}
+			db.Statement.ReflectValue.Set(sliceValue)
+		} else {
+			// 查询单个记录
+			if err := setRecordToStruct(db.Statement.ReflectValue, listResp.Items[0], db.Statement.Schema, dialector); err != nil {
+				return err
+			}
+		}
+	}
+
+	db.RowsAffected = int64(len(listResp.Items))
+	return nil
+}
+
+// rowCallback 行查询回调
+// 处理 db.Row() 与 db.Rows()，将查询到的记录包装为标准的 *sql.Row / *sql.Rows
+func rowCallback(db *gorm.DB, dialector *Dialector) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	var rs *ResultSet
+	if db.Statement.SQL.Len() > 0 {
+		// db.Raw("SELECT ...").Rows() / Scan()
+		result, err := rawSelectResultSet(db, dialector)
+		if err != nil {
+			return err
+		}
+		rs = result
+	} else {
+		listResp, err := fetchQueryRecords(db, dialector)
+		if err != nil {
+			return err
+		}
+		if rs, err = buildResultSet(db.Statement, dialector, listResp); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if isRows, ok := db.Get("rows"); ok && isRows.(bool) {
+		db.Statement.Settings.Delete("rows")
+		db.Statement.Dest, err = rs.QueryRows(db.Statement.Context)
+		if err != nil {
+			return err
+		}
+	} else {
+		db.Statement.Dest = rs.QueryRow(db.Statement.Context)
 	}
 
+	db.RowsAffected = int64(len(rs.Rows))
+	return nil
+}
+
+// fetchQueryRecords 根据 GORM 语句的 WHERE、ORDER BY 子句查询记录
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//
+// 返回:
+//   - *ListRecordsResponse: 查询到的记录
+//   - error: 查询过程中的错误
+func fetchQueryRecords(db *gorm.DB, dialector *Dialector) (*ListRecordsResponse, error) {
 	// 获取表名和表 ID
 	tableName := db.Statement.Table
+	if tableName == "" {
+		return nil, fmt.Errorf("未指定查询的表名，请使用 Model() 或 Table()")
+	}
 
 	tableID, err := getTableID(dialector, tableName)
 	if err != nil {
 
-		return err
+		return nil, err
+	}
+
+	// 过滤和排序条件按字段名下推，先按字段 ID 检查字段是否改过名，请求中使用当前字段名
+	_, filtered := db.Statement.Clauses["WHERE"]
+	_, ordered := db.Statement.Clauses["ORDER BY"]
+	if client := dialector.baseClient(); (filtered || ordered) && client != nil && client.schemaIDs.tracksFields(tableID) {
+		_, _ = getTableFields(dialector, tableName)
 	}
 
 	// 构建查询请求
 	req := &ListRecordsRequest{
 		// 不指定字段名，让API返回所有字段（像CLI一样）
 		FieldNames: make([]string, 0),
+		// 模型有自动时间字段时请求记录的创建时间和最后修改时间
+		AutomaticFields: wantsRecordTimes(db.Statement.Schema),
+	}
+
+	if size, ok := pageSizeOf(db); ok {
+		req.PageSize = size
 	}
 
 	// 注释掉字段名添加逻辑，避免InvalidFieldNames错误
@@ -1121,16 +1604,19 @@ func queryCallback(db *gorm.DB, dialector *Dialector) error {
 	// }
 
 	// 处理 WHERE 条件
+	var residuals []*likeMatcher
 	if whereClause, ok := db.Statement.Clauses["WHERE"]; ok {
 
 		if where, ok := whereClause.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
 
 			// 打印每个表达式的详细信息
 
-			converter := &SQLConverter{}
+			converter := &SQLConverter{config: dialector.Config}
 			filter := converter.buildFilter(where.Exprs)
 
 			req.Filter = filter
+			// 无法完全下推的字符串匹配条件在客户端按通配符位置和大小写规则校验
+			residuals = converter.residuals(where.Exprs)
 		}
 	}
 
@@ -1140,18 +1626,7 @@ func queryCallback(db *gorm.DB, dialector *Dialector) error {
 
 			sort := make([]string, 0)
 			for _, column := range orderBy.Columns {
-				columnName := column.Column.Name
-				// 验证字段名有效性，过滤掉无效的字段名
-				if columnName != "" && isValidFieldName(columnName) {
-					if column.Desc {
-						sort = append(sort, fmt.Sprintf("-%s", columnName))
-					} else {
-						sort = append(sort, columnName)
-					}
-				} else if columnName != "" {
-					// 记录无效的字段名用于调试
-					common.Debugf("跳过无效的排序字段名: %q", columnName)
-				}
+				sort = append(sort, orderColumnKeys(column)...)
 			}
 			if len(sort) > 0 {
 				req.Sort = sort
@@ -1159,73 +1634,252 @@ func queryCallback(db *gorm.DB, dialector *Dialector) error {
 		}
 	}
 
-	// 如果有过滤条件，使用 POST 请求
-	var apiReq *APIRequest
-	if req.Filter != nil {
-		// 使用 POST 请求发送 filter
-		apiReq = &APIRequest{
-			Method: "POST",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/search", dialector.Config.AppToken, tableID),
-			Body:   req,
+	// 配置了排序规则或不区分大小写时在客户端重新排序，服务端按编码排序且区分大小写
+	var compare textComparer
+	if len(req.Sort) > 0 {
+		compare = sortComparer(db, dialector.Config)
+	}
+
+	// 客户端校验字符串匹配条件并排序
+	finish := func(resp *ListRecordsResponse) *ListRecordsResponse {
+		resp = filterLikeResponse(resp, residuals)
+		if compare != nil {
+			sortRecords(resp.Items, req.Sort, compare)
 		}
-	} else {
-		// 没有过滤条件，使用 GET 请求
-		// 不传递任何查询参数，让API返回所有字段
-		apiReq = &APIRequest{
-			Method: "GET",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", dialector.Config.AppToken, tableID),
-			// 不设置QueryParams，获取所有字段
+		return resp
+	}
+
+	// Paginate 设置的游标分页只读取一页，并记录下一页的游标；客户端排序只在本页内进行
+	if page, ok := paginationOf(db); ok {
+		// 随后 Preload 的关联查询会复制语句设置，关联查询不分页
+		db.Statement.Settings.Delete(paginateSettingKey)
+		resp, err := searchRecordsPage(db.Statement.Context, dialector, tableID, req, page)
+		if err != nil {
+			return nil, err
 		}
+		next := ""
+		if resp.HasMore {
+			next = resp.PageToken
+		}
+		db.InstanceSet(nextCursorKey, next)
+		return finish(resp), nil
+	}
+
+	// IN 条件（如 Preload 的关联查询）拆分批次并读取全部分页
+	if whereClause, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := whereClause.Expression.(clause.Where); ok {
+			if in, rest, ok := findInCondition(where.Exprs); ok {
+				resp, err := fetchInBatches(db, dialector, tableID, in, rest, req.Sort)
+				if err != nil {
+					return nil, err
+				}
+				return finish(resp), nil
+			}
+		}
+	}
+
+	// 客户端排序需要读取全部分页，排序后再按 LIMIT 截断，避免只排序第一页
+	if compare != nil {
+		records, err := sortedResultRecords(statementContext(db), dialector, tableID, req, residuals, compare, statementLimit(db.Statement))
+		if err != nil {
+			return nil, err
+		}
+		return &ListRecordsResponse{Items: records}, nil
+	}
+
+	// 客户端校验会过滤掉服务端的部分结果，需要读取全部分页再校验，避免只在第一页中匹配
+	if len(residuals) > 0 {
+		// Count() 统计全部满足条件的记录，不受 MaxResultRows 限制
+		limit := dialector.Config.MaxResultRows
+		if isCountSelect(db.Statement) {
+			limit = 0
+		}
+		records, err := readAllPages(statementContext(db), dialector, tableID, req, residuals, limit)
+		if err != nil {
+			return nil, err
+		}
+		return finish(&ListRecordsResponse{Items: records}), nil
 	}
 
-	resp, err := dialector.Client.DoRequest(context.Background(), apiReq)
+	resp, err := searchRecords(db.Statement.Context, dialector, tableID, req)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return finish(resp), nil
+}
 
-	// 解析响应
-	var apiResp ListRecordsAPIResponse
-	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+// statementLimit 获取语句 LIMIT 子句的行数，未设置时返回 0
+func statementLimit(stmt *gorm.Statement) int {
+	if limitClause, ok := stmt.Clauses["LIMIT"]; ok {
+		if limit, ok := limitClause.Expression.(clause.Limit); ok && limit.Limit != nil && *limit.Limit > 0 {
+			return *limit.Limit
+		}
+	}
+	return 0
+}
+
+// searchRecords 查询表中的记录，只读取一页
+// 每页记录数取自请求的 PageSize 或 Config.DefaultPageSize，都未设置时使用飞书 API 的默认值
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求
+//
+// 返回:
+//   - *ListRecordsResponse: 查询到的记录
+//   - error: 查询过程中的错误
+func searchRecords(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest) (*ListRecordsResponse, error) {
+	resp, _, err := fetchRecordsPage(ctx, dialector, tableID, req, req.PageToken, recordsPageSize(dialector.Config, req, 0))
+	return resp, err
+}
+
+// isModelDestination 判断查询目标是否为模型结构体（或其切片）
+// 只有目标类型与解析出的 Schema 一致时才走结构体字段映射，其余目标（map、基础类型切片等）走通用扫描
+func isModelDestination(stmt *gorm.Statement) bool {
+	if stmt.Schema == nil || !stmt.ReflectValue.IsValid() {
+		return false
+	}
+
+	destType := stmt.ReflectValue.Type()
+	if destType.Kind() == reflect.Slice || destType.Kind() == reflect.Array {
+		destType = destType.Elem()
+	}
+	for destType.Kind() == reflect.Ptr {
+		destType = destType.Elem()
+	}
+	return destType == stmt.Schema.ModelType
+}
+
+// scanResultSet 使用 gorm.Scan 将结果集扫描到语句的目标中
+func scanResultSet(db *gorm.DB, rs *ResultSet) error {
+	rows, err := rs.QueryRows(db.Statement.Context)
+	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	// 检查API调用是否成功
-	if apiResp.Code != 0 {
-		return fmt.Errorf("API返回错误: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	gorm.Scan(rows, db, 0)
+	return rows.Err()
+}
+
+// buildResultSet 将查询到的记录转换为内存结果集
+// 列的确定顺序：显式 SELECT 的列 > 模型字段 > record_id 加表中全部字段
+// 主键列与 record_id 列的值来自记录 ID，其余列按表字段类型转换为 Go 值
+// 参数:
+//   - stmt: GORM 语句
+//   - dialector: BaseSQL 的方言器实例
+//   - listResp: 查询到的记录
+//
+// 返回:
+//   - *ResultSet: 内存结果集
+//   - error: 错误信息
+func buildResultSet(stmt *gorm.Statement, dialector *Dialector, listResp *ListRecordsResponse) (*ResultSet, error) {
+	// COUNT(*) 查询直接返回记录总数
+	if isCountSelect(stmt) {
+		rs := NewResultSet([]string{"count(*)"})
+		total := int64(listResp.Total)
+		if total < int64(len(listResp.Items)) {
+			total = int64(len(listResp.Items))
+		}
+		rs.AppendRow(total)
+		return rs, nil
 	}
 
-	if apiResp.Data == nil {
-		return fmt.Errorf("API响应数据为空")
+	return recordsToResultSet(stmt, dialector, stmt.Table, selectedColumns(stmt), listResp.Items)
+}
+
+// recordsToResultSet 按列名将记录转换为内存结果集
+// 参数:
+//   - stmt: GORM 语句，用于确定模型字段与主键列
+//   - dialector: BaseSQL 的方言器实例
+//   - tableName: 表名
+//   - columns: 需要返回的列，为空时按模型字段或表中全部字段返回
+//   - records: 记录列表
+//
+// 返回:
+//   - *ResultSet: 内存结果集
+//   - error: 错误信息
+func recordsToResultSet(stmt *gorm.Statement, dialector *Dialector, tableName string, columns []string, records []*Record) (*ResultSet, error) {
+	tableFieldsList, err := getTableFields(dialector, tableName)
+	if err != nil {
+		return nil, err
+	}
+	tableFields := make(map[string]*Field, len(tableFieldsList))
+	for _, tableField := range tableFieldsList {
+		tableFields[tableField.FieldName] = tableField
 	}
 
-	listResp := apiResp.Data
+	primaryKey := ""
+	if stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil {
+		primaryKey = stmt.Schema.PrioritizedPrimaryField.DBName
+	}
 
-	// 设置结果
-	if len(listResp.Items) > 0 {
-		// 判断是否是查询单个记录
-		if db.Statement.ReflectValue.Kind() == reflect.Slice {
-			// 查询多个记录
-			sliceValue := reflect.MakeSlice(db.Statement.ReflectValue.Type(), 0, len(listResp.Items))
-			for _, record := range listResp.Items {
-				elemValue := reflect.New(db.Statement.Schema.ModelType).Elem()
-				if err := setRecordToStruct(elemValue, record, db.Statement.Schema, dialector); err != nil {
-					return err
+	if len(columns) == 0 {
+		if stmt.Schema != nil {
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" && field.Readable {
+					columns = append(columns, field.DBName)
 				}
-				sliceValue = reflect.Append(sliceValue, elemValue)
 			}
-			db.Statement.ReflectValue.Set(sliceValue)
 		} else {
-			// 查询单个记录
-			if len(listResp.Items) > 0 {
-				if err := setRecordToStruct(db.Statement.ReflectValue, listResp.Items[0], db.Statement.Schema, dialector); err != nil {
-					return err
+			columns = append(columns, RecordIDColumn)
+			for _, tableField := range tableFieldsList {
+				columns = append(columns, tableField.FieldName)
+			}
+		}
+	}
+
+	rs := NewResultSet(columns)
+	for _, record := range records {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			if column == RecordIDColumn || (primaryKey != "" && column == primaryKey) {
+				values[i] = record.RecordID
+				continue
+			}
+			value := record.Fields[column]
+			if tableField, ok := tableFields[column]; ok && value != nil {
+				value = tableField.ConvertToGoValue(value)
+			}
+			values[i] = value
+		}
+		rs.AppendRow(values...)
+	}
+	return rs, nil
+}
+
+// selectedColumns 获取语句中显式选择的列名
+func selectedColumns(stmt *gorm.Statement) []string {
+	var columns []string
+	if selectClause, ok := stmt.Clauses["SELECT"]; ok {
+		if sel, ok := selectClause.Expression.(clause.Select); ok {
+			for _, column := range sel.Columns {
+				if column.Name != "" && column.Name != "*" {
+					columns = append(columns, column.Name)
 				}
 			}
 		}
 	}
+	if len(columns) == 0 {
+		for _, name := range stmt.Selects {
+			if name != "" && name != "*" {
+				columns = append(columns, name)
+			}
+		}
+	}
+	return columns
+}
 
-	db.RowsAffected = int64(len(listResp.Items))
-	return nil
+// isCountSelect 判断语句是否为 db.Count() 生成的 COUNT(*) 查询
+func isCountSelect(stmt *gorm.Statement) bool {
+	selectClause, ok := stmt.Clauses["SELECT"]
+	if !ok {
+		return false
+	}
+	// clause.Select 合并时会把 Expression 直接提升为子句表达式
+	expr, ok := selectClause.Expression.(clause.Expr)
+	return ok && strings.HasPrefix(strings.ToLower(strings.TrimSpace(expr.SQL)), "count(")
 }
 
 // updateCallback 更新回调
@@ -1235,11 +1889,20 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 		return db.Error
 	}
 
+	if err := dialector.Config.CheckWritable("UPDATE"); err != nil {
+		return err
+	}
+
 	if db.Statement.Schema == nil {
 
 		return fmt.Errorf("schema not found")
 	}
 
+	// Association 的 Append/Replace 只选择关系本身，写入的是所属记录的关联字段
+	if handled, err := updateLinkFields(db, dialector); handled || err != nil {
+		return err
+	}
+
 	// 获取表名、表 ID 和记录 ID
 	tableName := db.Statement.Table
 
@@ -1249,6 +1912,11 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 		return err
 	}
 
+	// 按主键列表或模型切片更新多条记录时使用批量更新接口
+	if recordIDs, ok := statementRecordIDs(db); ok {
+		return batchUpdateCallback(db, dialector, tableID, recordIDs)
+	}
+
 	var recordID string
 	if db.Statement.Schema.PrioritizedPrimaryField != nil {
 		// 首先尝试从 Model 中获取主键值
@@ -1330,24 +1998,18 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 		}
 	}
 
-	// 如果没有 SET 子句，使用结构体字段
-	if len(fields) == 0 && db.Statement.ReflectValue.Kind() == reflect.Struct {
-
-		for _, field := range db.Statement.Schema.Fields {
-			if field.PrimaryKey || field.AutoIncrement {
-				continue
-			}
-
-			value, ok := field.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
-			if !ok {
-				continue
-			}
-
-			fields[field.DBName] = value
-
+	// 其次使用 Update/Updates 指定的字段，ReflectValue 此时是传入的映射而不是模型
+	if len(fields) == 0 {
+		if assigned := assignedFields(db.Statement); len(assigned) > 0 {
+			fields = assigned
 		}
 	}
 
+	// 如果都没有，使用结构体字段
+	if len(fields) == 0 && db.Statement.ReflectValue.Kind() == reflect.Struct {
+		fields = structUpdateFields(db.Statement.Context, db.Statement.Schema, db.Statement.ReflectValue)
+	}
+
 	if len(fields) == 0 {
 
 		return nil
@@ -1355,22 +2017,34 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 
 	// 获取表字段信息并转换字段值
 	tableFieldsList, err := getTableFields(dialector, tableName)
+	tableFields := make(map[string]*Field)
 	if err == nil {
 		// 将字段列表转换为map以便查找
-		tableFields := make(map[string]*Field)
 		for _, tableField := range tableFieldsList {
 			tableFields[tableField.FieldName] = tableField
 		}
 
-		// 转换字段值
+		// 转换字段值，自动编号、公式等只读字段由飞书计算，不写入
 		for fieldName, value := range fields {
 			if tableField, exists := tableFields[fieldName]; exists {
+				if tableField.IsReadOnly() {
+					delete(fields, fieldName)
+					continue
+				}
 				fields[fieldName] = tableField.ConvertFromGoValue(value)
 			}
 		}
 	} else {
 
 	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// 检查校验规则
+	if err := dialector.validateWrite(tableName, db.Statement.Schema, tableFields, fields, true); err != nil {
+		return err
+	}
 
 	// 更新记录请求
 	req := &UpdateRecordRequest{
@@ -1384,7 +2058,7 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 		Body:   req,
 	}
 
-	_, err = dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err = dialector.api().DoRequest(statementContext(db), apiReq)
 	if err != nil {
 
 		return err
@@ -1392,6 +2066,10 @@ func updateCallback(db *gorm.DB, dialector *Dialector) error {
 
 	db.RowsAffected = 1
 
+	if target, ok := writeTarget(db.Statement); ok {
+		returnRecords(db, dialector, tableID, map[string]reflect.Value{recordID: target})
+	}
+
 	return nil
 }
 
@@ -1401,10 +2079,20 @@ func deleteCallback(db *gorm.DB, dialector *Dialector) error {
 		return db.Error
 	}
 
+	if err := dialector.Config.CheckWritable("DELETE"); err != nil {
+		return err
+	}
+
 	if db.Statement.Schema == nil {
 		return fmt.Errorf("schema not found")
 	}
 
+	// Association 的 Delete/Replace/Clear 删除中间表的行，对应从所属记录的关联字段中移除记录 ID
+	registerLinkRelations(db.Statement.Schema)
+	if link := lookupLinkRelation(db.Statement.Table); link != nil {
+		return deleteLinkRecords(db, dialector, link)
+	}
+
 	// 获取表名、表 ID 和记录 ID
 	tableName := db.Statement.Table
 	tableID, err := getTableID(dialector, tableName)
@@ -1412,6 +2100,12 @@ func deleteCallback(db *gorm.DB, dialector *Dialector) error {
 		return err
 	}
 
+	// 按主键列表或模型切片删除多条记录时使用批量删除接口，影响行数为实际删除的记录数
+	if recordIDs, ok := statementRecordIDs(db); ok {
+		db.RowsAffected, err = batchDeleteRecords(statementContext(db), dialector, tableID, recordIDs)
+		return err
+	}
+
 	var recordID string
 	if db.Statement.Schema.PrioritizedPrimaryField != nil {
 		// 首先尝试从 Model 中获取主键值
@@ -1454,7 +2148,7 @@ func deleteCallback(db *gorm.DB, dialector *Dialector) error {
 		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, recordID),
 	}
 
-	_, err = dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err = dialector.api().DoRequest(statementContext(db), apiReq)
 	if err != nil {
 		return err
 	}
@@ -1463,79 +2157,14 @@ func deleteCallback(db *gorm.DB, dialector *Dialector) error {
 	return nil
 }
 
-// setRecordToStruct 将记录设置到结构体
+// setRecordToStruct 将单条记录设置到结构体，批量设置多条记录时使用 newRecordScanner
 func setRecordToStruct(structValue reflect.Value, record *Record, schema *schema.Schema, dialector *Dialector) error {
 	if record == nil {
 		return fmt.Errorf("记录不能为空")
 	}
-	if schema == nil {
-		return fmt.Errorf("schema不能为空")
-	}
-
-	// 获取表字段信息用于类型转换
-	tableName := schema.Table
-	tableFieldsList, err := getTableFields(dialector, tableName)
+	scanner, err := newRecordScanner(schema, dialector)
 	if err != nil {
-		// 如果获取字段信息失败，记录警告并继续使用原始逻辑
-
-		for _, field := range schema.Fields {
-			// 处理主键字段：主键值来自 record.RecordID
-			if field.PrimaryKey {
-				if record.RecordID != "" {
-
-					if err := field.Set(context.Background(), structValue, record.RecordID); err != nil {
-						return fmt.Errorf("设置主键字段 %s 失败: %w", field.DBName, err)
-					}
-				}
-				continue
-			}
-
-			// 处理普通字段：值来自 record.Fields
-			if value, ok := record.Fields[field.DBName]; ok {
-
-				if err := field.Set(context.Background(), structValue, value); err != nil {
-					return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
-				}
-			}
-		}
-		return nil
-	}
-
-	// 将字段列表转换为map以便查找
-	tableFields := make(map[string]*Field)
-	for _, tableField := range tableFieldsList {
-		tableFields[tableField.FieldName] = tableField
-	}
-
-	// 遍历结构体字段并设置值
-	for _, field := range schema.Fields {
-		// 处理主键字段：主键值来自 record.RecordID
-		if field.PrimaryKey {
-			if record.RecordID != "" {
-				if err := field.Set(context.Background(), structValue, record.RecordID); err != nil {
-					return fmt.Errorf("设置主键字段 %s 失败: %w", field.DBName, err)
-				}
-			}
-			continue
-		}
-
-		// 处理普通字段：值来自 record.Fields
-		if value, ok := record.Fields[field.DBName]; ok {
-			// 查找对应的表字段信息
-			if tableField, exists := tableFields[field.DBName]; exists {
-				// 使用 ConvertToGoValue 进行类型转换
-				convertedValue := tableField.ConvertToGoValue(value)
-				if err := field.Set(context.Background(), structValue, convertedValue); err != nil {
-					return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
-				}
-			} else {
-				// 如果找不到字段信息，直接设置原始值
-				if err := field.Set(context.Background(), structValue, value); err != nil {
-					return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
-				}
-			}
-		}
+		return err
 	}
-
-	return nil
+	return scanner.scan(structValue, record)
 }
@@ -0,0 +1,108 @@
+package basesql
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// chaosErrorType 注入的故障使用的 APIError 类型
+const chaosErrorType = "chaos"
+
+// chaosFaults 注入的故障，均为默认重试判断会重试的错误，用于验证应用的重试与降级处理
+var chaosFaults = []struct {
+	code    int
+	message string
+}{
+	{429, "请求频率过高，请稍后重试"},
+	{500, "API 请求失败: status=500"},
+	{503, "API 请求失败: status=503"},
+	{1254290, "API 错误 1254290: TooManyRequest"},
+}
+
+// chaosInjector 按比例为 API 请求注入失败和延迟
+type chaosInjector struct {
+	rate     float64       // 受影响的请求比例
+	maxDelay time.Duration // 最长注入延迟，0 表示受影响的请求全部失败
+	mu       sync.Mutex
+	rand     *rand.Rand
+}
+
+// newChaosInjector 根据配置创建故障注入器
+// 未开启故障注入，或环境变量 BASESQL_ENV 为 production、prod 时返回 nil
+func newChaosInjector(config *Config) *chaosInjector {
+	if config.ChaosRate <= 0 {
+		return nil
+	}
+	if isProductionEnv() {
+		common.Warnf("BASESQL_ENV 为生产环境，忽略故障注入配置 ChaosRate=%v", config.ChaosRate)
+		return nil
+	}
+	common.Warnf("已启用故障注入：%.0f%% 的 API 请求将失败或延迟（最长 %v），仅用于测试", config.ChaosRate*100, config.ChaosDelay)
+	return &chaosInjector{
+		rate:     config.ChaosRate,
+		maxDelay: config.ChaosDelay,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// isProductionEnv 判断环境变量 BASESQL_ENV 是否声明了生产环境
+func isProductionEnv() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("BASESQL_ENV"))) {
+	case "production", "prod":
+		return true
+	}
+	return false
+}
+
+// inject 为一次请求注入故障
+// 受影响的请求在设置了 ChaosDelay 时一半延迟后正常发出，另一半直接失败；未设置时全部失败
+// 参数:
+//   - ctx: 上下文，延迟期间取消时返回上下文的错误
+//
+// 返回:
+//   - error: 注入的错误，请求不受影响或只被延迟时为 nil
+func (ci *chaosInjector) inject(ctx context.Context) error {
+	if ci == nil {
+		return nil
+	}
+
+	ci.mu.Lock()
+	affected := ci.rand.Float64() < ci.rate
+	delayed := ci.maxDelay > 0 && ci.rand.Intn(2) == 0
+	delay := time.Duration(0)
+	if ci.maxDelay > 0 {
+		delay = time.Duration(ci.rand.Int63n(int64(ci.maxDelay)) + 1)
+	}
+	fault := chaosFaults[ci.rand.Intn(len(chaosFaults))]
+	ci.mu.Unlock()
+
+	if !affected {
+		return nil
+	}
+	if delayed {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+			return nil
+		}
+	}
+	return common.NewAPIError(fault.code, chaosErrorType, fault.message, "故障注入")
+}
+
+// IsChaosError 判断错误是否由故障注入（Config.ChaosRate）产生
+// 参数:
+//   - err: 错误
+//
+// 返回:
+//   - bool: 是否为注入的故障
+func IsChaosError(err error) bool {
+	apiErr := unwrapAPIError(err)
+	return apiErr != nil && apiErr.Type == chaosErrorType
+}
@@ -0,0 +1,59 @@
+package basesql
+
+import "github.com/ag9920/basesql/internal/common"
+
+// CircuitBreakerState 熔断器状态
+type CircuitBreakerState = common.CircuitBreakerState
+
+const (
+	// CircuitClosed 关闭状态，请求正常发送
+	CircuitClosed = common.StateClosed
+	// CircuitOpen 开启状态，请求被直接拒绝
+	CircuitOpen = common.StateOpen
+	// CircuitHalfOpen 半开状态，放行少量请求探测服务是否恢复
+	CircuitHalfOpen = common.StateHalfOpen
+)
+
+// ErrCircuitOpen 熔断器开启时请求返回的错误
+var ErrCircuitOpen = common.ErrCircuitOpen
+
+// CircuitBreakerState 获取熔断器当前状态
+// 返回:
+//   - CircuitBreakerState: 熔断器状态
+func (c *Client) CircuitBreakerState() CircuitBreakerState {
+	c.stabilityMutex.RLock()
+	defer c.stabilityMutex.RUnlock()
+	return c.circuitBreaker.GetState()
+}
+
+// TripCircuitBreaker 手动开启熔断
+// 开启后所有请求直接返回 ErrCircuitOpen，不会自动恢复，直到调用 ResetCircuitBreaker。
+// 适合在飞书服务故障或需要暂停写入时由运维人员主动切断请求
+func (c *Client) TripCircuitBreaker() {
+	c.stabilityMutex.RLock()
+	defer c.stabilityMutex.RUnlock()
+	c.circuitBreaker.Trip()
+}
+
+// ResetCircuitBreaker 手动关闭熔断并清空失败计数
+func (c *Client) ResetCircuitBreaker() {
+	c.stabilityMutex.RLock()
+	defer c.stabilityMutex.RUnlock()
+	c.circuitBreaker.Reset()
+}
+
+// logCircuitStateChange 记录熔断器状态变化的结构化日志
+// 熔断开启记为警告，其余状态变化记为信息
+func logCircuitStateChange(appToken string, from, to CircuitBreakerState) {
+	logger := common.DefaultLogger.WithFields(map[string]interface{}{
+		"event":     "circuit_breaker_state_change",
+		"app_token": appToken,
+		"from":      from.String(),
+		"to":        to.String(),
+	})
+	if to == CircuitOpen {
+		logger.Warn("熔断器已开启，请求将被拒绝")
+		return
+	}
+	logger.Info("熔断器状态变化")
+}
@@ -18,17 +18,26 @@ import (
 // Client 飞书 API 客户端，负责处理与飞书多维表格 API 的所有通信
 // 包括认证、令牌管理、请求发送等核心功能
 type Client struct {
-	config         *Config                       // 客户端配置
-	httpClient     *http.Client                  // HTTP 客户端
-	accessToken    string                        // 当前访问令牌
-	tokenMutex     sync.RWMutex                  // 令牌读写锁，保证并发安全
-	tokenExpiry    time.Time                     // 令牌过期时间
-	retryConfig    *RetryConfig                  // 重试配置
-	circuitBreaker *common.CircuitBreaker        // 熔断器
-	connectionPool *common.ConnectionPool        // 连接池
-	rateLimiter    *common.TokenBucket           // 限流器
-	stabilityMutex sync.RWMutex                  // 稳定性组件锁
-	maskSensitive  *security.SensitiveDataMasker // 敏感数据遮蔽器
+	config         *Config                         // 客户端配置
+	httpClient     *http.Client                    // HTTP 客户端
+	accessToken    string                          // 当前访问令牌
+	tokenMutex     sync.RWMutex                    // 令牌读写锁，保证并发安全
+	tokenExpiry    time.Time                       // 令牌过期时间
+	retryConfig    *RetryConfig                    // 重试配置，未单独设置策略的操作类型使用
+	retryPolicies  map[RetryOperation]*RetryConfig // 按操作类型设置的重试策略
+	shouldRetry    ShouldRetryFunc                 // 自定义重试判断，为空时使用默认判断
+	retryMutex     sync.RWMutex                    // 重试配置读写锁
+	circuitBreaker *common.CircuitBreaker          // 熔断器
+	connectionPool *common.ConnectionPool          // 连接池
+	rateLimiter    *common.TokenBucket             // 限流器，配置了分读写、分表限流时不使用
+	rateLimits     *partitionedLimiter             // 分读写、分表的限流器，未配置时为空
+	sharedLimiter  *common.TokenBucket             // 注册表的全局限流器，不通过注册表创建时为空
+	stabilityMutex sync.RWMutex                    // 稳定性组件锁
+	maskSensitive  *security.SensitiveDataMasker   // 敏感数据遮蔽器
+	counters       requestCounters                 // 请求统计计数器
+	schemaCache    *schemaCache                    // 表结构缓存，未启用缓存时为空
+	schemaIDs      *schemaIDs                      // 表名、字段名解析到的 ID
+	chaos          *chaosInjector                  // 故障注入器，未开启故障注入时为空
 }
 
 // 使用公共工具包的 RetryConfig 类型
@@ -40,6 +49,7 @@ var DefaultRetryConfig = RetryConfig{
 	InitialDelay: time.Second,
 	MaxDelay:     30 * time.Second,
 	Multiplier:   2.0,
+	Jitter:       true,
 }
 
 // NewClient 创建新的飞书 API 客户端
@@ -55,7 +65,7 @@ func NewClient(config *Config) (*Client, error) {
 	}
 
 	// 初始化连接池
-	connectionPool := common.NewConnectionPool(common.DefaultConnectionPoolConfig())
+	connectionPool := common.NewConnectionPool(connectionPoolConfig(config))
 
 	// 初始化熔断器
 	circuitBreaker := common.NewCircuitBreaker(common.DefaultCircuitBreakerConfig())
@@ -74,6 +84,14 @@ func NewClient(config *Config) (*Client, error) {
 		connectionPool: connectionPool,
 		rateLimiter:    rateLimiter,
 		maskSensitive:  maskSensitive,
+		schemaIDs:      newSchemaIDs(),
+		chaos:          newChaosInjector(config),
+	}
+	if config.RateLimits != nil {
+		client.rateLimits = newPartitionedLimiter(config.RateLimits, client.resolveRateLimitTables)
+	}
+	if config.SchemaCacheTTL > 0 {
+		client.schemaCache = newSchemaCache(config.SchemaCacheTTL, config.CacheDir, config.AppID, config.BaseURL)
 	}
 
 	// 注册资源到全局资源管理器
@@ -104,27 +122,30 @@ func NewClient(config *Config) (*Client, error) {
 		common.Warnf("注册限流器资源失败: %v", err)
 	}
 
-	// 设置熔断器状态变化回调
+	// 设置熔断器状态变化回调，每次状态变化都记录结构化日志
 	circuitBreaker.SetStateChangeCallback(func(from, to common.CircuitBreakerState) {
-		if config.DebugMode {
-
-		}
+		logCircuitStateChange(config.AppToken, from, to)
 	})
 
-	// 初始化时获取访问令牌
-	if err := client.refreshToken(context.Background()); err != nil {
-		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+	// 初始化时获取访问令牌，延迟认证时推迟到第一次请求
+	if !config.LazyAuth {
+		if err := client.refreshToken(context.Background()); err != nil {
+			return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+		}
 	}
 
 	return client, nil
 }
 
 // SetRetryConfig 设置重试配置
+// 该配置作用于所有未通过 SetRetryPolicy 单独设置策略的操作类型
 // 参数:
 //   - config: 重试配置
 func (c *Client) SetRetryConfig(config *RetryConfig) {
 	if config != nil {
+		c.retryMutex.Lock()
 		c.retryConfig = config
+		c.retryMutex.Unlock()
 	}
 }
 
@@ -175,11 +196,13 @@ func (c *Client) refreshToken(ctx context.Context) error {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.counters.recordCall(len(body), 0)
 		return common.NewAPIError(0, "network", fmt.Sprintf("token request failed: %v", err), "")
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	c.counters.recordCall(len(body), len(respBody))
 	if err != nil {
 		return err
 	}
@@ -223,6 +246,8 @@ func (c *Client) getAccessToken(ctx context.Context) (string, error) {
 		c.tokenMutex.RLock()
 		token = c.accessToken
 		c.tokenMutex.RUnlock()
+	} else {
+		c.counters.recordCacheHit()
 	}
 
 	return token, nil
@@ -255,18 +280,67 @@ func (c *Client) DoRequest(ctx context.Context, req *APIRequest) (*APIResponse,
 		return nil, fmt.Errorf("请求路径不能为空")
 	}
 
-	// 使用重试机制执行请求
-	return c.doRequestWithRetry(ctx, req)
+	// 表结构读取优先使用缓存
+	cacheable := c.schemaCache != nil && isSchemaReadRequest(req)
+	if cacheable {
+		if resp, ok := c.schemaCache.get(req.Path); ok {
+			c.counters.recordCacheHit()
+			return resp, nil
+		}
+	}
+
+	// 为创建记录请求附加 client_token，避免超时重试导致重复插入
+	req, err := withClientToken(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// 字段在飞书界面中改过名时，请求中的原字段名换成当前字段名
+	req = c.renameRequestFields(req)
+
+	// 使用重试机制执行请求，并记录到语句的请求记录器供日志使用
+	if ctx, err = withRequestID(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.doRequestWithRetry(ctx, req)
+	recordAPICall(ctx, req, time.Since(start), err)
+
+	// 按表名解析到的表 ID 失效时重新解析后重试一次
+	if retry, ok := c.retryMovedTable(ctx, req, resp, err); ok {
+		req = retry
+		start = time.Now()
+		resp, err = c.doRequestWithRetry(ctx, req)
+		recordAPICall(ctx, req, time.Since(start), err)
+	}
+
+	// 修改表结构的请求无论成功与否都使缓存失效，避免请求实际已生效但响应超时时读到旧结构
+	if c.schemaCache != nil {
+		if cacheable && err == nil {
+			c.schemaCache.put(req.Path, resp)
+		} else if isSchemaWriteRequest(req) {
+			c.schemaCache.invalidate(schemaCacheAppToken(req.Path))
+		}
+	}
+	return resp, err
 }
 
 // doRequestWithRetry 带重试机制的请求执行
+// 按请求的操作类型选择重试策略，并在服务端给出 Retry-After 时至少等待该时长，但不超过策略的 MaxDelay；
+// 等待结束时会超过上下文的截止时间的重试不再进行，直接返回最后一次的错误
 func (c *Client) doRequestWithRetry(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+	op := classifyRetryOperation(req)
+	policy, shouldRetry := c.retryPolicyFor(op)
 	var lastErr error
 
-	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
+	attempt := 0
+	for ; attempt <= policy.MaxRetries; attempt++ {
 		// 如果不是第一次尝试，等待一段时间
 		if attempt > 0 {
-			delay := c.retryConfig.CalculateBackoffDelay(attempt)
+			delay := retryDelay(policy, attempt, lastErr)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return nil, fmt.Errorf("请求失败，已重试 %d 次，等待 %v 后重试将超过上下文的截止时间: %w", attempt-1, delay, lastErr)
+			}
+			c.counters.recordRetry(lastErr, delay)
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
@@ -283,26 +357,50 @@ func (c *Client) doRequestWithRetry(ctx context.Context, req *APIRequest) (*APIR
 		lastErr = err
 
 		// 检查是否应该重试
-		if !common.ShouldRetry(err, attempt, c.retryConfig) {
+		if attempt >= policy.MaxRetries || !shouldRetry(err, attempt, op) {
 			break
 		}
 	}
 
-	return nil, fmt.Errorf("请求失败，已重试 %d 次: %w", c.retryConfig.MaxRetries, lastErr)
+	return nil, fmt.Errorf("请求失败，已重试 %d 次: %w", attempt, lastErr)
+}
+
+// refundShared 归还从注册表全局限流器取走的令牌
+func (c *Client) refundShared() {
+	if c.sharedLimiter != nil {
+		c.sharedLimiter.Refund()
+	}
 }
 
 // doSingleRequest 执行单次请求
 func (c *Client) doSingleRequest(ctx context.Context, req *APIRequest) (*APIResponse, error) {
-	// 限流检查
-	if !c.rateLimiter.Allow() {
+	// 限流检查：先检查注册表的全局限流器，客户端自身的限流器拒绝时归还全局令牌，
+	// 被拒绝的请求不消耗任何令牌
+	if c.sharedLimiter != nil && !c.sharedLimiter.Allow() {
+		return nil, common.NewAPIError(429, "rate_limit", "超过全局请求频率限制，请稍后重试", "")
+	}
+	if c.rateLimits != nil {
+		if err := c.rateLimits.allow(ctx, req); err != nil {
+			c.refundShared()
+			return nil, err
+		}
+	} else if !c.rateLimiter.Allow() {
+		c.refundShared()
 		return nil, common.NewAPIError(429, "rate_limit", "请求频率过高，请稍后重试", "")
 	}
 
 	// 使用熔断器执行请求
 	var resp *http.Response
 	var err error
+	var sentBytes int
+	start := time.Now()
 
 	err = c.circuitBreaker.Execute(ctx, func() error {
+		// 故障注入的错误与真实失败一样计入熔断器
+		if chaosErr := c.chaos.inject(ctx); chaosErr != nil {
+			return chaosErr
+		}
+
 		// 获取有效的访问令牌
 		token, tokenErr := c.getAccessToken(ctx)
 		if tokenErr != nil {
@@ -329,6 +427,10 @@ func (c *Client) doSingleRequest(ctx context.Context, req *APIRequest) (*APIResp
 				return fmt.Errorf("序列化请求体失败: %w", marshalErr)
 			}
 			body = bytes.NewBuffer(bodyBytes)
+			sentBytes = len(bodyBytes)
+		}
+		if logger := c.logger(); apiDebugEnabled(logger) {
+			logAPIRequestDetail(ctx, logger, req, bodyBytes)
 		}
 
 		// 创建 HTTP 请求
@@ -342,6 +444,11 @@ func (c *Client) doSingleRequest(ctx context.Context, req *APIRequest) (*APIResp
 		httpReq.Header.Set("Content-Type", "application/json")
 		httpReq.Header.Set("User-Agent", "BaseSQL/1.0.0")
 
+		// 携带语句的追踪 ID，便于与服务端日志关联
+		if traceID := TraceIDFromContext(ctx); traceID != "" {
+			httpReq.Header.Set("X-Request-Id", traceID)
+		}
+
 		// 设置自定义请求头
 		for key, value := range req.Headers {
 			httpReq.Header.Set(key, value)
@@ -349,16 +456,26 @@ func (c *Client) doSingleRequest(ctx context.Context, req *APIRequest) (*APIResp
 
 		// 使用连接池执行请求
 		resp, err = c.connectionPool.ExecuteRequest(ctx, httpReq)
+		if err != nil {
+			c.counters.recordCall(sentBytes, 0)
+		}
 		return err
 	})
 
 	if err != nil {
+		if logger := c.logger(); apiDebugEnabled(logger) {
+			logAPIResponseDetail(ctx, logger, req, 0, nil, "", time.Since(start), err)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// 读取响应
 	respBody, err := io.ReadAll(resp.Body)
+	c.counters.recordCall(sentBytes, len(respBody))
+	if logger := c.logger(); apiDebugEnabled(logger) {
+		logAPIResponseDetail(ctx, logger, req, resp.StatusCode, respBody, resp.Header.Get("X-Tt-Logid"), time.Since(start), err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("读取响应体失败: %w", err)
 	}
@@ -377,10 +494,14 @@ func (c *Client) doSingleRequest(ctx context.Context, req *APIRequest) (*APIResp
 			Code int    `json:"code"`
 			Msg  string `json:"msg"`
 		}
+		var apiErr *APIError
 		if json.Unmarshal(respBody, &errorResp) == nil && errorResp.Code != 0 {
-			return nil, common.NewAPIError(errorResp.Code, "api", fmt.Sprintf("API 错误 %d: %s", errorResp.Code, errorResp.Msg), "")
+			apiErr = common.NewAPIError(errorResp.Code, "api", fmt.Sprintf("API 错误 %d: %s", errorResp.Code, errorResp.Msg), "")
+		} else {
+			apiErr = common.NewAPIError(resp.StatusCode, "http", fmt.Sprintf("API 请求失败: status=%d", resp.StatusCode), "")
 		}
-		return nil, fmt.Errorf("API 请求失败: status=%d", resp.StatusCode)
+		apiErr.RetryAfter = common.ParseRetryAfter(resp.Header, time.Now())
+		return nil, apiErr
 	}
 
 	return apiResp, nil
@@ -431,7 +552,9 @@ func (c *Client) GetStabilityStats() map[string]interface{} {
 		stats["connection_pool"] = c.connectionPool.GetStats()
 	}
 
-	if c.rateLimiter != nil {
+	if c.rateLimits != nil {
+		stats["rate_limits"] = c.rateLimits.stats()
+	} else if c.rateLimiter != nil {
 		stats["rate_limiter"] = c.rateLimiter.GetStats()
 	}
 
@@ -453,10 +576,14 @@ func (c *Client) ResetStabilityComponents() error {
 		c.rateLimiter.Reset()
 	}
 
+	if c.rateLimits != nil {
+		c.rateLimits.reset()
+	}
+
 	return nil
 }
 
-// UpdateRateLimiterConfig 更新限流器配置
+// UpdateRateLimiterConfig 更新默认限流器配置，配置了 Config.RateLimits 时不影响分读写、分表的限流器
 // 参数:
 //   - config: 新的限流器配置
 //
@@ -477,6 +604,22 @@ func (c *Client) UpdateRateLimiterConfig(config *common.RateLimiterConfig) error
 	return nil
 }
 
+// connectionPoolConfig 根据配置生成连接池配置，未设置的项使用默认值
+func connectionPoolConfig(config *Config) *common.ConnectionPoolConfig {
+	poolConfig := common.DefaultConnectionPoolConfig()
+	if config.MaxIdleConns > 0 {
+		poolConfig.MaxIdleConnections = config.MaxIdleConns
+		poolConfig.MaxConnections = max(poolConfig.MaxConnections, config.MaxIdleConns)
+	}
+	if config.IdleConnTimeout > 0 {
+		poolConfig.IdleTimeout = config.IdleConnTimeout
+	}
+	poolConfig.MaxConnsPerHost = config.MaxConnsPerHost
+	poolConfig.EnableHTTP2 = config.EnableHTTP2
+	poolConfig.DisableCompression = config.DisableCompression
+	return poolConfig
+}
+
 // UpdateConnectionPoolConfig 更新连接池配置
 // 参数:
 //   - config: 新的连接池配置
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ag9920/basesql/internal/cli"
 	"github.com/ag9920/basesql/internal/common"
@@ -17,11 +25,28 @@ var (
 	version = "1.0.0"
 
 	// 全局配置选项，这些选项可以通过命令行参数或环境变量设置
-	configFile string // 配置文件路径，默认为 ~/.basesql/config.env
-	appID      string // 飞书应用 ID，用于身份认证
-	appSecret  string // 飞书应用密钥，用于身份认证
-	appToken   string // 多维表格 App Token，用于访问特定的多维表格
-	debug      bool   // 调试模式开关，启用后显示详细的请求和响应信息
+	configFile string        // 配置文件路径，默认为 ~/.basesql/config.env
+	appID      string        // 飞书应用 ID，用于身份认证
+	appSecret  string        // 飞书应用密钥，用于身份认证
+	appToken   string        // 多维表格 App Token，用于访问特定的多维表格
+	debug      bool          // 调试模式开关，启用后显示详细的请求和响应信息
+	format     string        // 查询结果输出格式：table、json、csv
+	tableStyle string        // 表格样式：ascii、borderless、markdown
+	vertical   bool          // 纵向显示查询结果，等价于语句以 \G 结尾
+	stats      bool          // 每条命令执行后输出 API 调用统计
+	rulesFile  string        // 校验规则文件路径，默认为 ~/.basesql/rules.yaml
+	readOnly   bool          // 只读模式，拒绝所有写操作
+	policyFile string        // 语句策略文件路径，默认为 ~/.basesql/policy.yaml
+	profile    string        // 使用的策略角色，默认为 default
+	notifyFile string        // 任务通知配置文件路径，默认为 ~/.basesql/notify.yaml
+	lazyAuth   bool          // 延迟认证，第一次请求时再获取访问令牌
+	collation  string        // 字符串比较规则：binary、case_insensitive
+	maxRows    int           // SELECT 最多读取的记录数，0 表示不限制
+	pageSize   int           // 读取记录时的每页记录数，0 表示使用默认值
+	slowQuery  time.Duration // 慢查询阈值，0 表示使用策略角色中的配置
+	logFile    string        // 日志文件路径
+	noColor    bool          // 关闭颜色输出，包括 shell 的语法高亮和日志颜色
+	noCache    bool          // 不读写磁盘上的表结构缓存
 )
 
 // main 函数是 CLI 工具的入口点
@@ -111,12 +136,74 @@ func setupGlobalFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&appSecret, "app-secret", "",
 		"飞书应用密钥，用于身份认证")
 	cmd.PersistentFlags().StringVar(&appToken, "app-token", "",
-		"多维表格 App Token，用于访问特定的多维表格")
+		"多维表格 App Token，用于访问特定的多维表格；也可以直接填写浏览器中的多维表格或知识库链接")
 
 	// 调试模式标志
 	cmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false,
 		"启用调试模式，显示详细的请求和响应信息")
 
+	// 输出格式标志
+	cmd.PersistentFlags().StringVar(&format, "format", cli.OutputFormatTable,
+		"查询结果输出格式 (table|json|csv)")
+	cmd.PersistentFlags().StringVar(&tableStyle, "table-style", string(common.TableStyleASCII),
+		"表格样式 (ascii|borderless|markdown)")
+	cmd.PersistentFlags().BoolVar(&vertical, "vertical", false,
+		"纵向显示查询结果，每条记录逐行显示 \"字段: 值\"（等价于语句以 \\G 结尾）")
+
+	// 统计信息标志
+	cmd.PersistentFlags().BoolVar(&stats, "stats", false,
+		"每条命令执行后输出 API 调用次数、传输字节数、缓存命中、重试与限流等待统计")
+
+	// 校验规则文件标志
+	cmd.PersistentFlags().StringVar(&rulesFile, "rules", "",
+		"校验规则文件路径 (默认: ~/.basesql/rules.yaml)，写入前和 validate 命令按规则校验字段值")
+
+	// 只读模式标志
+	cmd.PersistentFlags().BoolVar(&readOnly, "read-only", false,
+		"只读模式，拒绝 INSERT、UPDATE、DELETE、CREATE、DROP 等写操作 (也可通过环境变量 BASESQL_READ_ONLY=true 开启)")
+
+	// 语句策略标志
+	cmd.PersistentFlags().StringVar(&policyFile, "policy", "",
+		"语句策略文件路径 (默认: ~/.basesql/policy.yaml)，按角色限制可执行的语句类型和表")
+	cmd.PersistentFlags().StringVar(&profile, "profile", "",
+		"使用的策略角色 (默认: 环境变量 BASESQL_PROFILE，未设置时使用 default 角色)")
+
+	// 任务通知标志
+	cmd.PersistentFlags().StringVar(&notifyFile, "notify", "",
+		"任务通知配置文件路径 (默认: ~/.basesql/notify.yaml)，按角色将 seed、dedupe、generate、validate 的结果发送到飞书群")
+
+	// 延迟认证标志
+	cmd.PersistentFlags().BoolVar(&lazyAuth, "lazy-auth", false,
+		"延迟认证，启动时不获取访问令牌，第一次请求时再获取 (也可通过环境变量 BASESQL_LAZY_AUTH=true 开启)")
+
+	// 字符串比较规则标志
+	cmd.PersistentFlags().StringVar(&collation, "collation", "",
+		"字符串比较规则 (binary|case_insensitive)，case_insensitive 时等于、LIKE 的客户端匹配和排序不区分大小写 (默认: 环境变量 BASESQL_COLLATION，未设置时为 binary)")
+
+	// 结果行数上限标志
+	cmd.PersistentFlags().IntVar(&maxRows, "max-rows", cli.DefaultMaxRows,
+		"SELECT 最多输出的行数，超过时截断并提示，分组和聚合查询超过时报错，0 表示不限制")
+
+	// 颜色标志
+	cmd.PersistentFlags().BoolVar(&noColor, "no-color", false,
+		"关闭颜色输出，包括 shell 的语法高亮和日志颜色 (也可通过环境变量 NO_COLOR 关闭)")
+
+	// 每页记录数标志
+	cmd.PersistentFlags().IntVar(&pageSize, "page-size", 0,
+		"读取记录时的每页记录数 (1-500，默认 500)，单页响应过大导致超时时可以调小；API 拒绝时自动减半重试")
+
+	// 慢查询阈值标志
+	cmd.PersistentFlags().DurationVar(&slowQuery, "slow-query-threshold", 0,
+		"慢查询阈值，如 2s，语句耗时超过时输出耗时和飞书 API 请求明细 (默认: 语句策略文件中角色的 slow_query_threshold)")
+
+	// 表结构缓存标志
+	cmd.PersistentFlags().BoolVar(&noCache, "no-cache", false,
+		"不使用 ~/.basesql/cache 中的表结构缓存，重新获取表和字段列表 (默认缓存 5 分钟，可用 refresh-schema 命令刷新)")
+
+	// 日志文件标志
+	cmd.PersistentFlags().StringVar(&logFile, "log-file", "",
+		"将日志写入文件并自动切割 (默认: 环境变量 BASESQL_LOG_FILE)，切割策略由 BASESQL_LOG_MAX_SIZE_MB、BASESQL_LOG_MAX_AGE、BASESQL_LOG_MAX_BACKUPS、BASESQL_LOG_COMPRESS 设置")
+
 	// 注意：配置文件标志已设置
 }
 
@@ -127,6 +214,7 @@ func setupGlobalFlags(cmd *cobra.Command) {
 func addSubCommands(cmd *cobra.Command) {
 	// 连接测试命令
 	cmd.AddCommand(newConnectCmd())
+	cmd.AddCommand(newDoctorCmd())
 
 	// SQL 执行命令
 	cmd.AddCommand(newQueryCmd())
@@ -137,6 +225,33 @@ func addSubCommands(cmd *cobra.Command) {
 
 	// 配置管理命令
 	cmd.AddCommand(newConfigCmd())
+
+	// 种子数据命令
+	cmd.AddCommand(newSeedCmd())
+	cmd.AddCommand(newDedupeCmd())
+	cmd.AddCommand(newExpireCmd())
+	cmd.AddCommand(newGenerateCmd())
+	cmd.AddCommand(newImportCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newSyncCmd())
+	cmd.AddCommand(newBenchCmd())
+	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newProfileCmd())
+
+	// REST 网关命令
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newBreakerCmd())
+	cmd.AddCommand(newRefreshSchemaCmd())
+
+	// 查询历史命令
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newStatsCmd())
+
+	// SQL 格式化命令
+	cmd.AddCommand(newFmtCmd())
+	cmd.AddCommand(newLintCmd())
 }
 
 // getExitCode 根据错误类型返回适当的退出码
@@ -215,6 +330,11 @@ func newConnectCmd() *cobra.Command {
 			}
 			defer client.Close()
 
+			// 获取访问令牌并读取表结构，确认凭据和多维表格都可用
+			if err := client.Warmup(cmd.Context()); err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+
 			fmt.Println("✅ 连接成功！")
 			fmt.Println("📋 可以开始使用 BaseSQL 操作飞书多维表格了")
 			return nil
@@ -223,11 +343,57 @@ func newConnectCmd() *cobra.Command {
 	return cmd
 }
 
+// newDoctorCmd 创建诊断命令
+// 该命令依次检查配置、认证、多维表格访问、数据表列表、示例读取、权限范围和限流情况，
+// 并针对失败项给出修复建议；存在失败项时以非零退出码结束，便于在脚本中使用
+// 返回:
+//   - *cobra.Command: 诊断命令实例
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "诊断连接、权限和限流问题",
+		Long: `运行一组连接诊断检查，并为失败项给出修复建议。
+
+检查项：
+  • 配置：必需的配置项是否齐全
+  • 认证：能否获取 tenant_access_token
+  • 多维表格访问：App Token 是否有效、应用是否有访问权限
+  • 数据表列表：能否列出数据表
+  • 示例读取：能否从第一个数据表读取记录
+  • 权限范围：是否缺少 bitable 相关权限
+  • 限流探测：连续请求是否触发限流
+
+存在失败项时命令以非零退出码结束。`,
+		Example: `  # 诊断当前配置
+  basesql doctor
+
+  # 以 JSON 输出诊断结果，便于脚本处理
+  basesql doctor --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := getConfig()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			report := cli.RunDoctor(ctx, config)
+			if err := cli.PrintDoctorReport(report, strings.ToLower(config.Format)); err != nil {
+				return err
+			}
+			if report.Failed() {
+				return fmt.Errorf("诊断发现问题，请根据上面的建议修复")
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
 // newQueryCmd 创建查询命令
 // 该命令用于执行 SELECT 查询语句
 // 返回:
 //   - *cobra.Command: 查询命令实例
 func newQueryCmd() *cobra.Command {
+	var output, out string
+
 	cmd := &cobra.Command{
 		Use:   "query [SQL]",
 		Short: "执行 SELECT 查询语句",
@@ -238,7 +404,16 @@ func newQueryCmd() *cobra.Command {
   • SELECT field1, field2 FROM table_name
   • SELECT * FROM table_name WHERE condition
   • SHOW TABLES
-  • SHOW COLUMNS FROM table_name`,
+  • SHOW COLUMNS FROM table_name
+
+使用 --output 可以将 SELECT 的结果发送到终端以外的目标：
+  • webhook:<URL>   以 JSON POST 到指定地址
+  • feishu:<URL>    通过飞书群机器人发送消息卡片（签名密钥读取环境变量 BASESQL_FEISHU_BOT_SECRET）
+  • s3://bucket/key 上传到 S3，键以 .csv 结尾时上传 CSV，否则上传 JSON
+  • file:<路径>     写入本地文件
+
+使用 --out <路径>（等同于 --output file:<路径>，或在语句中使用 SELECT ... INTO OUTFILE 'path'）将结果直接写入本地文件，
+不渲染终端表格。文件格式由扩展名决定（.csv 或 .json），其他扩展名使用 --format 指定的 csv 或 json。`,
 		Args: cobra.ExactArgs(1),
 		Example: `  # 查询所有数据
   basesql query "SELECT * FROM users"
@@ -250,21 +425,48 @@ func newQueryCmd() *cobra.Command {
   basesql query "SELECT * FROM users WHERE name = '张三'"
 
   # 显示所有表
-  basesql query "SHOW TABLES"`,
+  basesql query "SHOW TABLES"
+
+  # 将结果上传到 S3
+  basesql query "SELECT * FROM users" --output s3://reports/users.csv
+
+  # 将结果写入本地文件
+  basesql query "SELECT name, email FROM users WHERE age > 18" --out users.csv
+  basesql query "SELECT * FROM users INTO OUTFILE 'users.json'"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if args[0] == "" {
 				return fmt.Errorf("SQL 查询语句不能为空")
 			}
 
+			// 先解析输出地址，避免查询完成后才发现地址无效
+			writer, dest, err := cli.NewQueryOutput(output, out, format)
+			if err != nil {
+				return err
+			}
+
 			client, err := cli.NewClient(getConfig())
 			if err != nil {
 				return fmt.Errorf("连接失败: %w", err)
 			}
 			defer client.Close()
+			enableHistory(client)
+			enableStats(client)
+			enableProfileCache(client)
 
-			return client.Query(args[0])
+			if writer == nil {
+				return client.Query(args[0])
+			}
+			rows, err := client.QueryTo(cmd.Context(), args[0], writer)
+			if err != nil {
+				return err
+			}
+			common.PrintSuccess(fmt.Sprintf("已将 %d 条记录发送到 %s", rows, dest))
+			return nil
 		},
 	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "将查询结果发送到外部目标 (webhook:<URL>|feishu:<URL>|s3://bucket/key|file:<路径>)")
+	cmd.Flags().StringVar(&out, "out", "", "将查询结果直接写入本地文件，等同于 --output file:<路径>，格式由扩展名决定 (.csv|.json)")
 	return cmd
 }
 
@@ -301,6 +503,8 @@ func newExecCmd() *cobra.Command {
 				return fmt.Errorf("连接失败: %w", err)
 			}
 			defer client.Close()
+			enableHistory(client)
+			enableStats(client)
 
 			return client.Exec(args[0])
 		},
@@ -323,15 +527,16 @@ func newInteractiveCmd() *cobra.Command {
   • 支持多行 SQL 语句输入
   • 命令历史记录
   • 自动补全功能
-  • 语法高亮显示
+  • 语法高亮显示（关键字、字符串、数字和注释，--no-color 关闭）
   • 内置帮助命令`,
 		Example: `  # 启动交互式 shell
   basesql shell
 
-  # 在 shell 中执行命令
-  basesql> SELECT * FROM users;
-  basesql> SHOW TABLES;
-  basesql> exit`,
+  # 在 shell 中执行命令（提示符为多维表格名称）
+  mybase> SELECT * FROM users;
+  mybase> SHOW TABLES;
+  mybase> SELECT * FROM users\G
+  mybase> exit`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := cli.NewClient(getConfig())
 			if err != nil {
@@ -339,24 +544,50 @@ func newInteractiveCmd() *cobra.Command {
 			}
 			defer client.Close()
 
-			// 配置 readline
-			rl, err := readline.NewEx(&readline.Config{
-				Prompt:          "basesql> ",
-				HistoryFile:     os.ExpandEnv("$HOME/.basesql_history"),
+			// 获取连接信息，失败时仍然进入 Shell，使用默认提示符
+			info, infoErr := client.SessionInfo(context.Background())
+
+			// 配置 readline，终端支持颜色时为输入的 SQL 着色
+			rlConfig := &readline.Config{
+				Prompt:          info.Prompt(""),
+				HistoryFile:     cli.ShellHistoryPath(),
 				AutoComplete:    newCompleter(),
 				InterruptPrompt: "^C",
 				EOFPrompt:       "exit",
-			})
+			}
+			if common.ColorEnabled(os.Stdout) {
+				rlConfig.Painter = cli.SQLPainter{}
+			}
+			rl, err := readline.NewEx(rlConfig)
 			if err != nil {
 				return fmt.Errorf("初始化 readline 失败: %w", err)
 			}
 			defer rl.Close()
 
+			// 初始化 SQL 片段存储
+			snippetDir, err := cli.DefaultSnippetDir()
+			if err != nil {
+				return err
+			}
+			snippets := cli.NewSnippetStore(snippetDir)
+			lastSQL := "" // 最近一次执行的 SQL，供 \save 使用
+
+			// 启用结构化查询历史
+			history := enableHistory(client)
+			enableStats(client)
+			enableProfileCache(client)
+
 			// 显示欢迎信息
 			fmt.Println("🚀 BaseSQL 交互式 Shell")
 			fmt.Println("📝 输入 SQL 语句，使用 \\q 退出")
 			fmt.Println("💡 使用上下箭头键浏览命令历史，Tab 键自动补全")
 			fmt.Println("---")
+			if infoErr != nil {
+				common.PrintWarning(fmt.Sprintf("无法获取多维表格信息: %v", infoErr))
+			} else {
+				cli.PrintSessionInfo(info)
+			}
+			fmt.Println("---")
 
 			for {
 				line, err := rl.Readline()
@@ -372,6 +603,47 @@ func newInteractiveCmd() *cobra.Command {
 					continue
 				}
 
+				// 处理历史命令（\history、!N、!!）
+				if sql, handled := handleHistoryCommand(history, line); handled {
+					if sql == "" {
+						continue
+					}
+					fmt.Printf("▶️  %s\n", sql)
+					line = sql
+				}
+
+				// 处理 SQL 片段命令（\save、\list、\run）
+				if sql, handled := handleSnippetCommand(snippets, line, lastSQL); handled {
+					if sql == "" {
+						continue
+					}
+					fmt.Printf("▶️  %s\n", sql)
+					line = sql
+				}
+
+				// 格式化 SQL（\fmt）
+				if handleFmtCommand(line, lastSQL) {
+					fmt.Println()
+					continue
+				}
+
+				// 处理熔断器命令（\breaker）
+				if handleBreakerCommand(client, line) {
+					fmt.Println()
+					continue
+				}
+
+				// 刷新表结构（\refresh）
+				if line == "\\refresh" {
+					if err := client.RefreshSchema(); err != nil {
+						common.PrintError(err.Error())
+					} else {
+						common.PrintSuccess("已清空表 ID、字段 ID 和表结构缓存，之后按名称重新解析")
+					}
+					fmt.Println()
+					continue
+				}
+
 				// 处理内置命令
 				switch strings.ToLower(line) {
 				case "\\q", "quit", "exit":
@@ -381,17 +653,22 @@ func newInteractiveCmd() *cobra.Command {
 					printShellHelp()
 					continue
 				case "clear", "\\c":
-					fmt.Print("\033[2J\033[H") // 清屏
+					// readline 按平台清屏，Windows 控制台不依赖 ANSI 转义序列
+					readline.ClearScreen(rl.Stdout())
 					continue
 				}
 
 				// 执行 SQL 命令
-				if err := client.Execute(line); err != nil {
+				lastSQL = line
+				if err := executeInterruptible(client, line); errors.Is(err, cli.ErrQueryCancelled) {
+					common.PrintWarning("查询已取消 (query cancelled)")
+				} else if err != nil {
 					errorMsg := common.FormatUserError(err)
 					fmt.Print(errorMsg)
 				} else {
 					common.PrintSuccess("命令执行成功")
 				}
+				rl.SetPrompt(info.Prompt(client.DefaultTable()))
 				fmt.Println() // 添加空行分隔
 			}
 
@@ -415,12 +692,19 @@ func newConfigCmd() *cobra.Command {
 支持的配置项：
   • FEISHU_APP_ID: 飞书应用 ID
   • FEISHU_APP_SECRET: 飞书应用密钥
-  • FEISHU_APP_TOKEN: 飞书多维表格 Token`,
+  • FEISHU_APP_TOKEN: 飞书多维表格 Token，也可以填写多维表格链接
+    (https://xxx.feishu.cn/base/<app_token>?table=...，知识库链接 /wiki/<token> 通过知识库 API 换取)`,
 		Example: `  # 初始化配置文件
   basesql config init
 
   # 查看当前配置
-  basesql config show`,
+  basesql config show
+
+  # 逐项校验配置
+  basesql config validate
+
+  # 列出可以覆盖配置的环境变量
+  basesql config env`,
 	}
 
 	// 初始化配置子命令
@@ -442,7 +726,6 @@ func newConfigCmd() *cobra.Command {
 				return fmt.Errorf("初始化配置失败: %w", err)
 			}
 			fmt.Println("✅ 配置文件初始化成功！")
-			fmt.Println("📁 配置文件位置: ~/.basesql/config.env")
 			fmt.Println("💡 请编辑配置文件并填入您的飞书应用信息")
 			return nil
 		},
@@ -467,42 +750,1562 @@ func newConfigCmd() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(initCmd, showCmd)
+	// 校验配置子命令
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "逐项校验配置并指出需要修改的值",
+		Long: `逐项校验当前生效的配置，失败项会指明需要修改的命令行参数或环境变量。
+
+检查项：
+  • App ID / App Secret：格式是否正确，是否互相填反
+  • App Token：是否为有效的 app_token 或多维表格链接，
+    识别误填的知识库节点 token、数据表 ID、视图 ID、文档 token 等
+  • 网络：能否访问飞书开放平台
+  • 认证：凭证能否获取 tenant_access_token
+  • 多维表格访问：App Token 能否访问，知识库节点 token 会给出对应的 app_token
+  • 权限范围：应用是否开通了读写多维表格的权限
+
+存在失败项时命令以非零退出码结束。`,
+		Example: `  # 校验当前配置
+  basesql config validate
+
+  # 以 JSON 输出校验结果
+  basesql config validate --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := getConfig()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			report := cli.ValidateConfig(ctx, config)
+			if err := cli.PrintDoctorReport(report, strings.ToLower(config.Format)); err != nil {
+				return err
+			}
+			if report.Failed() {
+				return fmt.Errorf("配置校验未通过，请根据上面的建议修改")
+			}
+			return nil
+		},
+	}
+
+	// 环境变量列表子命令
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "列出可以覆盖配置的 BASESQL_* 环境变量",
+		Long: `列出驱动支持的所有 BASESQL_* 环境变量、值的格式、默认值和当前值。
+
+驱动的每个配置项都可以通过 BASESQL_<配置名> 环境变量覆盖，如 BASESQL_TIMEOUT=1m、
+BASESQL_RATE_LIMIT_QPS=20、BASESQL_MAX_RETRIES=5，设置了的环境变量优先于代码中的配置，
+容器部署时无需配置文件。时长可以写为 30s、5m 或秒数，限流和校验规则为 JSON。
+CLI 的 FEISHU_APP_ID、FEISHU_APP_SECRET、FEISHU_APP_TOKEN 未设置时同样读取对应的 BASESQL_* 环境变量。`,
+		Example: `  # 列出环境变量
+  basesql config env
+
+  # 以 Markdown 表格输出，便于粘贴到部署文档
+  basesql config env --table-style markdown
+
+  # 以 JSON 输出
+  basesql config env --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cli.PrintConfigEnv(strings.ToLower(format), tableStyle)
+		},
+	}
+
+	cmd.AddCommand(initCmd, showCmd, validateCmd, envCmd)
 	return cmd
 }
 
-// printShellHelp 显示交互式 Shell 的帮助信息
-func printShellHelp() {
-	fmt.Println("📚 BaseSQL 交互式 Shell 帮助")
-	fmt.Println("")
-	fmt.Println("🔧 内置命令:")
-	fmt.Println("  help, \\h     显示此帮助信息")
-	fmt.Println("  exit, quit, \\q  退出 Shell")
-	fmt.Println("  clear, \\c    清屏")
-	fmt.Println("")
-	fmt.Println("📝 SQL 命令示例:")
-	fmt.Println("  SHOW TABLES;")
-	fmt.Println("  SHOW COLUMNS FROM table_name;")
-	fmt.Println("  SELECT * FROM table_name;")
-	fmt.Println("  SELECT field1, field2 FROM table_name WHERE condition;")
-	fmt.Println("  INSERT INTO table (field1, field2) VALUES (value1, value2);")
-	fmt.Println("  UPDATE table SET field1=value1 WHERE condition;")
-	fmt.Println("  DELETE FROM table WHERE condition;")
-	fmt.Println("")
-	fmt.Println("💡 提示:")
-	fmt.Println("  • 使用上下箭头键浏览命令历史")
-	fmt.Println("  • 使用 Tab 键进行自动补全")
-	fmt.Println("  • SQL 语句可以不加分号结尾")
-	fmt.Println("")
+// newFmtCmd 创建 SQL 格式化命令
+// 该命令只在本地格式化 SQL，无需连接飞书
+// 返回:
+//   - *cobra.Command: SQL 格式化命令实例
+func newFmtCmd() *cobra.Command {
+	var (
+		file  string
+		write bool
+	)
+	cmd := &cobra.Command{
+		Use:   "fmt [SQL]",
+		Short: "格式化 SQL 语句",
+		Long: `格式化 SQL 语句：关键字统一为大写，空白规范为单个空格，
+FROM、WHERE、GROUP BY、ORDER BY、LIMIT 等子句另起一行，WHERE 中顶层的 AND、OR 缩进另起一行。
+字符串、表名和字段名保持原样，-- 注释保留。多条语句以分号分隔时逐条格式化。
+
+SQL 可以作为参数传入，也可以通过 --file 读取脚本文件，都未提供时从标准输入读取。`,
+		Example: `  # 格式化单条语句
+  basesql fmt "select * from t where a=1"
+
+  # 格式化脚本文件并写回
+  basesql fmt -f migrate.sql -w
+
+  # 从标准输入读取
+  cat query.sql | basesql fmt`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input []byte
+			var err error
+			switch {
+			case len(args) == 1:
+				input = []byte(args[0])
+			case file != "":
+				input, err = os.ReadFile(file)
+			default:
+				input, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return fmt.Errorf("读取 SQL 失败: %w", err)
+			}
+
+			formatted, err := cli.FormatSQL(string(input))
+			if err != nil {
+				return err
+			}
+			if write {
+				if file == "" {
+					return fmt.Errorf("--write 需要与 --file 一起使用")
+				}
+				return os.WriteFile(file, []byte(formatted+"\n"), 0644)
+			}
+			fmt.Println(formatted)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "要格式化的 SQL 文件")
+	cmd.Flags().BoolVarP(&write, "write", "w", false, "将格式化结果写回 --file 指定的文件")
+	return cmd
 }
 
-// getConfig 从命令行参数和环境变量获取配置
-// 该函数会按优先级顺序获取配置：命令行参数 > 环境变量 > 配置文件
+// newLintCmd 创建 SQL 检查命令
+// 该命令只在本地检查 SQL，无需连接飞书
 // 返回:
-//   - *cli.Config: 配置实例
-func getConfig() *cli.Config {
-	// 初始化日志系统
-	if err := common.InitializeLogging(debug, ""); err != nil {
+//   - *cobra.Command: SQL 检查命令实例
+func newLintCmd() *cobra.Command {
+	var sql string
+	cmd := &cobra.Command{
+		Use:   "lint [file.sql]",
+		Short: "检查 SQL 中会被忽略或错误处理的写法",
+		Long: `在执行前检查 SQL 中当前不支持、执行时不会报错但结果与预期不同的写法，例如：
+  - WHERE 中的多个 AND 条件、OR、NOT，只有第一个条件生效
+  - SELECT 中的 >、<、!= 等比较按等于处理
+  - SELECT 中 LIKE 的 _ 通配符和转义按普通字符匹配，没有 % 的模式按包含匹配
+  - UPDATE、DELETE 中的 ORDER BY、LIMIT，以及 JOIN、子查询等不支持的语法
+
+query、exec 和 shell 执行语句前也会进行同样的检查，并将提示输出到标准错误。
+发现错误级别的问题时命令以非零状态退出，可以在 CI 中检查 SQL 脚本。
+未指定文件和 --execute 时从标准输入读取。`,
+		Example: `  # 检查脚本文件
+  basesql lint migrate.sql
+
+  # 检查单条语句
+  basesql lint -e "SELECT * FROM t WHERE a = 1 OR b = 2"
+
+  # 以 JSON 输出检查结果
+  basesql lint migrate.sql --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input []byte
+			var err error
+			switch {
+			case sql != "":
+				input = []byte(sql)
+			case len(args) == 1:
+				input, err = os.ReadFile(args[0])
+			default:
+				input, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return fmt.Errorf("读取 SQL 失败: %w", err)
+			}
+
+			issues := cli.LintSQL(string(input))
+			if err := cli.PrintLintIssues(os.Stdout, issues, format); err != nil {
+				return err
+			}
+			if cli.HasLintErrors(issues) {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("SQL 检查未通过")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&sql, "execute", "e", "", "要检查的 SQL 语句")
+	return cmd
+}
+
+// newHistoryCmd 创建查询历史命令
+// 该命令用于查看 shell、query、exec 执行过的语句，无需连接飞书
+// 返回:
+//   - *cobra.Command: 查询历史命令实例
+func newHistoryCmd() *cobra.Command {
+	var (
+		search  string
+		session string
+		limit   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "查看查询历史",
+		Long: `查看执行过的 SQL 语句及其执行时间、耗时和返回行数。
+
+历史保存在 ~/.basesql/history.jsonl，shell、query 和 exec 执行的语句都会被记录。
+在 shell 中可以使用 !N 重新执行第 N 条历史语句。`,
+		Example: `  # 查看最近 50 条历史
+  basesql history
+
+  # 搜索包含 users 的语句
+  basesql history --search users
+
+  # 查看某次会话的全部历史
+  basesql history --session 20240101120000-12345 --limit 0`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := cli.DefaultHistoryPath()
+			if err != nil {
+				return err
+			}
+			entries, err := cli.NewHistoryStore(path).Search(cli.HistoryFilter{
+				Keyword: search,
+				Session: session,
+				Limit:   limit,
+			})
+			if err != nil {
+				return fmt.Errorf("读取查询历史失败: %w", err)
+			}
+			cli.PrintHistory(entries, session == "")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&search, "search", "s", "", "按关键字过滤 SQL（忽略大小写）")
+	cmd.Flags().StringVar(&session, "session", "", "只显示指定会话的历史")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "显示最近的条数，0 表示全部")
+	return cmd
+}
+
+// newStatsCmd 创建执行统计命令
+// 该命令用于分析 shell、query、exec 执行语句的耗时和 API 请求数，无需连接飞书
+// 返回:
+//   - *cobra.Command: 执行统计命令实例
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "查看语句执行统计",
+		Long: `查看执行过的语句的耗时、返回行数和 API 请求数。
+
+shell、query 和 exec 执行的每条语句都会将语句类型、表名、耗时、行数和 API 请求数
+记录到 ~/.basesql/stats.jsonl，可以长期观察最慢的查询和访问最多的表。`,
+	}
+
+	var (
+		by     string
+		sortBy string
+		since  string
+		limit  int
+	)
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "查看最慢的查询和访问最多的表",
+		Long: `按语句或表分组汇总执行统计，默认按总耗时降序排列。
+
+按语句分组时，字符串和数字字面量替换为 ?，条件值不同的同一条语句归为一组。`,
+		Example: `  # 总耗时最长的 10 类查询
+  basesql stats top
+
+  # 最近 7 天 API 请求最多的表
+  basesql stats top --by table --sort api_calls --since 7d
+
+  # 平均耗时最长的查询，以 JSON 输出
+  basesql stats top --sort avg --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinceTime, err := cli.ParseStatsSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			path, err := cli.DefaultStatsPath()
+			if err != nil {
+				return err
+			}
+			stats, err := cli.NewStatsStore(path).Load(sinceTime)
+			if err != nil {
+				return fmt.Errorf("读取执行统计失败: %w", err)
+			}
+			summaries, err := cli.SummarizeStats(stats, by, sortBy, limit)
+			if err != nil {
+				return err
+			}
+			return cli.PrintStatsSummaries(summaries, by, format)
+		},
+	}
+	topCmd.Flags().StringVar(&by, "by", cli.StatsByQuery, "分组方式 (query|table)")
+	topCmd.Flags().StringVar(&sortBy, "sort", "total", "排序字段 (total|avg|max|count|rows|api_calls)")
+	topCmd.Flags().StringVar(&since, "since", "", "只统计最近一段时间，如 24h、7d，默认全部")
+	topCmd.Flags().IntVarP(&limit, "limit", "n", 10, "显示的条数，0 表示全部")
+
+	cmd.AddCommand(topCmd)
+	return cmd
+}
+
+// newSeedCmd 创建种子数据命令
+// 该命令根据声明式的种子文件初始化表、字段和记录，用于快速搭建开发环境
+// 返回:
+//   - *cobra.Command: 种子数据命令实例
+func newSeedCmd() *cobra.Command {
+	var seedFile string
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "根据种子文件初始化表结构和数据",
+		Long: `根据 YAML 或 JSON 格式的种子文件声明式地创建表、字段和记录。
+
+该命令可以重复执行：
+  • 已存在的表和字段会被复用，缺失的字段会被补齐
+  • 设置了 key 的表按键字段匹配已有记录，存在则更新，不存在则插入
+  • 内容未变化的记录会被跳过
+
+种子文件示例：
+  tables:
+    - name: users
+      key: email
+      fields:
+        - name: email
+          type: text
+        - name: age
+          type: number
+      records:
+        - email: zhangsan@example.com
+          age: 18`,
+		Example: `  # 使用种子文件初始化开发环境
+  basesql seed --file seed.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if seedFile == "" {
+				return fmt.Errorf("请通过 --file 指定种子文件")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Seed(seedFile); err != nil {
+				return fmt.Errorf("初始化种子数据失败: %w", err)
+			}
+			fmt.Println("✅ 种子数据初始化完成！")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&seedFile, "file", "f", "", "种子文件路径 (YAML 或 JSON)")
+	return cmd
+}
+
+// newDedupeCmd 创建去重命令
+// 多维表格没有唯一约束，该命令按键字段查找重复记录并删除多余的记录
+// 返回:
+//   - *cobra.Command: 去重命令实例
+func newDedupeCmd() *cobra.Command {
+	var opts cli.DedupeOptions
+
+	cmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "按键字段删除重复记录",
+		Long: `按一个或多个键字段查找重复记录，每组只保留一条，删除其余记录。
+
+  • 多个键字段的值全部相同才视为重复
+  • 键字段全部为空的记录不参与去重
+  • 默认保留最早创建的记录，可以通过 --keep newest 保留最新的记录`,
+		Example: `  # 预览 users 表中 email 重复的记录
+  basesql dedupe --table users --key email --dry-run
+
+  # 删除重复记录，保留最新创建的一条
+  basesql dedupe --table users --key email --keep newest
+
+  # 按多个字段组合去重
+  basesql dedupe --table orders --key user_id,product_id`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" || len(opts.Keys) == 0 {
+				return fmt.Errorf("请通过 --table 和 --key 指定表名和键字段")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Dedupe(opts); err != nil {
+				return fmt.Errorf("去重失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "表名")
+	cmd.Flags().StringSliceVarP(&opts.Keys, "key", "k", nil, "判断重复的键字段，多个字段以逗号分隔")
+	cmd.Flags().StringVar(&opts.Keep, "keep", cli.DedupeKeepOldest, "保留策略: oldest, newest")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "只列出重复记录，不删除")
+	return cmd
+}
+
+// newExpireCmd 创建记录过期命令
+// 按过期配置为各表声明日期字段和保留时长，定期删除或归档过期的记录
+// 返回:
+//   - *cobra.Command: 记录过期命令实例
+func newExpireCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expire",
+		Short: "按保留时长清理过期记录",
+		Long: `按过期配置文件（默认 ~/.basesql/expire.yaml）删除或归档超过保留时长的记录。
+
+配置文件示例：
+  tables:
+    - table: logs
+      field: 发生时间      # 日期字段，省略时使用记录的创建时间
+      ttl: 30d            # 保留时长，支持 30d、720h
+    - table: orders
+      field: 下单时间
+      ttl: 365d
+      action: archive     # 先追加到归档文件（JSON Lines），再删除
+      archive: ~/archive/orders.jsonl
+    - table: tickets
+      field: 关闭时间
+      ttl: 90d
+      action: move        # 先写入归档表 tickets_archive（不存在时自动创建），再删除
+
+  • 日期字段为空或无法解析的记录不会过期
+  • 每批最多删除 500 条记录`,
+	}
+
+	var (
+		opts     cli.ExpireOptions
+		interval time.Duration
+	)
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "删除或归档过期记录",
+		Example: `  # 预览所有表中的过期记录
+  basesql expire run --dry-run
+
+  # 只清理 logs 表
+  basesql expire run --table logs
+
+  # 每小时执行一次，直到按 Ctrl+C 停止
+  basesql expire run --interval 1h`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval < 0 {
+				return fmt.Errorf("--interval 不能为负数")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if interval == 0 {
+				if err := client.Expire(opts); err != nil {
+					return fmt.Errorf("清理过期记录失败: %w", err)
+				}
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				// 定时执行时单次失败只报告，不中断后续执行
+				if err := client.Expire(opts); err != nil {
+					common.PrintError(fmt.Sprintf("清理过期记录失败: %v", err))
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	runCmd.Flags().StringVarP(&opts.File, "file", "f", "", "过期配置文件路径，默认 ~/.basesql/expire.yaml")
+	runCmd.Flags().StringVarP(&opts.Table, "table", "t", "", "只处理指定的表")
+	runCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "只列出过期记录，不删除、不归档")
+	runCmd.Flags().IntVar(&opts.BatchSize, "batch-size", 0, "每批删除的记录数，默认 500")
+	runCmd.Flags().DurationVar(&interval, "interval", 0, "按间隔重复执行，如 1h，0 表示只执行一次")
+
+	cmd.AddCommand(runCmd)
+	return cmd
+}
+
+// newGenerateCmd 创建记录生成命令
+// 该命令按模板生成合成记录并批量写入，用于压测和搭建演示用的多维表格
+// 返回:
+//   - *cobra.Command: 记录生成命令实例
+func newGenerateCmd() *cobra.Command {
+	var opts cli.GenerateOptions
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "按模板生成合成记录",
+		Long: `按模板为表生成指定数量的合成记录，每批最多 500 条写入，用于压测和搭建演示数据。
+
+模板按字段声明生成器，可选的生成器：
+  • name、email、phone、url     中文姓名、邮箱、手机号、网址
+  • word、sentence               单词、句子（words 指定单词数）
+  • number、float                范围内的整数、小数（min、max）
+  • date                         范围内的时间（from、to）
+  • bool、choice                 布尔值、从 values 中随机选择
+  • sequence、constant           带前缀的递增编号（prefix、start）、固定值（value）
+
+未指定模板时按字段类型自动选择生成器，人员、附件和只读字段不会生成数据。
+
+模板示例：
+  seed: 42
+  fields:
+    name:   {type: name}
+    email:  {type: email}
+    age:    {type: number, min: 18, max: 60}
+    joined: {type: date, from: 2023-01-01, to: 2024-12-31}
+    status: {type: choice, values: [active, disabled]}`,
+		Example: `  # 按模板生成 1000 条记录
+  basesql generate --table demo --count 1000 --template fake.yaml
+
+  # 按字段类型自动生成，并固定随机种子以便复现
+  basesql generate --table demo --count 100 --seed 42`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Generate(opts); err != nil {
+				return fmt.Errorf("生成记录失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "表名")
+	cmd.Flags().IntVarP(&opts.Count, "count", "n", 100, "生成的记录数")
+	cmd.Flags().StringVar(&opts.Template, "template", "", "生成模板文件路径 (YAML 或 JSON)，未指定时按字段类型自动生成")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "随机种子，相同的种子生成相同的数据 (默认使用模板中的种子或当前时间)")
+	return cmd
+}
+
+// newImportCmd 创建 CSV 导入命令
+// 该命令根据前若干行推断字段类型，建表或校验已有表结构后批量写入记录
+// 返回:
+//   - *cobra.Command: CSV 导入命令实例
+func newImportCmd() *cobra.Command {
+	var opts cli.ImportOptions
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "从 CSV 文件导入记录",
+		Long: `从 CSV 文件导入记录，第一行为列名，每批最多 500 条写入。
+
+根据前 --sample 行推断每一列的字段类型：
+  • 复选框  全部为 true/false、yes/no、是/否
+  • 数字    全部为数字（以 0 开头的编号保留为文本）
+  • 日期    全部为 2006-01-02、2006/01/02、2006-01-02 15:04:05 等格式
+  • 单选    取值不超过 20 种且重复出现
+  • 文本    其他情况
+
+目标表不存在时按推断的类型创建；已存在时检查每一列都有对应的可写字段且类型兼容，
+不兼容时不写入任何记录。
+
+--mode upsert 按 --key 指定的列匹配已有记录，存在则更新，否则插入，
+更新时 CSV 中的空值不会清空已有的字段值。
+
+每写完一批保存一次检查点（<文件>.checkpoint），中断后使用 --resume 跳过已写入的行继续，
+导入完成后检查点会被删除。`,
+		Example: `  # 预览推断的字段类型和与已有表的比对结果
+  basesql import --file users.csv --table users --schema-only
+
+  # 导入记录，表不存在时自动创建
+  basesql import --file users.csv --table users
+
+  # 按 email 匹配已有记录，存在则更新，否则插入
+  basesql import --file users.csv --table users --mode upsert --key email
+
+  # 从上次中断的位置继续
+  basesql import --file users.csv --table users --resume`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.File == "" {
+				return fmt.Errorf("请通过 --file 指定 CSV 文件")
+			}
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Import(opts); err != nil {
+				return fmt.Errorf("导入失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "CSV 文件路径，第一行为列名")
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "目标表名，不存在时自动创建")
+	cmd.Flags().IntVar(&opts.SampleRows, "sample", cli.DefaultImportSampleRows, "用于推断字段类型的行数")
+	cmd.Flags().BoolVar(&opts.SchemaOnly, "schema-only", false, "只预览推断的字段类型和与已有表的比对结果，不写入")
+	cmd.Flags().StringVar(&opts.Mode, "mode", cli.ImportModeInsert, "导入模式: insert (全部插入) 或 upsert (按 --key 匹配已有记录，存在则更新)")
+	cmd.Flags().StringVar(&opts.Key, "key", "", "upsert 模式下用于匹配已有记录的列名")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "从检查点继续上次中断的导入")
+	return cmd
+}
+
+// newExportCmd 创建 CSV 导出命令
+// 该命令按页读取表中的全部记录写入 CSV 文件，支持从检查点继续
+// 返回:
+//   - *cobra.Command: CSV 导出命令实例
+func newExportCmd() *cobra.Command {
+	var opts cli.ExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "将表中的记录导出为 CSV 文件",
+		Long: `按页（每页 500 条）读取表中的全部记录写入 CSV 文件，列与表中字段的顺序一致。
+
+每写完一页保存一次检查点（<文件>.checkpoint），记录已导出的条数、下一页的分页标记和文件大小。
+中断后使用 --resume 截断检查点之后写入的内容并从下一页继续，导出完成后检查点会被删除。`,
+		Example: `  # 导出整张表
+  basesql export --table users --file users.csv
+
+  # 从上次中断的位置继续
+  basesql export --table users --file users.csv --resume`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+			if opts.File == "" {
+				return fmt.Errorf("请通过 --file 指定导出文件")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Export(opts); err != nil {
+				return fmt.Errorf("导出失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "表名")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "导出的 CSV 文件路径")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "从检查点继续上次中断的导出")
+	return cmd
+}
+
+// newDiffCmd 创建比较命令
+// 该命令按键列比较表与 CSV 文件或另一张表，可以按数据源修改表
+// 返回:
+//   - *cobra.Command: 比较命令实例
+func newDiffCmd() *cobra.Command {
+	var opts cli.DiffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "比较表与 CSV 文件或另一张表",
+		Long: `以 CSV 文件或另一张表为数据源，按 --key 指定的列匹配记录，列出：
+  • 新增  数据源中有、表中没有的行
+  • 删除  表中有、数据源中没有的行
+  • 变更  两边都有但字段值不同的行
+
+只比较数据源中与表的可写字段同名的列，数据源中的值按字段类型转换后再与表中的显示值比较。
+指定 --apply 后按数据源修改表：插入新增行、更新变化的字段、删除数据源中没有的记录。
+--format json 输出完整的比较结果。`,
+		Example: `  # 比较表与 CSV 文件
+  basesql diff --table users --file users.csv --key email
+
+  # 比较两张表
+  basesql diff --table users --source-table users_staging --key email
+
+  # 按 CSV 文件修改表，使两边一致
+  basesql diff --table users --file users.csv --key email --apply`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+			if opts.Key == "" {
+				return fmt.Errorf("请通过 --key 指定用于匹配记录的键列")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Diff(opts); err != nil {
+				return fmt.Errorf("比较失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "要比较的表名")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "作为数据源的 CSV 文件，第一行为列名")
+	cmd.Flags().StringVar(&opts.SourceTable, "source-table", "", "作为数据源的另一张表")
+	cmd.Flags().StringVar(&opts.Key, "key", "", "用于匹配记录的键列")
+	cmd.Flags().BoolVar(&opts.Apply, "apply", false, "按数据源修改表：插入新增行、更新变更行、删除多出的记录")
+	return cmd
+}
+
+// newSyncCmd 创建双向同步命令
+// 该命令在表与 CSV 文件或 REST 接口之间双向同步，按冲突策略处理两边都有修改的行
+// 返回:
+//   - *cobra.Command: 同步命令实例
+func newSyncCmd() *cobra.Command {
+	var opts cli.SyncOptions
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "在表与外部数据源之间双向同步",
+		Long: `按 --key 指定的列匹配表与外部数据源中的记录，与上次同步的状态比较，
+把一边的新增、修改和删除应用到另一边。数据源地址格式为 <类型>:<地址>：
+  • csv:users.csv                       本地 CSV 文件
+  • csv:https://example.com/users.csv   通过 GET 读取、PUT 写回的 CSV
+  • rest:https://api.example.com/users  通过 GET 读取、PUT 写回的 JSON 对象数组
+
+两边都修改了同一行，或一边修改、另一边删除时为冲突，按 --conflict 处理：
+  • source-wins   以数据源为准（默认）
+  • bitable-wins  以多维表格为准
+  • newest-wins   以最后修改的一方为准，一边修改、一边删除时保留修改的一方
+
+首次同步没有状态，只在两边互相补齐缺少的行，不删除任何数据。
+一边删除的行默认在另一边保留并在结果中计数，指定 --delete 后才同步删除。
+冲突会逐行列出，--report 将同步结果和冲突报告以 JSON 写入文件。`,
+		Example: `  # 与 CSV 文件双向同步
+  basesql sync --table users --source csv:users.csv --key email
+
+  # 与 REST 接口同步，以最后修改的一方为准
+  basesql sync --table users --source rest:https://api.example.com/users --key email \
+    --conflict newest-wins --updated-column updated_at
+
+  # 同时同步删除的行
+  basesql sync --table users --source csv:users.csv --key email --delete
+
+  # 预览需要的修改，不写入任何一边
+  basesql sync --table users --source csv:users.csv --key email --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+			if opts.Source == "" {
+				return fmt.Errorf("请通过 --source 指定数据源")
+			}
+			if opts.Key == "" {
+				return fmt.Errorf("请通过 --key 指定用于匹配记录的键列")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Sync(opts); err != nil {
+				return fmt.Errorf("同步失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "要同步的表名")
+	cmd.Flags().StringVarP(&opts.Source, "source", "s", "", "数据源地址，如 csv:users.csv、rest:https://api.example.com/users")
+	cmd.Flags().StringVar(&opts.Key, "key", "", "用于匹配记录的键列")
+	cmd.Flags().StringVar(&opts.Conflict, "conflict", cli.SyncSourceWins, "冲突解决策略: source-wins, bitable-wins, newest-wins")
+	cmd.Flags().StringVar(&opts.UpdatedColumn, "updated-column", "", "数据源中记录行更新时间的列，newest-wins 使用")
+	cmd.Flags().StringVar(&opts.StateFile, "state", "", "同步状态文件路径（默认 ~/.basesql/sync/ 下按表名和数据源生成）")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "将同步结果和冲突报告以 JSON 写入该文件")
+	cmd.Flags().BoolVar(&opts.Delete, "delete", false, "将一边删除的行在另一边也删除（默认保留）")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "只列出需要的修改和冲突，不写入任何一边")
+	return cmd
+}
+
+// newBenchCmd 创建压测命令
+// 该命令以指定的并发持续读写表，报告延迟分位数、错误类别和吞吐量
+// 返回:
+//   - *cobra.Command: 压测命令实例
+func newBenchCmd() *cobra.Command {
+	opts := cli.BenchOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "对表执行压测，报告延迟分位数和吞吐量",
+		Long: `以指定的并发持续读写表，所有请求都经过客户端的限流器、重试和熔断器，
+用于调整 QPS 和批大小。
+
+负载类型：
+  • read   分页读取记录，每次读取 --batch-size 条
+  • write  批量插入合成记录，每次插入 --batch-size 条（按字段类型自动生成，与 generate 相同）
+  • mixed  80% 读、20% 写
+
+报告每类操作的 p50/p95/p99/最大延迟、每秒操作数和记录数，以及按类别统计的错误
+（rate_limit、timeout、circuit_open、auth、http_<状态码>、api_<错误码> 等）。
+
+写入压测会在表中留下记录，建议使用单独的表，或通过 --cleanup 在结束后删除。`,
+		Example: `  # 10 个并发读取 60 秒
+  basesql bench --table t --workload read --concurrency 10 --duration 60s
+
+  # 混合负载，每次写入 50 条，结束后删除写入的记录
+  basesql bench --table t --workload mixed --batch-size 50 --cleanup
+
+  # 以 JSON 输出报告
+  basesql bench --table t --workload write --duration 30s --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			if err := client.Bench(opts); err != nil {
+				return fmt.Errorf("压测失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Table, "table", "t", "", "表名")
+	cmd.Flags().StringVarP(&opts.Workload, "workload", "w", cli.BenchWorkloadRead, "负载类型: read, write, mixed")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 10, "并发数")
+	cmd.Flags().DurationVar(&opts.Duration, "duration", 60*time.Second, "持续时间，如 30s、5m")
+	cmd.Flags().IntVar(&opts.BatchSize, "batch-size", 100, "每次读取或写入的记录数 (1-500)")
+	cmd.Flags().BoolVar(&opts.Cleanup, "cleanup", false, "结束后删除压测写入的记录")
+	return cmd
+}
+
+// newValidateCmd 创建数据校验命令
+// 该命令按校验规则审计表中已有的记录，存在未通过校验的记录时以非零退出码结束
+// 返回:
+//   - *cobra.Command: 数据校验命令实例
+func newValidateCmd() *cobra.Command {
+	var table string
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "按校验规则审计表中已有的记录",
+		Long: `按校验规则检查表中的全部记录，列出未通过校验的记录和原因。
+
+校验规则在规则文件（默认 ~/.basesql/rules.yaml，可通过 --rules 指定）中按表声明，
+同样的规则也会在 INSERT 和 UPDATE 写入前检查。
+
+规则文件示例：
+  tables:
+    users:
+      - field: email
+        required: true
+        pattern: '^[^@]+@[^@]+$'
+      - field: age
+        min: 0
+        max: 150
+      - field: status
+        enum: [active, disabled]`,
+		Example: `  # 审计 users 表
+  basesql validate --table users
+
+  # 使用指定的规则文件，并以 JSON 输出
+  basesql validate --table users --rules rules.yaml --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if table == "" {
+				return fmt.Errorf("请通过 --table 指定表名")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			return client.Validate(table)
+		},
+	}
+
+	cmd.Flags().StringVarP(&table, "table", "t", "", "表名")
+	return cmd
+}
+
+// newProfileCmd 创建数据画像命令
+// 该命令逐页扫描表中的记录，统计每个字段的填充率、不同值数量、数值范围和高频值
+// 返回:
+//   - *cobra.Command: 数据画像命令实例
+func newProfileCmd() *cobra.Command {
+	var topN int
+
+	cmd := &cobra.Command{
+		Use:   "profile <table>",
+		Short: "统计表中各字段的数据分布",
+		Long: `逐页扫描表中的全部记录，统计每个字段：
+
+  • 填充率和不同值数量
+  • 数字字段的最小值、最大值和平均值
+  • 日期字段的时间范围
+  • 单选、多选字段出现次数最多的选项
+
+记录按页流式处理，不会一次性加载到内存中，适合较大的表。
+统计结果缓存在 ~/.basesql/profiles.json，之后的查询据此先计算过滤掉记录最多的 WHERE 条件。`,
+		Example: `  # 统计 tasks 表
+  basesql profile tasks
+
+  # 显示前 10 个高频选项，并以 JSON 输出
+  basesql profile tasks --top 10 --format json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+			enableProfileCache(client)
+
+			if err := client.Profile(args[0], topN); err != nil {
+				return fmt.Errorf("统计失败: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&topN, "top", cli.DefaultProfileTopN, "单选、多选字段显示的高频选项数量")
+	return cmd
+}
+
+// newServeCmd 创建 REST 网关命令
+// 该命令启动常驻的 HTTP 服务，通过 POST /query 和 POST /exec 提供 SQL 能力；
+// 指定 --grpc-addr 时同时启动 gRPC 服务
+// 返回:
+//   - *cobra.Command: REST 网关命令实例
+func newServeCmd() *cobra.Command {
+	var (
+		addr     string
+		grpcAddr string
+		token    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "启动 SQL-over-HTTP 网关",
+		Long: `启动常驻的 REST 网关，让非 Go 服务也能通过 HTTP 使用 BaseSQL 的 SQL 能力。
+所有请求共享同一个客户端，复用访问令牌、表结构缓存和限流器。
+
+接口：
+  • POST /query   执行 SELECT，返回 {"columns": [...], "rows": [...], "row_count": N}
+  • POST /exec    执行 INSERT、UPDATE、DELETE、CREATE、DROP，返回 {"rows_affected": N}
+  • GET  /status  熔断器状态、稳定性组件和请求统计（也可以用 basesql status --server 查看）
+  • POST /circuit-breaker/trip、/circuit-breaker/reset  手动开启或关闭熔断（basesql breaker）
+  • GET  /healthz 健康检查
+
+请求体为 {"sql": "...", "params": {...}}，SQL 中的 :name 由 params 中的同名参数替换。
+除 /healthz 外的接口都需要携带 Authorization: Bearer <token>，令牌通过 --token 或环境变量 BASESQL_SERVE_TOKEN 设置。
+
+指定 --grpc-addr 时同时启动 gRPC 服务（定义见 api/basesqlpb/basesql.proto），
+提供 QueryStream 和 Exec 两个方法，调用需要在 metadata 中携带 authorization: Bearer <token>。
+将 --addr 设为空字符串可以只启动 gRPC 服务。`,
+		Example: `  # 启动网关
+  BASESQL_SERVE_TOKEN=secret basesql serve --addr :8080
+
+  # 调用
+  curl -H 'Authorization: Bearer secret' localhost:8080/query \
+    -d '{"sql": "SELECT * FROM users WHERE name = :name", "params": {"name": "张三"}}'
+
+  # 同时启动 gRPC 服务
+  BASESQL_SERVE_TOKEN=secret basesql serve --addr :8080 --grpc-addr :9090`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if token == "" {
+				token = os.Getenv("BASESQL_SERVE_TOKEN")
+			}
+			if token == "" {
+				return fmt.Errorf("请通过 --token 或环境变量 BASESQL_SERVE_TOKEN 设置访问令牌")
+			}
+			if addr == "" && grpcAddr == "" {
+				return fmt.Errorf("请至少通过 --addr 或 --grpc-addr 指定一个监听地址")
+			}
+
+			client, err := cli.NewClient(getConfig())
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			// 开始处理请求前预热访问令牌和表结构缓存
+			start := time.Now()
+			if err := client.Warmup(cmd.Context()); err != nil {
+				return fmt.Errorf("预热失败: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "🔥 预热完成，耗时 %s\n", time.Since(start).Round(time.Millisecond))
+
+			var (
+				server     *cli.Server
+				grpcServer *cli.GRPCServer
+			)
+			if addr != "" {
+				if server, err = cli.NewServer(client, token); err != nil {
+					return err
+				}
+			}
+			if grpcAddr != "" {
+				if grpcServer, err = cli.NewGRPCServer(client, token); err != nil {
+					return err
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// 两个服务并行运行，任意一个失败时停止另一个
+			errCh := make(chan error, 2)
+			running := 0
+			if server != nil {
+				running++
+				go func() {
+					err := server.ListenAndServe(ctx, addr)
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						err = fmt.Errorf("网关运行失败: %w", err)
+					} else {
+						err = nil
+					}
+					errCh <- err
+				}()
+				common.PrintSuccess(fmt.Sprintf("REST 网关已启动，监听 %s", addr))
+			}
+			if grpcServer != nil {
+				running++
+				go func() {
+					err := grpcServer.ListenAndServe(ctx, grpcAddr)
+					if err != nil {
+						err = fmt.Errorf("gRPC 服务运行失败: %w", err)
+					}
+					errCh <- err
+				}()
+				common.PrintSuccess(fmt.Sprintf("gRPC 服务已启动，监听 %s", grpcAddr))
+			}
+
+			var runErr error
+			for i := 0; i < running; i++ {
+				if err := <-errCh; err != nil && runErr == nil {
+					runErr = err
+					stop()
+				}
+			}
+			if runErr != nil {
+				return runErr
+			}
+
+			if grpcServer != nil {
+				for _, m := range grpcServer.Metrics() {
+					fmt.Printf("📊 %s: 调用 %d 次，失败 %d 次，累计耗时 %s\n", m.Method, m.Calls, m.Errors, m.TotalDuration.Round(time.Millisecond))
+				}
+			}
+			fmt.Println("👋 网关已停止")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "REST 网关监听地址，为空时不启动")
+	cmd.Flags().StringVar(&grpcAddr, "grpc-addr", "", "gRPC 服务监听地址，为空时不启动")
+	cmd.Flags().StringVar(&token, "token", "", "访问令牌（默认读取环境变量 BASESQL_SERVE_TOKEN）")
+	return cmd
+}
+
+// enableHistory 为客户端启用结构化查询历史
+// 参数:
+//   - client: CLI 客户端
+//
+// 返回:
+//   - *cli.HistoryStore: 历史存储，无法确定历史文件路径时返回 nil
+func enableHistory(client *cli.Client) *cli.HistoryStore {
+	path, err := cli.DefaultHistoryPath()
+	if err != nil {
+		common.Warnf("无法启用查询历史: %v", err)
+		return nil
+	}
+	store := cli.NewHistoryStore(path)
+	client.EnableHistory(store, cli.NewHistorySession())
+	return store
+}
+
+// enableStats 为客户端启用语句执行统计，无法确定统计文件路径时只输出警告
+// 参数:
+//   - client: CLI 客户端
+func enableStats(client *cli.Client) {
+	path, err := cli.DefaultStatsPath()
+	if err != nil {
+		common.Warnf("无法启用执行统计: %v", err)
+		return
+	}
+	client.EnableStats(cli.NewStatsStore(path))
+}
+
+// enableProfileCache 为客户端启用 profile 统计缓存，无法确定缓存文件路径时只输出警告
+// 参数:
+//   - client: CLI 客户端
+func enableProfileCache(client *cli.Client) {
+	path, err := cli.DefaultProfileCachePath()
+	if err != nil {
+		common.Warnf("无法启用统计缓存: %v", err)
+		return
+	}
+	client.EnableProfileCache(cli.NewProfileCache(path))
+}
+
+// executeInterruptible 执行一条 Shell 语句
+// 执行期间按下 Ctrl-C 会取消当前语句（中止正在进行的请求和分页）并回到提示符，而不是退出 Shell
+// 参数:
+//   - client: CLI 客户端
+//   - sql: SQL 语句
+//
+// 返回:
+//   - error: 错误信息，语句被取消时返回 cli.ErrQueryCancelled
+func executeInterruptible(client *cli.Client, sql string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return client.ExecuteContext(ctx, sql)
+}
+
+// handleHistoryCommand 处理查询历史相关的 Shell 命令
+//   - \history [关键字]: 查看最近的历史，可按关键字过滤
+//   - !N: 重新执行序号为 N 的历史语句
+//   - !!: 重新执行上一条历史语句
+//
+// 参数:
+//   - store: 历史存储
+//   - line: 用户输入
+//
+// 返回:
+//   - string: 需要执行的 SQL（仅 !N、!! 返回）
+//   - bool: 输入是否为历史命令
+func handleHistoryCommand(store *cli.HistoryStore, line string) (string, bool) {
+	command, keyword, _ := strings.Cut(line, " ")
+	isHistory := strings.EqualFold(command, "\\history")
+	isRerun := strings.HasPrefix(line, "!") && !strings.Contains(line, " ")
+	if !isHistory && !isRerun {
+		return "", false
+	}
+	if store == nil {
+		common.PrintError("查询历史不可用")
+		return "", true
+	}
+
+	if isHistory {
+		entries, err := store.Search(cli.HistoryFilter{Keyword: strings.TrimSpace(keyword), Limit: 20})
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		cli.PrintHistory(entries, false)
+		return "", true
+	}
+
+	var entry *cli.HistoryEntry
+	if line == "!!" {
+		entries, err := store.Search(cli.HistoryFilter{Limit: 1})
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		if len(entries) == 0 {
+			common.PrintError("还没有历史记录")
+			return "", true
+		}
+		entry = &entries[0]
+	} else {
+		id, err := strconv.Atoi(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			common.PrintError(fmt.Sprintf("无效的历史序号: %s", line))
+			return "", true
+		}
+		if entry, err = store.Get(id); err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+	}
+	return entry.SQL, true
+}
+
+// handleFmtCommand 处理 shell 中的 \fmt 命令，输出格式化后的 SQL
+// 参数:
+//   - line: 输入行
+//   - lastSQL: 最近一次执行的 SQL，\fmt 不带参数时格式化该语句
+//
+// 返回:
+//   - bool: 输入是否为 \fmt 命令
+func handleFmtCommand(line, lastSQL string) bool {
+	command, sql, _ := strings.Cut(line, " ")
+	if !strings.EqualFold(command, "\\fmt") {
+		return false
+	}
+	if sql = strings.TrimSpace(sql); sql == "" {
+		sql = lastSQL
+	}
+	if sql == "" {
+		common.PrintError("用法: \\fmt [SQL]（省略 SQL 时格式化上一条语句）")
+		return true
+	}
+	formatted, err := cli.FormatSQL(sql)
+	if err != nil {
+		common.PrintError(err.Error())
+		return true
+	}
+	if common.ColorEnabled(os.Stdout) {
+		formatted = cli.HighlightSQL(formatted)
+	}
+	fmt.Println(formatted)
+	return true
+}
+
+// handleSnippetCommand 处理 SQL 片段相关的 Shell 命令
+//   - \save name [SQL]: 保存片段，省略 SQL 时保存最近一次执行的语句
+//   - \list: 列出已保存的片段
+//   - \run name [key=value ...]: 替换参数后执行片段
+//
+// 参数:
+//   - store: 片段存储
+//   - line: 用户输入
+//   - lastSQL: 最近一次执行的 SQL
+//
+// 返回:
+//   - string: 需要执行的 SQL（仅 \run 返回）
+//   - bool: 输入是否为片段命令
+func handleSnippetCommand(store *cli.SnippetStore, line, lastSQL string) (string, bool) {
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(command) {
+	case "\\save":
+		name, sql, _ := strings.Cut(rest, " ")
+		if name == "" {
+			common.PrintError("用法: \\save name [SQL]")
+			return "", true
+		}
+		if strings.TrimSpace(sql) == "" {
+			sql = lastSQL
+		}
+		if err := store.Save(name, sql); err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		common.PrintSuccess(fmt.Sprintf("片段 '%s' 已保存", name))
+		return "", true
+
+	case "\\list":
+		list, err := store.List()
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		if len(list) == 0 {
+			fmt.Println("📭 还没有保存的片段，使用 \\save name [SQL] 保存")
+			return "", true
+		}
+		table := common.NewTable("Name", "Params", "SQL")
+		for _, snippet := range list {
+			table.AppendRow(snippet.Name, strings.Join(snippet.Params(), ", "), snippet.SQL)
+		}
+		fmt.Print(table.String())
+		return "", true
+
+	case "\\run":
+		name, args, _ := strings.Cut(rest, " ")
+		if name == "" {
+			common.PrintError("用法: \\run name [key=value ...]")
+			return "", true
+		}
+		snippet, err := store.Get(name)
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		params, err := cli.ParseSnippetParams(args)
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		sql, err := snippet.Render(params)
+		if err != nil {
+			common.PrintError(err.Error())
+			return "", true
+		}
+		return sql, true
+	}
+
+	return "", false
+}
+
+// newStatusCmd 创建运行状态命令
+// 指定 --server 时查看运行中网关的状态，否则连接多维表格并查看本地客户端的状态
+// 返回:
+//   - *cobra.Command: 运行状态命令实例
+func newStatusCmd() *cobra.Command {
+	var server, token string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "查看熔断器状态和请求统计",
+		Long: `查看客户端的熔断器状态（CLOSED、OPEN、HALF_OPEN）、连接池和限流器统计以及累计请求统计。
+
+熔断器状态只存在于常驻进程中，通常通过 --server 查看运行中的 serve 网关；
+不指定 --server 时连接多维表格，查看本次新建客户端的状态，可用于确认凭据和连接正常。`,
+		Example: `  # 查看网关状态
+  BASESQL_SERVE_TOKEN=secret basesql status --server http://localhost:8080
+
+  # 以 JSON 输出
+  basesql status --server http://localhost:8080 --token secret --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := getConfig()
+			format := strings.ToLower(config.Format)
+
+			if server != "" {
+				report, err := cli.FetchServerStatus(cmd.Context(), server, serveToken(token))
+				if err != nil {
+					return fmt.Errorf("获取网关状态失败: %w", err)
+				}
+				return cli.PrintStatus(report, format)
+			}
+
+			client, err := cli.NewClient(config)
+			if err != nil {
+				return fmt.Errorf("连接失败: %w", err)
+			}
+			defer client.Close()
+
+			report, err := client.Status()
+			if err != nil {
+				return err
+			}
+			return cli.PrintStatus(report, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "网关地址，如 http://localhost:8080")
+	cmd.Flags().StringVar(&token, "token", "", "网关访问令牌，默认读取环境变量 BASESQL_SERVE_TOKEN")
+	return cmd
+}
+
+// newBreakerCmd 创建熔断器控制命令
+// 手动开启或关闭运行中网关的熔断器
+// 返回:
+//   - *cobra.Command: 熔断器控制命令实例
+func newBreakerCmd() *cobra.Command {
+	var server, token string
+
+	cmd := &cobra.Command{
+		Use:       "breaker <trip|reset>",
+		Short:     "手动开启或关闭网关的熔断器",
+		ValidArgs: []string{cli.BreakerActionTrip, cli.BreakerActionReset},
+		Args:      cobra.ExactValidArgs(1),
+		Long: `手动控制运行中 serve 网关的熔断器。
+
+  • trip   开启熔断，网关之后的所有请求直接失败，不会自动恢复，适合飞书服务故障或需要暂停写入时使用
+  • reset  关闭熔断并清空失败计数，恢复正常请求
+
+命令输出操作后的网关状态。交互式 Shell 中可以用 \breaker trip、\breaker reset 控制当前会话的熔断器。`,
+		Example: `  # 暂停网关对飞书的请求
+  BASESQL_SERVE_TOKEN=secret basesql breaker trip --server http://localhost:8080
+
+  # 恢复
+  BASESQL_SERVE_TOKEN=secret basesql breaker reset --server http://localhost:8080`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if server == "" {
+				return fmt.Errorf("请通过 --server 指定运行中的网关地址")
+			}
+			config := getConfig()
+
+			report, err := cli.ControlServerCircuitBreaker(cmd.Context(), server, serveToken(token), args[0])
+			if err != nil {
+				return fmt.Errorf("控制熔断器失败: %w", err)
+			}
+			return cli.PrintStatus(report, strings.ToLower(config.Format))
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "网关地址，如 http://localhost:8080")
+	cmd.Flags().StringVar(&token, "token", "", "网关访问令牌，默认读取环境变量 BASESQL_SERVE_TOKEN")
+	return cmd
+}
+
+// newRefreshSchemaCmd 创建 refresh-schema 命令
+func newRefreshSchemaCmd() *cobra.Command {
+	var server, token string
+
+	cmd := &cobra.Command{
+		Use:   "refresh-schema",
+		Short: "刷新本地表结构缓存，或让运行中的网关按名称重新解析表和字段",
+		Args:  cobra.NoArgs,
+		Long: `不指定 --server 时刷新本地的表结构缓存：删除 ~/.basesql/cache 中当前多维表格的缓存文件，
+重新获取全部数据表和字段列表并写入缓存。CLI 命令默认缓存表结构 5 分钟，
+在飞书界面中建表、改字段后需要立即生效时执行本命令，或在单条命令上使用 --no-cache。
+
+指定 --server 时清空运行中 serve 网关记住的表 ID、字段 ID 和表结构缓存。
+网关按表名第一次访问表后记住表 ID，之后在飞书界面中给表或字段改名不影响按原名称访问；
+调用方已改用新名称、或需要访问删除后重建的同名表时，执行本命令让网关按名称重新解析。
+交互式 Shell 中可以用 \refresh 刷新当前会话。`,
+		Example: `  # 刷新本地表结构缓存
+  basesql refresh-schema
+
+  # 刷新运行中的网关
+  BASESQL_SERVE_TOKEN=secret basesql refresh-schema --server http://localhost:8080`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := getConfig()
+			format := strings.ToLower(config.Format)
+
+			if server == "" {
+				// 刷新的就是磁盘上的缓存，忽略 --no-cache
+				config.NoCache = false
+				client, err := cli.NewClient(config)
+				if err != nil {
+					return fmt.Errorf("连接失败: %w", err)
+				}
+				defer client.Close()
+
+				if err := client.RefreshSchema(); err != nil {
+					return err
+				}
+				if err := client.Warmup(cmd.Context()); err != nil {
+					return fmt.Errorf("刷新表结构失败: %w", err)
+				}
+				if format != cli.OutputFormatJSON {
+					common.PrintSuccess("已刷新本地表结构缓存")
+				}
+				report, err := client.Status()
+				if err != nil {
+					return err
+				}
+				return cli.PrintStatus(report, format)
+			}
+
+			report, err := cli.RefreshServerSchema(cmd.Context(), server, serveToken(token))
+			if err != nil {
+				return fmt.Errorf("刷新表结构失败: %w", err)
+			}
+			if format != cli.OutputFormatJSON {
+				common.PrintSuccess("已清空表 ID、字段 ID 和表结构缓存")
+			}
+			return cli.PrintStatus(report, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "网关地址，如 http://localhost:8080，不指定时刷新本地表结构缓存")
+	cmd.Flags().StringVar(&token, "token", "", "网关访问令牌，默认读取环境变量 BASESQL_SERVE_TOKEN")
+	return cmd
+}
+
+// serveToken 获取网关访问令牌，未通过参数指定时读取环境变量 BASESQL_SERVE_TOKEN
+func serveToken(token string) string {
+	if token == "" {
+		return os.Getenv("BASESQL_SERVE_TOKEN")
+	}
+	return token
+}
+
+// handleBreakerCommand 处理交互式 Shell 中的熔断器命令
+//   - \breaker: 查看熔断器状态和请求统计
+//   - \breaker trip: 手动开启熔断
+//   - \breaker reset: 手动关闭熔断
+//
+// 返回:
+//   - bool: 输入是否为熔断器命令
+func handleBreakerCommand(client *cli.Client, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "\\breaker" {
+		return false
+	}
+	if len(fields) > 2 {
+		common.PrintError("用法: \\breaker [trip|reset]")
+		return true
+	}
+	if len(fields) == 2 {
+		if err := client.ControlCircuitBreaker(fields[1]); err != nil {
+			common.PrintError(err.Error())
+			return true
+		}
+	}
+	report, err := client.Status()
+	if err != nil {
+		common.PrintError(err.Error())
+		return true
+	}
+	_ = cli.PrintStatus(report, "")
+	return true
+}
+
+// printShellHelp 显示交互式 Shell 的帮助信息
+func printShellHelp() {
+	fmt.Println("📚 BaseSQL 交互式 Shell 帮助")
+	fmt.Println("")
+	fmt.Println("🔧 内置命令:")
+	fmt.Println("  help, \\h     显示此帮助信息")
+	fmt.Println("  exit, quit, \\q  退出 Shell")
+	fmt.Println("  clear, \\c    清屏")
+	fmt.Println("  \\save name [SQL]            保存 SQL 片段（省略 SQL 时保存上一条语句）")
+	fmt.Println("  \\list                       列出已保存的片段")
+	fmt.Println("  \\run name [key=value ...]   执行片段，替换其中的 :key 参数")
+	fmt.Println("  \\history [关键字]           查看最近的查询历史")
+	fmt.Println("  !N, !!                      重新执行第 N 条 / 上一条历史语句")
+	fmt.Println("  \\breaker [trip|reset]       查看熔断器状态，或手动开启 / 关闭熔断")
+	fmt.Println("  \\refresh                    清空记住的表 ID、字段 ID 和表结构缓存，按名称重新解析")
+	fmt.Println("  \\fmt [SQL]                  格式化 SQL（省略 SQL 时格式化上一条语句）")
+	fmt.Println("")
+	fmt.Println("📝 SQL 命令示例:")
+	fmt.Println("  SHOW TABLES;")
+	fmt.Println("  SHOW COLUMNS FROM table_name;")
+	fmt.Println("  SELECT * FROM table_name;")
+	fmt.Println("  SELECT field1, field2 FROM table_name WHERE condition;")
+	fmt.Println("  INSERT INTO table (field1, field2) VALUES (value1, value2);")
+	fmt.Println("  UPDATE table SET field1=value1 WHERE condition;")
+	fmt.Println("  DELETE FROM table WHERE condition;")
+	fmt.Println("  USE table_name;              设置默认表，之后可以省略 FROM，如 SELECT * WHERE condition; COUNT(*);")
+	fmt.Println("")
+	fmt.Println("💡 提示:")
+	fmt.Println("  • 使用上下箭头键浏览命令历史，Ctrl+R 反向搜索历史")
+	fmt.Println("  • 使用 Tab 键进行自动补全")
+	fmt.Println("  • SQL 语句可以不加分号结尾")
+	fmt.Println("  • 以 \\G 结尾的语句纵向显示结果，适合字段较多的表")
+	fmt.Println("  • 语句执行期间按 Ctrl+C 取消当前语句并回到提示符")
+	fmt.Println("")
+}
+
+// getConfig 从命令行参数和环境变量获取配置
+// 该函数会按优先级顺序获取配置：命令行参数 > 环境变量 > 配置文件
+// 返回:
+//   - *cli.Config: 配置实例
+func getConfig() *cli.Config {
+	// 初始化日志系统
+	if noColor {
+		common.DisableColor()
+	}
+	if err := common.InitializeLogging(debug, common.GetConfigValue(logFile, "BASESQL_LOG_FILE"), nil); err != nil {
 		fmt.Fprintf(os.Stderr, "❌ 日志系统初始化失败: %v\n", err)
 	}
 
@@ -513,6 +2316,21 @@ func getConfig() *cli.Config {
 		AppSecret:  appSecret,
 		AppToken:   appToken,
 		Debug:      debug,
+		Format:     format,
+		TableStyle: tableStyle,
+		Vertical:   vertical,
+		Stats:      stats,
+		RulesFile:  rulesFile,
+		ReadOnly:   readOnly,
+		PolicyFile: policyFile,
+		Profile:    profile,
+		NotifyFile: notifyFile,
+		LazyAuth:   lazyAuth,
+		Collation:  collation,
+		MaxRows:    maxRows,
+		PageSize:   pageSize,
+		SlowQuery:  slowQuery,
+		NoCache:    noCache,
 	}
 
 	// 如果命令行参数为空，尝试从环境变量获取
@@ -543,6 +2361,7 @@ func newCompleter() readline.AutoCompleter {
 		),
 		readline.PcItem("DESC"),
 		readline.PcItem("DESCRIBE"),
+		readline.PcItem("USE"),
 		readline.PcItem("INSERT",
 			readline.PcItem("INTO"),
 		),
@@ -0,0 +1,163 @@
+package basesql
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+)
+
+// SortCollation 客户端排序规则
+// 飞书 API 的排序按编码比较文本，中文姓名的顺序与用户预期不一致；
+// 设置排序规则后，带 ORDER BY 的查询在读取记录后按该规则在客户端重新排序
+type SortCollation string
+
+const (
+	// SortCollationUnicode 按 Unicode 排序算法（UCA）排序，与语言无关
+	SortCollationUnicode SortCollation = "unicode"
+	// SortCollationPinyin 中文按拼音排序，英文按字母排序，文本中的数字按数值比较
+	SortCollationPinyin SortCollation = "pinyin"
+	// SortCollationNumeric 按 Unicode 排序算法排序，文本中的数字按数值比较（item2 排在 item10 之前）
+	SortCollationNumeric SortCollation = "numeric"
+)
+
+// sortCollationSettingKey 语句设置中单次查询排序规则的键
+const sortCollationSettingKey = "basesql:sort_collation"
+
+// WithSortCollation 为单次查询指定客户端排序规则，覆盖 Config.SortCollation
+//
+//	basesql.WithSortCollation(db, basesql.SortCollationPinyin).Order("name").Find(&users)
+//
+// 参数:
+//   - db: GORM 数据库实例
+//   - collation: 排序规则
+//
+// 返回:
+//   - *gorm.DB: 设置了排序规则的数据库实例
+func WithSortCollation(db *gorm.DB, collation SortCollation) *gorm.DB {
+	return db.Set(sortCollationSettingKey, collation)
+}
+
+// validate 检查排序规则是否受支持，空值表示使用服务端排序
+func (s SortCollation) validate() bool {
+	switch s {
+	case "", SortCollationUnicode, SortCollationPinyin, SortCollationNumeric:
+		return true
+	}
+	return false
+}
+
+// textComparer 比较两个文本，返回 -1、0 或 1
+type textComparer func(a, b string) int
+
+// sortComparer 获取查询结果在客户端排序使用的文本比较函数
+// 查询指定或配置了排序规则时使用对应的排序规则；仅配置不区分大小写时按大小写折叠比较；
+// 其余情况使用服务端的排序，返回 nil
+// 参数:
+//   - db: GORM 数据库实例，用于读取 WithSortCollation 的设置
+//   - config: BaseSQL 配置
+//
+// 返回:
+//   - textComparer: 文本比较函数，不需要客户端排序时为 nil
+func sortComparer(db *gorm.DB, config *Config) textComparer {
+	collation := SortCollation("")
+	if config != nil {
+		collation = config.SortCollation
+	}
+	if value, ok := db.Get(sortCollationSettingKey); ok {
+		if c, ok := value.(SortCollation); ok {
+			collation = c
+		}
+	}
+	fold := config.caseInsensitive()
+
+	var tag language.Tag
+	var options []collate.Option
+	switch collation {
+	case SortCollationUnicode:
+		tag = language.Und
+	case SortCollationPinyin:
+		tag, options = language.Chinese, []collate.Option{collate.Numeric}
+	case SortCollationNumeric:
+		tag, options = language.Und, []collate.Option{collate.Numeric}
+	default:
+		if fold {
+			return func(a, b string) int { return compareText(a, b, true) }
+		}
+		return nil
+	}
+	if fold {
+		options = append(options, collate.IgnoreCase)
+	}
+	// Collator 不能并发使用，每次排序单独创建
+	collator := collate.New(tag, options...)
+	return func(a, b string) int {
+		if c := collator.CompareString(a, b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	}
+}
+
+// sortRecords 在客户端按排序条件对记录稳定排序
+// 排序条件与 ListRecordsRequest.Sort 格式相同，字段名前的 - 表示降序；
+// 两边都是数字时按数值比较，其余按文本比较，空值排在最前
+// 参数:
+//   - records: 待排序的记录
+//   - keys: 排序条件
+//   - compare: 文本比较函数
+func sortRecords(records []*Record, keys []string, compare textComparer) {
+	if len(keys) == 0 || len(records) < 2 {
+		return
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, key := range keys {
+			column, desc := strings.TrimPrefix(key, "-"), strings.HasPrefix(key, "-")
+			c := compareFieldValues(records[i].Fields[column], records[j].Fields[column], compare)
+			if c == 0 {
+				continue
+			}
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compareFieldValues 比较两个字段值，返回 -1、0 或 1
+func compareFieldValues(a, b interface{}, compare textComparer) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return compare(likeText(a), likeText(b))
+}
+
+// compareText 按字符串比较规则比较文本，不区分大小写且折叠后相同时再按原文比较，保证顺序稳定
+func compareText(a, b string, fold bool) int {
+	if fold {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
@@ -1,6 +1,7 @@
 package basesql
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ag9920/basesql/internal/common"
@@ -17,6 +18,17 @@ const (
 	AuthTypeUser AuthType = "user"
 )
 
+// Collation 字符串比较规则
+// 作用于客户端完成的比较：无法完全下推的 LIKE、ILIKE、等于条件的校验，以及结果排序
+type Collation string
+
+const (
+	// CollationBinary 按字节比较，区分大小写（默认）
+	CollationBinary Collation = "binary"
+	// CollationCaseInsensitive 不区分大小写，按 Unicode 大小写折叠比较，中文等无大小写的字符不受影响
+	CollationCaseInsensitive Collation = "case_insensitive"
+)
+
 // Config 飞书多维表格配置
 type Config struct {
 	// 飞书应用配置
@@ -37,11 +49,55 @@ type Config struct {
 	MaxRetries      int           `json:"max_retries"`      // 最大重试次数
 	RetryInterval   time.Duration `json:"retry_interval"`   // 重试间隔
 	RateLimitQPS    int           `json:"rate_limit_qps"`   // 每秒请求限制
+	RateLimits      *RateLimits   `json:"rate_limits"`      // 分读写、分表的限流配置，设置后替代所有请求共享的默认限流器
 	BatchSize       int           `json:"batch_size"`       // 批量操作大小
 	CacheEnabled    bool          `json:"cache_enabled"`    // 是否启用缓存
 	CacheTTL        time.Duration `json:"cache_ttl"`        // 缓存过期时间
+	SchemaCacheTTL  time.Duration `json:"schema_cache_ttl"` // 表结构缓存的过期时间，0 表示不缓存；缓存期间其他客户端修改的表结构在过期前不可见
+	CacheDir        string        `json:"cache_dir"`        // 表结构缓存的持久化目录，设置 SchemaCacheTTL 后生效，之后创建的客户端在过期前直接使用
 	DebugMode       bool          `json:"debug_mode"`       // 调试模式
 	ConsistencyMode bool          `json:"consistency_mode"` // 一致性模式
+	LazyAuth        bool          `json:"lazy_auth"`        // 延迟认证，创建客户端时不获取访问令牌，第一次请求时再获取
+
+	// 连接池配置
+	MaxIdleConns       int           `json:"max_idle_conns"`      // 最多保留的空闲连接数，0 使用默认值 10
+	MaxConnsPerHost    int           `json:"max_conns_per_host"`  // 与飞书 API 的最大连接数（包括使用中和空闲的连接），达到上限后请求排队等待，0 表示不限制
+	IdleConnTimeout    time.Duration `json:"idle_conn_timeout"`   // 空闲连接的关闭时间，0 使用默认值 90 秒
+	EnableHTTP2        bool          `json:"enable_http2"`        // 尝试使用 HTTP/2，同一主机的并发请求复用一个连接
+	DisableCompression bool          `json:"disable_compression"` // 关闭响应压缩，默认请求 gzip 压缩的响应并透明解压
+
+	// 校验配置
+	ValidationRules map[string][]ValidationRule `json:"validation_rules"` // 按表名声明的字段校验规则，创建和更新前检查
+
+	// 访问控制
+	ReadOnly bool `json:"read_only"` // 只读模式，拒绝所有创建、更新、删除记录以及建表、删表、修改字段的操作
+
+	// 表结构
+	AutoCreateSchema bool `json:"auto_create_schema"` // 插入记录时自动创建不存在的表和字段，字段类型根据写入的值推断
+
+	// 写入
+	ReturnRecordAfterWrite bool `json:"return_record_after_write"` // 创建、更新记录后重新读取记录并设置到模型，公式、修改时间等服务端计算的字段随之更新
+	DecimalPlaces          int  `json:"decimal_places"`            // 十进制文本写入数字、货币字段时保留的小数位数，四舍五入，0 表示保留全部位数
+	TxWriteBuffer          bool `json:"tx_write_buffer"`           // 事务中的 Create 缓冲到提交时按表批量写入，回滚时丢弃；主键在提交后才设置
+
+	BusinessKeyGenerator func(table string) string `json:"-"` // 生成业务主键（`basesql:"business_key"` 字段）的函数，创建时字段为空则调用，为空时生成 UUID
+
+	// 查询
+	Collation       Collation     `json:"collation"`         // 字符串比较规则，为空时使用 CollationBinary
+	SortCollation   SortCollation `json:"sort_collation"`    // 客户端排序规则，为空时使用服务端的排序
+	MaxResultRows   int           `json:"max_result_rows"`   // 需要读取全部分页的查询语句最多返回的记录数，超过时截断并输出警告；写入前查找目标记录、聚合与 Count 的统计、Repo 和 Preload 的查找不受限制，0 表示不限制
+	DefaultPageSize int           `json:"default_page_size"` // 读取记录时的每页记录数，最大 500；0 表示读取全部分页时每页 500 条，只读取一页时使用飞书 API 的默认值
+
+	// 慢查询
+	SlowQueryThreshold time.Duration        `json:"slow_query_threshold"` // 慢查询阈值，语句耗时超过时记录警告日志并调用 SlowQueryHook，0 表示不检测
+	SlowQueryHook      func(SlowQueryEvent) `json:"-"`                    // 慢查询回调，在执行语句的协程中同步调用，应尽快返回
+
+	// 故障注入，仅用于测试；环境变量 BASESQL_ENV 为 production 或 prod 时不生效
+	ChaosRate  float64       `json:"chaos_rate"`  // 注入故障的 API 请求比例（0-1），受影响的请求失败或被延迟，0 表示不注入
+	ChaosDelay time.Duration `json:"chaos_delay"` // 受影响的请求最长延迟，设置后一半受影响的请求延迟后正常发出，0 表示受影响的请求全部失败
+
+	// 多租户
+	Registry *ClientRegistry `json:"-"` // 客户端注册表，设置后 gorm.Open 从注册表获取共享客户端
 }
 
 // DefaultConfig 返回默认配置
@@ -74,11 +130,63 @@ func (c *Config) Validate() error {
 	if c.AuthType == AuthTypeUser && c.AccessToken == "" {
 		return ErrInvalidConfig("access_token is required for user auth type")
 	}
+	if c.RateLimits != nil {
+		if err := c.RateLimits.validate(); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
+	switch c.Collation {
+	case "", CollationBinary, CollationCaseInsensitive:
+	default:
+		return ErrInvalidConfig(fmt.Sprintf("unsupported collation: %s", c.Collation))
+	}
+	if !c.SortCollation.validate() {
+		return ErrInvalidConfig(fmt.Sprintf("unsupported sort collation: %s", c.SortCollation))
+	}
+	if c.DecimalPlaces < 0 {
+		return ErrInvalidConfig("decimal_places must not be negative")
+	}
+	if c.MaxResultRows < 0 {
+		return ErrInvalidConfig("max_result_rows must not be negative")
+	}
+	if c.DefaultPageSize < 0 || c.DefaultPageSize > common.MaxPageSize {
+		return ErrInvalidConfig(fmt.Sprintf("default_page_size must be between 0 and %d", common.MaxPageSize))
+	}
+	if c.ChaosRate < 0 || c.ChaosRate > 1 {
+		return ErrInvalidConfig("chaos_rate must be between 0 and 1")
+	}
+	if c.ChaosDelay < 0 {
+		return ErrInvalidConfig("chaos_delay must not be negative")
+	}
+	if c.SlowQueryThreshold < 0 {
+		return ErrInvalidConfig("slow_query_threshold must not be negative")
+	}
+	if c.MaxIdleConns < 0 || c.MaxConnsPerHost < 0 || c.IdleConnTimeout < 0 {
+		return ErrInvalidConfig("max_idle_conns, max_conns_per_host and idle_conn_timeout must not be negative")
+	}
 	return nil
 }
 
+// caseInsensitive 判断字符串比较是否不区分大小写
+func (c *Config) caseInsensitive() bool {
+	return c != nil && c.Collation == CollationCaseInsensitive
+}
+
 // Clone 克隆配置
 func (c *Config) Clone() *Config {
 	clone := *c
 	return &clone
 }
+
+// CheckWritable 检查是否允许写操作，只读模式下拒绝
+// 参数:
+//   - operation: 操作名称，如 INSERT、DROP TABLE
+//
+// 返回:
+//   - error: 只读模式下返回包装 ErrReadOnly 的错误
+func (c *Config) CheckWritable(operation string) error {
+	if c != nil && c.ReadOnly {
+		return fmt.Errorf("%w: 不允许执行 %s", ErrReadOnly, operation)
+	}
+	return nil
+}
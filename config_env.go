@@ -0,0 +1,202 @@
+package basesql
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 配置项环境变量的前缀
+// Config 的每个字段都可以通过 BASESQL_<JSON 字段名大写> 环境变量覆盖，如 BASESQL_TIMEOUT、BASESQL_RATE_LIMIT_QPS
+const EnvPrefix = "BASESQL_"
+
+// ConfigEnvVar 一个配置项对应的环境变量
+type ConfigEnvVar struct {
+	Name        string `json:"name"`        // 环境变量名
+	Field       string `json:"field"`       // Config 中的字段名
+	Type        string `json:"type"`        // 值的格式：string、int、float、bool、duration、json
+	Default     string `json:"default"`     // DefaultConfig 中的默认值，为空表示零值
+	Description string `json:"description"` // 说明
+	Sensitive   bool   `json:"sensitive"`   // 是否为密钥等敏感信息，展示时需要遮蔽
+}
+
+// configEnvDescriptions Config 字段的说明，ConfigEnvVars 据此生成环境变量列表
+var configEnvDescriptions = map[string]string{
+	"AppID":                  "飞书应用 ID",
+	"AppSecret":              "飞书应用密钥",
+	"BaseURL":                "飞书开放平台 API 地址",
+	"AuthType":               "认证类型：tenant 或 user",
+	"AccessToken":            "用户访问令牌，认证类型为 user 时使用",
+	"AppToken":               "多维表格 app_token，也可以填写多维表格链接或知识库链接",
+	"TableID":                "默认数据表 ID",
+	"Timeout":                "请求超时时间",
+	"MaxRetries":             "最大重试次数",
+	"RetryInterval":          "重试间隔",
+	"RateLimitQPS":           "每秒请求数上限",
+	"RateLimits":             "分读写、分表的限流配置，JSON 格式，如 {\"read\":{\"qps\":20},\"write\":{\"qps\":5}}",
+	"BatchSize":              "批量操作的每批记录数",
+	"CacheEnabled":           "是否启用缓存",
+	"CacheTTL":               "缓存过期时间",
+	"SchemaCacheTTL":         "表结构缓存的过期时间，0 表示不缓存",
+	"CacheDir":               "表结构缓存的持久化目录，为空时只缓存在内存中",
+	"DebugMode":              "调试模式，输出每个请求的详细日志",
+	"ConsistencyMode":        "一致性模式",
+	"LazyAuth":               "延迟认证，第一次请求时再获取访问令牌",
+	"MaxIdleConns":           "最多保留的空闲连接数，0 使用默认值 10",
+	"MaxConnsPerHost":        "与飞书 API 的最大连接数，0 表示不限制",
+	"IdleConnTimeout":        "空闲连接的关闭时间，0 使用默认值 90 秒",
+	"EnableHTTP2":            "尝试使用 HTTP/2",
+	"DisableCompression":     "关闭响应压缩",
+	"ValidationRules":        "按表名声明的字段校验规则，JSON 格式，如 {\"users\":[{\"field\":\"email\",\"required\":true}]}",
+	"ReadOnly":               "只读模式，拒绝所有写操作",
+	"AutoCreateSchema":       "插入记录时自动创建不存在的表和字段",
+	"ReturnRecordAfterWrite": "创建、更新记录后重新读取记录并设置到模型",
+	"DecimalPlaces":          "十进制文本写入数字、货币字段时保留的小数位数，0 表示保留全部位数",
+	"TxWriteBuffer":          "事务中的创建缓冲到提交时按表批量写入，回滚时丢弃",
+	"Collation":              "字符串比较规则：binary 或 case_insensitive",
+	"SortCollation":          "客户端排序规则，为空时使用服务端的排序",
+	"MaxResultRows":          "需要读取全部分页的查询最多返回的记录数，0 表示不限制",
+	"DefaultPageSize":        "读取记录时的每页记录数，最大 500",
+	"SlowQueryThreshold":     "慢查询阈值，语句耗时超过时记录警告日志，0 表示不检测",
+	"ChaosRate":              "注入故障的 API 请求比例（0-1），仅用于测试，BASESQL_ENV=production 时不生效",
+	"ChaosDelay":             "故障注入时受影响请求的最长延迟，0 表示受影响的请求全部失败",
+}
+
+// configEnvSensitive 值为密钥的字段
+var configEnvSensitive = map[string]bool{"AppSecret": true, "AccessToken": true}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigEnvVars 返回 Config 所有字段对应的环境变量，按字段声明的顺序排列
+// 环境变量名由 JSON 字段名生成，不可序列化的字段（如 Registry）不支持通过环境变量设置
+// 返回:
+//   - []ConfigEnvVar: 环境变量列表
+func ConfigEnvVars() []ConfigEnvVar {
+	defaults := reflect.ValueOf(DefaultConfig()).Elem()
+	configType := defaults.Type()
+
+	vars := make([]ConfigEnvVar, 0, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		envVar := ConfigEnvVar{
+			Name:        EnvPrefix + strings.ToUpper(name),
+			Field:       field.Name,
+			Type:        envValueType(field.Type),
+			Description: configEnvDescriptions[field.Name],
+			Sensitive:   configEnvSensitive[field.Name],
+		}
+		if value := defaults.Field(i); !value.IsZero() {
+			envVar.Default = formatEnvValue(value)
+		}
+		vars = append(vars, envVar)
+	}
+	return vars
+}
+
+// envValueType 返回字段类型对应的环境变量值格式
+func envValueType(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int64:
+		return "int"
+	case reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "bool"
+	default:
+		return "json"
+	}
+}
+
+// formatEnvValue 将配置值格式化为环境变量中的写法
+func formatEnvValue(value reflect.Value) string {
+	switch envValueType(value.Type()) {
+	case "duration":
+		return time.Duration(value.Int()).String()
+	case "json":
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// LoadEnv 用 BASESQL_* 环境变量覆盖配置
+// 设置了的环境变量优先于代码中的配置，未设置或为空的环境变量不影响对应字段，
+// 适合容器部署时不修改代码、不挂载配置文件地调整超时、限流、重试等参数。
+// gorm.Open 初始化时会自动调用，环境变量列表见 ConfigEnvVars
+// 时长格式为 Go 的时长写法（如 30s、5m）或秒数；RateLimits、ValidationRules 为 JSON
+// 返回:
+//   - error: 环境变量的值无法解析
+func (c *Config) LoadEnv() error {
+	target := reflect.ValueOf(c).Elem()
+	for _, envVar := range ConfigEnvVars() {
+		raw := strings.TrimSpace(os.Getenv(envVar.Name))
+		if raw == "" {
+			continue
+		}
+		field := target.FieldByName(envVar.Field)
+		if err := setEnvValue(field, envVar.Type, raw); err != nil {
+			return ErrInvalidConfig(fmt.Sprintf("环境变量 %s 的值 %q 无效: %v", envVar.Name, raw, err))
+		}
+	}
+	return nil
+}
+
+// setEnvValue 解析环境变量的值并设置到字段
+func setEnvValue(field reflect.Value, valueType, raw string) error {
+	switch valueType {
+	case "duration":
+		if seconds, err := strconv.Atoi(raw); err == nil {
+			field.SetInt(int64(time.Duration(seconds) * time.Second))
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("需要时长，如 30s、5m 或秒数")
+		}
+		field.SetInt(int64(d))
+	case "int":
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("需要整数")
+		}
+		field.SetInt(int64(n))
+	case "float":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("需要数字")
+		}
+		field.SetFloat(f)
+	case "bool":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("需要 true 或 false")
+		}
+		field.SetBool(b)
+	case "string":
+		field.SetString(raw)
+	default:
+		value := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(raw), value.Interface()); err != nil {
+			return fmt.Errorf("需要 JSON: %w", err)
+		}
+		field.Set(value.Elem())
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package basesql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateMacro 飞书多维表格日期字段的相对日期筛选值，由服务端按当前日期计算范围，无需手动计算时间戳
+// 可以作为查询条件的值使用，如 db.Where("due_date = ?", basesql.Today)；
+// SQL 中也可以直接书写对应的宏，如 WHERE due_date = TODAY()、WHERE created_at IN THIS_WEEK
+type DateMacro string
+
+// 支持的相对日期，注释中为 SQL 中的写法
+const (
+	Today      DateMacro = "Today"        // TODAY()
+	Tomorrow   DateMacro = "Tomorrow"     // TOMORROW()
+	Yesterday  DateMacro = "Yesterday"    // YESTERDAY()
+	ThisWeek   DateMacro = "CurrentWeek"  // THIS_WEEK
+	LastWeek   DateMacro = "LastWeek"     // LAST_WEEK
+	ThisMonth  DateMacro = "CurrentMonth" // THIS_MONTH
+	LastMonth  DateMacro = "LastMonth"    // LAST_MONTH
+	Past7Days  DateMacro = "TheLastWeek"  // PAST_7_DAYS
+	Next7Days  DateMacro = "TheNextWeek"  // NEXT_7_DAYS
+	Past30Days DateMacro = "TheLastMonth" // PAST_30_DAYS
+	Next30Days DateMacro = "TheNextMonth" // NEXT_30_DAYS
+)
+
+// dateMacros SQL 中的日期宏名称到筛选值的映射
+var dateMacros = map[string]DateMacro{
+	"TODAY":        Today,
+	"TOMORROW":     Tomorrow,
+	"YESTERDAY":    Yesterday,
+	"THIS_WEEK":    ThisWeek,
+	"LAST_WEEK":    LastWeek,
+	"THIS_MONTH":   ThisMonth,
+	"LAST_MONTH":   LastMonth,
+	"PAST_7_DAYS":  Past7Days,
+	"NEXT_7_DAYS":  Next7Days,
+	"PAST_30_DAYS": Past30Days,
+	"NEXT_30_DAYS": Next30Days,
+}
+
+// dateMacroPattern 匹配以日期宏为值的比较条件，宏名称后可以带空括号
+var dateMacroPattern = regexp.MustCompile(`(?i)(\w+)` + "[`\"]?" + `\s*(!=|<>|=|>|<|\sIN\s)\s*(TODAY|TOMORROW|YESTERDAY|THIS_WEEK|LAST_WEEK|THIS_MONTH|LAST_MONTH|PAST_7_DAYS|NEXT_7_DAYS|PAST_30_DAYS|NEXT_30_DAYS)\b(?:\s*\(\s*\))?`)
+
+// dateMacroOperators 日期宏条件的比较符到飞书操作符的映射
+// IN 与等于相同，表示日期落在宏代表的范围内
+var dateMacroOperators = map[string]string{
+	"=":  "is",
+	"IN": "is",
+	"!=": "isNot",
+	"<>": "isNot",
+	">":  "isGreater",
+	"<":  "isLess",
+}
+
+// relativeTimePattern 匹配以 NOW() 加减时间间隔为值的比较条件，如 modified_at > NOW() - INTERVAL 7 DAY
+var relativeTimePattern = regexp.MustCompile(`(?i)(\w+)` + "[`\"]?" + `\s*(!=|<>|>=|<=|=|>|<)\s*NOW\s*\(\s*\)(?:\s*([+-])\s*INTERVAL\s+'?(\d+)'?\s+(SECOND|MINUTE|HOUR|DAY|WEEK|MONTH|YEAR)S?\b)?`)
+
+// relativeTimeOperators 相对时间条件的比较符到飞书操作符的映射
+var relativeTimeOperators = map[string]string{
+	"=":  "is",
+	"!=": "isNot",
+	"<>": "isNot",
+	">":  "isGreater",
+	">=": "isGreaterEqual",
+	"<":  "isLess",
+	"<=": "isLessEqual",
+}
+
+// buildDateCondition 将以日期宏或相对时间为值的 SQL 条件转换为飞书的日期筛选条件
+// 相对时间在每次执行时按当前时间计算
+// 参数:
+//   - sql: 条件表达式
+//
+// 返回:
+//   - *FilterCondition: 过滤条件，表达式中没有日期宏或相对时间时返回 nil
+func buildDateCondition(sql string) *FilterCondition {
+	if condition := buildRelativeTimeCondition(sql, time.Now()); condition != nil {
+		return condition
+	}
+	return buildDateMacroCondition(sql)
+}
+
+// buildRelativeTimeCondition 将以 NOW() 加减时间间隔为值的 SQL 条件转换为精确日期的筛选条件
+// 参数:
+//   - sql: 条件表达式，如 modified_at > NOW() - INTERVAL 7 DAY
+//   - now: 当前时间
+//
+// 返回:
+//   - *FilterCondition: 过滤条件，值为 ExactDate 和毫秒时间戳；表达式中没有 NOW() 时返回 nil
+func buildRelativeTimeCondition(sql string, now time.Time) *FilterCondition {
+	matches := relativeTimePattern.FindStringSubmatch(sql)
+	if matches == nil {
+		return nil
+	}
+
+	t := now
+	if matches[3] != "" {
+		n, err := strconv.Atoi(matches[4])
+		if err != nil {
+			return nil
+		}
+		if matches[3] == "-" {
+			n = -n
+		}
+		switch strings.ToUpper(matches[5]) {
+		case "SECOND":
+			t = t.Add(time.Duration(n) * time.Second)
+		case "MINUTE":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "HOUR":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "DAY":
+			t = t.AddDate(0, 0, n)
+		case "WEEK":
+			t = t.AddDate(0, 0, 7*n)
+		case "MONTH":
+			t = t.AddDate(0, n, 0)
+		case "YEAR":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+
+	return &FilterCondition{
+		FieldName: matches[1],
+		Operator:  relativeTimeOperators[matches[2]],
+		Value:     []interface{}{"ExactDate", strconv.FormatInt(t.UnixMilli(), 10)},
+	}
+}
+
+// buildDateMacroCondition 将以日期宏为值的 SQL 条件转换为飞书的日期筛选条件
+// 参数:
+//   - sql: 条件表达式，如 due_date = TODAY()、created_at IN THIS_WEEK
+//
+// 返回:
+//   - *FilterCondition: 过滤条件，表达式中没有日期宏时返回 nil
+func buildDateMacroCondition(sql string) *FilterCondition {
+	matches := dateMacroPattern.FindStringSubmatch(sql)
+	if matches == nil {
+		return nil
+	}
+	operator, ok := dateMacroOperators[strings.ToUpper(strings.TrimSpace(matches[2]))]
+	if !ok {
+		return nil
+	}
+	return &FilterCondition{
+		FieldName: matches[1],
+		Operator:  operator,
+		Value:     []interface{}{string(dateMacros[strings.ToUpper(matches[3])])},
+	}
+}
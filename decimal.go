@@ -0,0 +1,147 @@
+package basesql
+
+import (
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxExactDigits float64 能够无损表示的十进制有效数字位数
+	maxExactDigits = 15
+
+	// maxRatDecimalPlaces big.Rat 不能用有限小数表示时保留的小数位数
+	maxRatDecimalPlaces = 30
+)
+
+// decimalPattern JSON 数字的语法，写入数字字段的十进制文本必须符合
+var decimalPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// preciseNumber 解析响应中的数字
+// float64 能够无损表示时返回 float64，与 json.Unmarshal 的结果相同；
+// 有效数字超过 15 位（如小数位很多的货币金额）时返回 json.Number，保留原文，
+// 扫描到 decimal 类型、big.Rat 或字符串字段时不经过 float64
+// 参数:
+//   - text: 数字的原文
+//
+// 返回:
+//   - interface{}: float64 或 json.Number
+//   - error: 不是合法的数字
+func preciseNumber(text string) (interface{}, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return json.Number(text), nil
+		}
+		return nil, err
+	}
+	if !exactFloat(text) {
+		return json.Number(text), nil
+	}
+	return value, nil
+}
+
+// exactFloat 判断十进制数字的有效数字是否不超过 float64 能够无损表示的位数
+func exactFloat(text string) bool {
+	mantissa := strings.TrimPrefix(text, "-")
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		mantissa = mantissa[:i]
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	digits = strings.TrimLeft(digits, "0")
+	digits = strings.TrimRight(digits, "0")
+	return len(digits) <= maxExactDigits
+}
+
+// normalizeRecordNumbers 将以 UseNumber 解析的记录字段值中的数字转换为 preciseNumber 的结果
+func normalizeRecordNumbers(records []*Record) {
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+		for name, value := range record.Fields {
+			record.Fields[name] = normalizeNumbers(value)
+		}
+	}
+}
+
+// normalizeNumbers 递归转换值中的 json.Number
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if number, err := preciseNumber(v.String()); err == nil {
+			return number
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
+
+// decimalText 获取写入数字字段的十进制文本，写入时作为 json.Number 发送，不经过 float64
+// 支持 json.Number 和符合 JSON 数字语法的字符串；decimal.Decimal 等类型由 customGoValue 先转换为字符串
+// 参数:
+//   - value: 写入的值
+//   - places: 保留的小数位数，四舍五入，0 表示保留全部位数
+//
+// 返回:
+//   - string: 十进制文本
+//   - bool: 值是否为十进制文本
+func decimalText(value interface{}, places int) (string, bool) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = strings.TrimSpace(v)
+	default:
+		return "", false
+	}
+	if !decimalPattern.MatchString(text) {
+		return "", false
+	}
+	if places <= 0 {
+		return text, true
+	}
+	rat, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return "", false
+	}
+	return rat.FloatString(places), true
+}
+
+// ratText 将 big.Rat 转换为十进制文本，能用有限小数表示时不丢失精度
+func ratText(rat *big.Rat) string {
+	if rat.IsInt() {
+		return rat.Num().String()
+	}
+	// 分母只含因子 2 和 5 时是有限小数，小数位数为两者次数的较大值
+	denom := new(big.Int).Set(rat.Denom())
+	places := 0
+	for _, factor := range []int64{2, 5} {
+		count := 0
+		divisor := big.NewInt(factor)
+		remainder := new(big.Int)
+		for {
+			quotient, mod := new(big.Int).QuoRem(denom, divisor, remainder)
+			if mod.Sign() != 0 {
+				break
+			}
+			denom = quotient
+			count++
+		}
+		places = max(places, count)
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 || places > maxRatDecimalPlaces {
+		places = maxRatDecimalPlaces
+	}
+	return rat.FloatString(places)
+}
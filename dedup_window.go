@@ -0,0 +1,163 @@
+package basesql
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultDedupWindowSize 去重窗口默认保留的键数
+const defaultDedupWindowSize = 100000
+
+// dedupWindow 最近写入的去重键的滑动窗口，超过容量时淘汰最早写入的键
+// 设置了文件路径时，写入的键以 JSON Lines 格式追加到文件，进程重启后恢复窗口
+type dedupWindow struct {
+	mu      sync.Mutex
+	size    int                 // 窗口容量
+	keys    map[string]struct{} // 窗口中的键
+	order   []string            // 按写入顺序排列的键，用于淘汰
+	path    string              // 持久化文件路径，为空时只保存在内存中
+	appends int                 // 上次整理文件后追加的键数
+}
+
+// newDedupWindow 创建去重窗口，文件存在时从中恢复最近写入的键
+// 参数:
+//   - size: 窗口容量，0 表示 100000
+//   - path: 持久化文件路径，为空时不持久化
+//
+// 返回:
+//   - *dedupWindow: 去重窗口
+//   - error: 读取文件失败时返回错误，窗口仍然可用
+func newDedupWindow(size int, path string) (*dedupWindow, error) {
+	if size <= 0 {
+		size = defaultDedupWindowSize
+	}
+	w := &dedupWindow{size: size, keys: make(map[string]struct{}), path: path}
+	if path == "" {
+		return w, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return w, nil
+		}
+		return w, fmt.Errorf("打开去重文件失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var key string
+		if err := json.Unmarshal(scanner.Bytes(), &key); err != nil || key == "" {
+			continue
+		}
+		w.appends++
+		w.add(key)
+	}
+	if err := scanner.Err(); err != nil {
+		return w, fmt.Errorf("读取去重文件失败: %w", err)
+	}
+	return w, nil
+}
+
+// contains 判断键是否在窗口中
+func (w *dedupWindow) contains(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.keys[key]
+	return ok
+}
+
+// record 记录一批写入成功的键，并追加到持久化文件
+// 追加的键超过窗口容量时用窗口中的键重写文件，避免文件无限增长
+// 返回:
+//   - error: 写入文件失败时返回错误，内存中的窗口已更新
+func (w *dedupWindow) record(keys []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, key := range keys {
+		w.add(key)
+	}
+	if w.path == "" || len(keys) == 0 {
+		return nil
+	}
+
+	w.appends += len(keys)
+	if w.appends > w.size {
+		return w.compact()
+	}
+	return w.appendFile(keys)
+}
+
+// add 将键加入窗口，超过容量时淘汰最早的键，调用方需持有锁
+func (w *dedupWindow) add(key string) {
+	if _, ok := w.keys[key]; ok {
+		return
+	}
+	w.keys[key] = struct{}{}
+	w.order = append(w.order, key)
+	if len(w.order) > w.size {
+		delete(w.keys, w.order[0])
+		w.order = w.order[1:]
+	}
+}
+
+// appendFile 将键追加到持久化文件，调用方需持有锁
+func (w *dedupWindow) appendFile(keys []string) error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("创建去重文件目录失败: %w", err)
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开去重文件失败: %w", err)
+	}
+	defer file.Close()
+	return writeDedupKeys(file, keys)
+}
+
+// compact 用窗口中的键重写持久化文件，先写临时文件再替换，调用方需持有锁
+func (w *dedupWindow) compact() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("创建去重文件目录失败: %w", err)
+	}
+	tmp := w.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("创建去重文件失败: %w", err)
+	}
+	if err := writeDedupKeys(file, w.order); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入去重文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return fmt.Errorf("替换去重文件失败: %w", err)
+	}
+	w.appends = len(w.order)
+	return nil
+}
+
+// writeDedupKeys 以 JSON Lines 格式写入键
+func writeDedupKeys(file *os.File, keys []string) error {
+	writer := bufio.NewWriter(file)
+	for _, key := range keys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("序列化去重键失败: %w", err)
+		}
+		writer.Write(data)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("写入去重文件失败: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/ag9920/basesql/internal/common"
@@ -16,10 +17,12 @@ import (
 )
 
 // Dialector 实现了 GORM 的方言器接口，用于将 GORM 操作转换为飞书多维表格 API 调用
-// 它包含了配置信息和客户端实例，是整个驱动的核心组件
+// 它包含了配置信息和客户端实例，是整个驱动的核心组件。
+// 初始化后方言器不再修改，每条语句的状态只保存在语句内部，同一个 *gorm.DB 可以在多个协程中并发使用
 type Dialector struct {
-	*Config         // 配置信息，包含认证、超时、重试等设置
-	Client  *Client // 飞书 API 客户端实例
+	*Config           // 配置信息，包含认证、超时、重试等设置
+	Client  *Client   // 飞书 API 客户端实例，注入了其他 APIClient 实现时为空
+	API     APIClient // 回调发送请求使用的接口，为空时初始化为 Client；单元测试可以注入替身实现
 }
 
 // Open 创建并返回一个新的 BaseSQL 方言器实例
@@ -54,6 +57,13 @@ func mergeWithDefaults(userConfig *Config) *Config {
 	// 创建新的配置实例，避免修改原始配置
 	mergedConfig := *userConfig
 
+	// AppToken 可以直接填写从浏览器复制的多维表格链接；知识库链接在 Initialize 中换取
+	if IsBaseURL(mergedConfig.AppToken) {
+		if ref, err := ParseBaseURL(mergedConfig.AppToken); err == nil && ref.AppToken != "" {
+			mergedConfig.AppToken = ref.AppToken
+		}
+	}
+
 	// 只覆盖零值字段
 	if mergedConfig.BaseURL == "" {
 		mergedConfig.BaseURL = defaultConfig.BaseURL
@@ -106,13 +116,32 @@ func (d *Dialector) Initialize(db *gorm.DB) error {
 	if d.Config == nil {
 		return fmt.Errorf("配置信息不能为 nil")
 	}
+	if err := d.Config.LoadEnv(); err != nil {
+		return err
+	}
 
-	// 初始化飞书 API 客户端
-	client, err := NewClient(d.Config)
-	if err != nil {
-		return fmt.Errorf("初始化飞书 API 客户端失败: %w", err)
+	// 初始化飞书 API 客户端，配置了注册表时复用同一租户的客户端；注入了 API 时直接使用
+	if d.API == nil {
+		var client *Client
+		var err error
+		if d.Config.Registry != nil {
+			client, err = d.Config.Registry.Get(d.Config)
+		} else {
+			client, err = NewClient(d.Config)
+		}
+		if err != nil {
+			return fmt.Errorf("初始化飞书 API 客户端失败: %w", err)
+		}
+		d.Client = client
+		d.API = client
+	} else if client, ok := d.API.(*Client); ok {
+		d.Client = client
+	}
+	if d.Client != nil {
+		if err := resolveAppToken(context.Background(), d.Client, d.Config); err != nil {
+			return fmt.Errorf("解析多维表格链接失败: %w", err)
+		}
 	}
-	d.Client = client
 
 	// 设置自定义连接池，用于拦截 SQL 操作
 	db.ConnPool = &ConnPool{Dialector: d}
@@ -293,20 +322,26 @@ func (c *ConnPool) QueryRowContext(ctx context.Context, query string, args ...in
 // 用于兼容 GORM 的事务处理机制
 type Transaction struct {
 	connPool *ConnPool // 关联的连接池
+
+	mu      sync.Mutex
+	pending []*txCreate // 开启 Config.TxWriteBuffer 时缓冲的创建，提交时批量写入
 }
 
 // Commit 提交事务
 // 实现 sql.driver.Tx 接口
 func (tx *Transaction) Commit() error {
-	// 飞书多维表格不支持事务，所有操作都是立即提交的
+	// 飞书多维表格不支持事务，除缓冲的创建外，所有操作都是立即提交的
 	common.Debugf("Transaction.Commit: 飞书多维表格不支持事务，操作已自动提交")
-	return nil
+	return tx.flush()
 }
 
 // Rollback 回滚事务
 // 实现 sql.driver.Tx 接口
 func (tx *Transaction) Rollback() error {
-	// 飞书多维表格不支持事务回滚，空实现
+	// 缓冲的创建尚未写入，直接丢弃；其余操作已经执行，无法撤销
+	if n := tx.discard(); n > 0 {
+		common.Infof("Transaction.Rollback: 已丢弃事务中缓冲的 %d 条创建", n)
+	}
 	common.Warnf("Transaction.Rollback: 飞书多维表格不支持事务回滚，已执行的操作无法撤销")
 	return nil
 }
@@ -10,6 +10,7 @@ var (
 	ErrConnectionFailed   = errors.New("basesql: connection failed")
 	ErrInvalidCredentials = errors.New("basesql: invalid credentials")
 	ErrTableNotFound      = errors.New("basesql: table not found")
+	ErrAmbiguousTable     = errors.New("basesql: ambiguous table name")
 	ErrFieldNotFound      = errors.New("basesql: field not found")
 	ErrRecordNotFound     = errors.New("basesql: record not found")
 	ErrUnsupportedType    = errors.New("basesql: unsupported data type")
@@ -18,6 +19,8 @@ var (
 	ErrInvalidQuery       = errors.New("basesql: invalid query")
 	ErrPermissionDenied   = errors.New("basesql: permission denied")
 	ErrInvalidOperation   = errors.New("basesql: invalid operation")
+	ErrUniqueViolation    = errors.New("basesql: unique constraint violated")
+	ErrReadOnly           = errors.New("basesql: write rejected in read-only mode")
 )
 
 // BaseError 基础错误类型
@@ -0,0 +1,103 @@
+package basesql
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// clientTokenParam 飞书创建记录接口的幂等参数，同一 client_token 的重复请求只会创建一次
+const clientTokenParam = "client_token"
+
+// randReader 生成 UUID 使用的随机数来源，测试中替换为会失败的读取器
+var randReader io.Reader = rand.Reader
+
+// idempotencyKeyCtxKey 幂等键在上下文中的键
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey 为上下文设置幂等键
+// 默认情况下每次创建请求都会自动生成 client_token，只保证该请求的自动重试不会重复插入；
+// 设置幂等键后 client_token 由幂等键和请求内容共同确定，进程重启后使用相同的键重新写入相同的记录也不会重复插入
+// 参数:
+//   - ctx: 上下文
+//   - key: 幂等键，如业务单号
+//
+// 返回:
+//   - context.Context: 携带幂等键的上下文
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// withClientToken 为创建记录请求附加 client_token
+// 在重试循环之外调用，保证同一请求的所有重试使用相同的 client_token；
+// 请求已携带 client_token 或不是创建记录请求时原样返回
+// 返回:
+//   - *APIRequest: 附加了 client_token 的请求
+//   - error: 生成随机 client_token 失败，此时不能保证重试不会重复插入
+func withClientToken(ctx context.Context, req *APIRequest) (*APIRequest, error) {
+	if !isCreateRecordRequest(req) {
+		return req, nil
+	}
+	if _, ok := req.QueryParams[clientTokenParam]; ok {
+		return req, nil
+	}
+
+	var token string
+	if key, ok := ctx.Value(idempotencyKeyCtxKey{}).(string); ok && key != "" {
+		body, _ := json.Marshal(req.Body)
+		token = uuidFromKey(req.Path + "\x00" + key + "\x00" + string(body))
+	} else {
+		var err error
+		if token, err = newUUID(); err != nil {
+			return nil, fmt.Errorf("生成 client_token 失败: %w", err)
+		}
+	}
+
+	// 复制请求，避免修改调用方的 QueryParams
+	clone := *req
+	clone.QueryParams = make(map[string]string, len(req.QueryParams)+1)
+	for k, v := range req.QueryParams {
+		clone.QueryParams[k] = v
+	}
+	clone.QueryParams[clientTokenParam] = token
+	return &clone, nil
+}
+
+// isCreateRecordRequest 判断是否为创建记录请求（单条或批量）
+func isCreateRecordRequest(req *APIRequest) bool {
+	if req.Method != "POST" {
+		return false
+	}
+	return strings.HasSuffix(req.Path, "/records") || strings.HasSuffix(req.Path, "/records/batch_create")
+}
+
+// newUUID 生成随机的 UUID v4
+// 返回:
+//   - string: UUID
+//   - error: 读取随机数失败
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(randReader, b[:]); err != nil {
+		return "", fmt.Errorf("读取随机数失败: %w", err)
+	}
+	return formatUUID(b), nil
+}
+
+// uuidFromKey 由任意字符串确定性地生成 UUID v4 格式的标识
+func uuidFromKey(key string) string {
+	sum := sha1.Sum([]byte(key))
+	var b [16]byte
+	copy(b[:], sum[:16])
+	return formatUUID(b)
+}
+
+// formatUUID 设置版本和变体位并格式化为 UUID 字符串
+func formatUUID(b [16]byte) string {
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
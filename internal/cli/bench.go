@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// 压测的负载类型
+const (
+	BenchWorkloadRead  = "read"  // 只读：分页读取记录
+	BenchWorkloadWrite = "write" // 只写：批量插入合成记录
+	BenchWorkloadMixed = "mixed" // 混合：80% 读、20% 写
+)
+
+// benchMixedReadRatio 混合负载中读操作的比例
+const benchMixedReadRatio = 0.8
+
+// BenchOptions 压测选项
+type BenchOptions struct {
+	Table       string        // 表名
+	Workload    string        // 负载类型：read、write、mixed
+	Concurrency int           // 并发数
+	Duration    time.Duration // 持续时间
+	BatchSize   int           // 每次读取或写入的记录数
+	Cleanup     bool          // 结束后删除压测写入的记录
+}
+
+// BenchOpStats 单类操作的压测统计
+type BenchOpStats struct {
+	Operation  string         `json:"operation"`   // 操作类型：read、write 或 total
+	Requests   int            `json:"requests"`    // 完成的操作数
+	Errors     int            `json:"errors"`      // 失败的操作数
+	Records    int            `json:"records"`     // 成功读取或写入的记录数
+	Throughput float64        `json:"throughput"`  // 每秒成功的操作数
+	RecordRate float64        `json:"record_rate"` // 每秒成功读取或写入的记录数
+	P50        time.Duration  `json:"p50"`         // 延迟中位数
+	P95        time.Duration  `json:"p95"`         // 95 分位延迟
+	P99        time.Duration  `json:"p99"`         // 99 分位延迟
+	Max        time.Duration  `json:"max"`         // 最大延迟
+	ErrorKinds map[string]int `json:"error_kinds"` // 按类别统计的错误数
+	latencies  []time.Duration
+}
+
+// BenchReport 压测报告
+type BenchReport struct {
+	Table       string               `json:"table"`       // 表名
+	Workload    string               `json:"workload"`    // 负载类型
+	Concurrency int                  `json:"concurrency"` // 并发数
+	BatchSize   int                  `json:"batch_size"`  // 每次读取或写入的记录数
+	Elapsed     time.Duration        `json:"elapsed"`     // 实际耗时
+	Operations  []*BenchOpStats      `json:"operations"`  // 按操作类型的统计，最后一项为合计
+	Requests    basesql.RequestStats `json:"requests"`    // 压测期间的 API 请求统计（含重试和限流等待）
+	Cleaned     int                  `json:"cleaned"`     // 清理的记录数
+}
+
+// benchRecorder 并发收集压测样本
+type benchRecorder struct {
+	mu      sync.Mutex
+	ops     map[string]*BenchOpStats
+	created []string // 写入的记录 ID，用于清理
+	keepIDs bool
+}
+
+// record 记录一次操作的结果
+func (r *benchRecorder) record(op string, latency time.Duration, records int, ids []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.ops[op]
+	if !ok {
+		stats = &BenchOpStats{Operation: op, ErrorKinds: make(map[string]int)}
+		r.ops[op] = stats
+	}
+	stats.Requests++
+	if err != nil {
+		stats.Errors++
+		stats.ErrorKinds[classifyBenchError(err)]++
+		return
+	}
+	stats.Records += records
+	stats.latencies = append(stats.latencies, latency)
+	if r.keepIDs {
+		r.created = append(r.created, ids...)
+	}
+}
+
+// classifyBenchError 将错误归类，便于判断瓶颈在本地限流、服务端还是网络
+func classifyBenchError(err error) string {
+	if errors.Is(err, common.ErrCircuitOpen) {
+		return "circuit_open"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == 429 || apiErr.Type == "rate_limit":
+			return "rate_limit"
+		case apiErr.Code == 401 || apiErr.Code == 403:
+			return "auth"
+		case apiErr.Type == "http":
+			return fmt.Sprintf("http_%d", apiErr.Code)
+		default:
+			return fmt.Sprintf("api_%d", apiErr.Code)
+		}
+	}
+	if strings.Contains(err.Error(), "API调用失败") {
+		return "api"
+	}
+	return "other"
+}
+
+// finish 计算百分位延迟和吞吐量
+func (s *BenchOpStats) finish(elapsed time.Duration) {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	s.P50 = percentile(s.latencies, 50)
+	s.P95 = percentile(s.latencies, 95)
+	s.P99 = percentile(s.latencies, 99)
+	if n := len(s.latencies); n > 0 {
+		s.Max = s.latencies[n-1]
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		s.Throughput = float64(len(s.latencies)) / seconds
+		s.RecordRate = float64(s.Records) / seconds
+	}
+}
+
+// percentile 按最近秩法计算已排序样本的百分位数
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Bench 对表执行压测并输出延迟分位数、错误类别和吞吐量
+// 参数:
+//   - opts: 压测选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Bench(opts BenchOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	report, err := c.executor.Bench(opts)
+	if err != nil {
+		return err
+	}
+
+	if c.config.Format == OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	t := c.executor.newTable("operation", "requests", "errors", "ops/s", "records/s", "p50", "p95", "p99", "max")
+	for _, op := range report.Operations {
+		t.AppendRow(op.Operation, strconv.Itoa(op.Requests), strconv.Itoa(op.Errors),
+			fmt.Sprintf("%.1f", op.Throughput), fmt.Sprintf("%.1f", op.RecordRate),
+			formatBenchLatency(op.P50), formatBenchLatency(op.P95), formatBenchLatency(op.P99), formatBenchLatency(op.Max))
+	}
+	c.executor.printTable(t)
+
+	total := report.Operations[len(report.Operations)-1]
+	if len(total.ErrorKinds) > 0 {
+		kinds := make([]string, 0, len(total.ErrorKinds))
+		for kind, n := range total.ErrorKinds {
+			kinds = append(kinds, fmt.Sprintf("%s %d", kind, n))
+		}
+		sort.Strings(kinds)
+		fmt.Printf("❗ 错误类别: %s\n", strings.Join(kinds, "，"))
+	}
+	fmt.Printf("📊 负载 %s，并发 %d，批大小 %d，耗时 %s；API 调用 %d 次，重试 %d 次，限流等待 %d 次（%s）\n",
+		report.Workload, report.Concurrency, report.BatchSize, report.Elapsed.Round(time.Millisecond),
+		report.Requests.APICalls, report.Requests.Retries, report.Requests.RateLimitWaits,
+		report.Requests.RateLimitWaitTime.Round(time.Millisecond))
+	if report.Cleaned > 0 {
+		fmt.Printf("🧹 已删除压测写入的 %d 条记录\n", report.Cleaned)
+	}
+	return nil
+}
+
+// formatBenchLatency 以毫秒显示延迟
+func formatBenchLatency(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// Bench 执行压测
+// 所有请求都经过客户端的限流器、重试和熔断器，结果反映实际可用的吞吐量
+// 参数:
+//   - opts: 压测选项
+//
+// 返回:
+//   - *BenchReport: 压测报告
+//   - error: 错误信息
+func (e *Executor) Bench(opts BenchOptions) (*BenchReport, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if opts.Concurrency <= 0 {
+		return nil, fmt.Errorf("并发数必须大于 0")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("持续时间必须大于 0")
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > common.MaxBatchSize {
+		return nil, fmt.Errorf("批大小必须在 1 到 %d 之间", common.MaxBatchSize)
+	}
+
+	reads, writes := false, false
+	switch opts.Workload {
+	case BenchWorkloadRead:
+		reads = true
+	case BenchWorkloadWrite:
+		writes = true
+	case BenchWorkloadMixed:
+		reads, writes = true, true
+	default:
+		return nil, fmt.Errorf("不支持的负载类型 '%s'，可选值: %s, %s, %s",
+			opts.Workload, BenchWorkloadRead, BenchWorkloadWrite, BenchWorkloadMixed)
+	}
+	if reads {
+		if err := e.policy.Check(common.CommandSelect, opts.Table); err != nil {
+			return nil, err
+		}
+	}
+	if writes {
+		if err := e.config.CheckWritable("INSERT"); err != nil {
+			return nil, err
+		}
+		if err := e.policy.Check(common.CommandInsert, opts.Table); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Cleanup && writes {
+		if err := e.policy.Check(common.CommandDelete, opts.Table); err != nil {
+			return nil, err
+		}
+	}
+
+	setupCtx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(setupCtx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	// 写入使用与 generate 命令相同的默认生成器
+	specs := make(map[string]*GeneratorSpec)
+	var fields []basesql.Field
+	if writes {
+		if fields, err = e.getFieldsList(setupCtx, tableID); err != nil {
+			return nil, err
+		}
+		for i := range fields {
+			if spec := defaultGenerator(&fields[i]); spec != nil {
+				specs[fields[i].FieldName] = spec
+			}
+		}
+		if len(specs) == 0 {
+			return nil, fmt.Errorf("表 '%s' 中没有可以自动生成数据的字段，无法执行写入压测", opts.Table)
+		}
+	}
+
+	recorder := &benchRecorder{ops: make(map[string]*BenchOpStats), keepIDs: opts.Cleanup}
+	before := e.client.RequestStats()
+
+	ctx, stop := context.WithTimeout(e.baseContext(), opts.Duration)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "⏱  压测 %s（负载 %s，并发 %d），持续 %s...\n", opts.Table, opts.Workload, opts.Concurrency, opts.Duration)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for worker := 0; worker < opts.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(worker)))
+			gen := &generator{rng: rng}
+			for seq := 0; ctx.Err() == nil; seq++ {
+				op := BenchWorkloadRead
+				if !reads || (writes && rng.Float64() >= benchMixedReadRatio) {
+					op = BenchWorkloadWrite
+				}
+
+				opStart := time.Now()
+				var n int
+				var ids []string
+				var err error
+				if op == BenchWorkloadRead {
+					n, err = e.benchRead(ctx, tableID, opts.BatchSize)
+				} else {
+					ids, err = e.benchWrite(ctx, tableID, fields, specs, gen, opts.BatchSize, seq)
+					n = len(ids)
+				}
+				latency := time.Since(opStart)
+
+				// 压测结束时被中断的请求不计入统计
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				recorder.record(op, latency, n, ids, err)
+			}
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &BenchReport{
+		Table:       opts.Table,
+		Workload:    opts.Workload,
+		Concurrency: opts.Concurrency,
+		BatchSize:   opts.BatchSize,
+		Elapsed:     elapsed,
+		Requests:    e.client.RequestStats().Sub(before),
+	}
+
+	total := &BenchOpStats{Operation: "total", ErrorKinds: make(map[string]int)}
+	for _, op := range []string{BenchWorkloadRead, BenchWorkloadWrite} {
+		stats, ok := recorder.ops[op]
+		if !ok {
+			continue
+		}
+		total.Requests += stats.Requests
+		total.Errors += stats.Errors
+		total.Records += stats.Records
+		total.latencies = append(total.latencies, stats.latencies...)
+		for kind, n := range stats.ErrorKinds {
+			total.ErrorKinds[kind] += n
+		}
+		stats.finish(elapsed)
+		report.Operations = append(report.Operations, stats)
+	}
+	total.finish(elapsed)
+	report.Operations = append(report.Operations, total)
+
+	if opts.Cleanup && len(recorder.created) > 0 {
+		cleaned, err := e.benchCleanup(tableID, recorder.created)
+		report.Cleaned = cleaned
+		if err != nil {
+			return report, fmt.Errorf("清理压测记录失败（已删除 %d 条）: %w", cleaned, err)
+		}
+	}
+	return report, nil
+}
+
+// benchRead 读取一页记录
+func (e *Executor) benchRead(ctx context.Context, tableID string, pageSize int) (int, error) {
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "GET",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records?page_size=%d", e.appToken, tableID, pageSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var apiResp basesql.ListRecordsAPIResponse
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return 0, fmt.Errorf("解析记录响应失败: %w", err)
+	}
+	if apiResp.Code != 0 || apiResp.Data == nil {
+		return 0, fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	return len(apiResp.Data.Items), nil
+}
+
+// benchWrite 批量插入一批合成记录，返回新记录的 ID
+func (e *Executor) benchWrite(ctx context.Context, tableID string, fields []basesql.Field, specs map[string]*GeneratorSpec, gen *generator, batchSize, seq int) ([]string, error) {
+	records := make([]*basesql.CreateRecordRequest, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		payload := make(map[string]interface{}, len(specs))
+		for name, spec := range specs {
+			field := queryengine.FindField(fields, name)
+			value := gen.value(spec, seq*batchSize+i)
+			if field.Type == basesql.FieldTypeMultiSelect {
+				value = []string{common.FormatValue(value)}
+			}
+			payload[name] = field.ConvertFromGoValue(value)
+		}
+		records = append(records, &basesql.CreateRecordRequest{Fields: payload})
+	}
+
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", e.appToken, tableID),
+		Body:   &basesql.BatchCreateRecordsRequest{Records: records},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Records []struct {
+				RecordID string `json:"record_id"`
+			} `json:"records"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return nil, fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	ids := make([]string, 0, len(apiResp.Data.Records))
+	for _, record := range apiResp.Data.Records {
+		ids = append(ids, record.RecordID)
+	}
+	return ids, nil
+}
+
+// benchCleanup 批量删除压测写入的记录
+func (e *Executor) benchCleanup(tableID string, ids []string) (int, error) {
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	deleted := 0
+	for start := 0; start < len(ids); start += common.MaxBatchSize {
+		end := start + common.MaxBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_delete", e.appToken, tableID),
+			Body:   &basesql.BatchDeleteRecordsRequest{Records: ids[start:end]},
+		})
+		if err == nil {
+			err = checkAPIResponse(resp.Body)
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted += end - start
+	}
+	return deleted, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// checkpointSuffix 检查点文件的后缀，检查点与 CSV 文件放在同一目录
+const checkpointSuffix = ".checkpoint"
+
+// Checkpoint 导入、导出任务的检查点
+// 每写完一批记录保存一次，任务中断后通过 --resume 从最后一个检查点继续
+type Checkpoint struct {
+	Job       string            `json:"job"`                  // 任务类型: import 或 export
+	Table     string            `json:"table"`                // 表名
+	Header    []string          `json:"header"`               // CSV 列名，继续时必须一致
+	Mode      string            `json:"mode,omitempty"`       // 导入模式
+	Key       string            `json:"key,omitempty"`        // upsert 模式的键列
+	Offset    int               `json:"offset"`               // 已完成的数据行数（导入）或已导出的记录数（导出）
+	PageToken string            `json:"page_token,omitempty"` // 导出时下一页的分页标记
+	Size      int64             `json:"size,omitempty"`       // 导出文件中已完成部分的字节数，继续时截断之后的内容
+	Keys      map[string]string `json:"keys,omitempty"`       // upsert 导入中已插入记录的键到记录 ID 的映射
+	UpdatedAt time.Time         `json:"updated_at"`           // 保存时间
+}
+
+// CheckpointPath 获取 CSV 文件对应的检查点文件路径
+// 参数:
+//   - file: CSV 文件路径
+//
+// 返回:
+//   - string: 检查点文件路径，如 users.csv.checkpoint
+func CheckpointPath(file string) string {
+	return file + checkpointSuffix
+}
+
+// LoadCheckpoint 读取检查点
+// 参数:
+//   - path: 检查点文件路径
+//
+// 返回:
+//   - *Checkpoint: 检查点，文件不存在时为 nil
+//   - error: 读取或解析错误
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取检查点失败: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("解析检查点 %s 失败: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// Save 保存检查点，先写入临时文件再重命名，中断时不会留下不完整的检查点
+// 参数:
+//   - path: 检查点文件路径
+//
+// 返回:
+//   - error: 写入错误
+func (c *Checkpoint) Save(path string) error {
+	c.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化检查点失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	return nil
+}
+
+// resumeCheckpoint 读取任务的检查点，并确认检查点属于当前任务
+// 存在检查点但未指定 --resume 时返回错误，避免重复写入已完成的部分
+// 参数:
+//   - path: 检查点文件路径
+//   - resume: 是否从检查点继续
+//   - expected: 当前任务的类型、表名、列名和导入模式
+//
+// 返回:
+//   - *Checkpoint: 需要继续的检查点，从头开始时为 nil
+//   - error: 检查点与当前任务不一致等错误
+func resumeCheckpoint(path string, resume bool, expected *Checkpoint) (*Checkpoint, error) {
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		if resume {
+			fmt.Printf("ℹ️  未找到检查点 %s，从头开始\n", path)
+		}
+		return nil, nil
+	}
+	if !resume {
+		return nil, fmt.Errorf("发现未完成任务的检查点 %s（已完成 %d 条），使用 --resume 继续，或删除该文件后重新开始",
+			path, checkpoint.Offset)
+	}
+
+	switch {
+	case checkpoint.Job != expected.Job:
+		return nil, fmt.Errorf("检查点 %s 属于 %s 任务，不能用于 %s", path, checkpoint.Job, expected.Job)
+	case checkpoint.Table != expected.Table:
+		return nil, fmt.Errorf("检查点 %s 对应表 '%s'，与当前的表 '%s' 不一致", path, checkpoint.Table, expected.Table)
+	case checkpoint.Mode != expected.Mode || checkpoint.Key != expected.Key:
+		return nil, fmt.Errorf("检查点 %s 的导入模式与当前参数不一致", path)
+	case strings.Join(checkpoint.Header, "\x00") != strings.Join(expected.Header, "\x00"):
+		return nil, fmt.Errorf("检查点 %s 记录的列与当前不一致，文件或表结构可能已变化", path)
+	}
+
+	fmt.Printf("⏩ 从检查点继续，已完成 %d 条\n", checkpoint.Offset)
+	return checkpoint, nil
+}
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -11,6 +14,12 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// ErrQueryCancelled 语句在执行过程中被取消（例如在 shell 中按下 Ctrl-C）
+var ErrQueryCancelled = errors.New("query cancelled")
+
+// ErrMaxRowsExceeded 分组或聚合查询满足条件的记录超过 --max-rows 上限
+var ErrMaxRowsExceeded = errors.New("max rows exceeded")
+
 // Config CLI 配置结构体
 // 包含连接飞书多维表格所需的所有配置信息
 type Config struct {
@@ -26,8 +35,42 @@ type Config struct {
 	Debug bool
 	// Timeout 连接超时时间（秒）
 	Timeout int
+	// Format 查询结果输出格式（table、json、csv，默认 table）
+	Format string
+	// TableStyle 表格样式（ascii、borderless、markdown，默认 ascii）
+	TableStyle string
+	// Vertical 是否纵向显示查询结果（每条记录逐行显示 "字段: 值"）
+	Vertical bool
+	// Stats 是否在每条命令执行后输出 API 调用统计
+	Stats bool
+	// RulesFile 校验规则文件路径（默认 ~/.basesql/rules.yaml，不存在时不启用校验）
+	RulesFile string
+	// ReadOnly 是否启用只读模式（拒绝 INSERT、UPDATE、DELETE、CREATE、DROP 等写操作）
+	ReadOnly bool
+	// PolicyFile 语句策略文件路径（默认 ~/.basesql/policy.yaml）
+	PolicyFile string
+	// Profile 使用的策略角色（默认 default，策略文件中没有该角色时不启用策略）
+	// 同时用于选择任务通知配置
+	Profile string
+	// NotifyFile 任务通知配置文件路径（默认 ~/.basesql/notify.yaml，不存在时不发送通知）
+	NotifyFile string
+	// LazyAuth 是否延迟认证（创建客户端时不获取访问令牌，第一次请求时再获取）
+	LazyAuth bool
+	// Collation 字符串比较规则（binary、case_insensitive，默认 binary）
+	Collation string
+	// MaxRows SELECT 最多输出的行数，超过时截断并提示，分组和聚合查询满足条件的记录超过时报错，0 表示不限制
+	MaxRows int
+	// PageSize 读取记录时的每页记录数（1-500），0 表示使用默认值 500
+	PageSize int
+	// SlowQuery 慢查询阈值，0 表示使用策略角色中的 slow_query_threshold，都未设置时不记录慢查询日志
+	SlowQuery time.Duration
+	// NoCache 是否不读写磁盘上的表结构缓存（~/.basesql/cache），每次命令都重新获取表和字段列表
+	NoCache bool
 }
 
+// DefaultMaxRows --max-rows 的默认值，避免误执行的全表查询读取整张大表
+const DefaultMaxRows = 10000
+
 // Client CLI 客户端
 // 封装了与飞书多维表格的交互逻辑
 type Client struct {
@@ -37,6 +80,18 @@ type Client struct {
 	config *Config
 	// executor SQL 执行器
 	executor *Executor
+	// history 查询历史存储，为空时不记录历史
+	history *HistoryStore
+	// stats 语句执行统计存储，为空时不记录统计
+	stats *StatsStore
+	// session 当前会话 ID
+	session string
+	// rules 按表名声明的校验规则
+	rules map[string][]basesql.ValidationRule
+	// notifier 任务通知，为空时不发送通知
+	notifier *Notifier
+	// defaultTable USE 设置的默认表，省略 FROM 的 SELECT 语句作用于该表
+	defaultTable string
 }
 
 // NewClient 创建新的 CLI 客户端
@@ -58,20 +113,57 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
 
+	// 加载校验规则
+	rules, err := loadValidationRules(cfg.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加载语句策略
+	policy, err := loadPolicy(cfg.PolicyFile, cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加载任务通知
+	notifier, err := loadNotifier(cfg.NotifyFile, cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建 BaseSQL 配置
 	baseCfg := &basesql.Config{
-		AppID:     cfg.AppID,
-		AppSecret: cfg.AppSecret,
-		AppToken:  cfg.AppToken,
-		AuthType:  basesql.AuthTypeTenant,
-		DebugMode: cfg.Debug,
-		Timeout:   300 * time.Second, // 增加超时时间到5分钟，支持大量数据分页获取
+		AppID:           cfg.AppID,
+		AppSecret:       cfg.AppSecret,
+		AppToken:        cfg.AppToken,
+		AuthType:        basesql.AuthTypeTenant,
+		DebugMode:       cfg.Debug,
+		Timeout:         300 * time.Second, // 增加超时时间到5分钟，支持大量数据分页获取
+		ValidationRules: rules,
+		ReadOnly:        cfg.ReadOnly,
+		LazyAuth:        cfg.LazyAuth,
+		Collation:       basesql.Collation(cfg.Collation),
+		MaxResultRows:   cfg.MaxRows,
+		DefaultPageSize: cfg.PageSize,
+		CacheEnabled:    true,
+		SchemaCacheTTL:  5 * time.Minute,
+	}
+	// 表结构缓存写入配置目录，之后执行的命令在过期前不再请求表和字段列表
+	if !cfg.NoCache {
+		if dir, err := DefaultSchemaCacheDir(); err == nil {
+			baseCfg.CacheDir = dir
+		}
+	}
+	// 慢查询阈值：命令行参数优先，其次为当前角色的配置
+	baseCfg.SlowQueryThreshold = cfg.SlowQuery
+	if baseCfg.SlowQueryThreshold == 0 && policy != nil {
+		baseCfg.SlowQueryThreshold = policy.SlowQueryThreshold
 	}
 
 	// 配置 GORM
 	gormConfig := &gorm.Config{
-		// 根据调试模式设置日志级别
-		Logger: logger.Default.LogMode(getLogLevel(cfg.Debug)),
+		// 语句日志与其他日志共用输出，根据调试模式设置日志级别
+		Logger: basesql.NewLogger(basesql.LoggerConfig{LogLevel: getLogLevel(cfg.Debug)}),
 		// 禁用外键约束检查（飞书多维表格不支持）
 		DisableForeignKeyConstraintWhenMigrating: true,
 	}
@@ -87,11 +179,16 @@ func NewClient(config *Config) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("创建执行器失败: %w", err)
 	}
+	executor.format = cfg.Format
+	executor.tableStyle = common.TableStyle(cfg.TableStyle)
+	executor.policy = policy
 
 	client := &Client{
 		db:       db,
 		config:   cfg,
 		executor: executor,
+		rules:    rules,
+		notifier: notifier,
 	}
 
 	// 验证连接
@@ -102,6 +199,31 @@ func NewClient(config *Config) (*Client, error) {
 	return client, nil
 }
 
+// newExecutor 为单个请求创建独立的执行器，继承客户端的语句策略
+// REST 网关和 gRPC 服务并发处理请求，不能共享同一个执行器
+func (c *Client) newExecutor() (*Executor, error) {
+	executor, err := NewExecutor(c.db)
+	if err != nil {
+		return nil, err
+	}
+	executor.policy = c.executor.policy
+	return executor, nil
+}
+
+// Warmup 预热客户端：获取访问令牌，并加载全部数据表的字段列表到表结构缓存
+// 常驻服务在开始处理请求前调用，延迟认证时也可以用于提前验证凭据
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - error: 认证或请求失败时返回错误
+func (c *Client) Warmup(ctx context.Context) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+	return c.executor.client.Warmup(ctx)
+}
+
 // Close 关闭客户端连接
 // 清理资源并关闭与飞书多维表格的连接
 // 返回:
@@ -131,6 +253,52 @@ func (c *Client) Query(sql string) error {
 	return c.Execute(sql)
 }
 
+// QueryTo 执行 SELECT 语句并将结果发送到输出目标，不输出到终端
+// 参数:
+//   - ctx: 上下文
+//   - sql: SQL 查询语句
+//   - writer: 输出目标
+//
+// 返回:
+//   - int: 发送的记录数
+//   - error: 错误信息
+func (c *Client) QueryTo(ctx context.Context, sql string, writer OutputWriter) (int, error) {
+	if c == nil || c.executor == nil {
+		return 0, fmt.Errorf("客户端未初始化")
+	}
+
+	cmd, err := ParseSQL(sql)
+	if err != nil {
+		return 0, err
+	}
+	if cmd.Type != common.CommandSelect {
+		return 0, fmt.Errorf("输出到外部目标只支持 SELECT 语句，不支持 %s", cmd.Type)
+	}
+	if cmd.OutFile != "" {
+		return 0, fmt.Errorf("INTO OUTFILE 不能与 --output、--out 同时使用")
+	}
+
+	ctx, traceID, err := common.EnsureTraceID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	before := c.executor.client.RequestStats()
+	start := time.Now()
+	result, err := c.executor.Query(ctx, cmd)
+	if err == nil {
+		c.executor.rowCount = int64(result.RowCount)
+		err = writer.Write(ctx, result)
+	}
+	duration := time.Since(start)
+	common.LogSQLExecution(ctx, sql, duration, err)
+	c.recordHistory(sql, start, duration, err)
+	c.recordStats(cmd, sql, start, duration, c.executor.client.RequestStats().Sub(before), err)
+	if err != nil {
+		return 0, fmt.Errorf("%w（追踪 ID: %s）", err, traceID)
+	}
+	return result.RowCount, nil
+}
+
 // Exec 执行修改操作
 // 专门用于执行 INSERT、UPDATE、DELETE 类型的语句
 // 参数:
@@ -153,6 +321,18 @@ func (c *Client) Exec(sql string) error {
 // 返回:
 //   - error: 错误信息
 func (c *Client) Execute(sql string) error {
+	return c.ExecuteContext(context.Background(), sql)
+}
+
+// ExecuteContext 在指定上下文中执行 SQL 语句
+// 上下文取消后正在进行的请求和分页会立即中止
+// 参数:
+//   - ctx: 上下文
+//   - sql: SQL 语句
+//
+// 返回:
+//   - error: 错误信息，语句被取消时返回 ErrQueryCancelled
+func (c *Client) ExecuteContext(ctx context.Context, sql string) error {
 	if c == nil {
 		return common.NewUserFriendlyError(
 			fmt.Errorf("客户端未初始化"),
@@ -170,7 +350,10 @@ func (c *Client) Execute(sql string) error {
 		)
 	}
 
-	sql = strings.TrimSpace(sql)
+	// 以 \G 结尾的语句纵向显示结果
+	sql, vertical := splitVerticalTerminator(sql)
+	c.executor.vertical = c.config.Vertical || vertical
+
 	if sql == "" {
 		return common.NewUserFriendlyError(
 			fmt.Errorf("SQL 语句为空"),
@@ -180,6 +363,20 @@ func (c *Client) Execute(sql string) error {
 		)
 	}
 
+	// USE 语句设置默认表
+	if table, ok := ParseUseStatement(sql); ok {
+		if err := c.UseTable(ctx, table); err != nil {
+			return common.NewUserFriendlyError(
+				err,
+				fmt.Sprintf("无法切换到表 '%s'", table),
+				"使用 'SHOW TABLES' 查看可用的表",
+			)
+		}
+		fmt.Printf("📌 默认表已切换为 %s，省略 FROM 的 SELECT 语句将作用于该表\n", table)
+		return nil
+	}
+	sql = applyDefaultTable(sql, c.defaultTable)
+
 	// 记录SQL执行开始
 	if c.config.Debug {
 		common.Debug(fmt.Sprintf("Starting SQL execution: %s", sql))
@@ -197,22 +394,67 @@ func (c *Client) Execute(sql string) error {
 		)
 	}
 
-	// 执行命令
+	// 提示执行时会被忽略或错误处理的写法，不阻止执行
+	for _, issue := range LintSQL(sql) {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", issue)
+	}
+
+	// 执行命令，每条语句使用一个追踪 ID 关联日志和飞书请求
+	ctx, traceID, err := common.EnsureTraceID(ctx)
+	if err != nil {
+		return err
+	}
+	before := c.executor.client.RequestStats()
 	start := time.Now()
+	c.executor.ctx = ctx
 	err = c.executor.Execute(cmd)
+	c.executor.ctx = nil
 	duration := time.Since(start)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		err = ErrQueryCancelled
+	}
+
+	requestStats := c.executor.client.RequestStats().Sub(before)
+	if c.config.Stats {
+		printCommandStats(requestStats, duration)
+	}
 
 	// 记录SQL执行日志
-	common.LogSQLExecution(sql, duration, err)
+	common.LogSQLExecution(ctx, sql, duration, err)
+	c.recordHistory(sql, start, duration, err)
+	c.recordStats(cmd, sql, start, duration, requestStats, err)
 
+	if errors.Is(err, ErrQueryCancelled) {
+		return err
+	}
 	if err != nil {
-		return common.NewUserFriendlyError(
-			err,
-			"SQL 执行失败",
-			"检查表名和字段名是否正确",
-			"确认是否有足够的权限执行此操作",
-			"使用 'SHOW TABLES' 查看可用的表",
-		)
+		var ufErr *common.UserFriendlyError
+		switch {
+		case errors.Is(err, basesql.ErrReadOnly):
+			ufErr = common.NewUserFriendlyError(
+				err,
+				"当前处于只读模式",
+				"只读模式由 --read-only 或环境变量 BASESQL_READ_ONLY 开启",
+				"如需写入，请去掉该选项后重新启动",
+			)
+		case errors.Is(err, ErrPolicyDenied):
+			ufErr = common.NewUserFriendlyError(
+				err,
+				"当前角色的语句策略不允许执行此语句",
+				"角色由 --profile 或环境变量 BASESQL_PROFILE 指定",
+				"策略在 --policy 指定的文件（默认 ~/.basesql/policy.yaml）中按角色声明",
+			)
+		default:
+			ufErr = common.NewUserFriendlyError(
+				err,
+				"SQL 执行失败",
+				"检查表名和字段名是否正确",
+				"确认是否有足够的权限执行此操作",
+				"使用 'SHOW TABLES' 查看可用的表",
+			)
+		}
+		ufErr.TraceID = traceID
+		return ufErr
 	}
 
 	// 显示执行成功信息
@@ -223,6 +465,123 @@ func (c *Client) Execute(sql string) error {
 	return nil
 }
 
+// EnableHistory 启用结构化查询历史
+// 启用后每条执行的语句（含耗时、行数和错误）都会追加到历史存储
+// 参数:
+//   - store: 历史存储
+//   - session: 会话 ID
+func (c *Client) EnableHistory(store *HistoryStore, session string) {
+	c.history = store
+	c.session = session
+}
+
+// recordHistory 记录一条查询历史，写入失败只记录警告，不影响命令执行结果
+func (c *Client) recordHistory(sql string, start time.Time, duration time.Duration, execErr error) {
+	if c.history == nil {
+		return
+	}
+	entry := HistoryEntry{
+		Session:  c.session,
+		SQL:      sql,
+		Time:     start,
+		Duration: duration,
+		Rows:     c.executor.RowCount(),
+	}
+	if execErr != nil {
+		entry.Error = execErr.Error()
+	}
+	if err := c.history.Append(entry); err != nil {
+		common.Warnf("记录查询历史失败: %v", err)
+	}
+}
+
+// EnableStats 启用语句执行统计
+// 启用后每条执行的语句的表名、耗时、行数和 API 请求数都会追加到统计存储，供 stats top 分析
+// 参数:
+//   - store: 统计存储
+func (c *Client) EnableStats(store *StatsStore) {
+	c.stats = store
+}
+
+// EnableProfileCache 启用 profile 统计缓存
+// 启用后 profile 命令的统计结果写入缓存，查询时按缓存的字段统计先计算过滤掉记录最多的 WHERE 条件
+// 参数:
+//   - cache: 统计缓存
+func (c *Client) EnableProfileCache(cache *ProfileCache) {
+	c.executor.profiles = cache
+}
+
+// recordStats 记录一条语句的执行统计，写入失败只记录警告，不影响命令执行结果
+func (c *Client) recordStats(cmd *common.SQLCommand, sql string, start time.Time, duration time.Duration, requestStats basesql.RequestStats, execErr error) {
+	if c.stats == nil {
+		return
+	}
+	stat := StatementStat{
+		Time:     start,
+		Session:  c.session,
+		Type:     cmd.Type.String(),
+		Table:    cmd.Table,
+		SQL:      sql,
+		Duration: duration,
+		Rows:     c.executor.RowCount(),
+		APICalls: requestStats.APICalls,
+		Failed:   execErr != nil,
+	}
+	if err := c.stats.Append(stat); err != nil {
+		common.Warnf("记录执行统计失败: %v", err)
+	}
+}
+
+// printCommandStats 输出单条命令的 API 调用统计
+// 统计信息输出到标准错误，避免混入 JSON/CSV 结果
+// 参数:
+//   - stats: 本条命令产生的请求统计
+//   - duration: 命令耗时
+func printCommandStats(stats basesql.RequestStats, duration time.Duration) {
+	fmt.Fprintf(os.Stderr,
+		"📈 统计: API 调用 %d 次，发送 %s，接收 %s，缓存命中 %d 次，重试 %d 次，限流等待 %d 次（%v），耗时 %v\n",
+		stats.APICalls,
+		formatBytes(stats.BytesSent),
+		formatBytes(stats.BytesReceived),
+		stats.CacheHits,
+		stats.Retries,
+		stats.RateLimitWaits,
+		stats.RateLimitWaitTime.Round(time.Millisecond),
+		duration.Round(time.Millisecond),
+	)
+}
+
+// formatBytes 将字节数格式化为易读的字符串
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// splitVerticalTerminator 拆分语句末尾的 \G 结束符
+// 与 MySQL 客户端一致，\G 代替分号结束语句，并要求纵向显示结果
+// 参数:
+//   - sql: SQL 语句
+//
+// 返回:
+//   - string: 去除结束符并去除首尾空白后的语句
+//   - bool: 是否以 \G 结尾
+func splitVerticalTerminator(sql string) (string, bool) {
+	sql = strings.TrimSpace(sql)
+	trimmed := strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+	if strings.HasSuffix(trimmed, `\G`) {
+		return strings.TrimSpace(strings.TrimSuffix(trimmed, `\G`)), true
+	}
+	return sql, false
+}
+
 // validateConnection 验证与飞书多维表格的连接
 // 通过执行简单的查询来验证连接是否正常
 // 返回:
@@ -238,6 +597,7 @@ func (c *Client) validateConnection() error {
 }
 
 // getLogLevel 根据调试模式获取日志级别
+// 非调试模式下不记录语句日志，执行错误由命令行直接输出
 // 参数:
 //   - debug: 是否启用调试模式
 //
@@ -247,7 +607,7 @@ func getLogLevel(debug bool) logger.LogLevel {
 	if debug {
 		return logger.Info
 	}
-	return logger.Warn
+	return logger.Silent
 }
 
 // loadConfig 加载和验证配置
@@ -264,19 +624,49 @@ func loadConfig(config *Config) (*Config, error) {
 	}
 
 	result := &Config{
-		Debug:   config.Debug,
-		Timeout: config.Timeout,
+		Debug:     config.Debug,
+		Timeout:   config.Timeout,
+		Format:    strings.ToLower(config.Format),
+		Vertical:  config.Vertical,
+		Stats:     config.Stats,
+		RulesFile: config.RulesFile,
+		// 命令行参数和环境变量任一开启即进入只读模式
+		ReadOnly:   config.ReadOnly || strings.EqualFold(common.GetEnv("BASESQL_READ_ONLY", ""), "true"),
+		PolicyFile: config.PolicyFile,
+		Profile:    getConfigValue(config.Profile, "BASESQL_PROFILE"),
+		NotifyFile: config.NotifyFile,
+		LazyAuth:   config.LazyAuth || strings.EqualFold(common.GetEnv("BASESQL_LAZY_AUTH", ""), "true"),
+		Collation:  strings.ToLower(getConfigValue(config.Collation, "BASESQL_COLLATION")),
+		MaxRows:    config.MaxRows,
+		PageSize:   config.PageSize,
+	}
+
+	if result.MaxRows < 0 {
+		return nil, fmt.Errorf("--max-rows 不能为负数: %d", result.MaxRows)
+	}
+	if result.PageSize < 0 || result.PageSize > common.MaxPageSize {
+		return nil, fmt.Errorf("--page-size 必须在 1 到 %d 之间: %d", common.MaxPageSize, result.PageSize)
 	}
 
+	if err := ValidateOutputFormat(result.Format); err != nil {
+		return nil, err
+	}
+
+	style, err := common.ParseTableStyle(config.TableStyle)
+	if err != nil {
+		return nil, err
+	}
+	result.TableStyle = string(style)
+
 	// 设置默认超时时间
 	if result.Timeout <= 0 {
 		result.Timeout = 30 // 默认 30 秒
 	}
 
-	// 优先使用命令行参数，其次使用环境变量
-	result.AppID = getConfigValue(config.AppID, "FEISHU_APP_ID")
-	result.AppSecret = getConfigValue(config.AppSecret, "FEISHU_APP_SECRET")
-	result.AppToken = getConfigValue(config.AppToken, "FEISHU_APP_TOKEN")
+	// 优先使用命令行参数，其次使用环境变量，FEISHU_* 未设置时使用库的 BASESQL_* 环境变量
+	result.AppID = getConfigValue(getConfigValue(config.AppID, "FEISHU_APP_ID"), "BASESQL_APP_ID")
+	result.AppSecret = getConfigValue(getConfigValue(config.AppSecret, "FEISHU_APP_SECRET"), "BASESQL_APP_SECRET")
+	result.AppToken = getConfigValue(getConfigValue(config.AppToken, "FEISHU_APP_TOKEN"), "BASESQL_APP_TOKEN")
 
 	// 验证必要的配置
 	if err := validateRequiredConfig(result); err != nil {
@@ -320,6 +710,13 @@ func validateRequiredConfig(config *Config) error {
 		missing = append(missing, "多维表格 Token (FEISHU_APP_TOKEN)")
 	}
 
+	if basesql.IsBaseURL(config.AppToken) {
+		// 多维表格链接在创建连接时解析，这里先检查格式，给出更明确的错误
+		if _, err := basesql.ParseBaseURL(config.AppToken); err != nil {
+			return err
+		}
+	}
+
 	if len(missing) > 0 {
 		return fmt.Errorf("缺少必要的配置信息: %s\n\n"+
 			"请通过以下方式之一提供配置:\n"+
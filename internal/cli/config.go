@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ag9920/basesql"
 	"github.com/ag9920/basesql/internal/common"
 )
 
@@ -39,19 +41,60 @@ FEISHU_APP_TOKEN=your_app_token
 # TIMEOUT=30
 `
 
+// ConfigDir 获取 BaseSQL 的配置目录 ~/.basesql
+// 用户主目录通过 os.UserHomeDir 获取，Windows 上为 %USERPROFILE%；
+// 无法确定用户主目录时（如未设置 HOME 的服务账户）使用 os.UserConfigDir 下的 basesql 目录
+// 返回:
+//   - string: 目录路径
+//   - error: 两者都无法确定时返回错误
+func ConfigDir() (string, error) {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(homeDir, ".basesql"), nil
+	}
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(userConfigDir, "basesql"), nil
+}
+
+// ShellHistoryPath 获取交互式 shell 的行编辑历史文件路径 ~/.basesql_history
+// 无法确定用户主目录时保存到配置目录下，都无法确定时返回空字符串，不保存历史
+// 返回:
+//   - string: 文件路径
+func ShellHistoryPath() string {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(homeDir, ".basesql_history")
+	}
+	if configDir, err := ConfigDir(); err == nil {
+		return filepath.Join(configDir, "shell_history")
+	}
+	return ""
+}
+
+// DefaultSchemaCacheDir 获取默认的表结构缓存目录 ~/.basesql/cache
+// 每个多维表格的表和字段列表缓存为目录下的 <app_token>-<摘要>.json，摘要区分应用和 API 地址
+// 返回:
+//   - string: 目录路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultSchemaCacheDir() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "cache"), nil
+}
+
 // InitConfig 初始化配置文件
-// 在用户主目录下创建 BaseSQL 配置文件
+// 在配置目录下创建 BaseSQL 配置文件
 // 返回:
 //   - error: 初始化错误信息
 func InitConfig() error {
-	// 获取用户主目录
-	homeDir, err := os.UserHomeDir()
+	// 创建配置目录
+	configDir, err := ConfigDir()
 	if err != nil {
-		return fmt.Errorf("获取用户主目录失败: %w", err)
+		return err
 	}
-
-	// 创建配置目录
-	configDir := filepath.Join(homeDir, ".basesql")
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("创建配置目录失败: %w", err)
 	}
@@ -87,12 +130,12 @@ func InitConfig() error {
 //   - error: 显示错误信息
 func ShowConfig() error {
 	// 获取配置文件路径
-	homeDir, err := os.UserHomeDir()
+	configDir, err := ConfigDir()
 	if err != nil {
-		return fmt.Errorf("获取用户主目录失败: %w", err)
+		return err
 	}
 
-	configFile := filepath.Join(homeDir, ".basesql", "config.env")
+	configFile := filepath.Join(configDir, "config.env")
 
 	fmt.Println("📋 BaseSQL 配置信息")
 	fmt.Println("")
@@ -163,3 +206,44 @@ func validateConfigCompleteness() error {
 
 	return nil
 }
+
+// configEnvEntry 环境变量及其当前值
+type configEnvEntry struct {
+	basesql.ConfigEnvVar
+	Value string `json:"value,omitempty"` // 当前值，敏感信息已遮盖
+}
+
+// PrintConfigEnv 列出可以覆盖配置的 BASESQL_* 环境变量及其当前值
+// 列表由 basesql.ConfigEnvVars 根据配置结构生成，与库支持的配置项保持一致
+// 参数:
+//   - format: 输出格式，json 输出 JSON 数组，其他值输出表格
+//   - style: 表格样式
+//
+// 返回:
+//   - error: 输出错误信息
+func PrintConfigEnv(format, style string) error {
+	vars := basesql.ConfigEnvVars()
+	entries := make([]configEnvEntry, len(vars))
+	for i, envVar := range vars {
+		entries[i] = configEnvEntry{ConfigEnvVar: envVar, Value: os.Getenv(envVar.Name)}
+		if envVar.Sensitive && entries[i].Value != "" {
+			entries[i].Value = maskSensitive(entries[i].Value)
+		}
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	tableStyle, err := common.ParseTableStyle(style)
+	if err != nil {
+		return err
+	}
+	table := common.NewTable("Variable", "Type", "Default", "Value", "Description").SetStyle(tableStyle).SetMaxColumnWidth(60)
+	for _, entry := range entries {
+		table.AppendRow(entry.Name, entry.Type, entry.Default, entry.Value, entry.Description)
+	}
+	return table.Render(os.Stdout)
+}
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+var (
+	// appIDRe 飞书应用 ID 的格式，如 cli_a1b2c3d4e5f6a7b8
+	appIDRe = regexp.MustCompile(`^cli_[0-9a-z]{16}$`)
+	// appSecretRe 飞书应用密钥的格式
+	appSecretRe = regexp.MustCompile(`^[0-9A-Za-z]{32}$`)
+	// appTokenRe 多维表格 app_token 的格式
+	appTokenRe = regexp.MustCompile(`^[0-9A-Za-z]{20,40}$`)
+)
+
+// tokenKinds 按前缀识别容易误填为 App Token 的标识符
+var tokenKinds = []struct {
+	prefix string
+	kind   string
+}{
+	{"cli_", "飞书应用 ID"},
+	{"wikcn", "知识库节点 token"},
+	{"tbl", "数据表 ID"},
+	{"vew", "视图 ID"},
+	{"doxcn", "文档 token"},
+	{"doccn", "文档 token"},
+	{"shtcn", "电子表格 token"},
+	{"t-", "tenant_access_token"},
+	{"u-", "user_access_token"},
+}
+
+// configSetting 一项配置的值和来源
+type configSetting struct {
+	name   string // 配置项名称
+	value  string // 生效的值
+	source string // 值的来源，命令行参数或环境变量
+	fixAt  string // 修改方式
+}
+
+// resolveSetting 记录配置项的值和来源
+// 生效的值与环境变量相同时视为来自环境变量，否则来自命令行参数
+func resolveSetting(name, value, flag, env string) configSetting {
+	setting := configSetting{name: name, value: value, fixAt: fmt.Sprintf("命令行参数 %s 或环境变量 %s", flag, env)}
+	switch {
+	case value == "":
+	case value == common.GetEnv(env, ""):
+		setting.source, setting.fixAt = "环境变量 "+env, "环境变量 "+env
+	default:
+		setting.source, setting.fixAt = "命令行参数 "+flag, "命令行参数 "+flag
+	}
+	return setting
+}
+
+// check 生成该配置项的检查结果，失败和警告时修复建议指明需要修改的位置
+func (s configSetting) check(status DoctorStatus, detail, fix string) DoctorCheck {
+	check := DoctorCheck{Name: s.name, Status: status, Detail: detail}
+	if s.source != "" && status == DoctorOK {
+		check.Detail += "（来自" + s.source + "）"
+	}
+	if fix != "" {
+		check.Fix = fmt.Sprintf("请修改%s: %s", s.fixAt, fix)
+	}
+	return check
+}
+
+// ValidateConfig 逐项校验配置
+// 先在本地检查 App ID、App Secret、App Token 的格式，识别填错位置的常见错误（如把知识库节点 token、
+// 数据表 ID 填为 App Token），再检查开放平台地址能否访问、凭证能否换取访问令牌、App Token 能否访问以及应用的权限范围；
+// 每个失败项都指明需要修改的命令行参数或环境变量
+// 参数:
+//   - ctx: 上下文
+//   - config: CLI 配置
+//
+// 返回:
+//   - *DoctorReport: 校验报告
+func ValidateConfig(ctx context.Context, config *Config) *DoctorReport {
+	report := &DoctorReport{}
+
+	appID := resolveSetting("App ID", config.AppID, "--app-id", "FEISHU_APP_ID")
+	appSecret := resolveSetting("App Secret", config.AppSecret, "--app-secret", "FEISHU_APP_SECRET")
+	appToken := resolveSetting("App Token", config.AppToken, "--app-token", "FEISHU_APP_TOKEN")
+
+	credentialsOK := report.addFormat(checkAppID(appID))
+	credentialsOK = report.addFormat(checkAppSecret(appSecret, appID)) && credentialsOK
+	tokenOK := report.addFormat(checkAppToken(appToken))
+
+	reachable := checkReachable(ctx, common.DefaultBaseURL)
+	report.add(reachable)
+	if reachable.Status == DoctorFail || !credentialsOK {
+		report.skip("认证", "多维表格访问", "权限范围")
+		return report
+	}
+
+	// 认证
+	baseCfg := basesql.DefaultConfig()
+	baseCfg.AppID = strings.TrimSpace(appID.value)
+	baseCfg.AppSecret = strings.TrimSpace(appSecret.value)
+	baseCfg.AppToken = strings.TrimSpace(appToken.value)
+	if baseCfg.AppToken == "" {
+		// 只校验凭证时 app_token 仍是必填项，用占位值创建客户端
+		baseCfg.AppToken = "-"
+	}
+	baseCfg.Timeout = 30 * time.Second
+	if config.Timeout > 0 {
+		baseCfg.Timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	start := time.Now()
+	client, err := basesql.NewClient(baseCfg)
+	if err != nil {
+		check := diagnoseError("认证", err)
+		check.Duration = time.Since(start)
+		if check.Code == feishuCodeInvalidAppID || check.Code == feishuCodeInvalidAppSecret {
+			check.Fix = fmt.Sprintf("App ID 或 App Secret 不正确，请在飞书开放平台的「凭证与基础信息」页面核对%s和%s", appID.fixAt, appSecret.fixAt)
+		}
+		report.add(check)
+		report.skip("多维表格访问", "权限范围")
+		return report
+	}
+	defer client.Close()
+	report.add(DoctorCheck{Name: "认证", Status: DoctorOK, Detail: "成功获取 tenant_access_token", Duration: time.Since(start)})
+
+	if !tokenOK {
+		report.skip("多维表格访问", "权限范围")
+		return report
+	}
+
+	// 多维表格访问
+	token, check := resolveConfigToken(ctx, client, appToken)
+	if check.Status == DoctorOK {
+		check = checkBaseAccess(ctx, client, appToken, token)
+	}
+	report.add(check)
+	if check.Status == DoctorFail {
+		report.add(scopeCheck(report))
+		return report
+	}
+
+	// 权限范围
+	check = scopeCheck(report)
+	if check.Status == DoctorOK {
+		if token == baseCfg.AppToken {
+			check = writePermissionCheck(ctx, client)
+		} else if tokenClient, err := basesql.NewClient(withAppToken(baseCfg, token)); err == nil {
+			// 链接形式的配置需要用换取后的 app_token 预检写入权限
+			check = writePermissionCheck(ctx, tokenClient)
+			tokenClient.Close()
+		}
+	}
+	report.add(check)
+	return report
+}
+
+// withAppToken 复制配置并替换 app_token
+func withAppToken(config *basesql.Config, appToken string) *basesql.Config {
+	copied := *config
+	copied.AppToken = appToken
+	return &copied
+}
+
+// addFormat 追加格式检查结果，返回是否可以继续进行依赖该配置的在线检查
+func (r *DoctorReport) addFormat(check DoctorCheck) bool {
+	r.add(check)
+	return check.Status != DoctorFail
+}
+
+// checkValueText 检查配置值中常见的复制错误：首尾空白和引号
+func checkValueText(setting configSetting) (DoctorCheck, bool) {
+	if setting.value == "" {
+		return setting.check(DoctorFail, "未设置", "设置"+setting.name), false
+	}
+	if strings.TrimSpace(setting.value) != setting.value {
+		return setting.check(DoctorFail, "值的首尾包含空格或换行", "去掉首尾的空白字符"), false
+	}
+	if strings.ContainsAny(setting.value, `"'`) {
+		return setting.check(DoctorFail, "值中包含引号", "去掉引号，环境变量和配置文件中的值不需要加引号"), false
+	}
+	return DoctorCheck{}, true
+}
+
+// tokenKind 按前缀识别标识符的类型，无法识别时返回空字符串
+func tokenKind(value string) string {
+	for _, kind := range tokenKinds {
+		if strings.HasPrefix(value, kind.prefix) {
+			return kind.kind
+		}
+	}
+	return ""
+}
+
+// checkAppID 检查 App ID 的格式
+func checkAppID(setting configSetting) DoctorCheck {
+	if check, ok := checkValueText(setting); !ok {
+		return check
+	}
+	if !strings.HasPrefix(setting.value, "cli_") {
+		detail := fmt.Sprintf("'%s' 不是应用 ID，应用 ID 以 cli_ 开头", maskID(setting.value))
+		if appTokenRe.MatchString(setting.value) {
+			detail += "，看起来是 App Secret 或 App Token"
+		}
+		return setting.check(DoctorFail, detail, "填写开放平台「凭证与基础信息」页面中的 App ID")
+	}
+	if !appIDRe.MatchString(setting.value) {
+		return setting.check(DoctorWarn, fmt.Sprintf("'%s' 与常见的应用 ID 格式（cli_ 加 16 位字符）不同", setting.value), "确认是否完整复制了 App ID")
+	}
+	return setting.check(DoctorOK, setting.value, "")
+}
+
+// checkAppSecret 检查 App Secret 的格式
+func checkAppSecret(setting, appID configSetting) DoctorCheck {
+	if check, ok := checkValueText(setting); !ok {
+		return check
+	}
+	if setting.value == appID.value || strings.HasPrefix(setting.value, "cli_") {
+		return setting.check(DoctorFail, "填写的是应用 ID 而不是应用密钥", "填写开放平台「凭证与基础信息」页面中的 App Secret")
+	}
+	if !appSecretRe.MatchString(setting.value) {
+		return setting.check(DoctorWarn, fmt.Sprintf("长度为 %d，与常见的应用密钥格式（32 位字母和数字）不同", len(setting.value)), "确认是否完整复制了 App Secret")
+	}
+	return setting.check(DoctorOK, maskID(setting.value), "")
+}
+
+// checkAppToken 检查 App Token 的格式，识别链接和误填的其他标识符
+func checkAppToken(setting configSetting) DoctorCheck {
+	if check, ok := checkValueText(setting); !ok {
+		return check
+	}
+	if basesql.IsBaseURL(setting.value) {
+		ref, err := basesql.ParseBaseURL(setting.value)
+		if err != nil {
+			return setting.check(DoctorFail, err.Error(), "填写 /base/ 或 /wiki/ 开头的多维表格链接，或链接中 /base/ 之后的 app_token")
+		}
+		if ref.WikiToken != "" {
+			return setting.check(DoctorOK, fmt.Sprintf("知识库链接，连接时通过知识库 API 换取节点 %s 对应的 app_token", ref.WikiToken), "")
+		}
+		return setting.check(DoctorOK, "多维表格链接，app_token 为 "+ref.AppToken, "")
+	}
+	if kind := tokenKind(setting.value); kind != "" {
+		fix := "填写多维表格链接中 /base/ 之后的部分，或直接填写完整的多维表格链接"
+		if kind == "知识库节点 token" {
+			fix = "填写完整的知识库链接（https://xxx.feishu.cn/wiki/" + setting.value + "），连接时会自动换取 app_token"
+		}
+		return setting.check(DoctorFail, fmt.Sprintf("'%s' 看起来是%s，不是多维表格 app_token", setting.value, kind), fix)
+	}
+	if !appTokenRe.MatchString(setting.value) {
+		return setting.check(DoctorWarn, fmt.Sprintf("'%s' 与常见的 app_token 格式不同", setting.value), "确认是否完整复制了多维表格链接中 /base/ 之后、? 之前的部分")
+	}
+	return setting.check(DoctorOK, setting.value, "")
+}
+
+// checkReachable 检查开放平台地址能否访问，收到任意 HTTP 响应即视为可以访问
+func checkReachable(ctx context.Context, baseURL string) DoctorCheck {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		return DoctorCheck{
+			Name:     "网络",
+			Status:   DoctorFail,
+			Detail:   fmt.Sprintf("无法访问 %s: %v", baseURL, err),
+			Fix:      "检查网络连接和代理设置（HTTPS_PROXY），确认可以访问飞书开放平台",
+			Duration: time.Since(start),
+		}
+	}
+	return DoctorCheck{Name: "网络", Status: DoctorOK, Detail: baseURL + " 可以访问", Duration: time.Since(start)}
+}
+
+// resolveConfigToken 获取配置对应的 app_token，知识库链接通过知识库 API 换取
+func resolveConfigToken(ctx context.Context, client *basesql.Client, setting configSetting) (string, DoctorCheck) {
+	if !basesql.IsBaseURL(setting.value) {
+		return setting.value, DoctorCheck{Status: DoctorOK}
+	}
+	ref, _ := basesql.ParseBaseURL(setting.value)
+	if ref.AppToken != "" {
+		return ref.AppToken, DoctorCheck{Status: DoctorOK}
+	}
+	token, err := client.ResolveWikiToken(ctx, ref.WikiToken)
+	if err != nil {
+		check := diagnoseError("多维表格访问", err)
+		if check.Fix == "" || check.Code == 0 {
+			check.Fix = fmt.Sprintf("知识库节点不是多维表格或应用无权阅读，请修改%s，填写多维表格链接或 app_token", setting.fixAt)
+		}
+		return "", check
+	}
+	return token, DoctorCheck{Status: DoctorOK}
+}
+
+// checkBaseAccess 检查 app_token 对应的多维表格能否访问
+// 多维表格不存在时尝试把它当作知识库节点 token 换取 app_token，提示应填写的值
+func checkBaseAccess(ctx context.Context, client *basesql.Client, setting configSetting, token string) DoctorCheck {
+	var app struct {
+		App struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	}
+	check := doctorRequest(ctx, client, "多维表格访问", fmt.Sprintf("/bitable/v1/apps/%s", token), nil, &app)
+	if check.Status == DoctorOK {
+		check.Detail = fmt.Sprintf("多维表格 '%s' 可访问", app.App.Name)
+		return check
+	}
+
+	if check.Code == feishuCodeBaseNotFound || check.Code == feishuCodeBaseTokenNotFound {
+		if resolved, err := client.ResolveWikiToken(ctx, token); err == nil {
+			check.Detail = fmt.Sprintf("'%s' 是知识库节点 token，不是多维表格 app_token", token)
+			check.Fix = fmt.Sprintf("请修改%s: 填写 %s，或完整的知识库链接", setting.fixAt, resolved)
+			return check
+		}
+	}
+	if check.Fix != "" {
+		check.Fix = fmt.Sprintf("%s（%s）", check.Fix, setting.fixAt)
+	}
+	return check
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// 去重时保留记录的策略
+const (
+	DedupeKeepOldest = "oldest" // 保留最早创建的记录
+	DedupeKeepNewest = "newest" // 保留最晚创建的记录
+)
+
+// DedupeOptions 去重选项
+type DedupeOptions struct {
+	Table  string   // 表名
+	Keys   []string // 判断重复的键字段，多个字段的值全部相同才视为重复
+	Keep   string   // 保留策略：oldest 或 newest
+	DryRun bool     // 只列出重复记录，不删除
+}
+
+// DedupeGroup 一组重复记录
+type DedupeGroup struct {
+	Key     string   // 键字段的显示值
+	Kept    string   // 保留的记录 ID
+	Removed []string // 删除的记录 ID
+}
+
+// DedupeResult 去重结果统计
+type DedupeResult struct {
+	Scanned int           // 扫描的记录数
+	Groups  []DedupeGroup // 重复记录分组
+	Deleted int           // 实际删除的记录数
+}
+
+// Duplicates 统计需要删除的重复记录数
+func (r *DedupeResult) Duplicates() int {
+	n := 0
+	for _, group := range r.Groups {
+		n += len(group.Removed)
+	}
+	return n
+}
+
+// Dedupe 按键字段删除表中的重复记录
+// 参数:
+//   - opts: 去重选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Dedupe(opts DedupeOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	result, err := c.executor.Dedupe(opts)
+	job := &JobResult{Job: "dedupe", Target: opts.Table, Err: err}
+	if result != nil {
+		for _, group := range result.Groups {
+			fmt.Printf("🔁 %s: 保留 %s，删除 %s\n", group.Key, group.Kept, strings.Join(group.Removed, ", "))
+		}
+		if opts.DryRun {
+			job.Summary = fmt.Sprintf("扫描记录 %d 条，发现 %d 组重复，共 %d 条待删除（预览模式，未删除）",
+				result.Scanned, len(result.Groups), result.Duplicates())
+		} else {
+			job.Summary = fmt.Sprintf("扫描记录 %d 条，发现 %d 组重复，删除 %d 条",
+				result.Scanned, len(result.Groups), result.Deleted)
+		}
+		fmt.Printf("📊 %s\n", job.Summary)
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Dedupe 执行去重
+// 参数:
+//   - opts: 去重选项
+//
+// 返回:
+//   - *DedupeResult: 去重结果（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Dedupe(opts DedupeOptions) (*DedupeResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if len(opts.Keys) == 0 {
+		return nil, fmt.Errorf("请至少指定一个键字段")
+	}
+	if opts.Keep == "" {
+		opts.Keep = DedupeKeepOldest
+	}
+	if opts.Keep != DedupeKeepOldest && opts.Keep != DedupeKeepNewest {
+		return nil, fmt.Errorf("不支持的保留策略 '%s'，可选值: %s, %s", opts.Keep, DedupeKeepOldest, DedupeKeepNewest)
+	}
+	// 预览模式不删除记录，只读模式下仍然可用
+	if !opts.DryRun {
+		if err := e.config.CheckWritable("DELETE"); err != nil {
+			return nil, err
+		}
+		if err := e.policy.Check(common.CommandDelete, opts.Table); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(ctx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(opts.Keys))
+	for i, key := range opts.Keys {
+		field := queryengine.FindField(fields, key)
+		if field == nil {
+			return nil, fmt.Errorf("字段 '%s' 不存在", key)
+		}
+		keys[i] = field.FieldName
+	}
+
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DedupeResult{Scanned: len(records), Groups: findDuplicates(records, keys, opts.Keep)}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	var ids []string
+	for _, group := range result.Groups {
+		ids = append(ids, group.Removed...)
+	}
+	for start := 0; start < len(ids); start += common.MaxBatchSize {
+		end := start + common.MaxBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_delete", e.appToken, tableID),
+			Body:   &basesql.BatchDeleteRecordsRequest{Records: ids[start:end]},
+		})
+		if err == nil {
+			err = checkAPIResponse(resp.Body)
+		}
+		if err != nil {
+			return result, fmt.Errorf("删除重复记录失败: %w", err)
+		}
+		result.Deleted += end - start
+	}
+	return result, nil
+}
+
+// findDuplicates 按键字段对记录分组，返回包含重复记录的分组
+// 键字段全部为空的记录不参与去重；分组按首次出现的顺序排列
+func findDuplicates(records []basesql.Record, keys []string, keep string) []DedupeGroup {
+	groups := make(map[string][]basesql.Record)
+	var order []string
+	for _, record := range records {
+		parts := make([]string, len(keys))
+		empty := true
+		for i, key := range keys {
+			parts[i] = common.FormatValue(record.Fields[key])
+			if parts[i] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		k := strings.Join(parts, " | ")
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], record)
+	}
+
+	var result []DedupeGroup
+	for _, k := range order {
+		members := groups[k]
+		if len(members) < 2 {
+			continue
+		}
+		// 按创建时间排序，创建时间相同时保持表中的原始顺序
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].CreatedTime < members[j].CreatedTime
+		})
+		keptIndex := 0
+		if keep == DedupeKeepNewest {
+			keptIndex = len(members) - 1
+		}
+		group := DedupeGroup{Key: k, Kept: members[keptIndex].RecordID}
+		for i, record := range members {
+			if i != keptIndex {
+				group.Removed = append(group.Removed, record.RecordID)
+			}
+		}
+		result = append(result, group)
+	}
+	return result
+}
@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// DiffOptions 表与数据源的比较选项
+type DiffOptions struct {
+	Table       string // 目标表
+	File        string // 作为数据源的 CSV 文件，与 SourceTable 二选一
+	SourceTable string // 作为数据源的另一张表
+	Key         string // 匹配两边记录的键列
+	Apply       bool   // 按数据源修改目标表：插入新增行、更新变更行、删除数据源中没有的记录
+}
+
+// DiffChange 变更行中一个字段的变化
+type DiffChange struct {
+	Field string `json:"field"` // 字段名
+	Old   string `json:"old"`   // 目标表中的值
+	New   string `json:"new"`   // 数据源中的值
+}
+
+// DiffRow 一条新增、删除或变更的行
+type DiffRow struct {
+	Key      string            `json:"key"`                 // 键列的值
+	RecordID string            `json:"record_id,omitempty"` // 目标表中的记录 ID，新增行为空
+	Values   map[string]string `json:"values,omitempty"`    // 新增行为数据源中的值，删除行为目标表中的值
+	Changes  []DiffChange      `json:"changes,omitempty"`   // 变更行的字段变化
+
+	payload map[string]interface{} // 数据源中的值按目标字段类型转换后的结果，用于写入
+}
+
+// DiffResult 表与数据源的比较结果
+type DiffResult struct {
+	Columns   []string  `json:"columns"`           // 参与比较的列
+	Ignored   []string  `json:"ignored,omitempty"` // 数据源中有、目标表中不存在或只读的列
+	Added     []DiffRow `json:"added"`             // 数据源中有、目标表中没有的行
+	Removed   []DiffRow `json:"removed"`           // 目标表中有、数据源中没有的行
+	Changed   []DiffRow `json:"changed"`           // 两边都有但值不同的行
+	Unchanged int       `json:"unchanged"`         // 两边一致的行数
+	Unkeyed   int       `json:"unkeyed"`           // 目标表中键为空、不参与比较的记录数
+	Applied   bool      `json:"applied"`           // 是否已按数据源修改目标表
+}
+
+// Diff 比较表与 CSV 文件或另一张表，按需将差异应用到表中
+// 参数:
+//   - opts: 比较选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Diff(opts DiffOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	result, err := c.executor.Diff(opts)
+	job := &JobResult{Job: "diff", Target: opts.Table, Err: err}
+	if result != nil {
+		if c.config.Format == OutputFormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(result); encodeErr != nil && err == nil {
+				err = encodeErr
+			}
+		} else {
+			c.executor.printDiff(opts.Key, result)
+		}
+		job.Summary = fmt.Sprintf("新增 %d 行，删除 %d 行，变更 %d 行，未变化 %d 行",
+			len(result.Added), len(result.Removed), len(result.Changed), result.Unchanged)
+		if result.Applied {
+			job.Summary += "（已应用到表中）"
+		}
+		if c.config.Format != OutputFormatJSON {
+			fmt.Printf("📊 %s\n", job.Summary)
+		}
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Diff 按键列比较目标表与数据源
+// 数据源中的值先按目标字段的类型转换，再与表中的值按显示值比较
+// 参数:
+//   - opts: 比较选项
+//
+// 返回:
+//   - *DiffResult: 比较结果（应用出错时为已完成部分之前的比较结果）
+//   - error: 错误信息
+func (e *Executor) Diff(opts DiffOptions) (*DiffResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if (opts.File == "") == (opts.SourceTable == "") {
+		return nil, fmt.Errorf("请通过 --file 或 --source-table 指定一个数据源")
+	}
+	if opts.Key == "" {
+		return nil, fmt.Errorf("请通过 --key 指定用于匹配记录的键列")
+	}
+	if err := e.policy.Check(common.CommandSelect, opts.Table); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	header, rows, err := e.diffSource(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tableID, err := e.getTableID(ctx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只比较目标表中存在且可写的列
+	result := &DiffResult{Added: []DiffRow{}, Removed: []DiffRow{}, Changed: []DiffRow{}}
+	var indexes []int
+	var columnFields []*basesql.Field
+	keyIndex := -1
+	for i, name := range header {
+		field := queryengine.FindField(fields, name)
+		if field == nil || field.IsReadOnly() {
+			result.Ignored = append(result.Ignored, name)
+			continue
+		}
+		if name == opts.Key {
+			keyIndex = len(columnFields)
+		}
+		indexes = append(indexes, i)
+		columnFields = append(columnFields, field)
+		result.Columns = append(result.Columns, field.FieldName)
+	}
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("键列 '%s' 必须同时存在于数据源和表 '%s' 的可写字段中", opts.Key, opts.Table)
+	}
+	keyField := columnFields[keyIndex]
+
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]basesql.Record, len(records))
+	for _, record := range records {
+		key := diffDisplayValue(keyField, record.Fields[keyField.FieldName])
+		if key == "" {
+			result.Unkeyed++
+			continue
+		}
+		if _, ok := targets[key]; ok {
+			return nil, fmt.Errorf("表 '%s' 中键 '%s' 重复，可以先使用 basesql dedupe --table %s --key %s 去重",
+				opts.Table, key, opts.Table, keyField.FieldName)
+		}
+		targets[key] = record
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(indexes))
+		for j, index := range indexes {
+			if index < len(row) {
+				values[j] = row[index]
+			}
+		}
+		payload, err := importPayload(columnFields, values)
+		if err != nil {
+			return nil, fmt.Errorf("数据源第 %d 行: %w", i+1, err)
+		}
+		display, err := diffPayloadDisplay(columnFields, payload)
+		if err != nil {
+			return nil, fmt.Errorf("数据源第 %d 行: %w", i+1, err)
+		}
+
+		key := display[keyField.FieldName]
+		if key == "" {
+			return nil, fmt.Errorf("数据源第 %d 行的键列 '%s' 为空", i+1, opts.Key)
+		}
+		if first, ok := seen[key]; ok {
+			return nil, fmt.Errorf("数据源中键 '%s' 重复（第 %d 行和第 %d 行）", key, first, i+1)
+		}
+		seen[key] = i + 1
+
+		record, ok := targets[key]
+		if !ok {
+			result.Added = append(result.Added, DiffRow{Key: key, Values: display, payload: payload})
+			continue
+		}
+		var changes []DiffChange
+		for _, field := range columnFields {
+			old := diffDisplayValue(field, record.Fields[field.FieldName])
+			if old != display[field.FieldName] {
+				changes = append(changes, DiffChange{Field: field.FieldName, Old: old, New: display[field.FieldName]})
+			}
+		}
+		if len(changes) == 0 {
+			result.Unchanged++
+			continue
+		}
+		result.Changed = append(result.Changed, DiffRow{Key: key, RecordID: record.RecordID, Changes: changes, payload: payload})
+	}
+
+	for _, record := range records {
+		key := diffDisplayValue(keyField, record.Fields[keyField.FieldName])
+		if _, ok := seen[key]; ok || key == "" {
+			continue
+		}
+		values := make(map[string]string, len(columnFields))
+		for _, field := range columnFields {
+			values[field.FieldName] = diffDisplayValue(field, record.Fields[field.FieldName])
+		}
+		result.Removed = append(result.Removed, DiffRow{Key: key, RecordID: record.RecordID, Values: values})
+	}
+
+	if !opts.Apply {
+		return result, nil
+	}
+	if err := e.applyDiff(ctx, opts.Table, tableID, result); err != nil {
+		return result, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// diffSource 读取数据源的列名和各行的值
+// CSV 文件的值保持原样；另一张表的值取显示值，与导出的 CSV 一致
+func (e *Executor) diffSource(ctx context.Context, opts DiffOptions) ([]string, [][]string, error) {
+	if opts.File != "" {
+		file, err := os.Open(opts.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开 CSV 文件失败: %w", err)
+		}
+		defer file.Close()
+
+		reader := csv.NewReader(file)
+		header, err := readImportHeader(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取 CSV 文件失败: %w", err)
+		}
+		return header, rows, nil
+	}
+
+	if err := e.policy.Check(common.CommandSelect, opts.SourceTable); err != nil {
+		return nil, nil, err
+	}
+	tableID, err := e.getTableID(ctx, opts.SourceTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.FieldName
+	}
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = make([]string, len(fields))
+		for j := range fields {
+			rows[i][j] = diffDisplayValue(&fields[j], record.Fields[fields[j].FieldName])
+		}
+	}
+	return header, rows, nil
+}
+
+// diffDisplayValue 获取用于比较的显示值
+// 飞书不返回未勾选的复选框，空值与 false 视为相同
+func diffDisplayValue(field *basesql.Field, value interface{}) string {
+	display := common.FormatValue(value)
+	if field.Type == basesql.FieldTypeCheckbox && display == "" {
+		return "false"
+	}
+	return display
+}
+
+// diffPayloadDisplay 获取写入值的显示值
+// 写入值先经过 JSON 序列化和反序列化，与 API 返回的记录值结构一致后再格式化
+func diffPayloadDisplay(fields []*basesql.Field, payload map[string]interface{}) (map[string]string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化记录失败: %w", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("解析记录失败: %w", err)
+	}
+
+	display := make(map[string]string, len(fields))
+	for _, field := range fields {
+		display[field.FieldName] = diffDisplayValue(field, normalized[field.FieldName])
+	}
+	return display, nil
+}
+
+// applyDiff 按比较结果修改目标表：插入新增行、更新变更的字段、删除数据源中没有的记录
+func (e *Executor) applyDiff(ctx context.Context, table, tableID string, result *DiffResult) error {
+	steps := []struct {
+		count   int
+		command common.SQLCommandType
+		action  string
+	}{
+		{len(result.Added), common.CommandInsert, "INSERT"},
+		{len(result.Changed), common.CommandUpdate, "UPDATE"},
+		{len(result.Removed), common.CommandDelete, "DELETE"},
+	}
+	// 写入前检查全部需要的操作，避免只应用了一部分
+	for _, step := range steps {
+		if step.count == 0 {
+			continue
+		}
+		if err := e.config.CheckWritable(step.action); err != nil {
+			return err
+		}
+		if err := e.policy.Check(step.command, table); err != nil {
+			return err
+		}
+	}
+
+	recordsPath := fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", e.appToken, tableID)
+	for start := 0; start < len(result.Added); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(result.Added))
+		records := make([]*basesql.CreateRecordRequest, 0, end-start)
+		for _, row := range result.Added[start:end] {
+			records = append(records, &basesql.CreateRecordRequest{Fields: row.payload})
+		}
+		if err := e.postBatch(ctx, recordsPath+"/batch_create", &basesql.BatchCreateRecordsRequest{Records: records}); err != nil {
+			return fmt.Errorf("插入新增行失败: %w", err)
+		}
+		fmt.Printf("  ✅ 已插入 %d/%d 行\n", end, len(result.Added))
+	}
+
+	for start := 0; start < len(result.Changed); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(result.Changed))
+		records := make([]*basesql.BatchUpdateRecord, 0, end-start)
+		for _, row := range result.Changed[start:end] {
+			// 只写入变化的字段，数据源中为空的值清空表中的字段
+			fields := make(map[string]interface{}, len(row.Changes))
+			for _, change := range row.Changes {
+				fields[change.Field] = row.payload[change.Field]
+			}
+			records = append(records, &basesql.BatchUpdateRecord{RecordID: row.RecordID, Fields: fields})
+		}
+		if err := e.postBatch(ctx, recordsPath+"/batch_update", &basesql.BatchUpdateRecordsRequest{Records: records}); err != nil {
+			return fmt.Errorf("更新变更行失败: %w", err)
+		}
+		fmt.Printf("  ✅ 已更新 %d/%d 行\n", end, len(result.Changed))
+	}
+
+	for start := 0; start < len(result.Removed); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(result.Removed))
+		ids := make([]string, 0, end-start)
+		for _, row := range result.Removed[start:end] {
+			ids = append(ids, row.RecordID)
+		}
+		if err := e.postBatch(ctx, recordsPath+"/batch_delete", &basesql.BatchDeleteRecordsRequest{Records: ids}); err != nil {
+			return fmt.Errorf("删除多出的记录失败: %w", err)
+		}
+		fmt.Printf("  ✅ 已删除 %d/%d 行\n", end, len(result.Removed))
+	}
+	return nil
+}
+
+// postBatch 发送批量写入请求并检查业务错误码
+func (e *Executor) postBatch(ctx context.Context, path string, body interface{}) error {
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{Method: "POST", Path: path, Body: body})
+	if err != nil {
+		return err
+	}
+	return checkAPIResponse(resp.Body)
+}
+
+// printDiff 输出比较结果
+func (e *Executor) printDiff(key string, result *DiffResult) {
+	if len(result.Ignored) > 0 {
+		fmt.Printf("ℹ️  以下列在表中不存在或只读，未参与比较: %s\n", strings.Join(result.Ignored, ", "))
+	}
+	if result.Unkeyed > 0 {
+		fmt.Printf("ℹ️  表中有 %d 条记录的键列为空，未参与比较\n", result.Unkeyed)
+	}
+
+	if len(result.Added) > 0 {
+		fmt.Printf("➕ 新增 %d 行:\n", len(result.Added))
+		table := e.newTable(result.Columns...)
+		for _, row := range result.Added {
+			table.AppendRow(diffRowCells(result.Columns, row.Values)...)
+		}
+		e.printTable(table)
+	}
+	if len(result.Removed) > 0 {
+		fmt.Printf("➖ 删除 %d 行:\n", len(result.Removed))
+		table := e.newTable(append([]string{"record_id"}, result.Columns...)...)
+		for _, row := range result.Removed {
+			table.AppendRow(append([]string{row.RecordID}, diffRowCells(result.Columns, row.Values)...)...)
+		}
+		e.printTable(table)
+	}
+	if len(result.Changed) > 0 {
+		fmt.Printf("✏️  变更 %d 行:\n", len(result.Changed))
+		table := e.newTable(key, "字段", "表中的值", "数据源的值")
+		for _, row := range result.Changed {
+			for _, change := range row.Changes {
+				table.AppendRow(row.Key, change.Field, change.Old, change.New)
+			}
+		}
+		e.printTable(table)
+	}
+}
+
+// diffRowCells 按列的顺序获取行的值
+func diffRowCells(columns []string, values map[string]string) []string {
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		cells[i] = values[column]
+	}
+	return cells
+}
@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// DoctorStatus 诊断检查结果状态
+type DoctorStatus string
+
+// 诊断检查结果状态
+const (
+	DoctorOK   DoctorStatus = "ok"   // 检查通过
+	DoctorWarn DoctorStatus = "warn" // 检查通过但存在隐患
+	DoctorFail DoctorStatus = "fail" // 检查失败
+	DoctorSkip DoctorStatus = "skip" // 前置检查失败，跳过
+)
+
+// rateLimitProbeRequests 限流探测发送的请求数
+const rateLimitProbeRequests = 5
+
+// 飞书开放平台常见错误码
+const (
+	feishuCodeInvalidAppID      = 10003    // app_id 无效
+	feishuCodeInvalidAppSecret  = 10014    // app_secret 无效
+	feishuCodeTokenInvalid      = 99991663 // 访问令牌无效
+	feishuCodeScopeMissing      = 99991672 // 应用未开通所需权限
+	feishuCodeRateLimited       = 99991400 // 请求频率超限
+	feishuCodeBaseNotFound      = 91402    // 多维表格不存在
+	feishuCodeBaseForbidden     = 91403    // 无多维表格访问权限
+	feishuCodeBaseTokenNotFound = 1254040  // app_token 不存在
+	feishuCodeRolePermNotAllow  = 1254302  // 高级权限下无访问权限
+)
+
+// scopeListRe 从权限错误信息中提取缺失的权限范围，如 [bitable:app, bitable:app:readonly]
+var scopeListRe = regexp.MustCompile(`\[([a-z_:.,\s]+)\]`)
+
+// DoctorCheck 单项诊断检查结果
+type DoctorCheck struct {
+	Name     string        `json:"name"`             // 检查项名称
+	Status   DoctorStatus  `json:"status"`           // 检查结果
+	Detail   string        `json:"detail"`           // 结果说明
+	Fix      string        `json:"fix,omitempty"`    // 修复建议
+	Duration time.Duration `json:"duration_ns"`      // 检查耗时
+	Code     int           `json:"code,omitempty"`   // 飞书错误码
+	Scopes   string        `json:"scopes,omitempty"` // 缺失的权限范围
+}
+
+// DoctorReport 诊断报告
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"` // 检查结果列表，按执行顺序排列
+}
+
+// Failed 判断是否存在失败的检查项
+func (r *DoctorReport) Failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == DoctorFail {
+			return true
+		}
+	}
+	return false
+}
+
+// add 追加检查结果
+func (r *DoctorReport) add(check DoctorCheck) {
+	r.Checks = append(r.Checks, check)
+}
+
+// skip 将剩余检查项标记为跳过
+func (r *DoctorReport) skip(names ...string) {
+	for _, name := range names {
+		r.add(DoctorCheck{Name: name, Status: DoctorSkip, Detail: "前置检查失败，已跳过"})
+	}
+}
+
+// RunDoctor 运行连接诊断
+// 依次检查配置、认证、多维表格访问、数据表列表、示例读取、权限范围和限流情况，
+// 前置检查失败时跳过依赖它的后续检查
+// 参数:
+//   - ctx: 上下文
+//   - config: CLI 配置
+//
+// 返回:
+//   - *DoctorReport: 诊断报告
+func RunDoctor(ctx context.Context, config *Config) *DoctorReport {
+	report := &DoctorReport{}
+
+	// 1. 配置
+	cfg, err := loadConfig(config)
+	if err != nil {
+		report.add(DoctorCheck{
+			Name:   "配置",
+			Status: DoctorFail,
+			Detail: strings.SplitN(err.Error(), "\n", 2)[0],
+			Fix:    "通过 --app-id/--app-secret/--app-token 或环境变量 FEISHU_APP_ID、FEISHU_APP_SECRET、FEISHU_APP_TOKEN 提供配置，也可以运行 'basesql config init' 生成配置文件",
+		})
+		report.skip("认证", "多维表格访问", "数据表列表", "示例读取", "权限范围", "限流探测")
+		return report
+	}
+	report.add(DoctorCheck{Name: "配置", Status: DoctorOK, Detail: fmt.Sprintf("App ID %s，App Token %s", maskID(cfg.AppID), maskID(cfg.AppToken))})
+
+	// 2. 认证
+	baseCfg := basesql.DefaultConfig()
+	baseCfg.AppID = cfg.AppID
+	baseCfg.AppSecret = cfg.AppSecret
+	baseCfg.AppToken = cfg.AppToken
+	baseCfg.Timeout = time.Duration(cfg.Timeout) * time.Second
+
+	start := time.Now()
+	client, err := basesql.NewClient(baseCfg)
+	if err != nil {
+		check := diagnoseError("认证", err)
+		check.Duration = time.Since(start)
+		report.add(check)
+		report.skip("多维表格访问", "数据表列表", "示例读取", "权限范围", "限流探测")
+		return report
+	}
+	defer client.Close()
+	report.add(DoctorCheck{Name: "认证", Status: DoctorOK, Detail: "成功获取 tenant_access_token", Duration: time.Since(start)})
+
+	// 3. 多维表格访问
+	var app struct {
+		App struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	}
+	check := doctorRequest(ctx, client, "多维表格访问", fmt.Sprintf("/bitable/v1/apps/%s", cfg.AppToken), nil, &app)
+	if check.Status == DoctorOK {
+		check.Detail = fmt.Sprintf("多维表格 '%s' 可访问", app.App.Name)
+	}
+	report.add(check)
+	if check.Status == DoctorFail {
+		report.add(scopeCheck(report))
+		report.skip("数据表列表", "示例读取", "限流探测")
+		return report
+	}
+
+	// 4. 数据表列表
+	var tables struct {
+		Items []basesql.Table `json:"items"`
+		Total int             `json:"total"`
+	}
+	tablesPath := fmt.Sprintf("/bitable/v1/apps/%s/tables", cfg.AppToken)
+	check = doctorRequest(ctx, client, "数据表列表", tablesPath, map[string]string{"page_size": "100"}, &tables)
+	if check.Status == DoctorOK {
+		check.Detail = fmt.Sprintf("共 %d 个数据表", len(tables.Items))
+		if len(tables.Items) == 0 {
+			check.Status = DoctorWarn
+			check.Fix = "多维表格中还没有数据表，可以使用 CREATE TABLE 或 'basesql seed' 创建"
+		}
+	}
+	report.add(check)
+
+	// 5. 示例读取
+	if check.Status == DoctorFail || len(tables.Items) == 0 {
+		report.skip("示例读取")
+	} else {
+		table := tables.Items[0]
+		var records struct {
+			Items []basesql.Record `json:"items"`
+			Total int              `json:"total"`
+		}
+		check = doctorRequest(ctx, client, "示例读取",
+			fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", cfg.AppToken, table.TableID),
+			map[string]string{"page_size": "1"}, &records)
+		if check.Status == DoctorOK {
+			check.Detail = fmt.Sprintf("从表 '%s' 读取成功，共 %d 条记录", table.Name, records.Total)
+		}
+		report.add(check)
+	}
+
+	// 6. 权限范围
+	check = scopeCheck(report)
+	if check.Status == DoctorOK {
+		check = writePermissionCheck(ctx, client)
+	}
+	report.add(check)
+
+	// 7. 限流探测
+	report.add(probeRateLimit(ctx, client, tablesPath))
+
+	return report
+}
+
+// doctorRequest 发送诊断用的 GET 请求并解析响应的 data 字段
+func doctorRequest(ctx context.Context, client *basesql.Client, name, path string, query map[string]string, data interface{}) DoctorCheck {
+	start := time.Now()
+	resp, err := client.DoRequest(ctx, &basesql.APIRequest{Method: "GET", Path: path, QueryParams: query})
+	if err == nil {
+		err = decodeFeishuResponse(resp.Body, data)
+	}
+	if err != nil {
+		check := diagnoseError(name, err)
+		check.Duration = time.Since(start)
+		return check
+	}
+	return DoctorCheck{Name: name, Status: DoctorOK, Duration: time.Since(start)}
+}
+
+// decodeFeishuResponse 解析飞书 API 响应，业务错误码非 0 时返回 APIError
+func decodeFeishuResponse(body []byte, data interface{}) error {
+	var apiResp struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return common.NewAPIError(apiResp.Code, "api", apiResp.Msg, "")
+	}
+	if data != nil && len(apiResp.Data) > 0 {
+		if err := json.Unmarshal(apiResp.Data, data); err != nil {
+			return fmt.Errorf("解析响应数据失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// diagnoseError 根据错误码生成失败的检查结果和修复建议
+func diagnoseError(name string, err error) DoctorCheck {
+	check := DoctorCheck{Name: name, Status: DoctorFail, Detail: err.Error()}
+
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) || apiErr.Type == "network" {
+		check.Fix = "检查网络连接和代理设置，确认可以访问 " + common.DefaultBaseURL
+		return check
+	}
+
+	check.Code = apiErr.Code
+	switch apiErr.Code {
+	case feishuCodeInvalidAppID, feishuCodeInvalidAppSecret:
+		check.Fix = "App ID 或 App Secret 不正确，请在飞书开放平台的「凭证与基础信息」页面核对"
+	case feishuCodeTokenInvalid:
+		check.Fix = "访问令牌无效，请确认应用已发布且未被停用"
+	case feishuCodeScopeMissing:
+		if matches := scopeListRe.FindStringSubmatch(apiErr.Error()); len(matches) == 2 {
+			check.Scopes = matches[1]
+		}
+		check.Fix = "应用缺少多维表格权限，请在开放平台「权限管理」中开通 bitable:app（或只读的 bitable:app:readonly），并发布新版本"
+	case feishuCodeBaseNotFound, feishuCodeBaseTokenNotFound:
+		check.Fix = "App Token 对应的多维表格不存在，请从多维表格 URL 中 /base/ 之后的部分重新复制 App Token"
+	case feishuCodeBaseForbidden, feishuCodeRolePermNotAllow:
+		check.Fix = "应用无权访问该多维表格，请在多维表格右上角「...」→「添加文档应用」中添加该应用，并授予可编辑权限"
+	case feishuCodeRateLimited, 429:
+		check.Status = DoctorWarn
+		check.Fix = "请求频率超限，请稍后重试或降低并发"
+	default:
+		if apiErr.Code >= 500 {
+			check.Fix = "飞书服务暂时不可用，请稍后重试"
+		} else {
+			check.Fix = "请参考飞书开放平台错误码文档排查: https://open.feishu.cn/document/server-docs/api-call-guide/generic-error-code"
+		}
+	}
+	return check
+}
+
+// scopeCheck 根据已有检查结果汇总权限范围
+func scopeCheck(report *DoctorReport) DoctorCheck {
+	for _, check := range report.Checks {
+		switch check.Code {
+		case feishuCodeScopeMissing:
+			detail := "应用未开通多维表格相关权限"
+			if check.Scopes != "" {
+				detail += "，需要以下权限之一: " + check.Scopes
+			}
+			return DoctorCheck{Name: "权限范围", Status: DoctorFail, Detail: detail, Fix: check.Fix, Code: check.Code, Scopes: check.Scopes}
+		case feishuCodeBaseForbidden, feishuCodeRolePermNotAllow:
+			return DoctorCheck{Name: "权限范围", Status: DoctorFail, Detail: "应用权限已开通，但未被添加为该多维表格的协作者", Fix: check.Fix, Code: check.Code}
+		}
+	}
+	return DoctorCheck{
+		Name:   "权限范围",
+		Status: DoctorOK,
+		Detail: "读取权限正常；写入操作还需要 bitable:app 权限和多维表格的可编辑权限",
+	}
+}
+
+// writePermissionCheck 读取正常时，预检写入记录和管理表结构所需的权限
+func writePermissionCheck(ctx context.Context, client *basesql.Client) DoctorCheck {
+	start := time.Now()
+	perms, err := client.CheckPermissions(ctx, basesql.PermWriteRecords, basesql.PermManageSchema)
+	if err != nil {
+		check := diagnoseError("权限范围", err)
+		check.Status = DoctorWarn
+		check.Duration = time.Since(start)
+		return check
+	}
+
+	check := DoctorCheck{Name: "权限范围", Status: DoctorOK, Detail: "读取和写入权限正常", Duration: time.Since(start)}
+	for _, result := range perms.Results {
+		switch {
+		case !result.Allowed:
+			check.Status = DoctorWarn
+			check.Detail = "只读访问：" + result.Reason
+			if len(result.MissingScopes) > 0 {
+				check.Scopes = strings.Join(result.MissingScopes, ", ")
+				check.Fix = "写入操作需要开通 " + check.Scopes + " 权限，请在开放平台「权限管理」中开通并发布新版本"
+			} else {
+				check.Fix = "请在多维表格右上角「...」→「添加文档应用」中为该应用授予可编辑权限"
+			}
+			return check
+		case !result.Verified:
+			check.Detail = "读取权限正常；" + result.Reason + "，写入操作还需要 bitable:app 权限和多维表格的可编辑权限"
+		}
+	}
+	return check
+}
+
+// probeRateLimit 连续发送少量请求，检测是否触发限流并统计平均延迟
+func probeRateLimit(ctx context.Context, client *basesql.Client, path string) DoctorCheck {
+	start := time.Now()
+	for i := 0; i < rateLimitProbeRequests; i++ {
+		resp, err := client.DoRequest(ctx, &basesql.APIRequest{Method: "GET", Path: path, QueryParams: map[string]string{"page_size": "1"}})
+		if err == nil {
+			err = decodeFeishuResponse(resp.Body, nil)
+		}
+		if err != nil {
+			check := diagnoseError("限流探测", err)
+			check.Duration = time.Since(start)
+			if check.Status == DoctorFail {
+				// 限流探测只用于提示，其他错误已在前面的检查中体现
+				check.Status = DoctorWarn
+			}
+			return check
+		}
+	}
+
+	duration := time.Since(start)
+	stats := client.RequestStats()
+	check := DoctorCheck{
+		Name:     "限流探测",
+		Status:   DoctorOK,
+		Detail:   fmt.Sprintf("连续 %d 次请求未触发限流，平均延迟 %v", rateLimitProbeRequests, (duration / rateLimitProbeRequests).Round(time.Millisecond)),
+		Duration: duration,
+	}
+	if stats.RateLimitWaits > 0 {
+		check.Status = DoctorWarn
+		check.Detail = fmt.Sprintf("诊断期间发生 %d 次限流等待", stats.RateLimitWaits)
+		check.Fix = "请求频率接近上限，批量操作时请降低并发或调小 RateLimitQPS"
+	}
+	return check
+}
+
+// maskID 遮蔽标识符，只保留首尾少量字符
+func maskID(id string) string {
+	if len(id) <= 8 {
+		return "****"
+	}
+	return id[:4] + "****" + id[len(id)-4:]
+}
+
+// PrintDoctorReport 输出诊断报告
+// 参数:
+//   - report: 诊断报告
+//   - format: 输出格式，json 时输出 JSON，其余输出文本
+//
+// 返回:
+//   - error: 输出错误信息
+func PrintDoctorReport(report *DoctorReport, format string) error {
+	if format == OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	icons := map[DoctorStatus]string{DoctorOK: "✅", DoctorWarn: "⚠️ ", DoctorFail: "❌", DoctorSkip: "⏭️ "}
+	for _, check := range report.Checks {
+		fmt.Printf("%s %s: %s", icons[check.Status], check.Name, check.Detail)
+		if check.Duration > 0 {
+			fmt.Printf(" (%v)", check.Duration.Round(time.Millisecond))
+		}
+		fmt.Println()
+		if check.Fix != "" {
+			fmt.Printf("   💡 %s\n", check.Fix)
+		}
+	}
+	return nil
+}
@@ -3,25 +3,36 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
+	"io"
+	"os"
 	"strings"
 	"time"
 
 	basesql "github.com/ag9920/basesql"
 	"github.com/ag9920/basesql/internal/common"
 	"github.com/ag9920/basesql/internal/security"
+	"github.com/ag9920/basesql/queryengine"
 	"gorm.io/gorm"
 )
 
 // Executor SQL 执行器
 // 负责执行各种 SQL 命令并与飞书多维表格 API 交互
 type Executor struct {
-	db       *gorm.DB        // GORM 数据库连接
-	client   *basesql.Client // BaseSQL 客户端
-	appToken string          // 飞书应用 Token
-	timeout  time.Duration   // 请求超时时间
+	db         *gorm.DB              // GORM 数据库连接
+	client     *basesql.Client       // BaseSQL 客户端
+	appToken   string                // 飞书应用 Token
+	timeout    time.Duration         // 请求超时时间
+	format     string                // 查询结果输出格式
+	tableStyle common.TableStyle     // 表格渲染样式
+	vertical   bool                  // 是否纵向显示记录（\G）
+	rowCount   int64                 // 最近一条命令返回或影响的行数
+	ctx        context.Context       // 当前语句的上下文，取消后中止正在进行的请求和分页
+	config     *basesql.Config       // BaseSQL 配置，用于只读模式检查
+	policy     *Policy               // 当前角色的语句策略，为空时不限制
+	with       map[string]*withTable // 当前语句中已物化的公用表表达式，键为小写的名称
+	profiles   *ProfileCache         // profile 统计缓存，为空时 WHERE 条件不按统计信息排列
 }
 
 // NewExecutor 创建新的 SQL 执行器
@@ -43,13 +54,54 @@ func NewExecutor(db *gorm.DB) (*Executor, error) {
 	}
 
 	return &Executor{
-		db:       db,
-		client:   dialector.Client,
-		appToken: dialector.Config.AppToken,
-		timeout:  dialector.Config.Timeout, // 使用配置中的超时时间
+		db:         db,
+		client:     dialector.Client,
+		appToken:   dialector.Config.AppToken,
+		timeout:    dialector.Config.Timeout, // 使用配置中的超时时间
+		tableStyle: common.TableStyleASCII,
+		config:     dialector.Config,
 	}, nil
 }
 
+// checkPolicy 按语句策略检查语句类型和表
+// SELECT 检查实际读取的每张表，包括 UNION 的各条查询和 WITH 中的查询，不检查公用表表达式的名称
+func (e *Executor) checkPolicy(cmd *common.SQLCommand) error {
+	if cmd.Type != common.CommandSelect {
+		return e.policy.Check(cmd.Type, cmd.Table)
+	}
+	for _, table := range sourceTables(cmd) {
+		if err := e.policy.Check(cmd.Type, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// baseContext 获取当前语句的上下文，未设置时使用 context.Background()
+// 只用于命令行交互执行的语句和命令，分页读取记录时在标准错误输出进度
+func (e *Executor) baseContext() context.Context {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return withProgress(ctx)
+}
+
+// progressCtxKey 上下文中表示分页读取记录时输出进度的键
+type progressCtxKey struct{}
+
+// withProgress 返回分页读取记录时在标准错误输出进度的上下文
+// Query、QueryEach 等供 HTTP 服务、gRPC 服务和库调用的方法使用调用方的上下文，默认不输出进度
+func withProgress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, progressCtxKey{}, true)
+}
+
+// showProgress 判断分页读取记录时是否输出进度
+func showProgress(ctx context.Context) bool {
+	progress, _ := ctx.Value(progressCtxKey{}).(bool)
+	return progress
+}
+
 // Execute 执行 SQL 命令
 // 根据命令类型分发到相应的处理函数
 // 参数:
@@ -65,18 +117,36 @@ func (e *Executor) Execute(cmd *common.SQLCommand) error {
 	if e.db == nil {
 		return fmt.Errorf("数据库连接未初始化")
 	}
+	e.rowCount = 0
 
 	// SQL注入验证
+	// SELECT 中的 UNION 已按语法拆分为各条查询，验证时去掉连接关键字
+	sql := cmd.RawSQL
+	if cmd.Type == common.CommandSelect {
+		sql = stripUnion(sql)
+	}
 	validator := security.NewSQLInjectionValidator()
-	if err := validator.ValidateSQL(cmd.RawSQL); err != nil {
+	if err := validator.ValidateSQL(sql); err != nil {
 		return fmt.Errorf("安全验证失败: %w", err)
 	}
 
+	// 只读模式下在发起任何请求前拒绝写语句
+	if isExecCommand(cmd.Type) {
+		if err := e.config.CheckWritable(string(cmd.Type)); err != nil {
+			return err
+		}
+	}
+
+	// 按语句策略检查语句类型和表
+	if err := e.checkPolicy(cmd); err != nil {
+		return err
+	}
+
 	// 记录执行开始时间
 	startTime := time.Now()
 	defer func() {
 		duration := time.Since(startTime)
-		if duration > common.SlowQueryThreshold {
+		if duration > e.slowQueryThreshold() {
 			fmt.Printf("⏱️  执行耗时: %v\n", duration)
 		}
 	}()
@@ -97,6 +167,9 @@ func (e *Executor) Execute(cmd *common.SQLCommand) error {
 	case common.CommandDescribe:
 		return e.describe(cmd.Table)
 	case common.CommandSelect:
+		if cmd.OutFile != "" {
+			return e.selectIntoFile(cmd)
+		}
 		return e.selectData(cmd)
 	case common.CommandInsert:
 		return e.insertData(cmd)
@@ -118,7 +191,7 @@ func (e *Executor) Execute(cmd *common.SQLCommand) error {
 // 返回:
 //   - error: 执行错误信息
 func (e *Executor) showTables() error {
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
 	defer cancel()
 
 	// 调用飞书 API 获取表列表
@@ -127,29 +200,14 @@ func (e *Executor) showTables() error {
 		return fmt.Errorf("获取表列表失败: %w", err)
 	}
 
-	// 显示表格头部
 	fmt.Println("📋 数据表列表:")
-	fmt.Println("+------------------+")
-	fmt.Println("| Tables_in_base   |")
-	fmt.Println("+------------------+")
-
-	// 显示表列表
-	if len(tables) == 0 {
-		fmt.Println("|   <无数据表>     |")
-	} else {
-		for _, table := range tables {
-			// 处理中文字符的显示宽度
-			displayName := table.Name
-			if common.GetDisplayWidth(displayName) > 16 {
-				// 截断过长的表名
-				displayName = common.TruncateString(displayName, 13) + "..."
-			}
-			fmt.Printf("| %-16s |\n", common.PadString(displayName, 16))
-		}
+	table := e.newTable("Tables_in_base")
+	for _, t := range tables {
+		table.AppendRow(t.Name)
 	}
-
-	fmt.Println("+------------------+")
+	e.printTable(table)
 	fmt.Printf("\n共 %d 个数据表\n", len(tables))
+	e.rowCount = int64(len(tables))
 
 	return nil
 }
@@ -160,11 +218,9 @@ func (e *Executor) showTables() error {
 //   - error: 执行错误信息
 func (e *Executor) showDatabases() error {
 	fmt.Println("🗄️  数据库列表:")
-	fmt.Println("+--------------------+")
-	fmt.Println("| Database           |")
-	fmt.Println("+--------------------+")
-	fmt.Printf("| %-18s |\n", "feishu_base")
-	fmt.Println("+--------------------+")
+	table := e.newTable("Database")
+	table.AppendRow("feishu_base")
+	e.printTable(table)
 	fmt.Println("\n💡 在飞书多维表格中，每个应用相当于一个数据库")
 
 	return nil
@@ -181,7 +237,7 @@ func (e *Executor) showColumns(tableName string) error {
 		return fmt.Errorf("表名不能为空")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
 	defer cancel()
 
 	// 获取表 ID
@@ -196,39 +252,23 @@ func (e *Executor) showColumns(tableName string) error {
 		return err
 	}
 
-	// 显示表头
 	fmt.Printf("📋 表 '%s' 的字段信息:\n", tableName)
-	fmt.Println("+-------------+-------------+------+-----+---------+-------+")
-	fmt.Println("| Field       | Type        | Null | Key | Default | Extra |")
-	fmt.Println("+-------------+-------------+------+-----+---------+-------+")
-
-	// 显示字段信息
-	if len(fields) == 0 {
-		fmt.Println("|   <无字段>   |             |      |     |         |       |")
-	} else {
-		for _, field := range fields {
-			fieldType := getFieldTypeString(field.Type)
-			nullable := "YES"
-			key := ""
-			if field.IsPrimary {
-				key = "PRI"
-			}
-			defaultVal := "NULL"
-			extra := ""
-
-			// 处理字段名显示宽度
-			fieldName := field.FieldName
-			if common.GetDisplayWidth(fieldName) > 11 {
-				fieldName = common.TruncateString(fieldName, 8) + "..."
-			}
-
-			fmt.Printf("| %-11s | %-11s | %-4s | %-3s | %-7s | %-5s |\n",
-				common.PadString(fieldName, 11), fieldType, nullable, key, defaultVal, extra)
+	table := e.newTable("Field", "Type", "Null", "Key", "Default", "Extra")
+	for _, field := range fields {
+		key := ""
+		if field.IsPrimary {
+			key = "PRI"
+		}
+		// 自动编号由飞书按创建顺序生成，与 MySQL 的 auto_increment 类似，只读
+		extra := ""
+		if field.Type == basesql.FieldTypeAutoNumber {
+			extra = "auto_number"
 		}
+		table.AppendRow(field.FieldName, getFieldTypeString(field.Type), "YES", key, "NULL", extra)
 	}
-
-	fmt.Println("+-------------+-------------+------+-----+---------+-------+")
+	e.printTable(table)
 	fmt.Printf("\n共 %d 个字段\n", len(fields))
+	e.rowCount = int64(len(fields))
 
 	return nil
 }
@@ -272,24 +312,27 @@ func (e *Executor) getTableList(ctx context.Context) ([]basesql.Table, error) {
 // getTableID 根据表名获取表 ID
 // 参数:
 //   - ctx: 上下文
-//   - tableName: 表名
+//   - tableName: 表名，也可以直接使用表 ID
 //
 // 返回:
 //   - string: 表 ID
-//   - error: 错误信息
+//   - error: 错误信息，表不存在或同名的表不止一张时返回错误
 func (e *Executor) getTableID(ctx context.Context, tableName string) (string, error) {
+	// 表名解析一次后记住表 ID，serve 网关运行期间表在飞书界面中改名不影响按原名称访问
+	return e.client.ResolveTableID(ctx, tableName)
+}
+
+// resolveTable 按表名或表 ID 查找表，匹配规则见 basesql.ResolveTable
+func (e *Executor) resolveTable(ctx context.Context, nameOrID string) (*basesql.Table, error) {
 	tables, err := e.getTableList(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	for _, table := range tables {
-		if table.Name == tableName {
-			return table.TableID, nil
-		}
+	candidates := make([]*basesql.Table, len(tables))
+	for i := range tables {
+		candidates[i] = &tables[i]
 	}
-
-	return "", fmt.Errorf("表 '%s' 不存在", tableName)
+	return basesql.ResolveTable(candidates, nameOrID)
 }
 
 // getFieldsList 获取字段列表
@@ -369,333 +412,129 @@ func (e *Executor) getRecords(ctx context.Context, tableID string) ([]basesql.Re
 //   - error: 错误信息
 func (e *Executor) getRecordsWithLimit(ctx context.Context, tableID string, limit int) ([]basesql.Record, error) {
 	var allRecords []basesql.Record
+	err := e.forEachRecord(ctx, tableID, func(record basesql.Record) error {
+		allRecords = append(allRecords, record)
+		// 如果设置了限制且已达到限制，停止获取
+		if limit > 0 && len(allRecords) >= limit {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allRecords, nil
+}
+
+// errStopIteration 由 forEachRecord 的回调返回，表示提前结束遍历
+var errStopIteration = errors.New("stop iteration")
+
+// forEachRecord 分页遍历表中的记录
+// 每页获取后立即交给回调处理，不在内存中保留全部记录，适合扫描大表
+// 参数:
+//   - ctx: 上下文，取消后立即停止分页；由 withProgress 创建时在标准错误输出进度
+//   - tableID: 表 ID
+//   - fn: 处理单条记录的回调，返回 errStopIteration 时提前结束遍历
+//
+// 返回:
+//   - error: 请求错误或回调返回的错误
+func (e *Executor) forEachRecord(ctx context.Context, tableID string, fn func(record basesql.Record) error) error {
+	return e.scanRecords(ctx, tableID, showProgress(ctx), fn)
+}
+
+// scanRecords 分页遍历表中的记录，progress 为 false 时不在标准错误输出进度，
+// 用于逐条输出结果的查询，避免进度提示与结果交错
+func (e *Executor) scanRecords(ctx context.Context, tableID string, progress bool, fn func(record basesql.Record) error) error {
+	// 进度提示输出到标准错误，不显示进度时丢弃
+	var status io.Writer = os.Stderr
+	if !progress {
+		status = io.Discard
+	}
 	pageToken := ""
 	pageNum := 1
+	count := 0
+	pageSize := e.pageSize()
 
 	for {
-		// 构建查询参数
-		queryParams := fmt.Sprintf("?page_size=500")
-		if pageToken != "" {
-			queryParams += fmt.Sprintf("&page_token=%s", pageToken)
-		}
-
-		apiReq := &basesql.APIRequest{
-			Method: "GET",
-			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records%s", e.appToken, tableID, queryParams),
+		// 上下文已取消时立即停止分页
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintln(status) // 换行
+			return err
 		}
 
 		// 显示进度提示
 		if pageNum == 1 {
-			fmt.Printf("正在获取数据...")
+			fmt.Fprintf(status, "正在获取数据...")
 		} else {
-			fmt.Printf("\r正在获取数据... 第 %d 页", pageNum)
+			fmt.Fprintf(status, "\r正在获取数据... 第 %d 页", pageNum)
 		}
 
-		resp, err := e.client.DoRequest(ctx, apiReq)
+		// 返回创建时间等自动字段；API 拒绝每页记录数时自动减半，后续分页沿用减半后的大小
+		page, used, err := e.client.ListRecordsPage(ctx, tableID, &basesql.ListRecordsRequest{AutomaticFields: true}, pageToken, pageSize)
 		if err != nil {
-			fmt.Println() // 换行
-			return nil, fmt.Errorf("API 请求失败: %w", err)
-		}
-
-		var apiResp basesql.ListRecordsAPIResponse
-		if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
-			fmt.Println() // 换行
-			return nil, fmt.Errorf("解析记录响应失败: %w", err)
-		}
-
-		// 检查API调用是否成功
-		if apiResp.Code != 0 || apiResp.Data == nil {
-			fmt.Println() // 换行
-			return nil, fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+			fmt.Fprintln(status) // 换行
+			return fmt.Errorf("API 请求失败: %w", err)
 		}
-
-		// 转换指针切片为值切片并添加到总记录中
-		for _, item := range apiResp.Data.Items {
-			allRecords = append(allRecords, *item)
-			// 如果设置了限制且已达到限制，停止获取
-			if limit > 0 && len(allRecords) >= limit {
-				break
+		pageSize = used
+
+		for _, item := range page.Items {
+			count++
+			if err := fn(*item); err != nil {
+				if errors.Is(err, errStopIteration) {
+					fmt.Fprintf(status, "\r数据获取完成，共 %d 条记录\n", count)
+					return nil
+				}
+				fmt.Fprintln(status) // 换行
+				return err
 			}
 		}
 
-		// 如果设置了限制且已达到限制，停止获取
-		if limit > 0 && len(allRecords) >= limit {
-			break
-		}
-
 		// 检查是否还有更多数据
-		if !apiResp.Data.HasMore {
+		if !page.HasMore {
 			break
 		}
 
 		// 更新分页标记
-		pageToken = apiResp.Data.PageToken
+		pageToken = page.PageToken
 		pageNum++
 	}
 
 	// 清除进度提示
-	fmt.Printf("\r数据获取完成，共 %d 条记录\n", len(allRecords))
-	return allRecords, nil
-}
-
-// renderResultTable 渲染查询结果表格（原有的API方式，保留向后兼容）
-// 参数:
-//   - fields: 字段列表
-//   - records: 记录列表
-//
-// 返回:
-//   - error: 渲染错误信息
-func (e *Executor) renderResultTable(fields []basesql.Field, records []basesql.Record) error {
-	if len(fields) == 0 {
-		fmt.Println("📭 表中没有字段")
-		return nil
-	}
-
-	// 构建字段名列表
-	fieldNames := make([]string, 0, len(fields))
-	for _, field := range fields {
-		fieldNames = append(fieldNames, field.FieldName)
-	}
-
-	// 计算列宽
-	colWidths := e.calculateColumnWidths(fieldNames, records)
-
-	// 渲染表格
-	e.printTableHeader(fieldNames, colWidths)
-	e.printTableRows(fieldNames, records, colWidths)
-	e.printTableFooter(fieldNames, colWidths)
-
-	fmt.Printf("\n📊 查询返回 %d 行数据\n", len(records))
+	fmt.Fprintf(status, "\r数据获取完成，共 %d 条记录\n", count)
 	return nil
 }
 
-// renderGormResultTable 渲染GORM查询结果表格（新的GORM方式）
-// 参数:
-//   - columns: 列名列表
-//   - records: 记录列表（map格式）
-//
-// 返回:
-//   - error: 渲染错误信息
-func (e *Executor) renderGormResultTable(columns []string, records []map[string]interface{}) error {
-	if len(columns) == 0 {
-		fmt.Println("📭 表中没有字段")
-		return nil
-	}
-
-	// 计算列宽
-	colWidths := e.calculateGormColumnWidths(columns, records)
-
-	// 渲染表格
-	e.printGormTableHeader(columns, colWidths)
-	e.printGormTableRows(columns, records, colWidths)
-	e.printGormTableFooter(columns, colWidths)
-
-	fmt.Printf("\n📊 查询返回 %d 行数据\n", len(records))
-	return nil
-}
-
-// calculateColumnWidths 计算列宽
-// 参数:
-//   - fieldNames: 字段名列表
-//   - records: 记录列表
-//
+// RowCount 获取最近一条命令返回或影响的行数
 // 返回:
-//   - map[string]int: 列宽映射
-func (e *Executor) calculateColumnWidths(fieldNames []string, records []basesql.Record) map[string]int {
-	colWidths := make(map[string]int)
-
-	for _, fieldName := range fieldNames {
-		// 字段名的显示宽度
-		colWidths[fieldName] = common.GetDisplayWidth(fieldName)
-
-		// 遍历所有记录，找到最大宽度
-		for _, record := range records {
-			if value, exists := record.Fields[fieldName]; exists && value != nil {
-				valStr := common.FormatValue(value)
-				displayWidth := common.GetDisplayWidth(valStr)
-				if displayWidth > colWidths[fieldName] {
-					colWidths[fieldName] = displayWidth
-				}
-			}
-		}
-
-		// 设置最小和最大宽度
-		if colWidths[fieldName] < 8 {
-			colWidths[fieldName] = 8
-		} else if colWidths[fieldName] > 30 {
-			colWidths[fieldName] = 30 // 限制最大宽度
-		}
-	}
-
-	return colWidths
+//   - int64: 行数
+func (e *Executor) RowCount() int64 {
+	return e.rowCount
 }
 
-// printTableHeader 打印表格头部
+// printTable 输出表格，纵向模式下每条记录按 "列名: 值" 逐行显示
 // 参数:
-//   - fieldNames: 字段名列表
-//   - colWidths: 列宽映射
-func (e *Executor) printTableHeader(fieldNames []string, colWidths map[string]int) {
-	// 打印顶部边框
-	fmt.Print("+")
-	for _, fieldName := range fieldNames {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[fieldName]+2))
-	}
-	fmt.Println()
-
-	// 打印字段名
-	fmt.Print("|")
-	for _, fieldName := range fieldNames {
-		displayName := fieldName
-		if common.GetDisplayWidth(displayName) > colWidths[fieldName] {
-			displayName = common.TruncateString(displayName, colWidths[fieldName]-3) + "..."
-		}
-		fmt.Printf(" %s |", common.PadString(displayName, colWidths[fieldName]))
+//   - table: 表格实例
+func (e *Executor) printTable(table *common.Table) {
+	if e.vertical {
+		fmt.Print(table.VerticalString())
+		return
 	}
-	fmt.Println()
-
-	// 打印分隔线
-	fmt.Print("+")
-	for _, fieldName := range fieldNames {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[fieldName]+2))
-	}
-	fmt.Println()
+	fmt.Print(table.String())
 }
 
-// printTableRows 打印表格数据行
+// newTable 按执行器配置的样式创建表格
 // 参数:
-//   - fieldNames: 字段名列表
-//   - records: 记录列表
-//   - colWidths: 列宽映射
-func (e *Executor) printTableRows(fieldNames []string, records []basesql.Record, colWidths map[string]int) {
-	for _, record := range records {
-		fmt.Print("|")
-		for _, fieldName := range fieldNames {
-			val := ""
-			if value, exists := record.Fields[fieldName]; exists && value != nil {
-				val = common.FormatValue(value)
-				// 截断过长的值
-				if common.GetDisplayWidth(val) > colWidths[fieldName] {
-					val = common.TruncateString(val, colWidths[fieldName]-3) + "..."
-				}
-			}
-			fmt.Printf(" %s |", common.PadString(val, colWidths[fieldName]))
-		}
-		fmt.Println()
-	}
-}
-
-// printTableFooter 打印表格底部
-// 参数:
-//   - fieldNames: 字段名列表
-//   - colWidths: 列宽映射
-func (e *Executor) printTableFooter(fieldNames []string, colWidths map[string]int) {
-	fmt.Print("+")
-	for _, fieldName := range fieldNames {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[fieldName]+2))
-	}
-	fmt.Println()
-}
-
-// calculateGormColumnWidths 计算GORM查询结果的列宽
-// 参数:
-//   - columns: 列名列表
-//   - records: 记录列表（map格式）
+//   - headers: 表头列表
 //
 // 返回:
-//   - map[string]int: 列宽映射
-func (e *Executor) calculateGormColumnWidths(columns []string, records []map[string]interface{}) map[string]int {
-	colWidths := make(map[string]int)
-
-	for _, column := range columns {
-		// 列名的显示宽度
-		colWidths[column] = common.GetDisplayWidth(column)
-
-		// 遍历所有记录，找到最大宽度
-		for _, record := range records {
-			if value, exists := record[column]; exists && value != nil {
-				valStr := common.FormatValue(value)
-				displayWidth := common.GetDisplayWidth(valStr)
-				if displayWidth > colWidths[column] {
-					colWidths[column] = displayWidth
-				}
-			}
-		}
-
-		// 设置最小和最大宽度
-		if colWidths[column] < 8 {
-			colWidths[column] = 8
-		} else if colWidths[column] > 30 {
-			colWidths[column] = 30 // 限制最大宽度
-		}
-	}
-
-	return colWidths
-}
-
-// printGormTableHeader 打印GORM查询结果的表格头部
-// 参数:
-//   - columns: 列名列表
-//   - colWidths: 列宽映射
-func (e *Executor) printGormTableHeader(columns []string, colWidths map[string]int) {
-	// 打印顶部边框
-	fmt.Print("+")
-	for _, column := range columns {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[column]+2))
-	}
-	fmt.Println()
-
-	// 打印列名
-	fmt.Print("|")
-	for _, column := range columns {
-		displayName := column
-		if common.GetDisplayWidth(displayName) > colWidths[column] {
-			displayName = common.TruncateString(displayName, colWidths[column]-3) + "..."
-		}
-		fmt.Printf(" %s |", common.PadString(displayName, colWidths[column]))
-	}
-	fmt.Println()
-
-	// 打印分隔线
-	fmt.Print("+")
-	for _, column := range columns {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[column]+2))
-	}
-	fmt.Println()
-}
-
-// printGormTableRows 打印GORM查询结果的表格数据行
-// 参数:
-//   - columns: 列名列表
-//   - records: 记录列表（map格式）
-//   - colWidths: 列宽映射
-func (e *Executor) printGormTableRows(columns []string, records []map[string]interface{}, colWidths map[string]int) {
-	for _, record := range records {
-		fmt.Print("|")
-		for _, column := range columns {
-			val := ""
-			if value, exists := record[column]; exists && value != nil {
-				val = common.FormatValue(value)
-				// 截断过长的值
-				if common.GetDisplayWidth(val) > colWidths[column] {
-					val = common.TruncateString(val, colWidths[column]-3) + "..."
-				}
-			}
-			fmt.Printf(" %s |", common.PadString(val, colWidths[column]))
-		}
-		fmt.Println()
-	}
-}
-
-// printGormTableFooter 打印GORM查询结果的表格底部
-// 参数:
-//   - columns: 列名列表
-//   - colWidths: 列宽映射
-func (e *Executor) printGormTableFooter(columns []string, colWidths map[string]int) {
-	fmt.Print("+")
-	for _, column := range columns {
-		fmt.Printf("%s+", strings.Repeat("-", colWidths[column]+2))
-	}
-	fmt.Println()
+//   - *common.Table: 表格实例
+func (e *Executor) newTable(headers ...string) *common.Table {
+	table := common.NewTable(headers...).SetStyle(e.tableStyle)
+	if e.tableStyle == common.TableStyleMarkdown {
+		// Markdown 表格用于复制到文档，保留完整内容
+		table.SetMaxColumnWidth(0)
+	}
+	return table
 }
 
 // getStringValue 安全地从 map 中获取字符串值
@@ -780,51 +619,454 @@ func (e *Executor) selectData(cmd *common.SQLCommand) error {
 		return fmt.Errorf("表名不能为空")
 	}
 
-	fmt.Printf("执行查询: %s\n", cmd.RawSQL)
+	// 提示信息输出到标准错误，避免混入 JSON/CSV 结果
+	fmt.Fprintf(os.Stderr, "执行查询: %s\n", cmd.RawSQL)
 
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
 	defer cancel()
 
+	// WITH 子句中的查询先物化到内存中
+	if len(cmd.With) > 0 {
+		if err := e.materializeWith(ctx, cmd.With); err != nil {
+			return err
+		}
+		defer func() { e.with = nil }()
+	}
+
+	// 合并查询分别执行各条 SELECT 后合并结果
+	if len(cmd.Union) > 0 {
+		columns, rows, err := e.unionRows(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		e.rowCount = int64(len(rows))
+		if len(rows) == 0 {
+			fmt.Printf("📭 查询结果为空\n")
+			return nil
+		}
+		return e.renderResult(columns, rows)
+	}
+
+	// 不需要排序、分组和聚合的查询逐页输出，不在内存中保留全部记录；公用表表达式已在内存中
+	if _, ok := e.lookupWith(cmd.Table); !ok && !cmd.IsAggregate && len(cmd.OrderBy) == 0 && len(cmd.GroupBy) == 0 {
+		return e.streamSelect(ctx, cmd)
+	}
+
+	fields, records, err := e.loadSelect(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	// 如果是聚合查询，处理聚合函数
+	if cmd.IsAggregate {
+		return e.handleAggregateQuery(cmd, fields, records)
+	}
+
+	// 应用 WHERE、GROUP BY、ORDER BY 和 LIMIT，按 SELECT 投影确定输出列
+	columns, filteredRecords, err := e.selectRows(cmd, fields, records)
+	if err != nil {
+		return err
+	}
+
+	e.rowCount = int64(len(filteredRecords))
+
+	// 如果没有结果，显示空表
+	if len(filteredRecords) == 0 {
+		fmt.Printf("📭 查询结果为空\n")
+		return nil
+	}
+
+	// 按输出格式渲染查询结果
+	return e.renderResult(columns, filteredRecords)
+}
+
+// selectIntoFile 执行 SELECT ... INTO OUTFILE，将查询结果写入本地文件，不渲染终端表格
+// 文件格式由扩展名决定（.csv 或 .json），其他扩展名使用 --format 指定的 csv 或 json
+// 参数:
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - error: 执行错误信息
+func (e *Executor) selectIntoFile(cmd *common.SQLCommand) error {
+	// 先解析输出文件，避免查询完成后才发现无法确定格式
+	writer, err := NewFileWriter(cmd.OutFile, e.format)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	query := *cmd
+	query.OutFile = ""
+	result, err := e.Query(ctx, &query)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(ctx, result); err != nil {
+		return err
+	}
+	e.rowCount = int64(result.RowCount)
+	fmt.Fprintf(os.Stderr, "已将 %d 条记录写入 %s\n", result.RowCount, cmd.OutFile)
+	return nil
+}
+
+// loadSelect 获取 SELECT 语句所查询表的字段列表和满足 WHERE 条件的记录
+// 排序、分组和聚合需要全部满足条件的记录，LIMIT 和 --max-rows 在这些步骤之后由 selectRows 应用；
+// 分组和聚合查询满足条件的记录超过 --max-rows 时返回错误，不计算不完整的结果
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - []basesql.Field: 字段列表
+//   - []basesql.Record: 记录列表；公用表表达式的记录尚未应用 WHERE 条件
+//   - error: 错误信息
+func (e *Executor) loadSelect(ctx context.Context, cmd *common.SQLCommand) ([]basesql.Field, []basesql.Record, error) {
+	if table, ok := e.lookupWith(cmd.Table); ok {
+		return table.fields, table.records, nil
+	}
+
+	tableID, fields, err := e.resolveSelect(ctx, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 不需要排序、分组和聚合时，满足条件的记录达到 LIMIT 即可停止读取；
+	// 超过 --max-rows 时多读一条，用于判断输出是否被截断
+	aggregate := cmd.IsAggregate || len(cmd.GroupBy) > 0
+	maxRows := e.maxRows()
+	limit := 0
+	if !aggregate && len(cmd.OrderBy) == 0 {
+		limit = cmd.Limit
+		if maxRows > 0 && (limit <= 0 || limit > maxRows) {
+			limit = maxRows + 1
+		}
+	}
+
+	match := e.engine(cmd.Table).Matcher(fields, whereConditions(cmd))
+	var records []basesql.Record
+	err = e.forEachRecord(ctx, tableID, func(record basesql.Record) error {
+		if !match(record) {
+			return nil
+		}
+		if aggregate && maxRows > 0 && len(records) >= maxRows {
+			return fmt.Errorf("%w: 表 %s 满足条件的记录超过 %d 条，分组和聚合结果不完整；使用 WHERE 缩小范围，或通过 --max-rows 调大上限（0 表示不限制）", ErrMaxRowsExceeded, cmd.Table, maxRows)
+		}
+		records = append(records, record)
+		if limit > 0 && len(records) >= limit {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+	return fields, records, nil
+}
+
+// resolveSelect 获取 SELECT 语句所查询表的 ID 和字段列表
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - string: 表 ID
+//   - []basesql.Field: 字段列表
+//   - error: 错误信息
+func (e *Executor) resolveSelect(ctx context.Context, cmd *common.SQLCommand) (string, []basesql.Field, error) {
 	// 获取表 ID
 	tableID, err := e.getTableID(ctx, cmd.Table)
 	if err != nil {
-		return fmt.Errorf("获取表ID失败: %w", err)
+		return "", nil, fmt.Errorf("获取表ID失败: %w", err)
 	}
 
 	// 获取字段列表
 	fields, err := e.getFieldsList(ctx, tableID)
 	if err != nil {
-		return fmt.Errorf("获取字段列表失败: %w", err)
+		return "", nil, fmt.Errorf("获取字段列表失败: %w", err)
 	}
+	return tableID, fields, nil
+}
 
-	// 获取记录列表（考虑LIMIT限制）
-	var records []basesql.Record
-	if cmd.Limit > 0 {
-		records, err = e.getRecordsWithLimit(ctx, tableID, cmd.Limit)
-	} else {
-		records, err = e.getRecords(ctx, tableID)
+// streamSelect 逐页读取、过滤并输出普通 SELECT 的结果，内存占用与结果行数无关
+// 表格格式按前 streamSampleRows 条结果计算列宽，之后的结果逐条输出；LIMIT 按满足 WHERE 条件的记录计数
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - error: 执行错误信息
+func (e *Executor) streamSelect(ctx context.Context, cmd *common.SQLCommand) error {
+	tableID, fields, err := e.resolveSelect(ctx, cmd)
+	if err != nil {
+		return err
 	}
+	engine := e.engine(cmd.Table)
+	columns, err := engine.Project(cmd.Fields, fields)
+	if err != nil {
+		return err
+	}
+
+	match := engine.Matcher(fields, whereConditions(cmd))
+	writer := e.newResultWriter(os.Stdout, columns)
+	maxRows, truncated := e.maxRows(), false
+	err = e.scanRecords(ctx, tableID, false, func(record basesql.Record) error {
+		if !match(record) {
+			return nil
+		}
+		// --max-rows 按输出的行数计数，已输出的行不受影响
+		if maxRows > 0 && writer.Count() >= maxRows {
+			truncated = true
+			return errStopIteration
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			return err
+		}
+		if cmd.Limit > 0 && writer.Count() >= cmd.Limit {
+			return errStopIteration
+		}
+		return nil
+	})
+	e.rowCount = int64(writer.Count())
 	if err != nil {
 		return fmt.Errorf("获取记录失败: %w", err)
 	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if truncated {
+		printMaxRowsNotice(cmd.Table, maxRows)
+	}
+	return nil
+}
+
+// printMaxRowsNotice 提示查询结果超过 --max-rows 上限，只输出了前 maxRows 行
+func printMaxRowsNotice(table string, maxRows int) {
+	fmt.Fprintf(os.Stderr, "⚠️  表 %s 的查询结果超过 %d 行，只输出了前 %d 行；使用 LIMIT 缩小范围，或通过 --max-rows 调大上限（0 表示不限制）\n", table, maxRows, maxRows)
+}
+
+// Query 执行 SELECT 语句并返回结构化结果，不输出到终端
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - *QueryResult: 查询结果
+//   - error: 错误信息
+func (e *Executor) Query(ctx context.Context, cmd *common.SQLCommand) (*QueryResult, error) {
+	if err := e.checkQuery(cmd); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	if len(cmd.With) > 0 {
+		if err := e.materializeWith(ctx, cmd.With); err != nil {
+			return nil, err
+		}
+		defer func() { e.with = nil }()
+	}
+
+	if len(cmd.Union) > 0 {
+		columns, rows, err := e.unionRows(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		return newQueryResult(columns, rows), nil
+	}
+
+	fields, records, err := e.loadSelect(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
 
-	// 如果是聚合查询，处理聚合函数
 	if cmd.IsAggregate {
-		return e.handleAggregateQuery(cmd, fields, records)
+		value, err := e.aggregate(cmd, fields, records)
+		if err != nil {
+			return nil, err
+		}
+		label := cmd.Fields[0]
+		return &QueryResult{Columns: []string{label}, Rows: []map[string]interface{}{{label: value}}, RowCount: 1}, nil
 	}
 
-	// 应用WHERE条件过滤记录
-	filteredRecords := e.filterRecords(records, fields, cmd.Condition)
+	columns, rows, err := e.selectRows(cmd, fields, records)
+	if err != nil {
+		return nil, err
+	}
+	return newQueryResult(columns, rows), nil
+}
 
-	// 如果没有结果，显示空表
-	if len(filteredRecords) == 0 {
-		fmt.Printf("📭 查询结果为空\n")
+// checkQuery 校验 Query 和 QueryEach 执行的语句
+func (e *Executor) checkQuery(cmd *common.SQLCommand) error {
+	if cmd == nil || cmd.Type != common.CommandSelect {
+		return fmt.Errorf("只支持 SELECT 语句")
+	}
+	if cmd.Table == "" {
+		return fmt.Errorf("表名不能为空")
+	}
+	if cmd.OutFile != "" {
+		return fmt.Errorf("INTO OUTFILE 只能在 CLI 中执行")
+	}
+	return e.checkPolicy(cmd)
+}
+
+// QueryEach 执行 SELECT 语句，逐行将结果交给回调，不输出到终端
+// 不需要排序、分组和聚合的查询逐页读取和过滤，每读到一页就交出其中满足条件的行，不在内存中保留全部结果；
+// 其他查询需要全部满足条件的记录，先计算出完整结果再逐行交出
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//   - columns: 在第一行之前调用一次，结果为空时也会调用
+//   - row: 处理一行结果，键为列名；返回错误时停止查询并返回该错误
+//
+// 返回:
+//   - error: 错误信息
+func (e *Executor) QueryEach(ctx context.Context, cmd *common.SQLCommand, columns func(columns []string) error, row func(row map[string]interface{}) error) error {
+	if err := e.checkQuery(cmd); err != nil {
+		return err
+	}
+	if len(cmd.With) > 0 || len(cmd.Union) > 0 || cmd.IsAggregate || len(cmd.OrderBy) > 0 || len(cmd.GroupBy) > 0 {
+		result, err := e.Query(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		if err := columns(result.Columns); err != nil {
+			return err
+		}
+		for _, r := range result.Rows {
+			if err := row(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	tableID, fields, err := e.resolveSelect(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	engine := e.engine(cmd.Table)
+	resultColumns, err := engine.Project(cmd.Fields, fields)
+	if err != nil {
+		return err
+	}
+	if err := columns(columnLabels(resultColumns)); err != nil {
+		return err
+	}
+
+	match := engine.Matcher(fields, whereConditions(cmd))
+	maxRows, count, truncated := e.maxRows(), 0, false
+	var rowErr error
+	err = e.scanRecords(ctx, tableID, false, func(record basesql.Record) error {
+		if !match(record) {
+			return nil
+		}
+		if maxRows > 0 && count >= maxRows {
+			truncated = true
+			return errStopIteration
+		}
+		if rowErr = row(newResultRow(resultColumns, record)); rowErr != nil {
+			return rowErr
+		}
+		count++
+		if cmd.Limit > 0 && count >= cmd.Limit {
+			return errStopIteration
+		}
 		return nil
+	})
+	if rowErr != nil {
+		return rowErr
+	}
+	if err != nil {
+		return fmt.Errorf("获取记录失败: %w", err)
+	}
+	if truncated {
+		printMaxRowsNotice(cmd.Table, maxRows)
+	}
+	return nil
+}
+
+// selectRows 对读取的记录应用 WHERE 条件、GROUP BY 分组、ORDER BY 排序和 LIMIT，并确定输出列
+// GROUP BY 查询的 ORDER BY 按输出列排序，可使用聚合列的别名（如 ORDER BY c DESC）；
+// 普通查询的 ORDER BY 可使用表中的任意字段或投影列的别名（包括 CASE 表达式列）
+// 参数:
+//   - cmd: SQL 命令对象
+//   - fields: 表的字段列表
+//   - records: 读取的记录
+//
+// 返回:
+//   - []ResultColumn: 结果列
+//   - []basesql.Record: 结果行
+//   - error: 投影、分组或排序错误
+func (e *Executor) selectRows(cmd *common.SQLCommand, fields []basesql.Field, records []basesql.Record) ([]ResultColumn, []basesql.Record, error) {
+	engine := e.engine(cmd.Table)
+	filtered := engine.Filter(records, fields, whereConditions(cmd))
+
+	var columns []ResultColumn
+	var err error
+	if len(cmd.GroupBy) > 0 {
+		if columns, filtered, err = engine.Group(cmd.Fields, cmd.GroupBy, fields, filtered); err != nil {
+			return nil, nil, err
+		}
+	} else if columns, err = engine.Project(cmd.Fields, fields); err != nil {
+		return nil, nil, err
+	}
+
+	if len(cmd.OrderBy) > 0 {
+		// 排序前复制，避免改变调用方记录的顺序
+		filtered = append([]basesql.Record(nil), filtered...)
+		if len(cmd.GroupBy) > 0 {
+			err = engine.Sort(filtered, queryengine.ColumnFields(columns), cmd.OrderBy)
+		} else {
+			err = engine.SortProjected(filtered, fields, columns, cmd.OrderBy)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if cmd.Limit > 0 && len(filtered) > cmd.Limit {
+		filtered = filtered[:cmd.Limit]
 	}
+	if maxRows := e.maxRows(); maxRows > 0 && len(filtered) > maxRows {
+		filtered = filtered[:maxRows]
+		printMaxRowsNotice(cmd.Table, maxRows)
+	}
+	return columns, filtered, nil
+}
 
-	// 渲染查询结果表格
-	return e.renderResultTable(fields, filteredRecords)
+// Exec 执行 INSERT、UPDATE、DELETE、CREATE、DROP 语句，不输出到终端
+// 参数:
+//   - ctx: 上下文
+//   - cmd: SQL 命令对象
+//
+// 返回:
+//   - int64: 影响的行数
+//   - error: 错误信息
+func (e *Executor) Exec(ctx context.Context, cmd *common.SQLCommand) (int64, error) {
+	if cmd == nil {
+		return 0, fmt.Errorf("SQL 命令不能为空")
+	}
+	if !isExecCommand(cmd.Type) {
+		return 0, fmt.Errorf("不支持通过 Exec 执行 %s 语句", cmd.Type)
+	}
+	if err := e.config.CheckWritable(string(cmd.Type)); err != nil {
+		return 0, err
+	}
+	if err := e.policy.Check(cmd.Type, cmd.Table); err != nil {
+		return 0, err
+	}
+
+	result := e.db.WithContext(ctx).Exec(cmd.RawSQL)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
 }
 
 // insertData 插入数据
@@ -843,12 +1085,13 @@ func (e *Executor) insertData(cmd *common.SQLCommand) error {
 
 	// 使用GORM的原生SQL执行，通过rawCallback处理
 	// 这样可以复用GORM driver中的所有插入逻辑，避免代码重复
-	result := e.db.Exec(cmd.RawSQL)
+	result := e.db.WithContext(e.baseContext()).Exec(cmd.RawSQL)
 	if result.Error != nil {
 		return fmt.Errorf("插入执行失败: %w", result.Error)
 	}
 
 	fmt.Printf("✅ 成功插入 %d 条记录\n", result.RowsAffected)
+	e.rowCount = result.RowsAffected
 	return nil
 }
 
@@ -873,12 +1116,13 @@ func (e *Executor) updateData(cmd *common.SQLCommand) error {
 
 	// 使用GORM的原生SQL执行，通过rawCallback处理
 	// 这样可以复用GORM driver中的所有更新逻辑，避免代码重复
-	result := e.db.Exec(cmd.RawSQL)
+	result := e.db.WithContext(e.baseContext()).Exec(cmd.RawSQL)
 	if result.Error != nil {
 		return fmt.Errorf("更新执行失败: %w", result.Error)
 	}
 
 	fmt.Printf("✅ 更新成功，影响 %d 行\n", result.RowsAffected)
+	e.rowCount = result.RowsAffected
 	return nil
 }
 
@@ -905,12 +1149,13 @@ func (e *Executor) deleteData(cmd *common.SQLCommand) error {
 
 	// 使用GORM的原生SQL执行，通过rawCallback处理
 	// 这样可以复用GORM driver中的所有删除逻辑，避免代码重复
-	result := e.db.Exec(cmd.RawSQL)
+	result := e.db.WithContext(e.baseContext()).Exec(cmd.RawSQL)
 	if result.Error != nil {
 		return fmt.Errorf("删除执行失败: %w", result.Error)
 	}
 
 	fmt.Printf("✅ 删除成功，影响 %d 行\n", result.RowsAffected)
+	e.rowCount = result.RowsAffected
 	return nil
 }
 
@@ -928,7 +1173,7 @@ func (e *Executor) createTable(cmd *common.SQLCommand) error {
 	fmt.Printf("🏗️  执行创建表: %s\n", cmd.RawSQL)
 
 	// 执行原生 SQL 创建表
-	result := e.db.Exec(cmd.RawSQL)
+	result := e.db.WithContext(e.baseContext()).Exec(cmd.RawSQL)
 	if result.Error != nil {
 		return fmt.Errorf("创建表执行失败: %w", result.Error)
 	}
@@ -954,7 +1199,7 @@ func (e *Executor) dropTable(cmd *common.SQLCommand) error {
 	// 这里可以添加用户确认逻辑
 
 	// 执行原生 SQL 删除表
-	result := e.db.Exec(cmd.RawSQL)
+	result := e.db.WithContext(e.baseContext()).Exec(cmd.RawSQL)
 	if result.Error != nil {
 		return fmt.Errorf("删除表执行失败: %w", result.Error)
 	}
@@ -963,6 +1208,15 @@ func (e *Executor) dropTable(cmd *common.SQLCommand) error {
 	return nil
 }
 
+// isExecCommand 判断命令是否为可以通过 Exec 执行的写操作
+func isExecCommand(t common.SQLCommandType) bool {
+	switch t {
+	case common.CommandInsert, common.CommandUpdate, common.CommandDelete, common.CommandCreate, common.CommandDrop:
+		return true
+	}
+	return false
+}
+
 // handleAggregateQuery 处理聚合查询
 // 参数:
 //   - cmd: SQL 命令对象
@@ -972,302 +1226,91 @@ func (e *Executor) dropTable(cmd *common.SQLCommand) error {
 // 返回:
 //   - error: 执行错误信息
 func (e *Executor) handleAggregateQuery(cmd *common.SQLCommand, fields []basesql.Field, records []basesql.Record) error {
-	// 首先应用WHERE条件过滤记录
-	filteredRecords := e.filterRecords(records, fields, cmd.Condition)
-
-	var result interface{}
-	var err error
-
-	// 根据聚合函数类型计算结果
-	switch cmd.AggregateFunction {
-	case "COUNT":
-		result = len(filteredRecords)
-	case "SUM":
-		result, err = e.calculateSum(filteredRecords, fields, cmd.AggregateField)
-	case "AVG":
-		result, err = e.calculateAvg(filteredRecords, fields, cmd.AggregateField)
-	case "MIN":
-		result, err = e.calculateMin(filteredRecords, fields, cmd.AggregateField)
-	case "MAX":
-		result, err = e.calculateMax(filteredRecords, fields, cmd.AggregateField)
-	default:
-		return fmt.Errorf("不支持的聚合函数: %s", cmd.AggregateFunction)
-	}
-
+	result, err := e.aggregate(cmd, fields, records)
 	if err != nil {
-		return fmt.Errorf("聚合计算失败: %w", err)
+		return err
 	}
 
 	// 显示聚合结果
-	fmt.Printf("+%s+\n", strings.Repeat("-", 20))
-	fmt.Printf("| %-18s |\n", cmd.Fields[0])
-	fmt.Printf("+%s+\n", strings.Repeat("-", 20))
-	fmt.Printf("| %-18v |\n", result)
-	fmt.Printf("+%s+\n", strings.Repeat("-", 20))
+	table := e.newTable(cmd.Fields[0])
+	table.AppendRow(common.FormatValue(result))
+	e.printTable(table)
 	fmt.Printf("\n📊 聚合查询返回 1 行数据\n")
+	e.rowCount = 1
 
 	return nil
 }
 
-// filterRecords 根据WHERE条件过滤记录
+// aggregate 对满足 WHERE 条件的记录计算聚合函数
 // 参数:
-//   - records: 原始记录列表
+//   - cmd: SQL 命令对象
 //   - fields: 字段列表
-//   - conditions: WHERE条件
+//   - records: 记录列表
 //
 // 返回:
-//   - []basesql.Record: 过滤后的记录列表
-func (e *Executor) filterRecords(records []basesql.Record, fields []basesql.Field, conditions map[string]interface{}) []basesql.Record {
-	if len(conditions) == 0 {
-		return records
-	}
-
-	// 创建字段名到字段ID的映射
-	fieldNameToID := make(map[string]string)
-	for _, field := range fields {
-		fieldNameToID[field.FieldName] = field.FieldID
-	}
-
-	var filtered []basesql.Record
-
-	for _, record := range records {
-		match := true
-
-		for fieldName, expectedValue := range conditions {
-			// 跳过操作符标记
-			if strings.HasPrefix(fieldName, "_operator_") {
-				continue
-			}
-
-			// 尝试使用字段名直接获取值
-			actualValue := record.Fields[fieldName]
-
-			// 如果使用字段名获取不到值，尝试使用字段ID
-			if actualValue == nil {
-				if fieldID, exists := fieldNameToID[fieldName]; exists {
-					actualValue = record.Fields[fieldID]
-				}
-			}
-
-			// 检查操作符
-			operatorKey := "_operator_" + fieldName
-			operator, hasOperator := conditions[operatorKey]
-
-			if hasOperator && operator == "LIKE" {
-				// LIKE操作
-				if !e.matchLike(actualValue, expectedValue) {
-					match = false
-					break
-				}
-			} else {
-				// 等值比较
-				if !e.matchEqual(actualValue, expectedValue) {
-					match = false
-					break
-				}
-			}
-		}
-
-		if match {
-			filtered = append(filtered, record)
-		}
-	}
-
-	return filtered
-}
-
-// getFieldIDByName 根据字段名获取字段ID
-func (e *Executor) getFieldIDByName(fields []basesql.Field, fieldName string) string {
-	for _, field := range fields {
-		if field.FieldName == fieldName {
-			return field.FieldID
-		}
-	}
-	return ""
-}
-
-// matchLike 执行LIKE匹配
-func (e *Executor) matchLike(actualValue, expectedValue interface{}) bool {
-	actualStr := fmt.Sprintf("%v", actualValue)
-	expectedStr := fmt.Sprintf("%v", expectedValue)
-
-	// 简单的LIKE实现，支持%通配符
-	if strings.Contains(expectedStr, "%") {
-		// 手动构建正则表达式模式
-		pattern := ""
-		for _, char := range expectedStr {
-			if char == '%' {
-				pattern += ".*"
-			} else {
-				// 转义正则表达式特殊字符
-				charStr := string(char)
-				if strings.ContainsAny(charStr, ".+*?^${}()|[]\\") {
-					pattern += "\\" + charStr
-				} else {
-					pattern += charStr
-				}
-			}
-		}
-
-		// 确保完全匹配（从开始到结束）
-		pattern = "^" + pattern + "$"
-
-		matched, err := regexp.MatchString(pattern, actualStr)
-		if err != nil {
-			return false
-		}
-
-		return matched
-	}
-
-	// 如果没有通配符，检查是否包含
-	return strings.Contains(actualStr, expectedStr)
-}
-
-// matchEqual 执行等值匹配
-func (e *Executor) matchEqual(actualValue, expectedValue interface{}) bool {
-	// 处理 nil 值
-	if actualValue == nil {
-		return expectedValue == nil || fmt.Sprintf("%v", expectedValue) == "" || fmt.Sprintf("%v", expectedValue) == "<nil>"
-	}
-	if expectedValue == nil {
-		return actualValue == nil || fmt.Sprintf("%v", actualValue) == "" || fmt.Sprintf("%v", actualValue) == "<nil>"
-	}
-
-	// 转换为字符串进行比较
-	actualStr := fmt.Sprintf("%v", actualValue)
-	expectedStr := fmt.Sprintf("%v", expectedValue)
+//   - interface{}: 聚合结果
+//   - error: 错误信息
+func (e *Executor) aggregate(cmd *common.SQLCommand, fields []basesql.Field, records []basesql.Record) (interface{}, error) {
+	// 首先应用WHERE条件过滤记录
+	engine := e.engine(cmd.Table)
+	filteredRecords := engine.Filter(records, fields, whereConditions(cmd))
 
-	// 处理空字符串和 "<nil>" 的情况
-	if actualStr == "<nil>" {
-		actualStr = ""
-	}
-	if expectedStr == "<nil>" {
-		expectedStr = ""
+	result, err := engine.Aggregate(cmd.AggregateFunction, cmd.AggregateField, fields, filteredRecords)
+	if err != nil {
+		return nil, fmt.Errorf("聚合计算失败: %w", err)
 	}
-
-	return actualStr == expectedStr
+	return result, nil
 }
 
-// calculateSum 计算SUM聚合
-func (e *Executor) calculateSum(records []basesql.Record, fields []basesql.Field, fieldName string) (float64, error) {
-	if fieldName == "*" {
-		return 0, fmt.Errorf("SUM函数不支持*参数")
-	}
-
-	fieldID := e.getFieldIDByName(fields, fieldName)
-	if fieldID == "" {
-		return 0, fmt.Errorf("字段 %s 不存在", fieldName)
-	}
-
-	var sum float64
-	for _, record := range records {
-		value := record.Fields[fieldID]
-		if numValue, err := e.convertToNumber(value); err == nil {
-			sum += numValue
-		}
+// slowQueryThreshold 超过后输出执行耗时的阈值，未通过 --slow-query-threshold 或策略角色设置时使用默认值
+func (e *Executor) slowQueryThreshold() time.Duration {
+	if e.config != nil && e.config.SlowQueryThreshold > 0 {
+		return e.config.SlowQueryThreshold
 	}
-
-	return sum, nil
+	return common.SlowQueryThreshold
 }
 
-// calculateAvg 计算AVG聚合
-func (e *Executor) calculateAvg(records []basesql.Record, fields []basesql.Field, fieldName string) (float64, error) {
-	sum, err := e.calculateSum(records, fields, fieldName)
-	if err != nil {
-		return 0, err
+// maxRows 获取 SELECT 最多读取的记录数，0 表示不限制
+func (e *Executor) maxRows() int {
+	if e.config == nil {
+		return 0
 	}
-
-	if len(records) == 0 {
-		return 0, nil
-	}
-
-	return sum / float64(len(records)), nil
+	return e.config.MaxResultRows
 }
 
-// calculateMin 计算MIN聚合
-func (e *Executor) calculateMin(records []basesql.Record, fields []basesql.Field, fieldName string) (interface{}, error) {
-	if fieldName == "*" {
-		return nil, fmt.Errorf("MIN函数不支持*参数")
-	}
-
-	fieldID := e.getFieldIDByName(fields, fieldName)
-	if fieldID == "" {
-		return nil, fmt.Errorf("字段 %s 不存在", fieldName)
-	}
-
-	if len(records) == 0 {
-		return nil, nil
-	}
-
-	var min interface{}
-	for i, record := range records {
-		value := record.Fields[fieldID]
-		if i == 0 || e.compareValues(value, min) < 0 {
-			min = value
-		}
+// pageSize 读取记录时的每页记录数，未通过 --page-size 指定时为 500
+func (e *Executor) pageSize() int {
+	if e.config != nil && e.config.DefaultPageSize > 0 {
+		return e.config.DefaultPageSize
 	}
-
-	return min, nil
+	return common.MaxPageSize
 }
 
-// calculateMax 计算MAX聚合
-func (e *Executor) calculateMax(records []basesql.Record, fields []basesql.Field, fieldName string) (interface{}, error) {
-	if fieldName == "*" {
-		return nil, fmt.Errorf("MAX函数不支持*参数")
-	}
-
-	fieldID := e.getFieldIDByName(fields, fieldName)
-	if fieldID == "" {
-		return nil, fmt.Errorf("字段 %s 不存在", fieldName)
-	}
-
-	if len(records) == 0 {
-		return nil, nil
-	}
-
-	var max interface{}
-	for i, record := range records {
-		value := record.Fields[fieldID]
-		if i == 0 || e.compareValues(value, max) > 0 {
-			max = value
+// whereConditions 将解析后的 WHERE 条件转换为查询引擎的条件
+// 解析器把比较操作符记录在键 "_operator_字段名" 中，等值条件没有操作符
+func whereConditions(cmd *common.SQLCommand) []queryengine.Condition {
+	conditions := make([]queryengine.Condition, 0, len(cmd.Condition))
+	for field, value := range cmd.Condition {
+		if strings.HasPrefix(field, "_operator_") {
+			continue
 		}
+		operator, _ := cmd.Condition["_operator_"+field].(string)
+		conditions = append(conditions, queryengine.Condition{Field: field, Operator: operator, Value: value})
 	}
-
-	return max, nil
+	return conditions
 }
 
-// convertToNumber 将值转换为数字
-func (e *Executor) convertToNumber(value interface{}) (float64, error) {
-	switch v := value.(type) {
-	case float64:
-		return v, nil
-	case int:
-		return float64(v), nil
-	case string:
-		return strconv.ParseFloat(v, 64)
-	default:
-		return 0, fmt.Errorf("无法转换为数字: %v", value)
+// engine 按配置的字符串比较规则创建客户端查询引擎，过滤、分组、排序和投影都由它执行
+// 表有缓存的 profile 统计时，多个 WHERE 条件按统计估计的选择性排列；公用表表达式和 table 为空时不使用统计
+func (e *Executor) engine(table string) *queryengine.Engine {
+	engine := &queryengine.Engine{}
+	if e.config != nil {
+		engine.Collation = e.config.Collation
 	}
-}
-
-// compareValues 比较两个值
-func (e *Executor) compareValues(a, b interface{}) int {
-	aStr := fmt.Sprintf("%v", a)
-	bStr := fmt.Sprintf("%v", b)
-
-	// 尝试数字比较
-	if aNum, aErr := strconv.ParseFloat(aStr, 64); aErr == nil {
-		if bNum, bErr := strconv.ParseFloat(bStr, 64); bErr == nil {
-			if aNum < bNum {
-				return -1
-			} else if aNum > bNum {
-				return 1
-			}
-			return 0
+	if _, ok := e.lookupWith(table); !ok && table != "" && e.profiles != nil {
+		if cached := e.profiles.Get(e.appToken, table); cached != nil {
+			engine.Stats = cached.Profile.columnStats()
 		}
 	}
-
-	// 字符串比较
-	return strings.Compare(aStr, bStr)
+	return engine
 }
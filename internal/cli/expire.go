@@ -0,0 +1,585 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+	"gopkg.in/yaml.v3"
+)
+
+// 过期记录的处理方式
+const (
+	ExpireActionDelete  = "delete"  // 直接删除
+	ExpireActionArchive = "archive" // 先追加到归档文件，再删除
+	ExpireActionMove    = "move"    // 先写入归档表，再删除
+)
+
+// 归档表中额外的字段
+const (
+	archiveSourceIDField   = "source_record_id" // 原记录 ID，作为归档表的索引列
+	archiveArchivedAtField = "archived_at"      // 归档时间
+)
+
+// ExpireFile 记录过期配置文件结构
+// 按表声明日期字段和保留时长，例如：
+//
+//	tables:
+//	  - table: logs
+//	    field: 发生时间
+//	    ttl: 30d
+//	  - table: orders
+//	    ttl: 365d
+//	    action: archive
+//	    archive: ~/archive/orders.jsonl
+//	  - table: tickets
+//	    field: 关闭时间
+//	    ttl: 90d
+//	    action: move
+type ExpireFile struct {
+	Tables []ExpireRule `yaml:"tables" json:"tables"` // 各表的过期规则
+}
+
+// ExpireRule 单个表的过期规则
+type ExpireRule struct {
+	Table   string `yaml:"table" json:"table"`                         // 表名
+	Field   string `yaml:"field,omitempty" json:"field,omitempty"`     // 日期字段，为空时使用记录的创建时间
+	TTL     string `yaml:"ttl" json:"ttl"`                             // 保留时长，如 30d、720h
+	Action  string `yaml:"action,omitempty" json:"action,omitempty"`   // 处理方式：delete（默认）、archive 或 move
+	Archive string `yaml:"archive,omitempty" json:"archive,omitempty"` // 归档文件路径（JSON Lines），action 为 archive 时必填
+
+	ArchiveTable string `yaml:"archive_table,omitempty" json:"archive_table,omitempty"` // 归档表名，action 为 move 时使用，默认为 "<表名>_archive"
+
+	ttl time.Duration // 解析后的保留时长
+}
+
+// ExpireOptions 过期清理选项
+type ExpireOptions struct {
+	File      string // 过期配置文件路径，为空时使用 ~/.basesql/expire.yaml
+	Table     string // 只处理指定的表，为空表示配置中的全部表
+	DryRun    bool   // 只列出过期记录，不删除、不归档
+	BatchSize int    // 每批删除的记录数，0 表示 500
+}
+
+// ExpireResult 单个表的过期清理结果
+type ExpireResult struct {
+	Table    string // 表名
+	Scanned  int    // 扫描的记录数
+	Expired  int    // 过期的记录数
+	Archived int    // 已归档（写入归档文件或归档表）的记录数
+	Deleted  int    // 已删除的记录数
+}
+
+// DefaultExpirePath 获取默认的过期配置文件路径
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultExpirePath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "expire.yaml"), nil
+}
+
+// LoadExpireFile 读取并校验过期配置文件
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 配置文件路径
+//
+// 返回:
+//   - *ExpireFile: 过期配置
+//   - error: 读取或校验错误
+func LoadExpireFile(path string) (*ExpireFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取过期配置文件失败: %w", err)
+	}
+
+	var file ExpireFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析过期配置文件失败: %w", err)
+	}
+	if len(file.Tables) == 0 {
+		return nil, fmt.Errorf("过期配置文件中没有定义任何表")
+	}
+
+	for i := range file.Tables {
+		rule := &file.Tables[i]
+		if rule.Table == "" {
+			return nil, fmt.Errorf("第 %d 条过期规则缺少 table", i+1)
+		}
+		if rule.ttl, err = parseRetention(rule.TTL); err != nil {
+			return nil, fmt.Errorf("表 '%s' 的 ttl 无效: %w", rule.Table, err)
+		}
+		switch rule.Action {
+		case "":
+			rule.Action = ExpireActionDelete
+		case ExpireActionDelete:
+		case ExpireActionArchive:
+			if rule.Archive == "" {
+				return nil, fmt.Errorf("表 '%s' 的处理方式为 archive，但没有指定 archive 文件", rule.Table)
+			}
+		case ExpireActionMove:
+			if rule.ArchiveTable == "" {
+				rule.ArchiveTable = rule.Table + "_archive"
+			}
+			if strings.EqualFold(rule.ArchiveTable, rule.Table) {
+				return nil, fmt.Errorf("表 '%s' 的归档表不能是它自己", rule.Table)
+			}
+		default:
+			return nil, fmt.Errorf("表 '%s' 的处理方式 '%s' 无效，可选值: %s, %s, %s",
+				rule.Table, rule.Action, ExpireActionDelete, ExpireActionArchive, ExpireActionMove)
+		}
+	}
+	return &file, nil
+}
+
+// parseRetention 解析保留时长，支持 Go 的时长写法（如 720h）和以 d 结尾的天数（如 30d）
+func parseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("无效的时长 %q，示例: 24h、7d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("无效的时长 %q，示例: 24h、7d", value)
+	}
+	return d, nil
+}
+
+// Expire 按过期配置删除或归档过期的记录
+// 参数:
+//   - opts: 过期清理选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Expire(opts ExpireOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	path := opts.File
+	if path == "" {
+		defaultPath, err := DefaultExpirePath()
+		if err != nil {
+			return err
+		}
+		path = defaultPath
+	}
+	file, err := LoadExpireFile(path)
+	if err != nil {
+		return err
+	}
+
+	matched := false
+	for _, rule := range file.Tables {
+		if opts.Table != "" && !strings.EqualFold(rule.Table, opts.Table) {
+			continue
+		}
+		matched = true
+
+		start := time.Now()
+		result, err := c.executor.Expire(rule, opts)
+		job := &JobResult{Job: "expire", Target: rule.Table, Err: err}
+		if result != nil {
+			if opts.DryRun {
+				job.Summary = fmt.Sprintf("扫描记录 %d 条，%d 条超过 %s（预览模式，未删除）", result.Scanned, result.Expired, rule.TTL)
+			} else {
+				job.Summary = fmt.Sprintf("扫描记录 %d 条，%d 条超过 %s，归档 %d 条，删除 %d 条",
+					result.Scanned, result.Expired, rule.TTL, result.Archived, result.Deleted)
+			}
+			fmt.Printf("📊 %s: %s\n", rule.Table, job.Summary)
+		}
+		job.Duration = time.Since(start)
+		c.notifyJob(job)
+		if err != nil {
+			return fmt.Errorf("表 '%s': %w", rule.Table, err)
+		}
+	}
+	if !matched {
+		return fmt.Errorf("过期配置文件 %s 中没有表 '%s' 的规则", path, opts.Table)
+	}
+	return nil
+}
+
+// Expire 按单个表的过期规则删除或归档过期的记录
+// 先扫描全表找出过期记录，再分批归档、删除，避免边翻页边删除导致漏读
+// 参数:
+//   - rule: 过期规则
+//   - opts: 过期清理选项
+//
+// 返回:
+//   - *ExpireResult: 清理结果（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Expire(rule ExpireRule, opts ExpireOptions) (*ExpireResult, error) {
+	if rule.ttl <= 0 {
+		ttl, err := parseRetention(rule.TTL)
+		if err != nil {
+			return nil, err
+		}
+		rule.ttl = ttl
+	}
+	// 预览模式不删除记录，只读模式下仍然可用
+	if !opts.DryRun {
+		if err := e.config.CheckWritable("DELETE"); err != nil {
+			return nil, err
+		}
+		if err := e.policy.Check(common.CommandDelete, rule.Table); err != nil {
+			return nil, err
+		}
+		if rule.Action == ExpireActionMove {
+			if err := e.config.CheckWritable("INSERT"); err != nil {
+				return nil, err
+			}
+			if err := e.policy.Check(common.CommandInsert, rule.ArchiveTable); err != nil {
+				return nil, err
+			}
+		}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > common.MaxBatchSize {
+		batchSize = common.MaxBatchSize
+	}
+
+	ctx := e.baseContext()
+	tableID, err := e.getTableID(ctx, rule.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	field := ""
+	if rule.Field != "" {
+		f := queryengine.FindField(fields, rule.Field)
+		if f == nil {
+			return nil, fmt.Errorf("字段 '%s' 不存在", rule.Field)
+		}
+		field = f.FieldName
+	}
+
+	cutoff := time.Now().Add(-rule.ttl)
+	result := &ExpireResult{Table: rule.Table}
+	var expired []basesql.Record
+	err = e.forEachRecord(ctx, tableID, func(record basesql.Record) error {
+		result.Scanned++
+		at, ok := expireTime(record, field)
+		if ok && at.Before(cutoff) {
+			expired = append(expired, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Expired = len(expired)
+
+	if opts.DryRun {
+		for _, record := range expired {
+			at, _ := expireTime(record, field)
+			fmt.Printf("🗑️  %s  %s\n", record.RecordID, at.Local().Format("2006-01-02 15:04:05"))
+		}
+		if rule.Action == ExpireActionMove && len(expired) > 0 {
+			fmt.Printf("📦 以上记录将移动到归档表 '%s'\n", rule.ArchiveTable)
+		}
+		return result, nil
+	}
+	if len(expired) == 0 {
+		return result, nil
+	}
+
+	var archiveTableID string
+	if rule.Action == ExpireActionMove {
+		if archiveTableID, err = e.prepareArchiveTable(ctx, rule.ArchiveTable, fields); err != nil {
+			return result, err
+		}
+	}
+
+	recordsPath := fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", e.appToken, tableID)
+	for start := 0; start < len(expired); start += batchSize {
+		batch := expired[start:min(start+batchSize, len(expired))]
+		// 先归档再删除，删除失败时重新执行会重复归档，但不会丢失记录
+		if rule.Action == ExpireActionArchive {
+			if err := appendArchive(rule.Archive, rule.Table, batch); err != nil {
+				return result, err
+			}
+			result.Archived += len(batch)
+		}
+		// 先写入归档表再删除，删除失败时重新执行会在归档表中留下重复记录，可按 source_record_id 去重
+		if rule.Action == ExpireActionMove {
+			if err := e.moveToArchiveTable(ctx, archiveTableID, fields, batch); err != nil {
+				return result, err
+			}
+			result.Archived += len(batch)
+		}
+
+		ids := make([]string, len(batch))
+		for i, record := range batch {
+			ids[i] = record.RecordID
+		}
+		if err := e.postBatch(ctx, recordsPath+"/batch_delete", &basesql.BatchDeleteRecordsRequest{Records: ids}); err != nil {
+			return result, fmt.Errorf("删除过期记录失败: %w", err)
+		}
+		result.Deleted += len(batch)
+		fmt.Printf("  ✅ 已删除 %d/%d 条\n", result.Deleted, len(expired))
+	}
+	return result, nil
+}
+
+// expireTime 获取记录用于判断过期的时间
+// 未指定字段时使用记录的创建时间；日期字段为毫秒时间戳，文本字段按日期格式或时间戳解析
+// 字段为空或无法解析的记录不会过期
+func expireTime(record basesql.Record, field string) (time.Time, bool) {
+	if field == "" {
+		if record.CreatedTime == 0 {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(record.CreatedTime), true
+	}
+
+	switch v := record.Fields[field].(type) {
+	case float64:
+		return time.UnixMilli(int64(v)), true
+	case int64:
+		return time.UnixMilli(v), true
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return time.UnixMilli(n), true
+		}
+	case string:
+		return parseSyncTime(v)
+	}
+	return time.Time{}, false
+}
+
+// prepareArchiveTable 准备归档表：不存在时按源表的可写字段创建，已存在时补齐缺失的字段
+// 归档表以 source_record_id 为索引列保存原记录 ID，并增加 archived_at 日期字段记录归档时间
+// 参数:
+//   - ctx: 上下文
+//   - name: 归档表名
+//   - fields: 源表的字段列表
+//
+// 返回:
+//   - string: 归档表 ID
+//   - error: 错误信息
+func (e *Executor) prepareArchiveTable(ctx context.Context, name string, fields []basesql.Field) (string, error) {
+	wanted := []*basesql.CreateFieldRequest{{FieldName: archiveSourceIDField, Type: basesql.FieldTypeText}}
+	for _, field := range fields {
+		if field.IsReadOnly() || field.FieldName == archiveSourceIDField || field.FieldName == archiveArchivedAtField {
+			continue
+		}
+		wanted = append(wanted, &basesql.CreateFieldRequest{
+			FieldName:   field.FieldName,
+			Type:        field.Type,
+			Property:    archiveFieldProperty(field),
+			Description: field.Description,
+		})
+	}
+	wanted = append(wanted, &basesql.CreateFieldRequest{
+		FieldName: archiveArchivedAtField,
+		Type:      basesql.FieldTypeDate,
+		Property:  map[string]interface{}{"date_formatter": "yyyy/MM/dd HH:mm"},
+	})
+
+	tables, err := e.getTableList(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, table := range tables {
+		if table.Name != name {
+			continue
+		}
+		// 归档表已存在时补齐源表新增的字段
+		existing, err := e.getFieldsList(ctx, table.TableID)
+		if err != nil {
+			return "", err
+		}
+		for _, field := range wanted {
+			if queryengine.FindField(existing, field.FieldName) != nil {
+				continue
+			}
+			if err := e.policy.Check(common.CommandCreate, name); err != nil {
+				return "", err
+			}
+			if err := e.createArchiveField(ctx, table.TableID, field); err != nil {
+				return "", err
+			}
+		}
+		return table.TableID, nil
+	}
+
+	if err := e.policy.Check(common.CommandCreate, name); err != nil {
+		return "", err
+	}
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", e.appToken),
+		Body:   &basesql.CreateTableRequest{Table: &basesql.TableRequest{Name: name, Fields: wanted}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建归档表失败: %w", err)
+	}
+
+	var apiResp struct {
+		Code int                          `json:"code"`
+		Msg  string                       `json:"msg"`
+		Data *basesql.CreateTableResponse `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return "", fmt.Errorf("解析创建表响应失败: %w", err)
+	}
+	if apiResp.Code != 0 || apiResp.Data == nil || !apiResp.Data.IsSuccess() {
+		return "", fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+
+	fmt.Printf("  ✅ 创建归档表 '%s'\n", name)
+	return apiResp.Data.TableID, nil
+}
+
+// createArchiveField 为已存在的归档表添加字段
+func (e *Executor) createArchiveField(ctx context.Context, tableID string, field *basesql.CreateFieldRequest) error {
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", e.appToken, tableID),
+		Body:   field,
+	})
+	if err != nil {
+		return fmt.Errorf("创建字段 '%s' 失败: %w", field.FieldName, err)
+	}
+	if err := checkAPIResponse(resp.Body); err != nil {
+		return fmt.Errorf("创建字段 '%s' 失败: %w", field.FieldName, err)
+	}
+
+	fmt.Printf("  ✅ 归档表添加字段 '%s'\n", field.FieldName)
+	return nil
+}
+
+// archiveFieldProperty 复制源字段的属性用于创建归档表字段
+// 单选、多选的选项只保留名称和颜色，选项 ID 由飞书重新生成
+func archiveFieldProperty(field basesql.Field) map[string]interface{} {
+	if len(field.Property) == 0 {
+		return nil
+	}
+	property := make(map[string]interface{}, len(field.Property))
+	for key, value := range field.Property {
+		property[key] = value
+	}
+	if options, ok := property["options"].([]interface{}); ok {
+		copied := make([]interface{}, 0, len(options))
+		for _, option := range options {
+			if m, ok := option.(map[string]interface{}); ok {
+				copied = append(copied, map[string]interface{}{"name": m["name"], "color": m["color"]})
+			}
+		}
+		property["options"] = copied
+	}
+	return property
+}
+
+// moveToArchiveTable 将一批记录写入归档表，保留可写字段的值、原记录 ID 和归档时间
+func (e *Executor) moveToArchiveTable(ctx context.Context, tableID string, fields []basesql.Field, records []basesql.Record) error {
+	archivedAt := time.Now().UnixMilli()
+	rows := make([]*basesql.CreateRecordRequest, 0, len(records))
+	for _, record := range records {
+		payload := map[string]interface{}{
+			archiveSourceIDField:   record.RecordID,
+			archiveArchivedAtField: archivedAt,
+		}
+		for i := range fields {
+			field := &fields[i]
+			value, ok := record.Fields[field.FieldName]
+			if !ok || value == nil || field.IsReadOnly() ||
+				field.FieldName == archiveSourceIDField || field.FieldName == archiveArchivedAtField {
+				continue
+			}
+			payload[field.FieldName] = archiveValue(field, value)
+		}
+		rows = append(rows, &basesql.CreateRecordRequest{Fields: payload})
+	}
+
+	path := fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", e.appToken, tableID)
+	if err := e.postBatch(ctx, path, &basesql.BatchCreateRecordsRequest{Records: rows}); err != nil {
+		return fmt.Errorf("写入归档表失败: %w", err)
+	}
+	return nil
+}
+
+// archiveValue 将读取到的字段值转换为写入格式
+// 富文本分段合并为纯文本，人员只保留 ID，附件只保留 file_token，其余类型的读写格式相同
+func archiveValue(field *basesql.Field, value interface{}) interface{} {
+	items, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	switch field.Type {
+	case basesql.FieldTypeText, basesql.FieldTypeBarcode, basesql.FieldTypePhone:
+		var text strings.Builder
+		for _, item := range items {
+			if segment, ok := item.(map[string]interface{}); ok {
+				text.WriteString(common.FormatValue(segment["text"]))
+			}
+		}
+		return text.String()
+	case basesql.FieldTypeUser:
+		return archiveItemKeys(items, "id")
+	case basesql.FieldTypeAttachment:
+		return archiveItemKeys(items, "file_token")
+	}
+	return value
+}
+
+// archiveItemKeys 只保留列表中每个对象的指定键
+func archiveItemKeys(items []interface{}, key string) []interface{} {
+	result := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, map[string]interface{}{key: m[key]})
+		}
+	}
+	return result
+}
+
+// appendArchive 将记录以 JSON Lines 格式追加到归档文件
+func appendArchive(path, table string, records []basesql.Record) error {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开归档文件失败: %w", err)
+	}
+	defer file.Close()
+
+	archivedAt := time.Now()
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		entry := struct {
+			Table      string    `json:"table"`
+			ArchivedAt time.Time `json:"archived_at"`
+			basesql.Record
+		}{table, archivedAt, record}
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("写入归档文件失败: %w", err)
+		}
+	}
+	return file.Sync()
+}
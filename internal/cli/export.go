@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// ExportOptions CSV 导出选项
+type ExportOptions struct {
+	Table  string // 表名
+	File   string // 导出的 CSV 文件路径
+	Resume bool   // 从检查点继续上次中断的导出
+}
+
+// ExportResult CSV 导出结果统计
+type ExportResult struct {
+	Columns  []string // 导出的列，与表中字段的顺序一致
+	Exported int      // 已导出的记录数，包括从检查点继续前已导出的部分
+}
+
+// Export 将表中的记录导出为 CSV 文件
+// 参数:
+//   - opts: 导出选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Export(opts ExportOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	result, err := c.executor.Export(opts)
+	job := &JobResult{Job: "export", Target: opts.Table, Err: err}
+	if result != nil {
+		job.Summary = fmt.Sprintf("导出记录 %d 条到 %s", result.Exported, opts.File)
+		fmt.Printf("📊 %s\n", job.Summary)
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Export 按页读取表中的记录并写入 CSV 文件，每写完一页保存一次检查点
+// 参数:
+//   - opts: 导出选项
+//
+// 返回:
+//   - *ExportResult: 导出结果（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Export(opts ExportOptions) (*ExportResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if opts.File == "" {
+		return nil, fmt.Errorf("导出文件路径不能为空")
+	}
+	if err := e.policy.Check(common.CommandSelect, opts.Table); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(ctx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	result := &ExportResult{Columns: make([]string, 0, len(fields))}
+	for _, field := range fields {
+		result.Columns = append(result.Columns, field.FieldName)
+	}
+
+	checkpointPath := CheckpointPath(opts.File)
+	checkpoint := &Checkpoint{Job: "export", Table: opts.Table, Header: result.Columns}
+	resumed, err := resumeCheckpoint(checkpointPath, opts.Resume, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *os.File
+	if resumed != nil {
+		// 截断最后一个检查点之后写入的内容，避免重复导出中断时的那一页
+		checkpoint = resumed
+		if file, err = os.OpenFile(opts.File, os.O_WRONLY, 0o644); err != nil {
+			return nil, fmt.Errorf("打开导出文件失败: %w", err)
+		}
+		if err := file.Truncate(checkpoint.Size); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("截断导出文件失败: %w", err)
+		}
+		if _, err := file.Seek(checkpoint.Size, io.SeekStart); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("定位导出文件失败: %w", err)
+		}
+	} else {
+		if file, err = os.Create(opts.File); err != nil {
+			return nil, fmt.Errorf("创建导出文件失败: %w", err)
+		}
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if resumed == nil {
+		if err := writer.Write(result.Columns); err != nil {
+			return nil, fmt.Errorf("写入 CSV 表头失败: %w", err)
+		}
+	}
+
+	result.Exported = checkpoint.Offset
+	pageToken := checkpoint.PageToken
+	pageSize := e.pageSize()
+	for {
+		page, used, err := e.exportPage(tableID, pageToken, pageSize)
+		if err != nil {
+			if checkpoint.Offset > 0 {
+				return result, fmt.Errorf("%w\n已导出 %d 条，检查点已保存到 %s，使用 --resume 继续", err, checkpoint.Offset, checkpointPath)
+			}
+			return result, err
+		}
+		pageSize = used
+
+		for _, record := range page.Items {
+			if record == nil {
+				continue
+			}
+			values := make([]string, len(result.Columns))
+			for i, column := range result.Columns {
+				values[i] = common.FormatValue(record.Fields[column])
+			}
+			if err := writer.Write(values); err != nil {
+				return result, fmt.Errorf("写入 CSV 数据失败: %w", err)
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return result, fmt.Errorf("写入 CSV 数据失败: %w", err)
+		}
+		result.Exported += len(page.Items)
+		fmt.Printf("  ✅ 已导出 %d 条记录\n", result.Exported)
+
+		if !page.HasMore || page.PageToken == "" {
+			break
+		}
+
+		// 文件内容落盘后再保存检查点，继续时检查点之前的内容一定完整
+		if err := file.Sync(); err != nil {
+			return result, fmt.Errorf("写入导出文件失败: %w", err)
+		}
+		size, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return result, fmt.Errorf("定位导出文件失败: %w", err)
+		}
+		checkpoint.Offset = result.Exported
+		checkpoint.PageToken = page.PageToken
+		checkpoint.Size = size
+		if err := checkpoint.Save(checkpointPath); err != nil {
+			common.PrintWarning(err.Error())
+		}
+		pageToken = page.PageToken
+	}
+
+	if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		common.PrintWarning(fmt.Sprintf("删除检查点失败: %v", err))
+	}
+	return result, nil
+}
+
+// exportPage 读取一页记录，每页单独计算超时
+// API 拒绝每页记录数时自动减半，返回实际使用的每页记录数供后续分页沿用
+func (e *Executor) exportPage(tableID, pageToken string, size int) (*basesql.ListRecordsResponse, int, error) {
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	page, used, err := e.client.ListRecordsPage(ctx, tableID, nil, pageToken, size)
+	if err != nil {
+		return nil, size, fmt.Errorf("读取记录失败: %w", err)
+	}
+	return page, used, nil
+}
@@ -0,0 +1,470 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+	"gopkg.in/yaml.v3"
+)
+
+// 模板中可以使用的生成器类型
+const (
+	GeneratorName     = "name"     // 中文姓名
+	GeneratorEmail    = "email"    // 邮箱地址
+	GeneratorPhone    = "phone"    // 手机号
+	GeneratorURL      = "url"      // 网址
+	GeneratorWord     = "word"     // 单个英文单词
+	GeneratorSentence = "sentence" // 由若干单词组成的句子
+	GeneratorNumber   = "number"   // 范围内的整数
+	GeneratorFloat    = "float"    // 范围内的小数，保留两位
+	GeneratorDate     = "date"     // 范围内的日期时间
+	GeneratorBool     = "bool"     // 布尔值
+	GeneratorChoice   = "choice"   // 从候选值中随机选择一个
+	GeneratorSequence = "sequence" // 递增的编号，可以带前缀
+	GeneratorConstant = "constant" // 固定值
+)
+
+// generatorTypes 支持的生成器类型
+var generatorTypes = map[string]bool{
+	GeneratorName: true, GeneratorEmail: true, GeneratorPhone: true, GeneratorURL: true,
+	GeneratorWord: true, GeneratorSentence: true, GeneratorNumber: true, GeneratorFloat: true,
+	GeneratorDate: true, GeneratorBool: true, GeneratorChoice: true, GeneratorSequence: true,
+	GeneratorConstant: true,
+}
+
+// 生成假数据使用的词表
+var (
+	fakeSurnames   = []string{"王", "李", "张", "刘", "陈", "杨", "赵", "黄", "周", "吴", "徐", "孙", "胡", "朱", "高", "林", "何", "郭", "马", "罗"}
+	fakeGivenNames = []string{"伟", "芳", "娜", "敏", "静", "丽", "强", "磊", "军", "洋", "勇", "艳", "杰", "娟", "涛", "明", "超", "秀英", "霞", "平", "刚", "桂英", "子涵", "浩然", "一诺", "欣怡"}
+	fakeWords      = []string{"alpha", "bravo", "cloud", "delta", "echo", "focus", "green", "harbor", "indigo", "jade", "kernel", "lunar", "maple", "nova", "orbit", "pixel", "quartz", "river", "solar", "tiger", "ultra", "vivid", "willow", "xenon", "yonder", "zephyr"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// GenerateTemplate 记录生成模板
+// 按字段名声明生成器，例如：
+//
+//	seed: 42
+//	fields:
+//	  name:   {type: name}
+//	  email:  {type: email}
+//	  age:    {type: number, min: 18, max: 60}
+//	  joined: {type: date, from: 2023-01-01, to: 2024-12-31}
+//	  status: {type: choice, values: [active, disabled]}
+type GenerateTemplate struct {
+	Seed   int64                     `yaml:"seed,omitempty" json:"seed,omitempty"` // 随机种子，相同的种子生成相同的数据
+	Fields map[string]*GeneratorSpec `yaml:"fields" json:"fields"`                 // 字段名到生成器的映射
+}
+
+// GeneratorSpec 单个字段的生成器配置
+type GeneratorSpec struct {
+	Type   string        `yaml:"type" json:"type"`                         // 生成器类型，如 name、email、number
+	Min    *float64      `yaml:"min,omitempty" json:"min,omitempty"`       // number、float 的最小值（默认 0）
+	Max    *float64      `yaml:"max,omitempty" json:"max,omitempty"`       // number、float 的最大值（默认 1000）
+	From   string        `yaml:"from,omitempty" json:"from,omitempty"`     // date 的起始时间（默认一年前）
+	To     string        `yaml:"to,omitempty" json:"to,omitempty"`         // date 的结束时间（默认当前时间）
+	Values []interface{} `yaml:"values,omitempty" json:"values,omitempty"` // choice 的候选值
+	Prefix string        `yaml:"prefix,omitempty" json:"prefix,omitempty"` // sequence 的前缀
+	Start  int           `yaml:"start,omitempty" json:"start,omitempty"`   // sequence 的起始编号（默认 1）
+	Words  int           `yaml:"words,omitempty" json:"words,omitempty"`   // sentence 的单词数（默认 6）
+	Value  interface{}   `yaml:"value,omitempty" json:"value,omitempty"`   // constant 的固定值
+
+	from, to time.Time // 解析后的日期范围
+}
+
+// GenerateOptions 记录生成选项
+type GenerateOptions struct {
+	Table    string // 表名
+	Count    int    // 生成的记录数
+	Template string // 模板文件路径，为空时按字段类型自动选择生成器
+	Seed     int64  // 随机种子，0 表示使用模板中的种子或当前时间
+}
+
+// GenerateResult 记录生成结果统计
+type GenerateResult struct {
+	Fields  []string // 生成了数据的字段
+	Created int      // 成功插入的记录数
+}
+
+// LoadGenerateTemplate 读取并校验记录生成模板
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 模板文件路径
+//
+// 返回:
+//   - *GenerateTemplate: 解析后的模板
+//   - error: 读取或校验错误
+func LoadGenerateTemplate(path string) (*GenerateTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取生成模板失败: %w", err)
+	}
+
+	var template GenerateTemplate
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("解析生成模板失败: %w", err)
+	}
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Validate 校验生成模板，并解析日期范围
+// 返回:
+//   - error: 校验错误
+func (t *GenerateTemplate) Validate() error {
+	if len(t.Fields) == 0 {
+		return fmt.Errorf("生成模板中没有声明任何字段")
+	}
+	for name, spec := range t.Fields {
+		if spec == nil {
+			return fmt.Errorf("字段 '%s' 缺少生成器配置", name)
+		}
+		if err := spec.validate(); err != nil {
+			return fmt.Errorf("字段 '%s' 的生成器无效: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validate 校验单个生成器配置
+func (s *GeneratorSpec) validate() error {
+	s.Type = strings.ToLower(s.Type)
+	if !generatorTypes[s.Type] {
+		types := make([]string, 0, len(generatorTypes))
+		for t := range generatorTypes {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return fmt.Errorf("不支持的生成器类型 '%s'，可选值: %s", s.Type, strings.Join(types, ", "))
+	}
+
+	switch s.Type {
+	case GeneratorNumber, GeneratorFloat:
+		if s.Min != nil && s.Max != nil && *s.Min > *s.Max {
+			return fmt.Errorf("min 不能大于 max")
+		}
+	case GeneratorDate:
+		now := time.Now()
+		s.from, s.to = now.AddDate(-1, 0, 0), now
+		if s.From != "" {
+			t, err := parseGenerateTime(s.From)
+			if err != nil {
+				return err
+			}
+			s.from = t
+		}
+		if s.To != "" {
+			t, err := parseGenerateTime(s.To)
+			if err != nil {
+				return err
+			}
+			s.to = t
+		}
+		if s.from.After(s.to) {
+			return fmt.Errorf("from 不能晚于 to")
+		}
+	case GeneratorChoice:
+		if len(s.Values) == 0 {
+			return fmt.Errorf("choice 需要通过 values 声明候选值")
+		}
+	case GeneratorConstant:
+		if s.Value == nil {
+			return fmt.Errorf("constant 需要通过 value 声明固定值")
+		}
+	}
+	return nil
+}
+
+// parseGenerateTime 解析模板中的时间，支持日期和日期时间两种格式
+func parseGenerateTime(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 '%s'，应为 2006-01-02 或 2006-01-02 15:04:05", value)
+}
+
+// generator 按生成器配置生成字段值
+type generator struct {
+	rng *rand.Rand
+}
+
+// value 生成第 index 条记录（从 0 开始）的字段值
+func (g *generator) value(spec *GeneratorSpec, index int) interface{} {
+	switch spec.Type {
+	case GeneratorName:
+		return g.pick(fakeSurnames) + g.pick(fakeGivenNames)
+	case GeneratorEmail:
+		return fmt.Sprintf("%s.%s%d@%s", g.pick(fakeWords), g.pick(fakeWords), g.rng.Intn(1000), g.pick(fakeDomains))
+	case GeneratorPhone:
+		prefixes := []string{"130", "138", "150", "159", "186", "188"}
+		return fmt.Sprintf("%s%08d", g.pick(prefixes), g.rng.Intn(100000000))
+	case GeneratorURL:
+		return fmt.Sprintf("https://%s/%s/%s", g.pick(fakeDomains), g.pick(fakeWords), g.pick(fakeWords))
+	case GeneratorWord:
+		return g.pick(fakeWords)
+	case GeneratorSentence:
+		n := spec.Words
+		if n <= 0 {
+			n = 6
+		}
+		words := make([]string, n)
+		for i := range words {
+			words[i] = g.pick(fakeWords)
+		}
+		return strings.ToUpper(words[0][:1]) + strings.Join(words, " ")[1:] + "."
+	case GeneratorNumber:
+		lo, hi := spec.bounds()
+		return int64(lo) + g.rng.Int63n(int64(hi)-int64(lo)+1)
+	case GeneratorFloat:
+		lo, hi := spec.bounds()
+		v, _ := strconv.ParseFloat(strconv.FormatFloat(lo+g.rng.Float64()*(hi-lo), 'f', 2, 64), 64)
+		return v
+	case GeneratorDate:
+		span := spec.to.Sub(spec.from)
+		if span <= 0 {
+			return spec.from
+		}
+		return spec.from.Add(time.Duration(g.rng.Int63n(int64(span)))).Truncate(time.Second)
+	case GeneratorBool:
+		return g.rng.Intn(2) == 1
+	case GeneratorChoice:
+		return spec.Values[g.rng.Intn(len(spec.Values))]
+	case GeneratorSequence:
+		start := spec.Start
+		if start == 0 {
+			start = 1
+		}
+		return fmt.Sprintf("%s%d", spec.Prefix, start+index)
+	case GeneratorConstant:
+		return spec.Value
+	}
+	return nil
+}
+
+// pick 从词表中随机选择一个词
+func (g *generator) pick(words []string) string {
+	return words[g.rng.Intn(len(words))]
+}
+
+// bounds 获取 number、float 生成器的取值范围
+func (s *GeneratorSpec) bounds() (float64, float64) {
+	lo, hi := 0.0, 1000.0
+	if s.Min != nil {
+		lo = *s.Min
+	}
+	if s.Max != nil {
+		hi = *s.Max
+	}
+	if lo > hi {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// defaultGenerator 根据字段类型选择默认的生成器
+// 人员、附件、系统字段等无法凭空生成的字段返回 nil
+func defaultGenerator(field *basesql.Field) *GeneratorSpec {
+	if field.IsReadOnly() {
+		return nil
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	var spec *GeneratorSpec
+	switch field.Type {
+	case basesql.FieldTypeText:
+		spec = &GeneratorSpec{Type: GeneratorSentence, Words: 4}
+		if field.IsPrimary {
+			spec = &GeneratorSpec{Type: GeneratorName}
+		}
+	case basesql.FieldTypeNumber:
+		spec = &GeneratorSpec{Type: GeneratorNumber, Min: ptr(0), Max: ptr(1000)}
+	case basesql.FieldTypeCurrency:
+		spec = &GeneratorSpec{Type: GeneratorFloat, Min: ptr(0), Max: ptr(10000)}
+	case basesql.FieldTypeProgress:
+		spec = &GeneratorSpec{Type: GeneratorFloat, Min: ptr(0), Max: ptr(1)}
+	case basesql.FieldTypeRating:
+		spec = &GeneratorSpec{Type: GeneratorNumber, Min: ptr(1), Max: ptr(5)}
+	case basesql.FieldTypeDate:
+		spec = &GeneratorSpec{Type: GeneratorDate}
+	case basesql.FieldTypeCheckbox:
+		spec = &GeneratorSpec{Type: GeneratorBool}
+	case basesql.FieldTypePhone:
+		spec = &GeneratorSpec{Type: GeneratorPhone}
+	case basesql.FieldTypeURL:
+		spec = &GeneratorSpec{Type: GeneratorURL}
+	case basesql.FieldTypeBarcode:
+		spec = &GeneratorSpec{Type: GeneratorSequence, Start: 100000}
+	case basesql.FieldTypeSingleSelect, basesql.FieldTypeMultiSelect:
+		options := fieldOptions(field)
+		if len(options) == 0 {
+			return nil
+		}
+		spec = &GeneratorSpec{Type: GeneratorChoice, Values: options}
+	default:
+		return nil
+	}
+	if err := spec.validate(); err != nil {
+		return nil
+	}
+	return spec
+}
+
+// fieldOptions 获取单选、多选字段的选项名称
+func fieldOptions(field *basesql.Field) []interface{} {
+	raw, _ := field.Property["options"].([]interface{})
+	options := make([]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if option, ok := item.(map[string]interface{}); ok {
+			if name, ok := option["name"].(string); ok && name != "" {
+				options = append(options, name)
+			}
+		}
+	}
+	return options
+}
+
+// Generate 按模板生成合成记录并写入表中
+// 参数:
+//   - opts: 生成选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Generate(opts GenerateOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	var template *GenerateTemplate
+	if opts.Template != "" {
+		t, err := LoadGenerateTemplate(opts.Template)
+		if err != nil {
+			return err
+		}
+		template = t
+	}
+
+	start := time.Now()
+	result, err := c.executor.Generate(opts, template)
+	job := &JobResult{Job: "generate", Target: opts.Table, Err: err}
+	if result != nil {
+		job.Summary = fmt.Sprintf("生成字段 %s，插入记录 %d 条", strings.Join(result.Fields, ", "), result.Created)
+		fmt.Printf("📊 %s\n", job.Summary)
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Generate 生成合成记录并逐条通过 INSERT 写入
+// 与 exec 执行的 INSERT 走同一路径，写入前同样按校验规则和字段取值范围检查
+// 参数:
+//   - opts: 生成选项
+//   - template: 生成模板，为空时按字段类型自动选择生成器
+//
+// 返回:
+//   - *GenerateResult: 生成结果（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Generate(opts GenerateOptions, template *GenerateTemplate) (*GenerateResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if opts.Count <= 0 {
+		return nil, fmt.Errorf("生成的记录数必须大于 0")
+	}
+	if err := e.config.CheckWritable("INSERT"); err != nil {
+		return nil, err
+	}
+	if err := e.policy.Check(common.CommandInsert, opts.Table); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(ctx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确定每个字段的生成器，模板中的字段必须存在且可写
+	specs := make(map[string]*GeneratorSpec)
+	if template != nil {
+		for name, spec := range template.Fields {
+			field := queryengine.FindField(fields, name)
+			if field == nil {
+				return nil, fmt.Errorf("字段 '%s' 不存在", name)
+			}
+			if field.IsReadOnly() {
+				return nil, fmt.Errorf("不能写入只读字段 '%s'", field.FieldName)
+			}
+			specs[field.FieldName] = spec
+		}
+	} else {
+		for i := range fields {
+			if spec := defaultGenerator(&fields[i]); spec != nil {
+				specs[fields[i].FieldName] = spec
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("表 '%s' 中没有可以自动生成数据的字段，请通过模板声明", opts.Table)
+	}
+
+	seed := opts.Seed
+	if seed == 0 && template != nil {
+		seed = template.Seed
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	gen := &generator{rng: rand.New(rand.NewSource(seed))}
+
+	// 按字段名排序，保证相同的种子生成相同的数据
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// 字段名和占位符在每条记录中相同，值通过 ? 绑定，不拼接到 SQL 中
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", opts.Table, strings.Join(names, ", "), placeholders)
+
+	result := &GenerateResult{Fields: names}
+	for i := 0; i < opts.Count; i++ {
+		values := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			field := queryengine.FindField(fields, name)
+			value := gen.value(specs[name], i)
+			if field.Type == basesql.FieldTypeMultiSelect {
+				value = common.FormatValue(value)
+			}
+			values = append(values, value)
+		}
+
+		if err := e.db.WithContext(ctx).Exec(insert, values...).Error; err != nil {
+			return result, fmt.Errorf("插入第 %d 条记录失败: %w", i+1, err)
+		}
+		result.Created++
+		if result.Created%common.MaxBatchSize == 0 || result.Created == opts.Count {
+			fmt.Printf("  ✅ 已插入 %d/%d 条记录\n", result.Created, opts.Count)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/api/basesqlpb"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+const (
+	// DefaultGRPCChunkSize QueryStream 每个块默认包含的记录数
+	DefaultGRPCChunkSize = 500
+	// maxGRPCChunkSize QueryStream 每个块最多包含的记录数，避免单条消息超过 gRPC 的大小限制
+	maxGRPCChunkSize = 5000
+)
+
+// GRPCMethodMetrics 单个 gRPC 方法的调用统计
+type GRPCMethodMetrics struct {
+	Method        string        // 完整方法名，如 /basesql.v1.BaseSQL/Exec
+	Calls         int64         // 调用次数
+	Errors        int64         // 返回非 OK 状态的次数
+	TotalDuration time.Duration // 累计耗时
+}
+
+// GRPCServer gRPC 服务
+// 与 REST 网关一样通过共享的客户端执行 SQL，QueryStream 按块流式返回结果，Exec 返回影响的行数
+type GRPCServer struct {
+	basesqlpb.UnimplementedBaseSQLServer
+
+	client *Client // 共享的 CLI 客户端
+	token  string  // 访问令牌，调用需要在 metadata 中携带 authorization: Bearer <token>
+
+	mu      sync.Mutex                    // 保护 metrics
+	metrics map[string]*GRPCMethodMetrics // 按方法名统计的调用指标
+}
+
+// NewGRPCServer 创建 gRPC 服务
+// 参数:
+//   - client: CLI 客户端
+//   - token: 访问令牌，不能为空
+//
+// 返回:
+//   - *GRPCServer: 服务实例
+//   - error: 参数错误
+func NewGRPCServer(client *Client, token string) (*GRPCServer, error) {
+	if client == nil || client.db == nil {
+		return nil, fmt.Errorf("客户端未初始化")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("访问令牌不能为空")
+	}
+	return &GRPCServer{
+		client:  client,
+		token:   token,
+		metrics: make(map[string]*GRPCMethodMetrics),
+	}, nil
+}
+
+// Server 创建注册了 BaseSQL 服务和健康检查服务的 gRPC 服务器
+// 所有调用先经过统计拦截器，再经过鉴权拦截器；健康检查服务不需要令牌
+// 返回:
+//   - *grpc.Server: gRPC 服务器
+func (s *GRPCServer) Server() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.metricsUnaryInterceptor, s.authUnaryInterceptor),
+		grpc.ChainStreamInterceptor(s.metricsStreamInterceptor, s.authStreamInterceptor),
+	)
+	basesqlpb.RegisterBaseSQLServer(server, s)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	return server
+}
+
+// ListenAndServe 在指定地址上启动 gRPC 服务，ctx 取消后优雅关闭
+// 参数:
+//   - ctx: 上下文
+//   - addr: 监听地址，如 ":9090"
+//
+// 返回:
+//   - error: 启动或运行错误
+func (s *GRPCServer) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := s.Server()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		// 等待进行中的调用结束，超时后强制关闭
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(30 * time.Second):
+			server.Stop()
+		}
+		return nil
+	}
+}
+
+// QueryStream 执行 SELECT，按块流式返回结果
+// 不需要排序、分组和聚合的查询边读取边发送，每凑满一个块就发送，内存占用与结果行数无关
+func (s *GRPCServer) QueryStream(req *basesqlpb.QueryRequest, stream basesqlpb.BaseSQL_QueryStreamServer) error {
+	cmd, sql, err := prepareStatement(req.GetSql(), req.GetParams().AsMap())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if cmd.Type != common.CommandSelect {
+		return status.Errorf(codes.InvalidArgument, "QueryStream 只支持 SELECT 语句，不支持 %s", cmd.Type)
+	}
+
+	executor, err := s.client.newExecutor()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	chunkSize := int(req.GetChunkSize())
+	if chunkSize <= 0 {
+		chunkSize = DefaultGRPCChunkSize
+	}
+	if chunkSize > maxGRPCChunkSize {
+		chunkSize = maxGRPCChunkSize
+	}
+
+	// 每凑满一个块就发送，不在内存中保留全部结果；第一个块总是携带列名，即使结果为空
+	var chunk *basesqlpb.QueryChunk
+	var columns []string
+	onColumns := func(labels []string) error {
+		columns = labels
+		chunk = &basesqlpb.QueryChunk{Columns: labels}
+		return nil
+	}
+	onRow := func(row map[string]interface{}) error {
+		values := make([]*structpb.Value, 0, len(columns))
+		for _, column := range columns {
+			value, err := toProtoValue(row[column])
+			if err != nil {
+				return status.Errorf(codes.Internal, "转换字段 %s 的值失败: %v", column, err)
+			}
+			values = append(values, value)
+		}
+		chunk.Rows = append(chunk.Rows, &basesqlpb.Row{Values: values})
+		if len(chunk.Rows) < chunkSize {
+			return nil
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		chunk = &basesqlpb.QueryChunk{}
+		return nil
+	}
+
+	ctx, traceID, err := grpcTraceContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = executor.QueryEach(ctx, cmd, onColumns, onRow)
+	common.LogSQLExecution(ctx, sql, time.Since(start), err)
+	if err != nil {
+		// 转换失败和发送失败已经是 gRPC 状态错误
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return grpcStatusError(err, traceID)
+	}
+	if len(chunk.Rows) > 0 || len(chunk.Columns) > 0 {
+		return stream.Send(chunk)
+	}
+	return nil
+}
+
+// Exec 执行 INSERT、UPDATE、DELETE、CREATE、DROP，返回影响的行数
+func (s *GRPCServer) Exec(ctx context.Context, req *basesqlpb.ExecRequest) (*basesqlpb.ExecResponse, error) {
+	cmd, sql, err := prepareStatement(req.GetSql(), req.GetParams().AsMap())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !isExecCommand(cmd.Type) {
+		return nil, status.Errorf(codes.InvalidArgument, "Exec 只支持 INSERT、UPDATE、DELETE、CREATE、DROP 语句，不支持 %s", cmd.Type)
+	}
+
+	executor, err := s.client.newExecutor()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	ctx, traceID, err := grpcTraceContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	affected, err := executor.Exec(ctx, cmd)
+	common.LogSQLExecution(ctx, sql, time.Since(start), err)
+	if err != nil {
+		return nil, grpcStatusError(err, traceID)
+	}
+	return &basesqlpb.ExecResponse{RowsAffected: affected}, nil
+}
+
+// Metrics 获取按方法名排序的调用统计
+// 返回:
+//   - []GRPCMethodMetrics: 调用统计
+func (s *GRPCServer) Metrics() []GRPCMethodMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := make([]GRPCMethodMetrics, 0, len(s.metrics))
+	for _, m := range s.metrics {
+		metrics = append(metrics, *m)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Method < metrics[j].Method })
+	return metrics
+}
+
+// authUnaryInterceptor 校验一元调用的访问令牌
+func (s *GRPCServer) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authStreamInterceptor 校验流式调用的访问令牌
+func (s *GRPCServer) authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize 从 metadata 中读取并校验访问令牌，健康检查服务不需要令牌
+func (s *GRPCServer) authorize(ctx context.Context, method string) error {
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token := strings.TrimPrefix(value, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "访问令牌无效")
+}
+
+// metricsUnaryInterceptor 统计一元调用
+func (s *GRPCServer) metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.recordCall(info.FullMethod, time.Since(start), err)
+	return resp, err
+}
+
+// metricsStreamInterceptor 统计流式调用
+func (s *GRPCServer) metricsStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	s.recordCall(info.FullMethod, time.Since(start), err)
+	return err
+}
+
+// recordCall 累计一次调用的统计并记录日志
+func (s *GRPCServer) recordCall(method string, duration time.Duration, err error) {
+	code := status.Code(err)
+
+	s.mu.Lock()
+	m, ok := s.metrics[method]
+	if !ok {
+		m = &GRPCMethodMetrics{Method: method}
+		s.metrics[method] = m
+	}
+	m.Calls++
+	if code != codes.OK {
+		m.Errors++
+	}
+	m.TotalDuration += duration
+	s.mu.Unlock()
+
+	common.DefaultLogger.WithFields(map[string]interface{}{
+		"method":   method,
+		"code":     code.String(),
+		"duration": duration.String(),
+	}).Debug("gRPC call completed")
+}
+
+// grpcTraceContext 沿用调用方 metadata 中的 x-request-id 作为追踪 ID，没有时生成新的，并通过响应头返回
+// 生成追踪 ID 失败时返回 Internal 状态的错误
+func grpcTraceContext(ctx context.Context) (context.Context, string, error) {
+	traceID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 && common.ValidTraceID(values[0]) {
+			traceID = values[0]
+		}
+	}
+	if traceID == "" {
+		var err error
+		if traceID, err = common.NewTraceID(); err != nil {
+			return ctx, "", status.Error(codes.Internal, err.Error())
+		}
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", traceID))
+	return common.ContextWithTraceID(ctx, traceID), traceID, nil
+}
+
+// grpcStatusError 根据执行错误确定 gRPC 状态码，与 REST 网关的 HTTP 状态码一一对应
+// 错误信息末尾附带追踪 ID
+func grpcStatusError(err error, traceID string) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, basesql.ErrValidationFailed):
+		code = codes.InvalidArgument
+	case errors.Is(err, basesql.ErrUniqueViolation):
+		code = codes.AlreadyExists
+	case errors.Is(err, basesql.ErrReadOnly), errors.Is(err, ErrPolicyDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Errorf(code, "%s（追踪 ID: %s）", err.Error(), traceID)
+}
+
+// toProtoValue 将查询结果中的字段值转换为 protobuf Value
+// 无法直接转换的值（如时间、人员、附件）先按 JSON 编码，与 REST 网关的输出保持一致
+func toProtoValue(value interface{}) (*structpb.Value, error) {
+	if v, err := structpb.NewValue(value); err == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(generic)
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 语法高亮使用的 ANSI 颜色，只使用 Windows 控制台也能转换的基本颜色
+const (
+	highlightKeyword = "\033[1;34m" // 关键字：加粗蓝色
+	highlightString  = "\033[32m"   // 字符串：绿色
+	highlightNumber  = "\033[33m"   // 数字：黄色
+	highlightComment = "\033[36m"   // 注释：青色
+	highlightReset   = "\033[0m"
+)
+
+// sqlKeywords 高亮的 SQL 关键字和函数名，大写
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		SELECT FROM WHERE AND OR NOT IN IS NULL LIKE ILIKE BETWEEN EXISTS AS DISTINCT
+		ORDER BY GROUP HAVING LIMIT OFFSET ASC DESC UNION ALL CASE WHEN THEN ELSE END
+		INSERT INTO VALUES UPDATE SET DELETE CREATE DROP ALTER TABLE ADD COLUMN RENAME TO
+		SHOW TABLES DATABASES COLUMNS DESCRIBE DESC EXPLAIN USE IF TRUE FALSE
+		JOIN LEFT RIGHT INNER OUTER ON WITH
+		COUNT SUM AVG MIN MAX COALESCE IFNULL NOW LOWER UPPER LENGTH`) {
+		sqlKeywords[keyword] = true
+	}
+}
+
+// SQLPainter 为交互式 shell 的输入行着色，实现 readline.Painter 接口
+// 关键字、字符串、数字和注释在输入时即时着色；只插入颜色转义序列，不改变字符，光标位置不受影响
+type SQLPainter struct{}
+
+// Paint 返回着色后的输入行
+// 参数:
+//   - line: 当前输入行
+//   - pos: 光标位置
+//
+// 返回:
+//   - []rune: 插入了颜色转义序列的输入行
+func (SQLPainter) Paint(line []rune, _ int) []rune {
+	return []rune(HighlightSQL(string(line)))
+}
+
+// HighlightSQL 为 SQL 文本中的关键字、字符串、数字和注释插入 ANSI 颜色
+// 未闭合的字符串和注释着色到行尾；反引号包围的标识符和表名、字段名不着色
+// 参数:
+//   - sql: SQL 文本
+//
+// 返回:
+//   - string: 着色后的文本
+func HighlightSQL(sql string) string {
+	var sb strings.Builder
+	for _, token := range lexSQL(sql) {
+		color := ""
+		switch token.kind {
+		case sqlTokenKeyword:
+			color = highlightKeyword
+		case sqlTokenString:
+			color = highlightString
+		case sqlTokenNumber:
+			color = highlightNumber
+		case sqlTokenComment:
+			color = highlightComment
+		}
+		if color == "" {
+			sb.WriteString(token.text)
+			continue
+		}
+		sb.WriteString(color)
+		sb.WriteString(token.text)
+		sb.WriteString(highlightReset)
+	}
+	return sb.String()
+}
+
+// sqlTokenKind SQL 词法单元的类型
+type sqlTokenKind int
+
+const (
+	sqlTokenSpace   sqlTokenKind = iota // 空白
+	sqlTokenKeyword                     // 关键字和函数名
+	sqlTokenIdent                       // 标识符、反引号标识符和 :name 参数
+	sqlTokenString                      // 单引号或双引号字符串
+	sqlTokenNumber                      // 数字
+	sqlTokenComment                     // -- 注释
+	sqlTokenSymbol                      // 运算符和标点
+)
+
+// sqlToken SQL 词法单元
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// lexSQL 将 SQL 文本切分为词法单元，拼接所有单元的文本即为原文
+// 未闭合的字符串和注释延续到文本末尾，不会返回错误，适合处理正在输入的语句
+func lexSQL(sql string) []sqlToken {
+	runes := []rune(sql)
+	var tokens []sqlToken
+	emit := func(kind sqlTokenKind, start, end int) int {
+		tokens = append(tokens, sqlToken{kind: kind, text: string(runes[start:end])})
+		return end
+	}
+	scan := func(start int, match func(rune) bool) int {
+		end := start
+		for end < len(runes) && match(runes[end]) {
+			end++
+		}
+		return end
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i = emit(sqlTokenSpace, i, scan(i, unicode.IsSpace))
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			i = emit(sqlTokenComment, i, scan(i, func(r rune) bool { return r != '\n' }))
+		case r == '\'' || r == '"':
+			i = emit(sqlTokenString, i, closingQuote(runes, i))
+		case r == '`':
+			i = emit(sqlTokenIdent, i, closingQuote(runes, i))
+		case unicode.IsDigit(r):
+			i = emit(sqlTokenNumber, i, scan(i, func(r rune) bool { return unicode.IsDigit(r) || r == '.' }))
+		case isIdentRune(r), r == ':' && i+1 < len(runes) && isIdentRune(runes[i+1]):
+			end := scan(i+1, func(r rune) bool { return isIdentRune(r) || unicode.IsDigit(r) })
+			kind := sqlTokenIdent
+			if sqlKeywords[strings.ToUpper(string(runes[i:end]))] {
+				kind = sqlTokenKeyword
+			}
+			i = emit(kind, i, end)
+		case strings.ContainsRune("<>!=", r):
+			i = emit(sqlTokenSymbol, i, scan(i, func(r rune) bool { return strings.ContainsRune("<>!=", r) }))
+		default:
+			i = emit(sqlTokenSymbol, i, i+1)
+		}
+	}
+	return tokens
+}
+
+// closingQuote 返回从 start 处引号开始的字符串之后的位置
+// 连续两个引号和反斜杠转义的引号不结束字符串，未闭合时返回行尾
+func closingQuote(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// isIdentRune 判断字符能否作为标识符的开头，中文等字段名同样视为标识符
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
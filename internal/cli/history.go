@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// HistoryEntry 一条结构化的查询历史
+type HistoryEntry struct {
+	ID       int           `json:"-"`               // 序号，从 1 开始，按写入顺序分配
+	Session  string        `json:"session"`         // 会话 ID，同一次进程执行的命令共享
+	SQL      string        `json:"sql"`             // 执行的 SQL 语句
+	Time     time.Time     `json:"time"`            // 执行开始时间
+	Duration time.Duration `json:"duration"`        // 执行耗时
+	Rows     int64         `json:"rows"`            // 返回或影响的行数
+	Error    string        `json:"error,omitempty"` // 执行失败时的错误信息
+}
+
+// HistoryStore 查询历史存储
+// 历史以 JSON Lines 格式追加写入文件，每行一条记录
+type HistoryStore struct {
+	path  string     // 历史文件路径
+	mutex sync.Mutex // 保证并发追加写入安全
+}
+
+// DefaultHistoryPath 获取默认的历史文件路径 ~/.basesql/history.jsonl
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultHistoryPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "history.jsonl"), nil
+}
+
+// NewHistoryStore 创建查询历史存储
+// 参数:
+//   - path: 历史文件路径，不存在时在首次写入时创建
+//
+// 返回:
+//   - *HistoryStore: 历史存储实例
+func NewHistoryStore(path string) *HistoryStore {
+	return &HistoryStore{path: path}
+}
+
+// NewHistorySession 生成新的会话 ID
+// 返回:
+//   - string: 以启动时间和进程号组成的会话 ID
+func NewHistorySession() string {
+	return fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), os.Getpid())
+}
+
+// Append 追加一条历史记录
+// 参数:
+//   - entry: 历史记录
+//
+// 返回:
+//   - error: 写入错误信息
+func (h *HistoryStore) Append(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化历史记录失败: %w", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("创建历史目录失败: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开历史文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入历史记录失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取全部历史记录
+// 无法解析的行会被跳过，序号按文件中的顺序分配
+// 返回:
+//   - []HistoryEntry: 历史记录，文件不存在时为空
+//   - error: 读取错误信息
+func (h *HistoryStore) Load() ([]HistoryEntry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开历史文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entry.ID = len(entries) + 1
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Get 按序号获取历史记录
+// 参数:
+//   - id: 历史序号
+//
+// 返回:
+//   - *HistoryEntry: 历史记录
+//   - error: 序号不存在时返回错误
+func (h *HistoryStore) Get(id int) (*HistoryEntry, error) {
+	entries, err := h.Load()
+	if err != nil {
+		return nil, err
+	}
+	if id < 1 || id > len(entries) {
+		return nil, fmt.Errorf("历史记录 %d 不存在", id)
+	}
+	return &entries[id-1], nil
+}
+
+// HistoryFilter 历史查询条件
+type HistoryFilter struct {
+	Keyword string // SQL 中包含的关键字，忽略大小写
+	Session string // 会话 ID
+	Limit   int    // 返回最近的条数，0 表示不限制
+}
+
+// Search 按条件查询历史记录，结果按时间顺序排列
+// 参数:
+//   - filter: 查询条件
+//
+// 返回:
+//   - []HistoryEntry: 匹配的历史记录
+//   - error: 读取错误信息
+func (h *HistoryStore) Search(filter HistoryFilter) ([]HistoryEntry, error) {
+	entries, err := h.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	keyword := strings.ToLower(filter.Keyword)
+	matched := make([]HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.Session != "" && entry.Session != filter.Session {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(entry.SQL), keyword) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+	return matched, nil
+}
+
+// PrintHistory 以表格输出历史记录
+// 参数:
+//   - entries: 历史记录
+//   - showSession: 是否显示会话列
+func PrintHistory(entries []HistoryEntry, showSession bool) {
+	if len(entries) == 0 {
+		fmt.Println("📭 没有匹配的历史记录")
+		return
+	}
+
+	headers := []string{"#", "Time", "Duration", "Rows", "SQL"}
+	if showSession {
+		headers = append([]string{"#", "Session"}, headers[1:]...)
+	}
+	// SQL 列需要比默认列宽更宽，才能看清完整语句
+	table := common.NewTable(headers...).SetMaxColumnWidth(80)
+	for _, entry := range entries {
+		rows := fmt.Sprintf("%d", entry.Rows)
+		if entry.Error != "" {
+			rows = "ERROR"
+		}
+		cells := []string{
+			fmt.Sprintf("%d", entry.ID),
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Duration.Round(time.Millisecond).String(),
+			rows,
+			entry.SQL,
+		}
+		if showSession {
+			cells = append([]string{cells[0], entry.Session}, cells[1:]...)
+		}
+		table.AppendRow(cells...)
+	}
+	fmt.Print(table.String())
+}
@@ -0,0 +1,790 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// 类型推断的默认值和阈值
+const (
+	DefaultImportSampleRows = 100 // 默认用于推断字段类型的行数
+	importSelectMaxOptions  = 20  // 推断为单选时最多的不同取值数
+	importSelectMaxLength   = 30  // 推断为单选时单个取值的最大长度（字符数）
+)
+
+// 导入模式
+const (
+	ImportModeInsert = "insert" // 所有行都插入为新记录
+	ImportModeUpsert = "upsert" // 按键字段匹配已有记录，存在则更新，否则插入
+)
+
+// importDateLayouts 可以识别为日期的格式
+var importDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"2006/01/02 15:04",
+	"2006/01/02 15:04:05",
+	time.RFC3339,
+}
+
+// importBoolValues 可以识别为复选框的取值
+var importBoolValues = map[string]bool{
+	"true": true, "yes": true, "是": true,
+	"false": false, "no": false, "否": false,
+}
+
+// ImportOptions CSV 导入选项
+type ImportOptions struct {
+	File       string // CSV 文件路径，第一行为列名
+	Table      string // 目标表名，不存在时按推断的字段类型创建
+	SampleRows int    // 用于推断字段类型的行数，0 表示使用默认值
+	SchemaOnly bool   // 只预览推断的字段类型和与已有表的比对结果，不写入
+	Mode       string // 导入模式: insert 或 upsert，默认 insert
+	Key        string // upsert 模式下用于匹配已有记录的列名
+	Resume     bool   // 从检查点继续上次中断的导入
+}
+
+// ImportColumn CSV 列及其推断的字段类型
+type ImportColumn struct {
+	Name    string            // 列名
+	Type    basesql.FieldType // 推断的字段类型
+	Options []string          // 推断为单选时的选项，按首次出现的顺序
+	Empty   bool              // 样本中的值全部为空
+}
+
+// ImportResult CSV 导入结果统计
+type ImportResult struct {
+	Columns      []ImportColumn // 推断的列
+	TableCreated bool           // 是否新建了表
+	Issues       []string       // 与已有表结构不兼容的问题
+	Created      int            // 成功插入的记录数
+	Updated      int            // upsert 模式下更新的记录数
+}
+
+// Import 从 CSV 文件导入记录
+// 参数:
+//   - opts: 导入选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Import(opts ImportOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	result, err := c.executor.Import(opts)
+	if opts.SchemaOnly {
+		return err
+	}
+	job := &JobResult{Job: "import", Target: opts.Table, Err: err}
+	if result != nil {
+		job.Summary = fmt.Sprintf("插入记录 %d 条", result.Created)
+		if opts.Mode == ImportModeUpsert {
+			job.Summary += fmt.Sprintf("，更新记录 %d 条", result.Updated)
+		}
+		if result.TableCreated {
+			job.Summary = fmt.Sprintf("新建表 '%s'，%s", opts.Table, job.Summary)
+		}
+		fmt.Printf("📊 %s\n", job.Summary)
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Import 读取 CSV 文件，根据前若干行推断字段类型，建表或校验已有表结构后按批写入
+// upsert 模式下按键字段匹配已有记录，存在则更新，否则插入
+// 参数:
+//   - opts: 导入选项
+//
+// 返回:
+//   - *ImportResult: 导入结果（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Import(opts ImportOptions) (*ImportResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if opts.SampleRows <= 0 {
+		opts.SampleRows = DefaultImportSampleRows
+	}
+	switch opts.Mode {
+	case "", ImportModeInsert:
+		if opts.Key != "" {
+			return nil, fmt.Errorf("--key 只能在 upsert 模式下使用")
+		}
+		opts.Mode = ImportModeInsert
+	case ImportModeUpsert:
+		if opts.Key == "" {
+			return nil, fmt.Errorf("upsert 模式需要通过 --key 指定用于匹配已有记录的列")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的导入模式 '%s'，可选值: %s, %s", opts.Mode, ImportModeInsert, ImportModeUpsert)
+	}
+
+	file, err := os.Open(opts.File)
+	if err != nil {
+		return nil, fmt.Errorf("打开 CSV 文件失败: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := readImportHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// 读取样本行，样本行之后也要写入
+	var sample [][]string
+	for len(sample) < opts.SampleRows {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取 CSV 文件失败: %w", err)
+		}
+		sample = append(sample, row)
+	}
+
+	keyIndex := -1
+	for i, name := range header {
+		if opts.Key != "" && name == opts.Key {
+			keyIndex = i
+		}
+	}
+	if opts.Key != "" && keyIndex < 0 {
+		return nil, fmt.Errorf("键列 '%s' 不在 CSV 的列名中", opts.Key)
+	}
+
+	result := &ImportResult{Columns: InferImportColumns(header, sample)}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	// 表不存在时导入会创建新表，同名的表不止一张时报错
+	tableID := ""
+	table, err := e.resolveTable(ctx, opts.Table)
+	switch {
+	case err == nil:
+		tableID = table.TableID
+	case !errors.Is(err, basesql.ErrTableNotFound):
+		return nil, fmt.Errorf("获取表失败: %w", err)
+	}
+
+	var fields []basesql.Field
+	if tableID != "" {
+		if fields, err = e.getFieldsList(ctx, tableID); err != nil {
+			return nil, err
+		}
+		result.Issues = validateImportColumns(result.Columns, fields)
+	}
+
+	if opts.SchemaOnly {
+		e.printImportSchema(opts.Table, result.Columns, fields, tableID != "")
+		return result, nil
+	}
+	if len(result.Issues) > 0 {
+		return result, fmt.Errorf("CSV 与表 '%s' 的结构不兼容:\n  • %s\n可以使用 --schema-only 预览推断的字段类型",
+			opts.Table, strings.Join(result.Issues, "\n  • "))
+	}
+
+	if err := e.config.CheckWritable("INSERT"); err != nil {
+		return nil, err
+	}
+	if err := e.policy.Check(common.CommandInsert, opts.Table); err != nil {
+		return nil, err
+	}
+	if opts.Mode == ImportModeUpsert {
+		if err := e.policy.Check(common.CommandUpdate, opts.Table); err != nil {
+			return nil, err
+		}
+	}
+
+	checkpointPath := CheckpointPath(opts.File)
+	checkpoint := &Checkpoint{Job: "import", Table: opts.Table, Header: header, Mode: opts.Mode, Key: opts.Key}
+	resumed, err := resumeCheckpoint(checkpointPath, opts.Resume, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if resumed != nil {
+		checkpoint = resumed
+	}
+
+	if tableID == "" {
+		if err := e.config.CheckWritable("CREATE TABLE"); err != nil {
+			return nil, err
+		}
+		if err := e.policy.Check(common.CommandCreate, opts.Table); err != nil {
+			return nil, err
+		}
+		if tableID, err = e.createImportTable(ctx, opts.Table, result.Columns); err != nil {
+			return result, err
+		}
+		result.TableCreated = true
+		if fields, err = e.getFieldsList(ctx, tableID); err != nil {
+			return result, err
+		}
+	}
+
+	columnFields := make([]*basesql.Field, len(header))
+	for i, name := range header {
+		columnFields[i] = queryengine.FindField(fields, name)
+		if columnFields[i] == nil {
+			return result, fmt.Errorf("字段 '%s' 不存在", name)
+		}
+	}
+
+	w := &importWriter{
+		executor:       e,
+		ctx:            e.baseContext(),
+		tableID:        tableID,
+		fields:         columnFields,
+		result:         result,
+		checkpoint:     checkpoint,
+		checkpointPath: checkpointPath,
+		done:           checkpoint.Offset,
+	}
+	if opts.Mode == ImportModeUpsert {
+		w.key = columnFields[keyIndex]
+		if w.existing, err = e.importExistingKeys(ctx, tableID, w.key, result.TableCreated); err != nil {
+			return result, err
+		}
+		// 表的记录列表可能还未包含刚插入的记录，以检查点中记录的为准
+		for key, recordID := range checkpoint.Keys {
+			w.existing[key] = recordID
+		}
+	}
+
+	if err := w.run(sample, reader); err != nil {
+		if w.done > 0 {
+			return result, fmt.Errorf("%w\n已完成前 %d 行，检查点已保存到 %s，使用 --resume 继续", err, w.done, checkpointPath)
+		}
+		return result, err
+	}
+	if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		common.PrintWarning(fmt.Sprintf("删除检查点失败: %v", err))
+	}
+	return result, nil
+}
+
+// importExistingKeys 按键字段的显示值索引表中已有的记录
+// 参数:
+//   - ctx: 上下文
+//   - tableID: 表 ID
+//   - key: 键字段
+//   - created: 表是否刚刚创建，新建的表中没有记录
+//
+// 返回:
+//   - map[string]string: 键字段的显示值到记录 ID 的映射，键重复时以最后一条为准
+//   - error: 错误信息
+func (e *Executor) importExistingKeys(ctx context.Context, tableID string, key *basesql.Field, created bool) (map[string]string, error) {
+	existing := make(map[string]string)
+	if created {
+		return existing, nil
+	}
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("读取已有记录失败: %w", err)
+	}
+	for _, record := range records {
+		if value := common.FormatValue(record.Fields[key.FieldName]); value != "" {
+			existing[value] = record.RecordID
+		}
+	}
+	return existing, nil
+}
+
+// importWriter 将 CSV 行按批写入表中
+// upsert 模式下键字段的值已存在的行更新对应记录，其余行插入新记录
+type importWriter struct {
+	executor *Executor
+	ctx      context.Context
+	tableID  string
+	fields   []*basesql.Field // 每一列对应的字段
+	result   *ImportResult
+
+	key      *basesql.Field    // upsert 模式的键字段，insert 模式为空
+	existing map[string]string // 键字段的显示值到记录 ID 的映射，包括本次导入插入的记录
+
+	checkpoint     *Checkpoint // 每写完一批保存的检查点
+	checkpointPath string
+
+	row        int                            // 已读取的数据行数，从第一行数据开始计数
+	done       int                            // 已写入的数据行数，继续时跳过这些行
+	last       int                            // 当前批次的最后一行
+	batchStart int                            // 当前批次的第一行
+	creates    []*basesql.CreateRecordRequest // 当前批次待插入的记录
+	createKeys []string                       // 待插入记录的键，插入后记录其记录 ID
+	updates    []*basesql.BatchUpdateRecord   // 当前批次待更新的记录
+	pending    map[string]bool                // 当前批次中出现过的键
+}
+
+// run 依次写入样本行和之后的行
+func (w *importWriter) run(sample [][]string, reader *csv.Reader) error {
+	for _, values := range sample {
+		if err := w.add(values); err != nil {
+			return err
+		}
+	}
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取 CSV 文件失败: %w", err)
+		}
+		if err := w.add(values); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+// add 将一行加入当前批次，批次满时写入；从检查点继续时跳过已写入的行
+func (w *importWriter) add(values []string) error {
+	w.row++
+	if w.row <= w.done {
+		return nil
+	}
+	payload, err := importPayload(w.fields, values)
+	if err != nil {
+		return fmt.Errorf("第 %d 行: %w", w.row, err)
+	}
+
+	if w.key == nil {
+		w.begin()
+		w.creates = append(w.creates, &basesql.CreateRecordRequest{Fields: payload})
+	} else {
+		key := common.FormatValue(payload[w.key.FieldName])
+		if key == "" {
+			return fmt.Errorf("第 %d 行: 键字段 '%s' 为空", w.row, w.key.FieldName)
+		}
+		// 同一批次中键重复时先写入前面的行，使后面的行更新刚写入的记录
+		if w.pending[key] {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+		w.begin()
+		if recordID, ok := w.existing[key]; ok {
+			w.updates = append(w.updates, &basesql.BatchUpdateRecord{RecordID: recordID, Fields: payload})
+		} else {
+			w.creates = append(w.creates, &basesql.CreateRecordRequest{Fields: payload})
+			w.createKeys = append(w.createKeys, key)
+		}
+		w.pending[key] = true
+	}
+	w.last = w.row
+
+	if len(w.creates) >= common.MaxBatchSize || len(w.updates) >= common.MaxBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// begin 在当前批次为空时记录批次的第一行
+func (w *importWriter) begin() {
+	if len(w.creates) == 0 && len(w.updates) == 0 {
+		w.batchStart = w.row
+		w.pending = make(map[string]bool)
+	}
+}
+
+// flush 写入当前批次的插入和更新
+func (w *importWriter) flush() error {
+	if len(w.creates) == 0 && len(w.updates) == 0 {
+		return nil
+	}
+	e := w.executor
+	ctx, cancel := context.WithTimeout(w.ctx, e.timeout)
+	defer cancel()
+	rows := fmt.Sprintf("%d-%d", w.batchStart, w.last)
+
+	if len(w.creates) > 0 {
+		resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", e.appToken, w.tableID),
+			Body:   &basesql.BatchCreateRecordsRequest{Records: w.creates},
+		})
+		var data basesql.BatchCreateRecordsResponse
+		if err == nil {
+			err = decodeFeishuResponse(resp.Body, &data)
+		}
+		if err != nil {
+			return fmt.Errorf("插入第 %s 行失败: %w", rows, err)
+		}
+		// 记录插入的记录 ID，文件中后面出现相同的键时更新该记录
+		for i, record := range data.GetRecords() {
+			if i < len(w.createKeys) && record != nil {
+				w.existing[w.createKeys[i]] = record.RecordID
+				if w.checkpoint.Keys == nil {
+					w.checkpoint.Keys = make(map[string]string)
+				}
+				w.checkpoint.Keys[w.createKeys[i]] = record.RecordID
+			}
+		}
+		w.result.Created += len(w.creates)
+	}
+
+	if len(w.updates) > 0 {
+		resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_update", e.appToken, w.tableID),
+			Body:   &basesql.BatchUpdateRecordsRequest{Records: w.updates},
+		})
+		if err == nil {
+			err = checkAPIResponse(resp.Body)
+		}
+		if err != nil {
+			return fmt.Errorf("更新第 %s 行失败: %w", rows, err)
+		}
+		w.result.Updated += len(w.updates)
+	}
+
+	if w.key == nil {
+		fmt.Printf("  ✅ 已插入 %d 条记录\n", w.result.Created)
+	} else {
+		fmt.Printf("  ✅ 已插入 %d 条、更新 %d 条记录\n", w.result.Created, w.result.Updated)
+	}
+	w.creates, w.createKeys, w.updates = nil, nil, nil
+
+	// 保存失败时继续导入，中断后只能从上一个检查点继续
+	w.done = w.last
+	w.checkpoint.Offset = w.done
+	if err := w.checkpoint.Save(w.checkpointPath); err != nil {
+		common.PrintWarning(err.Error())
+	}
+	return nil
+}
+
+// readImportHeader 读取并校验 CSV 的列名行
+func readImportHeader(reader *csv.Reader) ([]string, error) {
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV 文件为空")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 CSV 文件失败: %w", err)
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Excel 导出的 UTF-8 CSV 带有 BOM
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("CSV 第 %d 列的列名为空", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("CSV 中的列名 '%s' 重复", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	return header, nil
+}
+
+// InferImportColumns 根据样本行推断每一列的字段类型
+// 非空值全部为 true/false、yes/no、是/否时为复选框，全部为数字时为数字，全部为日期时为日期，
+// 取值较少且重复出现时为单选，其余为文本
+// 参数:
+//   - header: 列名
+//   - rows: 样本行
+//
+// 返回:
+//   - []ImportColumn: 推断的列
+func InferImportColumns(header []string, rows [][]string) []ImportColumn {
+	columns := make([]ImportColumn, len(header))
+	for i, name := range header {
+		var values []string
+		for _, row := range rows {
+			if i < len(row) {
+				if value := strings.TrimSpace(row[i]); value != "" {
+					values = append(values, value)
+				}
+			}
+		}
+		columns[i] = inferImportColumn(name, values)
+	}
+	return columns
+}
+
+// inferImportColumn 根据一列的非空样本值推断字段类型
+func inferImportColumn(name string, values []string) ImportColumn {
+	column := ImportColumn{Name: name, Type: basesql.FieldTypeText}
+	if len(values) == 0 {
+		column.Empty = true
+		return column
+	}
+
+	all := func(match func(string) bool) bool {
+		for _, value := range values {
+			if !match(value) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case all(func(v string) bool { _, ok := parseImportBool(v); return ok }):
+		column.Type = basesql.FieldTypeCheckbox
+	case all(func(v string) bool { _, ok := parseImportNumber(v); return ok }):
+		column.Type = basesql.FieldTypeNumber
+	case all(func(v string) bool { _, ok := parseImportDate(v); return ok }):
+		column.Type = basesql.FieldTypeDate
+	default:
+		if options := importSelectOptions(values); options != nil {
+			column.Type = basesql.FieldTypeSingleSelect
+			column.Options = options
+		}
+	}
+	return column
+}
+
+// importSelectOptions 取值较少、较短且有重复时返回单选的选项，否则返回 nil
+func importSelectOptions(values []string) []string {
+	var options []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if utf8.RuneCountInString(value) > importSelectMaxLength {
+			return nil
+		}
+		if !seen[value] {
+			seen[value] = true
+			options = append(options, value)
+			if len(options) > importSelectMaxOptions {
+				return nil
+			}
+		}
+	}
+	// 每个取值平均至少出现两次，避免把姓名、编号等唯一值推断为单选
+	if len(values) < 2*len(options) {
+		return nil
+	}
+	return options
+}
+
+// parseImportBool 解析复选框的取值，不区分大小写
+func parseImportBool(value string) (bool, bool) {
+	b, ok := importBoolValues[strings.ToLower(strings.TrimSpace(value))]
+	return b, ok
+}
+
+// parseImportNumber 解析数字
+// 以 0 开头的多位整数（如邮编、工号）保留为文本，避免丢失前导零
+func parseImportNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	digits := strings.TrimPrefix(value, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseImportDate 按本地时区解析日期
+func parseImportDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// importCompatible 判断推断的类型能否写入已有字段
+func importCompatible(column ImportColumn, field *basesql.Field) bool {
+	switch field.Type {
+	case basesql.FieldTypeText, basesql.FieldTypePhone, basesql.FieldTypeURL, basesql.FieldTypeBarcode,
+		basesql.FieldTypeSingleSelect, basesql.FieldTypeMultiSelect:
+		return true
+	case basesql.FieldTypeNumber, basesql.FieldTypeCurrency, basesql.FieldTypeProgress, basesql.FieldTypeRating:
+		return column.Empty || column.Type == basesql.FieldTypeNumber
+	case basesql.FieldTypeDate:
+		return column.Empty || column.Type == basesql.FieldTypeDate
+	case basesql.FieldTypeCheckbox:
+		return column.Empty || column.Type == basesql.FieldTypeCheckbox
+	default:
+		return false
+	}
+}
+
+// validateImportColumns 校验推断的列与已有表结构是否兼容
+// 返回:
+//   - []string: 不兼容的问题，兼容时为空
+func validateImportColumns(columns []ImportColumn, fields []basesql.Field) []string {
+	var issues []string
+	for _, column := range columns {
+		field := queryengine.FindField(fields, column.Name)
+		switch {
+		case field == nil:
+			issues = append(issues, fmt.Sprintf("列 '%s' 在表中不存在", column.Name))
+		case field.IsReadOnly():
+			issues = append(issues, fmt.Sprintf("列 '%s' 对应只读字段，不能写入", column.Name))
+		case !importCompatible(column, field):
+			issues = append(issues, fmt.Sprintf("列 '%s' 推断为%s，不能写入%s字段 '%s'", column.Name,
+				basesql.GetFieldTypeName(column.Type), basesql.GetFieldTypeName(field.Type), field.FieldName))
+		}
+	}
+	return issues
+}
+
+// printImportSchema 输出推断的字段类型，表已存在时同时输出对应的字段和兼容性
+func (e *Executor) printImportSchema(tableName string, columns []ImportColumn, fields []basesql.Field, exists bool) {
+	if !exists {
+		fmt.Printf("📋 表 '%s' 不存在，导入时将按以下字段创建:\n", tableName)
+		table := e.newTable("列名", "推断类型", "选项")
+		for _, column := range columns {
+			table.AppendRow(column.Name, importColumnTypeName(column), strings.Join(column.Options, ", "))
+		}
+		e.printTable(table)
+		return
+	}
+
+	fmt.Printf("📋 表 '%s' 已存在，CSV 列与已有字段的对应关系:\n", tableName)
+	table := e.newTable("列名", "推断类型", "表字段类型", "状态")
+	for _, column := range columns {
+		fieldType, status := "-", "❌ 字段不存在"
+		if field := queryengine.FindField(fields, column.Name); field != nil {
+			fieldType = basesql.GetFieldTypeName(field.Type)
+			switch {
+			case field.IsReadOnly():
+				status = "❌ 只读字段"
+			case importCompatible(column, field):
+				status = "✅"
+			default:
+				status = "❌ 类型不兼容"
+			}
+		}
+		table.AppendRow(column.Name, importColumnTypeName(column), fieldType, status)
+	}
+	e.printTable(table)
+}
+
+// importColumnTypeName 获取推断类型的显示名称
+func importColumnTypeName(column ImportColumn) string {
+	if column.Empty {
+		return basesql.GetFieldTypeName(column.Type) + "（样本为空）"
+	}
+	return basesql.GetFieldTypeName(column.Type)
+}
+
+// createImportTable 按推断的列创建表，第一列为索引列
+func (e *Executor) createImportTable(ctx context.Context, tableName string, columns []ImportColumn) (string, error) {
+	req := &basesql.CreateTableRequest{
+		Table: &basesql.TableRequest{
+			Name:   tableName,
+			Fields: make([]*basesql.CreateFieldRequest, 0, len(columns)),
+		},
+	}
+	for i, column := range columns {
+		fieldType := column.Type
+		// 索引列只能是文本、数字、日期等类型，推断为复选框或单选时按文本创建
+		if i == 0 && (fieldType == basesql.FieldTypeCheckbox || fieldType == basesql.FieldTypeSingleSelect) {
+			fieldType = basesql.FieldTypeText
+		}
+		field := &basesql.CreateFieldRequest{FieldName: column.Name, Type: fieldType}
+		if fieldType == basesql.FieldTypeSingleSelect {
+			options := make([]interface{}, 0, len(column.Options))
+			for _, option := range column.Options {
+				options = append(options, map[string]interface{}{"name": option})
+			}
+			field.Property = map[string]interface{}{"options": options}
+		}
+		req.Table.Fields = append(req.Table.Fields, field)
+	}
+
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", e.appToken),
+		Body:   req,
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建表失败: %w", err)
+	}
+
+	var apiResp struct {
+		Code int                          `json:"code"`
+		Msg  string                       `json:"msg"`
+		Data *basesql.CreateTableResponse `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return "", fmt.Errorf("解析创建表响应失败: %w", err)
+	}
+	if apiResp.Code != 0 || apiResp.Data == nil || !apiResp.Data.IsSuccess() {
+		return "", fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+
+	fmt.Printf("  ✅ 创建表 '%s'\n", tableName)
+	return apiResp.Data.TableID, nil
+}
+
+// importPayload 将一行 CSV 值按对应字段的类型转换为记录字段，空值不写入
+func importPayload(fields []*basesql.Field, values []string) (map[string]interface{}, error) {
+	payload := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+		raw := strings.TrimSpace(values[i])
+		if raw == "" {
+			continue
+		}
+
+		var value interface{} = raw
+		switch field.Type {
+		case basesql.FieldTypeNumber, basesql.FieldTypeCurrency, basesql.FieldTypeProgress, basesql.FieldTypeRating:
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("列 '%s' 的值 '%s' 不是有效的数字", field.FieldName, raw)
+			}
+			value = n
+		case basesql.FieldTypeDate:
+			t, ok := parseImportDate(raw)
+			if !ok {
+				return nil, fmt.Errorf("列 '%s' 的值 '%s' 不是有效的日期", field.FieldName, raw)
+			}
+			value = t
+		case basesql.FieldTypeCheckbox:
+			b, ok := parseImportBool(raw)
+			if !ok {
+				return nil, fmt.Errorf("列 '%s' 的值 '%s' 不是有效的复选框取值", field.FieldName, raw)
+			}
+			value = b
+		case basesql.FieldTypeMultiSelect:
+			var options []string
+			for _, option := range strings.Split(raw, ",") {
+				if option = strings.TrimSpace(option); option != "" {
+					options = append(options, option)
+				}
+			}
+			value = options
+		}
+		payload[field.FieldName] = field.ConvertFromGoValue(value)
+	}
+	return payload, nil
+}
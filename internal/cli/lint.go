@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LintSeverity 检查结果的严重程度
+type LintSeverity string
+
+const (
+	// LintError 语句会得到错误的结果，如被忽略的条件
+	LintError LintSeverity = "error"
+	// LintWarning 语句的结果可能与预期不同，如 LIKE 的匹配方式
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue 一条检查结果
+type LintIssue struct {
+	Statement int          `json:"statement"` // 语句序号，从 1 开始
+	Line      int          `json:"line"`      // 问题所在的行，从 1 开始
+	Rule      string       `json:"rule"`      // 规则名
+	Severity  LintSeverity `json:"severity"`  // 严重程度
+	Message   string       `json:"message"`   // 说明和修改建议
+}
+
+// String 返回检查结果的文本形式
+func (i LintIssue) String() string {
+	return fmt.Sprintf("第 %d 行 [%s] %s", i.Line, i.Rule, i.Message)
+}
+
+// lintToken 带行号的词法单元
+type lintToken struct {
+	sqlToken
+	line  int
+	upper string
+}
+
+// unsupportedConstructs 执行时不支持、会被错误解析的关键字
+var unsupportedConstructs = map[string]string{
+	"JOIN":     "不支持 JOIN，语句只会读取 FROM 之后的第一张表",
+	"HAVING":   "不支持 HAVING，会被当作 GROUP BY 字段的一部分",
+	"DISTINCT": "不支持 DISTINCT，会被当作字段名的一部分；可以使用 GROUP BY 去重",
+	"OFFSET":   "不支持 OFFSET，请使用 export 命令的分页或缩小 WHERE 条件",
+}
+
+// LintSQL 检查 SQL 中执行时会被忽略或错误处理的写法
+// CLI 对 SELECT 的 WHERE 条件在客户端匹配，只支持单个条件，非等于的比较会按等于处理；
+// UPDATE、DELETE 下推到飞书的过滤条件，同样只使用第一个条件。这些情况执行时不会报错，
+// 但结果与 SQL 的语义不同，LintSQL 在执行前找出它们
+// 参数:
+//   - sql: SQL 文本，可以包含以分号分隔的多条语句
+//
+// 返回:
+//   - []LintIssue: 检查结果，按语句和出现顺序排列
+func LintSQL(sql string) []LintIssue {
+	var issues []LintIssue
+	line := 1
+	statementIndex := 0
+	for _, statement := range splitStatements(lexSQL(sql)) {
+		var tokens []lintToken
+		for _, token := range statement {
+			if token.kind != sqlTokenSpace && token.kind != sqlTokenComment {
+				tokens = append(tokens, lintToken{sqlToken: token, line: line, upper: strings.ToUpper(token.text)})
+			}
+			line += strings.Count(token.text, "\n")
+		}
+		if len(tokens) == 0 || len(tokens) == 1 && tokens[0].text == ";" {
+			continue
+		}
+		statementIndex++
+		for _, issue := range lintStatement(tokens) {
+			issue.Statement = statementIndex
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// lintStatement 检查单条语句
+func lintStatement(tokens []lintToken) []LintIssue {
+	var issues []LintIssue
+	reported := make(map[string]bool)
+	report := func(token lintToken, rule string, severity LintSeverity, message string) {
+		if reported[rule] {
+			return
+		}
+		reported[rule] = true
+		issues = append(issues, LintIssue{Line: token.line, Rule: rule, Severity: severity, Message: message})
+	}
+
+	statement := tokens[0].upper
+	isSelect := statement == "SELECT" || statement == "WITH"
+	clause := statement
+	depth := 0
+	inBetween := false
+	hasAggregate := false
+	hasGroupBy := false
+
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+			// WITH name AS (SELECT ...) 中的查询不是子查询
+			if i+1 < len(tokens) && tokens[i+1].upper == "SELECT" && !(statement == "WITH" && i > 0 && tokens[i-1].upper == "AS") {
+				report(token, "subquery", LintError, "不支持子查询，请先单独查询出结果再代入条件")
+			}
+			continue
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if token.kind == sqlTokenKeyword {
+			if message, ok := unsupportedConstructs[token.upper]; ok {
+				report(token, "unsupported-"+strings.ToLower(token.upper), LintError, message)
+			}
+			if sqlFunctionKeywords[token.upper] && i+1 < len(tokens) && tokens[i+1].text == "(" {
+				hasAggregate = true
+			}
+		}
+		if depth > 0 {
+			continue
+		}
+
+		switch token.upper {
+		case "WHERE", "GROUP", "ORDER", "LIMIT", "SET", "VALUES", "FROM", "UNION":
+			if token.kind == sqlTokenKeyword {
+				clause = token.upper
+				hasGroupBy = hasGroupBy || token.upper == "GROUP"
+				if (statement == "UPDATE" || statement == "DELETE") && (token.upper == "ORDER" || token.upper == "LIMIT") {
+					report(token, "clause-in-write", LintError,
+						fmt.Sprintf("%s 不支持 %s，它会被当作 WHERE 条件值的一部分，请去掉该子句", statement, clauseName(token.upper)))
+				}
+				if isSelect && token.upper == "ORDER" && hasAggregate && !hasGroupBy {
+					report(token, "order-by-ignored", LintWarning, "没有 GROUP BY 的聚合查询只返回一行，ORDER BY 被忽略")
+				}
+				continue
+			}
+		}
+		if clause != "WHERE" {
+			continue
+		}
+
+		switch {
+		case token.upper == "BETWEEN":
+			inBetween = true
+		case token.upper == "AND" && inBetween:
+			inBetween = false
+		case token.upper == "AND":
+			report(token, "multiple-conditions", LintError,
+				"WHERE 只支持单个条件，AND 之后的条件会被当作第一个条件的值，结果与预期不同；请拆分为多次查询或使用 query 的 --format json 后在本地过滤")
+		case token.upper == "OR":
+			report(token, "or-condition", LintError,
+				"WHERE 不支持 OR，条件不会按任一满足匹配；请分别查询各个条件")
+		case token.upper == "NOT" && (i == 0 || tokens[i-1].upper != "IS"):
+			report(token, "not-condition", LintError, "WHERE 不支持 NOT，取反会被忽略；请改用 != 或拆分条件")
+		case token.text == "<>":
+			report(token, "not-equal-operator", LintError, "不支持 <> 运算符，请改用 !=")
+		case isSelect && token.kind == sqlTokenSymbol && isComparison(token.text):
+			report(token, "comparison-operator", LintError,
+				fmt.Sprintf("SELECT 的 WHERE 中 %s 会按等于比较；需要范围过滤时请通过 GORM 查询或在导出后过滤", token.text))
+		case isSelect && (token.upper == "LIKE" || token.upper == "ILIKE") && i+1 < len(tokens) && tokens[i+1].kind == sqlTokenString:
+			pattern := strings.Trim(tokens[i+1].text, `'"`)
+			switch {
+			case strings.Contains(pattern, "_") || strings.Contains(pattern, `\`):
+				report(token, "like-wildcard", LintWarning,
+					fmt.Sprintf("SELECT 的 %s 只支持 %% 通配符，模式 '%s' 中的 _ 和反斜杠转义按普通字符匹配", token.upper, pattern))
+			case !strings.Contains(pattern, "%"):
+				report(token, "like-without-wildcard", LintWarning,
+					fmt.Sprintf("没有通配符的 %s '%s' 按包含匹配，而不是完全相等；需要完全相等时请使用 =", token.upper, pattern))
+			}
+		}
+	}
+	return issues
+}
+
+// isComparison 判断是否为等于以外的比较运算符
+func isComparison(op string) bool {
+	switch op {
+	case ">", "<", ">=", "<=", "!=":
+		return true
+	}
+	return false
+}
+
+// clauseName 返回子句的完整名称
+func clauseName(keyword string) string {
+	if keyword == "ORDER" || keyword == "GROUP" {
+		return keyword + " BY"
+	}
+	return keyword
+}
+
+// PrintLintIssues 输出检查结果
+// 参数:
+//   - w: 输出目标
+//   - issues: 检查结果
+//   - format: 输出格式，OutputFormatJSON 时输出 JSON 数组，否则逐行输出
+//
+// 返回:
+//   - error: 写入错误
+func PrintLintIssues(w io.Writer, issues []LintIssue, format string) error {
+	if format == OutputFormatJSON {
+		if issues == nil {
+			issues = []LintIssue{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(issues)
+	}
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(w, "✅ 没有发现问题")
+		return err
+	}
+	for _, issue := range issues {
+		icon := "⚠️ "
+		if issue.Severity == LintError {
+			icon = "❌"
+		}
+		if _, err := fmt.Fprintf(w, "%s 语句 %d，%s\n", icon, issue.Statement, issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasLintErrors 判断检查结果中是否有错误级别的问题
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gopkg.in/yaml.v3"
+)
+
+// notifyTimeout 发送任务通知的超时时间
+const notifyTimeout = 10 * time.Second
+
+// NotifyFile 任务通知配置文件结构
+// 按角色声明通知发送到的飞书群机器人，例如：
+//
+//	profiles:
+//	  default:
+//	    webhook: https://open.feishu.cn/open-apis/bot/v2/hook/<token>
+//	    secret: <签名密钥>
+//	    jobs: [seed, validate]
+//	    on_success: true
+//	    failure_threshold: 10
+type NotifyFile struct {
+	Profiles map[string]*NotifyConfig `yaml:"profiles" json:"profiles"` // 角色名到通知配置的映射
+}
+
+// NotifyConfig 单个角色的任务通知配置
+// 任务出错，或失败数达到阈值时发送告警；成功通知需要通过 on_success 开启
+type NotifyConfig struct {
+	Webhook          string   `yaml:"webhook" json:"webhook"`                                         // 飞书群机器人 webhook 地址
+	Secret           string   `yaml:"secret,omitempty" json:"secret,omitempty"`                       // 签名密钥，为空时读取环境变量 BASESQL_FEISHU_BOT_SECRET
+	Jobs             []string `yaml:"jobs,omitempty" json:"jobs,omitempty"`                           // 需要通知的任务，为空表示全部
+	OnSuccess        bool     `yaml:"on_success,omitempty" json:"on_success,omitempty"`               // 任务成功时也发送通知
+	FailureThreshold int      `yaml:"failure_threshold,omitempty" json:"failure_threshold,omitempty"` // 失败数达到该值才告警，0 表示有失败即告警
+}
+
+// JobResult 一次任务的执行结果
+type JobResult struct {
+	Job      string        // 任务名，如 seed、dedupe、validate
+	Target   string        // 任务对象，如表名或种子文件路径
+	Summary  string        // 结果摘要
+	Failures int           // 失败数，如未通过校验的记录数
+	Duration time.Duration // 耗时
+	Err      error         // 执行错误
+}
+
+// Notifier 将任务结果以消息卡片发送到飞书群
+type Notifier struct {
+	profile string
+	config  *NotifyConfig
+	bot     *feishuCardWriter
+}
+
+// DefaultNotifyPath 获取默认的任务通知配置文件路径
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultNotifyPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "notify.yaml"), nil
+}
+
+// LoadNotifyFile 读取并校验任务通知配置文件
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 配置文件路径
+//
+// 返回:
+//   - map[string]*NotifyConfig: 角色名到通知配置的映射
+//   - error: 读取或校验错误
+func LoadNotifyFile(path string) (map[string]*NotifyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取任务通知配置文件失败: %w", err)
+	}
+
+	var file NotifyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析任务通知配置文件失败: %w", err)
+	}
+
+	for name, config := range file.Profiles {
+		if config == nil {
+			return nil, fmt.Errorf("角色 '%s' 的通知配置为空", name)
+		}
+		if _, err := parseOutputURL(config.Webhook); err != nil {
+			return nil, fmt.Errorf("角色 '%s' 的 webhook 无效: %w", name, err)
+		}
+		if config.FailureThreshold < 0 {
+			return nil, fmt.Errorf("角色 '%s' 的 failure_threshold 不能为负数", name)
+		}
+	}
+	return file.Profiles, nil
+}
+
+// loadNotifier 加载 CLI 使用的任务通知
+// 指定了配置文件时必须能够读取；未指定时尝试读取默认路径，文件不存在或没有对应角色则不发送通知
+func loadNotifier(path, profile string) (*Notifier, error) {
+	if profile == "" {
+		profile = DefaultPolicyProfile
+	}
+
+	if path == "" {
+		defaultPath, err := DefaultNotifyPath()
+		if err != nil {
+			return nil, nil
+		}
+		if _, err := os.Stat(defaultPath); err != nil {
+			return nil, nil
+		}
+		path = defaultPath
+	}
+
+	profiles, err := LoadNotifyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := profiles[profile]
+	if !ok {
+		return nil, nil
+	}
+	return NewNotifier(profile, config), nil
+}
+
+// NewNotifier 创建任务通知
+// 参数:
+//   - profile: 角色名，显示在消息卡片中
+//   - config: 通知配置
+//
+// 返回:
+//   - *Notifier: 任务通知实例
+func NewNotifier(profile string, config *NotifyConfig) *Notifier {
+	secret := config.Secret
+	if secret == "" {
+		secret = common.GetEnv("BASESQL_FEISHU_BOT_SECRET", "")
+	}
+	return &Notifier{
+		profile: profile,
+		config:  config,
+		bot:     &feishuCardWriter{endpoint: config.Webhook, secret: secret},
+	}
+}
+
+// ShouldNotify 判断任务结果是否需要发送通知
+// 参数:
+//   - result: 任务结果
+//
+// 返回:
+//   - bool: 是否需要发送
+func (n *Notifier) ShouldNotify(result *JobResult) bool {
+	if n == nil {
+		return false
+	}
+	if len(n.config.Jobs) > 0 {
+		matched := false
+		for _, job := range n.config.Jobs {
+			if strings.EqualFold(job, result.Job) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if n.isAlert(result) {
+		return true
+	}
+	return n.config.OnSuccess
+}
+
+// Notify 发送任务结果通知，不需要通知时直接返回
+// 参数:
+//   - ctx: 上下文
+//   - result: 任务结果
+//
+// 返回:
+//   - error: 发送失败时返回错误
+func (n *Notifier) Notify(ctx context.Context, result *JobResult) error {
+	if !n.ShouldNotify(result) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return n.bot.sendCard(ctx, n.buildCard(result))
+}
+
+// isAlert 判断任务结果是否需要告警：执行出错，或失败数达到阈值
+func (n *Notifier) isAlert(result *JobResult) bool {
+	if result.Err != nil {
+		return true
+	}
+	return result.Failures > 0 && result.Failures >= n.config.FailureThreshold
+}
+
+// buildCard 构建任务结果的消息卡片，告警使用红色标题，成功使用绿色标题
+func (n *Notifier) buildCard(result *JobResult) map[string]interface{} {
+	template, title := "green", fmt.Sprintf("BaseSQL 任务完成: %s", result.Job)
+	if n.isAlert(result) {
+		template, title = "red", fmt.Sprintf("BaseSQL 任务告警: %s", result.Job)
+	}
+
+	lines := []string{fmt.Sprintf("**角色**: %s", n.profile)}
+	if result.Target != "" {
+		lines = append(lines, fmt.Sprintf("**对象**: %s", result.Target))
+	}
+	lines = append(lines, fmt.Sprintf("**耗时**: %s", result.Duration.Round(time.Millisecond)))
+	if result.Summary != "" {
+		lines = append(lines, fmt.Sprintf("**结果**: %s", result.Summary))
+	}
+	if result.Failures > 0 {
+		lines = append(lines, fmt.Sprintf("**失败数**: %d", result.Failures))
+	}
+	if result.Err != nil {
+		lines = append(lines, fmt.Sprintf("**错误**: %s", result.Err.Error()))
+	}
+
+	return map[string]interface{}{
+		"config": map[string]interface{}{"wide_screen_mode": true},
+		"header": map[string]interface{}{
+			"template": template,
+			"title":    map[string]interface{}{"tag": "plain_text", "content": title},
+		},
+		"elements": []interface{}{
+			map[string]interface{}{
+				"tag":  "div",
+				"text": map[string]interface{}{"tag": "lark_md", "content": strings.Join(lines, "\n")},
+			},
+		},
+	}
+}
+
+// notifyJob 发送任务结果通知，发送失败只输出警告，不影响任务本身的结果
+func (c *Client) notifyJob(result *JobResult) {
+	if c.notifier == nil {
+		return
+	}
+	if err := c.notifier.Notify(context.Background(), result); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  发送任务通知失败: %v\n", err)
+	}
+}
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// outputHTTPTimeout 输出目标 HTTP 请求的超时时间
+const outputHTTPTimeout = 30 * time.Second
+
+// feishuCardMaxRows 飞书消息卡片中预览的最大记录数
+const feishuCardMaxRows = 10
+
+// OutputWriter 查询结果的输出目标
+// 新的输出目标实现该接口，并通过 RegisterOutputWriter 注册地址前缀
+type OutputWriter interface {
+	// Write 将查询结果发送到输出目标
+	Write(ctx context.Context, result *QueryResult) error
+}
+
+// OutputWriterFactory 根据输出地址创建输出目标，地址包含前缀，如 webhook:https://example.com/hook
+type OutputWriterFactory func(dest string) (OutputWriter, error)
+
+var (
+	outputWritersMu sync.RWMutex
+	outputWriters   = map[string]OutputWriterFactory{
+		"webhook": newWebhookWriter,
+		"feishu":  newFeishuCardWriter,
+		"s3":      newS3Writer,
+		"file":    newFileWriter,
+	}
+)
+
+// RegisterOutputWriter 注册输出目标
+// 参数:
+//   - scheme: 地址前缀（冒号之前的部分），如 kafka
+//   - factory: 创建输出目标的函数
+func RegisterOutputWriter(scheme string, factory OutputWriterFactory) {
+	outputWritersMu.Lock()
+	defer outputWritersMu.Unlock()
+	outputWriters[strings.ToLower(scheme)] = factory
+}
+
+// NewOutputWriter 根据输出地址创建输出目标
+// 参数:
+//   - dest: 输出地址，如 webhook:https://example.com/hook、s3://bucket/key.csv
+//
+// 返回:
+//   - OutputWriter: 输出目标
+//   - error: 地址格式错误或前缀未注册
+func NewOutputWriter(dest string) (OutputWriter, error) {
+	idx := strings.Index(dest, ":")
+	if idx <= 0 {
+		return nil, fmt.Errorf("输出地址格式错误: %s，应为 <类型>:<地址>，如 webhook:https://example.com/hook", dest)
+	}
+	scheme := strings.ToLower(dest[:idx])
+
+	outputWritersMu.RLock()
+	factory, ok := outputWriters[scheme]
+	schemes := make([]string, 0, len(outputWriters))
+	for s := range outputWriters {
+		schemes = append(schemes, s)
+	}
+	outputWritersMu.RUnlock()
+
+	if !ok {
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("不支持的输出类型: %s，可选值: %s", scheme, strings.Join(schemes, "、"))
+	}
+	return factory(dest)
+}
+
+// NewQueryOutput 根据 query 命令的 --output 和 --out 参数创建输出目标
+// --out <路径> 是 --output file:<路径> 的简写，两者写入本地文件时都在扩展名无法确定格式时使用 format
+// 参数:
+//   - output: --output 的值，如 webhook:https://example.com/hook、file:users.csv
+//   - out: --out 的值，本地文件路径
+//   - format: 全局 --format 的值
+//
+// 返回:
+//   - OutputWriter: 输出目标，两个参数都为空时返回 nil
+//   - string: 用于提示的输出地址
+//   - error: 参数冲突或地址无效
+func NewQueryOutput(output, out, format string) (OutputWriter, string, error) {
+	if output != "" && out != "" {
+		return nil, "", fmt.Errorf("--output 和 --out 不能同时使用")
+	}
+	if out != "" {
+		output = "file:" + out
+	}
+	if output == "" {
+		return nil, "", nil
+	}
+	if len(output) >= len("file:") && strings.EqualFold(output[:len("file:")], "file:") {
+		path := output[len("file:"):]
+		w, err := NewFileWriter(path, format)
+		return w, path, err
+	}
+	w, err := NewOutputWriter(output)
+	return w, output, err
+}
+
+// encodeResult 按格式编码查询结果
+// JSON 为对象数组，键顺序与 SELECT 投影一致；CSV 表头使用列名
+// 参数:
+//   - result: 查询结果
+//   - format: 输出格式，json 或 csv
+//
+// 返回:
+//   - []byte: 编码后的内容
+//   - string: 对应的 Content-Type
+//   - error: 编码错误
+func encodeResult(result *QueryResult, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	contentType, err := writeResult(&buf, result, format)
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// writeResult 按格式将查询结果逐行写入 w，编码格式与 encodeResult 相同
+// 参数:
+//   - w: 写入目标
+//   - result: 查询结果
+//   - format: 输出格式，json 或 csv
+//
+// 返回:
+//   - string: 对应的 Content-Type
+//   - error: 编码或写入错误
+func writeResult(w io.Writer, result *QueryResult, format string) (string, error) {
+	switch format {
+	case OutputFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(result.Columns); err != nil {
+			return "", fmt.Errorf("写入 CSV 表头失败: %w", err)
+		}
+		for _, row := range result.Rows {
+			values := make([]string, len(result.Columns))
+			for i, column := range result.Columns {
+				values[i] = formatResultValue(row[column])
+			}
+			if err := writer.Write(values); err != nil {
+				return "", fmt.Errorf("写入 CSV 数据失败: %w", err)
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return "", err
+		}
+		return "text/csv; charset=utf-8", nil
+	case OutputFormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return "", err
+		}
+		// 每条记录编码后立即写入，不在内存中拼接完整结果
+		var buf bytes.Buffer
+		for i, row := range result.Rows {
+			buf.Reset()
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("{")
+			for j, column := range result.Columns {
+				if j > 0 {
+					buf.WriteString(",")
+				}
+				key, err := json.Marshal(column)
+				if err != nil {
+					return "", fmt.Errorf("序列化列名失败: %w", err)
+				}
+				value, err := json.Marshal(row[column])
+				if err != nil {
+					return "", fmt.Errorf("序列化字段 %s 失败: %w", column, err)
+				}
+				buf.Write(key)
+				buf.WriteString(":")
+				buf.Write(value)
+			}
+			buf.WriteString("}")
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return "", err
+			}
+		}
+		if _, err := io.WriteString(w, "]\n"); err != nil {
+			return "", err
+		}
+		return "application/json; charset=utf-8", nil
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s，可选值: json、csv", format)
+	}
+}
+
+// formatResultValue 将查询结果中的字段值格式化为文本
+func formatResultValue(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return common.FormatValue(value)
+}
+
+// postJSON 以 JSON 发送 POST 请求，非 2xx 响应返回错误，成功时返回响应体
+func postJSON(ctx context.Context, endpoint string, body interface{}) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求体失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outputHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// parseOutputURL 解析并校验 HTTP(S) 地址
+func parseOutputURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("无效的 HTTP 地址: %s", raw)
+	}
+	return u.String(), nil
+}
+
+// webhookWriter 以 JSON 将查询结果 POST 到指定地址
+// 请求体与 REST 网关 /query 的响应一致：{"columns": [...], "rows": [...], "row_count": N}
+type webhookWriter struct {
+	endpoint string
+}
+
+// newWebhookWriter 创建 webhook 输出目标，地址格式为 webhook:https://example.com/hook
+func newWebhookWriter(dest string) (OutputWriter, error) {
+	endpoint, err := parseOutputURL(dest[len("webhook:"):])
+	if err != nil {
+		return nil, err
+	}
+	return &webhookWriter{endpoint: endpoint}, nil
+}
+
+// Write 发送查询结果
+func (w *webhookWriter) Write(ctx context.Context, result *QueryResult) error {
+	if _, err := postJSON(ctx, w.endpoint, result); err != nil {
+		return fmt.Errorf("发送 webhook 失败: %w", err)
+	}
+	return nil
+}
+
+// feishuCardWriter 通过飞书群机器人发送消息卡片，卡片中预览前几条记录
+// 机器人启用了签名校验时，通过环境变量 BASESQL_FEISHU_BOT_SECRET 提供签名密钥
+type feishuCardWriter struct {
+	endpoint string
+	secret   string
+}
+
+// newFeishuCardWriter 创建飞书消息卡片输出目标，地址格式为 feishu:https://open.feishu.cn/open-apis/bot/v2/hook/<token>
+func newFeishuCardWriter(dest string) (OutputWriter, error) {
+	endpoint, err := parseOutputURL(dest[len("feishu:"):])
+	if err != nil {
+		return nil, err
+	}
+	return &feishuCardWriter{endpoint: endpoint, secret: common.GetEnv("BASESQL_FEISHU_BOT_SECRET", "")}, nil
+}
+
+// Write 发送消息卡片
+func (w *feishuCardWriter) Write(ctx context.Context, result *QueryResult) error {
+	return w.sendCard(ctx, buildFeishuCard(result))
+}
+
+// sendCard 通过群机器人发送消息卡片，配置了签名密钥时附带签名
+func (w *feishuCardWriter) sendCard(ctx context.Context, card map[string]interface{}) error {
+	message := map[string]interface{}{
+		"msg_type": "interactive",
+		"card":     card,
+	}
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		// 飞书机器人签名：以 timestamp + "\n" + secret 为密钥对空内容做 HmacSHA256
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+w.secret))
+		message["timestamp"] = timestamp
+		message["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	body, err := postJSON(ctx, w.endpoint, message)
+	if err != nil {
+		return fmt.Errorf("发送飞书消息卡片失败: %w", err)
+	}
+
+	// 飞书机器人在 HTTP 200 的响应体中返回业务错误码
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Code != 0 {
+		return fmt.Errorf("发送飞书消息卡片失败: code=%d, msg=%s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// buildFeishuCard 构建查询结果的消息卡片
+func buildFeishuCard(result *QueryResult) map[string]interface{} {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 **%d** 条记录", result.RowCount)
+	if result.RowCount > feishuCardMaxRows {
+		fmt.Fprintf(&sb, "，以下为前 %d 条", feishuCardMaxRows)
+	}
+	for i, row := range result.Rows {
+		if i == feishuCardMaxRows {
+			break
+		}
+		parts := make([]string, len(result.Columns))
+		for j, column := range result.Columns {
+			parts[j] = fmt.Sprintf("**%s**: %s", column, formatResultValue(row[column]))
+		}
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, strings.Join(parts, "，"))
+	}
+
+	return map[string]interface{}{
+		"config": map[string]interface{}{"wide_screen_mode": true},
+		"header": map[string]interface{}{
+			"template": "blue",
+			"title":    map[string]interface{}{"tag": "plain_text", "content": "BaseSQL 查询结果"},
+		},
+		"elements": []interface{}{
+			map[string]interface{}{
+				"tag":  "div",
+				"text": map[string]interface{}{"tag": "lark_md", "content": sb.String()},
+			},
+		},
+	}
+}
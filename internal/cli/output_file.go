@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileWriter 将查询结果写入本地文件
+// 先写入同目录下的临时文件，完成后重命名为目标文件，写入失败时不会留下不完整的文件
+type fileWriter struct {
+	path   string
+	format string
+}
+
+// newFileWriter 创建本地文件输出目标，地址格式为 file:<路径>，格式由扩展名决定
+func newFileWriter(dest string) (OutputWriter, error) {
+	return NewFileWriter(dest[len("file:"):], "")
+}
+
+// NewFileWriter 创建本地文件输出目标
+// 参数:
+//   - path: 文件路径，扩展名为 .csv 时写入 CSV，为 .json 时写入 JSON
+//   - format: 扩展名无法确定格式时使用的输出格式，csv 或 json
+//
+// 返回:
+//   - OutputWriter: 输出目标
+//   - error: 路径为空或无法确定文件格式
+func NewFileWriter(path, format string) (OutputWriter, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("输出文件路径不能为空")
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		format = OutputFormatCSV
+	case ".json":
+		format = OutputFormatJSON
+	default:
+		format = strings.ToLower(format)
+		if format != OutputFormatCSV && format != OutputFormatJSON {
+			return nil, fmt.Errorf("无法确定输出文件 %s 的格式，请使用 .csv 或 .json 扩展名，或通过 --format 指定 csv、json", path)
+		}
+	}
+	return &fileWriter{path: path, format: format}, nil
+}
+
+// Write 将查询结果写入文件
+func (w *fileWriter) Write(ctx context.Context, result *QueryResult) error {
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), "."+filepath.Base(w.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后临时文件已不存在，删除不会生效
+
+	buffered := bufio.NewWriter(tmp)
+	if _, err := writeResult(buffered, result, w.format); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("设置输出文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("保存输出文件失败: %w", err)
+	}
+	return nil
+}
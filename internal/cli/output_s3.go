@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// s3Writer 将查询结果上传到 S3（或兼容 S3 的对象存储）
+// 凭证从环境变量 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、AWS_SESSION_TOKEN 读取，
+// 区域从 AWS_REGION 读取（默认 us-east-1），AWS_ENDPOINT_URL 可以指向 MinIO 等兼容服务
+type s3Writer struct {
+	bucket       string
+	key          string
+	format       string
+	region       string
+	endpoint     string // 服务地址，为空时使用 AWS 的虚拟主机风格地址
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// newS3Writer 创建 S3 输出目标，地址格式为 s3://bucket/key，键以 .csv 结尾时上传 CSV，否则上传 JSON
+func newS3Writer(dest string) (OutputWriter, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("S3 地址格式错误: %s，应为 s3://bucket/key", dest)
+	}
+
+	w := &s3Writer{
+		bucket:       u.Host,
+		key:          strings.TrimPrefix(u.Path, "/"),
+		format:       OutputFormatJSON,
+		region:       common.GetEnv("AWS_REGION", "us-east-1"),
+		endpoint:     strings.TrimSuffix(common.GetEnv("AWS_ENDPOINT_URL", ""), "/"),
+		accessKey:    common.GetEnv("AWS_ACCESS_KEY_ID", ""),
+		secretKey:    common.GetEnv("AWS_SECRET_ACCESS_KEY", ""),
+		sessionToken: common.GetEnv("AWS_SESSION_TOKEN", ""),
+	}
+	if strings.EqualFold(path.Ext(w.key), ".csv") {
+		w.format = OutputFormatCSV
+	}
+	if w.accessKey == "" || w.secretKey == "" {
+		return nil, fmt.Errorf("上传到 S3 需要设置环境变量 AWS_ACCESS_KEY_ID 和 AWS_SECRET_ACCESS_KEY")
+	}
+	return w, nil
+}
+
+// Write 上传查询结果
+func (w *s3Writer) Write(ctx context.Context, result *QueryResult) error {
+	body, contentType, err := encodeResult(result, w.format)
+	if err != nil {
+		return err
+	}
+
+	// 自定义服务地址使用路径风格，AWS 使用虚拟主机风格
+	objectPath := "/" + escapeS3Key(w.key)
+	endpoint := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", w.bucket, w.region)
+	if w.endpoint != "" {
+		endpoint = w.endpoint
+		objectPath = "/" + w.bucket + objectPath
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outputHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+objectPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	w.sign(req, body, time.Now().UTC())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("上传到 S3 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("上传到 S3 失败: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// sign 使用 AWS Signature Version 4 为请求签名
+// 签名包含 Host 和请求中已设置的全部请求头
+func (w *s3Writer) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if w.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", w.sessionToken)
+	}
+
+	canonicalRequest, signedHeaders := s3CanonicalRequest(req, payloadHash)
+	scope := date + "/" + w.region + "/s3/aws4_request"
+	stringToSign := s3StringToSign(amzDate, scope, canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+w.secretKey), date)
+	key = hmacSHA256(key, w.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		w.accessKey, scope, signedHeaders, signature))
+}
+
+// s3CanonicalRequest 构造 SigV4 的规范请求，签名的请求头为 Host 和除 Authorization 外已设置的全部请求头
+// 返回:
+//   - string: 规范请求
+//   - string: 以分号连接的签名请求头名称，按字母顺序排列
+func s3CanonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, header := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" || name == "host" {
+			continue
+		}
+		// 多个值以逗号连接，值两侧的空白去除，中间连续的空白合并为一个空格
+		trimmed := make([]string, len(header))
+		for i, value := range header {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	return strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// s3StringToSign 构造 SigV4 的待签名字符串
+func s3StringToSign(amzDate, scope, canonicalRequest string) string {
+	return strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+}
+
+// escapeS3Key 按 SigV4 的规则转义对象键：除字母、数字和 -_.~ 外全部百分号编码，保留路径分隔符
+func escapeS3Key(key string) string {
+	var sb strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+// sha256Hex 计算 SHA-256 摘要的十六进制表示
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
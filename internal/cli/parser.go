@@ -69,7 +69,7 @@ func identifyCommandType(sql string) common.SQLCommandType {
 	upperSQL := strings.ToUpper(sql)
 
 	switch {
-	case strings.HasPrefix(upperSQL, "SELECT"):
+	case strings.HasPrefix(upperSQL, "SELECT"), strings.HasPrefix(upperSQL, "WITH"):
 		return common.CommandSelect
 	case strings.HasPrefix(upperSQL, "INSERT"):
 		return common.CommandInsert
@@ -128,8 +128,8 @@ func parseShow(sql string, cmd *common.SQLCommand) (*common.SQLCommand, error) {
 }
 
 // parseSelect 解析 SELECT 命令
-// 支持 SELECT fields FROM table [WHERE condition] 语法
-// 支持聚合函数如 COUNT(*), SUM(field), AVG(field) 等
+// 支持 SELECT fields FROM table [WHERE condition] 语法，以及将结果写入文件的 INTO OUTFILE 'path' 子句
+// 支持聚合函数如 COUNT(*), SUM(field), AVG(field) 等，以及 UNION 和 WITH 子句
 // 参数:
 //   - sql: SQL 语句
 //   - cmd: 命令对象
@@ -138,9 +138,54 @@ func parseShow(sql string, cmd *common.SQLCommand) (*common.SQLCommand, error) {
 //   - *SQLCommand: 解析后的命令
 //   - error: 解析错误
 func parseSelect(sql string, cmd *SQLCommand) (*SQLCommand, error) {
-	return common.DefaultSQLParser.ParseSelectSQL(sql, cmd)
+	// INTO OUTFILE 'path' 可以写在 FROM 之前或语句末尾，解析其余部分前先移除
+	outFile := ""
+	if loc := intoOutfileRe.FindStringSubmatchIndex(sql); loc != nil {
+		for i := 2; i < len(loc); i += 2 {
+			if loc[i] >= 0 {
+				outFile = sql[loc[i]:loc[i+1]]
+			}
+		}
+		if strings.TrimSpace(outFile) == "" {
+			return nil, fmt.Errorf("INTO OUTFILE 的文件路径不能为空")
+		}
+		sql = sql[:loc[0]] + sql[loc[1]:]
+	}
+
+	// WITH 子句中的各个查询分别解析，执行时先物化到内存中
+	if strings.HasPrefix(strings.ToUpper(sql), "WITH") {
+		with, rest, err := parseWith(sql)
+		if err != nil {
+			return nil, err
+		}
+		if cmd, err = parseSelect(rest, cmd); err != nil {
+			return nil, err
+		}
+		cmd.With = with
+		cmd.OutFile = outFile
+		return cmd, nil
+	}
+
+	// 以 UNION、UNION ALL 连接的多条 SELECT 分别解析，执行时合并结果
+	if parts, all := splitUnion(sql); len(parts) > 1 {
+		if err := parseUnion(parts, all, cmd); err != nil {
+			return nil, err
+		}
+		cmd.OutFile = outFile
+		return cmd, nil
+	}
+
+	cmd, err := common.DefaultSQLParser.ParseSelectSQL(sql, cmd)
+	if err != nil {
+		return nil, err
+	}
+	cmd.OutFile = outFile
+	return cmd, nil
 }
 
+// intoOutfileRe 匹配 SELECT 中的 INTO OUTFILE 'path' 子句，路径可以使用单引号或双引号
+var intoOutfileRe = regexp.MustCompile(`(?i)\s+INTO\s+OUTFILE\s+(?:'([^']*)'|"([^"]*)")`)
+
 // parseInsert 解析 INSERT 命令
 // 支持 INSERT INTO table (fields) VALUES (values) 语法
 // 参数:
@@ -178,7 +223,7 @@ func parseUpdate(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 //   - error: 解析错误
 func parseDelete(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 	// DELETE FROM table [WHERE condition]
-	re := regexp.MustCompile(`(?i)DELETE\s+FROM\s+([^\s;]+)(?:\s+WHERE\s+(.*))?	`)
+	re := regexp.MustCompile(`(?i)DELETE\s+FROM\s+([^\s;]+)(?:\s+WHERE\s+(.*))?`)
 	matches := re.FindStringSubmatch(sql)
 
 	if len(matches) < 2 {
@@ -298,9 +343,8 @@ func parseValue(valueStr string) (interface{}, error) {
 	}
 
 	// 移除首尾引号
-	if (strings.HasPrefix(valueStr, "'") && strings.HasSuffix(valueStr, "'")) ||
-		(strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"")) {
-		return valueStr[1 : len(valueStr)-1], nil
+	if unquoted, ok := common.UnquoteSQLString(valueStr); ok {
+		return unquoted, nil
 	}
 
 	// 尝试解析为数字
@@ -373,24 +417,24 @@ func parseWhereClause(whereClause string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("WHERE 条件不能为空")
 	}
 
-	// 支持多种操作符：=, LIKE, >, <, >=, <=, !=
-	// 优先匹配 LIKE 操作符（不区分大小写）
-	likeRe := regexp.MustCompile(`(?i)([^\s]+)\s+LIKE\s+(.+)`)
+	// 支持多种操作符：=, LIKE, ILIKE, >, <, >=, <=, !=
+	// 优先匹配 LIKE、ILIKE 操作符（关键字不区分大小写）
+	likeRe := regexp.MustCompile(`(?i)([^\s]+)\s+(I?LIKE)\s+(.+)`)
 	likeMatches := likeRe.FindStringSubmatch(whereClause)
 
-	if len(likeMatches) >= 3 {
+	if len(likeMatches) >= 4 {
 		field := strings.TrimSpace(likeMatches[1])
-		valueStr := strings.TrimSpace(likeMatches[2])
+		valueStr := strings.TrimSpace(likeMatches[3])
 
 		value, err := parseValue(valueStr)
 		if err != nil {
 			return nil, fmt.Errorf("解析 WHERE 条件值失败: %w", err)
 		}
 
-		// 为 LIKE 操作添加特殊标记
+		// 为 LIKE、ILIKE 操作添加特殊标记
 		return map[string]interface{}{
 			field:                value,
-			"_operator_" + field: "LIKE",
+			"_operator_" + field: strings.ToUpper(likeMatches[2]),
 		}, nil
 	}
 
@@ -418,5 +462,5 @@ func parseWhereClause(whereClause string) (map[string]interface{}, error) {
 	}
 
 	// 如果都不匹配，返回错误
-	return nil, fmt.Errorf("WHERE 条件格式错误，支持的格式: field = value, field LIKE 'pattern', field > value, field < value, field >= value, field <= value, field != value")
+	return nil, fmt.Errorf("WHERE 条件格式错误，支持的格式: field = value, field LIKE 'pattern', field ILIKE 'pattern', field > value, field < value, field >= value, field <= value, field != value")
 }
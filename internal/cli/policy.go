@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPolicyDenied 语句被当前角色的语句策略拒绝
+var ErrPolicyDenied = errors.New("statement denied by policy")
+
+// DefaultPolicyProfile 未指定角色时使用的角色名，策略文件中没有该角色时不启用策略
+const DefaultPolicyProfile = "default"
+
+// policyWildcard 匹配任意语句类型或表名
+const policyWildcard = "*"
+
+// policyStatements 策略中可以使用的语句类型
+var policyStatements = map[common.SQLCommandType]bool{
+	common.CommandSelect:   true,
+	common.CommandInsert:   true,
+	common.CommandUpdate:   true,
+	common.CommandDelete:   true,
+	common.CommandCreate:   true,
+	common.CommandDrop:     true,
+	common.CommandShow:     true,
+	common.CommandDescribe: true,
+}
+
+// PolicyFile 语句策略文件结构
+// 按角色声明允许和禁止的语句类型与表，例如：
+//
+//	profiles:
+//	  analyst:
+//	    allow:
+//	      - statements: [SELECT, SHOW, DESCRIBE]
+//	      - statements: [UPDATE]
+//	        tables: [tasks]
+//	    deny:
+//	      - statements: [DROP]
+//	    slow_query_threshold: 2s
+type PolicyFile struct {
+	Profiles map[string]*Policy `yaml:"profiles" json:"profiles"` // 角色名到策略的映射
+}
+
+// Policy 单个角色的语句策略
+// 语句需要匹配至少一条 allow 规则（allow 为空时视为全部允许），且不匹配任何 deny 规则
+type Policy struct {
+	Allow []PolicyRule `yaml:"allow" json:"allow"` // 允许规则
+	Deny  []PolicyRule `yaml:"deny" json:"deny"`   // 禁止规则，优先于允许规则
+
+	// SlowQueryThreshold 该角色的慢查询阈值，语句耗时超过时输出耗时并记录慢查询日志，0 表示使用默认值
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold,omitempty" json:"slow_query_threshold,omitempty"`
+}
+
+// PolicyRule 策略规则
+type PolicyRule struct {
+	Statements []string `yaml:"statements" json:"statements"`             // 语句类型，如 SELECT、UPDATE，* 表示全部
+	Tables     []string `yaml:"tables,omitempty" json:"tables,omitempty"` // 表名，为空或 * 表示全部
+}
+
+// DefaultPolicyPath 获取默认的语句策略文件路径
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultPolicyPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "policy.yaml"), nil
+}
+
+// LoadPolicyFile 读取并校验语句策略文件
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 策略文件路径
+//
+// 返回:
+//   - map[string]*Policy: 角色名到策略的映射
+//   - error: 读取或校验错误
+func LoadPolicyFile(path string) (map[string]*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取语句策略文件失败: %w", err)
+	}
+
+	var file PolicyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析语句策略文件失败: %w", err)
+	}
+
+	for name, policy := range file.Profiles {
+		if policy == nil {
+			return nil, fmt.Errorf("角色 '%s' 的策略为空", name)
+		}
+		if policy.SlowQueryThreshold < 0 {
+			return nil, fmt.Errorf("角色 '%s' 的 slow_query_threshold 不能为负数", name)
+		}
+		for _, rules := range [][]PolicyRule{policy.Allow, policy.Deny} {
+			for i, rule := range rules {
+				if len(rule.Statements) == 0 {
+					return nil, fmt.Errorf("角色 '%s' 的第 %d 条规则缺少 statements", name, i+1)
+				}
+				for _, stmt := range rule.Statements {
+					if stmt != policyWildcard && !policyStatements[common.SQLCommandType(strings.ToUpper(stmt))] {
+						return nil, fmt.Errorf("角色 '%s' 的规则包含不支持的语句类型 '%s'", name, stmt)
+					}
+				}
+			}
+		}
+	}
+	return file.Profiles, nil
+}
+
+// loadPolicy 加载 CLI 使用的语句策略
+// 指定了角色时策略文件必须存在且包含该角色；未指定角色时使用 default 角色，文件或角色不存在则不启用策略
+func loadPolicy(path, profile string) (*Policy, error) {
+	explicit := profile != ""
+	if !explicit {
+		profile = DefaultPolicyProfile
+	}
+
+	if path == "" {
+		defaultPath, err := DefaultPolicyPath()
+		if err != nil {
+			if explicit {
+				return nil, err
+			}
+			return nil, nil
+		}
+		if _, err := os.Stat(defaultPath); err != nil {
+			if explicit {
+				return nil, fmt.Errorf("指定了角色 '%s'，但语句策略文件 %s 不存在", profile, defaultPath)
+			}
+			return nil, nil
+		}
+		path = defaultPath
+	}
+
+	profiles, err := LoadPolicyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy, ok := profiles[profile]
+	if !ok && explicit {
+		return nil, fmt.Errorf("语句策略文件 %s 中没有角色 '%s'", path, profile)
+	}
+	return policy, nil
+}
+
+// Check 检查语句是否被策略允许
+// 参数:
+//   - statement: 语句类型
+//   - table: 语句操作的表名，SHOW TABLES 等不针对单个表的语句为空
+//
+// 返回:
+//   - error: 不允许时返回包装 ErrPolicyDenied 的错误
+func (p *Policy) Check(statement common.SQLCommandType, table string) error {
+	if p == nil {
+		return nil
+	}
+
+	target := string(statement)
+	if table != "" {
+		target += " " + table
+	}
+	for _, rule := range p.Deny {
+		if rule.matches(statement, table) {
+			return fmt.Errorf("%w: 禁止执行 %s", ErrPolicyDenied, target)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.Allow {
+		if rule.matches(statement, table) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 不允许执行 %s", ErrPolicyDenied, target)
+}
+
+// matches 判断规则是否匹配语句
+// 限定了表的规则不匹配 SHOW TABLES 等不针对单个表的语句
+func (r PolicyRule) matches(statement common.SQLCommandType, table string) bool {
+	matched := false
+	for _, stmt := range r.Statements {
+		if stmt == policyWildcard || strings.EqualFold(stmt, string(statement)) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+
+	if len(r.Tables) == 0 {
+		return true
+	}
+	for _, t := range r.Tables {
+		if t == policyWildcard || (table != "" && strings.EqualFold(t, table)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// profileDistinctLimit 每个字段最多跟踪的不同值数量，超过后只报告下限，避免大表占用过多内存
+const profileDistinctLimit = 10000
+
+// DefaultProfileTopN 选项字段默认报告的高频值数量
+const DefaultProfileTopN = 5
+
+// ValueCount 值及其出现次数
+type ValueCount struct {
+	Value string `json:"value"` // 值
+	Count int    `json:"count"` // 出现次数
+}
+
+// FieldProfile 单个字段的统计信息
+type FieldProfile struct {
+	Name     string       `json:"name"`             // 字段名
+	Type     string       `json:"type"`             // 字段类型
+	Filled   int          `json:"filled"`           // 非空记录数
+	FillRate float64      `json:"fill_rate"`        // 填充率（0-1）
+	Distinct int          `json:"distinct"`         // 不同值数量
+	Capped   bool         `json:"capped,omitempty"` // 不同值数量超过跟踪上限，Distinct 为下限
+	Min      string       `json:"min,omitempty"`    // 最小值（数字和日期字段）
+	Max      string       `json:"max,omitempty"`    // 最大值（数字和日期字段）
+	Mean     *float64     `json:"mean,omitempty"`   // 平均值（数字字段）
+	TopN     []ValueCount `json:"top,omitempty"`    // 高频值（选项字段）
+
+	// 扫描过程中的累计值
+	kind     profileKind    // 统计方式
+	distinct map[string]int // 各值的出现次数
+	sum      float64        // 数值之和
+	numCount int            // 数值个数
+	min, max float64        // 数值范围
+}
+
+// TableProfile 表的统计信息
+type TableProfile struct {
+	Table   string          `json:"table"`   // 表名
+	Records int             `json:"records"` // 记录数
+	Fields  []*FieldProfile `json:"fields"`  // 各字段的统计信息，按字段顺序排列
+}
+
+// profileKind 字段的统计方式
+type profileKind int
+
+const (
+	profileKindGeneric profileKind = iota // 只统计填充率和不同值
+	profileKindNumber                     // 额外统计最小值、最大值和平均值
+	profileKindDate                       // 额外统计日期范围
+	profileKindSelect                     // 额外统计高频值
+)
+
+// profileKindOf 根据字段类型确定统计方式
+func profileKindOf(fieldType basesql.FieldType) profileKind {
+	switch fieldType {
+	case basesql.FieldTypeNumber, basesql.FieldTypeCurrency, basesql.FieldTypeProgress, basesql.FieldTypeRating:
+		return profileKindNumber
+	case basesql.FieldTypeDate, basesql.FieldTypeCreatedTime, basesql.FieldTypeModifiedTime:
+		return profileKindDate
+	case basesql.FieldTypeSingleSelect, basesql.FieldTypeMultiSelect:
+		return profileKindSelect
+	default:
+		return profileKindGeneric
+	}
+}
+
+// Profile 扫描表并输出各字段的统计信息
+// 参数:
+//   - table: 表名
+//   - topN: 选项字段报告的高频值数量
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Profile(table string, topN int) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	profile, err := c.executor.Profile(table, topN)
+	if err != nil {
+		return err
+	}
+
+	if c.config.Format == OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(profile)
+	}
+
+	t := c.executor.newTable("field", "type", "fill_rate", "distinct", "min", "max", "mean", "top")
+	for _, fp := range profile.Fields {
+		distinct := fmt.Sprintf("%d", fp.Distinct)
+		if fp.Capped {
+			distinct += "+"
+		}
+		mean := ""
+		if fp.Mean != nil {
+			mean = strconv.FormatFloat(*fp.Mean, 'f', 2, 64)
+		}
+		top := make([]string, len(fp.TopN))
+		for i, vc := range fp.TopN {
+			top[i] = fmt.Sprintf("%s(%d)", vc.Value, vc.Count)
+		}
+		t.AppendRow(fp.Name, fp.Type, fmt.Sprintf("%.1f%%", fp.FillRate*100), distinct, fp.Min, fp.Max, mean, strings.Join(top, ", "))
+	}
+	c.executor.printTable(t)
+	fmt.Printf("\n共 %d 条记录，%d 个字段\n", profile.Records, len(profile.Fields))
+	return nil
+}
+
+// Profile 逐页扫描表中的记录并统计各字段
+// 基于 forEachRecord 流式处理，不在内存中保留全部记录
+// 参数:
+//   - table: 表名
+//   - topN: 选项字段报告的高频值数量
+//
+// 返回:
+//   - *TableProfile: 统计信息
+//   - error: 错误信息
+func (e *Executor) Profile(table string, topN int) (*TableProfile, error) {
+	if topN <= 0 {
+		topN = DefaultProfileTopN
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &TableProfile{Table: table, Fields: make([]*FieldProfile, 0, len(fields))}
+	for _, field := range fields {
+		profile.Fields = append(profile.Fields, &FieldProfile{
+			Name:     field.FieldName,
+			Type:     getFieldTypeString(field.Type),
+			kind:     profileKindOf(field.Type),
+			distinct: make(map[string]int),
+			min:      math.Inf(1),
+			max:      math.Inf(-1),
+		})
+	}
+
+	err = e.forEachRecord(ctx, tableID, func(record basesql.Record) error {
+		profile.Records++
+		for _, fp := range profile.Fields {
+			fp.observe(record.Fields[fp.Name])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fp := range profile.Fields {
+		fp.finish(profile.Records, topN)
+	}
+
+	// 缓存统计结果，之后的查询按字段统计排列 WHERE 条件
+	if e.profiles != nil {
+		if err := e.profiles.Save(e.appToken, profile); err != nil {
+			common.Warnf("缓存统计结果失败: %v", err)
+		}
+	}
+	return profile, nil
+}
+
+// observe 累计一个字段值
+func (fp *FieldProfile) observe(value interface{}) {
+	text := common.FormatValue(value)
+	if text == "" {
+		return
+	}
+	fp.Filled++
+
+	// 多选字段的每个选项分别计数，其余字段按整体值计数
+	items := []string{text}
+	if fp.kind == profileKindSelect {
+		items = selectItems(value)
+	}
+	for _, item := range items {
+		if _, ok := fp.distinct[item]; ok || len(fp.distinct) < profileDistinctLimit {
+			fp.distinct[item]++
+		} else {
+			fp.Capped = true
+		}
+	}
+
+	if fp.kind == profileKindNumber || fp.kind == profileKindDate {
+		if n, ok := value.(float64); ok {
+			fp.sum += n
+			fp.numCount++
+			fp.min = math.Min(fp.min, n)
+			fp.max = math.Max(fp.max, n)
+		}
+	}
+}
+
+// finish 计算最终的统计结果
+func (fp *FieldProfile) finish(records, topN int) {
+	if records > 0 {
+		fp.FillRate = float64(fp.Filled) / float64(records)
+	}
+	fp.Distinct = len(fp.distinct)
+
+	if fp.numCount > 0 {
+		switch fp.kind {
+		case profileKindNumber:
+			fp.Min = strconv.FormatFloat(fp.min, 'f', -1, 64)
+			fp.Max = strconv.FormatFloat(fp.max, 'f', -1, 64)
+			mean := fp.sum / float64(fp.numCount)
+			fp.Mean = &mean
+		case profileKindDate:
+			fp.Min = time.UnixMilli(int64(fp.min)).Format("2006-01-02 15:04:05")
+			fp.Max = time.UnixMilli(int64(fp.max)).Format("2006-01-02 15:04:05")
+		}
+	}
+
+	if fp.kind == profileKindSelect {
+		counts := make([]ValueCount, 0, len(fp.distinct))
+		for value, count := range fp.distinct {
+			counts = append(counts, ValueCount{Value: value, Count: count})
+		}
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Value < counts[j].Value
+		})
+		if len(counts) > topN {
+			counts = counts[:topN]
+		}
+		fp.TopN = counts
+	}
+	fp.distinct = nil
+}
+
+// selectItems 获取选项字段的各个选项
+func selectItems(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return []string{common.FormatValue(value)}
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		if text := common.FormatValue(item); text != "" {
+			items = append(items, text)
+		}
+	}
+	return items
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// ProfileCache profile 命令统计结果的缓存
+// 查询时按缓存的字段统计估计 WHERE 条件的选择性，先计算过滤掉记录最多的条件；
+// 统计结果只影响条件的计算顺序，表中的数据变化后不影响查询结果，重新执行 profile 即可更新
+type ProfileCache struct {
+	path     string                    // 缓存文件路径
+	mutex    sync.Mutex                // 保证并发读写安全
+	profiles map[string]*CachedProfile // 已读取的缓存，键见 profileCacheKey，首次使用时从文件读取
+}
+
+// CachedProfile 缓存的表统计信息
+type CachedProfile struct {
+	ProfiledAt time.Time     `json:"profiled_at"` // 统计时间
+	Profile    *TableProfile `json:"profile"`     // 统计信息
+}
+
+// DefaultProfileCachePath 获取默认的统计缓存文件路径 ~/.basesql/profiles.json
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultProfileCachePath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "profiles.json"), nil
+}
+
+// NewProfileCache 创建统计缓存
+// 参数:
+//   - path: 缓存文件路径，不存在时在首次保存时创建
+//
+// 返回:
+//   - *ProfileCache: 统计缓存实例
+func NewProfileCache(path string) *ProfileCache {
+	return &ProfileCache{path: path}
+}
+
+// profileCacheKey 缓存的键，不同多维表格中的同名表分别缓存
+func profileCacheKey(appToken, table string) string {
+	return appToken + "/" + table
+}
+
+// load 首次使用时读取缓存文件，调用方需要持有锁
+func (pc *ProfileCache) load() error {
+	if pc.profiles != nil {
+		return nil
+	}
+	pc.profiles = make(map[string]*CachedProfile)
+	data, err := os.ReadFile(pc.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取统计缓存失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &pc.profiles); err != nil {
+		return fmt.Errorf("解析统计缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Save 保存表的统计信息，覆盖同一张表之前的统计
+// 参数:
+//   - appToken: 多维表格的 App Token
+//   - profile: 统计信息
+//
+// 返回:
+//   - error: 读取或写入缓存文件失败
+func (pc *ProfileCache) Save(appToken string, profile *TableProfile) error {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	if err := pc.load(); err != nil {
+		return err
+	}
+	pc.profiles[profileCacheKey(appToken, profile.Table)] = &CachedProfile{ProfiledAt: time.Now(), Profile: profile}
+
+	data, err := json.MarshalIndent(pc.profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化统计缓存失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(pc.path), 0700); err != nil {
+		return fmt.Errorf("创建统计缓存目录失败: %w", err)
+	}
+	if err := os.WriteFile(pc.path, data, 0600); err != nil {
+		return fmt.Errorf("写入统计缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Get 获取表的统计信息
+// 参数:
+//   - appToken: 多维表格的 App Token
+//   - table: 表名
+//
+// 返回:
+//   - *CachedProfile: 缓存的统计信息，没有缓存或缓存文件无法读取时返回 nil
+func (pc *ProfileCache) Get(appToken, table string) *CachedProfile {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	if err := pc.load(); err != nil {
+		return nil
+	}
+	return pc.profiles[profileCacheKey(appToken, table)]
+}
+
+// columnStats 将表的统计信息转换为查询引擎的字段统计，键为字段名
+func (p *TableProfile) columnStats() map[string]queryengine.ColumnStats {
+	stats := make(map[string]queryengine.ColumnStats, len(p.Fields))
+	for _, fp := range p.Fields {
+		top := make(map[string]int, len(fp.TopN))
+		for _, vc := range fp.TopN {
+			top[vc.Value] = vc.Count
+		}
+		stats[fp.Name] = queryengine.ColumnStats{Records: p.Records, Filled: fp.Filled, Distinct: fp.Distinct, Top: top}
+	}
+	return stats
+}
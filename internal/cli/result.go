@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// 查询结果的输出格式
+const (
+	OutputFormatTable = "table" // 表格（默认）
+	OutputFormatJSON  = "json"  // JSON 数组
+	OutputFormatCSV   = "csv"   // CSV
+)
+
+// ResultColumn 查询结果中的一列，见 queryengine.Column
+type ResultColumn = queryengine.Column
+
+// QueryResult 结构化的查询结果，供 REST 网关等非终端调用方使用
+type QueryResult struct {
+	Columns  []string                 `json:"columns"`   // 列名，顺序与 SELECT 投影一致
+	Rows     []map[string]interface{} `json:"rows"`      // 记录，键为列名
+	RowCount int                      `json:"row_count"` // 记录数
+}
+
+// newQueryResult 按结果列构建结构化查询结果，字段值转换为对应的 Go 类型
+func newQueryResult(columns []ResultColumn, records []basesql.Record) *QueryResult {
+	result := &QueryResult{
+		Columns:  columnLabels(columns),
+		Rows:     make([]map[string]interface{}, 0, len(records)),
+		RowCount: len(records),
+	}
+	for _, record := range records {
+		result.Rows = append(result.Rows, newResultRow(columns, record))
+	}
+	return result
+}
+
+// newResultRow 按结果列将一条记录转换为结果行，键为列名
+func newResultRow(columns []ResultColumn, record basesql.Record) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		var value interface{}
+		if raw := column.Value(record); raw != nil {
+			value = queryengine.GoValue(column.Field, raw)
+		}
+		row[column.Label] = value
+	}
+	return row
+}
+
+// ValidateOutputFormat 校验输出格式
+// 参数:
+//   - format: 输出格式，为空时视为表格
+//
+// 返回:
+//   - error: 不支持的格式
+func ValidateOutputFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", OutputFormatTable, OutputFormatJSON, OutputFormatCSV:
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，可选值: table、json、csv", format)
+	}
+}
+
+// columnLabels 获取结果列的显示名称列表
+func columnLabels(columns []ResultColumn) []string {
+	labels := make([]string, len(columns))
+	for i, column := range columns {
+		labels[i] = column.Label
+	}
+	return labels
+}
+
+// streamSampleRows 流式输出表格时计算列宽使用的样本行数，与一页记录数相同
+const streamSampleRows = 500
+
+// resultWriter 逐条输出查询结果，不在内存中保留全部记录
+type resultWriter interface {
+	// WriteRecord 输出一条记录
+	WriteRecord(record basesql.Record) error
+	// Close 结束输出，没有记录时提示查询结果为空
+	Close() error
+	// Count 返回已输出的记录数
+	Count() int
+}
+
+// newResultWriter 按配置的输出格式创建结果写入器
+// 参数:
+//   - w: 输出目标
+//   - columns: 结果列
+//
+// 返回:
+//   - resultWriter: 结果写入器
+func (e *Executor) newResultWriter(w io.Writer, columns []ResultColumn) resultWriter {
+	switch strings.ToLower(e.format) {
+	case OutputFormatJSON:
+		return &jsonResultWriter{w: bufio.NewWriter(w), columns: columns}
+	case OutputFormatCSV:
+		return &csvResultWriter{out: w, w: csv.NewWriter(w), columns: columns}
+	default:
+		return &tableResultWriter{w: w, columns: columns, table: e.newTable(columnLabels(columns)...).Stream(w, streamSampleRows, e.vertical)}
+	}
+}
+
+// renderResult 按配置的输出格式渲染查询结果
+// 参数:
+//   - columns: 结果列
+//   - records: 记录列表
+//
+// 返回:
+//   - error: 渲染错误信息
+func (e *Executor) renderResult(columns []ResultColumn, records []basesql.Record) error {
+	writer := e.newResultWriter(os.Stdout, columns)
+	for _, record := range records {
+		if err := writer.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// tableResultWriter 以表格输出查询结果，列的顺序与显示名称由 SELECT 投影决定
+// 列宽按前 streamSampleRows 条记录计算，之后的记录逐条输出
+type tableResultWriter struct {
+	w       io.Writer
+	columns []ResultColumn
+	table   *common.TableWriter
+}
+
+// WriteRecord 输出一条记录
+func (t *tableResultWriter) WriteRecord(record basesql.Record) error {
+	cells := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		if value := column.Value(record); value != nil {
+			cells[i] = formatCell(column.Field, value)
+		}
+	}
+	return t.table.AppendRow(cells...)
+}
+
+// formatCell 格式化表格中的单元格
+// 未自定义范围的进度字段显示为百分比，小数位数与字段的格式一致；评分字段显示为星级；
+// 超链接字段显示文本和链接地址
+func formatCell(field *basesql.Field, value interface{}) string {
+	switch field.Type {
+	case basesql.FieldTypeURL:
+		if link, ok := basesql.ParseLink(value); ok {
+			return link.String()
+		}
+	case basesql.FieldTypeProgress:
+		n, ok := field.ConvertToGoValue(value).(float64)
+		if lower, upper, _ := field.ValueRange(); ok && lower == 0 && upper == 1 {
+			return strconv.FormatFloat(n*100, 'f', formatterPlaces(field), 64) + "%"
+		}
+	case basesql.FieldTypeRating:
+		n, ok := field.ConvertToGoValue(value).(float64)
+		_, upper, _ := field.ValueRange()
+		if stars := int(math.Round(n)); ok && stars >= 0 && stars <= int(upper) {
+			return strings.Repeat("★", stars) + strings.Repeat("☆", int(upper)-stars)
+		}
+	}
+	return common.FormatValue(value)
+}
+
+// formatterPlaces 获取数字字段格式中的小数位数，如 "0.00%" 为 2
+func formatterPlaces(field *basesql.Field) int {
+	formatter, _ := field.Property["formatter"].(string)
+	if i := strings.Index(formatter, "."); i >= 0 {
+		return strings.Count(formatter[i+1:], "0")
+	}
+	return 0
+}
+
+// Close 输出表格底部和返回的行数
+func (t *tableResultWriter) Close() error {
+	if t.table.Count() == 0 {
+		_, err := fmt.Fprintf(t.w, "📭 查询结果为空\n")
+		return err
+	}
+	if err := t.table.Close(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(t.w, "\n📊 查询返回 %d 行数据\n", t.table.Count())
+	return err
+}
+
+// Count 返回已输出的记录数
+func (t *tableResultWriter) Count() int {
+	return t.table.Count()
+}
+
+// jsonResultWriter 以 JSON 数组输出查询结果，对象的键顺序与 SELECT 投影一致
+type jsonResultWriter struct {
+	w       *bufio.Writer
+	columns []ResultColumn
+	count   int
+}
+
+// WriteRecord 输出一条记录
+func (j *jsonResultWriter) WriteRecord(record basesql.Record) error {
+	var buf bytes.Buffer
+	if j.count == 0 {
+		buf.WriteString("[")
+	} else {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\n  {")
+	for i, column := range j.columns {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		key, err := json.Marshal(column.Label)
+		if err != nil {
+			return fmt.Errorf("序列化列名失败: %w", err)
+		}
+		var value interface{}
+		if raw := column.Value(record); raw != nil {
+			value = queryengine.GoValue(column.Field, raw)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("序列化字段 %s 失败: %w", column.Label, err)
+		}
+		buf.Write(key)
+		buf.WriteString(": ")
+		buf.Write(data)
+	}
+	buf.WriteString("}")
+	j.count++
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+// Close 结束 JSON 数组
+func (j *jsonResultWriter) Close() error {
+	if j.count == 0 {
+		if _, err := j.w.WriteString("📭 查询结果为空\n"); err != nil {
+			return err
+		}
+		return j.w.Flush()
+	}
+	if _, err := j.w.WriteString("\n]\n"); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
+
+// Count 返回已输出的记录数
+func (j *jsonResultWriter) Count() int {
+	return j.count
+}
+
+// csvResultWriter 以 CSV 输出查询结果，表头使用列的显示名称
+type csvResultWriter struct {
+	out     io.Writer
+	w       *csv.Writer
+	columns []ResultColumn
+	count   int
+}
+
+// WriteRecord 输出一条记录，第一条记录前输出表头
+func (c *csvResultWriter) WriteRecord(record basesql.Record) error {
+	if c.count == 0 {
+		if err := c.w.Write(columnLabels(c.columns)); err != nil {
+			return fmt.Errorf("写入 CSV 表头失败: %w", err)
+		}
+	}
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		row[i] = common.FormatValue(column.Value(record))
+	}
+	if err := c.w.Write(row); err != nil {
+		return fmt.Errorf("写入 CSV 数据失败: %w", err)
+	}
+	c.count++
+	return nil
+}
+
+// Close 刷新缓冲的 CSV 内容
+func (c *csvResultWriter) Close() error {
+	if c.count == 0 {
+		_, err := fmt.Fprintf(c.out, "📭 查询结果为空\n")
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Count 返回已输出的记录数
+func (c *csvResultWriter) Count() int {
+	return c.count
+}
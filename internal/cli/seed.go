@@ -0,0 +1,473 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"gopkg.in/yaml.v3"
+)
+
+// SeedFile 种子数据文件结构
+// 以声明式的方式描述需要初始化的表、字段和记录
+type SeedFile struct {
+	Tables []*SeedTable `yaml:"tables" json:"tables"` // 需要初始化的表列表
+}
+
+// SeedTable 种子数据中的表定义
+type SeedTable struct {
+	Name    string                   `yaml:"name" json:"name"`       // 表名
+	Key     string                   `yaml:"key" json:"key"`         // 用于匹配已有记录的键字段，为空时只插入
+	Fields  []*SeedField             `yaml:"fields" json:"fields"`   // 字段定义
+	Records []map[string]interface{} `yaml:"records" json:"records"` // 记录列表
+}
+
+// SeedField 种子数据中的字段定义
+type SeedField struct {
+	Name        string `yaml:"name" json:"name"`                                   // 字段名
+	Type        string `yaml:"type" json:"type"`                                   // 字段类型，如 text、number、date
+	Description string `yaml:"description,omitempty" json:"description,omitempty"` // 字段描述
+}
+
+// SeedResult 种子数据执行结果统计
+type SeedResult struct {
+	TablesCreated  int // 新建的表数量
+	FieldsCreated  int // 新建的字段数量
+	RecordsCreated int // 新插入的记录数量
+	RecordsUpdated int // 更新的记录数量
+	RecordsSkipped int // 无变化而跳过的记录数量
+}
+
+// seedFieldTypes 种子文件中字段类型名称到飞书字段类型的映射
+var seedFieldTypes = map[string]basesql.FieldType{
+	"text":          basesql.FieldTypeText,
+	"number":        basesql.FieldTypeNumber,
+	"single_select": basesql.FieldTypeSingleSelect,
+	"multi_select":  basesql.FieldTypeMultiSelect,
+	"date":          basesql.FieldTypeDate,
+	"checkbox":      basesql.FieldTypeCheckbox,
+	"user":          basesql.FieldTypeUser,
+	"phone":         basesql.FieldTypePhone,
+	"url":           basesql.FieldTypeURL,
+	"attachment":    basesql.FieldTypeAttachment,
+	"barcode":       basesql.FieldTypeBarcode,
+	"progress":      basesql.FieldTypeProgress,
+	"currency":      basesql.FieldTypeCurrency,
+	"rating":        basesql.FieldTypeRating,
+}
+
+// LoadSeedFile 读取并校验种子数据文件
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 种子文件路径
+//
+// 返回:
+//   - *SeedFile: 解析后的种子数据
+//   - error: 读取或校验错误
+func LoadSeedFile(path string) (*SeedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子文件失败: %w", err)
+	}
+
+	var seed SeedFile
+	if err := yaml.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("解析种子文件失败: %w", err)
+	}
+
+	if err := seed.Validate(); err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+// Validate 校验种子数据定义的完整性
+// 返回:
+//   - error: 校验错误
+func (s *SeedFile) Validate() error {
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("种子文件中没有定义任何表")
+	}
+
+	seen := make(map[string]bool)
+	for i, table := range s.Tables {
+		if table == nil || table.Name == "" {
+			return fmt.Errorf("第 %d 个表缺少名称", i+1)
+		}
+		if seen[table.Name] {
+			return fmt.Errorf("表 '%s' 重复定义", table.Name)
+		}
+		seen[table.Name] = true
+
+		fieldNames := make(map[string]bool)
+		for _, field := range table.Fields {
+			if field == nil || field.Name == "" {
+				return fmt.Errorf("表 '%s' 中存在缺少名称的字段", table.Name)
+			}
+			if _, err := parseSeedFieldType(field.Type); err != nil {
+				return fmt.Errorf("表 '%s' 字段 '%s': %w", table.Name, field.Name, err)
+			}
+			fieldNames[field.Name] = true
+		}
+
+		if table.Key != "" && len(table.Fields) > 0 && !fieldNames[table.Key] {
+			return fmt.Errorf("表 '%s' 的键字段 '%s' 未在字段列表中定义", table.Name, table.Key)
+		}
+		for j, record := range table.Records {
+			if table.Key != "" {
+				if _, ok := record[table.Key]; !ok {
+					return fmt.Errorf("表 '%s' 第 %d 条记录缺少键字段 '%s'", table.Name, j+1, table.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseSeedFieldType 解析种子文件中的字段类型名称
+// 参数:
+//   - name: 字段类型名称，为空时默认为 text
+//
+// 返回:
+//   - basesql.FieldType: 飞书字段类型
+//   - error: 不支持的类型
+func parseSeedFieldType(name string) (basesql.FieldType, error) {
+	if name == "" {
+		return basesql.FieldTypeText, nil
+	}
+	fieldType, ok := seedFieldTypes[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("不支持的字段类型: %s", name)
+	}
+	return fieldType, nil
+}
+
+// Seed 根据种子文件初始化表、字段和记录
+// 重复执行是幂等的：已存在的表和字段会被复用，记录按键字段匹配后更新
+// 参数:
+//   - path: 种子文件路径
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Seed(path string) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	seed, err := LoadSeedFile(path)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	result, err := c.executor.Seed(seed)
+	job := &JobResult{Job: "seed", Target: path, Err: err}
+	if result != nil {
+		job.Summary = fmt.Sprintf("新建表 %d 个，新建字段 %d 个，插入记录 %d 条，更新记录 %d 条，跳过记录 %d 条",
+			result.TablesCreated, result.FieldsCreated, result.RecordsCreated,
+			result.RecordsUpdated, result.RecordsSkipped)
+		fmt.Printf("📊 %s\n", job.Summary)
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Seed 执行种子数据初始化
+// 参数:
+//   - seed: 已校验的种子数据
+//
+// 返回:
+//   - *SeedResult: 执行结果统计（出错时为已完成部分的统计）
+//   - error: 错误信息
+func (e *Executor) Seed(seed *SeedFile) (*SeedResult, error) {
+	if err := e.config.CheckWritable("seed"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	result := &SeedResult{}
+
+	tables, err := e.getTableList(ctx)
+	if err != nil {
+		return result, fmt.Errorf("获取表列表失败: %w", err)
+	}
+	candidates := make([]*basesql.Table, len(tables))
+	for i := range tables {
+		candidates[i] = &tables[i]
+	}
+	// 按种子文件中的表名查找已有的表，同名的表不止一张时无法确定写入哪一张
+	tableIDs := make(map[string]string, len(seed.Tables))
+	for _, table := range seed.Tables {
+		existing, err := basesql.ResolveTable(candidates, table.Name)
+		switch {
+		case err == nil:
+			tableIDs[table.Name] = existing.TableID
+		case !errors.Is(err, basesql.ErrTableNotFound):
+			return result, err
+		}
+	}
+
+	// 写入前按语句策略检查全部表，避免只初始化了一部分
+	for _, table := range seed.Tables {
+		if err := e.checkSeedPolicy(table, tableIDs); err != nil {
+			return result, err
+		}
+	}
+
+	for _, table := range seed.Tables {
+		fmt.Printf("🌱 初始化表: %s\n", table.Name)
+		if err := e.seedTable(ctx, table, tableIDs, result); err != nil {
+			return result, fmt.Errorf("初始化表 '%s' 失败: %w", table.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// checkSeedPolicy 检查初始化单个表需要的语句是否被策略允许
+// 表不存在时需要 CREATE，写入记录需要 INSERT，按键更新已有记录还需要 UPDATE
+// 已有表缺少字段时需要的 CREATE 在补齐字段前检查
+func (e *Executor) checkSeedPolicy(table *SeedTable, tableIDs map[string]string) error {
+	if _, exists := tableIDs[table.Name]; !exists {
+		if err := e.policy.Check(common.CommandCreate, table.Name); err != nil {
+			return err
+		}
+	}
+	if len(table.Records) == 0 {
+		return nil
+	}
+	if err := e.policy.Check(common.CommandInsert, table.Name); err != nil {
+		return err
+	}
+	if table.Key != "" {
+		return e.policy.Check(common.CommandUpdate, table.Name)
+	}
+	return nil
+}
+
+// seedTable 初始化单个表，包括表结构和记录
+func (e *Executor) seedTable(ctx context.Context, table *SeedTable, tableIDs map[string]string, result *SeedResult) error {
+	tableID, exists := tableIDs[table.Name]
+	if !exists {
+		id, err := e.createSeedTable(ctx, table)
+		if err != nil {
+			return err
+		}
+		tableID = id
+		tableIDs[table.Name] = id
+		result.TablesCreated++
+	}
+
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return err
+	}
+
+	// 补齐缺失的字段
+	existing := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		existing[field.FieldName] = true
+	}
+	added := false
+	for _, field := range table.Fields {
+		if existing[field.Name] {
+			continue
+		}
+		if err := e.policy.Check(common.CommandCreate, table.Name); err != nil {
+			return err
+		}
+		if err := e.createSeedField(ctx, tableID, field); err != nil {
+			return err
+		}
+		result.FieldsCreated++
+		added = true
+	}
+	if added {
+		if fields, err = e.getFieldsList(ctx, tableID); err != nil {
+			return err
+		}
+	}
+
+	if len(table.Records) == 0 {
+		return nil
+	}
+	return e.seedRecords(ctx, table, tableID, fields, result)
+}
+
+// createSeedTable 创建种子文件中定义的表
+func (e *Executor) createSeedTable(ctx context.Context, table *SeedTable) (string, error) {
+	req := &basesql.CreateTableRequest{
+		Table: &basesql.TableRequest{
+			Name:   table.Name,
+			Fields: make([]*basesql.CreateFieldRequest, 0, len(table.Fields)),
+		},
+	}
+	for _, field := range table.Fields {
+		fieldType, _ := parseSeedFieldType(field.Type)
+		req.Table.Fields = append(req.Table.Fields, &basesql.CreateFieldRequest{
+			FieldName:   field.Name,
+			Type:        fieldType,
+			Description: field.Description,
+		})
+	}
+
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", e.appToken),
+		Body:   req,
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建表失败: %w", err)
+	}
+
+	var apiResp struct {
+		Code int                          `json:"code"`
+		Msg  string                       `json:"msg"`
+		Data *basesql.CreateTableResponse `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return "", fmt.Errorf("解析创建表响应失败: %w", err)
+	}
+	if apiResp.Code != 0 || apiResp.Data == nil || !apiResp.Data.IsSuccess() {
+		return "", fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+
+	fmt.Printf("  ✅ 创建表 '%s'\n", table.Name)
+	return apiResp.Data.TableID, nil
+}
+
+// createSeedField 为已存在的表添加字段
+func (e *Executor) createSeedField(ctx context.Context, tableID string, field *SeedField) error {
+	fieldType, _ := parseSeedFieldType(field.Type)
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", e.appToken, tableID),
+		Body: &basesql.CreateFieldRequest{
+			FieldName:   field.Name,
+			Type:        fieldType,
+			Description: field.Description,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("创建字段 '%s' 失败: %w", field.Name, err)
+	}
+	if err := checkAPIResponse(resp.Body); err != nil {
+		return fmt.Errorf("创建字段 '%s' 失败: %w", field.Name, err)
+	}
+
+	fmt.Printf("  ✅ 添加字段 '%s'\n", field.Name)
+	return nil
+}
+
+// seedRecords 按键字段插入或更新记录
+func (e *Executor) seedRecords(ctx context.Context, table *SeedTable, tableID string, fields []basesql.Field, result *SeedResult) error {
+	fieldMap := make(map[string]*basesql.Field, len(fields))
+	for i := range fields {
+		fieldMap[fields[i].FieldName] = &fields[i]
+	}
+
+	// 按键字段值索引已有记录
+	existing := make(map[string]basesql.Record)
+	if table.Key != "" {
+		records, err := e.getRecords(ctx, tableID)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			key := common.FormatValue(record.Fields[table.Key])
+			if key != "" {
+				existing[key] = record
+			}
+		}
+	}
+
+	for i, values := range table.Records {
+		payload := make(map[string]interface{}, len(values))
+		for name, value := range values {
+			field, ok := fieldMap[name]
+			if !ok {
+				return fmt.Errorf("第 %d 条记录引用了不存在的字段 '%s'", i+1, name)
+			}
+			if field.IsReadOnly() {
+				return fmt.Errorf("第 %d 条记录不能写入只读字段 '%s'", i+1, name)
+			}
+			payload[name] = field.ConvertFromGoValue(value)
+		}
+
+		if table.Key != "" {
+			if record, ok := existing[common.FormatValue(values[table.Key])]; ok {
+				if seedRecordUnchanged(record, values) {
+					result.RecordsSkipped++
+					continue
+				}
+				if err := e.writeSeedRecord(ctx, "PUT",
+					fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", e.appToken, tableID, record.RecordID),
+					payload); err != nil {
+					return fmt.Errorf("更新第 %d 条记录失败: %w", i+1, err)
+				}
+				result.RecordsUpdated++
+				continue
+			}
+		}
+
+		if err := e.writeSeedRecord(ctx, "POST",
+			fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", e.appToken, tableID),
+			payload); err != nil {
+			return fmt.Errorf("插入第 %d 条记录失败: %w", i+1, err)
+		}
+		result.RecordsCreated++
+	}
+	return nil
+}
+
+// writeSeedRecord 发送单条记录的创建或更新请求
+func (e *Executor) writeSeedRecord(ctx context.Context, method, path string, fields map[string]interface{}) error {
+	resp, err := e.client.DoRequest(ctx, &basesql.APIRequest{
+		Method: method,
+		Path:   path,
+		Body:   &basesql.CreateRecordRequest{Fields: fields},
+	})
+	if err != nil {
+		return err
+	}
+	return checkAPIResponse(resp.Body)
+}
+
+// seedRecordUnchanged 判断已有记录是否与种子记录一致
+// 以显示值进行比较，避免因 API 返回格式差异导致重复更新
+func seedRecordUnchanged(record basesql.Record, values map[string]interface{}) bool {
+	for name, value := range values {
+		if common.FormatValue(record.Fields[name]) != common.FormatValue(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAPIResponse 检查飞书 API 响应体中的业务错误码
+// 参数:
+//   - body: 响应体
+//
+// 返回:
+//   - error: 业务错误
+func checkAPIResponse(body []byte) error {
+	var apiResp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// maxServerRequestBytes 单个请求体的最大字节数
+const maxServerRequestBytes = 1 << 20
+
+// ServerRequest REST 网关的请求体
+type ServerRequest struct {
+	SQL    string                 `json:"sql"`              // SQL 语句，可以包含 :name 形式的命名参数
+	Params map[string]interface{} `json:"params,omitempty"` // 命名参数的值
+}
+
+// ServerExecResponse POST /exec 的响应体
+type ServerExecResponse struct {
+	RowsAffected int64 `json:"rows_affected"` // 影响的行数
+}
+
+// ServerErrorResponse 请求失败时的响应体
+type ServerErrorResponse struct {
+	Error   string `json:"error"`              // 错误信息
+	TraceID string `json:"trace_id,omitempty"` // 请求的追踪 ID，与响应头 X-Request-Id 相同
+}
+
+// Server REST 网关
+// 通过 HTTP 提供 SQL 查询和执行能力，所有请求共享同一个客户端（访问令牌、表结构缓存和限流器）
+type Server struct {
+	client *Client // 共享的 CLI 客户端
+	token  string  // 访问令牌，请求需要携带 Authorization: Bearer <token>
+}
+
+// NewServer 创建 REST 网关
+// 参数:
+//   - client: CLI 客户端
+//   - token: 访问令牌，不能为空
+//
+// 返回:
+//   - *Server: 网关实例
+//   - error: 参数错误
+func NewServer(client *Client, token string) (*Server, error) {
+	if client == nil || client.db == nil {
+		return nil, fmt.Errorf("客户端未初始化")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("访问令牌不能为空")
+	}
+	return &Server{client: client, token: token}, nil
+}
+
+// Handler 获取网关的 HTTP 处理器
+//   - POST /query: 执行 SELECT，返回列名和记录
+//   - POST /exec: 执行 INSERT、UPDATE、DELETE、CREATE、DROP，返回影响的行数
+//   - GET /status: 熔断器状态、稳定性组件和请求统计
+//   - POST /circuit-breaker/trip、POST /circuit-breaker/reset: 手动开启或关闭熔断，返回操作后的状态
+//   - POST /schema/refresh: 清空记住的表 ID、字段 ID 和表结构缓存，返回操作后的状态
+//   - GET /healthz: 健康检查，不需要令牌
+//
+// 返回:
+//   - http.Handler: HTTP 处理器
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query", s.authorize(http.MethodPost, s.handleQuery))
+	mux.HandleFunc("/exec", s.authorize(http.MethodPost, s.handleExec))
+	mux.HandleFunc("/status", s.authorize(http.MethodGet, s.handleStatus))
+	mux.HandleFunc("/circuit-breaker/"+BreakerActionTrip, s.authorize(http.MethodPost, s.handleCircuitBreaker(BreakerActionTrip)))
+	mux.HandleFunc("/circuit-breaker/"+BreakerActionReset, s.authorize(http.MethodPost, s.handleCircuitBreaker(BreakerActionReset)))
+	mux.HandleFunc("/schema/refresh", s.authorize(http.MethodPost, s.handleRefreshSchema))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeServerJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+// ListenAndServe 在指定地址上启动网关，ctx 取消后优雅关闭
+// 参数:
+//   - ctx: 上下文
+//   - addr: 监听地址，如 ":8080"
+//
+// 返回:
+//   - error: 启动或关闭错误
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// authorize 为请求设置追踪 ID，并校验请求方法和访问令牌
+func (s *Server) authorize(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 沿用调用方的 X-Request-Id 作为追踪 ID，没有时生成新的，并在响应头中返回
+		traceID := r.Header.Get("X-Request-Id")
+		if !common.ValidTraceID(traceID) {
+			var err error
+			if traceID, err = common.NewTraceID(); err != nil {
+				writeServerError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		w.Header().Set("X-Request-Id", traceID)
+		r = r.WithContext(common.ContextWithTraceID(r.Context(), traceID))
+
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeServerError(w, http.StatusMethodNotAllowed, fmt.Errorf("只支持 %s 请求", method))
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeServerError(w, http.StatusUnauthorized, fmt.Errorf("访问令牌无效"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleStatus 处理 GET /status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	report, err := s.client.Status()
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServerJSON(w, http.StatusOK, report)
+}
+
+// handleCircuitBreaker 处理 POST /circuit-breaker/trip 和 POST /circuit-breaker/reset
+func (s *Server) handleCircuitBreaker(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.client.ControlCircuitBreaker(action); err != nil {
+			writeServerError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.handleStatus(w, r)
+	}
+}
+
+// handleRefreshSchema 处理 POST /schema/refresh
+func (s *Server) handleRefreshSchema(w http.ResponseWriter, r *http.Request) {
+	if err := s.client.RefreshSchema(); err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.handleStatus(w, r)
+}
+
+// handleQuery 处理 POST /query
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	cmd, sql, ok := s.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	if cmd.Type != common.CommandSelect {
+		writeServerError(w, http.StatusBadRequest, fmt.Errorf("/query 只支持 SELECT 语句，不支持 %s", cmd.Type))
+		return
+	}
+
+	executor, err := s.client.newExecutor()
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	start := time.Now()
+	result, err := executor.Query(r.Context(), cmd)
+	common.LogSQLExecution(r.Context(), sql, time.Since(start), err)
+	if err != nil {
+		writeServerError(w, serverErrorStatus(err), err)
+		return
+	}
+	writeServerJSON(w, http.StatusOK, result)
+}
+
+// handleExec 处理 POST /exec
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	cmd, sql, ok := s.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	if !isExecCommand(cmd.Type) {
+		writeServerError(w, http.StatusBadRequest, fmt.Errorf("/exec 只支持 INSERT、UPDATE、DELETE、CREATE、DROP 语句，不支持 %s", cmd.Type))
+		return
+	}
+
+	executor, err := s.client.newExecutor()
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	start := time.Now()
+	affected, err := executor.Exec(r.Context(), cmd)
+	common.LogSQLExecution(r.Context(), sql, time.Since(start), err)
+	if err != nil {
+		writeServerError(w, serverErrorStatus(err), err)
+		return
+	}
+	writeServerJSON(w, http.StatusOK, ServerExecResponse{RowsAffected: affected})
+}
+
+// parseRequest 读取请求体、绑定参数并解析 SQL，失败时直接写入错误响应
+func (s *Server) parseRequest(w http.ResponseWriter, r *http.Request) (*common.SQLCommand, string, bool) {
+	var req ServerRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxServerRequestBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		writeServerError(w, http.StatusBadRequest, fmt.Errorf("解析请求体失败: %w", err))
+		return nil, "", false
+	}
+
+	cmd, sql, err := prepareStatement(req.SQL, req.Params)
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, err)
+		return nil, "", false
+	}
+	return cmd, sql, true
+}
+
+// prepareStatement 绑定命名参数并解析 SQL，REST 网关和 gRPC 服务共用
+// 参数:
+//   - sql: SQL 语句
+//   - params: 参数名到参数值的映射
+//
+// 返回:
+//   - *common.SQLCommand: 解析后的 SQL 命令
+//   - string: 绑定参数后的 SQL 语句
+//   - error: SQL 为空、参数错误或解析失败
+func prepareStatement(sql string, params map[string]interface{}) (*common.SQLCommand, string, error) {
+	if strings.TrimSpace(sql) == "" {
+		return nil, "", fmt.Errorf("sql 不能为空")
+	}
+
+	bound, err := BindParams(sql, params)
+	if err != nil {
+		return nil, "", err
+	}
+	cmd, err := ParseSQL(bound)
+	if err != nil {
+		return nil, "", err
+	}
+	return cmd, bound, nil
+}
+
+// BindParams 使用参数替换 SQL 中的 :name 占位符
+// 与 SQL 片段不同，参数按 JSON 类型转换为字面量：字符串总是加引号并转义，数字和布尔值保持原样，null 转换为 NULL
+// 参数:
+//   - sql: SQL 语句
+//   - params: 参数名到参数值的映射
+//
+// 返回:
+//   - string: 替换后的 SQL 语句
+//   - error: 缺少参数或参数类型不支持时返回错误
+func BindParams(sql string, params map[string]interface{}) (string, error) {
+	var missing, invalid []string
+	seen := make(map[string]bool)
+	bound := scanSnippetParams(sql, func(name string) string {
+		value, ok := params[name]
+		literal, valid := paramLiteral(value)
+		if !ok || !valid {
+			if !seen[name] {
+				seen[name] = true
+				if !ok {
+					missing = append(missing, name)
+				} else {
+					invalid = append(invalid, name)
+				}
+			}
+			return ":" + name
+		}
+		return literal
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("缺少参数: %s", strings.Join(missing, ", "))
+	}
+	if len(invalid) > 0 {
+		return "", fmt.Errorf("参数类型不支持（只支持字符串、数字、布尔值和 null）: %s", strings.Join(invalid, ", "))
+	}
+	return bound, nil
+}
+
+// paramLiteral 将 JSON 参数值转换为 SQL 字面量
+func paramLiteral(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", true
+	case string:
+		return common.QuoteSQLString(v), true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// serverErrorStatus 根据执行错误确定 HTTP 状态码
+func serverErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, basesql.ErrValidationFailed):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, basesql.ErrUniqueViolation):
+		return http.StatusConflict
+	case errors.Is(err, basesql.ErrReadOnly), errors.Is(err, ErrPolicyDenied):
+		return http.StatusForbidden
+	case errors.Is(err, context.Canceled):
+		return 499 // 客户端关闭了连接
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeServerError 写入错误响应
+func writeServerError(w http.ResponseWriter, status int, err error) {
+	writeServerJSON(w, status, ServerErrorResponse{Error: err.Error(), TraceID: w.Header().Get("X-Request-Id")})
+}
+
+// writeServerJSON 写入 JSON 响应
+func writeServerJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
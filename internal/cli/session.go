@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	basesql "github.com/ag9920/basesql"
+)
+
+// SessionInfo 当前连接的多维表格信息，在交互式 Shell 启动时显示
+type SessionInfo struct {
+	BaseName   string   `json:"base_name"`   // 多维表格名称
+	AppToken   string   `json:"app_token"`   // 多维表格 App Token
+	TableCount int      `json:"table_count"` // 数据表数量，无法获取时为 -1
+	AuthType   string   `json:"auth_type"`   // 认证类型: tenant 或 user
+	Scopes     []string `json:"scopes"`      // 应用已开通的权限范围，无法查询时为 nil
+}
+
+// SessionInfo 获取当前连接的多维表格名称、数据表数量、认证类型和已开通的权限范围
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - *SessionInfo: 连接信息
+//   - error: 无法读取多维表格时返回错误；数据表数量和权限范围查询失败不视为错误
+func (c *Client) SessionInfo(ctx context.Context) (*SessionInfo, error) {
+	if c == nil || c.executor == nil {
+		return nil, fmt.Errorf("客户端未初始化")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.executor.timeout)
+	defer cancel()
+
+	client := c.executor.client
+	info := &SessionInfo{
+		AppToken:   c.config.AppToken,
+		TableCount: -1,
+		AuthType:   string(c.executor.config.AuthType),
+	}
+
+	var app struct {
+		App struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	}
+	if err := getFeishuJSON(ctx, client, fmt.Sprintf("/bitable/v1/apps/%s", c.config.AppToken), nil, &app); err != nil {
+		return nil, fmt.Errorf("读取多维表格信息失败: %w", err)
+	}
+	info.BaseName = app.App.Name
+
+	var tables basesql.ListTablesResponse
+	if err := getFeishuJSON(ctx, client, fmt.Sprintf("/bitable/v1/apps/%s/tables", c.config.AppToken),
+		map[string]string{"page_size": "1"}, &tables); err == nil {
+		info.TableCount = tables.Total
+	}
+
+	info.Scopes = client.GrantedScopes(ctx)
+	return info, nil
+}
+
+// Prompt 获取交互式 Shell 的提示符，如 "mybase> "，设置了默认表时为 "mybase/tasks> "
+// 参数:
+//   - table: USE 设置的默认表，未设置时为空
+//
+// 返回:
+//   - string: 提示符
+func (s *SessionInfo) Prompt(table string) string {
+	name := "basesql"
+	if s != nil {
+		if baseName := strings.Join(strings.Fields(s.BaseName), "_"); baseName != "" {
+			name = baseName
+		}
+	}
+	if table != "" {
+		name += "/" + table
+	}
+	return name + "> "
+}
+
+// PrintSessionInfo 输出连接信息
+// 参数:
+//   - info: 连接信息
+func PrintSessionInfo(info *SessionInfo) {
+	fmt.Printf("📦 多维表格: %s (%s)\n", info.BaseName, info.AppToken)
+	if info.TableCount >= 0 {
+		fmt.Printf("📋 数据表: %d 个\n", info.TableCount)
+	} else {
+		fmt.Println("📋 数据表: 未知")
+	}
+
+	authType := "应用身份 (tenant_access_token)"
+	if info.AuthType == string(basesql.AuthTypeUser) {
+		authType = "用户身份 (user_access_token)"
+	}
+	fmt.Printf("🔑 认证方式: %s\n", authType)
+
+	switch {
+	case info.Scopes == nil:
+		fmt.Println("🛡️  权限范围: 无法查询（应用未开通应用信息读取权限）")
+	case len(info.Scopes) == 0:
+		fmt.Println("🛡️  权限范围: 无")
+	default:
+		// 只列出与多维表格相关的权限范围，其余只显示数量
+		var related []string
+		for _, scope := range info.Scopes {
+			if strings.HasPrefix(scope, "bitable:") || strings.HasPrefix(scope, "base:") || strings.HasPrefix(scope, "drive:") {
+				related = append(related, scope)
+			}
+		}
+		line := "无多维表格相关权限"
+		if len(related) > 0 {
+			line = strings.Join(related, ", ")
+		}
+		if others := len(info.Scopes) - len(related); others > 0 {
+			line += fmt.Sprintf("（另有 %d 项其他权限）", others)
+		}
+		fmt.Printf("🛡️  权限范围: %s\n", line)
+	}
+}
+
+// getFeishuJSON 发送 GET 请求并解析响应的 data 字段
+func getFeishuJSON(ctx context.Context, client *basesql.Client, path string, query map[string]string, data interface{}) error {
+	resp, err := client.DoRequest(ctx, &basesql.APIRequest{Method: "GET", Path: path, QueryParams: query})
+	if err != nil {
+		return err
+	}
+	return decodeFeishuResponse(resp.Body, data)
+}
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// snippetExt 片段文件扩展名
+const snippetExt = ".sql"
+
+// snippetNameRe 片段名称格式：字母、数字、下划线、连字符或中文
+var snippetNameRe = regexp.MustCompile(`^[\p{Han}A-Za-z0-9_\-]+$`)
+
+// Snippet 已保存的 SQL 片段
+// SQL 中可以使用 :name 形式的命名参数，执行时通过 name=value 替换
+type Snippet struct {
+	Name string // 片段名称
+	SQL  string // SQL 语句
+}
+
+// SnippetStore 片段存储
+// 每个片段保存为目录下的一个 <name>.sql 文件，便于直接查看和编辑
+type SnippetStore struct {
+	dir string // 存储目录
+}
+
+// DefaultSnippetDir 获取默认的片段存储目录 ~/.basesql/snippets
+// 返回:
+//   - string: 目录路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultSnippetDir() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "snippets"), nil
+}
+
+// NewSnippetStore 创建片段存储
+// 参数:
+//   - dir: 存储目录，不存在时在首次保存时创建
+//
+// 返回:
+//   - *SnippetStore: 片段存储实例
+func NewSnippetStore(dir string) *SnippetStore {
+	return &SnippetStore{dir: dir}
+}
+
+// Save 保存片段，同名片段会被覆盖
+// 参数:
+//   - name: 片段名称
+//   - sql: SQL 语句
+//
+// 返回:
+//   - error: 保存错误信息
+func (s *SnippetStore) Save(name, sql string) error {
+	if err := validateSnippetName(name); err != nil {
+		return err
+	}
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return fmt.Errorf("片段 '%s' 的 SQL 语句不能为空", name)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建片段目录失败: %w", err)
+	}
+	if err := os.WriteFile(s.path(name), []byte(sql+"\n"), 0600); err != nil {
+		return fmt.Errorf("保存片段失败: %w", err)
+	}
+	return nil
+}
+
+// Get 获取片段
+// 参数:
+//   - name: 片段名称
+//
+// 返回:
+//   - *Snippet: 片段
+//   - error: 片段不存在或读取失败
+func (s *SnippetStore) Get(name string) (*Snippet, error) {
+	if err := validateSnippetName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("片段 '%s' 不存在，使用 \\list 查看已保存的片段", name)
+		}
+		return nil, fmt.Errorf("读取片段失败: %w", err)
+	}
+	return &Snippet{Name: name, SQL: strings.TrimSpace(string(data))}, nil
+}
+
+// List 列出所有片段，按名称排序
+// 返回:
+//   - []Snippet: 片段列表，目录不存在时为空
+//   - error: 读取错误信息
+func (s *SnippetStore) List() ([]Snippet, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取片段目录失败: %w", err)
+	}
+
+	snippets := make([]Snippet, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != snippetExt {
+			continue
+		}
+		snippet, err := s.Get(strings.TrimSuffix(entry.Name(), snippetExt))
+		if err != nil {
+			continue
+		}
+		snippets = append(snippets, *snippet)
+	}
+	sort.Slice(snippets, func(i, j int) bool { return snippets[i].Name < snippets[j].Name })
+	return snippets, nil
+}
+
+// path 获取片段文件路径
+func (s *SnippetStore) path(name string) string {
+	return filepath.Join(s.dir, name+snippetExt)
+}
+
+// validateSnippetName 校验片段名称
+func validateSnippetName(name string) error {
+	if !snippetNameRe.MatchString(name) {
+		return fmt.Errorf("无效的片段名称 '%s'，只能包含字母、数字、下划线、连字符或中文", name)
+	}
+	return nil
+}
+
+// Params 获取片段中使用的命名参数，按首次出现的顺序返回
+func (sn *Snippet) Params() []string {
+	var params []string
+	seen := make(map[string]bool)
+	scanSnippetParams(sn.SQL, func(name string) string {
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+		return ":" + name
+	})
+	return params
+}
+
+// Render 使用参数替换片段中的 :name 占位符
+// 字符串参数会被加上单引号并转义，数字和布尔值保持原样；引号内的内容不做替换
+// 参数:
+//   - params: 参数名到参数值的映射
+//
+// 返回:
+//   - string: 替换后的 SQL 语句
+//   - error: 缺少参数时返回错误
+func (sn *Snippet) Render(params map[string]string) (string, error) {
+	var missing []string
+	seen := make(map[string]bool)
+	sql := scanSnippetParams(sn.SQL, func(name string) string {
+		value, ok := params[name]
+		if !ok {
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+			return ":" + name
+		}
+		return snippetLiteral(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("片段 '%s' 缺少参数: %s", sn.Name, strings.Join(missing, ", "))
+	}
+	return sql, nil
+}
+
+// ParseSnippetParams 解析 name=value 形式的参数列表
+// 参数值可以使用单引号或双引号包裹，以便包含空格
+// 参数:
+//   - args: 参数字符串，如 "owner=张三 status='进行中'"
+//
+// 返回:
+//   - map[string]string: 参数映射
+//   - error: 参数格式错误
+func ParseSnippetParams(args string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, token := range splitShellArgs(args) {
+		name, value, ok := strings.Cut(token, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("无效的参数 '%s'，格式应为 name=value", token)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// scanSnippetParams 扫描引号外的 :name 占位符，并用 replace 的返回值替换
+func scanSnippetParams(sql string, replace func(name string) string) string {
+	var sb strings.Builder
+	runes := []rune(sql)
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if quote != 0 {
+			sb.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			sb.WriteRune(r)
+		case r == ':' && i+1 < len(runes) && isSnippetParamRune(runes[i+1]) && (i == 0 || runes[i-1] != ':'):
+			j := i + 1
+			for j < len(runes) && isSnippetParamRune(runes[j]) {
+				j++
+			}
+			sb.WriteString(replace(string(runes[i+1 : j])))
+			i = j - 1
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// isSnippetParamRune 判断字符是否可以出现在参数名中
+func isSnippetParamRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// snippetLiteral 将参数值转换为 SQL 字面量
+func snippetLiteral(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "null":
+		return value
+	}
+	return common.QuoteSQLString(value)
+}
+
+// splitShellArgs 按空白拆分参数，支持单引号和双引号包裹
+func splitShellArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
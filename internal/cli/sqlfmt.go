@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// sqlClauseKeywords 在语句顶层另起一行的子句关键字
+var sqlClauseKeywords = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "SET": true, "VALUES": true, "UNION": true,
+	"JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true,
+}
+
+// sqlFunctionKeywords 函数名，与括号之间不加空格
+var sqlFunctionKeywords = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+	"COALESCE": true, "IFNULL": true, "NOW": true, "LOWER": true, "UPPER": true, "LENGTH": true,
+}
+
+// FormatSQL 格式化 SQL 语句
+// 关键字统一为大写，空白规范为单个空格，FROM、WHERE、ORDER BY 等子句另起一行，
+// WHERE 和 HAVING 中顶层的 AND、OR 缩进两格另起一行；字符串、标识符的大小写和注释保持不变。
+// 多条语句以分号分隔时逐条格式化，语句之间空一行
+// 参数:
+//   - sql: SQL 文本
+//
+// 返回:
+//   - string: 格式化后的 SQL
+//   - error: 存在无法识别的语句时返回错误
+func FormatSQL(sql string) (string, error) {
+	var formatted []string
+	for _, tokens := range splitStatements(lexSQL(sql)) {
+		text := formatStatement(tokens)
+		code := strings.TrimSuffix(stripComments(text), ";")
+		switch {
+		case code == "" && text != "" && text != ";":
+			// 只有注释的部分（如最后一条语句之后的注释）原样保留
+			formatted = append(formatted, text)
+		case code == "":
+		case !isKnownStatement(code):
+			return "", fmt.Errorf("无法识别的 SQL 语句: %s", strings.TrimSpace(tokensText(tokens)))
+		default:
+			formatted = append(formatted, text)
+		}
+	}
+	if len(formatted) == 0 {
+		return "", fmt.Errorf("SQL 语句不能为空")
+	}
+	return strings.Join(formatted, "\n\n"), nil
+}
+
+// splitStatements 按引号和注释之外的分号切分语句，分号保留在所属语句的末尾
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var statements [][]sqlToken
+	var current []sqlToken
+	for _, token := range tokens {
+		current = append(current, token)
+		if token.kind == sqlTokenSymbol && token.text == ";" {
+			statements = append(statements, current)
+			current = nil
+		}
+	}
+	return append(statements, current)
+}
+
+// formatStatement 格式化单条语句的词法单元
+func formatStatement(tokens []sqlToken) string {
+	var sb strings.Builder
+	var (
+		statement    string // 语句的第一个关键字
+		prev         sqlToken
+		spaceBefore  bool // 原文中当前单元之前是否有空白
+		depth        int  // 括号嵌套深度
+		inCondition  bool // 是否位于 WHERE 或 HAVING 中
+		inBetween    bool // BETWEEN 之后的 AND 不换行
+		unary        bool // 上一个单元是负号，与数字之间不加空格
+		lineStart    = true
+		newLine      = func(indent string) { sb.WriteString("\n" + indent); lineStart = true }
+		writeSpacing = func(token sqlToken) {
+			if lineStart {
+				return
+			}
+			switch {
+			case token.text == "," || token.text == ")" || token.text == ";" || token.text == ".":
+			case prev.text == "(" || prev.text == "." || unary:
+			case token.text == "(" && (sqlFunctionKeywords[strings.ToUpper(prev.text)] || prev.kind == sqlTokenIdent && !spaceBefore):
+			case token.text == "\\" || prev.text == "\\":
+			default:
+				sb.WriteString(" ")
+			}
+		}
+	)
+
+	for _, token := range tokens {
+		if token.kind == sqlTokenSpace {
+			spaceBefore = true
+			continue
+		}
+		upper := strings.ToUpper(token.text)
+		if token.kind == sqlTokenKeyword {
+			token.text = upper
+			if statement == "" {
+				statement = upper
+			}
+		}
+
+		if token.kind == sqlTokenKeyword && depth == 0 && sb.Len() > 0 {
+			switch {
+			case sqlClauseKeywords[upper] && breaksClause(statement, prev, upper):
+				newLine("")
+				inCondition = upper == "WHERE" || upper == "HAVING"
+			case inCondition && upper == "AND" && inBetween:
+				inBetween = false
+			case inCondition && (upper == "AND" || upper == "OR"):
+				newLine("  ")
+			case upper == "BETWEEN":
+				inBetween = true
+			}
+		}
+
+		writeSpacing(token)
+		sb.WriteString(token.text)
+		lineStart = false
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+		}
+		if token.kind == sqlTokenComment {
+			newLine("")
+		}
+		unary = token.text == "-" && (prev.kind == sqlTokenKeyword || prev.kind == sqlTokenSymbol && prev.text != ")")
+		prev, spaceBefore = token, false
+	}
+	return strings.TrimRight(sb.String(), "\n ")
+}
+
+// isKnownStatement 使用 CLI 的语句识别判断是否为支持的语句，避免把任意文本当作 SQL 输出
+func isKnownStatement(sql string) bool {
+	upper := strings.ToUpper(sql)
+	return identifyCommandType(sql) != common.CommandUnknown || strings.HasPrefix(upper, "USE ")
+}
+
+// breaksClause 判断子句关键字是否需要另起一行
+// DELETE FROM、INSERT INTO 的 FROM 不换行，LEFT JOIN 等连接的 JOIN 与前面的 LEFT 同行
+func breaksClause(statement string, prev sqlToken, keyword string) bool {
+	prevUpper := strings.ToUpper(prev.text)
+	switch keyword {
+	case "FROM":
+		return statement == "SELECT"
+	case "JOIN":
+		return prevUpper != "LEFT" && prevUpper != "RIGHT" && prevUpper != "INNER" && prevUpper != "OUTER"
+	case "SET":
+		return statement == "UPDATE"
+	default:
+		return true
+	}
+}
+
+// stripComments 去掉 SQL 文本中的 -- 注释
+func stripComments(sql string) string {
+	var sb strings.Builder
+	for _, token := range lexSQL(sql) {
+		if token.kind != sqlTokenComment {
+			sb.WriteString(token.text)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// tokensText 拼接词法单元的原文
+func tokensText(tokens []sqlToken) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString(token.text)
+	}
+	return sb.String()
+}
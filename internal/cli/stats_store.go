@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// 统计排行的分组方式
+const (
+	StatsByQuery = "query" // 按语句指纹分组，字面量不同的同一条语句归为一组
+	StatsByTable = "table" // 按表分组
+)
+
+// statsSortKeys 汇总统计的排序字段，均按降序排列
+var statsSortKeys = map[string]func(StatsSummary) int64{
+	"total":     func(s StatsSummary) int64 { return int64(s.TotalDuration) },
+	"avg":       func(s StatsSummary) int64 { return int64(s.AvgDuration) },
+	"max":       func(s StatsSummary) int64 { return int64(s.MaxDuration) },
+	"count":     func(s StatsSummary) int64 { return int64(s.Count) },
+	"rows":      func(s StatsSummary) int64 { return s.Rows },
+	"api_calls": func(s StatsSummary) int64 { return s.APICalls },
+}
+
+// StatementStat 一条语句的执行统计
+type StatementStat struct {
+	Time     time.Time     `json:"time"`             // 执行开始时间
+	Session  string        `json:"session"`          // 会话 ID
+	Type     string        `json:"type"`             // 语句类型，如 SELECT、UPDATE
+	Table    string        `json:"table,omitempty"`  // 目标表名，SHOW TABLES 等语句为空
+	SQL      string        `json:"sql"`              // 执行的 SQL 语句
+	Duration time.Duration `json:"duration"`         // 执行耗时
+	Rows     int64         `json:"rows"`             // 返回或影响的行数
+	APICalls int64         `json:"api_calls"`        // 发出的 API 请求数
+	Failed   bool          `json:"failed,omitempty"` // 是否执行失败
+}
+
+// StatsStore 语句执行统计存储
+// 统计以 JSON Lines 格式追加写入文件，每行一条语句，用于长期观察慢查询和访问最多的表
+type StatsStore struct {
+	path  string     // 统计文件路径
+	mutex sync.Mutex // 保证并发追加写入安全
+}
+
+// DefaultStatsPath 获取默认的统计文件路径 ~/.basesql/stats.jsonl
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultStatsPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "stats.jsonl"), nil
+}
+
+// NewStatsStore 创建语句执行统计存储
+// 参数:
+//   - path: 统计文件路径，不存在时在首次写入时创建
+//
+// 返回:
+//   - *StatsStore: 统计存储实例
+func NewStatsStore(path string) *StatsStore {
+	return &StatsStore{path: path}
+}
+
+// Append 追加一条语句的执行统计
+// 参数:
+//   - stat: 执行统计
+//
+// 返回:
+//   - error: 写入错误信息
+func (s *StatsStore) Append(stat StatementStat) error {
+	data, err := json.Marshal(stat)
+	if err != nil {
+		return fmt.Errorf("序列化执行统计失败: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建统计目录失败: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开统计文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入执行统计失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取指定时间之后的执行统计
+// 无法解析的行会被跳过
+// 参数:
+//   - since: 起始时间，零值表示全部
+//
+// 返回:
+//   - []StatementStat: 执行统计，文件不存在时为空
+//   - error: 读取错误信息
+func (s *StatsStore) Load(since time.Time) ([]StatementStat, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开统计文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var stats []StatementStat
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var stat StatementStat
+		if err := json.Unmarshal(scanner.Bytes(), &stat); err != nil {
+			continue
+		}
+		if stat.Time.Before(since) {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取统计文件失败: %w", err)
+	}
+	return stats, nil
+}
+
+// StatsSummary 一组语句的汇总统计
+type StatsSummary struct {
+	Key           string        `json:"key"`            // 语句指纹或表名
+	Count         int           `json:"count"`          // 执行次数
+	Failures      int           `json:"failures"`       // 失败次数
+	TotalDuration time.Duration `json:"total_duration"` // 总耗时
+	AvgDuration   time.Duration `json:"avg_duration"`   // 平均耗时
+	MaxDuration   time.Duration `json:"max_duration"`   // 最长耗时
+	Rows          int64         `json:"rows"`           // 返回或影响的总行数
+	APICalls      int64         `json:"api_calls"`      // API 请求总数
+}
+
+// SummarizeStats 按语句指纹或表分组汇总执行统计
+// 按语句分组时，字符串和数字字面量替换为 ?，关键字统一为大写，WHERE 条件值不同的同一条语句归为一组；
+// 按表分组时跳过没有目标表的语句
+// 参数:
+//   - stats: 执行统计
+//   - by: 分组方式，StatsByQuery 或 StatsByTable
+//   - sortBy: 排序字段，可选 total、avg、max、count、rows、api_calls，按降序排列
+//   - limit: 返回的组数，0 表示全部
+//
+// 返回:
+//   - []StatsSummary: 汇总统计
+//   - error: 分组方式或排序字段无效时返回错误
+func SummarizeStats(stats []StatementStat, by, sortBy string, limit int) ([]StatsSummary, error) {
+	var keyOf func(StatementStat) string
+	switch by {
+	case StatsByQuery:
+		keyOf = func(stat StatementStat) string { return queryFingerprint(stat.SQL) }
+	case StatsByTable:
+		keyOf = func(stat StatementStat) string { return stat.Table }
+	default:
+		return nil, fmt.Errorf("不支持的分组方式 %q，可选 %s、%s", by, StatsByQuery, StatsByTable)
+	}
+	sortKey, ok := statsSortKeys[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("不支持的排序字段 %q，可选 total、avg、max、count、rows、api_calls", sortBy)
+	}
+
+	groups := make(map[string]*StatsSummary)
+	var summaries []*StatsSummary
+	for _, stat := range stats {
+		key := keyOf(stat)
+		if key == "" {
+			continue
+		}
+		summary, ok := groups[key]
+		if !ok {
+			summary = &StatsSummary{Key: key}
+			groups[key] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Count++
+		if stat.Failed {
+			summary.Failures++
+		}
+		summary.TotalDuration += stat.Duration
+		if stat.Duration > summary.MaxDuration {
+			summary.MaxDuration = stat.Duration
+		}
+		summary.Rows += stat.Rows
+		summary.APICalls += stat.APICalls
+	}
+
+	result := make([]StatsSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		summary.AvgDuration = summary.TotalDuration / time.Duration(summary.Count)
+		result = append(result, *summary)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return sortKey(result[i]) > sortKey(result[j])
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// queryFingerprint 生成语句指纹：字面量替换为 ?，关键字大写，空白和注释规范化
+func queryFingerprint(sql string) string {
+	var parts []string
+	for _, token := range lexSQL(sql) {
+		switch token.kind {
+		case sqlTokenSpace, sqlTokenComment:
+		case sqlTokenString, sqlTokenNumber:
+			parts = append(parts, "?")
+		case sqlTokenKeyword:
+			parts = append(parts, strings.ToUpper(token.text))
+		default:
+			parts = append(parts, token.text)
+		}
+	}
+	return strings.TrimSuffix(strings.Join(parts, " "), " ;")
+}
+
+// PrintStatsSummaries 输出汇总统计
+// 参数:
+//   - summaries: 汇总统计
+//   - by: 分组方式，决定第一列的列名
+//   - format: 输出格式，OutputFormatJSON 时输出 JSON，否则输出表格
+//
+// 返回:
+//   - error: 序列化错误
+func PrintStatsSummaries(summaries []StatsSummary, by, format string) error {
+	if format == OutputFormatJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(summaries) == 0 {
+		fmt.Println("📭 没有执行统计")
+		return nil
+	}
+
+	keyHeader := "SQL"
+	if by == StatsByTable {
+		keyHeader = "Table"
+	}
+	table := common.NewTable(keyHeader, "Count", "Total", "Avg", "Max", "Rows", "API Calls", "Failures").SetMaxColumnWidth(80)
+	for _, summary := range summaries {
+		table.AppendRow(
+			summary.Key,
+			fmt.Sprintf("%d", summary.Count),
+			summary.TotalDuration.Round(time.Millisecond).String(),
+			summary.AvgDuration.Round(time.Millisecond).String(),
+			summary.MaxDuration.Round(time.Millisecond).String(),
+			fmt.Sprintf("%d", summary.Rows),
+			fmt.Sprintf("%d", summary.APICalls),
+			fmt.Sprintf("%d", summary.Failures),
+		)
+	}
+	fmt.Print(table.String())
+	return nil
+}
+
+// ParseStatsSince 解析统计的时间范围，如 24h、7d，空字符串表示全部
+// 参数:
+//   - value: 时间范围，支持 Go 的时长写法和以 d 结尾的天数
+//   - now: 当前时间
+//
+// 返回:
+//   - time.Time: 起始时间
+//   - error: 格式无效时返回错误
+func ParseStatsSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	var days int
+	if _, err := fmt.Sscanf(value, "%dd", &days); err == nil && strings.HasSuffix(value, "d") && days > 0 {
+		return now.AddDate(0, 0, -days), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("无效的时间范围 %q，示例: 24h、7d", value)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// 熔断器手动控制操作
+const (
+	// BreakerActionTrip 手动开启熔断
+	BreakerActionTrip = "trip"
+	// BreakerActionReset 手动关闭熔断
+	BreakerActionReset = "reset"
+)
+
+// StatusReport 客户端运行状态
+type StatusReport struct {
+	AppToken       string                 `json:"app_token"`       // 多维表格 App Token
+	CircuitBreaker string                 `json:"circuit_breaker"` // 熔断器状态: CLOSED、OPEN、HALF_OPEN
+	Stability      map[string]interface{} `json:"stability"`       // 熔断器、连接池和限流器的统计信息
+	Requests       basesql.RequestStats   `json:"requests"`        // 累计请求统计
+}
+
+// Status 获取客户端运行状态
+// 返回:
+//   - *StatusReport: 运行状态
+//   - error: 客户端未初始化时返回错误
+func (c *Client) Status() (*StatusReport, error) {
+	if c == nil || c.executor == nil {
+		return nil, fmt.Errorf("客户端未初始化")
+	}
+	client := c.executor.client
+	return &StatusReport{
+		AppToken:       c.config.AppToken,
+		CircuitBreaker: client.CircuitBreakerState().String(),
+		Stability:      client.GetStabilityStats(),
+		Requests:       client.RequestStats(),
+	}, nil
+}
+
+// ControlCircuitBreaker 手动开启或关闭熔断
+// 参数:
+//   - action: BreakerActionTrip 或 BreakerActionReset
+//
+// 返回:
+//   - error: 操作不支持或客户端未初始化时返回错误
+func (c *Client) ControlCircuitBreaker(action string) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+	switch action {
+	case BreakerActionTrip:
+		c.executor.client.TripCircuitBreaker()
+	case BreakerActionReset:
+		c.executor.client.ResetCircuitBreaker()
+	default:
+		return fmt.Errorf("不支持的熔断器操作 '%s'，可选值: %s, %s", action, BreakerActionTrip, BreakerActionReset)
+	}
+	return nil
+}
+
+// RefreshSchema 清空客户端记住的表 ID、字段 ID 和表结构缓存，之后按名称重新解析
+// 返回:
+//   - error: 客户端未初始化时返回错误
+func (c *Client) RefreshSchema() error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+	c.executor.client.RefreshSchema()
+	return nil
+}
+
+// PrintStatus 输出运行状态
+// 参数:
+//   - report: 运行状态
+//   - format: 输出格式，OutputFormatJSON 时输出 JSON，否则输出文本
+//
+// 返回:
+//   - error: 输出错误
+func PrintStatus(report *StatusReport, format string) error {
+	if format == OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	icon := "🟢"
+	switch report.CircuitBreaker {
+	case "OPEN":
+		icon = "🔴"
+	case "HALF_OPEN":
+		icon = "🟡"
+	}
+	fmt.Printf("📦 App Token: %s\n", report.AppToken)
+	fmt.Printf("%s 熔断器: %s", icon, report.CircuitBreaker)
+	if breaker, ok := report.Stability["circuit_breaker"].(map[string]interface{}); ok {
+		fmt.Printf("（连续失败 %v 次", breaker["failures"])
+		if forced, _ := breaker["forced"].(bool); forced {
+			fmt.Print("，手动开启")
+		}
+		fmt.Print("）")
+	}
+	fmt.Println()
+	fmt.Printf("📊 API 调用 %d 次，重试 %d 次，缓存命中 %d 次，限流等待 %d 次（%s）\n",
+		report.Requests.APICalls, report.Requests.Retries, report.Requests.CacheHits,
+		report.Requests.RateLimitWaits, report.Requests.RateLimitWaitTime.Round(time.Millisecond))
+	if pool := statusPoolStats(report); pool != nil {
+		fmt.Printf("🔌 连接池: %d 个连接（使用中 %d，空闲 %d），进行中请求 %d 个，复用连接 %d 次，等待连接平均 %s、最长 %s\n",
+			pool.OpenConnections, pool.ActiveConnections, pool.IdleConnections, pool.InFlightRequests,
+			pool.ReusedConnections, pool.AverageWaitTime.Round(time.Microsecond), pool.MaxWaitTime.Round(time.Microsecond))
+		if pool.CompressedResponses > 0 {
+			fmt.Printf("🗜️  压缩响应 %d 个，传输 %s，解压后 %s\n",
+				pool.CompressedResponses, formatBytes(pool.CompressedBytes), formatBytes(pool.DecompressedBytes))
+		}
+	}
+	return nil
+}
+
+// statusPoolStats 读取运行状态中的连接池统计
+// 本地客户端的统计为 *common.PoolStats，网关返回的统计经 JSON 解码后为对象，统一转换为 PoolStats
+func statusPoolStats(report *StatusReport) *common.PoolStats {
+	value, ok := report.Stability["connection_pool"]
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var stats common.PoolStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
+
+// FetchServerStatus 获取运行中网关的状态
+// 参数:
+//   - ctx: 上下文
+//   - server: 网关地址，如 http://localhost:8080
+//   - token: 网关访问令牌
+//
+// 返回:
+//   - *StatusReport: 网关客户端的运行状态
+//   - error: 请求失败时返回错误
+func FetchServerStatus(ctx context.Context, server, token string) (*StatusReport, error) {
+	return serverStatusRequest(ctx, http.MethodGet, strings.TrimRight(server, "/")+"/status", token)
+}
+
+// ControlServerCircuitBreaker 手动开启或关闭运行中网关的熔断器
+// 参数:
+//   - ctx: 上下文
+//   - server: 网关地址，如 http://localhost:8080
+//   - token: 网关访问令牌
+//   - action: BreakerActionTrip 或 BreakerActionReset
+//
+// 返回:
+//   - *StatusReport: 操作后的运行状态
+//   - error: 请求失败时返回错误
+func ControlServerCircuitBreaker(ctx context.Context, server, token, action string) (*StatusReport, error) {
+	if action != BreakerActionTrip && action != BreakerActionReset {
+		return nil, fmt.Errorf("不支持的熔断器操作 '%s'，可选值: %s, %s", action, BreakerActionTrip, BreakerActionReset)
+	}
+	return serverStatusRequest(ctx, http.MethodPost, strings.TrimRight(server, "/")+"/circuit-breaker/"+action, token)
+}
+
+// RefreshServerSchema 让运行中的网关按名称重新解析表和字段
+// 参数:
+//   - ctx: 上下文
+//   - server: 网关地址，如 http://localhost:8080
+//   - token: 网关访问令牌
+//
+// 返回:
+//   - *StatusReport: 操作后的运行状态
+//   - error: 请求失败时返回错误
+func RefreshServerSchema(ctx context.Context, server, token string) (*StatusReport, error) {
+	return serverStatusRequest(ctx, http.MethodPost, strings.TrimRight(server, "/")+"/schema/refresh", token)
+}
+
+// serverStatusRequest 调用网关的状态接口
+func serverStatusRequest(ctx context.Context, method, url, token string) (*StatusReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求网关失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxServerRequestBytes))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp ServerErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			return nil, fmt.Errorf("网关返回错误 (HTTP %d): %s", resp.StatusCode, errResp.Error)
+		}
+		return nil, fmt.Errorf("网关返回错误 (HTTP %d)", resp.StatusCode)
+	}
+
+	var report StatusReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &report, nil
+}
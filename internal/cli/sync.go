@@ -0,0 +1,588 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// 同步冲突的解决策略
+const (
+	SyncSourceWins  = "source-wins"  // 以数据源为准
+	SyncBitableWins = "bitable-wins" // 以多维表格为准
+	SyncNewestWins  = "newest-wins"  // 以最后修改的一方为准
+)
+
+// 同步冲突的类型
+const (
+	SyncConflictBothModified    = "both_modified"    // 两边都修改了同一行，或首次同步时两边的值不同
+	SyncConflictModifiedDeleted = "modified_deleted" // 一边修改、另一边删除了同一行
+)
+
+// 冲突的胜出方
+const (
+	syncWinnerSource  = "source"
+	syncWinnerBitable = "bitable"
+)
+
+// SyncOptions 双向同步选项
+type SyncOptions struct {
+	Table         string // 表名
+	Source        string // 数据源地址，如 csv:users.csv、rest:https://api.example.com/users
+	Key           string // 匹配两边记录的键列
+	Conflict      string // 冲突解决策略，默认 source-wins
+	UpdatedColumn string // 数据源中记录行更新时间的列，newest-wins 使用，未指定时使用数据源的修改时间
+	StateFile     string // 同步状态文件路径，默认 ~/.basesql/sync/ 下按表名和数据源生成
+	Report        string // 将同步结果和冲突报告以 JSON 写入该文件
+	Delete        bool   // 将一边删除的行在另一边也删除，默认只报告，不删除任何一边的数据
+	DryRun        bool   // 只计算需要的修改，不写入任何一边
+}
+
+// SyncConflict 一条冲突及其处理结果
+type SyncConflict struct {
+	Key         string       `json:"key"`                    // 键列的值
+	Kind        string       `json:"kind"`                   // 冲突类型: both_modified 或 modified_deleted
+	Winner      string       `json:"winner"`                 // 胜出方: source 或 bitable
+	Changes     []DiffChange `json:"changes,omitempty"`      // 字段差异，Old 为表中的值，New 为数据源中的值
+	SourceTime  string       `json:"source_time,omitempty"`  // 数据源一方的修改时间
+	BitableTime string       `json:"bitable_time,omitempty"` // 多维表格一方的修改时间
+}
+
+// SyncResult 双向同步结果
+type SyncResult struct {
+	Columns        []string       `json:"columns"`           // 参与同步的列
+	Ignored        []string       `json:"ignored,omitempty"` // 数据源中有、表中不存在或只读的列
+	BitableCreated int            `json:"bitable_created"`   // 插入到表中的记录数
+	BitableUpdated int            `json:"bitable_updated"`   // 表中更新的记录数
+	BitableDeleted int            `json:"bitable_deleted"`   // 表中删除的记录数
+	SourceCreated  int            `json:"source_created"`    // 数据源中新增的行数
+	SourceUpdated  int            `json:"source_updated"`    // 数据源中更新的行数
+	SourceDeleted  int            `json:"source_deleted"`    // 数据源中删除的行数
+	DeletesSkipped int            `json:"deletes_skipped"`   // 未指定 Delete 而没有删除的行数
+	Unchanged      int            `json:"unchanged"`         // 两边一致的行数
+	Conflicts      []SyncConflict `json:"conflicts"`         // 冲突报告
+	DryRun         bool           `json:"dry_run"`           // 是否为预览
+}
+
+// syncState 上次同步完成时两边一致的内容
+// 用于判断一行是哪一边修改或删除的
+type syncState struct {
+	Table    string            `json:"table"`     // 表名
+	Source   string            `json:"source"`    // 数据源地址
+	Key      string            `json:"key"`       // 键列
+	SyncedAt time.Time         `json:"synced_at"` // 同步完成时间
+	Rows     map[string]string `json:"rows"`      // 键到各列显示值摘要的映射
+}
+
+// syncSide 一行在一边的内容
+type syncSide struct {
+	display map[string]string // 各列的显示值
+	hash    string            // 显示值的摘要
+	modTime time.Time         // 修改时间，未知时为零值
+}
+
+// Sync 在表与外部数据源之间双向同步
+// 参数:
+//   - opts: 同步选项
+//
+// 返回:
+//   - error: 错误信息
+func (c *Client) Sync(opts SyncOptions) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	result, err := c.executor.Sync(opts)
+	job := &JobResult{Job: "sync", Target: opts.Table, Err: err}
+	if result != nil {
+		if opts.Report != "" {
+			if reportErr := writeSyncReport(opts.Report, result); reportErr != nil {
+				common.PrintWarning(reportErr.Error())
+			}
+		}
+		if c.config.Format == OutputFormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(result); encodeErr != nil && err == nil {
+				err = encodeErr
+			}
+		} else {
+			c.executor.printSyncConflicts(result.Conflicts)
+		}
+		job.Summary = fmt.Sprintf("表: 插入 %d、更新 %d、删除 %d；数据源: 新增 %d、更新 %d、删除 %d；未变化 %d，冲突 %d",
+			result.BitableCreated, result.BitableUpdated, result.BitableDeleted,
+			result.SourceCreated, result.SourceUpdated, result.SourceDeleted,
+			result.Unchanged, len(result.Conflicts))
+		if result.DeletesSkipped > 0 {
+			job.Summary += fmt.Sprintf("；%d 行已在一边删除，未指定 --delete，另一边保留", result.DeletesSkipped)
+		}
+		if result.DryRun {
+			job.Summary += "（预览模式，未写入）"
+		}
+		job.Failures = len(result.Conflicts)
+		if c.config.Format != OutputFormatJSON {
+			fmt.Printf("📊 %s\n", job.Summary)
+		}
+	}
+	job.Duration = time.Since(start)
+	c.notifyJob(job)
+	return err
+}
+
+// Sync 执行双向同步
+// 与上次同步的状态比较判断每一行是哪一边新增、修改或删除的，将变化应用到另一边；
+// 两边都有变化时按冲突策略决定胜出方。首次同步没有状态，两边都有但值不同的行视为冲突，不删除任何一边的数据；
+// 未指定 Delete 时一边删除的行在另一边保留，只计入 DeletesSkipped，状态记录保留的一边，之后不再当作新增行
+// 参数:
+//   - opts: 同步选项
+//
+// 返回:
+//   - *SyncResult: 同步结果
+//   - error: 错误信息
+func (e *Executor) Sync(opts SyncOptions) (*SyncResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("表名不能为空")
+	}
+	if opts.Source == "" {
+		return nil, fmt.Errorf("请通过 --source 指定数据源")
+	}
+	if opts.Key == "" {
+		return nil, fmt.Errorf("请通过 --key 指定用于匹配记录的键列")
+	}
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = SyncSourceWins
+	case SyncSourceWins, SyncBitableWins, SyncNewestWins:
+	default:
+		return nil, fmt.Errorf("不支持的冲突策略 '%s'，可选值: %s, %s, %s", opts.Conflict, SyncSourceWins, SyncBitableWins, SyncNewestWins)
+	}
+	if err := e.policy.Check(common.CommandSelect, opts.Table); err != nil {
+		return nil, err
+	}
+
+	source, err := NewSyncSource(opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	statePath := opts.StateFile
+	if statePath == "" {
+		if statePath, err = defaultSyncStatePath(e.appToken, opts.Table, opts.Source); err != nil {
+			return nil, err
+		}
+	}
+	state, err := loadSyncState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Key != "" && state.Key != opts.Key {
+		return nil, fmt.Errorf("同步状态 %s 使用的键列是 '%s'，与当前的 '%s' 不一致", statePath, state.Key, opts.Key)
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	data, err := source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tableID, err := e.getTableID(ctx, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据源为空时按表的可写字段建立列
+	if len(data.Columns) == 0 {
+		for _, field := range fields {
+			if !field.IsReadOnly() {
+				data.Columns = append(data.Columns, field.FieldName)
+			}
+		}
+	}
+
+	result := &SyncResult{Conflicts: []SyncConflict{}, DryRun: opts.DryRun}
+	var columnFields []*basesql.Field
+	var keyField *basesql.Field
+	for _, name := range data.Columns {
+		field := queryengine.FindField(fields, name)
+		if field == nil || field.IsReadOnly() {
+			result.Ignored = append(result.Ignored, name)
+			continue
+		}
+		if name == opts.Key {
+			keyField = field
+		}
+		columnFields = append(columnFields, field)
+		result.Columns = append(result.Columns, name)
+	}
+	if keyField == nil {
+		return nil, fmt.Errorf("键列 '%s' 必须同时存在于数据源和表 '%s' 的可写字段中", opts.Key, opts.Table)
+	}
+
+	// 数据源一侧：值按表字段的类型转换后取显示值，与表中的值可以直接比较
+	sources := make(map[string]*syncSide, len(data.Rows))
+	payloads := make(map[string]map[string]interface{}, len(data.Rows))
+	sourceIndex := make(map[string]int, len(data.Rows))
+	var order []string
+	for i, row := range data.Rows {
+		values := make([]string, len(result.Columns))
+		for j, column := range result.Columns {
+			values[j] = row[column]
+		}
+		payload, err := importPayload(columnFields, values)
+		if err != nil {
+			return nil, fmt.Errorf("数据源第 %d 行: %w", i+1, err)
+		}
+		display, err := diffPayloadDisplay(columnFields, payload)
+		if err != nil {
+			return nil, fmt.Errorf("数据源第 %d 行: %w", i+1, err)
+		}
+		key := display[keyField.FieldName]
+		if key == "" {
+			return nil, fmt.Errorf("数据源第 %d 行的键列 '%s' 为空", i+1, opts.Key)
+		}
+		if first, ok := sourceIndex[key]; ok {
+			return nil, fmt.Errorf("数据源中键 '%s' 重复（第 %d 行和第 %d 行）", key, first+1, i+1)
+		}
+		modTime := data.ModTime
+		if opts.UpdatedColumn != "" {
+			if t, ok := parseSyncTime(row[opts.UpdatedColumn]); ok {
+				modTime = t
+			}
+		}
+		sources[key] = &syncSide{display: display, hash: syncHash(display), modTime: modTime}
+		payloads[key] = payload
+		sourceIndex[key] = i
+		order = append(order, key)
+	}
+
+	// 表一侧
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]*syncSide, len(records))
+	recordIDs := make(map[string]string, len(records))
+	for _, record := range records {
+		key := diffDisplayValue(keyField, record.Fields[keyField.FieldName])
+		if key == "" {
+			continue
+		}
+		if _, ok := targets[key]; ok {
+			return nil, fmt.Errorf("表 '%s' 中键 '%s' 重复，可以先使用 basesql dedupe --table %s --key %s 去重",
+				opts.Table, key, opts.Table, keyField.FieldName)
+		}
+		display := make(map[string]string, len(columnFields))
+		for _, field := range columnFields {
+			display[field.FieldName] = diffDisplayValue(field, record.Fields[field.FieldName])
+		}
+		side := &syncSide{display: display, hash: syncHash(display)}
+		if record.LastModified > 0 {
+			side.modTime = time.UnixMilli(record.LastModified)
+		}
+		targets[key] = side
+		recordIDs[key] = record.RecordID
+		if _, ok := sources[key]; !ok {
+			order = append(order, key)
+		}
+	}
+
+	// 逐行决定需要的修改
+	toBitable := &DiffResult{}
+	sourceUpdates := make(map[string]map[string]string)
+	sourceDeletes := make(map[string]bool)
+	var sourceCreates []map[string]string
+	finalRows := make(map[string]string)
+
+	for _, key := range order {
+		src, tgt := sources[key], targets[key]
+		base := state.Rows[key]
+
+		// 未开启删除时保留另一边的行，状态记录保留的内容，下次同步仍视为已在一边删除
+		skipDelete := func(kept *syncSide) {
+			result.DeletesSkipped++
+			finalRows[key] = kept.hash
+		}
+		useSource := func() {
+			switch {
+			case src == nil && !opts.Delete:
+				skipDelete(tgt)
+			case src == nil:
+				toBitable.Removed = append(toBitable.Removed, DiffRow{Key: key, RecordID: recordIDs[key]})
+				result.BitableDeleted++
+			case tgt == nil:
+				toBitable.Added = append(toBitable.Added, DiffRow{Key: key, payload: payloads[key]})
+				result.BitableCreated++
+				finalRows[key] = src.hash
+			default:
+				toBitable.Changed = append(toBitable.Changed, DiffRow{Key: key, RecordID: recordIDs[key],
+					Changes: syncChanges(result.Columns, tgt, src), payload: payloads[key]})
+				result.BitableUpdated++
+				finalRows[key] = src.hash
+			}
+		}
+		useBitable := func() {
+			switch {
+			case tgt == nil && !opts.Delete:
+				skipDelete(src)
+			case tgt == nil:
+				sourceDeletes[key] = true
+				result.SourceDeleted++
+			case src == nil:
+				sourceCreates = append(sourceCreates, tgt.display)
+				result.SourceCreated++
+				finalRows[key] = tgt.hash
+			default:
+				sourceUpdates[key] = tgt.display
+				result.SourceUpdated++
+				finalRows[key] = tgt.hash
+			}
+		}
+		conflict := func(kind string, keepModified bool) {
+			c := SyncConflict{Key: key, Kind: kind}
+			if src != nil && tgt != nil {
+				c.Changes = syncChanges(result.Columns, tgt, src)
+			}
+			if src != nil && !src.modTime.IsZero() {
+				c.SourceTime = src.modTime.Format(time.RFC3339)
+			}
+			if tgt != nil && !tgt.modTime.IsZero() {
+				c.BitableTime = tgt.modTime.Format(time.RFC3339)
+			}
+			c.Winner = syncWinnerSource
+			switch opts.Conflict {
+			case SyncBitableWins:
+				c.Winner = syncWinnerBitable
+			case SyncNewestWins:
+				// 删除的时间未知，一边修改、一边删除时保留修改的一边
+				if keepModified {
+					if src == nil {
+						c.Winner = syncWinnerBitable
+					}
+				} else if !src.modTime.IsZero() && tgt.modTime.After(src.modTime) {
+					c.Winner = syncWinnerBitable
+				}
+			}
+			result.Conflicts = append(result.Conflicts, c)
+			if c.Winner == syncWinnerSource {
+				useSource()
+			} else {
+				useBitable()
+			}
+		}
+
+		switch {
+		case src != nil && tgt != nil:
+			switch {
+			case src.hash == tgt.hash:
+				result.Unchanged++
+				finalRows[key] = src.hash
+			case base == "":
+				conflict(SyncConflictBothModified, false)
+			case tgt.hash == base:
+				useSource()
+			case src.hash == base:
+				useBitable()
+			default:
+				conflict(SyncConflictBothModified, false)
+			}
+		case src != nil:
+			switch {
+			case base == "":
+				useSource() // 数据源中新增的行
+			case src.hash == base:
+				useBitable() // 表中删除了该行
+			default:
+				conflict(SyncConflictModifiedDeleted, true)
+			}
+		default:
+			switch {
+			case base == "":
+				useBitable() // 表中新增的记录
+			case tgt.hash == base:
+				useSource() // 数据源中删除了该行
+			default:
+				conflict(SyncConflictModifiedDeleted, true)
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if len(toBitable.Added)+len(toBitable.Changed)+len(toBitable.Removed) > 0 {
+		if err := e.applyDiff(ctx, opts.Table, tableID, toBitable); err != nil {
+			return result, err
+		}
+	}
+
+	if len(sourceUpdates)+len(sourceDeletes)+len(sourceCreates) > 0 {
+		rows := make([]map[string]string, 0, len(data.Rows)+len(sourceCreates))
+		for i, row := range data.Rows {
+			key := order[i] // order 的前 len(data.Rows) 项与数据源的行一一对应
+			if sourceDeletes[key] {
+				continue
+			}
+			if display, ok := sourceUpdates[key]; ok {
+				for column, value := range display {
+					row[column] = value
+				}
+			}
+			rows = append(rows, row)
+		}
+		rows = append(rows, sourceCreates...)
+		data.Rows = rows
+		if err := source.Save(ctx, data); err != nil {
+			return result, err
+		}
+		fmt.Printf("  ✅ 已写回数据源，共 %d 行\n", len(rows))
+	}
+
+	state = &syncState{Table: opts.Table, Source: opts.Source, Key: opts.Key, SyncedAt: time.Now(), Rows: finalRows}
+	if err := state.save(statePath); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// syncChanges 计算表中的值与数据源中的值不同的列
+func syncChanges(columns []string, bitable, source *syncSide) []DiffChange {
+	var changes []DiffChange
+	for _, column := range columns {
+		if bitable.display[column] != source.display[column] {
+			changes = append(changes, DiffChange{Field: column, Old: bitable.display[column], New: source.display[column]})
+		}
+	}
+	return changes
+}
+
+// syncHash 计算一行显示值的摘要，JSON 序列化时键按名称排序，结果与列的顺序无关
+func syncHash(display map[string]string) string {
+	data, _ := json.Marshal(display)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// parseSyncTime 解析数据源中的更新时间，支持日期格式和秒或毫秒时间戳
+func parseSyncTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, ok := parseImportDate(value); ok {
+		return t, true
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n > common.MillisecondThreshold {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
+
+// defaultSyncStatePath 获取默认的同步状态文件路径
+// 同一个多维表格中的同一张表与同一个数据源共用一个状态文件
+func defaultSyncStatePath(appToken, table, source string) (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(appToken + "\x00" + table + "\x00" + source))
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, table)
+	return filepath.Join(configDir, "sync", fmt.Sprintf("%s-%s.json", name, hex.EncodeToString(sum[:6]))), nil
+}
+
+// loadSyncState 读取同步状态，文件不存在时返回空状态（首次同步）
+func loadSyncState(path string) (*syncState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &syncState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取同步状态失败: %w", err)
+	}
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析同步状态 %s 失败: %w", path, err)
+	}
+	return &state, nil
+}
+
+// save 保存同步状态，先写入临时文件再重命名
+func (s *syncState) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("创建同步状态目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化同步状态失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("保存同步状态失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("保存同步状态失败: %w", err)
+	}
+	return nil
+}
+
+// writeSyncReport 将同步结果以 JSON 写入文件
+func writeSyncReport(path string, result *SyncResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化同步报告失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入同步报告失败: %w", err)
+	}
+	return nil
+}
+
+// printSyncConflicts 输出冲突报告
+func (e *Executor) printSyncConflicts(conflicts []SyncConflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Printf("⚠️  冲突 %d 行:\n", len(conflicts))
+	table := e.newTable("键", "类型", "胜出方", "字段", "表中的值", "数据源的值")
+	for _, c := range conflicts {
+		kind := "两边都修改"
+		if c.Kind == SyncConflictModifiedDeleted {
+			kind = "一边修改、一边删除"
+		}
+		if len(c.Changes) == 0 {
+			table.AppendRow(c.Key, kind, c.Winner, "", "", "")
+			continue
+		}
+		for _, change := range c.Changes {
+			table.AppendRow(c.Key, kind, c.Winner, change.Field, change.Old, change.New)
+		}
+	}
+	e.printTable(table)
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// SyncSource 同步的外部数据源
+// 新的数据源（如 SQLite）实现该接口，并通过 RegisterSyncSource 注册地址前缀
+type SyncSource interface {
+	// Load 读取数据源中的全部行
+	Load(ctx context.Context) (*SyncData, error)
+	// Save 用同步后的全部行替换数据源中的内容
+	Save(ctx context.Context, data *SyncData) error
+}
+
+// SyncData 数据源中的全部行
+type SyncData struct {
+	Columns []string            // 列名，写回时按该顺序输出
+	Rows    []map[string]string // 各行的值，键为列名
+	ModTime time.Time           // 数据源的修改时间，行中没有更新时间列时用于 newest-wins，未知时为零值
+}
+
+// SyncSourceFactory 根据数据源地址创建数据源，地址包含前缀，如 csv:users.csv
+type SyncSourceFactory func(location string) (SyncSource, error)
+
+var (
+	syncSourcesMu sync.RWMutex
+	syncSources   = map[string]SyncSourceFactory{
+		"csv":  newCSVSyncSource,
+		"rest": newRESTSyncSource,
+	}
+)
+
+// RegisterSyncSource 注册同步数据源
+// 参数:
+//   - scheme: 地址前缀（冒号之前的部分），如 sqlite
+//   - factory: 创建数据源的函数
+func RegisterSyncSource(scheme string, factory SyncSourceFactory) {
+	syncSourcesMu.Lock()
+	defer syncSourcesMu.Unlock()
+	syncSources[strings.ToLower(scheme)] = factory
+}
+
+// NewSyncSource 根据数据源地址创建数据源
+// 参数:
+//   - location: 数据源地址，如 csv:users.csv、csv:https://example.com/users.csv、rest:https://api.example.com/users
+//
+// 返回:
+//   - SyncSource: 数据源
+//   - error: 地址格式错误或前缀未注册
+func NewSyncSource(location string) (SyncSource, error) {
+	idx := strings.Index(location, ":")
+	if idx <= 0 {
+		return nil, fmt.Errorf("数据源地址格式错误: %s，应为 <类型>:<地址>，如 csv:users.csv", location)
+	}
+	scheme := strings.ToLower(location[:idx])
+
+	syncSourcesMu.RLock()
+	factory, ok := syncSources[scheme]
+	schemes := make([]string, 0, len(syncSources))
+	for s := range syncSources {
+		schemes = append(schemes, s)
+	}
+	syncSourcesMu.RUnlock()
+
+	if !ok {
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("不支持的数据源类型: %s，可选值: %s", scheme, strings.Join(schemes, "、"))
+	}
+	return factory(location)
+}
+
+// csvFileSource 本地 CSV 文件，第一行为列名
+type csvFileSource struct {
+	path string
+}
+
+// csvEndpointSource 通过 HTTP 读写的 CSV：GET 读取，PUT 写回
+type csvEndpointSource struct {
+	endpoint string
+}
+
+// newCSVSyncSource 创建 CSV 数据源，地址为 csv:<文件路径> 或 csv:<HTTP(S) 地址>
+func newCSVSyncSource(location string) (SyncSource, error) {
+	target := location[len("csv:"):]
+	if target == "" {
+		return nil, fmt.Errorf("CSV 数据源缺少文件路径或地址")
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		endpoint, err := parseOutputURL(target)
+		if err != nil {
+			return nil, err
+		}
+		return &csvEndpointSource{endpoint: endpoint}, nil
+	}
+	return &csvFileSource{path: target}, nil
+}
+
+// Load 读取 CSV 文件，修改时间取文件的修改时间
+func (s *csvFileSource) Load(ctx context.Context) (*SyncData, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 CSV 文件失败: %w", err)
+	}
+	defer file.Close()
+
+	data, err := decodeSyncCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil {
+		data.ModTime = info.ModTime()
+	}
+	return data, nil
+}
+
+// Save 先写入临时文件再重命名，写入中断时不会破坏原文件
+func (s *csvFileSource) Save(ctx context.Context, data *SyncData) error {
+	content, err := encodeSyncCSV(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入 CSV 文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 CSV 文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 CSV 文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("写入 CSV 文件失败: %w", err)
+	}
+	return nil
+}
+
+// Load 通过 GET 读取 CSV，修改时间取 Last-Modified 响应头
+func (s *csvEndpointSource) Load(ctx context.Context) (*SyncData, error) {
+	body, header, err := syncHTTP(ctx, http.MethodGet, s.endpoint, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CSV 失败: %w", err)
+	}
+	data, err := decodeSyncCSV(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	data.ModTime = syncLastModified(header)
+	return data, nil
+}
+
+// Save 通过 PUT 写回全部内容
+func (s *csvEndpointSource) Save(ctx context.Context, data *SyncData) error {
+	content, err := encodeSyncCSV(data)
+	if err != nil {
+		return err
+	}
+	if _, _, err := syncHTTP(ctx, http.MethodPut, s.endpoint, "text/csv; charset=utf-8", content); err != nil {
+		return fmt.Errorf("写回 CSV 失败: %w", err)
+	}
+	return nil
+}
+
+// restSource 通过 HTTP 读写的 JSON 对象数组：GET 读取，PUT 写回
+// 对象中的值为字符串、数字或布尔值，写回时统一为字符串
+type restSource struct {
+	endpoint string
+}
+
+// newRESTSyncSource 创建 REST 数据源，地址为 rest:<HTTP(S) 地址>
+func newRESTSyncSource(location string) (SyncSource, error) {
+	endpoint, err := parseOutputURL(location[len("rest:"):])
+	if err != nil {
+		return nil, err
+	}
+	return &restSource{endpoint: endpoint}, nil
+}
+
+// Load 读取 JSON 对象数组，列为所有对象中出现过的键，按首次出现的顺序排列
+func (s *restSource) Load(ctx context.Context) (*SyncData, error) {
+	body, header, err := syncHTTP(ctx, http.MethodGet, s.endpoint, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("读取 REST 数据源失败: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var items []map[string]interface{}
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("解析 REST 数据源失败，应为 JSON 对象数组: %w", err)
+	}
+
+	data := &SyncData{Rows: make([]map[string]string, 0, len(items)), ModTime: syncLastModified(header)}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		// 对象的键没有顺序，同一个对象中新出现的键按名称排序
+		keys := make([]string, 0, len(item))
+		for key := range item {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		row := make(map[string]string, len(item))
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				data.Columns = append(data.Columns, key)
+			}
+			row[key] = common.FormatValue(item[key])
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data, nil
+}
+
+// Save 通过 PUT 写回全部对象，空值不输出
+func (s *restSource) Save(ctx context.Context, data *SyncData) error {
+	items := make([]map[string]string, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		item := make(map[string]string, len(row))
+		for _, column := range data.Columns {
+			if value := row[column]; value != "" {
+				item[column] = value
+			}
+		}
+		items = append(items, item)
+	}
+	content, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("序列化数据失败: %w", err)
+	}
+	if _, _, err := syncHTTP(ctx, http.MethodPut, s.endpoint, "application/json; charset=utf-8", content); err != nil {
+		return fmt.Errorf("写回 REST 数据源失败: %w", err)
+	}
+	return nil
+}
+
+// decodeSyncCSV 解析 CSV 内容，第一行为列名
+func decodeSyncCSV(r io.Reader) (*SyncData, error) {
+	reader := csv.NewReader(r)
+	header, err := readImportHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("读取 CSV 失败: %w", err)
+	}
+
+	data := &SyncData{Columns: header, Rows: make([]map[string]string, 0, len(records))}
+	for _, record := range records {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data, nil
+}
+
+// encodeSyncCSV 按列的顺序将全部行编码为 CSV
+func encodeSyncCSV(data *SyncData) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(data.Columns); err != nil {
+		return nil, fmt.Errorf("写入 CSV 表头失败: %w", err)
+	}
+	for _, row := range data.Rows {
+		values := make([]string, len(data.Columns))
+		for i, column := range data.Columns {
+			values[i] = row[column]
+		}
+		if err := writer.Write(values); err != nil {
+			return nil, fmt.Errorf("写入 CSV 数据失败: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// syncHTTP 发送数据源的读写请求，非 2xx 响应返回错误
+func syncHTTP(ctx context.Context, method, endpoint, contentType string, body []byte) ([]byte, http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, outputHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(respBody) > 1024 {
+			respBody = respBody[:1024]
+		}
+		return nil, nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, resp.Header, nil
+}
+
+// syncLastModified 解析 Last-Modified 响应头，缺失或格式错误时返回零值
+func syncLastModified(header http.Header) time.Time {
+	t, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+	"github.com/ag9920/basesql/queryengine"
+)
+
+// splitUnion 按顶层的 UNION、UNION ALL 拆分 SELECT 语句，引号和括号中的关键字不拆分
+// 参数:
+//   - sql: SELECT 语句
+//
+// 返回:
+//   - []string: 各条 SELECT 语句，没有 UNION 时只有一条
+//   - []bool: 各条 SELECT 是否以 UNION ALL 连接到之前的结果，第一条为 false
+func splitUnion(sql string) ([]string, []bool) {
+	tokens := lexSQL(sql)
+	parts := make([]string, 0, 1)
+	all := []bool{false}
+	var current strings.Builder
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.kind == sqlTokenSymbol && token.text == "(":
+			depth++
+		case token.kind == sqlTokenSymbol && token.text == ")" && depth > 0:
+			depth--
+		case token.kind == sqlTokenKeyword && depth == 0 && strings.EqualFold(token.text, "UNION"):
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			j := i + 1
+			for j < len(tokens) && tokens[j].kind == sqlTokenSpace {
+				j++
+			}
+			unionAll := j < len(tokens) && strings.EqualFold(tokens[j].text, "ALL")
+			if unionAll {
+				i = j
+			}
+			all = append(all, unionAll)
+			continue
+		}
+		current.WriteString(token.text)
+	}
+	return append(parts, strings.TrimSpace(current.String())), all
+}
+
+// stripUnion 去掉 SELECT 中连接各条查询的 UNION、UNION ALL 关键字，用于 SQL 注入验证
+// 引号中的文本保持不变
+func stripUnion(sql string) string {
+	var b strings.Builder
+	for _, token := range lexSQL(sql) {
+		if token.kind == sqlTokenKeyword && (strings.EqualFold(token.text, "UNION") || strings.EqualFold(token.text, "ALL")) {
+			continue
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+// parseUnion 解析以 UNION 连接的各条 SELECT，写入合并查询命令
+// 最后一条 SELECT 的 ORDER BY、LIMIT 作用于合并后的结果
+// 参数:
+//   - parts: 各条 SELECT 语句
+//   - all: 各条 SELECT 是否以 UNION ALL 连接
+//   - cmd: 合并查询命令
+//
+// 返回:
+//   - error: 某条 SELECT 为空或解析失败
+func parseUnion(parts []string, all []bool, cmd *SQLCommand) error {
+	for i, part := range parts {
+		if part == "" {
+			return fmt.Errorf("UNION 两侧都需要 SELECT 语句")
+		}
+		if identifyCommandType(part) != common.CommandSelect {
+			return fmt.Errorf("UNION 只能连接 SELECT 语句: %s", part)
+		}
+		sub := common.NewSQLCommand(common.CommandSelect)
+		sub.RawSQL = part
+		if _, err := common.DefaultSQLParser.ParseSelectSQL(part, sub); err != nil {
+			return fmt.Errorf("UNION 的第 %d 条 SELECT 解析失败: %w", i+1, err)
+		}
+		sub.UnionAll = all[i]
+		cmd.Union = append(cmd.Union, sub)
+	}
+
+	first, last := cmd.Union[0], cmd.Union[len(cmd.Union)-1]
+	cmd.Table = first.Table
+	cmd.Fields = first.Fields
+	cmd.OrderBy, last.OrderBy = last.OrderBy, nil
+	cmd.Limit, last.Limit = last.Limit, 0
+	return nil
+}
+
+// unionPart 合并查询中一条 SELECT 的结果
+type unionPart struct {
+	columns []ResultColumn
+	rows    []basesql.Record
+	all     bool
+}
+
+// unionRows 分别执行合并查询中的各条 SELECT，按列的位置合并结果
+// 各条 SELECT 的列数必须相同，列名取第一条 SELECT 的列名；同一列在各条 SELECT 中的字段类型不同时按文本输出。
+// UNION 对之前合并的全部结果去重，UNION ALL 保留重复的行；ORDER BY 和 LIMIT 在合并之后应用
+// 参数:
+//   - ctx: 上下文
+//   - cmd: 合并查询命令
+//
+// 返回:
+//   - []ResultColumn: 结果列
+//   - []basesql.Record: 结果行，以列名为键
+//   - error: 执行错误或各条 SELECT 的列数不一致
+func (e *Executor) unionRows(ctx context.Context, cmd *common.SQLCommand) ([]ResultColumn, []basesql.Record, error) {
+	parts := make([]unionPart, 0, len(cmd.Union))
+	for i, sub := range cmd.Union {
+		if sub.IsAggregate {
+			return nil, nil, fmt.Errorf("UNION 的第 %d 条 SELECT 不支持不带 GROUP BY 的聚合函数", i+1)
+		}
+		fields, records, err := e.loadSelect(ctx, sub)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns, rows, err := e.selectRows(sub, fields, records)
+		if err != nil {
+			return nil, nil, fmt.Errorf("UNION 的第 %d 条 SELECT 执行失败: %w", i+1, err)
+		}
+		if i > 0 && len(columns) != len(parts[0].columns) {
+			return nil, nil, fmt.Errorf("UNION 的第 %d 条 SELECT 有 %d 列，与第一条 SELECT 的 %d 列不一致", i+1, len(columns), len(parts[0].columns))
+		}
+		parts = append(parts, unionPart{columns: columns, rows: rows, all: sub.UnionAll})
+	}
+
+	columns, text := unionColumns(parts)
+	var rows []basesql.Record
+	for i, part := range parts {
+		for _, record := range part.rows {
+			row := basesql.Record{RecordID: record.RecordID, Fields: make(map[string]interface{}, len(columns))}
+			for j, column := range part.columns {
+				value := column.Value(record)
+				if text[j] && value != nil {
+					value = common.FormatValue(queryengine.GoValue(column.Field, value))
+				}
+				row.Fields[columns[j].Field.FieldName] = value
+			}
+			rows = append(rows, row)
+		}
+		if i > 0 && !part.all {
+			rows = distinctRows(columns, rows)
+		}
+	}
+
+	if len(cmd.OrderBy) > 0 {
+		if err := e.engine("").Sort(rows, queryengine.ColumnFields(columns), cmd.OrderBy); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cmd.Limit > 0 && len(rows) > cmd.Limit {
+		rows = rows[:cmd.Limit]
+	}
+	return columns, rows, nil
+}
+
+// unionColumns 确定合并结果的列：列名取第一条 SELECT 的列名，字段类型在各条 SELECT 中一致时沿用，否则为文本
+// 返回:
+//   - []ResultColumn: 合并结果的列，字段名与列名相同
+//   - []bool: 各列是否因类型不一致而按文本输出
+func unionColumns(parts []unionPart) ([]ResultColumn, []bool) {
+	first := parts[0].columns
+	columns := make([]ResultColumn, len(first))
+	text := make([]bool, len(first))
+	for j, column := range first {
+		field := *column.Field
+		for _, part := range parts[1:] {
+			text[j] = text[j] || part.columns[j].Field.Type != field.Type
+		}
+		if text[j] {
+			field = basesql.Field{Type: basesql.FieldTypeText}
+		}
+		field.FieldName = column.Label
+		columns[j] = ResultColumn{Field: &field, Label: column.Label}
+	}
+	return columns, text
+}
+
+// distinctRows 去除各列的值都相同的行，保留第一次出现的行
+func distinctRows(columns []ResultColumn, rows []basesql.Record) []basesql.Record {
+	seen := make(map[string]bool, len(rows))
+	result := rows[:0]
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for j, column := range columns {
+			if raw := column.Value(row); raw != nil {
+				values[j] = common.FormatValue(queryengine.GoValue(column.Field, raw))
+			}
+		}
+		key := strings.Join(values, "\x00")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, row)
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// useStatementRe USE 语句，如 USE tasks;
+var useStatementRe = regexp.MustCompile("(?i)^USE\\s+([^\\s;]+)\\s*;?$")
+
+// fromKeywordRe FROM 关键字
+var fromKeywordRe = keywordPattern("FROM")
+
+// defaultTableClauses 省略 FROM 时表名插入在这些子句之前
+var defaultTableClauses = []*regexp.Regexp{
+	keywordPattern("WHERE"),
+	keywordPattern("GROUP BY"),
+	keywordPattern("HAVING"),
+	keywordPattern("ORDER BY"),
+	keywordPattern("LIMIT"),
+}
+
+// bareAggregateRe 省略 SELECT 的聚合查询，如 COUNT(*)
+var bareAggregateRe = regexp.MustCompile(`(?i)^(COUNT|SUM|AVG|MIN|MAX)\s*\(`)
+
+// ParseUseStatement 解析 USE 语句
+// 参数:
+//   - sql: SQL 语句
+//
+// 返回:
+//   - string: 表名，去掉了反引号和引号
+//   - bool: 是否为 USE 语句
+func ParseUseStatement(sql string) (string, bool) {
+	matches := useStatementRe.FindStringSubmatch(strings.TrimSpace(sql))
+	if matches == nil {
+		return "", false
+	}
+	return strings.Trim(matches[1], "`\"'"), true
+}
+
+// UseTable 设置默认表，之后省略 FROM 的 SELECT 语句和 COUNT(*) 等聚合查询都作用于该表
+// 参数:
+//   - ctx: 上下文
+//   - table: 表名
+//
+// 返回:
+//   - error: 表不存在或客户端未初始化时返回错误
+func (c *Client) UseTable(ctx context.Context, table string) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.executor.timeout)
+	defer cancel()
+	if _, err := c.executor.getTableID(ctx, table); err != nil {
+		return err
+	}
+	c.defaultTable = table
+	return nil
+}
+
+// DefaultTable 获取 USE 设置的默认表，未设置时为空
+func (c *Client) DefaultTable() string {
+	if c == nil {
+		return ""
+	}
+	return c.defaultTable
+}
+
+// applyDefaultTable 为省略 FROM 的 SELECT 语句补充默认表
+// COUNT(*) 等省略 SELECT 的聚合查询先补充为 SELECT COUNT(*)
+func applyDefaultTable(sql, table string) string {
+	if table == "" {
+		return sql
+	}
+	if bareAggregateRe.MatchString(sql) {
+		sql = "SELECT " + sql
+	}
+	if identifyCommandType(sql) != CommandSelect || findKeyword(sql, fromKeywordRe) >= 0 {
+		return sql
+	}
+
+	// 表名插入在第一个 WHERE、GROUP BY 等子句之前，没有子句时插入在末尾
+	pos := len(strings.TrimRight(sql, "; \t\n"))
+	for _, clause := range defaultTableClauses {
+		if i := findKeyword(sql, clause); i >= 0 && i < pos {
+			pos = i
+		}
+	}
+	head := strings.TrimRight(sql[:pos], " \t\n")
+	tail := strings.TrimLeft(sql[pos:], " \t\n")
+	if tail == "" || strings.HasPrefix(tail, ";") {
+		return head + " FROM " + table + tail
+	}
+	return head + " FROM " + table + " " + tail
+}
+
+// keywordPattern 编译关键字的匹配模式，关键字中的空格匹配任意空白，不区分大小写
+func keywordPattern(keyword string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + strings.ReplaceAll(keyword, " ", `\s+`) + `\b`)
+}
+
+// findKeyword 查找引号外的关键字
+// 参数:
+//   - sql: SQL 语句
+//   - pattern: keywordPattern 编译的关键字模式
+//
+// 返回:
+//   - int: 关键字的起始位置，未找到时为 -1
+func findKeyword(sql string, pattern *regexp.Regexp) int {
+	for _, loc := range pattern.FindAllStringIndex(sql, -1) {
+		if !insideQuotes(sql, loc[0]) {
+			return loc[0]
+		}
+	}
+	return -1
+}
+
+// insideQuotes 判断位置是否在单引号、双引号或反引号之内
+func insideQuotes(sql string, pos int) bool {
+	var quote byte
+	for i := 0; i < pos; i++ {
+		switch c := sql[i]; {
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return quote != 0
+}
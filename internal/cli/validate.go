@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/queryengine"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile 校验规则文件结构
+// 按表名声明字段校验规则，例如：
+//
+//	tables:
+//	  users:
+//	    - field: email
+//	      required: true
+//	      pattern: '^[^@]+@[^@]+$'
+type RulesFile struct {
+	Tables map[string][]basesql.ValidationRule `yaml:"tables" json:"tables"` // 表名到校验规则的映射
+}
+
+// RecordViolation 单条记录的校验失败信息
+type RecordViolation struct {
+	RecordID string                    `json:"record_id"` // 记录 ID
+	Errors   []basesql.ValidationError `json:"errors"`    // 未通过的规则
+}
+
+// ValidationReport 表数据校验报告
+type ValidationReport struct {
+	Table      string            `json:"table"`      // 表名
+	Scanned    int               `json:"scanned"`    // 扫描的记录数
+	Violations []RecordViolation `json:"violations"` // 未通过校验的记录
+}
+
+// DefaultRulesPath 获取默认的校验规则文件路径
+// 返回:
+//   - string: 文件路径
+//   - error: 获取用户主目录失败时返回错误
+func DefaultRulesPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "rules.yaml"), nil
+}
+
+// LoadRulesFile 读取并校验规则文件
+// 支持 YAML 与 JSON 格式（JSON 是 YAML 的子集）
+// 参数:
+//   - path: 规则文件路径
+//
+// 返回:
+//   - map[string][]basesql.ValidationRule: 表名到校验规则的映射
+//   - error: 读取或校验错误
+func LoadRulesFile(path string) (map[string][]basesql.ValidationRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取校验规则文件失败: %w", err)
+	}
+
+	var rules RulesFile
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("解析校验规则文件失败: %w", err)
+	}
+
+	for table, tableRules := range rules.Tables {
+		for i, rule := range tableRules {
+			if rule.Field == "" {
+				return nil, fmt.Errorf("表 '%s' 的第 %d 条规则缺少 field", table, i+1)
+			}
+			// 用示例值试运行一次，提前发现无效的正则表达式
+			if err := basesql.ValidateRecord([]basesql.ValidationRule{rule}, map[string]interface{}{rule.Field: "x"}, true); err != nil && !errors.Is(err, basesql.ErrValidationFailed) {
+				return nil, fmt.Errorf("表 '%s' 的规则无效: %w", table, err)
+			}
+		}
+	}
+	return rules.Tables, nil
+}
+
+// loadValidationRules 加载 CLI 使用的校验规则
+// 指定了规则文件时必须能够读取；未指定时尝试读取默认路径，文件不存在则不启用校验
+func loadValidationRules(path string) (map[string][]basesql.ValidationRule, error) {
+	if path != "" {
+		return LoadRulesFile(path)
+	}
+	defaultPath, err := DefaultRulesPath()
+	if err != nil {
+		return nil, nil
+	}
+	if _, err := os.Stat(defaultPath); err != nil {
+		return nil, nil
+	}
+	return LoadRulesFile(defaultPath)
+}
+
+// Validate 按校验规则审计表中已有的记录
+// 参数:
+//   - table: 表名
+//
+// 返回:
+//   - error: 存在未通过校验的记录或执行出错时返回错误
+func (c *Client) Validate(table string) error {
+	if c == nil || c.executor == nil {
+		return fmt.Errorf("客户端未初始化")
+	}
+
+	start := time.Now()
+	report, err := c.executor.Validate(table, c.rules[table])
+	if err != nil {
+		c.notifyJob(&JobResult{Job: "validate", Target: table, Duration: time.Since(start), Err: err})
+		return err
+	}
+	c.notifyJob(&JobResult{
+		Job:      "validate",
+		Target:   table,
+		Summary:  fmt.Sprintf("扫描记录 %d 条，%d 条未通过校验", report.Scanned, len(report.Violations)),
+		Failures: len(report.Violations),
+		Duration: time.Since(start),
+	})
+
+	if c.config.Format == OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		if len(report.Violations) > 0 {
+			t := c.executor.newTable("record_id", "field", "rule", "message")
+			for _, v := range report.Violations {
+				for _, ve := range v.Errors {
+					t.AppendRow(v.RecordID, ve.Field, ve.Rule, ve.Message)
+				}
+			}
+			c.executor.printTable(t)
+		}
+		fmt.Printf("📊 扫描记录 %d 条，%d 条未通过校验\n", report.Scanned, len(report.Violations))
+	}
+
+	if len(report.Violations) > 0 {
+		return fmt.Errorf("%w: %d 条记录未通过校验", basesql.ErrValidationFailed, len(report.Violations))
+	}
+	return nil
+}
+
+// Validate 按校验规则检查表中的全部记录
+// 参数:
+//   - table: 表名
+//   - rules: 校验规则
+//
+// 返回:
+//   - *ValidationReport: 校验报告
+//   - error: 错误信息
+func (e *Executor) Validate(table string, rules []basesql.ValidationRule) (*ValidationReport, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("表 '%s' 没有配置校验规则，请在规则文件中声明", table)
+	}
+
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.timeout)
+	defer cancel()
+
+	tableID, err := e.getTableID(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := e.getFieldsList(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if queryengine.FindField(fields, rule.Field) == nil {
+			return nil, fmt.Errorf("规则引用了不存在的字段 '%s'", rule.Field)
+		}
+	}
+
+	records, err := e.getRecords(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{Table: table, Scanned: len(records), Violations: []RecordViolation{}}
+	for _, record := range records {
+		err := basesql.ValidateRecord(rules, record.Fields, false)
+		var verrs basesql.ValidationErrors
+		if errors.As(err, &verrs) {
+			report.Violations = append(report.Violations, RecordViolation{RecordID: record.RecordID, Errors: verrs})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// withTable 物化到内存中的公用表表达式，字段名为查询的列名
+type withTable struct {
+	fields  []basesql.Field
+	records []basesql.Record
+}
+
+// parseWith 解析语句开头的 WITH 子句
+// 支持 WITH name [(column, ...)] AS (SELECT ...) [, ...] SELECT ...，不支持 RECURSIVE
+// 参数:
+//   - sql: 以 WITH 开头的语句
+//
+// 返回:
+//   - []*common.WithQuery: 各个公用表表达式
+//   - string: WITH 子句之后的 SELECT 语句
+//   - error: 语法错误
+func parseWith(sql string) ([]*common.WithQuery, string, error) {
+	var tokens []sqlToken
+	for _, token := range lexSQL(sql) {
+		if token.kind != sqlTokenComment {
+			tokens = append(tokens, token)
+		}
+	}
+	pos := 0
+	next := func() sqlToken {
+		for pos < len(tokens) && tokens[pos].kind == sqlTokenSpace {
+			pos++
+		}
+		if pos >= len(tokens) {
+			return sqlToken{}
+		}
+		pos++
+		return tokens[pos-1]
+	}
+	// group 读取与已读取的左括号匹配的右括号之前的内容
+	group := func() (string, error) {
+		var text strings.Builder
+		for depth := 1; pos < len(tokens); pos++ {
+			token := tokens[pos]
+			if token.kind == sqlTokenSymbol && token.text == "(" {
+				depth++
+			} else if token.kind == sqlTokenSymbol && token.text == ")" {
+				if depth--; depth == 0 {
+					pos++
+					return strings.TrimSpace(text.String()), nil
+				}
+			}
+			text.WriteString(token.text)
+		}
+		return "", fmt.Errorf("WITH 子句的括号未闭合")
+	}
+
+	if token := next(); !strings.EqualFold(token.text, "WITH") {
+		return nil, "", fmt.Errorf("语句需要以 WITH 开头")
+	}
+	var with []*common.WithQuery
+	names := make(map[string]bool)
+	for {
+		token := next()
+		if strings.EqualFold(token.text, "RECURSIVE") {
+			return nil, "", fmt.Errorf("不支持 WITH RECURSIVE")
+		}
+		name := trimIdentifier(token.text)
+		if name == "" || token.kind != sqlTokenIdent && token.kind != sqlTokenKeyword {
+			return nil, "", fmt.Errorf("WITH 子句缺少公用表表达式的名称")
+		}
+		if names[strings.ToLower(name)] {
+			return nil, "", fmt.Errorf("WITH 子句中的名称 %s 重复", name)
+		}
+		names[strings.ToLower(name)] = true
+		query := &common.WithQuery{Name: name}
+
+		token = next()
+		if token.text == "(" {
+			columns, err := group()
+			if err != nil {
+				return nil, "", err
+			}
+			for _, column := range strings.Split(columns, ",") {
+				if column = trimIdentifier(column); column == "" {
+					return nil, "", fmt.Errorf("%s 的列名列表不能包含空列名", name)
+				}
+				query.Columns = append(query.Columns, column)
+			}
+			token = next()
+		}
+		if !strings.EqualFold(token.text, "AS") {
+			return nil, "", fmt.Errorf("%s 之后需要 AS (SELECT ...)", name)
+		}
+		if token = next(); token.text != "(" {
+			return nil, "", fmt.Errorf("%s 的查询需要写在括号中", name)
+		}
+		text, err := group()
+		if err != nil {
+			return nil, "", err
+		}
+		if identifyCommandType(text) != common.CommandSelect {
+			return nil, "", fmt.Errorf("%s 的查询必须是 SELECT 语句", name)
+		}
+		sub := common.NewSQLCommand(common.CommandSelect)
+		sub.RawSQL = text
+		if sub, err = parseSelect(text, sub); err != nil {
+			return nil, "", fmt.Errorf("%s 的查询解析失败: %w", name, err)
+		}
+		if sub.OutFile != "" {
+			return nil, "", fmt.Errorf("%s 的查询不能使用 INTO OUTFILE", name)
+		}
+		if len(sub.With) > 0 {
+			return nil, "", fmt.Errorf("%s 的查询不能嵌套 WITH 子句", name)
+		}
+		query.Query = sub
+		with = append(with, query)
+
+		if token = next(); token.text == "," {
+			continue
+		}
+		if token.text != "" {
+			pos--
+		}
+		break
+	}
+
+	var rest strings.Builder
+	for _, token := range tokens[pos:] {
+		rest.WriteString(token.text)
+	}
+	main := strings.TrimSpace(rest.String())
+	if identifyCommandType(main) != common.CommandSelect || strings.HasPrefix(strings.ToUpper(main), "WITH") {
+		return nil, "", fmt.Errorf("WITH 子句之后需要 SELECT 语句")
+	}
+	return with, main, nil
+}
+
+// trimIdentifier 去除标识符两侧的空白、反引号和引号
+func trimIdentifier(name string) string {
+	return strings.Trim(strings.TrimSpace(name), "`'\"")
+}
+
+// sourceTables 获取 SELECT 实际读取的表，展开 UNION 和 WITH，公用表表达式的名称不计入
+func sourceTables(cmd *common.SQLCommand) []string {
+	names := make(map[string]bool)
+	var tables []string
+	var visit func(c *common.SQLCommand)
+	visit = func(c *common.SQLCommand) {
+		for _, with := range c.With {
+			visit(with.Query)
+			names[strings.ToLower(with.Name)] = true
+		}
+		if len(c.Union) > 0 {
+			for _, part := range c.Union {
+				visit(part)
+			}
+			return
+		}
+		if !names[strings.ToLower(c.Table)] {
+			tables = append(tables, c.Table)
+		}
+	}
+	visit(cmd)
+	return tables
+}
+
+// materializeWith 按声明顺序执行 WITH 子句中的查询，结果保存在内存中供语句的其余部分引用
+// 后面的查询可以引用前面的公用表表达式；语句执行完后调用方需要清空 e.with
+// 参数:
+//   - ctx: 上下文
+//   - with: 公用表表达式
+//
+// 返回:
+//   - error: 执行错误或列名列表与查询的列数不一致
+func (e *Executor) materializeWith(ctx context.Context, with []*common.WithQuery) error {
+	e.with = make(map[string]*withTable, len(with))
+	for _, query := range with {
+		if err := e.materializeWithQuery(ctx, query); err != nil {
+			return fmt.Errorf("WITH %s 执行失败: %w", query.Name, err)
+		}
+	}
+	return nil
+}
+
+// materializeWithQuery 执行一个公用表表达式，将结果转换为以列名为字段名的记录
+func (e *Executor) materializeWithQuery(ctx context.Context, query *common.WithQuery) error {
+	cmd := query.Query
+	if cmd.IsAggregate {
+		return fmt.Errorf("不支持不带 GROUP BY 的聚合函数")
+	}
+
+	var columns []ResultColumn
+	var rows []basesql.Record
+	var err error
+	if len(cmd.Union) > 0 {
+		columns, rows, err = e.unionRows(ctx, cmd)
+	} else {
+		var fields []basesql.Field
+		var records []basesql.Record
+		if fields, records, err = e.loadSelect(ctx, cmd); err == nil {
+			columns, rows, err = e.selectRows(cmd, fields, records)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(query.Columns) > 0 && len(query.Columns) != len(columns) {
+		return fmt.Errorf("列名列表有 %d 列，查询有 %d 列", len(query.Columns), len(columns))
+	}
+
+	table := &withTable{fields: make([]basesql.Field, len(columns)), records: make([]basesql.Record, len(rows))}
+	for j, column := range columns {
+		table.fields[j] = *column.Field
+		table.fields[j].FieldID = ""
+		table.fields[j].FieldName = column.Label
+		if len(query.Columns) > 0 {
+			table.fields[j].FieldName = query.Columns[j]
+		}
+	}
+	for i, row := range rows {
+		record := basesql.Record{RecordID: row.RecordID, Fields: make(map[string]interface{}, len(columns))}
+		for j, column := range columns {
+			record.Fields[table.fields[j].FieldName] = column.Value(row)
+		}
+		table.records[i] = record
+	}
+	e.with[strings.ToLower(query.Name)] = table
+	return nil
+}
+
+// lookupWith 按名称查找当前语句中已物化的公用表表达式
+func (e *Executor) lookupWith(name string) (*withTable, bool) {
+	table, ok := e.with[strings.ToLower(name)]
+	return table, ok
+}
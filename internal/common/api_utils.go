@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -42,6 +45,8 @@ type APIError struct {
 	Message string `json:"message"`
 	// Details 错误详情
 	Details string `json:"details,omitempty"`
+	// RetryAfter 服务端要求的最短重试等待时间，来自 Retry-After 等响应头
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
 }
 
 // Error 实现 error 接口
@@ -172,6 +177,8 @@ type RetryConfig struct {
 	MaxDelay time.Duration `json:"max_delay"`
 	// Multiplier 退避倍数
 	Multiplier float64 `json:"multiplier"`
+	// Jitter 是否启用完全抖动（在 0 到退避时间之间随机取值），避免多个客户端同步重试
+	Jitter bool `json:"jitter"`
 }
 
 // DefaultRetryConfig 默认重试配置
@@ -181,6 +188,7 @@ func DefaultRetryConfig() *RetryConfig {
 		InitialDelay: time.Second,
 		MaxDelay:     30 * time.Second,
 		Multiplier:   2.0,
+		Jitter:       true,
 	}
 }
 
@@ -205,9 +213,37 @@ func (rc *RetryConfig) CalculateBackoffDelay(attempt int) time.Duration {
 		}
 	}
 
+	if rc.Jitter && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+
 	return delay
 }
 
+// ParseRetryAfter 解析响应头中的重试等待时间
+// 依次识别 Retry-After（秒数或 HTTP 日期）和飞书的 x-ogw-ratelimit-reset（秒数）
+// 参数:
+//   - headers: 响应头
+//   - now: 当前时间，用于计算 HTTP 日期格式的等待时长
+//
+// 返回:
+//   - time.Duration: 等待时间，未提供或无法解析时返回 0
+func ParseRetryAfter(headers http.Header, now time.Time) time.Duration {
+	for _, key := range []string{"Retry-After", "X-Ogw-Ratelimit-Reset"} {
+		value := strings.TrimSpace(headers.Get(key))
+		if value == "" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(value); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+	return 0
+}
+
 // IsRetryableError 判断是否为可重试错误
 // 参数:
 //   - err: 错误
@@ -2,11 +2,14 @@ package common
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"sync"
 	"time"
 )
 
+// ErrCircuitOpen 熔断器处于开启状态，请求被直接拒绝
+var ErrCircuitOpen = errors.New("熔断器开启，拒绝请求")
+
 // CircuitBreakerState 熔断器状态
 type CircuitBreakerState int
 
@@ -62,6 +65,7 @@ type CircuitBreaker struct {
 	failures      int
 	requests      int
 	lastFailTime  time.Time
+	forced        bool // 手动开启熔断，调用 Reset 前不会自动恢复
 	mutex         sync.RWMutex
 	onStateChange func(from, to CircuitBreakerState)
 }
@@ -102,7 +106,7 @@ func (cb *CircuitBreaker) SetStateChangeCallback(callback func(from, to CircuitB
 func (cb *CircuitBreaker) Execute(ctx context.Context, operation func() error) error {
 	// 检查是否允许执行
 	if !cb.allowRequest() {
-		return fmt.Errorf("熔断器开启，拒绝请求")
+		return ErrCircuitOpen
 	}
 
 	// 执行操作
@@ -127,6 +131,10 @@ func (cb *CircuitBreaker) allowRequest() bool {
 	case StateClosed:
 		return true
 	case StateOpen:
+		// 手动开启的熔断不自动恢复
+		if cb.forced {
+			return false
+		}
 		// 检查是否可以转为半开状态
 		if now.Sub(cb.lastFailTime) > cb.config.Timeout {
 			cb.setState(StateHalfOpen)
@@ -226,15 +234,28 @@ func (cb *CircuitBreaker) GetStats() map[string]interface{} {
 		"failures":       cb.failures,
 		"requests":       cb.requests,
 		"last_fail_time": cb.lastFailTime,
+		"forced":         cb.forced,
 	}
 }
 
+// Trip 手动开启熔断
+// 开启后拒绝所有请求，不会在超时后自动转为半开状态，直到调用 Reset
+func (cb *CircuitBreaker) Trip() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = true
+	cb.lastFailTime = time.Now()
+	cb.setState(StateOpen)
+}
+
 // Reset 重置熔断器
 func (cb *CircuitBreaker) Reset() {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 
 	cb.setState(StateClosed)
+	cb.forced = false
 	cb.failures = 0
 	cb.requests = 0
 	cb.lastFailTime = time.Time{}
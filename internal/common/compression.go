@@ -0,0 +1,89 @@
+package common
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// acceptGzip 为请求声明接受 gzip 压缩的响应
+// 请求已自行设置 Accept-Encoding 或 Range 时不做处理，与 http.Transport 的自动压缩规则一致
+// 参数:
+//   - req: HTTP 请求
+//
+// 返回:
+//   - bool: 是否设置了 Accept-Encoding，设置后需要调用 decodeGzipResponse 解压响应
+func acceptGzip(req *http.Request) bool {
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" || req.Method == http.MethodHead {
+		return false
+	}
+	req.Header = req.Header.Clone()
+	req.Header.Set("Accept-Encoding", "gzip")
+	return true
+}
+
+// decodeGzipResponse 透明解压 gzip 压缩的响应
+// 响应体替换为解压后的内容，并像 http.Transport 一样移除 Content-Encoding 和 Content-Length；
+// 响应体关闭时通过 onClose 报告实际传输的字节数和解压后的字节数
+// 参数:
+//   - resp: HTTP 响应
+//   - onClose: 响应体关闭时的回调
+func decodeGzipResponse(resp *http.Response, onClose func(compressed, decompressed int64)) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{wire: &countingReader{r: resp.Body}, body: resp.Body, onClose: onClose}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipBody 解压 gzip 响应体，第一次读取时才解析 gzip 头，空响应体不会报错
+type gzipBody struct {
+	wire         *countingReader
+	body         io.ReadCloser
+	reader       *gzip.Reader
+	err          error
+	decompressed int64
+	once         sync.Once
+	onClose      func(compressed, decompressed int64)
+}
+
+// Read 读取解压后的内容
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = gzip.NewReader(b.wire)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.reader.Read(p)
+	b.decompressed += int64(n)
+	return n, err
+}
+
+// Close 关闭响应体
+func (b *gzipBody) Close() error {
+	b.once.Do(func() {
+		if b.onClose != nil {
+			b.onClose(b.wire.n, b.decompressed)
+		}
+	})
+	return b.body.Close()
+}
+
+// countingReader 统计读取字节数的 Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read 读取内容并累计字节数
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
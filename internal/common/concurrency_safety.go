@@ -319,34 +319,31 @@ func CheckGoroutineLeak(description string) {
 // 问题：资源管理器的清理协程和资源访问之间可能存在竞态条件
 // 修复：使用适当的锁机制保护共享状态，确保清理协程的安全停止
 
+// 4. 并发安全检查器初始化与默认日志器的竞态
+// 问题：检查器在 init 中启动协程，等待一段时间后通过默认日志器输出，
+//       与 logger.go 的 init 对 DefaultLogger 的赋值之间没有同步，-race 下报告数据竞争
+// 修复：在 init 中同步记录，不输出日志，也不再启动协程
+
 func init() {
-	// 静默初始化并发安全检查器，避免启动时显示日志
-	go func() {
-		// 等待一小段时间确保logger初始化完成
-		time.Sleep(100 * time.Millisecond)
-
-		// 静默记录已修复的问题（仅用于内部统计，不输出日志）
-		checker := GetGlobalConcurrencyChecker()
-
-		// 静默记录熔断器修复
-		checker.reportIssueSilent(
-			"callback_safety",
-			"熔断器状态变化回调的并发安全问题",
-			"internal/common/circuit_breaker.go:setState",
-			"medium",
-		)
-		checker.markIssueFixedSilent(0)
-
-		// 静默记录优化器修复
-		checker.reportIssueSilent(
-			"goroutine_management",
-			"性能优化器缓存清理goroutine的启动条件",
-			"internal/performance/optimizer.go:NewQueryOptimizer",
-			"low",
-		)
-		checker.markIssueFixedSilent(1)
-
-		// 使用 DEBUG 级别记录初始化完成（正常情况下不会显示）
-		Debug("并发安全检查器已初始化，已记录修复的问题")
-	}()
+	// 静默记录已修复的问题（仅用于内部统计，不输出日志）
+	// 本文件的 init 先于 logger.go 执行，此时默认日志器尚未创建，不能输出日志
+	checker := GetGlobalConcurrencyChecker()
+
+	// 静默记录熔断器修复
+	checker.reportIssueSilent(
+		"callback_safety",
+		"熔断器状态变化回调的并发安全问题",
+		"internal/common/circuit_breaker.go:setState",
+		"medium",
+	)
+	checker.markIssueFixedSilent(0)
+
+	// 静默记录优化器修复
+	checker.reportIssueSilent(
+		"goroutine_management",
+		"性能优化器缓存清理goroutine的启动条件",
+		"internal/performance/optimizer.go:NewQueryOptimizer",
+		"low",
+	)
+	checker.markIssueFixedSilent(1)
 }
@@ -3,7 +3,10 @@ package common
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"sync"
 	"time"
 )
@@ -20,6 +23,12 @@ type ConnectionPoolConfig struct {
 	IdleTimeout time.Duration `json:"idle_timeout"`
 	// KeepAlive 保持连接时间
 	KeepAlive time.Duration `json:"keep_alive"`
+	// MaxConnsPerHost 每个主机的最大连接数（包括使用中和空闲的连接），达到上限后请求排队等待，0 表示不限制
+	MaxConnsPerHost int `json:"max_conns_per_host"`
+	// EnableHTTP2 是否尝试使用 HTTP/2，开启后同一主机的请求复用一个连接
+	EnableHTTP2 bool `json:"enable_http2"`
+	// DisableCompression 是否关闭响应压缩，默认请求 gzip 压缩的响应并透明解压
+	DisableCompression bool `json:"disable_compression"`
 }
 
 // DefaultConnectionPoolConfig 默认连接池配置
@@ -42,14 +51,26 @@ type ConnectionPool struct {
 }
 
 // PoolStats 连接池统计信息
+// 连接数按连接池拨号建立、尚未关闭的连接统计，使用中的连接数按进行中的请求估算：
+// HTTP/1.1 下每个进行中的请求占用一个连接，HTTP/2 下多个请求共用连接，空闲连接数可能偏小
 type PoolStats struct {
-	ActiveConnections int           `json:"active_connections"`
-	IdleConnections   int           `json:"idle_connections"`
-	TotalRequests     int64         `json:"total_requests"`
-	FailedRequests    int64         `json:"failed_requests"`
-	AverageLatency    time.Duration `json:"average_latency"`
-	LastRequestTime   time.Time     `json:"last_request_time"`
-	mutex             sync.RWMutex
+	OpenConnections     int           `json:"open_connections"`   // 已建立的连接数
+	ActiveConnections   int           `json:"active_connections"` // 使用中的连接数
+	IdleConnections     int           `json:"idle_connections"`   // 空闲的连接数
+	InFlightRequests    int           `json:"in_flight_requests"` // 进行中的请求数，响应体读取完毕并关闭后结束
+	TotalRequests       int64         `json:"total_requests"`
+	FailedRequests      int64         `json:"failed_requests"`
+	ReusedConnections   int64         `json:"reused_connections"`   // 复用已有连接的请求数
+	CompressedResponses int64         `json:"compressed_responses"` // gzip 压缩的响应数
+	CompressedBytes     int64         `json:"compressed_bytes"`     // 压缩响应实际传输的字节数
+	DecompressedBytes   int64         `json:"decompressed_bytes"`   // 压缩响应解压后的字节数
+	AverageLatency      time.Duration `json:"average_latency"`
+	AverageWaitTime     time.Duration `json:"average_wait_time"` // 请求获取连接的平均等待时间，包括新建连接的耗时
+	MaxWaitTime         time.Duration `json:"max_wait_time"`     // 请求获取连接的最长等待时间
+	LastRequestTime     time.Time     `json:"last_request_time"`
+	totalWaitTime       time.Duration
+	connRequests        int64
+	mutex               sync.RWMutex
 }
 
 // NewConnectionPool 创建新的连接池
@@ -63,24 +84,69 @@ func NewConnectionPool(config *ConnectionPoolConfig) *ConnectionPool {
 		config = DefaultConnectionPoolConfig()
 	}
 
-	// 创建 HTTP 客户端
+	cp := &ConnectionPool{
+		config: config,
+		stats:  &PoolStats{},
+	}
+	cp.httpClient = cp.newHTTPClient(config)
+	return cp
+}
+
+// newHTTPClient 按连接池配置创建 HTTP 客户端
+// 通过连接池拨号建立的连接计入 OpenConnections，连接关闭时扣除
+func (cp *ConnectionPool) newHTTPClient(config *ConnectionPoolConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   config.ConnectionTimeout,
+		KeepAlive: config.KeepAlive,
+	}
 	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			cp.updateConnectionStats(1)
+			return &countedConn{Conn: conn, onClose: func() { cp.updateConnectionStats(-1) }}, nil
+		},
 		MaxIdleConns:        config.MaxConnections,
 		MaxIdleConnsPerHost: config.MaxIdleConnections,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
 		IdleConnTimeout:     config.IdleTimeout,
 		DisableKeepAlives:   false,
+		ForceAttemptHTTP2:   config.EnableHTTP2,
+		DisableCompression:  true, // 压缩由 ExecuteRequest 处理，以便统计传输的字节数
 	}
 
-	httpClient := &http.Client{
+	return &http.Client{
 		Transport: transport,
 		Timeout:   config.ConnectionTimeout,
 	}
+}
 
-	return &ConnectionPool{
-		config:     config,
-		httpClient: httpClient,
-		stats:      &PoolStats{},
-	}
+// countedConn 计入连接池统计的连接，关闭时只扣除一次
+type countedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+// Close 关闭连接
+func (c *countedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
+
+// inFlightBody 进行中请求的响应体，关闭时结束请求
+type inFlightBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+// Close 关闭响应体
+func (b *inFlightBody) Close() error {
+	b.once.Do(b.done)
+	return b.ReadCloser.Close()
 }
 
 // GetHTTPClient 获取 HTTP 客户端
@@ -105,19 +171,37 @@ func (cp *ConnectionPool) ExecuteRequest(ctx context.Context, req *http.Request)
 
 	// 更新统计信息
 	cp.updateRequestStats(true)
+	cp.updateInFlightStats(1)
+
+	// 记录获取连接的等待时间
+	var getConn time.Time
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) { getConn = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) { cp.updateWaitStats(time.Since(getConn), info.Reused) },
+	})
 
 	// 执行请求
-	resp, err := cp.httpClient.Do(req.WithContext(ctx))
+	cp.mutex.RLock()
+	httpClient, config := cp.httpClient, cp.config
+	cp.mutex.RUnlock()
+	req = req.WithContext(ctx)
+	compressed := !config.DisableCompression && acceptGzip(req)
+	resp, err := httpClient.Do(req)
 
 	// 记录延迟
 	latency := time.Since(start)
 	cp.updateLatencyStats(latency)
 
 	if err != nil {
+		cp.updateInFlightStats(-1)
 		cp.updateRequestStats(false)
 		return nil, fmt.Errorf("HTTP 请求失败: %w", err)
 	}
 
+	if compressed {
+		decodeGzipResponse(resp, cp.updateCompressionStats)
+	}
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, done: func() { cp.updateInFlightStats(-1) }}
 	return resp, nil
 }
 
@@ -151,6 +235,57 @@ func (cp *ConnectionPool) updateLatencyStats(latency time.Duration) {
 	}
 }
 
+// updateConnectionStats 更新已建立的连接数
+// 参数:
+//   - delta: 新建连接为 1，关闭连接为 -1
+func (cp *ConnectionPool) updateConnectionStats(delta int) {
+	cp.stats.mutex.Lock()
+	defer cp.stats.mutex.Unlock()
+
+	cp.stats.OpenConnections += delta
+}
+
+// updateInFlightStats 更新进行中的请求数
+// 参数:
+//   - delta: 请求开始为 1，结束为 -1
+func (cp *ConnectionPool) updateInFlightStats(delta int) {
+	cp.stats.mutex.Lock()
+	defer cp.stats.mutex.Unlock()
+
+	cp.stats.InFlightRequests += delta
+}
+
+// updateWaitStats 更新获取连接的等待时间
+// 参数:
+//   - wait: 等待时间
+//   - reused: 是否复用已有连接
+func (cp *ConnectionPool) updateWaitStats(wait time.Duration, reused bool) {
+	cp.stats.mutex.Lock()
+	defer cp.stats.mutex.Unlock()
+
+	cp.stats.connRequests++
+	cp.stats.totalWaitTime += wait
+	if wait > cp.stats.MaxWaitTime {
+		cp.stats.MaxWaitTime = wait
+	}
+	if reused {
+		cp.stats.ReusedConnections++
+	}
+}
+
+// updateCompressionStats 更新压缩响应的统计信息
+// 参数:
+//   - compressed: 实际传输的字节数
+//   - decompressed: 解压后的字节数
+func (cp *ConnectionPool) updateCompressionStats(compressed, decompressed int64) {
+	cp.stats.mutex.Lock()
+	defer cp.stats.mutex.Unlock()
+
+	cp.stats.CompressedResponses++
+	cp.stats.CompressedBytes += compressed
+	cp.stats.DecompressedBytes += decompressed
+}
+
 // GetStats 获取连接池统计信息
 // 返回:
 //   - *PoolStats: 统计信息
@@ -159,14 +294,25 @@ func (cp *ConnectionPool) GetStats() *PoolStats {
 	defer cp.stats.mutex.RUnlock()
 
 	// 返回统计信息的副本
-	return &PoolStats{
-		ActiveConnections: cp.stats.ActiveConnections,
-		IdleConnections:   cp.stats.IdleConnections,
-		TotalRequests:     cp.stats.TotalRequests,
-		FailedRequests:    cp.stats.FailedRequests,
-		AverageLatency:    cp.stats.AverageLatency,
-		LastRequestTime:   cp.stats.LastRequestTime,
+	stats := &PoolStats{
+		OpenConnections:     cp.stats.OpenConnections,
+		ActiveConnections:   min(cp.stats.InFlightRequests, cp.stats.OpenConnections),
+		InFlightRequests:    cp.stats.InFlightRequests,
+		TotalRequests:       cp.stats.TotalRequests,
+		FailedRequests:      cp.stats.FailedRequests,
+		ReusedConnections:   cp.stats.ReusedConnections,
+		CompressedResponses: cp.stats.CompressedResponses,
+		CompressedBytes:     cp.stats.CompressedBytes,
+		DecompressedBytes:   cp.stats.DecompressedBytes,
+		AverageLatency:      cp.stats.AverageLatency,
+		MaxWaitTime:         cp.stats.MaxWaitTime,
+		LastRequestTime:     cp.stats.LastRequestTime,
+	}
+	stats.IdleConnections = stats.OpenConnections - stats.ActiveConnections
+	if cp.stats.connRequests > 0 {
+		stats.AverageWaitTime = cp.stats.totalWaitTime / time.Duration(cp.stats.connRequests)
 	}
+	return stats
 }
 
 // GetConfig 获取连接池配置
@@ -195,18 +341,11 @@ func (cp *ConnectionPool) UpdateConfig(config *ConnectionPoolConfig) error {
 	// 更新配置
 	cp.config = config
 
-	// 重新创建 HTTP 客户端
-	transport := &http.Transport{
-		MaxIdleConns:        config.MaxConnections,
-		MaxIdleConnsPerHost: config.MaxIdleConnections,
-		IdleConnTimeout:     config.IdleTimeout,
-		DisableKeepAlives:   false,
-	}
-
-	cp.httpClient = &http.Client{
-		Transport: transport,
-		Timeout:   config.ConnectionTimeout,
+	// 关闭原客户端的空闲连接并重新创建 HTTP 客户端
+	if transport, ok := cp.httpClient.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
 	}
+	cp.httpClient = cp.newHTTPClient(config)
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// colorSupport 按文件描述符缓存的终端颜色支持情况
+	colorSupport sync.Map
+	// colorDisabled 是否已通过 DisableColor 关闭颜色
+	colorDisabled atomic.Bool
+)
+
+// DisableColor 关闭所有 ANSI 颜色输出，对应 CLI 的 --no-color 选项
+func DisableColor() {
+	colorDisabled.Store(true)
+}
+
+// ColorEnabled 判断输出是否可以使用 ANSI 颜色
+// 调用过 DisableColor、设置了 NO_COLOR 环境变量、TERM=dumb、输出不是终端（重定向到文件或管道）时不使用颜色；
+// Windows 控制台需要开启虚拟终端处理才能解释 ANSI 转义序列，无法开启时（如旧版 cmd.exe）同样不使用颜色
+// 参数:
+//   - w: 输出目标
+//
+// 返回:
+//   - bool: 是否可以输出颜色
+func ColorEnabled(w io.Writer) bool {
+	if colorDisabled.Load() || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	if enabled, ok := colorSupport.Load(file.Fd()); ok {
+		return enabled.(bool)
+	}
+	enabled := isTerminal(file) && enableVirtualTerminal(file)
+	colorSupport.Store(file.Fd(), enabled)
+	return enabled
+}
+
+// isTerminal 判断文件是否为终端
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
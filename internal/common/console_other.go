@@ -0,0 +1,10 @@
+//go:build !windows
+
+package common
+
+import "os"
+
+// enableVirtualTerminal 开启终端的 ANSI 转义序列支持，非 Windows 终端默认支持
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
@@ -22,6 +22,9 @@ const (
 	// DefaultBatchSize 默认批量操作大小
 	DefaultBatchSize = 100
 
+	// DefaultPageSize 默认页面大小（飞书 API 未指定 page_size 时的默认值）
+	DefaultPageSize = 20
+
 	// MaxPageSize 最大页面大小（飞书 API 限制）
 	MaxPageSize = 500
 
@@ -82,7 +85,7 @@ const (
 
 // 性能监控相关常量
 const (
-	// SlowQueryThreshold 慢查询阈值
+	// SlowQueryThreshold 默认的慢查询阈值，CLI 未通过 --slow-query-threshold 或策略角色设置阈值时使用
 	SlowQueryThreshold = 100 * time.Millisecond
 )
 
@@ -0,0 +1,392 @@
+package common
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logBackupTimeFormat 历史日志文件名中的时间格式，按字典序排列即按时间排列
+const logBackupTimeFormat = "20060102T150405.000"
+
+// LogRotationConfig 日志文件切割配置
+type LogRotationConfig struct {
+	// MaxSizeMB 单个日志文件的最大大小（MB），超过后切割，0 表示不按大小切割
+	MaxSizeMB int `json:"max_size_mb"`
+	// MaxAge 单个日志文件的最长写入时间，超过后切割，0 表示不按时间切割
+	MaxAge time.Duration `json:"max_age"`
+	// MaxBackups 保留的历史文件数，超过时删除最旧的文件，0 表示全部保留
+	MaxBackups int `json:"max_backups"`
+	// Compress 是否使用 gzip 压缩历史文件
+	Compress bool `json:"compress"`
+}
+
+// DefaultLogRotationConfig 默认日志文件切割配置
+func DefaultLogRotationConfig() *LogRotationConfig {
+	return &LogRotationConfig{
+		MaxSizeMB:  100,
+		MaxAge:     24 * time.Hour,
+		MaxBackups: 7,
+		Compress:   true,
+	}
+}
+
+// LogRotationConfigFromEnv 使用环境变量覆盖日志文件切割配置
+//   - BASESQL_LOG_MAX_SIZE_MB: 单个文件最大大小（MB）
+//   - BASESQL_LOG_MAX_AGE: 单个文件最长写入时间，如 24h
+//   - BASESQL_LOG_MAX_BACKUPS: 保留的历史文件数
+//   - BASESQL_LOG_COMPRESS: 是否压缩历史文件，true 或 false
+//
+// 参数:
+//   - base: 基础配置，为 nil 时使用默认配置
+//
+// 返回:
+//   - *LogRotationConfig: 覆盖后的配置
+//   - error: 环境变量格式错误
+func LogRotationConfigFromEnv(base *LogRotationConfig) (*LogRotationConfig, error) {
+	if base == nil {
+		base = DefaultLogRotationConfig()
+	}
+	config := *base
+
+	if value := GetEnv("BASESQL_LOG_MAX_SIZE_MB", ""); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("BASESQL_LOG_MAX_SIZE_MB 必须是非负整数: %s", value)
+		}
+		config.MaxSizeMB = n
+	}
+	if value := GetEnv("BASESQL_LOG_MAX_AGE", ""); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("BASESQL_LOG_MAX_AGE 必须是非负时长，如 24h: %s", value)
+		}
+		config.MaxAge = d
+	}
+	if value := GetEnv("BASESQL_LOG_MAX_BACKUPS", ""); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("BASESQL_LOG_MAX_BACKUPS 必须是非负整数: %s", value)
+		}
+		config.MaxBackups = n
+	}
+	if value := GetEnv("BASESQL_LOG_COMPRESS", ""); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("BASESQL_LOG_COMPRESS 必须是 true 或 false: %s", value)
+		}
+		config.Compress = b
+	}
+	return &config, nil
+}
+
+// RotatingFile 按大小和时间自动切割的日志文件
+// 切割时当前文件重命名为 <名称>-<时间>.<扩展名>，按配置压缩并只保留最近的若干个历史文件
+type RotatingFile struct {
+	path     string
+	config   LogRotationConfig
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	wg       sync.WaitGroup // 等待后台压缩和清理完成
+	pending  []string       // 等待压缩和清理的历史文件，按切割顺序排列
+	working  bool           // 是否有后台协程正在处理 pending
+}
+
+// NewRotatingFile 打开日志文件，文件已存在时追加写入
+// 参数:
+//   - path: 日志文件路径
+//   - config: 切割配置，为 nil 时使用默认配置
+//
+// 返回:
+//   - *RotatingFile: 日志文件
+//   - error: 打开文件失败时返回错误
+func NewRotatingFile(path string, config *LogRotationConfig) (*RotatingFile, error) {
+	if config == nil {
+		config = DefaultLogRotationConfig()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	rf := &RotatingFile{path: path, config: *config}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	// 已有文件超过最长写入时间时先切割，避免继续写入很久以前的文件
+	if info, err := rf.file.Stat(); err == nil && rf.config.MaxAge > 0 && info.Size() > 0 &&
+		time.Since(info.ModTime()) > rf.config.MaxAge {
+		if err := rf.rotate(); err != nil {
+			rf.file.Close()
+			return nil, err
+		}
+	}
+	return rf, nil
+}
+
+// Write 写入日志，写入前检查是否需要切割
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, fmt.Errorf("日志文件已关闭")
+	}
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即切割日志文件
+// 返回:
+//   - error: 切割失败时返回错误
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return fmt.Errorf("日志文件已关闭")
+	}
+	return rf.rotate()
+}
+
+// Close 关闭日志文件，并等待后台的压缩和清理完成
+// 返回:
+//   - error: 关闭文件失败时返回错误
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	rf.mu.Unlock()
+
+	rf.wg.Wait()
+	return err
+}
+
+// open 打开当前日志文件
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+// shouldRotate 判断写入 n 字节前是否需要切割，空文件不切割
+func (rf *RotatingFile) shouldRotate(n int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.config.MaxSizeMB > 0 && rf.size+n > int64(rf.config.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	return rf.config.MaxAge > 0 && time.Since(rf.openedAt) > rf.config.MaxAge
+}
+
+// rotate 将当前文件重命名为历史文件并重新打开，调用方需持有锁
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("关闭日志文件失败: %w", err)
+	}
+	rf.file = nil
+
+	backup := rf.backupName(time.Now())
+	if err := os.Rename(rf.path, backup); err != nil {
+		// 重命名失败时继续写入原文件，避免之后的写入都因文件已关闭而失败
+		if openErr := rf.open(); openErr != nil {
+			return errors.Join(fmt.Errorf("重命名日志文件失败: %w", err), openErr)
+		}
+		return fmt.Errorf("重命名日志文件失败: %w", err)
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	// 历史文件交给唯一的后台协程按切割顺序依次压缩和清理，避免清理删除正在压缩的文件
+	rf.pending = append(rf.pending, backup)
+	if !rf.working {
+		rf.working = true
+		rf.wg.Add(1)
+		go rf.processBackups()
+	}
+	return nil
+}
+
+// processBackups 依次压缩 pending 中的历史文件并清理超出保留数量的文件，没有待处理的文件时退出
+func (rf *RotatingFile) processBackups() {
+	defer rf.wg.Done()
+	for {
+		rf.mu.Lock()
+		if len(rf.pending) == 0 {
+			rf.working = false
+			rf.mu.Unlock()
+			return
+		}
+		backup := rf.pending[0]
+		rf.pending = rf.pending[1:]
+		rf.mu.Unlock()
+
+		// 连续切割时较早的历史文件可能已经超出保留数量并被清理，不再压缩
+		if rf.config.Compress {
+			if err := compressLogFile(backup); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "压缩日志文件 %s 失败: %v\n", backup, err)
+			}
+		}
+		if err := rf.removeOldBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "清理历史日志文件失败: %v\n", err)
+		}
+	}
+}
+
+// backupName 生成历史文件名，如 basesql-20240102T150405.000.log
+// 同一毫秒内多次切割时追加序号，如 basesql-20240102T150405.000-1.log，避免覆盖已有的历史文件
+func (rf *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(rf.path)
+	prefix := strings.TrimSuffix(rf.path, ext)
+	stamp := t.Format(logBackupTimeFormat)
+	name := fmt.Sprintf("%s-%s%s", prefix, stamp, ext)
+	for seq := 1; fileExists(name) || fileExists(name+".gz"); seq++ {
+		name = fmt.Sprintf("%s-%s-%d%s", prefix, stamp, seq, ext)
+	}
+	return name
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// parseBackupStamp 解析历史文件名中的时间和序号，如 20240102T150405.000-1
+func parseBackupStamp(stamp string) (time.Time, int, bool) {
+	seq := 0
+	if i := strings.LastIndex(stamp, "-"); i >= 0 {
+		n, err := strconv.Atoi(stamp[i+1:])
+		if err != nil || n < 1 {
+			return time.Time{}, 0, false
+		}
+		stamp, seq = stamp[:i], n
+	}
+	t, err := time.Parse(logBackupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+// backups 获取全部历史文件，按时间从旧到新排列
+func (rf *RotatingFile) backups() ([]string, error) {
+	dir := filepath.Dir(rf.path)
+	ext := filepath.Ext(rf.path)
+	prefix := strings.TrimSuffix(filepath.Base(rf.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		path string
+		time time.Time
+		seq  int
+	}
+	var found []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		t, seq, ok := parseBackupStamp(stamp)
+		if !ok {
+			continue
+		}
+		found = append(found, backup{path: filepath.Join(dir, name), time: t, seq: seq})
+	}
+	// 序号使同一毫秒内的历史文件按切割顺序排列
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].time.Equal(found[j].time) {
+			return found[i].time.Before(found[j].time)
+		}
+		return found[i].seq < found[j].seq
+	})
+	backups := make([]string, 0, len(found))
+	for _, b := range found {
+		backups = append(backups, b.path)
+	}
+	return backups, nil
+}
+
+// removeOldBackups 删除超出保留数量的最旧历史文件
+func (rf *RotatingFile) removeOldBackups() error {
+	if rf.config.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := rf.backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > rf.config.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// compressLogFile 将文件压缩为 <文件名>.gz 并删除原文件
+func compressLogFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
@@ -1,13 +1,18 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/ag9920/basesql/internal/security"
 )
 
 // LogLevel 日志级别
@@ -46,42 +51,53 @@ type LogEntry struct {
 	Message   string                 `json:"message"`
 	Fields    map[string]interface{} `json:"fields,omitempty"`
 	Caller    string                 `json:"caller,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
 	Error     string                 `json:"error,omitempty"`
 }
 
 // Logger 结构化日志器
+// 日志输出到标准错误，避免与命令输出的查询结果混在一起；消息、错误和字符串字段中的敏感信息会被遮蔽
 type Logger struct {
 	level      LogLevel
-	output     *os.File
+	output     io.Writer
 	structured bool
 	fields     map[string]interface{}
+	masker     *security.SensitiveDataMasker
+	mu         *sync.Mutex // 与 WithField 派生的日志器共享，保证并发写入的日志不交错
 }
 
 // DefaultLogger 默认日志器实例
 var DefaultLogger *Logger
 
-// init 初始化默认日志器
+// init 初始化默认日志器，默认只输出警告及以上级别，调试模式下由 InitializeLogging 调整
 func init() {
-	DefaultLogger = NewLogger(LogLevelInfo, false)
+	DefaultLogger = NewLogger(LogLevelWarn, false)
 }
 
 // NewLogger 创建新的日志器
 func NewLogger(level LogLevel, structured bool) *Logger {
 	return &Logger{
 		level:      level,
-		output:     os.Stdout,
+		output:     os.Stderr,
 		structured: structured,
 		fields:     make(map[string]interface{}),
+		masker:     security.NewSensitiveDataMasker(),
+		mu:         &sync.Mutex{},
 	}
 }
 
+// GetLevel 获取日志级别
+func (l *Logger) GetLevel() LogLevel {
+	return l.level
+}
+
 // SetLevel 设置日志级别
 func (l *Logger) SetLevel(level LogLevel) {
 	l.level = level
 }
 
 // SetOutput 设置输出目标
-func (l *Logger) SetOutput(output *os.File) {
+func (l *Logger) SetOutput(output io.Writer) {
 	l.output = output
 }
 
@@ -92,21 +108,7 @@ func (l *Logger) SetStructured(structured bool) {
 
 // WithField 添加字段
 func (l *Logger) WithField(key string, value interface{}) *Logger {
-	newLogger := &Logger{
-		level:      l.level,
-		output:     l.output,
-		structured: l.structured,
-		fields:     make(map[string]interface{}),
-	}
-
-	// 复制现有字段
-	for k, v := range l.fields {
-		newLogger.fields[k] = v
-	}
-
-	// 添加新字段
-	newLogger.fields[key] = value
-	return newLogger
+	return l.WithFields(map[string]interface{}{key: value})
 }
 
 // WithFields 添加多个字段
@@ -115,7 +117,9 @@ func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
 		level:      l.level,
 		output:     l.output,
 		structured: l.structured,
-		fields:     make(map[string]interface{}),
+		fields:     make(map[string]interface{}, len(l.fields)+len(fields)),
+		masker:     l.masker,
+		mu:         l.mu,
 	}
 
 	// 复制现有字段
@@ -131,6 +135,26 @@ func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
 	return newLogger
 }
 
+// WithLevel 返回指定级别的日志器副本，与原日志器共享输出目标和字段
+func (l *Logger) WithLevel(level LogLevel) *Logger {
+	newLogger := l.WithFields(nil)
+	newLogger.level = level
+	return newLogger
+}
+
+// WithTraceID 添加追踪 ID，输出在日志条目的 trace_id 中
+func (l *Logger) WithTraceID(traceID string) *Logger {
+	return l.WithField("trace_id", traceID)
+}
+
+// WithContext 添加上下文中的追踪 ID，上下文没有追踪 ID 时返回原日志器
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		return l.WithTraceID(traceID)
+	}
+	return l
+}
+
 // WithError 添加错误字段
 func (l *Logger) WithError(err error) *Logger {
 	if err == nil {
@@ -148,8 +172,22 @@ func (l *Logger) log(level LogLevel, message string, err error) {
 	entry := LogEntry{
 		Timestamp: time.Now(),
 		Level:     level,
-		Message:   message,
-		Fields:    l.fields,
+		Message:   l.masker.MaskSensitiveData(message),
+		Fields:    make(map[string]interface{}, len(l.fields)),
+	}
+
+	// 复制字段并遮蔽敏感信息，追踪 ID 单独输出
+	for k, v := range l.fields {
+		switch value := v.(type) {
+		case string:
+			if k == "trace_id" {
+				entry.TraceID = value
+				continue
+			}
+			entry.Fields[k] = l.masker.MaskSensitiveData(value)
+		default:
+			entry.Fields[k] = v
+		}
 	}
 
 	// 添加调用者信息
@@ -159,9 +197,12 @@ func (l *Logger) log(level LogLevel, message string, err error) {
 
 	// 添加错误信息
 	if err != nil {
-		entry.Error = err.Error()
+		entry.Error = l.masker.MaskSensitiveData(err.Error())
 	}
 
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if l.structured {
 		// 结构化输出（JSON）
 		if data, err := json.Marshal(entry); err == nil {
@@ -202,6 +243,9 @@ func (l *Logger) formatHumanReadable(entry LogEntry) {
 	}
 
 	reset := "\033[0m"
+	if !ColorEnabled(l.output) {
+		color, reset = "", ""
+	}
 
 	// 基本信息
 	var parts []string
@@ -216,6 +260,11 @@ func (l *Logger) formatHumanReadable(entry LogEntry) {
 	// 消息
 	parts = append(parts, entry.Message)
 
+	// 追踪 ID
+	if entry.TraceID != "" {
+		parts = append(parts, "trace_id="+entry.TraceID)
+	}
+
 	// 字段信息
 	if len(entry.Fields) > 0 {
 		var fieldParts []string
@@ -304,7 +353,7 @@ func SetLogLevel(level LogLevel) {
 }
 
 // SetLogOutput 设置全局日志输出
-func SetLogOutput(output *os.File) {
+func SetLogOutput(output io.Writer) {
 	DefaultLogger.SetOutput(output)
 }
 
@@ -374,20 +423,21 @@ func FatalWithErr(message string, err error) {
 }
 
 // LogSQLExecution 记录SQL执行日志
-func LogSQLExecution(sql string, duration time.Duration, err error) {
-	logger := DefaultLogger.WithFields(map[string]interface{}{
+// 成功和失败都记为调试日志，错误由调用方向用户报告，普通模式下不重复输出
+func LogSQLExecution(ctx context.Context, sql string, duration time.Duration, err error) {
+	logger := DefaultLogger.WithContext(ctx).WithFields(map[string]interface{}{
 		"sql":      sql,
 		"duration": duration.String(),
 	})
 
 	if err != nil {
-		logger.WithError(err).Error("SQL execution failed")
+		logger.WithError(err).Debug("SQL execution failed")
 	} else {
-		logger.Info("SQL execution completed")
+		logger.Debug("SQL execution completed")
 	}
 }
 
-// LogAPIRequest 记录API请求日志
+// LogAPIRequest 记录API请求日志，记为调试日志
 func LogAPIRequest(method, url string, statusCode int, duration time.Duration, err error) {
 	logger := DefaultLogger.WithFields(map[string]interface{}{
 		"method":      method,
@@ -397,39 +447,60 @@ func LogAPIRequest(method, url string, statusCode int, duration time.Duration, e
 	})
 
 	if err != nil {
-		logger.WithError(err).Error("API request failed")
+		logger.WithError(err).Debug("API request failed")
 	} else if statusCode >= 400 {
-		logger.Warn("API request completed with error status")
+		logger.Debug("API request completed with error status")
 	} else {
-		logger.Info("API request completed successfully")
+		logger.Debug("API request completed successfully")
 	}
 }
 
-// LogPerformanceMetrics 记录性能指标
+// LogPerformanceMetrics 记录性能指标，记为调试日志
 func LogPerformanceMetrics(operation string, metrics map[string]interface{}) {
 	logger := DefaultLogger.WithField("operation", operation).WithFields(metrics)
-	logger.Info("Performance metrics")
+	logger.Debug("Performance metrics")
 }
 
+// logFileOutput 当前的日志文件，重新初始化日志系统时关闭
+var logFileOutput *RotatingFile
+
 // InitializeLogging 初始化日志系统
-func InitializeLogging(debug bool, logFile string) error {
-	// 设置日志级别
+// 指定日志文件时写入结构化日志，并按切割配置自动切割、压缩和清理历史文件
+// 参数:
+//   - debug: 是否输出调试日志
+//   - logFile: 日志文件路径，为空时输出到标准输出
+//   - rotation: 日志文件切割配置，为 nil 时使用默认配置，并由 BASESQL_LOG_* 环境变量覆盖
+//
+// 返回:
+//   - error: 初始化错误
+func InitializeLogging(debug bool, logFile string, rotation *LogRotationConfig) error {
+	// 设置日志级别：调试模式输出请求和语句的详细信息，普通模式只输出警告和错误
 	if debug {
 		SetLogLevel(LogLevelDebug)
-		SetStructuredLogging(false) // 调试模式使用人类可读格式
 	} else {
-		SetLogLevel(LogLevelInfo)
-		SetStructuredLogging(false) // 默认使用人类可读格式
+		SetLogLevel(LogLevelWarn)
 	}
+	SetStructuredLogging(false) // 终端输出使用人类可读格式
 
 	// 设置日志文件
 	if logFile != "" {
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if rotation == nil {
+			var err error
+			if rotation, err = LogRotationConfigFromEnv(nil); err != nil {
+				return err
+			}
+		}
+		file, err := NewRotatingFile(logFile, rotation)
 		if err != nil {
 			return fmt.Errorf("failed to open log file: %w", err)
 		}
 		SetLogOutput(file)
 		SetStructuredLogging(true) // 文件输出使用结构化格式
+
+		if logFileOutput != nil {
+			logFileOutput.Close()
+		}
+		logFileOutput = file
 	}
 
 	return nil
@@ -124,6 +124,26 @@ func (tb *TokenBucket) AllowN(n int) bool {
 	return false
 }
 
+// Refund 归还 Allow 取走的一个令牌
+// 请求通过本令牌桶后又被其他令牌桶拒绝时调用，该请求改为计入拒绝统计
+func (tb *TokenBucket) Refund() {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	tb.refill(time.Now())
+	tb.tokens++
+	if tb.tokens > float64(tb.config.Burst) {
+		tb.tokens = float64(tb.config.Burst)
+	}
+
+	tb.stats.mutex.Lock()
+	if tb.stats.AllowedRequests > 0 {
+		tb.stats.AllowedRequests--
+	}
+	tb.stats.RejectedRequests++
+	tb.stats.mutex.Unlock()
+}
+
 // Wait 等待直到可以执行请求
 // 参数:
 //   - ctx: 上下文
@@ -76,9 +76,12 @@ type SQLCommand struct {
 	// ShowType SHOW 命令的子类型（TABLES、COLUMNS 等）
 	ShowType string `json:"show_type,omitempty"`
 
-	// OrderBy 排序字段
+	// OrderBy 排序字段，按声明顺序排列，降序的字段名前加 -（与飞书 API 的排序格式相同）
 	OrderBy []string `json:"order_by,omitempty"`
 
+	// GroupBy 分组字段（用于 SELECT ... GROUP BY）
+	GroupBy []string `json:"group_by,omitempty"`
+
 	// Limit 限制返回记录数
 	Limit int `json:"limit,omitempty"`
 
@@ -93,6 +96,35 @@ type SQLCommand struct {
 
 	// IsAggregate 是否是聚合查询
 	IsAggregate bool `json:"is_aggregate,omitempty"`
+
+	// OutFile SELECT ... INTO OUTFILE 指定的输出文件路径
+	OutFile string `json:"out_file,omitempty"`
+
+	// Explain 是否只返回执行计划（EXPLAIN SELECT）
+	Explain bool `json:"explain,omitempty"`
+
+	// Union 以 UNION、UNION ALL 连接的各条 SELECT，按声明顺序排列；
+	// 非空时该命令为合并查询，Fields 为第一条 SELECT 的投影，OrderBy 和 Limit 作用于合并后的结果
+	Union []*SQLCommand `json:"union,omitempty"`
+
+	// UnionAll 该 SELECT 以 UNION ALL 连接到之前的结果，合并时不去重
+	UnionAll bool `json:"union_all,omitempty"`
+
+	// With WITH 子句中的公用表表达式，按声明顺序排列，后面的查询可以引用前面的名称
+	With []*WithQuery `json:"with,omitempty"`
+}
+
+// WithQuery WITH 子句中的一个公用表表达式（非递归）
+// 查询结果在执行时物化到内存中，语句的其余部分按名称引用，如同一张只读的表
+type WithQuery struct {
+	// Name 引用时使用的名称
+	Name string `json:"name"`
+
+	// Columns 列名列表，为空时使用查询的列名
+	Columns []string `json:"columns,omitempty"`
+
+	// Query 定义公用表表达式的 SELECT
+	Query *SQLCommand `json:"query"`
 }
 
 // NewSQLCommand 创建新的 SQL 命令
@@ -22,9 +22,8 @@ func (p *SQLParser) ParseValue(valueStr string) interface{} {
 	}
 
 	// 移除首尾引号
-	if (strings.HasPrefix(valueStr, "'") && strings.HasSuffix(valueStr, "'")) ||
-		(strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"")) {
-		return valueStr[1 : len(valueStr)-1]
+	if unquoted, ok := UnquoteSQLString(valueStr); ok {
+		return unquoted
 	}
 
 	// 尝试解析为数字
@@ -45,16 +44,36 @@ func (p *SQLParser) ParseValue(valueStr string) interface{} {
 	return valueStr
 }
 
+// QuoteSQLString 将字符串格式化为单引号字面量，值中的单引号写为两个单引号
+func QuoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// UnquoteSQLString 去掉字面量两侧的单引号或双引号，并将其中连续的两个引号还原为一个
+// 参数:
+//   - s: 加引号的字面量
+//
+// 返回:
+//   - string: 去掉引号后的值
+//   - bool: s 是否为加引号的字面量
+func UnquoteSQLString(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return s, false
+	}
+	quote := s[:1]
+	return strings.ReplaceAll(s[1:len(s)-1], quote+quote, quote), true
+}
+
 // ParseSelectSQL 解析SELECT语句
 func (p *SQLParser) ParseSelectSQL(sql string, cmd *SQLCommand) (*SQLCommand, error) {
 	cmd.Type = CommandSelect
 
-	// SELECT fields FROM table [WHERE condition] [LIMIT number]
-	re := regexp.MustCompile(`(?i)SELECT\s+(.*?)\s+FROM\s+([^\s;]+)(?:\s+WHERE\s+(.*?))?(?:\s+LIMIT\s+(\d+))?(?:\s*;\s*)?$`)
+	// SELECT fields FROM table [WHERE condition] [GROUP BY fields] [ORDER BY field [ASC|DESC], ...] [LIMIT number]
+	re := regexp.MustCompile(`(?i)SELECT\s+(.*?)\s+FROM\s+([^\s;]+)(?:\s+WHERE\s+(.*?))?(?:\s+GROUP\s+BY\s+(.*?))?(?:\s+ORDER\s+BY\s+(.*?))?(?:\s+LIMIT\s+(\d+))?(?:\s*;\s*)?$`)
 	matches := re.FindStringSubmatch(sql)
 
 	if len(matches) < 3 {
-		return nil, fmt.Errorf("SELECT 语法错误，正确格式: SELECT fields FROM table [WHERE condition] [LIMIT number]")
+		return nil, fmt.Errorf("SELECT 语法错误，正确格式: SELECT fields FROM table [WHERE condition] [GROUP BY fields] [ORDER BY field [ASC|DESC]] [LIMIT number]")
 	}
 
 	// 解析字段列表
@@ -63,11 +82,20 @@ func (p *SQLParser) ParseSelectSQL(sql string, cmd *SQLCommand) (*SQLCommand, er
 		return nil, err
 	}
 
+	// 解析 GROUP BY 子句，分组查询的投影逐列解析，聚合列在执行时按组计算
+	if len(matches) > 4 && matches[4] != "" {
+		for _, field := range p.parseFieldList(matches[4]) {
+			cmd.GroupBy = append(cmd.GroupBy, trimIdentifier(field))
+		}
+		if len(cmd.GroupBy) == 0 {
+			return nil, fmt.Errorf("GROUP BY 字段列表解析失败")
+		}
+	}
+
 	// 检查是否包含聚合函数
-	aggregateRe := regexp.MustCompile(`(?i)(COUNT|SUM|AVG|MIN|MAX)\s*\(\s*(\*|[^\)]+)\s*\)`)
 	aggregateMatches := aggregateRe.FindStringSubmatch(fieldsStr)
 
-	if len(aggregateMatches) >= 3 {
+	if len(aggregateMatches) >= 3 && len(cmd.GroupBy) == 0 {
 		// 这是一个聚合查询
 		cmd.IsAggregate = true
 		cmd.AggregateFunction = strings.ToUpper(aggregateMatches[1])
@@ -100,9 +128,18 @@ func (p *SQLParser) ParseSelectSQL(sql string, cmd *SQLCommand) (*SQLCommand, er
 		cmd.Condition = conditions
 	}
 
+	// 解析 ORDER BY 子句
+	if len(matches) > 5 && matches[5] != "" {
+		orderBy, err := p.parseOrderBy(matches[5])
+		if err != nil {
+			return nil, fmt.Errorf("ORDER BY 解析失败: %w", err)
+		}
+		cmd.OrderBy = orderBy
+	}
+
 	// 解析 LIMIT 子句
-	if len(matches) > 4 && matches[4] != "" {
-		limitStr := strings.TrimSpace(matches[4])
+	if len(matches) > 6 && matches[6] != "" {
+		limitStr := strings.TrimSpace(matches[6])
 		limit, err := strconv.Atoi(limitStr)
 		if err != nil {
 			return nil, fmt.Errorf("LIMIT 值必须是数字: %s", limitStr)
@@ -252,11 +289,13 @@ func (p *SQLParser) ParseDeleteSQL(sql string, cmd *SQLCommand) (*SQLCommand, er
 }
 
 // parseFieldList 解析字段列表
+// 引号和括号中的逗号不作为分隔符，如 CASE WHEN status IN ('a', 'b') THEN ... END
 func (p *SQLParser) parseFieldList(fieldsStr string) []string {
 	var fields []string
 	var current strings.Builder
 	inQuotes := false
 	quoteChar := byte(0)
+	depth := 0
 
 	for i := 0; i < len(fieldsStr); i++ {
 		char := fieldsStr[i]
@@ -265,7 +304,11 @@ func (p *SQLParser) parseFieldList(fieldsStr string) []string {
 			if char == '\'' || char == '"' {
 				inQuotes = true
 				quoteChar = char
-			} else if char == ',' {
+			} else if char == '(' {
+				depth++
+			} else if char == ')' && depth > 0 {
+				depth--
+			} else if char == ',' && depth == 0 {
 				field := strings.TrimSpace(current.String())
 				if field != "" {
 					fields = append(fields, field)
@@ -294,6 +337,102 @@ func (p *SQLParser) parseFieldList(fieldsStr string) []string {
 	return fields
 }
 
+// parseOrderBy 解析 ORDER BY 子句
+// 每项为 "field [ASC|DESC]"，结果与飞书 API 的排序格式相同，降序的字段名前加 -
+// 参数:
+//   - orderStr: ORDER BY 之后的内容
+//
+// 返回:
+//   - []string: 排序条件，按声明顺序排列
+//   - error: 解析错误
+func (p *SQLParser) parseOrderBy(orderStr string) ([]string, error) {
+	var keys []string
+	for _, item := range p.parseFieldList(orderStr) {
+		parts := strings.Fields(item)
+		desc := false
+		if n := len(parts); n > 1 {
+			switch strings.ToUpper(parts[n-1]) {
+			case "DESC":
+				desc = true
+				parts = parts[:n-1]
+			case "ASC":
+				parts = parts[:n-1]
+			}
+		}
+		name := trimIdentifier(strings.Join(parts, " "))
+		if name == "" {
+			return nil, fmt.Errorf("排序字段不能为空: %q", item)
+		}
+		if desc {
+			name = "-" + name
+		}
+		keys = append(keys, name)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("排序字段不能为空")
+	}
+	return keys, nil
+}
+
+// aggregateRe 匹配聚合函数调用
+var aggregateRe = regexp.MustCompile(`(?i)(COUNT|SUM|AVG|MIN|MAX)\s*\(\s*(\*|[^\)]+)\s*\)`)
+
+// ParseAggregate 解析聚合函数表达式，如 COUNT(*)、SUM(amount)
+// 参数:
+//   - expr: 列表达式，不含别名
+//
+// 返回:
+//   - string: 大写的聚合函数名
+//   - string: 聚合函数作用的字段，COUNT(*) 为 *
+//   - bool: 是否为聚合函数表达式
+func ParseAggregate(expr string) (string, string, bool) {
+	matches := aggregateRe.FindStringSubmatch(strings.TrimSpace(expr))
+	if len(matches) < 3 || matches[0] != strings.TrimSpace(expr) {
+		return "", "", false
+	}
+	return strings.ToUpper(matches[1]), trimIdentifier(matches[2]), true
+}
+
+// selectAliasRe 匹配带 AS 关键字的列别名
+var selectAliasRe = regexp.MustCompile(`(?i)^(.+?)\s+AS\s+(.+)$`)
+
+// caseAliasRe 匹配省略 AS 关键字的 CASE 表达式列别名
+var caseAliasRe = regexp.MustCompile(`(?is)^(CASE\s.*\sEND)\s+(\S+)$`)
+
+// ParseSelectColumn 解析 SELECT 投影中的单个列表达式
+// 支持 "field"、"field AS alias" 与 "field alias" 三种写法，CASE ... END 表达式同样可以带别名，
+// 标识符两侧的反引号和引号会被去除
+// 参数:
+//   - expr: 列表达式
+//
+// 返回:
+//   - string: 字段名
+//   - string: 别名，未指定时与字段名相同
+func ParseSelectColumn(expr string) (string, string) {
+	expr = strings.TrimSpace(expr)
+	field, alias := expr, ""
+
+	if matches := selectAliasRe.FindStringSubmatch(expr); len(matches) == 3 {
+		field, alias = matches[1], matches[2]
+	} else if matches := caseAliasRe.FindStringSubmatch(expr); len(matches) == 3 {
+		field, alias = matches[1], matches[2]
+	} else if parts := strings.Fields(expr); len(parts) == 2 {
+		field, alias = parts[0], parts[1]
+	}
+
+	field = trimIdentifier(field)
+	alias = trimIdentifier(alias)
+	if alias == "" {
+		alias = field
+	}
+	return field, alias
+}
+
+// trimIdentifier 去除标识符两侧的空白、反引号和引号
+func trimIdentifier(name string) string {
+	return strings.Trim(strings.TrimSpace(name), "`'\"")
+}
+
 // parseValueList 解析值列表
 func (p *SQLParser) parseValueList(valuesStr string) []interface{} {
 	var values []interface{}
@@ -317,6 +456,12 @@ func (p *SQLParser) parseValueList(valuesStr string) []interface{} {
 			}
 		} else {
 			if char == quoteChar {
+				// 引号中连续的两个引号表示一个引号
+				if i+1 < len(valuesStr) && valuesStr[i+1] == quoteChar {
+					current.WriteByte(char)
+					i++
+					continue
+				}
 				inQuotes = false
 				quoteChar = 0
 				continue
@@ -374,10 +519,7 @@ func (p *SQLParser) parseSetClause(setClause string) (map[string]interface{}, er
 		valueStr := strings.TrimSpace(parts[1])
 
 		// 移除值周围的引号
-		if (strings.HasPrefix(valueStr, "'") && strings.HasSuffix(valueStr, "'")) ||
-			(strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"")) {
-			valueStr = valueStr[1 : len(valueStr)-1]
-		}
+		valueStr, _ = UnquoteSQLString(valueStr)
 
 		updateFields[field] = p.parseValue(valueStr)
 	}
@@ -401,10 +543,7 @@ func (p *SQLParser) parseWhereClause(whereClause string) (map[string]interface{}
 		valueStr := strings.TrimSpace(likeMatches[2])
 
 		// 移除值周围的引号
-		if (strings.HasPrefix(valueStr, "'") && strings.HasSuffix(valueStr, "'")) ||
-			(strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"")) {
-			valueStr = valueStr[1 : len(valueStr)-1]
-		}
+		valueStr, _ = UnquoteSQLString(valueStr)
 
 		// 为 LIKE 操作添加特殊标记
 		return map[string]interface{}{
@@ -413,6 +552,16 @@ func (p *SQLParser) parseWhereClause(whereClause string) (map[string]interface{}
 		}, nil
 	}
 
+	// 匹配 IN 操作符，值为括号中的列表或日期宏（如 THIS_WEEK）
+	inRe := regexp.MustCompile(`(?i)^\s*([^\s]+)\s+IN\s+(.+)$`)
+	if inMatches := inRe.FindStringSubmatch(whereClause); len(inMatches) >= 3 {
+		field := strings.TrimSpace(inMatches[1])
+		return map[string]interface{}{
+			field:                strings.TrimSpace(inMatches[2]),
+			"_operator_" + field: "IN",
+		}, nil
+	}
+
 	// 匹配其他比较操作符
 	compareRe := regexp.MustCompile(`([^\s<>=!]+)\s*(>=|<=|!=|>|<|=)\s*(.+)`)
 	compareMatches := compareRe.FindStringSubmatch(whereClause)
@@ -423,10 +572,7 @@ func (p *SQLParser) parseWhereClause(whereClause string) (map[string]interface{}
 		valueStr := strings.TrimSpace(compareMatches[3])
 
 		// 移除值周围的引号
-		if (strings.HasPrefix(valueStr, "'") && strings.HasSuffix(valueStr, "'")) ||
-			(strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"")) {
-			valueStr = valueStr[1 : len(valueStr)-1]
-		}
+		valueStr, _ = UnquoteSQLString(valueStr)
 
 		result := map[string]interface{}{field: p.parseValue(valueStr)}
 		// 如果不是等号，添加操作符信息
@@ -438,7 +584,7 @@ func (p *SQLParser) parseWhereClause(whereClause string) (map[string]interface{}
 	}
 
 	// 如果都不匹配，返回错误
-	return nil, fmt.Errorf("WHERE 条件格式错误，支持的格式: field = value, field LIKE 'pattern', field > value, field < value, field >= value, field <= value, field != value")
+	return nil, fmt.Errorf("WHERE 条件格式错误，支持的格式: field = value, field LIKE 'pattern', field IN (values), field > value, field < value, field >= value, field <= value, field != value")
 }
 
 // DefaultSQLParser 默认的SQL解析器实例
@@ -0,0 +1,362 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TableStyle 表格渲染样式
+type TableStyle string
+
+// 支持的表格样式
+const (
+	TableStyleASCII      TableStyle = "ascii"      // 带边框的 ASCII 表格（默认）
+	TableStyleBorderless TableStyle = "borderless" // 无边框，列之间以空格分隔
+	TableStyleMarkdown   TableStyle = "markdown"   // Markdown 表格，便于粘贴到文档
+)
+
+// DefaultMaxColumnWidth 表格单列的默认最大显示宽度
+const DefaultMaxColumnWidth = 30
+
+// ParseTableStyle 解析表格样式名称
+// 参数:
+//   - name: 样式名称，为空时返回默认样式
+//
+// 返回:
+//   - TableStyle: 表格样式
+//   - error: 不支持的样式
+func ParseTableStyle(name string) (TableStyle, error) {
+	switch style := TableStyle(strings.ToLower(strings.TrimSpace(name))); style {
+	case "":
+		return TableStyleASCII, nil
+	case TableStyleASCII, TableStyleBorderless, TableStyleMarkdown:
+		return style, nil
+	default:
+		return "", fmt.Errorf("不支持的表格样式: %s，可选值: ascii、borderless、markdown", name)
+	}
+}
+
+// Table 按显示宽度对齐的文本表格
+// 列宽按单元格的终端显示宽度计算，中文等宽字符占 2 列，保证混排内容对齐
+type Table struct {
+	headers        []string   // 表头
+	rows           [][]string // 数据行
+	style          TableStyle // 渲染样式
+	maxColumnWidth int        // 单列最大显示宽度，0 表示不限制
+}
+
+// NewTable 创建表格
+// 参数:
+//   - headers: 表头列表
+//
+// 返回:
+//   - *Table: 使用默认样式与默认最大列宽的表格
+func NewTable(headers ...string) *Table {
+	return &Table{
+		headers:        headers,
+		style:          TableStyleASCII,
+		maxColumnWidth: DefaultMaxColumnWidth,
+	}
+}
+
+// SetStyle 设置渲染样式
+func (t *Table) SetStyle(style TableStyle) *Table {
+	t.style = style
+	return t
+}
+
+// SetMaxColumnWidth 设置单列最大显示宽度，超出部分以 "..." 截断
+func (t *Table) SetMaxColumnWidth(width int) *Table {
+	t.maxColumnWidth = width
+	return t
+}
+
+// AppendRow 追加一行数据，列数不足时以空字符串补齐
+func (t *Table) AppendRow(cells ...string) {
+	row := make([]string, len(t.headers))
+	copy(row, cells)
+	t.rows = append(t.rows, row)
+}
+
+// Render 将表格写入输出
+// 参数:
+//   - w: 输出目标
+//
+// 返回:
+//   - error: 写入错误信息
+func (t *Table) Render(w io.Writer) error {
+	_, err := io.WriteString(w, t.String())
+	return err
+}
+
+// String 返回渲染后的表格文本
+func (t *Table) String() string {
+	if len(t.headers) == 0 {
+		return ""
+	}
+
+	headers, rows := t.formatRows()
+	widths := t.columnWidths(headers, rows)
+
+	var sb strings.Builder
+	t.writeHeader(&sb, headers, widths)
+	for _, row := range rows {
+		t.writeRow(&sb, row, widths)
+	}
+	t.writeFooter(&sb, widths)
+	return sb.String()
+}
+
+// formatRows 返回规范化后的表头和数据行
+func (t *Table) formatRows() ([]string, [][]string) {
+	headers := make([]string, len(t.headers))
+	for i, header := range t.headers {
+		headers[i] = t.formatCell(header)
+	}
+	rows := make([][]string, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = t.formatCell(cell)
+		}
+	}
+	return headers, rows
+}
+
+// columnWidths 按表头和数据行计算每列的显示宽度
+func (t *Table) columnWidths(headers []string, rows [][]string) []int {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = GetDisplayWidth(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if width := GetDisplayWidth(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	if t.style == TableStyleMarkdown {
+		// Markdown 分隔行至少需要 3 个 "-"
+		for i := range widths {
+			if widths[i] < 3 {
+				widths[i] = 3
+			}
+		}
+	}
+	return widths
+}
+
+// writeHeader 写入表头及其上下的分隔线
+func (t *Table) writeHeader(sb *strings.Builder, headers []string, widths []int) {
+	switch t.style {
+	case TableStyleBorderless:
+		writeTableLine(sb, headers, widths, "", "  ", "")
+	case TableStyleMarkdown:
+		writeTableLine(sb, headers, widths, "| ", " | ", " |")
+		writeTableSeparator(sb, widths, "|", "|", "|", '-')
+	default:
+		writeTableSeparator(sb, widths, "+", "+", "+", '-')
+		writeTableLine(sb, headers, widths, "| ", " | ", " |")
+		writeTableSeparator(sb, widths, "+", "+", "+", '-')
+	}
+}
+
+// writeRow 写入一行已规范化的数据
+func (t *Table) writeRow(sb *strings.Builder, row []string, widths []int) {
+	if t.style == TableStyleBorderless {
+		writeTableLine(sb, row, widths, "", "  ", "")
+		return
+	}
+	writeTableLine(sb, row, widths, "| ", " | ", " |")
+}
+
+// writeFooter 写入表格底部的分隔线，只有 ASCII 样式有底边框
+func (t *Table) writeFooter(sb *strings.Builder, widths []int) {
+	if t.style != TableStyleBorderless && t.style != TableStyleMarkdown {
+		writeTableSeparator(sb, widths, "+", "+", "+", '-')
+	}
+}
+
+// VerticalString 返回纵向显示的表格文本
+// 与 MySQL 的 \G 输出一致，每条记录以分隔行开头，每列单独一行显示为 "列名: 值"，
+// 适合列数较多的宽表；纵向显示不截断单元格内容
+func (t *Table) VerticalString() string {
+	labelWidth := t.labelWidth()
+	var sb strings.Builder
+	for i, row := range t.rows {
+		t.writeVerticalRow(&sb, i+1, row, labelWidth)
+	}
+	return sb.String()
+}
+
+// labelWidth 纵向显示时列名的对齐宽度
+func (t *Table) labelWidth() int {
+	labelWidth := 0
+	for _, header := range t.headers {
+		if width := GetDisplayWidth(header); width > labelWidth {
+			labelWidth = width
+		}
+	}
+	return labelWidth
+}
+
+// writeVerticalRow 以纵向格式写入第 index 条记录
+func (t *Table) writeVerticalRow(sb *strings.Builder, index int, row []string, labelWidth int) {
+	sb.WriteString(fmt.Sprintf("%s %d. row %s\n", strings.Repeat("*", 27), index, strings.Repeat("*", 27)))
+	for j, header := range t.headers {
+		sb.WriteString(strings.Repeat(" ", labelWidth-GetDisplayWidth(header)))
+		sb.WriteString(header)
+		sb.WriteString(": ")
+		sb.WriteString(row[j])
+		sb.WriteString("\n")
+	}
+}
+
+// TableWriter 逐行写出的表格，内存占用与行数无关
+// 先缓存前 sampleRows 行，按样本计算列宽后写出表头和样本行，之后的行按已确定的列宽立即写出，
+// 超出列宽的单元格以 "..." 截断（Markdown 样式保留完整内容）；
+// 行数不超过 sampleRows 时输出与 Table.String 完全一致。纵向显示的每条记录互不影响，直接写出
+type TableWriter struct {
+	w          io.Writer
+	table      *Table // 表头、样式与尚未写出的样本行
+	sampleRows int    // 计算列宽使用的样本行数
+	vertical   bool   // 是否纵向显示
+	widths     []int  // 列宽，写出表头后确定
+	count      int    // 已追加的行数
+}
+
+// Stream 创建以该表格的表头和样式逐行写出的 TableWriter，用于尚未追加数据行的表格
+// 参数:
+//   - w: 输出目标
+//   - sampleRows: 计算列宽使用的样本行数，不大于 0 时为 1
+//   - vertical: 是否纵向显示
+//
+// 返回:
+//   - *TableWriter: 表格写入器，写完后需要调用 Close
+func (t *Table) Stream(w io.Writer, sampleRows int, vertical bool) *TableWriter {
+	return &TableWriter{w: w, table: t, sampleRows: max(sampleRows, 1), vertical: vertical}
+}
+
+// AppendRow 追加一行数据，样本行数已满时立即写出
+func (tw *TableWriter) AppendRow(cells ...string) error {
+	tw.count++
+	if tw.vertical {
+		row := make([]string, len(tw.table.headers))
+		copy(row, cells)
+		var sb strings.Builder
+		tw.table.writeVerticalRow(&sb, tw.count, row, tw.table.labelWidth())
+		_, err := io.WriteString(tw.w, sb.String())
+		return err
+	}
+	if tw.widths == nil {
+		tw.table.AppendRow(cells...)
+		if len(tw.table.rows) < tw.sampleRows {
+			return nil
+		}
+		return tw.flushSample()
+	}
+
+	row := make([]string, len(tw.widths))
+	for i := range row {
+		if i < len(cells) {
+			row[i] = tw.table.formatCell(cells[i])
+		}
+		// Markdown 不需要对齐；无边框样式的最后一列不补齐，都保留完整内容
+		lastBorderless := tw.table.style == TableStyleBorderless && i == len(row)-1
+		if tw.table.style != TableStyleMarkdown && !lastBorderless {
+			row[i] = TruncateString(row[i], tw.widths[i])
+		}
+	}
+	var sb strings.Builder
+	tw.table.writeRow(&sb, row, tw.widths)
+	_, err := io.WriteString(tw.w, sb.String())
+	return err
+}
+
+// flushSample 按样本行确定列宽，写出表头和样本行并释放样本
+func (tw *TableWriter) flushSample() error {
+	if len(tw.table.headers) == 0 {
+		tw.widths = []int{}
+		return nil
+	}
+	headers, rows := tw.table.formatRows()
+	tw.widths = tw.table.columnWidths(headers, rows)
+
+	var sb strings.Builder
+	tw.table.writeHeader(&sb, headers, tw.widths)
+	for _, row := range rows {
+		tw.table.writeRow(&sb, row, tw.widths)
+	}
+	tw.table.rows = nil
+	_, err := io.WriteString(tw.w, sb.String())
+	return err
+}
+
+// Close 写出剩余的样本行和表格底部
+func (tw *TableWriter) Close() error {
+	if tw.vertical {
+		return nil
+	}
+	if tw.widths == nil {
+		if err := tw.flushSample(); err != nil {
+			return err
+		}
+	}
+	if len(tw.table.headers) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	tw.table.writeFooter(&sb, tw.widths)
+	_, err := io.WriteString(tw.w, sb.String())
+	return err
+}
+
+// Count 返回已追加的行数
+func (tw *TableWriter) Count() int {
+	return tw.count
+}
+
+// formatCell 规范化单元格内容：换行折叠为空格，Markdown 转义竖线，并按最大列宽截断
+func (t *Table) formatCell(cell string) string {
+	cell = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(cell)
+	if t.style == TableStyleMarkdown {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+	}
+	if t.maxColumnWidth > 0 {
+		cell = TruncateString(cell, t.maxColumnWidth)
+	}
+	return cell
+}
+
+// writeTableLine 写入一行单元格
+func writeTableLine(sb *strings.Builder, cells []string, widths []int, left, sep, right string) {
+	sb.WriteString(left)
+	for i, cell := range cells {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		if i == len(cells)-1 && right == "" {
+			// 无右边框时最后一列不补齐空格，避免行尾空白
+			sb.WriteString(cell)
+			continue
+		}
+		sb.WriteString(PadString(cell, widths[i]))
+	}
+	sb.WriteString(right)
+	sb.WriteString("\n")
+}
+
+// writeTableSeparator 写入分隔线
+func writeTableSeparator(sb *strings.Builder, widths []int, left, sep, right string, fill rune) {
+	sb.WriteString(left)
+	for i, width := range widths {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(strings.Repeat(string(fill), width+2))
+	}
+	sb.WriteString(right)
+	sb.WriteString("\n")
+}
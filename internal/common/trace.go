@@ -0,0 +1,83 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// traceIDCtxKey 追踪 ID 在上下文中的键
+type traceIDCtxKey struct{}
+
+// maxTraceIDLength 外部传入的追踪 ID 的最大长度
+const maxTraceIDLength = 128
+
+// NewTraceID 生成新的追踪 ID，为 16 字节随机数的十六进制表示
+// 返回:
+//   - string: 追踪 ID
+//   - error: 读取随机数失败
+func NewTraceID() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return "", fmt.Errorf("生成追踪 ID 失败: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// ContextWithTraceID 为上下文设置追踪 ID
+// 参数:
+//   - ctx: 上下文
+//   - traceID: 追踪 ID
+//
+// 返回:
+//   - context.Context: 携带追踪 ID 的上下文
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDCtxKey{}, traceID)
+}
+
+// TraceIDFromContext 获取上下文中的追踪 ID，未设置时为空
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceID, _ := ctx.Value(traceIDCtxKey{}).(string)
+	return traceID
+}
+
+// EnsureTraceID 获取上下文中的追踪 ID，未设置时生成新的追踪 ID 并写入上下文
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - context.Context: 携带追踪 ID 的上下文
+//   - string: 追踪 ID
+//   - error: 生成追踪 ID 失败
+func EnsureTraceID(ctx context.Context) (context.Context, string, error) {
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		return ctx, traceID, nil
+	}
+	traceID, err := NewTraceID()
+	if err != nil {
+		return ctx, "", err
+	}
+	return ContextWithTraceID(ctx, traceID), traceID, nil
+}
+
+// ValidTraceID 判断外部传入的追踪 ID（如 X-Request-Id 请求头）是否可以沿用
+// 只接受长度不超过 128 的字母、数字和 -_.:，避免日志注入
+func ValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > maxTraceIDLength {
+		return false
+	}
+	for _, r := range traceID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
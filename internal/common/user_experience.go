@@ -61,6 +61,7 @@ type UserFriendlyError struct {
 	UserMessage   string
 	Suggestions   []string
 	ErrorCode     string
+	TraceID       string // 语句的追踪 ID，输出错误时一并显示，便于反馈问题和搜索日志
 }
 
 func (e *UserFriendlyError) Error() string {
@@ -94,6 +95,10 @@ func FormatUserError(err error) string {
 			}
 		}
 
+		if ufErr.TraceID != "" {
+			result.WriteString(fmt.Sprintf("\n🔖 追踪 ID: %s（反馈问题或搜索日志时请提供）\n", ufErr.TraceID))
+		}
+
 		return result.String()
 	}
 
@@ -179,79 +184,11 @@ func FormatTableOutput(headers []string, rows [][]string) string {
 		return "📭 没有数据"
 	}
 
-	// 计算每列的最大宽度
-	colWidths := make([]int, len(headers))
-	for i, header := range headers {
-		colWidths[i] = GetDisplayWidth(header)
-	}
-
+	table := NewTable(headers...).SetMaxColumnWidth(0)
 	for _, row := range rows {
-		for i, cell := range row {
-			if i < len(colWidths) {
-				if width := GetDisplayWidth(cell); width > colWidths[i] {
-					colWidths[i] = width
-				}
-			}
-		}
-	}
-
-	var result strings.Builder
-
-	// 打印表头
-	result.WriteString("┌")
-	for i, width := range colWidths {
-		result.WriteString(strings.Repeat("─", width+2))
-		if i < len(colWidths)-1 {
-			result.WriteString("┬")
-		}
-	}
-	result.WriteString("┐\n")
-
-	// 打印表头内容
-	result.WriteString("│")
-	for i, header := range headers {
-		result.WriteString(fmt.Sprintf(" %s ", PadString(header, colWidths[i])))
-		if i < len(headers)-1 {
-			result.WriteString("│")
-		}
+		table.AppendRow(row...)
 	}
-	result.WriteString("│\n")
-
-	// 打印分隔线
-	result.WriteString("├")
-	for i, width := range colWidths {
-		result.WriteString(strings.Repeat("─", width+2))
-		if i < len(colWidths)-1 {
-			result.WriteString("┼")
-		}
-	}
-	result.WriteString("┤\n")
-
-	// 打印数据行
-	for _, row := range rows {
-		result.WriteString("│")
-		for i, cell := range row {
-			if i < len(colWidths) {
-				result.WriteString(fmt.Sprintf(" %s ", PadString(cell, colWidths[i])))
-				if i < len(row)-1 && i < len(colWidths)-1 {
-					result.WriteString("│")
-				}
-			}
-		}
-		result.WriteString("│\n")
-	}
-
-	// 打印底部边框
-	result.WriteString("└")
-	for i, width := range colWidths {
-		result.WriteString(strings.Repeat("─", width+2))
-		if i < len(colWidths)-1 {
-			result.WriteString("┴")
-		}
-	}
-	result.WriteString("┘\n")
-
-	return result.String()
+	return table.String()
 }
 
 // GetHelpText 获取帮助文本
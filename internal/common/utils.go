@@ -6,7 +6,7 @@ import (
 	"os"
 	"strings"
 	"time"
-	"unicode/utf8"
+	"unicode"
 
 	"gorm.io/gorm"
 )
@@ -130,7 +130,67 @@ func GetStringValue(m map[string]interface{}, key string) string {
 	return ""
 }
 
-// GetDisplayWidth 计算字符串的显示宽度（中文字符占2个宽度）
+// wideRuneRanges 终端中占 2 列显示宽度的字符区间（东亚宽字符与常见 emoji）
+var wideRuneRanges = [][2]rune{
+	{0x1100, 0x115F},   // 谚文字母
+	{0x231A, 0x231B},   // ⌚⌛
+	{0x23E9, 0x23EC},   // ⏩⏪⏫⏬
+	{0x23F0, 0x23F3},   // ⏰⏳
+	{0x25FD, 0x25FE},   // ◽◾
+	{0x2614, 0x2615},   // ☔☕
+	{0x2705, 0x2705},   // ✅
+	{0x270A, 0x270B},   // ✊✋
+	{0x274C, 0x274C},   // ❌
+	{0x2753, 0x2755},   // ❓❔❕
+	{0x2795, 0x2797},   // ➕➖➗
+	{0x2B1B, 0x2B1C},   // ⬛⬜
+	{0x2B50, 0x2B50},   // ⭐
+	{0x2E80, 0x303E},   // CJK 部首、符号与标点
+	{0x3041, 0x33FF},   // 假名、注音、CJK 兼容字符
+	{0x3400, 0x4DBF},   // CJK 扩展 A
+	{0x4E00, 0x9FFF},   // CJK 统一汉字
+	{0xA000, 0xA4CF},   // 彝文
+	{0xA960, 0xA97F},   // 谚文字母扩展 A
+	{0xAC00, 0xD7A3},   // 谚文音节
+	{0xF900, 0xFAFF},   // CJK 兼容汉字
+	{0xFE10, 0xFE19},   // 竖排标点
+	{0xFE30, 0xFE6F},   // CJK 兼容形式、小写变体
+	{0xFF00, 0xFF60},   // 全角 ASCII
+	{0xFFE0, 0xFFE6},   // 全角符号
+	{0x1F300, 0x1F64F}, // 杂项符号、表情
+	{0x1F680, 0x1F6FF}, // 交通与地图符号
+	{0x1F900, 0x1F9FF}, // 补充符号与象形文字
+	{0x20000, 0x3FFFD}, // CJK 扩展 B 及以后
+}
+
+// RuneWidth 计算单个字符在终端中的显示宽度
+// 参数:
+//   - r: 字符
+//
+// 返回:
+//   - int: 显示宽度，控制字符与组合字符为 0，东亚宽字符为 2，其余为 1
+func RuneWidth(r rune) int {
+	if r == 0 || unicode.IsControl(r) {
+		return 0
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	for _, rng := range wideRuneRanges {
+		if r < rng[0] {
+			break
+		}
+		if r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// GetDisplayWidth 计算字符串的显示宽度（中文等宽字符占2个宽度）
 // 参数:
 //   - s: 要计算的字符串
 //
@@ -139,18 +199,13 @@ func GetStringValue(m map[string]interface{}, key string) string {
 func GetDisplayWidth(s string) int {
 	width := 0
 	for _, r := range s {
-		if utf8.RuneLen(r) > 1 {
-			// 中文字符占2个宽度
-			width += 2
-		} else {
-			// 英文字符占1个宽度
-			width += 1
-		}
+		width += RuneWidth(r)
 	}
 	return width
 }
 
 // TruncateString 截断字符串到指定显示宽度
+// 超出宽度时以 "..." 结尾，结果的显示宽度不超过 maxLen
 // 参数:
 //   - s: 要截断的字符串
 //   - maxLen: 最大显示宽度
@@ -161,25 +216,24 @@ func TruncateString(s string, maxLen int) string {
 	if GetDisplayWidth(s) <= maxLen {
 		return s
 	}
+	if maxLen <= 3 {
+		return strings.Repeat(".", maxLen)
+	}
 
-	result := ""
+	var result strings.Builder
 	currentWidth := 0
 
 	for _, r := range s {
-		runeWidth := 1
-		if utf8.RuneLen(r) > 1 {
-			runeWidth = 2
-		}
-
+		runeWidth := RuneWidth(r)
 		if currentWidth+runeWidth > maxLen-3 { // 为 "..." 预留空间
 			break
 		}
 
-		result += string(r)
+		result.WriteRune(r)
 		currentWidth += runeWidth
 	}
 
-	return result + "..."
+	return result.String() + "..."
 }
 
 // PadString 填充字符串到指定显示宽度
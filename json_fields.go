@@ -0,0 +1,85 @@
+package basesql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// isJSONField 判断模型字段是否按 JSON 文本读写
+// 多维表格没有 JSON 类型的字段，以下模型字段写入时编码为 JSON 文本，读取时从文本解码：
+//   - 带 basesql:"json" 标签的字段
+//   - map 类型的字段
+//   - gorm 类型标签为 json 的字段，如 gorm:"type:json"
+//
+// gorm 只接受有数据类型的 map 和结构体字段，这类字段需要同时声明 gorm:"type:json"；
+// 使用 gorm serializer 标签的字段由 gorm 自己编码，不在此列
+func isJSONField(field *schema.Field) bool {
+	if field.Serializer != nil {
+		return false
+	}
+	for _, opt := range strings.Split(field.Tag.Get(basesqlTagName), ",") {
+		if strings.TrimSpace(opt) == "json" {
+			return true
+		}
+	}
+	return field.IndirectFieldType.Kind() == reflect.Map || strings.EqualFold(string(field.DataType), "json")
+}
+
+// fieldWriteValue 获取模型字段写入表中的值，JSON 字段编码为 JSON 文本，nil 的 map、切片和指针不写入
+// 参数:
+//   - field: 模型字段
+//   - value: 字段值
+//
+// 返回:
+//   - interface{}: 写入的值，为 nil 时不写入该字段
+func fieldWriteValue(field *schema.Field, value interface{}) interface{} {
+	if value == nil || !isJSONField(field) {
+		return value
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+	case reflect.String:
+		// 已经是 JSON 文本，如 Updates(map[string]interface{}{"payload": `{"a":1}`})
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return string(data)
+}
+
+// decodeJSONValue 将表中的 JSON 文本解码为 JSON 字段的类型
+// 参数:
+//   - field: 模型字段
+//   - value: 表中的值，通常为字符串
+//
+// 返回:
+//   - interface{}: 解码后的值，为 nil 时不设置该字段
+//   - error: 文本不是合法的 JSON
+func decodeJSONValue(field *schema.Field, value interface{}) (interface{}, error) {
+	text, ok := value.(string)
+	if !ok {
+		// 表中的值已经是 JSON 结构，如被改成了其他类型的字段
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	target := reflect.New(field.FieldType)
+	if err := json.Unmarshal([]byte(text), target.Interface()); err != nil {
+		return nil, fmt.Errorf("字段 %s 不是合法的 JSON: %w", field.DBName, err)
+	}
+	return target.Elem().Interface(), nil
+}
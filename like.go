@@ -0,0 +1,283 @@
+package basesql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm/clause"
+)
+
+// likeMatcher 字符串匹配条件的执行计划，用于 LIKE、ILIKE 以及不区分大小写的等于（视为没有通配符的模式）
+// 飞书的过滤条件只有 is 和 contains，没有前缀、后缀匹配；is 区分大小写，contains 不区分大小写：
+// 区分大小写且没有通配符时下推为 is，形如 %abc% 且大小写规则与 contains 一致时下推为 contains，两者与原条件语义一致；
+// 其余模式（如 abc%、%abc、a_c）以最长的字面量片段下推为 contains 缩小结果，再在客户端按通配符位置和大小写规则校验
+type likeMatcher struct {
+	operator string           // 原条件的操作符: LIKE、ILIKE 或 =
+	column   string           // 字段名
+	pattern  string           // 原始模式
+	fold     bool             // 是否不区分大小写
+	pushdown *FilterCondition // 下推到服务端的条件，模式中没有字面量时为空
+	exact    bool             // 下推条件是否与原条件语义一致，一致时不需要客户端校验
+	re       *regexp.Regexp   // 客户端校验使用的正则表达式
+}
+
+// likeToken LIKE 模式解析后的片段
+type likeToken struct {
+	wildcard rune   // 通配符 % 或 _，字面量片段为 0
+	literal  string // 字面量内容
+}
+
+// parseLikeTokens 将 LIKE 模式拆分为字面量与通配符，反斜杠转义其后的字符
+func parseLikeTokens(pattern string) []likeToken {
+	var tokens []likeToken
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, likeToken{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			literal.WriteRune(runes[i])
+		case r == '%' || r == '_':
+			flush()
+			tokens = append(tokens, likeToken{wildcard: r})
+		default:
+			literal.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// newLikeMatcher 根据 LIKE 模式中通配符的位置生成执行计划
+// 参数:
+//   - operator: 操作符，LIKE 或 ILIKE
+//   - column: 字段名
+//   - value: LIKE 的模式，非字符串时按其字符串形式处理
+//   - fold: 是否不区分大小写
+//
+// 返回:
+//   - *likeMatcher: LIKE 条件的执行计划
+func newLikeMatcher(operator, column string, value interface{}, fold bool) *likeMatcher {
+	pattern := common.FormatValue(value)
+	return buildLikeMatcher(&likeMatcher{operator: operator, column: column, pattern: pattern, fold: fold}, parseLikeTokens(pattern))
+}
+
+// newEqualFoldMatcher 生成不区分大小写的等于条件的执行计划，值中的 % 和 _ 按字面量处理
+func newEqualFoldMatcher(column, value string) *likeMatcher {
+	m := &likeMatcher{operator: "=", column: column, pattern: value, fold: true}
+	var tokens []likeToken
+	if value != "" {
+		tokens = []likeToken{{literal: value}}
+	}
+	return buildLikeMatcher(m, tokens)
+}
+
+// buildLikeMatcher 根据模式片段生成客户端校验的正则表达式和下推条件
+func buildLikeMatcher(m *likeMatcher, tokens []likeToken) *likeMatcher {
+	var expr strings.Builder
+	if m.fold {
+		expr.WriteString("(?is)^")
+	} else {
+		expr.WriteString("(?s)^")
+	}
+	longest := ""
+	wildcards, singles := 0, 0
+	for _, token := range tokens {
+		switch token.wildcard {
+		case '%':
+			expr.WriteString(".*")
+			wildcards++
+		case '_':
+			expr.WriteString(".")
+			wildcards++
+			singles++
+		default:
+			expr.WriteString(regexp.QuoteMeta(token.literal))
+			if len([]rune(token.literal)) > len([]rune(longest)) {
+				longest = token.literal
+			}
+		}
+	}
+	expr.WriteString("$")
+	m.re = regexp.MustCompile(expr.String())
+
+	// 没有大小写之分的字面量（如中文、数字）在两种规则下比较结果相同
+	switch {
+	case wildcards == 0 && (!m.fold || uncased(longest)):
+		m.pushdown = &FilterCondition{FieldName: m.column, Operator: "is", Value: []interface{}{longest}}
+		m.exact = true
+	case wildcards == 0:
+		m.pushdown = &FilterCondition{FieldName: m.column, Operator: "contains", Value: []interface{}{longest}}
+	case longest == "":
+		// 只有 % 时匹配任意非空值；含 _ 时只能在客户端校验长度
+		if singles == 0 {
+			m.pushdown = &FilterCondition{FieldName: m.column, Operator: "isNotEmpty", Value: []interface{}{}}
+			m.exact = true
+		}
+	default:
+		m.pushdown = &FilterCondition{FieldName: m.column, Operator: "contains", Value: []interface{}{longest}}
+		m.exact = len(tokens) == 3 && tokens[0].wildcard == '%' && tokens[2].wildcard == '%' && (m.fold || uncased(longest))
+	}
+	return m
+}
+
+// uncased 判断字符串是否没有大小写之分
+func uncased(s string) bool {
+	return strings.ToLower(s) == strings.ToUpper(s)
+}
+
+// kind 返回模式的匹配方式，用于执行计划说明
+func (m *likeMatcher) kind() string {
+	kind := "通配符匹配"
+	tokens := parseLikeTokens(m.pattern)
+	switch {
+	case m.operator == "=":
+		kind = "等于"
+	case len(tokens) == 2 && tokens[0].wildcard == 0 && tokens[1].wildcard == '%':
+		kind = "前缀匹配"
+	case len(tokens) == 2 && tokens[0].wildcard == '%' && tokens[1].wildcard == 0:
+		kind = "后缀匹配"
+	}
+	if m.fold {
+		return "不区分大小写的" + kind
+	}
+	return kind
+}
+
+// explain 返回条件的执行方式说明
+func (m *likeMatcher) explain() string {
+	condition := fmt.Sprintf("%s %s '%s'", m.column, m.operator, m.pattern)
+	switch {
+	case m.pushdown == nil:
+		return fmt.Sprintf("%s: 模式中没有字面量，全部在客户端按%s校验", condition, m.kind())
+	case m.exact && len(m.pushdown.Value) == 0:
+		return fmt.Sprintf("%s: 服务端 %s，与原条件语义一致", condition, m.pushdown.Operator)
+	case m.exact:
+		return fmt.Sprintf("%s: 服务端 %s %q，与原条件语义一致", condition, m.pushdown.Operator, m.pushdown.Value[0])
+	default:
+		return fmt.Sprintf("%s: 服务端 %s %q 缩小结果，客户端按%s校验", condition, m.pushdown.Operator, m.pushdown.Value[0], m.kind())
+	}
+}
+
+// match 判断记录的字段值是否满足 LIKE 模式，字段为空时不匹配
+func (m *likeMatcher) match(record *Record) bool {
+	value, ok := record.Fields[m.column]
+	if !ok || value == nil {
+		return false
+	}
+	return m.re.MatchString(likeText(value))
+}
+
+// likeText 获取字段值的文本形式，富文本由各片段拼接
+func likeText(value interface{}) string {
+	segments, ok := value.([]interface{})
+	if !ok {
+		return (&Field{}).convertToString(value)
+	}
+	var text strings.Builder
+	for _, segment := range segments {
+		if obj, ok := segment.(map[string]interface{}); ok {
+			if s, ok := obj["text"].(string); ok {
+				text.WriteString(s)
+				continue
+			}
+		}
+		text.WriteString(common.FormatValue(segment))
+	}
+	return text.String()
+}
+
+// exprMatcher 从 GORM 的条件表达式中取出字符串匹配条件的执行计划
+// LIKE 按 fold 决定是否区分大小写，ILIKE 总是不区分大小写；fold 为 true 时字符串的等于条件也按不区分大小写处理
+// 其他条件返回 nil
+func exprMatcher(expr clause.Expression, fold bool) *likeMatcher {
+	switch e := expr.(type) {
+	case clause.Like:
+		if column, ok := e.Column.(clause.Column); ok && column.Name != "" {
+			return newLikeMatcher("LIKE", column.Name, e.Value, fold)
+		}
+	case clause.Eq:
+		if column, ok := e.Column.(clause.Column); ok && column.Name != "" && fold {
+			if value, ok := e.Value.(string); ok {
+				return newEqualFoldMatcher(column.Name, value)
+			}
+		}
+	case clause.Expr:
+		if len(e.Vars) != 1 {
+			return nil
+		}
+		for _, op := range []string{" ILIKE ?", " ilike ?"} {
+			if field, _, ok := strings.Cut(e.SQL, op); ok && strings.TrimSpace(field) != "" {
+				return newLikeMatcher("ILIKE", strings.TrimSpace(field), e.Vars[0], true)
+			}
+		}
+		for _, op := range []string{" LIKE ?", " like ?"} {
+			if field, _, ok := strings.Cut(e.SQL, op); ok && strings.TrimSpace(field) != "" {
+				return newLikeMatcher("LIKE", strings.TrimSpace(field), e.Vars[0], fold)
+			}
+		}
+		if field, _, ok := strings.Cut(e.SQL, " = ?"); ok && strings.TrimSpace(field) != "" && fold {
+			if value, ok := e.Vars[0].(string); ok {
+				return newEqualFoldMatcher(strings.TrimSpace(field), value)
+			}
+		}
+	}
+	return nil
+}
+
+// likeResiduals 收集需要在客户端校验的字符串匹配条件
+func likeResiduals(exprs []clause.Expression, fold bool) []*likeMatcher {
+	var residuals []*likeMatcher
+	for _, expr := range exprs {
+		if m := exprMatcher(expr, fold); m != nil && !m.exact {
+			residuals = append(residuals, m)
+		}
+	}
+	return residuals
+}
+
+// filterLikeRecords 按通配符位置和大小写规则在客户端过滤记录
+// 参数:
+//   - records: 服务端返回的记录
+//   - matchers: 需要客户端校验的条件
+//
+// 返回:
+//   - []*Record: 满足全部条件的记录
+func filterLikeRecords(records []*Record, matchers []*likeMatcher) []*Record {
+	if len(matchers) == 0 {
+		return records
+	}
+	filtered := make([]*Record, 0, len(records))
+	for _, record := range records {
+		matched := true
+		for _, m := range matchers {
+			if !m.match(record) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// filterLikeResponse 在客户端校验查询结果中的字符串匹配条件，并更新记录数
+func filterLikeResponse(resp *ListRecordsResponse, matchers []*likeMatcher) *ListRecordsResponse {
+	if len(matchers) == 0 {
+		return resp
+	}
+	resp.Items = filterLikeRecords(resp.Items, matchers)
+	resp.Total = len(resp.Items)
+	return resp
+}
@@ -0,0 +1,416 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// linkRelation 声明为关联字段的多对多关系
+// 多维表格的关联字段直接在记录中保存所关联记录的 ID，没有中间表；
+// gorm 对中间表的读写（Association 的 Append/Delete/Replace/Clear 以及 Preload）
+// 都转换为对所属记录关联字段的读取-修改-写入
+type linkRelation struct {
+	relation  *schema.Relationship // gorm 解析出的多对多关系
+	column    string               // 所属表中关联字段的名称
+	ownerKey  string               // 中间表中所属记录 ID 的列名
+	targetKey string               // 中间表中所关联记录 ID 的列名
+}
+
+// linkRelations 中间表名到关联字段的映射，在模型第一次被查询、写入或迁移时登记
+var linkRelations sync.Map
+
+// registerLinkRelations 登记模型中声明为关联字段的多对多关系
+// 关系字段的标签为 `gorm:"many2many:<中间表名>" basesql:"link"`，关联字段名默认取关系字段名的蛇形形式，
+// 也可以通过 `basesql:"link=<字段名>"` 指定
+// 参数:
+//   - sch: 模型结构
+func registerLinkRelations(sch *schema.Schema) {
+	if sch == nil {
+		return
+	}
+	for _, rel := range sch.Relationships.Many2Many {
+		column, ok := linkColumn(rel.Field)
+		if !ok || rel.JoinTable == nil {
+			continue
+		}
+		link := &linkRelation{relation: rel, column: column}
+		for _, ref := range rel.References {
+			if ref.PrimaryValue != "" {
+				continue
+			}
+			if ref.OwnPrimaryKey {
+				link.ownerKey = ref.ForeignKey.DBName
+			} else {
+				link.targetKey = ref.ForeignKey.DBName
+			}
+		}
+		linkRelations.Store(rel.JoinTable.Table, link)
+	}
+}
+
+// lookupLinkRelation 根据中间表名查找关联字段，不是关联字段的中间表时返回 nil
+func lookupLinkRelation(table string) *linkRelation {
+	if value, ok := linkRelations.Load(table); ok {
+		return value.(*linkRelation)
+	}
+	return nil
+}
+
+// linkColumn 解析关系字段的 link 标签，返回关联字段名
+func linkColumn(field *schema.Field) (string, bool) {
+	for _, opt := range strings.Split(field.Tag.Get(basesqlTagName), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		if !strings.EqualFold(key, "link") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			value = schema.NamingStrategy{}.ColumnName("", field.Name)
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// parseLinkRecordIDs 解析关联字段的值，返回所关联记录的 ID
+// 读取接口返回的格式有 {"link_record_ids": [...]}、[{"record_ids": [...], "text": ...}] 和记录 ID 数组几种
+func parseLinkRecordIDs(value interface{}) []string {
+	var ids []string
+	switch v := value.(type) {
+	case []string:
+		ids = append(ids, v...)
+	case []interface{}:
+		for _, item := range v {
+			switch elem := item.(type) {
+			case string:
+				ids = append(ids, elem)
+			case map[string]interface{}:
+				ids = append(ids, parseLinkRecordIDs(elem)...)
+			}
+		}
+	case map[string]interface{}:
+		if list, ok := v["link_record_ids"]; ok {
+			ids = append(ids, parseLinkRecordIDs(list)...)
+		}
+		if list, ok := v["record_ids"]; ok {
+			ids = append(ids, parseLinkRecordIDs(list)...)
+		}
+		if id, ok := v["record_id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// linkTargetIDs 获取关系字段中各关联记录的 ID
+func linkTargetIDs(ctx context.Context, rel *schema.Relationship, owner reflect.Value) ([]string, error) {
+	primary := rel.FieldSchema.PrioritizedPrimaryField
+	if primary == nil {
+		return nil, fmt.Errorf("关联的模型 %s 没有主键，无法写入关联字段", rel.FieldSchema.Name)
+	}
+
+	values := reflect.Indirect(rel.Field.ReflectValueOf(ctx, owner))
+	ids := make([]string, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		value, zero := primary.ValueOf(ctx, reflect.Indirect(values.Index(i)))
+		if zero {
+			return nil, fmt.Errorf("关联的 %s 记录缺少记录 ID，请先创建该记录", rel.FieldSchema.Name)
+		}
+		ids = append(ids, common.FormatValue(value))
+	}
+	return ids, nil
+}
+
+// updateLinkFields 处理 Association 的 Append/Replace
+// gorm 追加关联时以 Select(关系名) 更新所属记录，这里读取关联字段当前的记录 ID，合并新的 ID 后写回，
+// 关系字段中已有但未加载的关联不会丢失；Replace 随后删除多余关联的步骤由 deleteLinkRecords 处理
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//
+// 返回:
+//   - bool: 语句是否为关联字段的更新
+//   - error: 更新过程中的错误
+func updateLinkFields(db *gorm.DB, dialector *Dialector) (bool, error) {
+	stmt := db.Statement
+	registerLinkRelations(stmt.Schema)
+
+	var links []*linkRelation
+	for _, name := range stmt.Selects {
+		rel, ok := stmt.Schema.Relationships.Relations[name]
+		if !ok || rel.JoinTable == nil {
+			continue
+		}
+		if link := lookupLinkRelation(rel.JoinTable.Table); link != nil {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return false, nil
+	}
+
+	owner := reflect.Indirect(stmt.ReflectValue)
+	if owner.Kind() != reflect.Struct || stmt.Schema.PrioritizedPrimaryField == nil {
+		return true, fmt.Errorf("关联字段只支持按单条记录追加")
+	}
+	ownerID, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, owner)
+	if zero {
+		return true, fmt.Errorf("record ID not found")
+	}
+
+	for _, link := range links {
+		ids, err := linkTargetIDs(stmt.Context, link.relation, owner)
+		if err != nil {
+			return true, err
+		}
+		add := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			add[id] = true
+		}
+		if err := modifyLinkField(statementContext(db), dialector, link, []string{common.FormatValue(ownerID)}, func(current []string) []string {
+			for _, id := range current {
+				delete(add, id)
+			}
+			for _, id := range ids {
+				if add[id] {
+					current = append(current, id)
+					delete(add, id)
+				}
+			}
+			return current
+		}); err != nil {
+			return true, err
+		}
+	}
+	db.RowsAffected = 1
+	return true, nil
+}
+
+// deleteLinkRecords 处理 Association 的 Delete/Replace/Clear 对中间表的删除
+// WHERE 条件中所属记录 ID 的 IN 确定要修改的记录，所关联记录 ID 的 IN 为要移除的关联，
+// NOT IN 为要保留的关联（Replace），没有关联记录 ID 的条件时清空关联字段（Clear）
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - link: 关联字段
+//
+// 返回:
+//   - error: 删除过程中的错误
+func deleteLinkRecords(db *gorm.DB, dialector *Dialector, link *linkRelation) error {
+	var owners []string
+	var remove, keep map[string]bool
+	for _, expr := range linkWhereExprs(db.Statement) {
+		switch e := expr.(type) {
+		case clause.IN:
+			switch linkExprColumn(e.Column) {
+			case link.ownerKey:
+				owners = append(owners, linkValues(e.Values)...)
+			case link.targetKey:
+				remove = linkValueSet(e.Values)
+			}
+		case clause.Eq:
+			if linkExprColumn(e.Column) == link.ownerKey {
+				owners = append(owners, common.FormatValue(e.Value))
+			}
+		case clause.NotConditions:
+			for _, notExpr := range e.Exprs {
+				if in, ok := notExpr.(clause.IN); ok && linkExprColumn(in.Column) == link.targetKey {
+					keep = linkValueSet(in.Values)
+				}
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return fmt.Errorf("删除关联时缺少所属记录 ID")
+	}
+
+	drop := func(id string) bool {
+		switch {
+		case remove != nil:
+			return remove[id]
+		case keep != nil:
+			return !keep[id]
+		default:
+			return true
+		}
+	}
+
+	removed := 0
+	err := modifyLinkField(statementContext(db), dialector, link, owners, func(current []string) []string {
+		result := make([]string, 0, len(current))
+		for _, id := range current {
+			if drop(id) {
+				removed++
+				continue
+			}
+			result = append(result, id)
+		}
+		return result
+	})
+	db.RowsAffected = int64(removed)
+	return err
+}
+
+// queryLinkRecords 处理 Preload 对中间表的查询
+// 按所属记录 ID 批量读取所属记录，将关联字段中的每个记录 ID 展开为一行中间表数据
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - link: 关联字段
+//
+// 返回:
+//   - error: 查询过程中的错误
+func queryLinkRecords(db *gorm.DB, dialector *Dialector, link *linkRelation) error {
+	var owners []string
+	for _, expr := range linkWhereExprs(db.Statement) {
+		if in, ok := expr.(clause.IN); ok && linkExprColumn(in.Column) == link.ownerKey {
+			owners = append(owners, linkValues(in.Values)...)
+		}
+	}
+
+	records, err := readLinkOwners(statementContext(db), dialector, link, owners)
+	if err != nil {
+		return err
+	}
+
+	joinTable := link.relation.JoinTable
+	ownerField, targetField := joinTable.LookUpField(link.ownerKey), joinTable.LookUpField(link.targetKey)
+	if ownerField == nil || targetField == nil {
+		return fmt.Errorf("关联字段 %s 的中间表结构无效", link.column)
+	}
+
+	dest := db.Statement.ReflectValue
+	if dest.Kind() != reflect.Slice {
+		return fmt.Errorf("关联字段 %s 只支持查询到切片", link.column)
+	}
+	rows := reflect.MakeSlice(dest.Type(), 0, len(records))
+	for _, record := range records {
+		for _, id := range parseLinkRecordIDs(record.Fields[link.column]) {
+			row := reflect.New(joinTable.ModelType).Elem()
+			if err := ownerField.Set(db.Statement.Context, row, record.RecordID); err != nil {
+				return err
+			}
+			if err := targetField.Set(db.Statement.Context, row, id); err != nil {
+				return err
+			}
+			if dest.Type().Elem().Kind() == reflect.Ptr {
+				row = row.Addr()
+			}
+			rows = reflect.Append(rows, row)
+		}
+	}
+	dest.Set(rows)
+	db.RowsAffected = int64(rows.Len())
+	return nil
+}
+
+// modifyLinkField 读取所属记录关联字段当前的记录 ID，按 modify 修改后写回，值不变的记录不写入
+func modifyLinkField(ctx context.Context, dialector *Dialector, link *linkRelation, owners []string, modify func([]string) []string) error {
+	if err := dialector.Config.CheckWritable("UPDATE"); err != nil {
+		return err
+	}
+	records, err := readLinkOwners(ctx, dialector, link, owners)
+	if err != nil {
+		return err
+	}
+	tableID, err := getTableID(dialector, link.relation.Schema.Table)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		current := parseLinkRecordIDs(record.Fields[link.column])
+		updated := modify(append([]string(nil), current...))
+		if strings.Join(updated, ",") == strings.Join(current, ",") {
+			continue
+		}
+		_, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "PUT",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/%s", dialector.Config.AppToken, tableID, record.RecordID),
+			Body:   &UpdateRecordRequest{Fields: map[string]interface{}{link.column: updated}},
+		})
+		if err != nil {
+			return fmt.Errorf("更新记录 %s 的关联字段 %s 失败: %w", record.RecordID, link.column, err)
+		}
+	}
+	return nil
+}
+
+// readLinkOwners 按记录 ID 批量读取关联字段所属的记录
+func readLinkOwners(ctx context.Context, dialector *Dialector, link *linkRelation, owners []string) ([]*Record, error) {
+	if len(owners) == 0 {
+		return nil, nil
+	}
+	tableID, err := getTableID(dialector, link.relation.Schema.Table)
+	if err != nil {
+		return nil, err
+	}
+	return batchGetRecords(ctx, dialector, tableID, owners)
+}
+
+// linkWhereExprs 展开语句 WHERE 子句中以 AND 连接的条件
+func linkWhereExprs(stmt *gorm.Statement) []clause.Expression {
+	whereClause, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return nil
+	}
+	where, ok := whereClause.Expression.(clause.Where)
+	if !ok {
+		return nil
+	}
+
+	var exprs []clause.Expression
+	var expand func([]clause.Expression)
+	expand = func(list []clause.Expression) {
+		for _, expr := range list {
+			switch e := expr.(type) {
+			case clause.Where:
+				expand(e.Exprs)
+			case clause.AndConditions:
+				expand(e.Exprs)
+			default:
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	expand(where.Exprs)
+	return exprs
+}
+
+// linkExprColumn 获取条件中的列名
+func linkExprColumn(column interface{}) string {
+	switch c := column.(type) {
+	case clause.Column:
+		return c.Name
+	case string:
+		return c
+	}
+	return ""
+}
+
+// linkValues 将条件中的值转换为记录 ID
+func linkValues(values []interface{}) []string {
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		if id := common.FormatValue(value); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// linkValueSet 将条件中的值转换为记录 ID 集合
+func linkValueSet(values []interface{}) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, id := range linkValues(values) {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,260 @@
+package basesql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// NewTraceID 生成新的追踪 ID
+// 返回:
+//   - string: 32 位十六进制追踪 ID
+//   - error: 读取随机数失败
+func NewTraceID() (string, error) {
+	return common.NewTraceID()
+}
+
+// WithTraceID 为上下文设置追踪 ID，语句日志、API 调试日志和飞书请求的 X-Request-Id 请求头都会带上它
+// 参数:
+//   - ctx: 上下文
+//   - traceID: 追踪 ID，如上游请求的 X-Request-Id
+//
+// 返回:
+//   - context.Context: 携带追踪 ID 的上下文
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return common.ContextWithTraceID(ctx, traceID)
+}
+
+// TraceIDFromContext 获取上下文中的追踪 ID
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - string: 追踪 ID，未设置时为空
+func TraceIDFromContext(ctx context.Context) string {
+	return common.TraceIDFromContext(ctx)
+}
+
+// apiCall 一次飞书 API 请求的记录
+type apiCall struct {
+	method   string
+	path     string
+	duration time.Duration
+	err      error
+}
+
+// String 返回请求的简要描述，如 "POST /bitable/v1/apps/xxx/tables/tbl/records/search 120ms"
+func (c apiCall) String() string {
+	s := fmt.Sprintf("%s %s %s", c.method, c.path, c.duration.Round(time.Millisecond))
+	if c.err != nil {
+		s += " failed"
+	}
+	return s
+}
+
+// apiCallRecorder 记录一条语句执行期间发出的飞书 API 请求
+type apiCallRecorder struct {
+	operation string // 操作类型: query、row、raw、create、update、delete
+	table     string // 表名，原生 SQL 时为空
+	mu        sync.Mutex
+	calls     []apiCall
+	pages     int    // 读取的记录分页数
+	filter    string // 读取记录时使用的过滤条件（JSON）
+}
+
+// apiCallRecorderCtxKey 请求记录器在上下文中的键
+type apiCallRecorderCtxKey struct{}
+
+// withAPICallRecorder 创建携带请求记录器的上下文
+func withAPICallRecorder(ctx context.Context, operation, table string) (context.Context, *apiCallRecorder) {
+	recorder := &apiCallRecorder{operation: operation, table: table}
+	return context.WithValue(ctx, apiCallRecorderCtxKey{}, recorder), recorder
+}
+
+// recordAPICall 将请求记录到上下文中的记录器，上下文没有记录器时忽略
+func recordAPICall(ctx context.Context, req *APIRequest, duration time.Duration, err error) {
+	recorder, ok := ctx.Value(apiCallRecorderCtxKey{}).(*apiCallRecorder)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	recorder.calls = append(recorder.calls, apiCall{method: req.Method, path: req.Path, duration: duration, err: err})
+	recorder.mu.Unlock()
+}
+
+// snapshot 获取已记录请求的描述
+func (r *apiCallRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]string, len(r.calls))
+	for i, call := range r.calls {
+		calls[i] = call.String()
+	}
+	return calls
+}
+
+// statementContext 获取语句的上下文，未设置时返回 context.Background()
+func statementContext(db *gorm.DB) context.Context {
+	if db.Statement != nil && db.Statement.Context != nil {
+		return db.Statement.Context
+	}
+	return context.Background()
+}
+
+// runTraced 执行回调函数并记录期间发出的飞书 API 请求，耗时超过 Config.SlowQueryThreshold 时报告慢查询
+// 原生 SQL 由 GORM 在回调结束后调用日志器的 Trace；模型操作没有 SQL，GORM 不会记录，
+// 使用 NewLogger 创建的日志器时在这里补充调用
+func runTraced(db *gorm.DB, dialector *Dialector, operation string, fn func(*gorm.DB, *Dialector) error) error {
+	begin := time.Now()
+	ctx, recorder := withAPICallRecorder(statementContext(db), operation, db.Statement.Table)
+	db.Statement.Context = ctx
+
+	// 事务中的其他语句执行前先写入缓冲的创建，保持写入顺序，查询也能读到这些记录
+	var err error
+	if operation != "create" {
+		err = flushTxCreates(db)
+	}
+	if err == nil {
+		err = fn(db, dialector)
+	}
+	reportSlowQuery(db, dialector.Config, recorder, time.Since(begin), err)
+
+	if _, ok := db.Logger.(*structuredLogger); ok && db.Statement.SQL.Len() == 0 {
+		db.Logger.Trace(ctx, begin, func() (string, int64) { return "", db.RowsAffected }, err)
+	}
+	return err
+}
+
+// LoggerConfig GORM 日志器配置
+type LoggerConfig struct {
+	LogLevel                  gormlogger.LogLevel              // 日志级别，默认 gormlogger.Warn
+	SlowThreshold             time.Duration                    // 慢语句阈值，超过时记录警告，0 表示不记录慢语句
+	IgnoreRecordNotFoundError bool                             // 是否忽略 gorm.ErrRecordNotFound
+	Format                    string                           // 输出格式: text 或 json，默认 text
+	Output                    io.Writer                        // 输出目标，为空时与 BaseSQL 的其他日志共用输出（默认标准错误）
+	TraceIDFunc               func(ctx context.Context) string // 从上下文获取追踪 ID，默认使用 TraceIDFromContext
+}
+
+// structuredLogger 基于结构化日志器的 GORM 日志器
+type structuredLogger struct {
+	config LoggerConfig
+	logger *common.Logger
+}
+
+// NewLogger 创建基于结构化日志的 GORM 日志器，用于 gorm.Config.Logger
+// 语句日志记录操作类型、表名、耗时、影响行数以及实际发出的飞书 API 请求，
+// 并带上上下文中的追踪 ID；敏感信息会被遮蔽
+// 参数:
+//   - config: 日志器配置
+//
+// 返回:
+//   - gormlogger.Interface: GORM 日志器
+func NewLogger(config LoggerConfig) gormlogger.Interface {
+	if config.LogLevel == 0 {
+		config.LogLevel = gormlogger.Warn
+	}
+	if config.TraceIDFunc == nil {
+		config.TraceIDFunc = TraceIDFromContext
+	}
+
+	// 未指定输出时在记录日志时使用全局日志器，跟随 InitializeLogging 设置的日志文件和格式
+	l := &structuredLogger{config: config}
+	if config.Output != nil {
+		l.logger = common.NewLogger(common.LogLevelDebug, config.Format == "json")
+		l.logger.SetOutput(config.Output)
+	}
+	return l
+}
+
+// LogMode 返回指定日志级别的日志器副本
+func (l *structuredLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.config.LogLevel = level
+	return &clone
+}
+
+// Info 记录信息日志
+func (l *structuredLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Info {
+		l.withContext(ctx).Infof(msg, data...)
+	}
+}
+
+// Warn 记录警告日志
+func (l *structuredLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Warn {
+		l.withContext(ctx).Warnf(msg, data...)
+	}
+}
+
+// Error 记录错误日志
+func (l *structuredLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Error {
+		l.withContext(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace 记录语句执行日志
+// 失败的语句记为错误，超过慢语句阈值的记为警告，其余仅在 Info 级别记录
+func (l *structuredLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	failed := err != nil && l.config.LogLevel >= gormlogger.Error &&
+		!(l.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound))
+	slow := l.config.SlowThreshold > 0 && elapsed > l.config.SlowThreshold && l.config.LogLevel >= gormlogger.Warn
+	if !failed && !slow && l.config.LogLevel < gormlogger.Info {
+		return
+	}
+
+	sql, rows := fc()
+	fields := map[string]interface{}{
+		"duration_ms": float64(elapsed) / float64(time.Millisecond),
+		"rows":        rows,
+	}
+	if sql != "" {
+		fields["sql"] = sql
+	}
+	if recorder, ok := ctx.Value(apiCallRecorderCtxKey{}).(*apiCallRecorder); ok {
+		fields["operation"] = recorder.operation
+		if recorder.table != "" {
+			fields["table"] = recorder.table
+		}
+		fields["api_calls"] = recorder.snapshot()
+	}
+	logger := l.withContext(ctx).WithFields(fields)
+
+	switch {
+	case failed:
+		logger.ErrorWithErr("语句执行失败", err)
+	case slow:
+		logger.WithField("slow_threshold_ms", float64(l.config.SlowThreshold)/float64(time.Millisecond)).Warn("慢语句")
+	default:
+		logger.Info("语句执行完成")
+	}
+}
+
+// withContext 获取带有上下文追踪 ID 的日志器
+// 级别已由 GORM 的 LogLevel 判断，这里返回不再过滤级别的日志器
+func (l *structuredLogger) withContext(ctx context.Context) *common.Logger {
+	logger := l.logger
+	if logger == nil {
+		logger = common.DefaultLogger.WithLevel(common.LogLevelDebug)
+	}
+	if ctx == nil {
+		return logger
+	}
+	if traceID := l.config.TraceIDFunc(ctx); traceID != "" {
+		return logger.WithTraceID(traceID)
+	}
+	return logger
+}
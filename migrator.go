@@ -3,6 +3,7 @@ package basesql
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -41,6 +42,9 @@ func (m Migrator) RunAutoMigrate(value interface{}) error {
 
 // CreateTable 创建表
 func (m Migrator) CreateTable(values ...interface{}) error {
+	if err := m.Dialector.Config.CheckWritable("CREATE TABLE"); err != nil {
+		return err
+	}
 	for _, value := range values {
 		tx := m.DB.Session(&gorm.Session{})
 		if err := m.createTable(value, tx); err != nil {
@@ -60,6 +64,8 @@ func (m Migrator) createTable(value interface{}, tx *gorm.DB) error {
 		}
 	}
 
+	registerLinkRelations(schemaValue)
+
 	// 检查表是否已存在
 	if m.HasTable(schemaValue.Table) {
 		return nil
@@ -68,8 +74,8 @@ func (m Migrator) createTable(value interface{}, tx *gorm.DB) error {
 	// 创建字段列表
 	var fields []*CreateFieldRequest
 	for _, field := range schemaValue.Fields {
-		// 跳过自增字段，但保留主键和唯一字段
-		if field.AutoIncrement {
+		// 跳过自增字段和关系字段，但保留主键和唯一字段
+		if field.AutoIncrement || field.DBName == "" {
 			continue
 		}
 
@@ -94,7 +100,7 @@ func (m Migrator) createTable(value interface{}, tx *gorm.DB) error {
 		},
 	}
 
-	_, err := m.Dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err := m.Dialector.api().DoRequest(context.Background(), apiReq)
 	return err
 }
 
@@ -124,7 +130,7 @@ func (m Migrator) HasTable(table interface{}) bool {
 		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", m.Dialector.Config.AppToken),
 	}
 
-	resp, err := m.Dialector.Client.DoRequest(context.Background(), apiReq)
+	resp, err := m.Dialector.api().DoRequest(context.Background(), apiReq)
 	if err != nil {
 		return false
 	}
@@ -139,51 +145,28 @@ func (m Migrator) HasTable(table interface{}) bool {
 		return false
 	}
 
-	// 检查表名是否存在
-	for _, table := range apiResp.Data.Items {
-		if table.Name == tableName {
+	// 之前解析到的表 ID 仍然存在时，表即使改了名也视为存在
+	if client := m.Dialector.baseClient(); client != nil {
+		if id, ok := client.schemaIDs.tableID(tableName); ok && apiResp.Data.GetTableByID(id) != nil {
 			return true
 		}
 	}
 
-	return false
+	// 同名的表不止一张时表同样存在
+	_, err = apiResp.Data.ResolveTable(tableName)
+	return err == nil || errors.Is(err, ErrAmbiguousTable)
 }
 
-// getTableID 通过表名获取表 ID
+// getTableID 通过表名获取表 ID，同名的表不止一张时报错，避免删除或修改了其中任意一张
 func (m Migrator) getTableID(tableName string) (string, error) {
-	// 调用 API 获取表列表
-	apiReq := &APIRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", m.Dialector.Config.AppToken),
-	}
-
-	resp, err := m.Dialector.Client.DoRequest(context.Background(), apiReq)
-	if err != nil {
-		return "", err
-	}
-
-	var apiResp ListTablesAPIResponse
-	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
-		return "", err
-	}
-
-	// 检查API调用是否成功
-	if apiResp.Code != 0 || apiResp.Data == nil {
-		return "", fmt.Errorf("API调用失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
-	}
-
-	// 查找表名对应的 ID
-	for _, table := range apiResp.Data.Items {
-		if table.Name == tableName {
-			return table.TableID, nil
-		}
-	}
-
-	return "", fmt.Errorf("table %s not found", tableName)
+	return getTableID(m.Dialector, tableName)
 }
 
 // DropTable 删除表
 func (m Migrator) DropTable(values ...interface{}) error {
+	if err := m.Dialector.Config.CheckWritable("DROP TABLE"); err != nil {
+		return err
+	}
 	for _, value := range values {
 		if err := m.dropTable(value); err != nil {
 			return err
@@ -208,12 +191,20 @@ func (m Migrator) dropTable(value interface{}) error {
 		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s", m.Dialector.Config.AppToken, tableID),
 	}
 
-	_, err = m.Dialector.Client.DoRequest(context.Background(), apiReq)
-	return err
+	if _, err = m.Dialector.api().DoRequest(context.Background(), apiReq); err != nil {
+		return err
+	}
+	if client := m.Dialector.baseClient(); client != nil {
+		client.schemaIDs.forgetTable(tableID)
+	}
+	return nil
 }
 
 // UpdateColumns 更新列
 func (m Migrator) UpdateColumns(value interface{}) error {
+	if err := m.Dialector.Config.CheckWritable("ALTER TABLE"); err != nil {
+		return err
+	}
 	schemaValue := m.DB.Statement.Schema
 	if schemaValue == nil {
 		var err error
@@ -235,8 +226,8 @@ func (m Migrator) UpdateColumns(value interface{}) error {
 
 	// 更新字段
 	for _, field := range schemaValue.Fields {
-		// 跳过自增字段，但保留主键和唯一字段
-		if field.AutoIncrement {
+		// 跳过自增字段和关系字段，但保留主键和唯一字段
+		if field.AutoIncrement || field.DBName == "" {
 			continue
 		}
 
@@ -280,7 +271,7 @@ func (m Migrator) getTableFields(tableName string) (map[string]*Field, error) {
 		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", m.Dialector.Config.AppToken, tableID),
 	}
 
-	resp, err := m.Dialector.Client.DoRequest(context.Background(), apiReq)
+	resp, err := m.Dialector.api().DoRequest(context.Background(), apiReq)
 	if err != nil {
 		return nil, err
 	}
@@ -323,7 +314,7 @@ func (m Migrator) updateField(tableName, fieldID string, field *schema.Field) er
 		},
 	}
 
-	_, err = m.Dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err = m.Dialector.api().DoRequest(context.Background(), apiReq)
 	return err
 }
 
@@ -347,28 +338,13 @@ func (m Migrator) addField(tableName string, field *schema.Field) error {
 		},
 	}
 
-	_, err = m.Dialector.Client.DoRequest(context.Background(), apiReq)
+	_, err = m.Dialector.api().DoRequest(context.Background(), apiReq)
 	return err
 }
 
 // getFieldType 获取字段类型
 func (m Migrator) getFieldType(field *schema.Field) FieldType {
-	switch field.DataType {
-	case schema.Bool:
-		return FieldTypeCheckbox
-	case schema.Int, schema.Uint:
-		return FieldTypeNumber
-	case schema.Float:
-		return FieldTypeNumber
-	case schema.String:
-		return FieldTypeText
-	case schema.Time:
-		return FieldTypeDate
-	case schema.Bytes:
-		return FieldTypeText
-	default:
-		return FieldTypeText
-	}
+	return schemaFieldType(field)
 }
 
 // getUIType 获取字段的 UI 类型
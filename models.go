@@ -204,6 +204,8 @@ type Field struct {
 	Property    map[string]interface{} `json:"property"`    // 字段属性配置（如选项列表、格式设置等）
 	Description string                 `json:"description"` // 字段描述
 	IsPrimary   bool                   `json:"is_primary"`  // 是否为主键字段
+
+	decimalPlaces int // 十进制文本写入时保留的小数位数，见 Config.DecimalPlaces
 }
 
 // Validate 验证字段结构的有效性
@@ -243,6 +245,39 @@ func (f *Field) IsReadOnly() bool {
 	return f.IsSystemField() || f.Type == FieldTypeFormula || f.Type == FieldTypeLookup
 }
 
+// ValueRange 获取进度、评分字段允许的取值范围，超出范围的值写入时会被飞书拒绝
+// 进度字段的值为比例，未自定义范围时为 0 到 1（即 0% 到 100%），自定义范围时使用属性中的 min 和 max；
+// 评分字段使用属性中的 min 和 max，缺失时为 0 到 5
+// 返回:
+//   - float64: 下限（包含）
+//   - float64: 上限（包含）
+//   - bool: 字段是否有取值范围
+func (f *Field) ValueRange() (float64, float64, bool) {
+	switch f.Type {
+	case FieldTypeProgress:
+		if customized, _ := f.Property["range_customize"].(bool); customized {
+			return f.propertyNumber("min", 0), f.propertyNumber("max", 1), true
+		}
+		return 0, 1, true
+	case FieldTypeRating:
+		return f.propertyNumber("min", 0), f.propertyNumber("max", 5), true
+	}
+	return 0, 0, false
+}
+
+// propertyNumber 获取字段属性中的数字，缺失或不是数字时返回默认值
+func (f *Field) propertyNumber(key string, defaultValue float64) float64 {
+	switch v := f.Property[key].(type) {
+	case float64:
+		return v
+	case json.Number:
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
 // MarshalJSON 自定义 JSON 序列化
 func (f *Field) MarshalJSON() ([]byte, error) {
 	type Alias Field
@@ -276,12 +311,12 @@ func (f *Field) UnmarshalJSON(data []byte) error {
 // Record 飞书多维表格的记录结构
 // 表示表中的一条数据记录，包含记录的所有字段值和元数据信息
 type Record struct {
-	RecordID       string                 `json:"record_id"`        // 记录的唯一标识符
-	Fields         map[string]interface{} `json:"fields"`           // 记录的字段值映射，key 为字段名，value 为字段值
-	CreatedTime    int64                  `json:"created_time"`     // 记录创建时间（毫秒时间戳）
-	LastModified   int64                  `json:"last_modified"`    // 记录最后修改时间（毫秒时间戳）
-	CreatedBy      *User                  `json:"created_by"`       // 记录创建者信息
-	LastModifiedBy *User                  `json:"last_modified_by"` // 记录最后修改者信息
+	RecordID       string                 `json:"record_id"`          // 记录的唯一标识符
+	Fields         map[string]interface{} `json:"fields"`             // 记录的字段值映射，key 为字段名，value 为字段值
+	CreatedTime    int64                  `json:"created_time"`       // 记录创建时间（毫秒时间戳）
+	LastModified   int64                  `json:"last_modified_time"` // 记录最后修改时间（毫秒时间戳）
+	CreatedBy      *User                  `json:"created_by"`         // 记录创建者信息
+	LastModifiedBy *User                  `json:"last_modified_by"`   // 记录最后修改者信息
 }
 
 // Validate 验证记录结构的有效性
@@ -1120,6 +1155,54 @@ func (resp *ListTablesResponse) GetTableByID(tableID string) *Table {
 	return nil
 }
 
+// ResolveTable 按表名或表 ID 查找表，见 ResolveTable
+func (resp *ListTablesResponse) ResolveTable(nameOrID string) (*Table, error) {
+	return ResolveTable(resp.GetTables(), nameOrID)
+}
+
+// ResolveTable 按表名或表 ID 查找表
+// 依次尝试表 ID、表名精确匹配和表名不区分大小写匹配；
+// 多维表格允许多张表同名，同名的表不止一张时返回 ErrAmbiguousTable，错误信息列出各表的 ID，可以改用表 ID 指定
+// 参数:
+//   - tables: 表列表
+//   - nameOrID: 表名或表 ID
+//
+// 返回:
+//   - *Table: 找到的表
+//   - error: 未找到表（ErrTableNotFound）或表名对应多张表（ErrAmbiguousTable）
+func ResolveTable(tables []*Table, nameOrID string) (*Table, error) {
+	for _, table := range tables {
+		if table != nil && table.TableID == nameOrID {
+			return table, nil
+		}
+	}
+
+	matchers := []func(string) bool{
+		func(name string) bool { return name == nameOrID },
+		func(name string) bool { return strings.EqualFold(name, nameOrID) },
+	}
+	for _, match := range matchers {
+		var found []*Table
+		for _, table := range tables {
+			if table != nil && match(table.Name) {
+				found = append(found, table)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		}
+		ids := make([]string, len(found))
+		for i, table := range found {
+			ids[i] = table.TableID
+		}
+		return nil, fmt.Errorf("%w: 有 %d 张表名为 '%s'，请改用表 ID 指定: %s", ErrAmbiguousTable, len(found), nameOrID, strings.Join(ids, ", "))
+	}
+	return nil, fmt.Errorf("%w: 未找到表 '%s'，请检查表名是否正确", ErrTableNotFound, nameOrID)
+}
+
 // ConvertToGoValue 将飞书多维表格字段值转换为 Go 语言标准类型
 // 该方法提供了类型安全的转换，支持多种数据类型的智能转换
 // 参数:
@@ -1157,6 +1240,9 @@ func (f *Field) ConvertToGoValue(value interface{}) interface{} {
 	case FieldTypeAttachment:
 		return f.convertToAttachmentList(value)
 
+	case FieldTypeAutoNumber:
+		return f.convertToString(value)
+
 	default:
 		return value
 	}
@@ -1204,6 +1290,10 @@ func (f *Field) convertToFloat64(value interface{}) float64 {
 		return float64(v)
 	case int64:
 		return float64(v)
+	case json.Number:
+		if num, err := v.Float64(); err == nil {
+			return num
+		}
 	case string:
 		if num, err := strconv.ParseFloat(v, 64); err == nil {
 			return num
@@ -1353,22 +1443,30 @@ func (f *Field) convertToAttachmentList(value interface{}) []string {
 }
 
 // ConvertFromGoValue 将 Go 语言标准类型转换为飞书多维表格字段值
-// 该方法将 Go 类型转换为飞书 API 期望的格式，确保数据能正确提交到飞书
+// 该方法将 Go 类型转换为飞书 API 期望的格式，确保数据能正确提交到飞书；
+// 实现 driver.Valuer 或 encoding.TextMarshaler 的自定义类型先转换为基础类型
 // 参数:
 //   - value: Go 语言类型的值
 //
 // 返回:
 //   - interface{}: 转换后的飞书 API 格式值，转换失败时返回 nil 或对应类型的零值
 func (f *Field) ConvertFromGoValue(value interface{}) interface{} {
-	if value == nil {
+	if value = customGoValue(value); value == nil {
 		return nil
 	}
 
 	switch f.Type {
-	case FieldTypeText, FieldTypePhone, FieldTypeURL, FieldTypeBarcode:
+	case FieldTypeText, FieldTypePhone, FieldTypeBarcode:
 		return f.convertFromString(value)
 
+	case FieldTypeURL:
+		return f.convertFromURL(value)
+
 	case FieldTypeNumber, FieldTypeCurrency, FieldTypeProgress, FieldTypeRating:
+		// 十进制文本原样写入，不经过 float64，避免小数位很多的金额丢失精度
+		if text, ok := decimalText(value, f.decimalPlaces); ok {
+			return json.Number(text)
+		}
 		return f.convertFromNumber(value)
 
 	case FieldTypeCheckbox:
@@ -1528,6 +1626,12 @@ func (f *Field) convertFromSingleSelect(value interface{}) interface{} {
 // convertFromMultiSelect 将值转换为多选格式
 func (f *Field) convertFromMultiSelect(value interface{}) interface{} {
 	switch v := value.(type) {
+	case string:
+		// 单个字符串视为一个选项，如 INSERT 语句中的 'urgent'
+		if v == "" {
+			return []map[string]interface{}{}
+		}
+		return []map[string]interface{}{{"text": v}}
 	case []string:
 		if len(v) == 0 {
 			return []map[string]interface{}{}
@@ -0,0 +1,208 @@
+package basesql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+)
+
+// pageSizeSettingKey 语句设置中单次查询每页记录数的键
+const pageSizeSettingKey = "basesql:page_size"
+
+// WithPageSize 为单次查询指定读取记录时的每页记录数，覆盖 Config.DefaultPageSize
+// 查询需要读取多页时，每页记录数越小请求次数越多；单页响应过大导致超时的表可以调小
+//
+//	basesql.WithPageSize(db, 100).Where("status = ?", "open").Find(&tasks)
+//
+// 参数:
+//   - db: GORM 数据库实例
+//   - size: 每页记录数，最大 500
+//
+// 返回:
+//   - *gorm.DB: 设置了每页记录数的数据库实例
+func WithPageSize(db *gorm.DB, size int) *gorm.DB {
+	return db.Set(pageSizeSettingKey, size)
+}
+
+// pageSizeOf 获取 WithPageSize 设置的每页记录数
+func pageSizeOf(db *gorm.DB) (int, bool) {
+	if value, ok := db.Get(pageSizeSettingKey); ok {
+		if size, ok := value.(int); ok && size > 0 {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// recordsPageSize 确定读取记录时的每页记录数
+// 请求指定的 PageSize 优先，其次为 Config.DefaultPageSize，都未设置时为 fallback；结果不超过 500
+// 参数:
+//   - config: BaseSQL 配置
+//   - req: 查询请求
+//   - fallback: 默认的每页记录数，0 表示不指定，使用飞书 API 的默认值
+//
+// 返回:
+//   - int: 每页记录数，0 表示不指定
+func recordsPageSize(config *Config, req *ListRecordsRequest, fallback int) int {
+	size := fallback
+	switch {
+	case req != nil && req.PageSize > 0:
+		size = req.PageSize
+	case config != nil && config.DefaultPageSize > 0:
+		size = config.DefaultPageSize
+	}
+	return min(size, common.MaxPageSize)
+}
+
+// fetchRecordsPage 读取一页记录，见 Client.ListRecordsPage
+func fetchRecordsPage(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, cursor string, size int) (*ListRecordsResponse, int, error) {
+	return readRecordsPage(ctx, dialector.api(), dialector.Config.AppToken, tableID, req, cursor, size)
+}
+
+// ListRecordsPage 读取一页记录
+// 有过滤或排序条件时使用 POST search 接口，否则使用 GET 列表接口；
+// API 拒绝每页记录数时减半后重试，调用方应使用返回的每页记录数继续翻页
+// 参数:
+//   - ctx: 上下文
+//   - tableID: 表 ID
+//   - req: 查询请求，可以为 nil，其中的分页参数不使用
+//   - cursor: 分页标记，读取第一页时为空
+//   - size: 每页记录数，0 表示不指定，使用飞书 API 的默认值
+//
+// 返回:
+//   - *ListRecordsResponse: 本页的记录和下一页的分页标记
+//   - int: 实际使用的每页记录数
+//   - error: 查询过程中的错误
+func (c *Client) ListRecordsPage(ctx context.Context, tableID string, req *ListRecordsRequest, cursor string, size int) (*ListRecordsResponse, int, error) {
+	return readRecordsPage(ctx, c, c.config.AppToken, tableID, req, cursor, size)
+}
+
+// readRecordsPage 读取一页记录，API 拒绝每页记录数时减半后重试
+func readRecordsPage(ctx context.Context, client APIClient, appToken, tableID string, req *ListRecordsRequest, cursor string, size int) (*ListRecordsResponse, int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		resp, err := requestRecordsPage(ctx, client, appToken, tableID, req, cursor, size)
+		if err == nil {
+			recordRecordsPage(ctx, req)
+		}
+		if err != nil && size > 1 && isPageSizeRejected(err) {
+			smaller := size / 2
+			common.Warnf("表 %s 拒绝每页 %d 条记录，改为每页 %d 条重试: %v", tableID, size, smaller, err)
+			size = smaller
+			continue
+		}
+		return resp, size, err
+	}
+}
+
+// pageSizeError API 因每页记录数拒绝请求时返回的错误
+type pageSizeError struct {
+	err error
+}
+
+// Error 返回错误信息
+func (e *pageSizeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *pageSizeError) Unwrap() error {
+	return e.err
+}
+
+// isPageSizeRejected 判断错误是否因每页记录数被 API 拒绝
+func isPageSizeRejected(err error) bool {
+	var rejected *pageSizeError
+	if errors.As(err, &rejected) {
+		return true
+	}
+	return mentionsPageSize([]byte(err.Error()))
+}
+
+// mentionsPageSize 判断错误信息是否指向 page_size 参数
+// 飞书 API 参数校验失败时在 msg 或 field_violations 中给出出错的参数名
+func mentionsPageSize(text []byte) bool {
+	text = bytes.ToLower(text)
+	return bytes.Contains(text, []byte("page_size")) || bytes.Contains(text, []byte("page size")) || bytes.Contains(text, []byte("pagesize"))
+}
+
+// requestRecordsPage 发送读取一页记录的请求
+func requestRecordsPage(ctx context.Context, client APIClient, appToken, tableID string, req *ListRecordsRequest, cursor string, size int) (*ListRecordsResponse, error) {
+	if req == nil {
+		req = &ListRecordsRequest{}
+	}
+	query := map[string]string{}
+	if size > 0 {
+		query["page_size"] = strconv.Itoa(size)
+	}
+	if cursor != "" {
+		query["page_token"] = cursor
+	}
+
+	apiReq := &APIRequest{
+		Method:      "GET",
+		Path:        fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records", appToken, tableID),
+		QueryParams: query,
+	}
+	if req.Filter != nil || len(req.Sort) > 0 {
+		// 分页参数通过查询参数传递，不放在请求体中
+		body := *req
+		body.PageSize, body.PageToken = 0, ""
+		apiReq.Method = "POST"
+		apiReq.Path += "/search"
+		apiReq.Body = &body
+	} else {
+		// 列表接口的其余参数同样通过查询参数传递
+		if req.ViewID != "" {
+			query["view_id"] = req.ViewID
+		}
+		if len(req.FieldNames) > 0 {
+			names, _ := json.Marshal(req.FieldNames)
+			query["field_names"] = string(names)
+		}
+		if req.UserIDType != "" {
+			query["user_id_type"] = req.UserIDType
+		}
+		for name, enabled := range map[string]bool{
+			"automatic_fields":    req.AutomaticFields,
+			"text_field_as_array": req.TextFieldAsArray,
+			"display_formula_ref": req.DisplayFormula,
+		} {
+			if enabled {
+				query[name] = "true"
+			}
+		}
+	}
+
+	resp, err := client.DoRequest(ctx, apiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp ListRecordsAPIResponse
+	if err := decodeRecordsPage(resp.Body, &apiResp); err != nil {
+		return nil, fmt.Errorf("解析API响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		err := fmt.Errorf("API返回错误: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+		if mentionsPageSize(resp.Body) {
+			return nil, &pageSizeError{err: err}
+		}
+		return nil, err
+	}
+	if apiResp.Data == nil {
+		return &ListRecordsResponse{}, nil
+	}
+	if client, ok := client.(*Client); ok {
+		client.restoreFieldNames(tableID, apiResp.Data.Items)
+	}
+	return apiResp.Data, nil
+}
@@ -0,0 +1,91 @@
+package basesql
+
+import (
+	"context"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+)
+
+const (
+	// paginateSettingKey 语句设置中分页参数的键
+	paginateSettingKey = "basesql:paginate"
+
+	// nextCursorKey 查询完成后下一页游标在语句实例设置中的键
+	nextCursorKey = "basesql:next_cursor"
+)
+
+// pagination 游标分页参数
+type pagination struct {
+	cursor string // 上一页返回的游标，第一页为空
+	size   int    // 每页记录数，0 表示使用默认值
+}
+
+// Paginate 为查询设置游标分页
+// 多维表格不支持偏移量，Offset 需要从头扫描；游标即飞书 API 的分页标记，按游标翻页每页只需一次请求。
+// 游标按服务端的排序翻页，配置了 SortCollation 或不区分大小写时客户端排序只在每页内进行，
+// 需要跨页的完整顺序时不要使用 Paginate。
+// 查询完成后通过 NextCursor 获取下一页的游标：
+//
+//	result := basesql.Paginate(db.Where("age > ?", 18), cursor, 50).Find(&users)
+//	next := basesql.NextCursor(result) // 为空表示没有更多记录
+//
+// 参数:
+//   - db: GORM 数据库实例
+//   - cursor: 上一页返回的游标，读取第一页时为空
+//   - size: 每页记录数，不大于 0 时使用 Config.DefaultPageSize，都未设置时为 20，最大 500
+//
+// 返回:
+//   - *gorm.DB: 设置了分页参数的数据库实例
+func Paginate(db *gorm.DB, cursor string, size int) *gorm.DB {
+	return db.Set(paginateSettingKey, pagination{cursor: cursor, size: min(max(size, 0), common.MaxPageSize)})
+}
+
+// NextCursor 获取分页查询后下一页的游标
+// 参数:
+//   - db: Paginate 查询执行后返回的数据库实例，如 Find 的返回值
+//
+// 返回:
+//   - string: 下一页的游标，为空表示没有更多记录或查询未使用 Paginate
+func NextCursor(db *gorm.DB) string {
+	if db == nil {
+		return ""
+	}
+	if value, ok := db.InstanceGet(nextCursorKey); ok {
+		if cursor, ok := value.(string); ok {
+			return cursor
+		}
+	}
+	return ""
+}
+
+// paginationOf 获取语句的分页参数
+func paginationOf(db *gorm.DB) (pagination, bool) {
+	if value, ok := db.Get(paginateSettingKey); ok {
+		if page, ok := value.(pagination); ok {
+			return page, true
+		}
+	}
+	return pagination{}, false
+}
+
+// searchRecordsPage 按游标读取一页记录
+// 有过滤或排序条件时使用 search 接口，否则使用列表接口
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求
+//   - page: 分页参数，未指定每页记录数时依次使用 WithPageSize、Config.DefaultPageSize，都未设置时为 20
+//
+// 返回:
+//   - *ListRecordsResponse: 本页的记录和下一页的分页标记
+//   - error: 查询过程中的错误
+func searchRecordsPage(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, page pagination) (*ListRecordsResponse, error) {
+	size := page.size
+	if size <= 0 {
+		size = recordsPageSize(dialector.Config, req, common.DefaultPageSize)
+	}
+	resp, _, err := fetchRecordsPage(ctx, dialector, tableID, req, page.cursor, size)
+	return resp, err
+}
@@ -0,0 +1,304 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// PermissionOp 需要预检权限的操作类型
+type PermissionOp string
+
+const (
+	// PermReadRecords 读取记录
+	PermReadRecords PermissionOp = "read_records"
+	// PermWriteRecords 创建、更新、删除记录
+	PermWriteRecords PermissionOp = "write_records"
+	// PermReadSchema 读取数据表和字段结构
+	PermReadSchema PermissionOp = "read_schema"
+	// PermManageSchema 创建、修改、删除数据表和字段
+	PermManageSchema PermissionOp = "manage_schema"
+)
+
+// 权限相关的飞书错误码
+const (
+	feishuCodeScopeMissing     = 99991672 // 应用未开通所需权限
+	feishuCodeBaseForbidden    = 91403    // 无多维表格访问权限
+	feishuCodeRolePermNotAllow = 1254302  // 高级权限下无访问权限
+)
+
+// 多维表格相关的权限范围
+const (
+	ScopeBitableApp         = "bitable:app"          // 查看、评论、编辑和管理多维表格
+	ScopeBitableAppReadonly = "bitable:app:readonly" // 查看、评论和导出多维表格
+)
+
+// permissionRequirements 各操作所需的权限范围（满足其一即可）和多维表格协作权限
+var permissionRequirements = map[PermissionOp]struct {
+	scopes []string
+	action string
+}{
+	PermReadRecords:  {scopes: []string{ScopeBitableApp, ScopeBitableAppReadonly}, action: "view"},
+	PermReadSchema:   {scopes: []string{ScopeBitableApp, ScopeBitableAppReadonly}, action: "view"},
+	PermWriteRecords: {scopes: []string{ScopeBitableApp}, action: "edit"},
+	PermManageSchema: {scopes: []string{ScopeBitableApp}, action: "edit"},
+}
+
+// allPermissionOps 未指定操作时默认检查的全部操作，按权限从低到高排列
+var allPermissionOps = []PermissionOp{PermReadRecords, PermReadSchema, PermWriteRecords, PermManageSchema}
+
+// missingScopeRe 从权限错误信息中提取缺失的权限范围，如 [bitable:app, bitable:app:readonly]
+var missingScopeRe = regexp.MustCompile(`\[([a-z_:.,\s]+)\]`)
+
+// PermissionResult 单项操作的权限预检结果
+type PermissionResult struct {
+	Op            PermissionOp `json:"op"`                       // 操作类型
+	Allowed       bool         `json:"allowed"`                  // 是否允许执行
+	Verified      bool         `json:"verified"`                 // 是否经过确认；无法查询时按允许处理但标记为未确认
+	MissingScopes []string     `json:"missing_scopes,omitempty"` // 缺失的权限范围（满足其一即可）
+	Reason        string       `json:"reason,omitempty"`         // 不允许或无法确认的原因
+}
+
+// PermissionReport 权限预检报告
+type PermissionReport struct {
+	GrantedScopes []string           `json:"granted_scopes,omitempty"` // 应用已开通的权限范围，无法查询时为空
+	Results       []PermissionResult `json:"results"`                  // 各操作的预检结果，按传入顺序排列
+}
+
+// Err 将报告转换为错误
+// 返回:
+//   - error: 存在不允许的操作时返回包装 ErrPermissionDenied 的错误，否则返回 nil
+func (r *PermissionReport) Err() error {
+	var denied []string
+	for _, result := range r.Results {
+		if result.Allowed {
+			continue
+		}
+		msg := fmt.Sprintf("%s: %s", result.Op, result.Reason)
+		if len(result.MissingScopes) > 0 {
+			msg += fmt.Sprintf("（需要开通 %s）", strings.Join(result.MissingScopes, " 或 "))
+		}
+		denied = append(denied, msg)
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrPermissionDenied, strings.Join(denied, "; "))
+}
+
+// CheckPermissions 预检当前应用执行指定操作所需的权限
+// 依次查询应用已开通的权限范围、试探读取多维表格，并查询应用对多维表格的协作权限，
+// 便于在批量操作开始前发现权限问题，而不是在中途收到 403
+// 参数:
+//   - ctx: 上下文
+//   - ops: 需要检查的操作，为空时检查全部操作
+//
+// 返回:
+//   - *PermissionReport: 预检报告，调用 Err 可获取汇总错误
+//   - error: 预检过程中的非权限错误，如网络错误
+func (c *Client) CheckPermissions(ctx context.Context, ops ...PermissionOp) (*PermissionReport, error) {
+	if len(ops) == 0 {
+		ops = allPermissionOps
+	}
+	for _, op := range ops {
+		if _, ok := permissionRequirements[op]; !ok {
+			return nil, fmt.Errorf("%w: 未知的操作类型 %q", ErrInvalidOperation, op)
+		}
+	}
+
+	report := &PermissionReport{GrantedScopes: c.grantedScopes(ctx)}
+
+	// 试探读取：能直接发现缺失的权限范围和多维表格访问权限
+	readErr := c.probeRead(ctx)
+	if readErr != nil && !isPermissionAPIError(readErr) {
+		return nil, readErr
+	}
+
+	// 协作权限：只在需要编辑权限时查询
+	editAllowed, editKnown := true, false
+	for _, op := range ops {
+		if permissionRequirements[op].action == "edit" {
+			editAllowed, editKnown = c.baseAuth(ctx, "edit")
+			break
+		}
+	}
+
+	for _, op := range ops {
+		report.Results = append(report.Results, evaluatePermission(op, report.GrantedScopes, readErr, editAllowed, editKnown))
+	}
+	return report, nil
+}
+
+// GrantedScopes 查询应用已开通的权限范围
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - []string: 已开通的权限范围，无法查询时（如缺少应用信息读取权限）为 nil
+func (c *Client) GrantedScopes(ctx context.Context) []string {
+	return c.grantedScopes(ctx)
+}
+
+// evaluatePermission 根据已开通的权限范围、读取试探结果和协作权限判断单项操作是否允许
+func evaluatePermission(op PermissionOp, granted []string, readErr error, editAllowed, editKnown bool) PermissionResult {
+	req := permissionRequirements[op]
+	result := PermissionResult{Op: op, Allowed: true, Verified: true}
+
+	// 权限范围
+	if granted != nil {
+		if !containsAny(granted, req.scopes) {
+			result.Allowed = false
+			result.MissingScopes = req.scopes
+			result.Reason = "应用未开通所需权限"
+			return result
+		}
+	} else if req.action == "edit" {
+		// 无法查询已开通的权限范围时，读取试探无法说明是否有写权限
+		result.Verified = false
+		result.Reason = "无法查询应用已开通的权限范围"
+	}
+
+	// 读取试探
+	var apiErr *common.APIError
+	if errors.As(readErr, &apiErr) {
+		switch apiErr.Code {
+		case feishuCodeScopeMissing:
+			result.Allowed = false
+			result.Verified = true
+			result.MissingScopes = req.scopes
+			if matches := missingScopeRe.FindStringSubmatch(apiErr.Message); len(matches) == 2 && req.action == "view" {
+				result.MissingScopes = splitScopes(matches[1])
+			}
+			result.Reason = "应用未开通所需权限"
+			return result
+		case feishuCodeBaseForbidden, feishuCodeRolePermNotAllow:
+			result.Allowed = false
+			result.Verified = true
+			result.Reason = "应用未被添加为该多维表格的协作者"
+			return result
+		}
+	}
+
+	// 协作权限
+	if req.action == "edit" {
+		if !editKnown {
+			result.Verified = false
+			result.Reason = "无法查询应用对多维表格的编辑权限"
+		} else if !editAllowed {
+			result.Allowed = false
+			result.Verified = true
+			result.Reason = "应用对该多维表格只有查看权限，请授予可编辑权限"
+		}
+	}
+	return result
+}
+
+// grantedScopes 查询应用已开通的权限范围
+// 该接口本身需要应用信息读取权限，查询失败时返回 nil 表示未知
+func (c *Client) grantedScopes(ctx context.Context) []string {
+	var data struct {
+		App struct {
+			Scopes []struct {
+				Scope string `json:"scope"`
+			} `json:"scopes"`
+		} `json:"app"`
+	}
+	err := c.getJSON(ctx, fmt.Sprintf("/application/v6/applications/%s", c.config.AppID), map[string]string{"lang": "zh_cn"}, &data)
+	if err != nil {
+		common.Debugf("查询应用权限范围失败: %v", err)
+		return nil
+	}
+	scopes := make([]string, 0, len(data.App.Scopes))
+	for _, s := range data.App.Scopes {
+		scopes = append(scopes, s.Scope)
+	}
+	return scopes
+}
+
+// probeRead 试探读取多维表格的数据表列表
+func (c *Client) probeRead(ctx context.Context) error {
+	return c.getJSON(ctx, fmt.Sprintf("/bitable/v1/apps/%s/tables", c.config.AppToken), map[string]string{"page_size": "1"}, nil)
+}
+
+// baseAuth 查询应用对多维表格是否拥有指定的协作权限
+// 返回:
+//   - bool: 是否拥有该权限
+//   - bool: 是否查询成功
+func (c *Client) baseAuth(ctx context.Context, action string) (bool, bool) {
+	var data struct {
+		AuthResult bool `json:"auth_result"`
+	}
+	err := c.getJSON(ctx, fmt.Sprintf("/drive/v1/permissions/%s/members/auth", c.config.AppToken),
+		map[string]string{"type": "bitable", "action": action}, &data)
+	if err != nil {
+		common.Debugf("查询多维表格协作权限失败: %v", err)
+		return true, false
+	}
+	return data.AuthResult, true
+}
+
+// getJSON 发送 GET 请求并解析响应的 data 字段，业务错误码非 0 时返回 APIError
+func (c *Client) getJSON(ctx context.Context, path string, query map[string]string, data interface{}) error {
+	resp, err := c.DoRequest(ctx, &APIRequest{Method: "GET", Path: path, QueryParams: query})
+	if err != nil {
+		return err
+	}
+	var apiResp struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return common.NewAPIError(apiResp.Code, "api", apiResp.Msg, "")
+	}
+	if data != nil && len(apiResp.Data) > 0 {
+		if err := json.Unmarshal(apiResp.Data, data); err != nil {
+			return fmt.Errorf("解析响应数据失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// isPermissionAPIError 判断错误是否为权限类 API 错误
+func isPermissionAPIError(err error) bool {
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case feishuCodeScopeMissing, feishuCodeBaseForbidden, feishuCodeRolePermNotAllow:
+		return true
+	}
+	return false
+}
+
+// containsAny 判断 have 中是否包含 want 中的任意一项
+func containsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitScopes 拆分以逗号分隔的权限范围列表
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
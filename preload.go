@@ -0,0 +1,371 @@
+package basesql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxBatchGetRecordIDs 按记录 ID 批量获取时单次请求的最大 ID 数（飞书 API 限制）
+	maxBatchGetRecordIDs = 100
+
+	// maxInFilterValues 单个 isAnyOf 过滤条件的最大取值数，超过时拆分为多次查询
+	maxInFilterValues = 100
+)
+
+// batchGetRecordsRequest 按记录 ID 批量获取记录的请求体
+type batchGetRecordsRequest struct {
+	RecordIDs       []string `json:"record_ids"`       // 记录 ID 列表
+	AutomaticFields bool     `json:"automatic_fields"` // 是否返回创建时间等自动字段
+}
+
+// batchGetRecordsResponse 按记录 ID 批量获取记录的响应
+type batchGetRecordsResponse struct {
+	Code int    `json:"code"` // 响应码
+	Msg  string `json:"msg"`  // 响应消息
+	Data struct {
+		Records         []*Record `json:"records"`           // 获取到的记录
+		AbsentRecordIDs []string  `json:"absent_record_ids"` // 不存在的记录 ID
+	} `json:"data"`
+}
+
+// findInCondition 查找 WHERE 子句中顶层的 IN 条件
+// gorm 的 Preload 对每个关联只发起一次 WHERE 外键 IN (...) 查询，这类查询需要拆分取值并读取全部分页，
+// 否则取值过多会超出 API 限制，关联记录超过一页时会缺失。
+// 有多个 IN 条件时选择取值最少的一个拆分，批次最少且每个批次的结果最小，其余 IN 条件随其他条件一起下推
+// 参数:
+//   - exprs: WHERE 子句的表达式
+//
+// 返回:
+//   - clause.IN: 找到的 IN 条件
+//   - []clause.Expression: 其余的条件
+//   - bool: 是否找到
+func findInCondition(exprs []clause.Expression) (clause.IN, []clause.Expression, bool) {
+	found := -1
+	for i, expr := range exprs {
+		in, ok := expr.(clause.IN)
+		if !ok {
+			continue
+		}
+		if column, ok := in.Column.(clause.Column); !ok || column.Name == "" || len(in.Values) == 0 {
+			continue
+		}
+		if found < 0 || len(in.Values) < len(exprs[found].(clause.IN).Values) {
+			found = i
+		}
+	}
+	if found < 0 {
+		return clause.IN{}, nil, false
+	}
+	rest := make([]clause.Expression, 0, len(exprs)-1)
+	rest = append(rest, exprs[:found]...)
+	rest = append(rest, exprs[found+1:]...)
+	return exprs[found].(clause.IN), rest, true
+}
+
+// isRecordIDColumn 判断列是否对应记录 ID（模型主键或 record_id 列）
+// db.Find(&users, []string{...}) 这样按主键列表查询时，gorm 使用 clause.PrimaryKey 占位表示主键列
+func isRecordIDColumn(stmt *gorm.Statement, name string) bool {
+	if name == RecordIDColumn || name == clause.PrimaryKey {
+		return true
+	}
+	return stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil &&
+		stmt.Schema.PrioritizedPrimaryField.DBName == name
+}
+
+// fetchInBatches 执行带 IN 条件的查询
+// 主键（记录 ID）上的 IN 且没有其他条件和排序时使用批量获取接口，每次最多 100 个 ID；
+// 有其他条件或排序时按其余条件查询，只保留指定的记录，见 searchRecordIDs；
+// 其他列上的 IN 按 maxInFilterValues 拆分为多个 isAnyOf 查询，每个查询读取全部分页后合并，
+// 拆分后各批次内部保持排序，批次之间不再重新排序
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - in: IN 条件
+//   - rest: 其余的 WHERE 条件
+//   - sort: 排序条件
+//
+// 返回:
+//   - *ListRecordsResponse: 合并后的记录
+//   - error: 查询过程中的错误
+func fetchInBatches(db *gorm.DB, dialector *Dialector, tableID string, in clause.IN, rest []clause.Expression, sort []string) (*ListRecordsResponse, error) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	column := in.Column.(clause.Column)
+
+	if isRecordIDColumn(db.Statement, column.Name) {
+		recordIDs := make([]string, 0, len(in.Values))
+		seen := make(map[string]bool, len(in.Values))
+		for _, value := range in.Values {
+			if id := common.FormatValue(value); id != "" && !seen[id] {
+				seen[id] = true
+				recordIDs = append(recordIDs, id)
+			}
+		}
+		if len(recordIDs) == 0 {
+			return &ListRecordsResponse{}, nil
+		}
+		var records []*Record
+		var err error
+		if len(rest) == 0 && len(sort) == 0 {
+			records, err = batchGetRecords(ctx, dialector, tableID, recordIDs)
+		} else {
+			records, err = searchRecordIDs(ctx, dialector, tableID, seen, rest, sort)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &ListRecordsResponse{Items: records, Total: len(records)}, nil
+	}
+
+	converter := &SQLConverter{config: dialector.Config}
+	seen := make(map[string]bool)
+	result := &ListRecordsResponse{}
+	for start := 0; start < len(in.Values); start += maxInFilterValues {
+		end := min(start+maxInFilterValues, len(in.Values))
+		exprs := append([]clause.Expression{clause.IN{Column: in.Column, Values: in.Values[start:end]}}, rest...)
+		req := &ListRecordsRequest{
+			FieldNames:      make([]string, 0),
+			Filter:          converter.buildFilter(exprs),
+			Sort:            sort,
+			AutomaticFields: wantsRecordTimes(db.Statement.Schema),
+		}
+		records, err := searchAllRecords(ctx, dialector, tableID, req)
+		if err != nil {
+			return nil, err
+		}
+		// 多选等字段的值可能同时命中多个批次
+		for _, record := range records {
+			if seen[record.RecordID] {
+				continue
+			}
+			seen[record.RecordID] = true
+			result.Items = append(result.Items, record)
+		}
+	}
+	result.Total = len(result.Items)
+	return result, nil
+}
+
+// searchRecordIDs 在指定的记录中按其余条件查询
+// 飞书的过滤条件不支持记录 ID，批量获取接口又不支持过滤和排序：按其余条件查询，只保留指定的记录，
+// 指定的记录全部找到后停止翻页
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - recordIDs: 指定的记录 ID 集合
+//   - rest: 其余的 WHERE 条件
+//   - sort: 排序条件
+//
+// 返回:
+//   - []*Record: 符合条件的指定记录，按排序条件的顺序
+//   - error: 查询过程中的错误
+func searchRecordIDs(ctx context.Context, dialector *Dialector, tableID string, recordIDs map[string]bool, rest []clause.Expression, sort []string) ([]*Record, error) {
+	converter := &SQLConverter{config: dialector.Config}
+	// 与批量获取接口一致，返回记录的自动字段
+	req := &ListRecordsRequest{
+		FieldNames:      make([]string, 0),
+		Filter:          converter.buildFilter(rest),
+		Sort:            sort,
+		AutomaticFields: true,
+	}
+
+	var records []*Record
+	pageToken := ""
+	size := recordsPageSize(dialector.Config, req, common.MaxPageSize)
+	for {
+		page, used, err := fetchRecordsPage(ctx, dialector, tableID, req, pageToken, size)
+		if err != nil {
+			return nil, err
+		}
+		size = used
+
+		for _, record := range page.Items {
+			if record != nil && recordIDs[record.RecordID] {
+				records = append(records, record)
+			}
+		}
+		if len(records) == len(recordIDs) || !page.HasMore || page.PageToken == "" {
+			return records, nil
+		}
+		pageToken = page.PageToken
+	}
+}
+
+// searchAllRecords 按过滤条件查询并读取全部分页
+// 写入前查找目标记录、按键查找已有记录和关联预加载都需要完整的结果，不受 MaxResultRows 限制
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求，每页记录数取自其中的 PageSize 或 Config.DefaultPageSize，都未设置时为 500
+//
+// 返回:
+//   - []*Record: 全部记录
+//   - error: 查询过程中的错误
+func searchAllRecords(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest) ([]*Record, error) {
+	return readAllPages(ctx, dialector, tableID, req, nil, 0)
+}
+
+// searchResultRecords 读取查询语句返回给调用方的记录，客户端校验字符串匹配条件
+// 配置了 MaxResultRows 时，满足条件的记录达到该数量后停止翻页并输出警告
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求
+//   - matchers: 需要在客户端校验的字符串匹配条件
+//
+// 返回:
+//   - []*Record: 满足条件的记录
+//   - error: 查询过程中的错误
+func searchResultRecords(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, matchers []*likeMatcher) ([]*Record, error) {
+	return readAllPages(ctx, dialector, tableID, req, matchers, dialector.Config.MaxResultRows)
+}
+
+// sortedResultRecords 读取全部分页并在客户端排序，再按 limit 截断
+// 客户端排序需要全部满足条件的记录，只排序一页时后续分页中的记录不参与排序；
+// 配置了 MaxResultRows 时在排序后截断返回的记录，读取的记录数不受限制
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求，Sort 为排序条件
+//   - matchers: 需要在客户端校验的字符串匹配条件
+//   - compare: 文本比较函数
+//   - limit: 返回的最大记录数，0 表示不限制
+//
+// 返回:
+//   - []*Record: 排序后的记录
+//   - error: 查询过程中的错误
+func sortedResultRecords(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, matchers []*likeMatcher, compare textComparer, limit int) ([]*Record, error) {
+	records, err := readAllPages(ctx, dialector, tableID, req, matchers, 0)
+	if err != nil {
+		return nil, err
+	}
+	sortRecords(records, req.Sort, compare)
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	if maxRows := dialector.Config.MaxResultRows; maxRows > 0 && len(records) > maxRows {
+		warnMaxResultRows(tableID, maxRows)
+		records = records[:maxRows]
+	}
+	return records, nil
+}
+
+// warnMaxResultRows 输出查询结果被 MaxResultRows 截断的警告
+func warnMaxResultRows(tableID string, limit int) {
+	common.Warnf("表 %s 的查询结果超过 MaxResultRows 上限 %d 条，已截断；使用 Limit 或 Paginate 分批读取，或调大 Config.MaxResultRows（0 表示不限制）", tableID, limit)
+}
+
+// searchLimitedRecords 读取带 LIMIT 的查询语句返回的记录，读取到 limit 条或没有更多分页时停止
+// 配置的 MaxResultRows 小于 limit 时按 MaxResultRows 截断并输出警告
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求
+//   - limit: 返回的最大记录数，大于 0
+//
+// 返回:
+//   - []*Record: 最多 limit 条记录
+//   - error: 查询过程中的错误
+func searchLimitedRecords(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, limit int) ([]*Record, error) {
+	if maxRows := dialector.Config.MaxResultRows; maxRows > 0 && maxRows < limit {
+		return readAllPages(ctx, dialector, tableID, req, nil, maxRows)
+	}
+	records, _, err := readPages(ctx, dialector, tableID, req, nil, limit)
+	return records, err
+}
+
+// readAllPages 逐页读取记录并在客户端校验字符串匹配条件，limit 大于 0 时满足条件的记录达到该数量后停止翻页并输出警告
+func readAllPages(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, matchers []*likeMatcher, limit int) ([]*Record, error) {
+	records, truncated, err := readPages(ctx, dialector, tableID, req, matchers, limit)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		warnMaxResultRows(tableID, limit)
+	}
+	return records, nil
+}
+
+// readPages 逐页读取记录并在客户端校验字符串匹配条件，limit 大于 0 时满足条件的记录达到该数量后停止翻页
+// 返回:
+//   - []*Record: 满足条件的记录，最多 limit 条
+//   - bool: 是否还有未返回的记录
+//   - error: 查询过程中的错误
+func readPages(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, matchers []*likeMatcher, limit int) ([]*Record, bool, error) {
+	var records []*Record
+	pageToken := ""
+	size := recordsPageSize(dialector.Config, req, common.MaxPageSize)
+	for {
+		page, used, err := fetchRecordsPage(ctx, dialector, tableID, req, pageToken, size)
+		if err != nil {
+			return nil, false, err
+		}
+		size = used
+
+		records = append(records, filterLikeRecords(page.Items, matchers)...)
+		more := page.HasMore && page.PageToken != ""
+		// 达到上限后停止翻页，避免意外读取整张大表
+		if limit > 0 && len(records) >= limit {
+			return records[:limit], more || len(records) > limit, nil
+		}
+		if !more {
+			return records, false, nil
+		}
+		pageToken = page.PageToken
+	}
+}
+
+// batchGetRecords 按记录 ID 批量获取记录，每次请求最多 100 个 ID，不存在的 ID 被忽略
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - recordIDs: 记录 ID 列表
+//
+// 返回:
+//   - []*Record: 获取到的记录
+//   - error: 请求过程中的错误
+func batchGetRecords(ctx context.Context, dialector *Dialector, tableID string, recordIDs []string) ([]*Record, error) {
+	var records []*Record
+	for start := 0; start < len(recordIDs); start += maxBatchGetRecordIDs {
+		end := min(start+maxBatchGetRecordIDs, len(recordIDs))
+		resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_get", dialector.Config.AppToken, tableID),
+			Body:   &batchGetRecordsRequest{RecordIDs: recordIDs[start:end], AutomaticFields: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var apiResp batchGetRecordsResponse
+		decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&apiResp); err != nil {
+			return nil, err
+		}
+		if apiResp.Code != 0 {
+			return nil, fmt.Errorf("API返回错误: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+		}
+		normalizeRecordNumbers(apiResp.Data.Records)
+		records = append(records, apiResp.Data.Records...)
+	}
+	if client := dialector.baseClient(); client != nil {
+		client.restoreFieldNames(tableID, records)
+	}
+	return records, nil
+}
@@ -0,0 +1,623 @@
+package queryengine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	basesql "github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// caseExpr SELECT 投影中的 CASE 表达式，读取记录后在客户端逐条计算，用于按条件划分类别（如年龄段）
+// 支持搜索式 CASE WHEN condition THEN result ... [ELSE result] END
+// 和简单式 CASE operand WHEN value THEN result ... [ELSE result] END；
+// 条件支持 =、!=、<>、>、>=、<、<=、IS [NOT] NULL、[NOT] IN (...)，可以用 AND、OR、NOT 和括号组合；
+// 操作数和结果可以是嵌套的 CASE 表达式，数字字段与不是数字的字符串比较时返回错误
+type caseExpr struct {
+	whens      []caseWhen
+	elseResult *exprOperand // 没有 ELSE 时为空，结果为 NULL
+	fold       bool         // 字符串比较是否不区分大小写
+}
+
+// caseWhen CASE 表达式中的一个 WHEN 分支
+type caseWhen struct {
+	condition exprCondition
+	result    exprOperand
+}
+
+// exprOperand 表达式中的操作数：字段引用、嵌套的 CASE 表达式或字面量
+type exprOperand struct {
+	field *basesql.Field // 引用的字段，为空时使用 expr 或 value
+	expr  *caseExpr      // 嵌套的 CASE 表达式
+	value interface{}    // 字面量：字符串、float64、bool 或 nil（NULL）
+}
+
+// eval 获取操作数在记录中的值，字段值转换为对应的 Go 类型
+func (o exprOperand) eval(record basesql.Record) interface{} {
+	if o.expr != nil {
+		return o.expr.eval(record)
+	}
+	if o.field == nil {
+		return o.value
+	}
+	raw := FieldValue(record, o.field)
+	if raw == nil {
+		return nil
+	}
+	return o.field.ConvertToGoValue(raw)
+}
+
+// exprCondition WHEN 分支的条件
+type exprCondition interface {
+	// match 判断记录是否满足条件，fold 为 true 时字符串比较不区分大小写
+	match(record basesql.Record, fold bool) bool
+}
+
+// andCondition 全部子条件满足
+type andCondition []exprCondition
+
+func (c andCondition) match(record basesql.Record, fold bool) bool {
+	for _, sub := range c {
+		if !sub.match(record, fold) {
+			return false
+		}
+	}
+	return true
+}
+
+// orCondition 任一子条件满足
+type orCondition []exprCondition
+
+func (c orCondition) match(record basesql.Record, fold bool) bool {
+	for _, sub := range c {
+		if sub.match(record, fold) {
+			return true
+		}
+	}
+	return false
+}
+
+// notCondition 子条件不满足
+type notCondition struct{ sub exprCondition }
+
+func (c notCondition) match(record basesql.Record, fold bool) bool {
+	return !c.sub.match(record, fold)
+}
+
+// compareCondition 比较条件，任一侧为 NULL 时不满足
+type compareCondition struct {
+	left, right exprOperand
+	op          string
+}
+
+func (c compareCondition) match(record basesql.Record, fold bool) bool {
+	a, b := c.left.eval(record), c.right.eval(record)
+	if a == nil || b == nil {
+		return false
+	}
+	return c.holds(compareExprValues(a, b, fold))
+}
+
+// holds 判断比较结果是否满足操作符
+func (c compareCondition) holds(cmp int) bool {
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// nullCondition IS NULL、IS NOT NULL 条件，空字符串视为 NULL
+type nullCondition struct {
+	operand exprOperand
+	not     bool
+}
+
+func (c nullCondition) match(record basesql.Record, fold bool) bool {
+	value := c.operand.eval(record)
+	null := value == nil || common.FormatValue(value) == ""
+	return null != c.not
+}
+
+// inCondition IN、NOT IN 条件
+type inCondition struct {
+	operand exprOperand
+	values  []exprOperand
+	not     bool
+}
+
+func (c inCondition) match(record basesql.Record, fold bool) bool {
+	value := c.operand.eval(record)
+	if value == nil {
+		return false
+	}
+	for _, candidate := range c.values {
+		if other := candidate.eval(record); other != nil && compareExprValues(value, other, fold) == 0 {
+			return !c.not
+		}
+	}
+	return c.not
+}
+
+// compareExprValues 比较两个非空值，返回 -1、0 或 1
+// 两边都能转换为数字时按数值比较，其余按文本比较
+func compareExprValues(a, b interface{}, fold bool) int {
+	if x, ok := exprNumber(a); ok {
+		if y, ok := exprNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	x, y := common.FormatValue(a), common.FormatValue(b)
+	if fold {
+		x, y = strings.ToLower(x), strings.ToLower(y)
+	}
+	return strings.Compare(x, y)
+}
+
+// exprNumber 将值转换为数字
+func exprNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// eval 按顺序匹配 WHEN 分支，返回第一个满足条件的分支的结果，都不满足时返回 ELSE 的结果
+func (c *caseExpr) eval(record basesql.Record) interface{} {
+	for _, when := range c.whens {
+		if when.condition.match(record, c.fold) {
+			return when.result.eval(record)
+		}
+	}
+	if c.elseResult != nil {
+		return c.elseResult.eval(record)
+	}
+	return nil
+}
+
+// resultType 结果列的字段类型：全部结果都是数字字面量时为数字，否则为文本
+func (c *caseExpr) resultType() basesql.FieldType {
+	results := make([]exprOperand, 0, len(c.whens)+1)
+	for _, when := range c.whens {
+		results = append(results, when.result)
+	}
+	if c.elseResult != nil {
+		results = append(results, *c.elseResult)
+	}
+	for _, result := range results {
+		if result.field != nil || (result.expr != nil && result.expr.resultType() != basesql.FieldTypeNumber) {
+			return basesql.FieldTypeText
+		}
+		if _, ok := result.value.(float64); !ok && result.value != nil {
+			return basesql.FieldTypeText
+		}
+	}
+	return basesql.FieldTypeNumber
+}
+
+// isCaseExpr 判断 SELECT 投影中的列表达式是否为 CASE 表达式
+func isCaseExpr(expr string) bool {
+	fields := strings.Fields(expr)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "CASE")
+}
+
+// parseCaseExpr 解析 CASE 表达式，表达式中的字段名按表的字段解析
+// 参数:
+//   - expr: CASE 表达式，不含别名
+//   - fields: 表的字段列表
+//   - fold: 字符串比较是否不区分大小写
+//
+// 返回:
+//   - *caseExpr: 解析后的表达式
+//   - error: 语法错误或引用的字段不存在
+func parseCaseExpr(expr string, fields []basesql.Field, fold bool) (*caseExpr, error) {
+	tokens, err := tokenizeExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, fields: fields, fold: fold}
+	c, err := p.parseCase()
+	if err != nil {
+		return nil, fmt.Errorf("CASE 表达式解析失败: %w", err)
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("CASE 表达式解析失败: END 之后有多余的内容 '%s'", p.peek().text)
+	}
+	return c, nil
+}
+
+// exprTokenKind 词法单元的类型
+type exprTokenKind int
+
+const (
+	exprTokenWord   exprTokenKind = iota // 关键字或字段名
+	exprTokenField                       // 反引号或双引号括起的字段名
+	exprTokenString                      // 单引号括起的字符串
+	exprTokenNumber                      // 数字
+	exprTokenSymbol                      // 比较符、括号和逗号
+)
+
+// exprToken 词法单元
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+// tokenizeExpr 将表达式切分为词法单元
+func tokenizeExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '\'' || ch == '`' || ch == '"':
+			// 单引号中的 '' 表示一个单引号
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(expr); j++ {
+				if expr[j] == ch {
+					if ch == '\'' && j+1 < len(expr) && expr[j+1] == '\'' {
+						text.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				text.WriteByte(expr[j])
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("引号未闭合: %s", expr[i:])
+			}
+			kind := exprTokenField
+			if ch == '\'' {
+				kind = exprTokenString
+			}
+			tokens = append(tokens, exprToken{kind: kind, text: text.String()})
+			i = j + 1
+		case strings.ContainsRune("(),", rune(ch)):
+			tokens = append(tokens, exprToken{kind: exprTokenSymbol, text: string(ch)})
+			i++
+		case strings.ContainsRune("=<>!", rune(ch)):
+			op := string(ch)
+			if i+1 < len(expr) && (expr[i+1] == '=' || ch == '<' && expr[i+1] == '>') {
+				op = expr[i : i+2]
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("不支持的操作符: !")
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenSymbol, text: op})
+			i += len(op)
+		case ch == '-' || ch == '.' || ch >= '0' && ch <= '9':
+			j := i + 1
+			for j < len(expr) && (expr[j] == '.' || expr[j] >= '0' && expr[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenNumber, text: expr[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t\n\r'`\"(),=<>!", rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenWord, text: expr[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser CASE 表达式的递归下降解析器
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	fields []basesql.Field
+	fold   bool // 字符串比较是否不区分大小写，用于各层 CASE 表达式
+}
+
+// done 判断是否已解析全部词法单元
+func (p *exprParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// peek 获取当前词法单元，解析结束时返回空的词法单元
+func (p *exprParser) peek() exprToken {
+	if p.done() {
+		return exprToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+// keyword 当前词法单元为指定关键字时前进并返回 true
+func (p *exprParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == exprTokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol 当前词法单元为指定符号时前进并返回 true
+func (p *exprParser) symbol(s string) bool {
+	if t := p.peek(); t.kind == exprTokenSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect 要求当前词法单元为指定关键字
+func (p *exprParser) expect(word string) error {
+	if !p.keyword(word) {
+		return fmt.Errorf("缺少 %s，位置: '%s'", word, p.peek().text)
+	}
+	return nil
+}
+
+// parseCase 解析 CASE ... END
+func (p *exprParser) parseCase() (*caseExpr, error) {
+	if err := p.expect("CASE"); err != nil {
+		return nil, err
+	}
+
+	// 简单式 CASE：CASE 之后紧跟操作数
+	var subject *exprOperand
+	if t := p.peek(); !(t.kind == exprTokenWord && strings.EqualFold(t.text, "WHEN")) {
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		subject = &operand
+	}
+
+	c := &caseExpr{fold: p.fold}
+	for p.keyword("WHEN") {
+		var condition exprCondition
+		if subject != nil {
+			value, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkComparable(*subject, value); err != nil {
+				return nil, err
+			}
+			condition = compareCondition{left: *subject, right: value, op: "="}
+		} else {
+			var err error
+			if condition, err = p.parseOr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("THEN"); err != nil {
+			return nil, err
+		}
+		result, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		c.whens = append(c.whens, caseWhen{condition: condition, result: result})
+	}
+	if len(c.whens) == 0 {
+		return nil, fmt.Errorf("CASE 至少需要一个 WHEN 分支")
+	}
+	if p.keyword("ELSE") {
+		result, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		c.elseResult = &result
+	}
+	if err := p.expect("END"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseOr 解析以 OR 连接的条件
+func (p *exprParser) parseOr() (exprCondition, error) {
+	var conditions orCondition
+	for {
+		condition, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+// parseAnd 解析以 AND 连接的条件
+func (p *exprParser) parseAnd() (exprCondition, error) {
+	var conditions andCondition
+	for {
+		condition, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		if !p.keyword("AND") {
+			break
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+// parseNot 解析 NOT、括号和单个谓词
+func (p *exprParser) parseNot() (exprCondition, error) {
+	if p.keyword("NOT") {
+		sub, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{sub: sub}, nil
+	}
+	if p.symbol("(") {
+		condition, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, fmt.Errorf("括号未闭合")
+		}
+		return condition, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate 解析比较、IS [NOT] NULL 和 [NOT] IN 谓词
+func (p *exprParser) parsePredicate() (exprCondition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return nullCondition{operand: left, not: not}, nil
+	}
+
+	not := p.keyword("NOT")
+	if p.keyword("IN") {
+		if !p.symbol("(") {
+			return nil, fmt.Errorf("IN 之后需要括号中的值列表")
+		}
+		var values []exprOperand
+		for {
+			value, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkComparable(left, value); err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if !p.symbol(",") {
+				break
+			}
+		}
+		if !p.symbol(")") {
+			return nil, fmt.Errorf("IN 的值列表缺少右括号")
+		}
+		return inCondition{operand: left, values: values, not: not}, nil
+	}
+	if not {
+		return nil, fmt.Errorf("NOT 之后需要 IN")
+	}
+
+	t := p.peek()
+	switch t.text {
+	case "=", "!=", "<>", ">", ">=", "<", "<=":
+		if t.kind != exprTokenSymbol {
+			break
+		}
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkComparable(left, right); err != nil {
+			return nil, err
+		}
+		return compareCondition{left: left, right: right, op: t.text}, nil
+	}
+	return nil, fmt.Errorf("缺少比较操作符，位置: '%s'", t.text)
+}
+
+// parseOperand 解析字面量或字段引用
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	if p.done() {
+		return exprOperand{}, fmt.Errorf("表达式不完整")
+	}
+	t := p.tokens[p.pos]
+	switch t.kind {
+	case exprTokenString:
+		p.pos++
+		return exprOperand{value: t.text}, nil
+	case exprTokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return exprOperand{}, fmt.Errorf("无效的数字: %s", t.text)
+		}
+		p.pos++
+		return exprOperand{value: n}, nil
+	case exprTokenWord:
+		switch strings.ToUpper(t.text) {
+		case "NULL":
+			p.pos++
+			return exprOperand{}, nil
+		case "TRUE", "FALSE":
+			p.pos++
+			return exprOperand{value: strings.EqualFold(t.text, "TRUE")}, nil
+		case "CASE":
+			nested, err := p.parseCase()
+			if err != nil {
+				return exprOperand{}, err
+			}
+			return exprOperand{expr: nested}, nil
+		case "WHEN", "THEN", "ELSE", "END", "AND", "OR", "NOT", "IS", "IN":
+			return exprOperand{}, fmt.Errorf("缺少操作数，位置: '%s'", t.text)
+		}
+		fallthrough
+	case exprTokenField:
+		field := FindField(p.fields, t.text)
+		if field == nil {
+			return exprOperand{}, fmt.Errorf("字段 '%s' 不存在", t.text)
+		}
+		p.pos++
+		return exprOperand{field: field}, nil
+	}
+	return exprOperand{}, fmt.Errorf("缺少操作数，位置: '%s'", t.text)
+}
+
+// checkComparable 检查两个操作数能否比较：数字字段不能与不是数字的字符串字面量比较
+func checkComparable(a, b exprOperand) error {
+	for _, pair := range [][2]exprOperand{{a, b}, {b, a}} {
+		field, literal := pair[0].field, pair[1]
+		if field == nil || field.Type != basesql.FieldTypeNumber || literal.field != nil || literal.expr != nil {
+			continue
+		}
+		if text, ok := literal.value.(string); ok {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err != nil {
+				return fmt.Errorf("数字字段 '%s' 不能与字符串 '%s' 比较", field.FieldName, text)
+			}
+		}
+	}
+	return nil
+}
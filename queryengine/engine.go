@@ -0,0 +1,516 @@
+// Package queryengine 在客户端对记录执行过滤、分组聚合、排序和投影，CLI 的 SELECT 使用同一套实现，
+// 可用于自行读取的记录，见 Pipeline
+package queryengine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// Engine 查询各阶段的执行引擎，零值按区分大小写的规则比较字符串
+type Engine struct {
+	Collation basesql.Collation      // 字符串比较规则，为空时使用 basesql.CollationBinary
+	Stats     map[string]ColumnStats // 字段的统计信息，键为字段名，用于确定 WHERE 条件的计算顺序，可以为空
+}
+
+// ColumnStats 字段的统计信息（如 basesql profile 的结果），用于估计条件的选择性
+// 统计信息只影响条件的计算顺序，与表中的数据不一致时不影响结果
+type ColumnStats struct {
+	Records  int            // 统计时的记录数
+	Filled   int            // 非空记录数
+	Distinct int            // 不同值数量
+	Top      map[string]int // 高频值及其出现次数，可以为空
+}
+
+// Condition WHERE 子句中的一个字段条件
+type Condition struct {
+	Field    string      // 字段名
+	Operator string      // 比较操作符：=、!=、<>、>、>=、<、<=、LIKE 或 ILIKE，为空时为 =
+	Value    interface{} // 期望的值，LIKE、ILIKE 条件为匹配模式
+}
+
+// isEqual 判断条件是否为等值比较
+func (c Condition) isEqual() bool {
+	return c.Operator == "" || c.Operator == "="
+}
+
+// 没有统计信息时条件的默认选择性：等值条件通常比 LIKE 条件过滤掉更多记录
+const (
+	defaultEqualSelectivity = 0.1
+	defaultLikeSelectivity  = 0.5
+)
+
+// fold 判断字符串比较是否不区分大小写
+func (e *Engine) fold() bool {
+	return e.Collation == basesql.CollationCaseInsensitive
+}
+
+// Column 查询结果中的一列
+// 由 SELECT 投影决定，顺序与 SQL 中声明的顺序一致
+type Column struct {
+	Field *basesql.Field // 对应的表字段，CASE 表达式列为按结果类型构造的字段
+	Label string         // 输出时显示的列名（别名或字段名）
+	expr  *caseExpr      // CASE 表达式列的表达式，普通列为空
+}
+
+// Value 获取记录在该列的原始值，CASE 表达式列按记录计算
+func (c Column) Value(record basesql.Record) interface{} {
+	if c.expr != nil {
+		return c.expr.eval(record)
+	}
+	return record.Fields[c.Field.FieldName]
+}
+
+// Project 根据 SELECT 字段列表构建结果列
+// "*" 展开为表中的全部字段（按字段列表的顺序），其余列保持声明顺序，可以使用 "field AS alias"；
+// CASE 表达式列在输出时按记录计算
+// 参数:
+//   - selectFields: SELECT 子句中的字段列表，为空时视为 "*"
+//   - fields: 表的字段列表
+//
+// 返回:
+//   - []Column: 结果列
+//   - error: 字段不存在或 CASE 表达式无效时返回错误
+func (e *Engine) Project(selectFields []string, fields []basesql.Field) ([]Column, error) {
+	if len(selectFields) == 0 {
+		selectFields = []string{"*"}
+	}
+
+	columns := make([]Column, 0, len(fields))
+	for _, expr := range selectFields {
+		if strings.TrimSpace(expr) == "*" {
+			for i := range fields {
+				columns = append(columns, Column{Field: &fields[i], Label: fields[i].FieldName})
+			}
+			continue
+		}
+
+		name, alias := common.ParseSelectColumn(expr)
+		if isCaseExpr(name) {
+			c, err := parseCaseExpr(name, fields, e.fold())
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, Column{Field: &basesql.Field{FieldName: alias, Type: c.resultType()}, Label: alias, expr: c})
+			continue
+		}
+		field := FindField(fields, name)
+		if field == nil {
+			return nil, fmt.Errorf("字段 '%s' 不存在", name)
+		}
+		if alias == name {
+			alias = field.FieldName
+		}
+		columns = append(columns, Column{Field: field, Label: alias})
+	}
+	return columns, nil
+}
+
+// Filter 根据 WHERE 条件过滤记录
+// 参数:
+//   - records: 原始记录列表
+//   - fields: 字段列表
+//   - conditions: WHERE 条件，见 Matcher
+//
+// 返回:
+//   - []basesql.Record: 过滤后的记录列表，没有条件时为原列表
+func (e *Engine) Filter(records []basesql.Record, fields []basesql.Field, conditions []Condition) []basesql.Record {
+	if len(conditions) == 0 {
+		return records
+	}
+
+	match := e.Matcher(fields, conditions)
+	var filtered []basesql.Record
+	for _, record := range records {
+		if match(record) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// Matcher 根据 WHERE 条件创建判断单条记录是否满足条件的函数，记录需要满足全部条件
+// LIKE、ILIKE 条件按模式匹配，= 和 != 按文本比较，>、>=、<、<= 两边都是数字时按数值比较，其余按文本比较；
+// 不支持的操作符不匹配任何记录。
+// 多个条件按估计的选择性从高到低计算（见 Stats），遇到不满足的条件立即返回，选择性相同时等值条件先于其他条件
+// 参数:
+//   - fields: 字段列表
+//   - conditions: WHERE 条件
+//
+// 返回:
+//   - func(basesql.Record) bool: 记录满足全部条件时返回 true
+func (e *Engine) Matcher(fields []basesql.Field, conditions []Condition) func(basesql.Record) bool {
+	// 创建字段名到字段ID的映射
+	fieldNameToID := make(map[string]string)
+	for _, field := range fields {
+		fieldNameToID[field.FieldName] = field.FieldID
+	}
+
+	type predicate struct {
+		fieldName   string
+		selectivity float64 // 估计满足条件的记录比例
+		equal       bool
+		match       func(actualValue interface{}) bool
+	}
+	predicates := make([]predicate, 0, len(conditions))
+	for _, condition := range conditions {
+		p := predicate{fieldName: condition.Field, equal: condition.isEqual()}
+		expected, fold := condition.Value, e.fold()
+		switch op := strings.ToUpper(condition.Operator); op {
+		case "", "=":
+			p.match = func(actualValue interface{}) bool {
+				return matchEqual(actualValue, expected, fold)
+			}
+		case "!=", "<>":
+			p.match = func(actualValue interface{}) bool {
+				return !matchEqual(actualValue, expected, fold)
+			}
+		case "LIKE", "ILIKE":
+			// ILIKE 总是不区分大小写
+			p.match = likeMatch(expected, op == "ILIKE" || fold)
+		case ">", ">=", "<", "<=":
+			p.match = func(actualValue interface{}) bool {
+				if actualValue == nil || expected == nil {
+					return false
+				}
+				return compareCondition{op: op}.holds(compareExprValues(actualValue, expected, fold))
+			}
+		default:
+			p.match = func(interface{}) bool { return false }
+		}
+		p.selectivity = e.selectivity(condition.Field, expected, !p.equal)
+		predicates = append(predicates, p)
+	}
+	sort.Slice(predicates, func(i, j int) bool {
+		a, b := predicates[i], predicates[j]
+		if a.selectivity != b.selectivity {
+			return a.selectivity < b.selectivity
+		}
+		if a.equal != b.equal {
+			return a.equal
+		}
+		return a.fieldName < b.fieldName
+	})
+
+	return func(record basesql.Record) bool {
+		for _, p := range predicates {
+			// 尝试使用字段名直接获取值
+			actualValue := record.Fields[p.fieldName]
+
+			// 如果使用字段名获取不到值，尝试使用字段ID
+			if actualValue == nil {
+				if fieldID, exists := fieldNameToID[p.fieldName]; exists {
+					actualValue = record.Fields[fieldID]
+				}
+			}
+
+			if !p.match(actualValue) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// selectivity 根据字段的统计信息估计满足条件的记录比例，越小表示条件过滤掉的记录越多
+// 等值条件：空值按未填充的比例，高频值按出现次数，其余按填充率平均分配到各个不同值；
+// LIKE 和范围等其他条件无法从统计信息中估计，按填充率的一半计算
+func (e *Engine) selectivity(fieldName string, value interface{}, like bool) float64 {
+	stats, ok := e.Stats[fieldName]
+	if !ok || stats.Records <= 0 {
+		if like {
+			return defaultLikeSelectivity
+		}
+		return defaultEqualSelectivity
+	}
+
+	records := float64(stats.Records)
+	fill := float64(stats.Filled) / records
+	text := common.FormatValue(value)
+	switch {
+	case like:
+		return fill * defaultLikeSelectivity
+	case text == "":
+		return 1 - fill
+	}
+	if count, ok := stats.Top[text]; ok {
+		return float64(count) / records
+	}
+	if stats.Distinct > 0 {
+		return fill / float64(stats.Distinct)
+	}
+	return fill
+}
+
+// likeMatch 创建 LIKE 匹配函数，模式只在创建时编译一次
+// fold 为 true 时不区分大小写（ILIKE 或 case_insensitive 比较规则）
+func likeMatch(expectedValue interface{}, fold bool) func(actualValue interface{}) bool {
+	expectedStr := fmt.Sprintf("%v", expectedValue)
+
+	// 简单的LIKE实现，支持%通配符
+	if strings.Contains(expectedStr, "%") {
+		// 手动构建正则表达式模式
+		pattern := ""
+		for _, char := range expectedStr {
+			if char == '%' {
+				pattern += ".*"
+			} else {
+				// 转义正则表达式特殊字符
+				charStr := string(char)
+				if strings.ContainsAny(charStr, ".+*?^${}()|[]\\") {
+					pattern += "\\" + charStr
+				} else {
+					pattern += charStr
+				}
+			}
+		}
+
+		// 确保完全匹配（从开始到结束）
+		pattern = "^" + pattern + "$"
+		if fold {
+			pattern = "(?i)" + pattern
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return func(interface{}) bool { return false }
+		}
+		return func(actualValue interface{}) bool {
+			return re.MatchString(fmt.Sprintf("%v", actualValue))
+		}
+	}
+
+	// 如果没有通配符，检查是否包含
+	if fold {
+		lower := strings.ToLower(expectedStr)
+		return func(actualValue interface{}) bool {
+			return strings.Contains(strings.ToLower(fmt.Sprintf("%v", actualValue)), lower)
+		}
+	}
+	return func(actualValue interface{}) bool {
+		return strings.Contains(fmt.Sprintf("%v", actualValue), expectedStr)
+	}
+}
+
+// matchEqual 执行等值匹配
+// fold 为 true 时字符串比较不区分大小写
+func matchEqual(actualValue, expectedValue interface{}, fold bool) bool {
+	// 处理 nil 值
+	if actualValue == nil {
+		return expectedValue == nil || fmt.Sprintf("%v", expectedValue) == "" || fmt.Sprintf("%v", expectedValue) == "<nil>"
+	}
+	if expectedValue == nil {
+		return actualValue == nil || fmt.Sprintf("%v", actualValue) == "" || fmt.Sprintf("%v", actualValue) == "<nil>"
+	}
+
+	// 转换为字符串进行比较
+	actualStr := fmt.Sprintf("%v", actualValue)
+	expectedStr := fmt.Sprintf("%v", expectedValue)
+
+	// 处理空字符串和 "<nil>" 的情况
+	if actualStr == "<nil>" {
+		actualStr = ""
+	}
+	if expectedStr == "<nil>" {
+		expectedStr = ""
+	}
+
+	if fold {
+		return strings.EqualFold(actualStr, expectedStr)
+	}
+	return actualStr == expectedStr
+}
+
+// Aggregate 对一组记录计算聚合函数
+// 参数:
+//   - function: 聚合函数，COUNT、SUM、AVG、MIN 或 MAX
+//   - fieldName: 聚合函数作用的字段，COUNT(*) 为 *
+//   - fields: 字段列表
+//   - records: 记录列表
+//
+// 返回:
+//   - interface{}: 聚合结果
+//   - error: 错误信息
+func (e *Engine) Aggregate(function, fieldName string, fields []basesql.Field, records []basesql.Record) (interface{}, error) {
+	switch function {
+	case "COUNT":
+		return len(records), nil
+	case "SUM":
+		return calculateSum(records, fields, fieldName)
+	case "AVG":
+		return calculateAvg(records, fields, fieldName)
+	case "MIN":
+		return calculateMin(records, fields, fieldName)
+	case "MAX":
+		return calculateMax(records, fields, fieldName)
+	default:
+		return nil, fmt.Errorf("不支持的聚合函数: %s", function)
+	}
+}
+
+// calculateSum 计算SUM聚合
+func calculateSum(records []basesql.Record, fields []basesql.Field, fieldName string) (float64, error) {
+	if fieldName == "*" {
+		return 0, fmt.Errorf("SUM函数不支持*参数")
+	}
+
+	field := FindField(fields, fieldName)
+	if field == nil {
+		return 0, fmt.Errorf("字段 %s 不存在", fieldName)
+	}
+
+	var sum float64
+	for _, record := range records {
+		value := FieldValue(record, field)
+		if numValue, err := convertToNumber(value); err == nil {
+			sum += numValue
+		}
+	}
+
+	return sum, nil
+}
+
+// calculateAvg 计算AVG聚合
+func calculateAvg(records []basesql.Record, fields []basesql.Field, fieldName string) (float64, error) {
+	sum, err := calculateSum(records, fields, fieldName)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	return sum / float64(len(records)), nil
+}
+
+// calculateMin 计算MIN聚合
+func calculateMin(records []basesql.Record, fields []basesql.Field, fieldName string) (interface{}, error) {
+	if fieldName == "*" {
+		return nil, fmt.Errorf("MIN函数不支持*参数")
+	}
+
+	field := FindField(fields, fieldName)
+	if field == nil {
+		return nil, fmt.Errorf("字段 %s 不存在", fieldName)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	var min interface{}
+	for i, record := range records {
+		value := FieldValue(record, field)
+		if i == 0 || compareValues(value, min) < 0 {
+			min = value
+		}
+	}
+
+	return min, nil
+}
+
+// calculateMax 计算MAX聚合
+func calculateMax(records []basesql.Record, fields []basesql.Field, fieldName string) (interface{}, error) {
+	if fieldName == "*" {
+		return nil, fmt.Errorf("MAX函数不支持*参数")
+	}
+
+	field := FindField(fields, fieldName)
+	if field == nil {
+		return nil, fmt.Errorf("字段 %s 不存在", fieldName)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	var max interface{}
+	for i, record := range records {
+		value := FieldValue(record, field)
+		if i == 0 || compareValues(value, max) > 0 {
+			max = value
+		}
+	}
+
+	return max, nil
+}
+
+// convertToNumber 将值转换为数字
+func convertToNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("无法转换为数字: %v", value)
+	}
+}
+
+// compareValues 比较两个值
+func compareValues(a, b interface{}) int {
+	aStr := fmt.Sprintf("%v", a)
+	bStr := fmt.Sprintf("%v", b)
+
+	// 尝试数字比较
+	if aNum, aErr := strconv.ParseFloat(aStr, 64); aErr == nil {
+		if bNum, bErr := strconv.ParseFloat(bStr, 64); bErr == nil {
+			if aNum < bNum {
+				return -1
+			} else if aNum > bNum {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	// 字符串比较
+	return strings.Compare(aStr, bStr)
+}
+
+// FindField 按名称查找字段，精确匹配失败时忽略大小写匹配
+func FindField(fields []basesql.Field, name string) *basesql.Field {
+	for i := range fields {
+		if fields[i].FieldName == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].FieldName, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// FieldValue 获取记录中字段的值，记录以字段名为键，兼容以字段 ID 为键的记录
+func FieldValue(record basesql.Record, field *basesql.Field) interface{} {
+	if field == nil {
+		return nil
+	}
+	if value, ok := record.Fields[field.FieldName]; ok {
+		return value
+	}
+	if field.FieldID != "" {
+		return record.Fields[field.FieldID]
+	}
+	return nil
+}
+
+// ColumnFields 获取结果列对应的字段，用于按输出列名排序
+func ColumnFields(columns []Column) []basesql.Field {
+	fields := make([]basesql.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = *column.Field
+	}
+	return fields
+}
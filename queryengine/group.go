@@ -0,0 +1,266 @@
+package queryengine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// groupKeySeparator 拼接多个分组字段值时使用的分隔符
+const groupKeySeparator = "\x00"
+
+// groupColumn GROUP BY 查询的一个输出列
+type groupColumn struct {
+	Column
+	function string // 聚合函数，分组字段为空
+	argument string // 聚合函数作用的字段，COUNT(*) 为 *
+}
+
+// Group 按 GROUP BY 对记录分组，每组输出一行
+// 投影中的非聚合列必须出现在 GROUP BY 中；各组按首条记录出现的顺序输出，每行的字段以列名为键。
+// 没有分组字段时全部记录为一组，没有记录时也输出一行（如 COUNT(*) 为 0）
+// 参数:
+//   - selectFields: SELECT 子句中的字段列表，包含分组字段和聚合函数，如 "dept"、"COUNT(*) AS c"
+//   - groupBy: 分组字段
+//   - fields: 表的字段列表
+//   - records: 满足 WHERE 条件的记录
+//
+// 返回:
+//   - []Column: 结果列
+//   - []basesql.Record: 每组一行的结果
+//   - error: 投影或聚合计算错误
+func (e *Engine) Group(selectFields, groupBy []string, fields []basesql.Field, records []basesql.Record) ([]Column, []basesql.Record, error) {
+	keys := make([]*basesql.Field, 0, len(groupBy))
+	for _, name := range groupBy {
+		field := FindField(fields, name)
+		if field == nil {
+			return nil, nil, fmt.Errorf("GROUP BY 字段 '%s' 不存在", name)
+		}
+		keys = append(keys, field)
+	}
+
+	columns, err := groupProjection(selectFields, fields, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var order []string
+	groups := make(map[string][]basesql.Record)
+	if len(keys) == 0 {
+		order = []string{""}
+	}
+	for _, record := range records {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = common.FormatValue(FieldValue(record, key))
+		}
+		groupKey := strings.Join(values, groupKeySeparator)
+		if _, ok := groups[groupKey]; !ok && len(keys) > 0 {
+			order = append(order, groupKey)
+		}
+		groups[groupKey] = append(groups[groupKey], record)
+	}
+
+	rows := make([]basesql.Record, 0, len(order))
+	for _, groupKey := range order {
+		members := groups[groupKey]
+		row := basesql.Record{Fields: make(map[string]interface{}, len(columns))}
+		for _, column := range columns {
+			if column.function == "" {
+				row.Fields[column.Label] = FieldValue(members[0], FindField(fields, column.argument))
+				continue
+			}
+			value, err := e.Aggregate(column.function, column.argument, fields, members)
+			if err != nil {
+				return nil, nil, fmt.Errorf("聚合计算失败: %w", err)
+			}
+			row.Fields[column.Label] = value
+		}
+		rows = append(rows, row)
+	}
+
+	result := make([]Column, len(columns))
+	for i, column := range columns {
+		result[i] = column.Column
+	}
+	return result, rows, nil
+}
+
+// groupProjection 根据 SELECT 字段列表构建 GROUP BY 查询的输出列
+// 输出列的字段名即列名，分组字段沿用原字段的类型，COUNT、SUM、AVG 为数字，MIN、MAX 沿用所作用字段的类型
+func groupProjection(selectFields []string, fields []basesql.Field, keys []*basesql.Field) ([]groupColumn, error) {
+	columns := make([]groupColumn, 0, len(selectFields))
+	for _, expr := range selectFields {
+		if strings.TrimSpace(expr) == "*" {
+			return nil, fmt.Errorf("GROUP BY 查询不支持 SELECT *，请列出分组字段和聚合函数")
+		}
+		name, alias := common.ParseSelectColumn(expr)
+
+		if function, argument, ok := common.ParseAggregate(name); ok {
+			output := basesql.Field{FieldName: alias, Type: basesql.FieldTypeNumber}
+			if argument != "*" {
+				field := FindField(fields, argument)
+				if field == nil {
+					return nil, fmt.Errorf("字段 '%s' 不存在", argument)
+				}
+				argument = field.FieldName
+				if function == "MIN" || function == "MAX" {
+					output = *field
+					output.FieldName = alias
+				}
+			}
+			columns = append(columns, groupColumn{Column: Column{Field: &output, Label: alias}, function: function, argument: argument})
+			continue
+		}
+
+		field := FindField(fields, name)
+		if field == nil {
+			return nil, fmt.Errorf("字段 '%s' 不存在", name)
+		}
+		grouped := false
+		for _, key := range keys {
+			grouped = grouped || key.FieldName == field.FieldName
+		}
+		if !grouped {
+			return nil, fmt.Errorf("字段 '%s' 必须出现在 GROUP BY 中或使用聚合函数", name)
+		}
+		if alias == name {
+			alias = field.FieldName
+		}
+		output := *field
+		output.FieldName = alias
+		columns = append(columns, groupColumn{Column: Column{Field: &output, Label: alias}, argument: field.FieldName})
+	}
+	return columns, nil
+}
+
+// Sort 按 ORDER BY 对记录稳定排序
+// 排序键相同的记录保持原来的顺序，多次查询的结果顺序一致；
+// 两边都是数字时按数值比较，其余按文本比较（case_insensitive 比较规则下不区分大小写），空值排在最前
+// 参数:
+//   - records: 待排序的记录，原地排序
+//   - fields: 可用于排序的字段，普通查询为表的字段，GROUP BY 查询为输出列（见 ColumnFields）
+//   - orderBy: 排序条件，降序的字段名前加 -
+//
+// 返回:
+//   - error: 排序字段不存在时返回错误
+func (e *Engine) Sort(records []basesql.Record, fields []basesql.Field, orderBy []string) error {
+	return e.sortBy(records, orderBy, func(name string) func(basesql.Record) interface{} {
+		field := FindField(fields, name)
+		if field == nil {
+			return nil
+		}
+		return func(record basesql.Record) interface{} { return FieldValue(record, field) }
+	})
+}
+
+// SortProjected 按 ORDER BY 对普通查询的记录稳定排序，排序键可以是投影列的别名（包括 CASE 表达式列）或表中的字段
+// 别名与表字段同名时按投影列排序，与 SQL 中 ORDER BY 优先匹配输出列一致；比较规则同 Sort
+// 参数:
+//   - records: 待排序的记录，原地排序
+//   - fields: 表的字段列表
+//   - columns: 投影的结果列，见 Project
+//   - orderBy: 排序条件，降序的列名前加 -
+//
+// 返回:
+//   - error: 排序键既不是投影列也不是表字段时返回错误
+func (e *Engine) SortProjected(records []basesql.Record, fields []basesql.Field, columns []Column, orderBy []string) error {
+	return e.sortBy(records, orderBy, func(name string) func(basesql.Record) interface{} {
+		if column := findColumn(columns, name); column != nil {
+			if column.expr != nil {
+				return column.Value
+			}
+			field := column.Field
+			return func(record basesql.Record) interface{} { return FieldValue(record, field) }
+		}
+		field := FindField(fields, name)
+		if field == nil {
+			return nil
+		}
+		return func(record basesql.Record) interface{} { return FieldValue(record, field) }
+	})
+}
+
+// findColumn 按列名查找结果列，精确匹配失败时忽略大小写匹配
+func findColumn(columns []Column, label string) *Column {
+	for i := range columns {
+		if columns[i].Label == label {
+			return &columns[i]
+		}
+	}
+	for i := range columns {
+		if strings.EqualFold(columns[i].Label, label) {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+// sortBy 按排序键稳定排序，resolve 返回排序键在记录中的取值函数，排序键不存在时返回 nil
+func (e *Engine) sortBy(records []basesql.Record, orderBy []string, resolve func(name string) func(basesql.Record) interface{}) error {
+	type sortKey struct {
+		value func(basesql.Record) interface{}
+		desc  bool
+	}
+	keys := make([]sortKey, 0, len(orderBy))
+	for _, key := range orderBy {
+		name := strings.TrimPrefix(key, "-")
+		value := resolve(name)
+		if value == nil {
+			return fmt.Errorf("ORDER BY 字段 '%s' 不存在", name)
+		}
+		keys = append(keys, sortKey{value: value, desc: strings.HasPrefix(key, "-")})
+	}
+	if len(keys) == 0 || len(records) < 2 {
+		return nil
+	}
+
+	fold := e.fold()
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, key := range keys {
+			c := compareSortValues(key.value(records[i]), key.value(records[j]), fold)
+			if c == 0 {
+				continue
+			}
+			if key.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return nil
+}
+
+// compareSortValues 比较两个排序键的值，返回 -1、0 或 1
+func compareSortValues(a, b interface{}, fold bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, err := convertToNumber(a); err == nil {
+		if y, err := convertToNumber(b); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	x, y := common.FormatValue(a), common.FormatValue(b)
+	if fold {
+		if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(x, y)
+}
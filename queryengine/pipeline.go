@@ -0,0 +1,247 @@
+package queryengine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ag9920/basesql"
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// Pipeline 按 SELECT 的执行顺序组合查询阶段：WHERE 过滤、GROUP BY 分组聚合或投影、ORDER BY 排序、LIMIT 截断
+// 用于自行读取的记录（如 db.Find 的结果、缓存或导出文件），语义与 CLI 的客户端查询相同：
+//
+//	result, err := queryengine.New(fields).
+//		Where("status", "done").
+//		Select("owner", "COUNT(*) AS c").
+//		GroupBy("owner").
+//		OrderBy("-c").
+//		Limit(10).
+//		Run(records)
+type Pipeline struct {
+	engine       Engine
+	fields       []basesql.Field
+	conditions   []Condition
+	selectFields []string
+	groupBy      []string
+	orderBy      []string
+	limit        int
+}
+
+// Result 查询管道的执行结果
+type Result struct {
+	Columns []Column         // 结果列，顺序与投影一致
+	Records []basesql.Record // 结果行；分组查询的行以列名为键，其余为原记录
+}
+
+// New 创建查询管道
+// 参数:
+//   - fields: 记录所属表的字段列表，用于按名称查找字段、确定字段类型
+//
+// 返回:
+//   - *Pipeline: 没有任何条件的查询管道，执行时返回全部记录的全部字段
+func New(fields []basesql.Field) *Pipeline {
+	return &Pipeline{fields: fields}
+}
+
+// FromSQL 根据单表 SELECT 语句创建查询管道，语句中的表名不使用
+// 支持投影（包括 CASE 表达式列和聚合函数）、GROUP BY、ORDER BY、LIMIT，
+// WHERE 只支持单个条件：=、!=、>、>=、<、<= 比较或 LIKE 模式匹配。
+// CLI 的 ILIKE、WITH、UNION 和 WHERE 中的 CASE 表达式、AND、OR 组合条件不支持，返回错误；
+// 需要这些条件时使用 Where、WhereLike 和 WhereCondition 构造查询管道
+//
+//	p, err := queryengine.FromSQL("SELECT owner, COUNT(*) AS c FROM tasks WHERE status = 'done' GROUP BY owner ORDER BY c DESC", fields)
+//
+// 参数:
+//   - sql: SELECT 语句
+//   - fields: 记录所属表的字段列表
+//
+// 返回:
+//   - *Pipeline: 查询管道
+//   - error: 语句解析失败
+func FromSQL(sql string, fields []basesql.Field) (*Pipeline, error) {
+	if err := checkFromSQL(sql); err != nil {
+		return nil, err
+	}
+	cmd := common.NewSQLCommand(common.CommandSelect)
+	if _, err := common.DefaultSQLParser.ParseSelectSQL(sql, cmd); err != nil {
+		return nil, err
+	}
+	p := New(fields)
+	for key, value := range cmd.Condition {
+		if strings.HasPrefix(key, "_operator_") {
+			continue
+		}
+		operator, _ := cmd.Condition["_operator_"+key].(string)
+		if operator == "IN" {
+			return nil, fmt.Errorf("FromSQL 不支持 IN 条件")
+		}
+		p.conditions = append(p.conditions, Condition{Field: key, Operator: operator, Value: value})
+	}
+	p.selectFields = cmd.Fields
+	p.groupBy = cmd.GroupBy
+	p.orderBy = cmd.OrderBy
+	p.limit = cmd.Limit
+	return p, nil
+}
+
+// Collation 设置字符串比较规则，影响 WHERE 等值比较、LIKE、CASE 表达式和 ORDER BY
+func (p *Pipeline) Collation(collation basesql.Collation) *Pipeline {
+	p.engine.Collation = collation
+	return p
+}
+
+// Stats 设置字段的统计信息，多个 WHERE 条件按估计的选择性从高到低计算，见 Engine.Stats
+func (p *Pipeline) Stats(stats map[string]ColumnStats) *Pipeline {
+	p.engine.Stats = stats
+	return p
+}
+
+// Where 添加等值条件，多个条件同时满足
+func (p *Pipeline) Where(field string, value interface{}) *Pipeline {
+	return p.WhereCondition(Condition{Field: field, Value: value})
+}
+
+// WhereLike 添加 LIKE 条件，pattern 中的 % 匹配任意字符，不含 % 时按包含匹配
+func (p *Pipeline) WhereLike(field, pattern string) *Pipeline {
+	return p.WhereCondition(Condition{Field: field, Operator: "LIKE", Value: pattern})
+}
+
+// WhereCondition 添加条件，支持的操作符见 Engine.Matcher；同一字段可以有多个条件，如范围的上下界
+func (p *Pipeline) WhereCondition(condition Condition) *Pipeline {
+	p.conditions = append(p.conditions, condition)
+	return p
+}
+
+// Select 设置投影，支持 "*"、"field AS alias"、CASE 表达式和聚合函数（如 "COUNT(*) AS c"）
+func (p *Pipeline) Select(columns ...string) *Pipeline {
+	p.selectFields = columns
+	return p
+}
+
+// GroupBy 设置分组字段，投影中的非聚合列必须出现在分组字段中
+func (p *Pipeline) GroupBy(fields ...string) *Pipeline {
+	p.groupBy = fields
+	return p
+}
+
+// OrderBy 设置排序条件，降序的字段名前加 -；分组查询按输出列排序，可使用聚合列的别名；
+// 普通查询可使用表中的字段或投影列的别名（包括 CASE 表达式列）
+func (p *Pipeline) OrderBy(keys ...string) *Pipeline {
+	p.orderBy = keys
+	return p
+}
+
+// Limit 设置最多返回的行数，0 表示不限制
+func (p *Pipeline) Limit(n int) *Pipeline {
+	p.limit = n
+	return p
+}
+
+// Run 对记录执行查询管道，不修改传入的记录列表
+// 投影中有聚合函数但没有分组字段时，全部记录作为一组，返回一行
+// 参数:
+//   - records: 记录列表，以字段名或字段 ID 为键
+//
+// 返回:
+//   - *Result: 查询结果
+//   - error: 字段不存在、投影无效或聚合计算错误
+func (p *Pipeline) Run(records []basesql.Record) (*Result, error) {
+	if p.limit < 0 {
+		return nil, fmt.Errorf("LIMIT 值不能为负数: %d", p.limit)
+	}
+	filtered := p.engine.Filter(records, p.fields, p.conditions)
+
+	var columns []Column
+	var err error
+	grouped := len(p.groupBy) > 0 || hasAggregate(p.selectFields)
+	if grouped {
+		if columns, filtered, err = p.engine.Group(p.selectFields, p.groupBy, p.fields, filtered); err != nil {
+			return nil, err
+		}
+	} else if columns, err = p.engine.Project(p.selectFields, p.fields); err != nil {
+		return nil, err
+	}
+
+	if len(p.orderBy) > 0 {
+		// 排序前复制，避免改变调用方记录的顺序
+		filtered = append([]basesql.Record(nil), filtered...)
+		if grouped {
+			err = p.engine.Sort(filtered, ColumnFields(columns), p.orderBy)
+		} else {
+			err = p.engine.SortProjected(filtered, p.fields, columns, p.orderBy)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.limit > 0 && len(filtered) > p.limit {
+		filtered = filtered[:p.limit]
+	}
+	return &Result{Columns: columns, Records: filtered}, nil
+}
+
+// unsupportedFromSQL FromSQL 不支持的语法，匹配时忽略字符串字面量
+var unsupportedFromSQL = []struct {
+	pattern *regexp.Regexp
+	feature string
+}{
+	{regexp.MustCompile(`(?i)^\s*WITH\b`), "WITH 公用表表达式"},
+	{regexp.MustCompile(`(?i)\bUNION\b`), "UNION"},
+	{regexp.MustCompile(`(?i)\bILIKE\b`), "ILIKE"},
+	{regexp.MustCompile(`(?i)\bWHERE\b.*\bCASE\b`), "WHERE 中的 CASE 表达式"},
+	{regexp.MustCompile(`(?i)\bWHERE\b.*\b(AND|OR)\b`), "WHERE 中的 AND、OR 组合条件"},
+}
+
+// sqlStringLiteral SQL 中的单引号字符串，两个单引号表示一个单引号
+var sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// checkFromSQL 检查语句是否使用了 FromSQL 不支持的语法，避免被解析成错误的条件
+func checkFromSQL(sql string) error {
+	stripped := sqlStringLiteral.ReplaceAllString(sql, "''")
+	for _, unsupported := range unsupportedFromSQL {
+		if unsupported.pattern.MatchString(stripped) {
+			return fmt.Errorf("FromSQL 不支持 %s", unsupported.feature)
+		}
+	}
+	return nil
+}
+
+// hasAggregate 判断投影中是否有聚合函数列
+func hasAggregate(selectFields []string) bool {
+	for _, expr := range selectFields {
+		name, _ := common.ParseSelectColumn(expr)
+		if _, _, ok := common.ParseAggregate(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Rows 将结果转换为以列名为键的行，字段值转换为对应的 Go 类型
+func (r *Result) Rows() []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(r.Records))
+	for _, record := range r.Records {
+		row := make(map[string]interface{}, len(r.Columns))
+		for _, column := range r.Columns {
+			var value interface{}
+			if raw := column.Value(record); raw != nil {
+				value = GoValue(column.Field, raw)
+			}
+			row[column.Label] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// GoValue 将字段值转换为结构化输出中的 Go 类型，超链接字段保留文本和链接地址
+func GoValue(field *basesql.Field, raw interface{}) interface{} {
+	if field.Type == basesql.FieldTypeURL {
+		if link, ok := basesql.ParseLink(raw); ok {
+			return link
+		}
+	}
+	return field.ConvertToGoValue(raw)
+}
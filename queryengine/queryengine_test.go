@@ -0,0 +1,339 @@
+package queryengine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ag9920/basesql"
+)
+
+var testFields = []basesql.Field{
+	{FieldID: "fld1", FieldName: "name", Type: basesql.FieldTypeText},
+	{FieldID: "fld2", FieldName: "owner", Type: basesql.FieldTypeText},
+	{FieldID: "fld3", FieldName: "status", Type: basesql.FieldTypeText},
+	{FieldID: "fld4", FieldName: "points", Type: basesql.FieldTypeNumber},
+}
+
+var testRecords = []basesql.Record{
+	{RecordID: "rec1", Fields: map[string]interface{}{"name": "login", "owner": "alice", "status": "done", "points": 3.0}},
+	{RecordID: "rec2", Fields: map[string]interface{}{"name": "logout", "owner": "bob", "status": "done", "points": 1.0}},
+	{RecordID: "rec3", Fields: map[string]interface{}{"name": "signup", "owner": "alice", "status": "Done", "points": 5.0}},
+	{RecordID: "rec4", Fields: map[string]interface{}{"name": "search", "owner": "bob", "status": "todo", "points": 8.0}},
+	{RecordID: "rec5", Fields: map[string]interface{}{"name": "export", "owner": "carol", "status": "done", "points": 2.0}},
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline *Pipeline
+		columns  []string
+		rows     []map[string]interface{}
+	}{
+		{
+			name:     "filter, project and sort",
+			pipeline: New(testFields).Where("status", "done").Select("name", "points AS p").OrderBy("-points").Limit(2),
+			columns:  []string{"name", "p"},
+			rows: []map[string]interface{}{
+				{"name": "login", "p": 3.0},
+				{"name": "export", "p": 2.0},
+			},
+		},
+		{
+			name:     "case insensitive collation",
+			pipeline: New(testFields).Collation(basesql.CollationCaseInsensitive).Where("status", "DONE").WhereLike("name", "%up").Select("name"),
+			columns:  []string{"name"},
+			rows:     []map[string]interface{}{{"name": "signup"}},
+		},
+		{
+			name:     "group by with aggregate alias in order by",
+			pipeline: New(testFields).Select("owner", "COUNT(*) AS c", "SUM(points) AS total").GroupBy("owner").OrderBy("-total"),
+			columns:  []string{"owner", "c", "total"},
+			rows: []map[string]interface{}{
+				{"owner": "bob", "c": 2.0, "total": 9.0},
+				{"owner": "alice", "c": 2.0, "total": 8.0},
+				{"owner": "carol", "c": 1.0, "total": 2.0},
+			},
+		},
+		{
+			name:     "aggregate without group by",
+			pipeline: New(testFields).Where("owner", "nobody").Select("COUNT(*) AS c"),
+			columns:  []string{"c"},
+			rows:     []map[string]interface{}{{"c": 0.0}},
+		},
+		{
+			name:     "case expression",
+			pipeline: New(testFields).Select("name", "CASE WHEN points >= 5 THEN 'large' ELSE 'small' END AS size").Limit(3),
+			columns:  []string{"name", "size"},
+			rows: []map[string]interface{}{
+				{"name": "login", "size": "small"},
+				{"name": "logout", "size": "small"},
+				{"name": "signup", "size": "large"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.pipeline.Run(testRecords)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var columns []string
+			for _, column := range result.Columns {
+				columns = append(columns, column.Label)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", columns, tt.columns)
+			}
+			if rows := result.Rows(); !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %v, want %v", rows, tt.rows)
+			}
+		})
+	}
+
+	if testRecords[0].RecordID != "rec1" || testRecords[4].RecordID != "rec5" {
+		t.Errorf("Run() must not reorder the caller's records")
+	}
+}
+
+func TestMatcherSelectivity(t *testing.T) {
+	engine := &Engine{Stats: map[string]ColumnStats{
+		"status": {Records: 100, Filled: 100, Distinct: 3, Top: map[string]int{"done": 80, "todo": 15}},
+		"owner":  {Records: 100, Filled: 90, Distinct: 30},
+		"name":   {Records: 100, Filled: 100, Distinct: 100},
+	}}
+	tests := []struct {
+		field string
+		value interface{}
+		like  bool
+		want  float64
+	}{
+		{"status", "done", false, 0.8},
+		{"status", "todo", false, 0.15},
+		{"owner", "alice", false, 0.03},
+		{"owner", nil, false, 0.1},
+		{"name", "%log%", true, 0.5},
+		{"points", 3.0, false, defaultEqualSelectivity},
+		{"points", "%3", true, defaultLikeSelectivity},
+	}
+	for _, tt := range tests {
+		if got := engine.selectivity(tt.field, tt.value, tt.like); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("selectivity(%s, %v) = %v, want %v", tt.field, tt.value, got, tt.want)
+		}
+	}
+
+	// 统计信息只影响计算顺序，不影响结果
+	conditions := []Condition{{Field: "status", Value: "done"}, {Field: "owner", Value: "alice"}, {Field: "name", Operator: "LIKE", Value: "%log%"}}
+	withStats := engine.Filter(testRecords, testFields, conditions)
+	withoutStats := (&Engine{}).Filter(testRecords, testFields, conditions)
+	if len(withStats) != 1 || withStats[0].RecordID != "rec1" || !reflect.DeepEqual(withStats, withoutStats) {
+		t.Errorf("Filter() with stats = %v, without stats = %v, want only rec1", withStats, withoutStats)
+	}
+}
+
+func TestMatcherOperators(t *testing.T) {
+	tests := []struct {
+		condition Condition
+		want      []string
+	}{
+		{Condition{Field: "status", Value: "done"}, []string{"rec1", "rec2", "rec5"}},
+		{Condition{Field: "status", Operator: "!=", Value: "done"}, []string{"rec3", "rec4"}},
+		{Condition{Field: "status", Operator: "<>", Value: "done"}, []string{"rec3", "rec4"}},
+		{Condition{Field: "points", Operator: ">", Value: 3.0}, []string{"rec3", "rec4"}},
+		{Condition{Field: "points", Operator: ">=", Value: 3.0}, []string{"rec1", "rec3", "rec4"}},
+		{Condition{Field: "points", Operator: "<", Value: "3"}, []string{"rec2", "rec5"}},
+		{Condition{Field: "points", Operator: "<=", Value: 1.0}, []string{"rec2"}},
+		{Condition{Field: "name", Operator: "LIKE", Value: "log%"}, []string{"rec1", "rec2"}},
+		{Condition{Field: "name", Operator: "ILIKE", Value: "%UP"}, []string{"rec3"}},
+		{Condition{Field: "name", Operator: "IN", Value: "(login)"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, record := range (&Engine{}).Filter(testRecords, testFields, []Condition{tt.condition}) {
+			got = append(got, record.RecordID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%s %s %v) = %v, want %v", tt.condition.Field, tt.condition.Operator, tt.condition.Value, got, tt.want)
+		}
+	}
+
+	// 同一字段的多个条件同时满足
+	result, err := New(testFields).
+		WhereCondition(Condition{Field: "points", Operator: ">", Value: 1.0}).
+		WhereCondition(Condition{Field: "points", Operator: "<", Value: 5.0}).
+		Select("name").Run(testRecords)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []map[string]interface{}{{"name": "login"}, {"name": "export"}}
+	if rows := result.Rows(); !reflect.DeepEqual(rows, want) {
+		t.Errorf("range rows = %v, want %v", rows, want)
+	}
+}
+
+func TestCaseExpr(t *testing.T) {
+	// 每条测试记录按 testRecords 的顺序给出 v 列的期望值
+	tests := []struct {
+		name      string
+		expr      string
+		collation basesql.Collation
+		want      []interface{}
+	}{
+		{"searched", "CASE WHEN points >= 5 THEN 'large' WHEN points >= 2 THEN 'medium' ELSE 'small' END", "", []interface{}{"medium", "small", "large", "large", "medium"}},
+		{"simple", "CASE owner WHEN 'alice' THEN 'A' WHEN 'bob' THEN 'B' ELSE 'other' END", "", []interface{}{"A", "B", "A", "B", "other"}},
+		{"no else is null", "CASE WHEN status = 'todo' THEN 'open' END", "", []interface{}{nil, nil, nil, "open", nil}},
+		{"else null with numeric results", "CASE WHEN points > 4 THEN 1 ELSE NULL END", "", []interface{}{nil, nil, 1.0, 1.0, nil}},
+		{"and, or, not, in and is null", "CASE WHEN owner IN ('alice', 'carol') AND NOT status = 'todo' THEN 'x' WHEN (points < 2 OR name IS NULL) THEN 'y' ELSE 'z' END", "", []interface{}{"x", "y", "x", "z", "x"}},
+		{"numeric string literal", "CASE WHEN points > '4' THEN 'hi' ELSE 'lo' END", "", []interface{}{"lo", "lo", "hi", "hi", "lo"}},
+		{"nested", "CASE WHEN status = 'done' THEN CASE WHEN points >= 3 THEN 'big' ELSE 'small' END ELSE 'other' END", "", []interface{}{"big", "small", "other", "other", "small"}},
+		{"nested case insensitive", "CASE WHEN status = 'done' THEN CASE WHEN points >= 3 THEN 'big' ELSE 'small' END ELSE 'other' END", basesql.CollationCaseInsensitive, []interface{}{"big", "small", "big", "other", "small"}},
+		{"quoted literal", "CASE WHEN owner = 'bob' THEN 'it''s bob' END", "", []interface{}{nil, "it's bob", nil, "it's bob", nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(testFields).Collation(tt.collation).Select(tt.expr + " AS v").Run(testRecords)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var got []interface{}
+			for _, row := range result.Rows() {
+				got = append(got, row["v"])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("v = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 结果全部是数字字面量时结果列为数字，否则为文本
+	for expr, want := range map[string]basesql.FieldType{
+		"CASE WHEN points > 4 THEN 1 ELSE 0 END":                                  basesql.FieldTypeNumber,
+		"CASE WHEN points > 4 THEN 1 ELSE 'none' END":                             basesql.FieldTypeText,
+		"CASE WHEN points > 4 THEN CASE owner WHEN 'bob' THEN 2 END ELSE 0 END":   basesql.FieldTypeNumber,
+		"CASE WHEN points > 4 THEN CASE owner WHEN 'bob' THEN 'b' END ELSE 0 END": basesql.FieldTypeText,
+		"CASE WHEN points > 4 THEN owner END":                                     basesql.FieldTypeText,
+	} {
+		columns, err := (&Engine{}).Project([]string{expr + " AS v"}, testFields)
+		if err != nil {
+			t.Fatalf("Project(%q) error = %v", expr, err)
+		}
+		if columns[0].Field.Type != want {
+			t.Errorf("Project(%q) type = %v, want %v", expr, columns[0].Field.Type, want)
+		}
+	}
+
+	for _, expr := range []string{
+		"CASE WHEN points > 'abc' THEN 1 END",
+		"CASE points WHEN 'many' THEN 1 END",
+		"CASE WHEN points IN (1, 'x') THEN 1 END",
+		"CASE WHEN missing = 1 THEN 1 END",
+		"CASE WHEN points > 1 THEN 1",
+		"CASE ELSE 1 END",
+		"CASE WHEN points > 1 THEN CASE WHEN points > 2 THEN 1 END",
+		"CASE WHEN NOT points = 1 OR THEN 1 END",
+		"CASE WHEN owner = 'bob THEN 1 END",
+	} {
+		if _, err := (&Engine{}).Project([]string{expr + " AS v"}, testFields); err == nil {
+			t.Errorf("Project(%q) error = nil, want error", expr)
+		}
+	}
+
+	// ORDER BY 可以使用 CASE 表达式列的别名
+	band := "CASE WHEN points >= 5 THEN 'large' WHEN points >= 2 THEN 'medium' ELSE 'small' END AS band"
+	result, err := New(testFields).Select("name", band).OrderBy("band", "-name").Run(testRecords)
+	if err != nil {
+		t.Fatalf("Run() with ORDER BY alias error = %v", err)
+	}
+	var names []interface{}
+	for _, row := range result.Rows() {
+		names = append(names, row["name"])
+	}
+	if want := []interface{}{"signup", "search", "login", "export", "logout"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ORDER BY band, name DESC = %v, want %v", names, want)
+	}
+
+	p, err := FromSQL("SELECT name, "+band+" FROM tasks ORDER BY band DESC, name LIMIT 3", testFields)
+	if err != nil {
+		t.Fatalf("FromSQL() error = %v", err)
+	}
+	if result, err = p.Run(testRecords); err != nil {
+		t.Fatalf("FromSQL Run() error = %v", err)
+	}
+	names = nil
+	for _, row := range result.Rows() {
+		names = append(names, row["name"])
+	}
+	if want := []interface{}{"logout", "export", "login"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("FromSQL ORDER BY band DESC, name = %v, want %v", names, want)
+	}
+
+	// 别名与字段同名时按投影列排序
+	result, err = New(testFields).Select("name", "CASE WHEN points >= 5 THEN 0 ELSE 1 END AS points").OrderBy("points", "name").Run(testRecords)
+	if err != nil {
+		t.Fatalf("Run() with alias shadowing a field error = %v", err)
+	}
+	names = nil
+	for _, row := range result.Rows() {
+		names = append(names, row["name"])
+	}
+	if want := []interface{}{"search", "signup", "export", "login", "logout"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ORDER BY shadowing alias = %v, want %v", names, want)
+	}
+
+	if _, err := New(testFields).Select("name").OrderBy("missing").Run(testRecords); err == nil {
+		t.Error("ORDER BY unknown column error = nil, want error")
+	}
+}
+
+func TestFromSQL(t *testing.T) {
+	p, err := FromSQL("SELECT owner, MAX(points) AS top FROM tasks WHERE status = 'done' GROUP BY owner ORDER BY top DESC LIMIT 1", testFields)
+	if err != nil {
+		t.Fatalf("FromSQL() error = %v", err)
+	}
+	result, err := p.Run(testRecords)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []map[string]interface{}{{"owner": "alice", "top": 3.0}}
+	if rows := result.Rows(); !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	p, err = FromSQL("SELECT name FROM tasks WHERE points > 3 ORDER BY points", testFields)
+	if err != nil {
+		t.Fatalf("FromSQL() error = %v", err)
+	}
+	if result, err = p.Run(testRecords); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want = []map[string]interface{}{{"name": "signup"}, {"name": "search"}}
+	if rows := result.Rows(); !reflect.DeepEqual(rows, want) {
+		t.Errorf("WHERE points > 3 rows = %v, want %v", rows, want)
+	}
+
+	// 不支持的语法返回错误，不被解析成错误的条件
+	for _, sql := range []string{
+		"SELECT name FROM tasks WHERE name ILIKE '%LOG%'",
+		"WITH d AS (SELECT * FROM tasks) SELECT name FROM d",
+		"SELECT name FROM tasks UNION SELECT owner FROM tasks",
+		"SELECT name FROM tasks WHERE CASE WHEN points > 2 THEN 1 ELSE 0 END = 1",
+		"SELECT name FROM tasks WHERE status = 'done' AND owner = 'alice'",
+		"SELECT name FROM tasks WHERE status = 'done' OR owner = 'alice'",
+		"SELECT name FROM tasks WHERE owner IN ('alice', 'bob')",
+	} {
+		if _, err := FromSQL(sql, testFields); err == nil {
+			t.Errorf("FromSQL(%q) expected an unsupported syntax error", sql)
+		}
+	}
+	// 字符串字面量中的关键字不影响检查
+	if _, err := FromSQL("SELECT name FROM tasks WHERE name = 'union and case'", testFields); err != nil {
+		t.Errorf("FromSQL() with keywords in a literal error = %v", err)
+	}
+
+	if _, err := New(testFields).Select("name").GroupBy("owner").Run(testRecords); err == nil {
+		t.Errorf("expected error for non-aggregated column outside GROUP BY")
+	}
+	if _, err := New(testFields).OrderBy("missing").Run(testRecords); err == nil {
+		t.Errorf("expected error for unknown ORDER BY field")
+	}
+}
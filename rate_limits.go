@@ -0,0 +1,255 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// 请求路径中的表 ID
+var tableIDPathPattern = regexp.MustCompile(`/tables/([^/?]+)`)
+
+// RateLimit 单个令牌桶的限流设置
+type RateLimit struct {
+	QPS   float64 `json:"qps"`   // 每秒请求数，0 表示不限制
+	Burst int     `json:"burst"` // 突发请求数，默认与 QPS 相同
+}
+
+// TableRateLimits 单个数据表的读写限流设置
+type TableRateLimits struct {
+	Read  RateLimit `json:"read"`  // 读请求限流
+	Write RateLimit `json:"write"` // 写请求限流
+}
+
+// RateLimits 分读写、分表的限流配置
+// 飞书按多维表格分别限制读写频率，Read 和 Write 对整个多维表格生效；
+// Tables 按表名或表 ID 额外限流，请求需要同时通过表级和多维表格级的令牌桶；
+// 表名在第一次请求其他表时通过表列表解析为表 ID
+type RateLimits struct {
+	Read   RateLimit                   `json:"read"`   // 读请求限流，包括记录查询、搜索和表结构读取
+	Write  RateLimit                   `json:"write"`  // 写请求限流，包括记录增删改和表结构修改
+	Tables map[string]*TableRateLimits `json:"tables"` // 按表名或表 ID 设置的限流
+}
+
+// validate 检查限流配置
+func (rl *RateLimits) validate() error {
+	check := func(scope string, limit RateLimit) error {
+		if limit.QPS < 0 || limit.Burst < 0 {
+			return fmt.Errorf("%s 的限流设置不能为负数", scope)
+		}
+		return nil
+	}
+	if err := check("read", rl.Read); err != nil {
+		return err
+	}
+	if err := check("write", rl.Write); err != nil {
+		return err
+	}
+	for tableID, table := range rl.Tables {
+		if table == nil {
+			continue
+		}
+		if err := check("表 "+tableID+" read", table.Read); err != nil {
+			return err
+		}
+		if err := check("表 "+tableID+" write", table.Write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRateLimitBucket 根据限流设置创建令牌桶，未限流时返回 nil
+func newRateLimitBucket(limit RateLimit) *common.TokenBucket {
+	if limit.QPS <= 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = int(limit.QPS)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return common.NewTokenBucket(&common.RateLimiterConfig{
+		Rate:   limit.QPS,
+		Burst:  burst,
+		Window: common.DefaultRateLimiterConfig().Window,
+	})
+}
+
+// rateLimitBuckets 一组读写令牌桶
+type rateLimitBuckets struct {
+	read  *common.TokenBucket
+	write *common.TokenBucket
+}
+
+// bucket 获取请求对应的令牌桶
+func (b *rateLimitBuckets) bucket(write bool) *common.TokenBucket {
+	if write {
+		return b.write
+	}
+	return b.read
+}
+
+// tableResolver 将分表限流配置中的表名或表 ID 解析为表 ID
+// 返回键到表 ID 的映射，无法解析的键不出现在结果中；获取表列表失败时返回错误
+type tableResolver func(ctx context.Context, keys []string) (map[string]string, error)
+
+// partitionedLimiter 按读写和数据表划分的限流器
+type partitionedLimiter struct {
+	base    rateLimitBuckets
+	tables  map[string]*rateLimitBuckets // 配置中的表名或表 ID -> 令牌桶
+	resolve tableResolver                // 为空时只按表 ID 匹配
+
+	mu         sync.Mutex
+	byID       map[string]*rateLimitBuckets // 表 ID -> 令牌桶，包括按表名解析到的表 ID
+	unresolved map[string]bool              // 尚未解析的配置键
+}
+
+// newPartitionedLimiter 根据限流配置创建限流器
+// 参数:
+//   - limits: 限流配置
+//   - resolve: 表名解析函数，为空时配置中的键只按表 ID 匹配
+func newPartitionedLimiter(limits *RateLimits, resolve tableResolver) *partitionedLimiter {
+	pl := &partitionedLimiter{
+		base: rateLimitBuckets{
+			read:  newRateLimitBucket(limits.Read),
+			write: newRateLimitBucket(limits.Write),
+		},
+		tables:     make(map[string]*rateLimitBuckets),
+		resolve:    resolve,
+		byID:       make(map[string]*rateLimitBuckets),
+		unresolved: make(map[string]bool),
+	}
+	for key, table := range limits.Tables {
+		if table == nil {
+			continue
+		}
+		buckets := &rateLimitBuckets{
+			read:  newRateLimitBucket(table.Read),
+			write: newRateLimitBucket(table.Write),
+		}
+		pl.tables[key] = buckets
+		pl.byID[key] = buckets
+		if resolve != nil {
+			pl.unresolved[key] = true
+		}
+	}
+	return pl
+}
+
+// tableBuckets 获取表 ID 对应的令牌桶
+// 表 ID 不在已知的映射中时，把尚未解析的配置键一次性解析为表 ID；解析时不持有锁，
+// 获取表列表的请求本身也要经过限流器
+func (pl *partitionedLimiter) tableBuckets(ctx context.Context, tableID string) *rateLimitBuckets {
+	pl.mu.Lock()
+	buckets, ok := pl.byID[tableID]
+	keys := make([]string, 0, len(pl.unresolved))
+	for key := range pl.unresolved {
+		keys = append(keys, key)
+	}
+	pl.mu.Unlock()
+	if ok || len(keys) == 0 {
+		return buckets
+	}
+
+	ids, err := pl.resolve(ctx, keys)
+	if err != nil {
+		return nil // 下次请求时重新解析
+	}
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	for _, key := range keys {
+		// 表不存在或同名的表不止一张时不再重复解析
+		delete(pl.unresolved, key)
+		if id, ok := ids[key]; ok {
+			pl.byID[id] = pl.tables[key]
+		}
+	}
+	return pl.byID[tableID]
+}
+
+// allow 检查请求是否通过限流，先检查表级令牌桶，再检查多维表格级令牌桶
+// 多维表格级令牌桶拒绝时归还表级令牌桶的令牌，被拒绝的请求不消耗任何令牌
+// 参数:
+//   - ctx: 上下文，用于解析表名
+//   - req: API 请求
+//
+// 返回:
+//   - error: 被限流时返回 rate_limit 错误
+func (pl *partitionedLimiter) allow(ctx context.Context, req *APIRequest) error {
+	write := isWriteRequest(req)
+	kind := "读"
+	if write {
+		kind = "写"
+	}
+
+	var taken *common.TokenBucket
+	if m := tableIDPathPattern.FindStringSubmatch(req.Path); m != nil {
+		if table := pl.tableBuckets(ctx, m[1]); table != nil {
+			if bucket := table.bucket(write); bucket != nil {
+				if !bucket.Allow() {
+					return common.NewAPIError(429, "rate_limit", fmt.Sprintf("表 %s 的%s请求频率过高，请稍后重试", m[1], kind), "")
+				}
+				taken = bucket
+			}
+		}
+	}
+	if bucket := pl.base.bucket(write); bucket != nil && !bucket.Allow() {
+		if taken != nil {
+			taken.Refund()
+		}
+		return common.NewAPIError(429, "rate_limit", fmt.Sprintf("%s请求频率过高，请稍后重试", kind), "")
+	}
+	return nil
+}
+
+// stats 获取各令牌桶的统计信息，键为 read、write、<表名或表 ID>.read、<表名或表 ID>.write
+func (pl *partitionedLimiter) stats() map[string]*common.RateLimiterStats {
+	stats := make(map[string]*common.RateLimiterStats)
+	add := func(prefix string, b *rateLimitBuckets) {
+		if b.read != nil {
+			stats[prefix+"read"] = b.read.GetStats()
+		}
+		if b.write != nil {
+			stats[prefix+"write"] = b.write.GetStats()
+		}
+	}
+	add("", &pl.base)
+	for key, table := range pl.tables {
+		add(key+".", table)
+	}
+	return stats
+}
+
+// reset 重置全部令牌桶
+func (pl *partitionedLimiter) reset() {
+	reset := func(b *rateLimitBuckets) {
+		if b.read != nil {
+			b.read.Reset()
+		}
+		if b.write != nil {
+			b.write.Reset()
+		}
+	}
+	reset(&pl.base)
+	for _, table := range pl.tables {
+		reset(table)
+	}
+}
+
+// isWriteRequest 判断请求是否计入写限流：记录增删改，以及建表、删表、修改字段
+func isWriteRequest(req *APIRequest) bool {
+	switch classifyRetryOperation(req) {
+	case RetryOpWrite:
+		return true
+	case RetryOpMetadata:
+		return req.Method != "GET"
+	default:
+		return false
+	}
+}
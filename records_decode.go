@@ -0,0 +1,442 @@
+package basesql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// recordChunkSize 解析记录时每次分配的记录数
+const recordChunkSize = 64
+
+// decodeRecordsPage 解析记录列表接口的响应
+// 默认使用 unmarshalRecordsPage；以 basesql_stream 构建标签编译时使用 streamRecordsPage，
+// 读取大页面的记录时分配更少的内存。两者对字段值中数字的处理相同，见 preciseNumber
+// 参数:
+//   - body: 响应体
+//   - resp: 解析结果
+//
+// 返回:
+//   - error: 响应体不是合法的 JSON
+func decodeRecordsPage(body []byte, resp *ListRecordsAPIResponse) error {
+	if streamRecordsDecoding {
+		return streamRecordsPage(body, resp)
+	}
+	return unmarshalRecordsPage(body, resp)
+}
+
+// unmarshalRecordsPage 使用 encoding/json 解析记录列表接口的响应，字段值中的数字按 preciseNumber 处理
+func unmarshalRecordsPage(body []byte, resp *ListRecordsAPIResponse) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(resp); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("响应体末尾有多余的内容")
+	}
+	if resp.Data != nil {
+		normalizeRecordNumbers(resp.Data.Items)
+	}
+	return nil
+}
+
+// streamRecordsPage 直接扫描响应体解析记录列表接口的响应
+// 结果与 unmarshalRecordsPage 相同，区别在于：
+//   - 同一页中重复出现的字段名（包括富文本、人员等字段值中对象的键）只保留一份字符串，各条记录共用
+//   - 记录分块分配，不再为每条记录单独分配内存
+//   - 字段值映射按上一条记录的字段数预分配容量，避免逐个字段扩容
+func streamRecordsPage(body []byte, resp *ListRecordsAPIResponse) error {
+	d := &pageDecoder{data: body, strings: make(map[string]string)}
+	_, err := d.object(func(key string) error {
+		switch key {
+		case "code":
+			return d.unmarshal(&resp.Code)
+		case "msg":
+			return d.stringValue(&resp.Msg)
+		case "data":
+			return d.recordsData(resp)
+		}
+		return d.skip()
+	})
+	if err != nil {
+		return err
+	}
+	if d.skipSpace(); d.pos < len(d.data) {
+		return d.errorf("响应体末尾有多余的内容")
+	}
+	return nil
+}
+
+// pageDecoder 记录列表响应的解析器
+type pageDecoder struct {
+	data    []byte
+	pos     int
+	strings map[string]string // 对象键的共用字符串
+}
+
+// recordsData 解析响应的 data 部分，data 为 null 时 resp.Data 为 nil
+func (d *pageDecoder) recordsData(resp *ListRecordsAPIResponse) error {
+	data := &ListRecordsResponse{}
+	isNull, err := d.object(func(key string) error {
+		switch key {
+		case "has_more":
+			return d.unmarshal(&data.HasMore)
+		case "page_token":
+			return d.stringValue(&data.PageToken)
+		case "total":
+			return d.unmarshal(&data.Total)
+		case "items":
+			return d.recordItems(data)
+		}
+		return d.skip()
+	})
+	if err != nil {
+		return err
+	}
+	if isNull {
+		resp.Data = nil
+		return nil
+	}
+	resp.Data = data
+	return nil
+}
+
+// recordItems 解析记录数组，数组为 null 时 Items 为 nil
+// 记录分块分配，每块 recordChunkSize 条，已解析的记录不会因扩容而复制
+func (d *pageDecoder) recordItems(data *ListRecordsResponse) error {
+	var chunk []Record
+	items := []*Record{}
+	sizeHint := 0
+	isNull, err := d.array(func() error {
+		if len(chunk) == cap(chunk) {
+			chunk = make([]Record, 0, recordChunkSize)
+		}
+		chunk = append(chunk, Record{})
+		record := &chunk[len(chunk)-1]
+		isNull, err := d.record(record, sizeHint)
+		if isNull {
+			items = append(items, nil)
+		} else {
+			items = append(items, record)
+		}
+		sizeHint = len(record.Fields)
+		return err
+	})
+	if err != nil || isNull {
+		data.Items = nil
+		return err
+	}
+	data.Items = items
+	return nil
+}
+
+// record 解析一条记录，返回值表示数组元素是否为 null
+func (d *pageDecoder) record(record *Record, sizeHint int) (bool, error) {
+	return d.object(func(key string) error {
+		switch key {
+		case "record_id":
+			return d.stringValue(&record.RecordID)
+		case "created_time":
+			return d.unmarshal(&record.CreatedTime)
+		case "last_modified_time":
+			return d.unmarshal(&record.LastModified)
+		case "created_by":
+			return d.unmarshal(&record.CreatedBy)
+		case "last_modified_by":
+			return d.unmarshal(&record.LastModifiedBy)
+		case "fields":
+			fields := make(map[string]interface{}, sizeHint)
+			isNull, err := d.object(func(name string) error {
+				value, err := d.value()
+				fields[name] = value
+				return err
+			})
+			if !isNull {
+				record.Fields = fields
+			}
+			return err
+		}
+		return d.skip()
+	})
+}
+
+// object 解析一个 JSON 对象，对每个键调用 fn，fn 负责读取键对应的值；返回值表示是否为 null
+func (d *pageDecoder) object(fn func(key string) error) (bool, error) {
+	if d.skipSpace(); d.literal("null") {
+		return true, nil
+	}
+	if !d.consume('{') {
+		return false, d.errorf("应为对象")
+	}
+	if d.skipSpace(); d.consume('}') {
+		return false, nil
+	}
+	for {
+		if d.skipSpace(); d.pos >= len(d.data) || d.data[d.pos] != '"' {
+			return false, d.errorf("应为字段名")
+		}
+		key, err := d.string(true)
+		if err != nil {
+			return false, err
+		}
+		if d.skipSpace(); !d.consume(':') {
+			return false, d.errorf("字段名后应为冒号")
+		}
+		if err := fn(key); err != nil {
+			return false, err
+		}
+		d.skipSpace()
+		if d.consume(',') {
+			continue
+		}
+		if d.consume('}') {
+			return false, nil
+		}
+		return false, d.errorf("对象中应为逗号或右花括号")
+	}
+}
+
+// array 解析一个 JSON 数组，对每个元素调用 fn，fn 负责读取元素；返回值表示是否为 null
+func (d *pageDecoder) array(fn func() error) (bool, error) {
+	if d.skipSpace(); d.literal("null") {
+		return true, nil
+	}
+	if !d.consume('[') {
+		return false, d.errorf("应为数组")
+	}
+	if d.skipSpace(); d.consume(']') {
+		return false, nil
+	}
+	for {
+		if err := fn(); err != nil {
+			return false, err
+		}
+		d.skipSpace()
+		if d.consume(',') {
+			continue
+		}
+		if d.consume(']') {
+			return false, nil
+		}
+		return false, d.errorf("数组中应为逗号或右方括号")
+	}
+}
+
+// value 解析任意 JSON 值，类型与 json.Unmarshal 解析到 interface{} 时相同，数字按 preciseNumber 处理
+func (d *pageDecoder) value() (interface{}, error) {
+	d.skipSpace()
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("响应体不完整")
+	}
+	switch c := d.data[d.pos]; {
+	case c == '{':
+		object := make(map[string]interface{})
+		_, err := d.object(func(key string) error {
+			value, err := d.value()
+			object[key] = value
+			return err
+		})
+		return object, err
+	case c == '[':
+		array := []interface{}{}
+		_, err := d.array(func() error {
+			value, err := d.value()
+			array = append(array, value)
+			return err
+		})
+		return array, err
+	case c == '"':
+		return d.string(false)
+	case c == '-' || isDigit(c):
+		return d.number()
+	case d.literal("true"):
+		return true, nil
+	case d.literal("false"):
+		return false, nil
+	case d.literal("null"):
+		return nil, nil
+	}
+	return nil, d.errorf("无法识别的值")
+}
+
+// string 解析一个 JSON 字符串，intern 为 true 时使用共用字符串
+// 含转义字符或非法 UTF-8 编码的文本交给 json.Unmarshal 处理，结果保持一致
+func (d *pageDecoder) string(intern bool) (string, error) {
+	raw, plain, err := d.scanString()
+	if err != nil {
+		return "", err
+	}
+	if !plain {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	text := raw[1 : len(raw)-1]
+	if !intern {
+		return string(text), nil
+	}
+	if s, ok := d.strings[string(text)]; ok {
+		return s, nil
+	}
+	s := string(text)
+	d.strings[s] = s
+	return s, nil
+}
+
+// scanString 扫描一个 JSON 字符串，返回包括引号在内的原文，plain 表示不含转义字符且是合法的 UTF-8 编码
+func (d *pageDecoder) scanString() (raw []byte, plain bool, err error) {
+	start := d.pos
+	d.pos++
+	plain = true
+	for d.pos < len(d.data) {
+		c := d.data[d.pos]
+		switch {
+		case c == '"':
+			d.pos++
+			return d.data[start:d.pos], plain, nil
+		case c == '\\':
+			plain = false
+			d.pos += 2
+		case c < 0x20:
+			return nil, false, d.errorf("字符串中有控制字符")
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRune(d.data[d.pos:])
+			if r == utf8.RuneError && size == 1 {
+				plain = false
+			}
+			d.pos += size
+		default:
+			d.pos++
+		}
+	}
+	return nil, false, d.errorf("字符串不完整")
+}
+
+// number 解析一个 JSON 数字，结果为 float64
+func (d *pageDecoder) number() (interface{}, error) {
+	start := d.pos
+	for d.pos < len(d.data) {
+		c := d.data[d.pos]
+		if !isDigit(c) && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			break
+		}
+		d.pos++
+	}
+	raw := d.data[start:d.pos]
+	digits := raw
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	// strconv 比 JSON 宽松，先排除 JSON 不允许的前导零、正号以及小数点前后缺少数字的写法
+	if len(digits) == 0 || !isDigit(digits[0]) || !isDigit(digits[len(digits)-1]) || (digits[0] == '0' && len(digits) > 1 && isDigit(digits[1])) {
+		return 0, d.errorf("数字格式错误")
+	}
+	for i, c := range digits {
+		if c == '.' && !isDigit(digits[i+1]) {
+			return 0, d.errorf("数字格式错误")
+		}
+	}
+	value, err := preciseNumber(string(raw))
+	if err != nil {
+		return 0, d.errorf("数字格式错误")
+	}
+	return value, nil
+}
+
+// isDigit 判断字符是否为十进制数字
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// stringValue 解析一个可以为 null 的字符串，null 时保持原值
+func (d *pageDecoder) stringValue(dst *string) error {
+	if d.skipSpace(); d.literal("null") {
+		return nil
+	}
+	if d.pos >= len(d.data) || d.data[d.pos] != '"' {
+		return d.errorf("应为字符串")
+	}
+	s, err := d.string(false)
+	*dst = s
+	return err
+}
+
+// unmarshal 截取下一个值交给 json.Unmarshal 解析，用于出现次数少的非文本字段
+func (d *pageDecoder) unmarshal(dst interface{}) error {
+	d.skipSpace()
+	start := d.pos
+	if err := d.skip(); err != nil {
+		return err
+	}
+	return json.Unmarshal(d.data[start:d.pos], dst)
+}
+
+// skip 跳过下一个值，不构建结果
+func (d *pageDecoder) skip() error {
+	d.skipSpace()
+	if d.pos >= len(d.data) {
+		return d.errorf("响应体不完整")
+	}
+	switch c := d.data[d.pos]; {
+	case c == '{':
+		_, err := d.object(func(string) error { return d.skip() })
+		return err
+	case c == '[':
+		_, err := d.array(d.skip)
+		return err
+	case c == '"':
+		raw, plain, err := d.scanString()
+		if err == nil && !plain {
+			// 转义字符是否合法由 json.Unmarshal 检查
+			var s string
+			err = json.Unmarshal(raw, &s)
+		}
+		return err
+	case c == '-' || isDigit(c):
+		_, err := d.number()
+		return err
+	case d.literal("true"), d.literal("false"), d.literal("null"):
+		return nil
+	}
+	return d.errorf("无法识别的值")
+}
+
+// skipSpace 跳过空白字符
+func (d *pageDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume 当前字符为 c 时前进一个字符
+func (d *pageDecoder) consume(c byte) bool {
+	if d.pos < len(d.data) && d.data[d.pos] == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// literal 当前位置为字面量 s 时跳过该字面量
+func (d *pageDecoder) literal(s string) bool {
+	if len(d.data)-d.pos >= len(s) && string(d.data[d.pos:d.pos+len(s)]) == s {
+		d.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// errorf 生成带位置信息的解析错误
+func (d *pageDecoder) errorf(msg string) error {
+	return fmt.Errorf("解析记录列表响应失败: %s (位置 %d)", msg, d.pos)
+}
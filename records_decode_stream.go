@@ -0,0 +1,6 @@
+//go:build basesql_stream
+
+package basesql
+
+// streamRecordsDecoding 记录列表响应是否逐个 token 解析，以 basesql_stream 构建标签编译时开启
+const streamRecordsDecoding = true
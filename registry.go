@@ -0,0 +1,185 @@
+package basesql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// RegistryConfig 客户端注册表配置
+type RegistryConfig struct {
+	GlobalQPS   float64 `json:"global_qps"`   // 所有租户合计的每秒请求上限，0 表示不限制
+	GlobalBurst int     `json:"global_burst"` // 全局突发请求数，默认与 GlobalQPS 相同
+}
+
+// registryKey 注册表中客户端的键
+// 认证方式和凭据不同的配置不共享客户端，避免使用其他配置已经完成认证的客户端；凭据只保存摘要
+type registryKey struct {
+	appID      string
+	appToken   string
+	authType   AuthType
+	credential string // AppSecret 和 AccessToken 的 SHA-256 摘要
+}
+
+// newRegistryKey 获取配置在注册表中的键
+func newRegistryKey(config *Config) registryKey {
+	sum := sha256.Sum256([]byte(config.AppSecret + "\n" + config.AccessToken))
+	return registryKey{
+		appID:      config.AppID,
+		appToken:   config.AppToken,
+		authType:   config.AuthType,
+		credential: hex.EncodeToString(sum[:]),
+	}
+}
+
+// ClientRegistry 多租户客户端注册表
+// 按 (AppID, AppToken) 和认证凭据复用客户端，同一个多维表格的多次 gorm.Open 共享访问令牌、连接池、熔断器、
+// 限流器和表结构缓存；设置了 GlobalQPS 时，所有客户端的请求还要经过同一个全局限流器
+type ClientRegistry struct {
+	mu      sync.Mutex
+	clients map[registryKey]*Client
+	limiter *common.TokenBucket // 全局限流器，为空时不限制
+	closed  bool
+}
+
+// NewClientRegistry 创建客户端注册表
+// 参数:
+//   - config: 注册表配置，为 nil 时不启用全局限流
+//
+// 返回:
+//   - *ClientRegistry: 注册表实例
+func NewClientRegistry(config *RegistryConfig) *ClientRegistry {
+	registry := &ClientRegistry{clients: make(map[registryKey]*Client)}
+	if config != nil && config.GlobalQPS > 0 {
+		burst := config.GlobalBurst
+		if burst <= 0 {
+			burst = int(config.GlobalQPS)
+			if burst < 1 {
+				burst = 1
+			}
+		}
+		registry.limiter = common.NewTokenBucket(&common.RateLimiterConfig{
+			Rate:   config.GlobalQPS,
+			Burst:  burst,
+			Window: common.DefaultRateLimiterConfig().Window,
+		})
+	}
+	return registry
+}
+
+// Get 获取或创建配置对应的客户端
+// 已存在 (AppID, AppToken)、认证方式、AppSecret 和 AccessToken 都相同的客户端时直接返回，
+// 其余客户端级别的设置（如 BaseURL）以首次创建时的配置为准
+// 参数:
+//   - config: 客户端配置
+//
+// 返回:
+//   - *Client: 共享的客户端
+//   - error: 注册表已关闭或创建客户端失败时返回错误
+func (r *ClientRegistry) Get(config *Config) (*Client, error) {
+	if config == nil {
+		return nil, ErrInvalidConfig("配置不能为空")
+	}
+	key := newRegistryKey(config)
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errors.New("客户端注册表已关闭")
+	}
+	if client, ok := r.clients[key]; ok {
+		r.mu.Unlock()
+		return client, nil
+	}
+	r.mu.Unlock()
+
+	// 在锁外创建客户端，避免获取访问令牌时阻塞其他租户
+	client, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client.sharedLimiter = r.limiter
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		client.Close()
+		return nil, errors.New("客户端注册表已关闭")
+	}
+	// 并发创建时保留先注册的客户端
+	if existing, ok := r.clients[key]; ok {
+		client.Close()
+		return existing, nil
+	}
+	r.clients[key] = client
+	return client, nil
+}
+
+// Remove 关闭并移除指定租户的客户端，租户下线时调用；使用不同凭据创建的客户端都会被移除
+// 参数:
+//   - appID: 应用 ID
+//   - appToken: 多维表格 App Token
+//
+// 返回:
+//   - error: 关闭客户端时的错误
+func (r *ClientRegistry) Remove(appID, appToken string) error {
+	r.mu.Lock()
+	var clients []*Client
+	for key, client := range r.clients {
+		if key.appID == appID && key.appToken == appToken {
+			clients = append(clients, client)
+			delete(r.clients, key)
+		}
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, client := range clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Len 获取注册表中的客户端数量
+// 返回:
+//   - int: 客户端数量
+func (r *ClientRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.clients)
+}
+
+// GlobalLimiterStats 获取全局限流器的统计信息
+// 返回:
+//   - *common.RateLimiterStats: 统计信息，未启用全局限流时为 nil
+func (r *ClientRegistry) GlobalLimiterStats() *common.RateLimiterStats {
+	if r.limiter == nil {
+		return nil
+	}
+	return r.limiter.GetStats()
+}
+
+// Close 关闭注册表中的全部客户端，之后不能再获取客户端
+// 返回:
+//   - error: 关闭客户端时的错误
+func (r *ClientRegistry) Close() error {
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = make(map[registryKey]*Client)
+	r.closed = true
+	r.mu.Unlock()
+
+	var errs []error
+	for key, client := range clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭客户端 %s/%s 失败: %w", key.appID, key.appToken, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,429 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Repo 基于 BaseSQL 方言的类型化仓储
+// 封装多维表格与关系数据库的差异：主键是字符串形式的记录 ID，分页使用游标而不是偏移量，
+// 批量写入按 API 限制拆分，查询会读取全部分页
+type Repo[T any] struct {
+	db *gorm.DB
+}
+
+// RepoPage 一页记录
+type RepoPage[T any] struct {
+	Items      []T    // 本页的记录
+	NextCursor string // 下一页的游标，为空表示没有更多记录
+	Total      int    // 表中的记录总数
+}
+
+// UpsertResult Upsert 的结果统计
+type UpsertResult struct {
+	Created int // 新建的记录数
+	Updated int // 更新的记录数
+}
+
+// NewRepo 创建类型化仓储
+// 参数:
+//   - db: 使用 BaseSQL 方言打开的 GORM 数据库实例
+//
+// 返回:
+//   - *Repo[T]: 模型 T 的仓储
+func NewRepo[T any](db *gorm.DB) *Repo[T] {
+	return &Repo[T]{db: db}
+}
+
+// FindByField 查询字段等于指定值的全部记录
+// 参数:
+//   - ctx: 上下文
+//   - field: 字段名（列名）
+//   - value: 字段值
+//
+// 返回:
+//   - []T: 匹配的记录，会读取全部分页
+//   - error: 查询过程中的错误
+func (r *Repo[T]) FindByField(ctx context.Context, field string, value interface{}) ([]T, error) {
+	dialector, sch, tableID, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := &SQLConverter{config: dialector.Config}
+	exprs := []clause.Expression{clause.Eq{Column: clause.Column{Name: field}, Value: value}}
+	req := &ListRecordsRequest{
+		FieldNames:      make([]string, 0),
+		Filter:          converter.buildFilter(exprs),
+		AutomaticFields: wantsRecordTimes(sch),
+	}
+	records, err := searchAllRecords(ctx, dialector, tableID, req)
+	if err != nil {
+		return nil, err
+	}
+	return r.toModels(sch, dialector, filterLikeRecords(records, converter.residuals(exprs)))
+}
+
+// FindByBusinessKey 按业务主键（`basesql:"business_key"` 字段）查找记录
+// 参数:
+//   - ctx: 上下文
+//   - key: 业务主键的值
+//
+// 返回:
+//   - *T: 匹配的记录
+//   - error: 模型没有业务主键时返回错误，没有匹配的记录时返回 gorm.ErrRecordNotFound
+func (r *Repo[T]) FindByBusinessKey(ctx context.Context, key string) (*T, error) {
+	_, sch, _, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	field := businessKeyField(sch)
+	if field == nil {
+		return nil, fmt.Errorf("模型 %s 没有声明业务主键，请为字段添加 `basesql:\"business_key\"` 标签", sch.Name)
+	}
+	items, err := r.FindByField(ctx, field.DBName, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &items[0], nil
+}
+
+// Page 按游标读取一页记录
+// 参数:
+//   - ctx: 上下文
+//   - cursor: 上一页返回的 NextCursor，读取第一页时为空
+//   - size: 每页记录数，不大于 0 时使用 Config.DefaultPageSize，未设置时为 20，最大 500
+//
+// 返回:
+//   - *RepoPage[T]: 本页的记录和下一页的游标
+//   - error: 查询过程中的错误
+func (r *Repo[T]) Page(ctx context.Context, cursor string, size int) (*RepoPage[T], error) {
+	dialector, sch, tableID, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := listRecordsPage(ctx, dialector, tableID, &ListRecordsRequest{AutomaticFields: wantsRecordTimes(sch)}, cursor, size)
+	if err != nil {
+		return nil, err
+	}
+	items, err := r.toModels(sch, dialector, resp.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &RepoPage[T]{Items: items, Total: resp.Total}
+	if resp.HasMore {
+		page.NextCursor = resp.PageToken
+	}
+	return page, nil
+}
+
+// BatchCreate 批量创建记录，每批最多 500 条，创建后将记录 ID 写回各元素的主键
+// 中途失败时之前的批次已经写入，已写入元素的主键已设置
+// 参数:
+//   - ctx: 上下文
+//   - items: 要创建的记录
+//
+// 返回:
+//   - error: 校验或写入错误
+func (r *Repo[T]) BatchCreate(ctx context.Context, items []T) error {
+	if len(items) == 0 {
+		return nil
+	}
+	dialector, sch, tableID, err := r.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	if err := dialector.Config.CheckWritable("INSERT"); err != nil {
+		return err
+	}
+	fieldMap, err := repoFieldMap(dialector, sch.Table)
+	if err != nil {
+		return err
+	}
+
+	values := make([]map[string]interface{}, len(items))
+	for i := range items {
+		if err := fillBusinessKey(ctx, dialector.Config, sch, reflect.ValueOf(&items[i]).Elem()); err != nil {
+			return err
+		}
+		values[i] = modelFieldValues(ctx, sch, reflect.ValueOf(&items[i]).Elem(), fieldMap)
+		if err := dialector.validateWrite(sch.Table, sch, fieldMap, values[i], false); err != nil {
+			return fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+		if err := checkUniqueFields(ctx, dialector, tableID, sch, values[i]); err != nil {
+			return fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+	}
+
+	indexes := make([]int, len(items))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return r.createBatches(ctx, dialector, sch, tableID, items, indexes, values)
+}
+
+// Upsert 按键字段写入记录：表中已有相同键的记录时更新，否则创建
+// 参数:
+//   - ctx: 上下文
+//   - key: 键字段名（列名），用于匹配已有记录；为空时使用业务主键，业务主键为空的元素先生成业务主键
+//   - items: 要写入的记录，写入后各元素的主键为对应的记录 ID
+//
+// 返回:
+//   - *UpsertResult: 新建和更新的记录数
+//   - error: 校验或写入错误
+func (r *Repo[T]) Upsert(ctx context.Context, key string, items []T) (*UpsertResult, error) {
+	result := &UpsertResult{}
+	if len(items) == 0 {
+		return result, nil
+	}
+	dialector, sch, tableID, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keyField *schema.Field
+	if key == "" {
+		if keyField = businessKeyField(sch); keyField == nil {
+			return nil, fmt.Errorf("模型 %s 没有声明业务主键，请指定键字段", sch.Name)
+		}
+		for i := range items {
+			if err := fillBusinessKey(ctx, dialector.Config, sch, reflect.ValueOf(&items[i]).Elem()); err != nil {
+				return nil, err
+			}
+		}
+	} else if keyField = sch.LookUpField(key); keyField == nil || keyField.DBName == "" {
+		return nil, fmt.Errorf("模型 %s 中没有键字段 '%s'", sch.Name, key)
+	}
+	if err := dialector.Config.CheckWritable("INSERT"); err != nil {
+		return nil, err
+	}
+	if err := dialector.Config.CheckWritable("UPDATE"); err != nil {
+		return nil, err
+	}
+	fieldMap, err := repoFieldMap(dialector, sch.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	// 读取已有记录的键
+	keys := make([]interface{}, len(items))
+	for i := range items {
+		value, _ := keyField.ValueOf(ctx, reflect.ValueOf(&items[i]).Elem())
+		if keys[i] = value; common.FormatValue(value) == "" {
+			return nil, fmt.Errorf("第 %d 条记录的键字段 '%s' 为空", i+1, keyField.DBName)
+		}
+	}
+	existing := make(map[string]string, len(items))
+	converter := &SQLConverter{}
+	for start := 0; start < len(keys); start += maxInFilterValues {
+		end := min(start+maxInFilterValues, len(keys))
+		req := &ListRecordsRequest{
+			FieldNames: make([]string, 0),
+			Filter:     converter.buildFilter([]clause.Expression{clause.IN{Column: clause.Column{Name: keyField.DBName}, Values: keys[start:end]}}),
+		}
+		records, err := searchAllRecords(ctx, dialector, tableID, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			value := record.Fields[keyField.DBName]
+			if tableField, ok := fieldMap[keyField.DBName]; ok && value != nil {
+				value = tableField.ConvertToGoValue(value)
+			}
+			existing[common.FormatValue(value)] = record.RecordID
+		}
+	}
+
+	var createIndexes []int
+	var createValues []map[string]interface{}
+	var updates []*BatchUpdateRecord
+	var updateIndexes []int
+	for i := range items {
+		values := modelFieldValues(ctx, sch, reflect.ValueOf(&items[i]).Elem(), fieldMap)
+		recordID, found := existing[common.FormatValue(keys[i])]
+		if err := dialector.validateWrite(sch.Table, sch, fieldMap, values, found); err != nil {
+			return nil, fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+		if found {
+			updates = append(updates, &BatchUpdateRecord{RecordID: recordID, Fields: values})
+			updateIndexes = append(updateIndexes, i)
+			continue
+		}
+		if err := checkUniqueFields(ctx, dialector, tableID, sch, values); err != nil {
+			return nil, fmt.Errorf("第 %d 条记录: %w", i+1, err)
+		}
+		createIndexes = append(createIndexes, i)
+		createValues = append(createValues, values)
+	}
+
+	for start := 0; start < len(updates); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(updates))
+		resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_update", dialector.Config.AppToken, tableID),
+			Body:   &BatchUpdateRecordsRequest{Records: updates[start:end]},
+		})
+		if err != nil {
+			return result, fmt.Errorf("批量更新记录失败: %w", err)
+		}
+		if err := repoCheckResponse(resp.Body, nil); err != nil {
+			return result, fmt.Errorf("批量更新记录失败: %w", err)
+		}
+		for j, i := range updateIndexes[start:end] {
+			if err := repoSetRecordID(ctx, sch, &items[i], updates[start+j].RecordID); err != nil {
+				return result, err
+			}
+		}
+		result.Updated += end - start
+	}
+
+	if err := r.createBatches(ctx, dialector, sch, tableID, items, createIndexes, createValues); err != nil {
+		return result, err
+	}
+	result.Created = len(createIndexes)
+	return result, nil
+}
+
+// createBatches 按 API 限制分批创建 items 中 indexes 指定的记录，values 与 indexes 一一对应
+func (r *Repo[T]) createBatches(ctx context.Context, dialector *Dialector, sch *schema.Schema, tableID string, items []T, indexes []int, values []map[string]interface{}) error {
+	for start := 0; start < len(indexes); start += common.MaxBatchSize {
+		end := min(start+common.MaxBatchSize, len(indexes))
+		req := &BatchCreateRecordsRequest{Records: make([]*CreateRecordRequest, 0, end-start)}
+		for _, fields := range values[start:end] {
+			req.Records = append(req.Records, &CreateRecordRequest{Fields: fields})
+		}
+
+		resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+			Method: "POST",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", dialector.Config.AppToken, tableID),
+			Body:   req,
+		})
+		if err != nil {
+			return fmt.Errorf("批量创建记录失败: %w", err)
+		}
+		var created BatchCreateRecordsResponse
+		if err := repoCheckResponse(resp.Body, &created); err != nil {
+			return fmt.Errorf("批量创建记录失败: %w", err)
+		}
+		for j, i := range indexes[start:end] {
+			if j >= len(created.Records) || created.Records[j] == nil {
+				break
+			}
+			if err := repoSetRecordID(ctx, sch, &items[i], created.Records[j].RecordID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve 解析模型结构并获取方言和表 ID
+func (r *Repo[T]) resolve(ctx context.Context) (*Dialector, *schema.Schema, string, error) {
+	dialector, ok := r.db.Dialector.(*Dialector)
+	if !ok {
+		return nil, nil, "", fmt.Errorf("仓储只支持 BaseSQL 方言打开的数据库")
+	}
+	stmt := &gorm.Statement{DB: r.db.WithContext(ctx)}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, nil, "", fmt.Errorf("解析模型失败: %w", err)
+	}
+	tableID, err := getTableID(dialector, stmt.Schema.Table)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return dialector, stmt.Schema, tableID, nil
+}
+
+// toModels 将记录转换为模型
+func (r *Repo[T]) toModels(sch *schema.Schema, dialector *Dialector, records []*Record) ([]T, error) {
+	scanner, err := newRecordScanner(sch, dialector)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, 0, len(records))
+	for _, record := range records {
+		var item T
+		if err := scanner.scan(reflect.ValueOf(&item).Elem(), record); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// listRecordsPage 读取一页记录
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - req: 查询请求，可以为 nil，其中的分页参数不使用
+//   - cursor: 分页标记，读取第一页时为空
+//   - size: 每页记录数，不大于 0 时使用 Config.DefaultPageSize，未设置时为 20，最大 500
+//
+// 返回:
+//   - *ListRecordsResponse: 本页的记录和下一页的分页标记
+//   - error: 查询过程中的错误
+func listRecordsPage(ctx context.Context, dialector *Dialector, tableID string, req *ListRecordsRequest, cursor string, size int) (*ListRecordsResponse, error) {
+	if size <= 0 {
+		size = recordsPageSize(dialector.Config, nil, common.DefaultPageSize)
+	}
+	resp, _, err := fetchRecordsPage(ctx, dialector, tableID, req, cursor, min(size, common.MaxPageSize))
+	return resp, err
+}
+
+// repoFieldMap 获取表字段名到字段信息的映射
+func repoFieldMap(dialector *Dialector, table string) (map[string]*Field, error) {
+	fields, err := getTableFields(dialector, table)
+	if err != nil {
+		return nil, fmt.Errorf("获取表字段信息失败: %w", err)
+	}
+	fieldMap := make(map[string]*Field, len(fields))
+	for _, field := range fields {
+		fieldMap[field.FieldName] = field
+	}
+	return fieldMap, nil
+}
+
+// repoSetRecordID 将记录 ID 写回模型的主键
+func repoSetRecordID[T any](ctx context.Context, sch *schema.Schema, item *T, recordID string) error {
+	if sch.PrioritizedPrimaryField == nil || recordID == "" {
+		return nil
+	}
+	if err := sch.PrioritizedPrimaryField.Set(ctx, reflect.ValueOf(item).Elem(), recordID); err != nil {
+		return fmt.Errorf("设置主键值失败: %w", err)
+	}
+	return nil
+}
+
+// repoCheckResponse 检查响应码并解析 data 部分，data 为 nil 时只检查响应码
+func repoCheckResponse(body []byte, data interface{}) error {
+	var resp struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("解析API响应失败: %w", err)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("API返回错误: code=%d, msg=%s", resp.Code, resp.Msg)
+	}
+	if data == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Data, data); err != nil {
+		return fmt.Errorf("解析API响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package basesql
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// RetryOperation 重试策略对应的操作类型
+type RetryOperation string
+
+const (
+	// RetryOpRead 读取记录，包括列表和搜索
+	RetryOpRead RetryOperation = "read"
+	// RetryOpWrite 创建、更新、删除记录
+	RetryOpWrite RetryOperation = "write"
+	// RetryOpMetadata 读取或修改多维表格、数据表、字段等元数据
+	RetryOpMetadata RetryOperation = "metadata"
+)
+
+// ShouldRetryFunc 自定义重试判断函数
+// 参数:
+//   - err: 本次请求的错误
+//   - attempt: 当前尝试次数，从 0 开始
+//   - op: 请求的操作类型
+//
+// 返回:
+//   - bool: 是否重试，重试次数上限仍由对应的 RetryConfig 控制
+type ShouldRetryFunc func(err error, attempt int, op RetryOperation) bool
+
+// feishuRetryableCodes 可以重试的飞书业务错误码
+// 其余业务错误码（权限、参数、资源不存在等）重试也不会成功
+var feishuRetryableCodes = map[int]bool{
+	99991400: true, // 请求频率超限
+	1254290:  true, // 请求过于频繁
+	1254291:  true, // 写冲突
+	1254607:  true, // 数据未就绪
+	1255040:  true, // 请求超时
+}
+
+// DefaultShouldRetry 默认重试判断，对网络错误、5xx、限流和可重试的飞书业务错误重试
+// 熔断器开启时直接返回，不在退避等待中消耗重试次数
+func DefaultShouldRetry(err error, attempt int, op RetryOperation) bool {
+	if errors.Is(err, common.ErrCircuitOpen) {
+		return false
+	}
+	if apiErr := unwrapAPIError(err); apiErr != nil {
+		if apiErr.Code >= 10000 {
+			return feishuRetryableCodes[apiErr.Code]
+		}
+		return common.IsRetryableError(apiErr)
+	}
+	return common.IsRetryableError(err)
+}
+
+// SetRetryPolicy 为指定操作类型设置重试策略
+// 例如为写操作设置更少的重试次数，为读操作设置更积极的重试
+// 参数:
+//   - op: 操作类型
+//   - config: 重试配置，为 nil 时恢复使用 SetRetryConfig 设置的通用配置
+func (c *Client) SetRetryPolicy(op RetryOperation, config *RetryConfig) {
+	c.retryMutex.Lock()
+	defer c.retryMutex.Unlock()
+
+	if config == nil {
+		delete(c.retryPolicies, op)
+		return
+	}
+	if c.retryPolicies == nil {
+		c.retryPolicies = make(map[RetryOperation]*RetryConfig)
+	}
+	c.retryPolicies[op] = config
+}
+
+// SetShouldRetry 设置自定义重试判断函数
+// 参数:
+//   - fn: 重试判断函数，为 nil 时恢复使用 DefaultShouldRetry
+func (c *Client) SetShouldRetry(fn ShouldRetryFunc) {
+	c.retryMutex.Lock()
+	c.shouldRetry = fn
+	c.retryMutex.Unlock()
+}
+
+// retryPolicyFor 获取操作类型对应的重试策略和重试判断函数
+func (c *Client) retryPolicyFor(op RetryOperation) (*RetryConfig, ShouldRetryFunc) {
+	c.retryMutex.RLock()
+	defer c.retryMutex.RUnlock()
+
+	policy := c.retryConfig
+	if p, ok := c.retryPolicies[op]; ok {
+		policy = p
+	}
+	shouldRetry := c.shouldRetry
+	if shouldRetry == nil {
+		shouldRetry = DefaultShouldRetry
+	}
+	return policy, shouldRetry
+}
+
+// classifyRetryOperation 根据请求方法和路径判断操作类型
+// 记录搜索接口虽然使用 POST，但不修改数据，按读操作处理
+func classifyRetryOperation(req *APIRequest) RetryOperation {
+	path := req.Path
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.Contains(path, "/records") {
+		return RetryOpMetadata
+	}
+	if req.Method == "GET" || strings.HasSuffix(path, "/records/search") {
+		return RetryOpRead
+	}
+	return RetryOpWrite
+}
+
+// retryDelay 计算第 attempt 次重试前的等待时间
+// 服务端给出的 Retry-After 长于退避时间时使用 Retry-After，但不超过策略的 MaxDelay，
+// 避免异常的响应头让请求长时间挂起
+func retryDelay(policy *RetryConfig, attempt int, err error) time.Duration {
+	delay := policy.CalculateBackoffDelay(attempt)
+	retryAfter := retryAfterOf(err)
+	if policy.MaxDelay > 0 && retryAfter > policy.MaxDelay {
+		retryAfter = policy.MaxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// retryAfterOf 获取错误中服务端要求的重试等待时间
+func retryAfterOf(err error) time.Duration {
+	if apiErr := unwrapAPIError(err); apiErr != nil {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// unwrapAPIError 从错误链中取出 APIError，不存在时返回 nil
+func unwrapAPIError(err error) *common.APIError {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return nil
+}
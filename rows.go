@@ -0,0 +1,189 @@
+package basesql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// resultDriverName 内存结果集驱动的注册名称
+// 飞书多维表格没有真正的数据库连接，*sql.Rows 只能通过 database/sql 驱动构造，
+// 因此注册一个只读的内存驱动，将已获取的记录包装为标准的 *sql.Rows / *sql.Row
+const resultDriverName = "basesql-result"
+
+// RecordIDColumn 结果集中表示记录 ID 的列名
+// 查询目标不是模型结构体时（如 map），记录 ID 以该列返回
+const RecordIDColumn = "record_id"
+
+var (
+	resultDBOnce sync.Once // 保证内存结果集数据库只初始化一次
+	resultDB     *sql.DB   // 内存结果集数据库实例
+	resultDBErr  error     // 内存结果集数据库初始化错误
+
+	resultSets   sync.Map // 待读取的结果集，键为结果集 ID
+	resultSetSeq uint64   // 结果集 ID 序列号
+)
+
+func init() {
+	sql.Register(resultDriverName, resultDriver{})
+}
+
+// ResultSet 内存中的查询结果集
+// 列顺序固定，值均已转换为 database/sql 支持的驱动值类型
+type ResultSet struct {
+	Columns []string         // 列名列表
+	Rows    [][]driver.Value // 行数据，每行的值与 Columns 一一对应
+}
+
+// NewResultSet 创建内存结果集
+// 参数:
+//   - columns: 列名列表
+//
+// 返回:
+//   - *ResultSet: 空的结果集实例
+func NewResultSet(columns []string) *ResultSet {
+	return &ResultSet{
+		Columns: columns,
+		Rows:    make([][]driver.Value, 0),
+	}
+}
+
+// AppendRow 向结果集追加一行数据
+// 值会被转换为驱动值类型，多值字段（如多选、人员）转换为逗号分隔的字符串
+// 参数:
+//   - values: 与列顺序一致的值列表
+func (rs *ResultSet) AppendRow(values ...interface{}) {
+	row := make([]driver.Value, len(rs.Columns))
+	for i := range row {
+		if i < len(values) {
+			row[i] = toDriverValue(values[i])
+		}
+	}
+	rs.Rows = append(rs.Rows, row)
+}
+
+// QueryRows 将结果集包装为 *sql.Rows
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - *sql.Rows: 标准结果集，调用方负责关闭
+//   - error: 错误信息
+func (rs *ResultSet) QueryRows(ctx context.Context) (*sql.Rows, error) {
+	db, err := openResultDB()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, rs.register())
+}
+
+// QueryRow 将结果集的第一行包装为 *sql.Row
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - *sql.Row: 标准单行结果，结果集为空时 Scan 返回 sql.ErrNoRows
+func (rs *ResultSet) QueryRow(ctx context.Context) *sql.Row {
+	db, err := openResultDB()
+	if err != nil {
+		// 驱动在 init 中注册，sql.Open 不会失败；这里保持与 ConnPool 一致的空行兜底
+		return &sql.Row{}
+	}
+	return db.QueryRowContext(ctx, rs.register())
+}
+
+// register 登记结果集并返回用于查询的 ID
+func (rs *ResultSet) register() string {
+	id := strconv.FormatUint(atomic.AddUint64(&resultSetSeq, 1), 10)
+	resultSets.Store(id, rs)
+	return id
+}
+
+// openResultDB 打开内存结果集数据库
+func openResultDB() (*sql.DB, error) {
+	resultDBOnce.Do(func() {
+		resultDB, resultDBErr = sql.Open(resultDriverName, "")
+	})
+	return resultDB, resultDBErr
+}
+
+// toDriverValue 将 Go 值转换为 database/sql 驱动值
+func toDriverValue(value interface{}) driver.Value {
+	if value == nil {
+		return nil
+	}
+	if v, err := driver.DefaultParameterConverter.ConvertValue(value); err == nil {
+		return v
+	}
+	return common.FormatValue(value)
+}
+
+// resultDriver 内存结果集驱动
+type resultDriver struct{}
+
+// Open 实现 driver.Driver 接口
+func (resultDriver) Open(name string) (driver.Conn, error) {
+	return resultConn{}, nil
+}
+
+// resultConn 内存结果集连接，仅支持按结果集 ID 查询
+type resultConn struct{}
+
+// Prepare 实现 driver.Conn 接口，内存结果集不支持预编译语句
+func (resultConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("BaseSQL: 内存结果集不支持预编译语句")
+}
+
+// Close 实现 driver.Conn 接口
+func (resultConn) Close() error {
+	return nil
+}
+
+// Begin 实现 driver.Conn 接口，内存结果集不支持事务
+func (resultConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("BaseSQL: 内存结果集不支持事务")
+}
+
+// QueryContext 实现 driver.QueryerContext 接口
+// query 为结果集 ID，结果集只能被读取一次
+func (resultConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	value, ok := resultSets.LoadAndDelete(query)
+	if !ok {
+		return nil, fmt.Errorf("BaseSQL: 结果集 %s 不存在或已被读取", query)
+	}
+	return &resultRows{set: value.(*ResultSet)}, nil
+}
+
+// resultRows 内存结果集游标
+type resultRows struct {
+	set *ResultSet
+	pos int
+}
+
+// Columns 实现 driver.Rows 接口
+func (r *resultRows) Columns() []string {
+	return r.set.Columns
+}
+
+// Close 实现 driver.Rows 接口
+func (r *resultRows) Close() error {
+	r.pos = len(r.set.Rows)
+	return nil
+}
+
+// Next 实现 driver.Rows 接口
+func (r *resultRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.set.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.set.Rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,238 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm/schema"
+)
+
+// structPlan 模型的扫描计划，只与模型结构有关
+// 预先挑出主键字段和需要从记录中读取的字段，扫描记录时不再逐条遍历 Schema 判断字段类别
+type structPlan struct {
+	primaryKeys  []*schema.Field // 值来自记录 ID 的主键字段
+	fields       []*schema.Field // 值来自记录字段的普通字段
+	jsonFields   []bool          // 与 fields 一一对应，是否为从 JSON 文本解码的字段，见 isJSONField
+	customScans  []bool          // 与 fields 一一对应，是否由字段类型自己解析值，见 isCustomScanField
+	createdTimes []*schema.Field // 值来自记录创建时间的 autoCreateTime 字段
+	updatedTimes []*schema.Field // 值来自记录最后修改时间的 autoUpdateTime 字段
+}
+
+// structPlans 按模型结构缓存的扫描计划，键为 *schema.Schema
+// gorm 对同一模型只解析一次 Schema，指针可以直接作为键
+var structPlans sync.Map
+
+// planFor 获取模型的扫描计划，第一次使用时构建并缓存
+func planFor(sch *schema.Schema) *structPlan {
+	if plan, ok := structPlans.Load(sch); ok {
+		return plan.(*structPlan)
+	}
+	plan := &structPlan{}
+	for _, field := range sch.Fields {
+		switch {
+		case field.PrimaryKey:
+			plan.primaryKeys = append(plan.primaryKeys, field)
+		case field.DBName != "":
+			plan.fields = append(plan.fields, field)
+			plan.jsonFields = append(plan.jsonFields, isJSONField(field))
+			plan.customScans = append(plan.customScans, isCustomScanField(field))
+		}
+		if field.AutoCreateTime != 0 {
+			plan.createdTimes = append(plan.createdTimes, field)
+		}
+		if field.AutoUpdateTime != 0 {
+			plan.updatedTimes = append(plan.updatedTimes, field)
+		}
+	}
+	actual, _ := structPlans.LoadOrStore(sch, plan)
+	return actual.(*structPlan)
+}
+
+// isRecordTimeField 判断字段是否为自动时间字段
+// 自动时间字段由多维表格维护，写入时跳过，读取时取自记录的创建时间和最后修改时间
+func isRecordTimeField(field *schema.Field) bool {
+	return field.AutoCreateTime != 0 || field.AutoUpdateTime != 0
+}
+
+// wantsRecordTimes 判断模型是否有自动时间字段，有时查询需要请求记录的自动字段
+func wantsRecordTimes(sch *schema.Schema) bool {
+	if sch == nil {
+		return false
+	}
+	plan := planFor(sch)
+	return len(plan.createdTimes) > 0 || len(plan.updatedTimes) > 0
+}
+
+// recordScanner 将同一张表的记录批量设置到模型
+// 扫描计划与表字段在创建时绑定一次，每个模型字段对应的类型转换函数预先确定，
+// 扫描大量记录时不再为每条记录重复获取表字段和按字段名查找
+type recordScanner struct {
+	plan     *structPlan
+	converts []func(interface{}) interface{} // 与 plan.fields 一一对应，表中没有该字段时为 nil
+}
+
+// newRecordScanner 创建记录扫描器
+// 获取表字段失败时不做类型转换，直接设置记录中的原始值
+// 参数:
+//   - sch: 模型结构
+//   - dialector: BaseSQL 的方言器实例
+//
+// 返回:
+//   - *recordScanner: 记录扫描器
+//   - error: 模型结构为空
+func newRecordScanner(sch *schema.Schema, dialector *Dialector) (*recordScanner, error) {
+	if sch == nil {
+		return nil, fmt.Errorf("schema不能为空")
+	}
+	plan := planFor(sch)
+	scanner := &recordScanner{plan: plan, converts: make([]func(interface{}) interface{}, len(plan.fields))}
+	for i, field := range plan.fields {
+		// Link 字段需要超链接的文本和地址，不经过表字段的转换
+		if field.IndirectFieldType == urlLinkType {
+			scanner.converts[i] = urlLinkValue
+		}
+	}
+
+	tableFields, err := getTableFields(dialector, sch.Table)
+	if err != nil {
+		return scanner, nil
+	}
+	byName := make(map[string]*Field, len(tableFields))
+	for _, tableField := range tableFields {
+		byName[tableField.FieldName] = tableField
+	}
+	for i, field := range plan.fields {
+		tableField, ok := byName[field.DBName]
+		switch {
+		case !ok || scanner.converts[i] != nil:
+		case tableField.Type == FieldTypeAutoNumber && isIntegerKind(field.IndirectFieldType.Kind()):
+			scanner.converts[i] = autoNumberInt
+		default:
+			scanner.converts[i] = tableField.ConvertToGoValue
+		}
+	}
+	return scanner, nil
+}
+
+// scan 将记录设置到结构体
+// 参数:
+//   - structValue: 模型结构体的值，必须可设置
+//   - record: 记录
+//
+// 返回:
+//   - error: 记录为空或字段设置失败
+func (s *recordScanner) scan(structValue reflect.Value, record *Record) error {
+	if record == nil {
+		return fmt.Errorf("记录不能为空")
+	}
+	ctx := context.Background()
+
+	// 主键值来自 record.RecordID
+	if record.RecordID != "" {
+		for _, field := range s.plan.primaryKeys {
+			if err := field.Set(ctx, structValue, record.RecordID); err != nil {
+				return fmt.Errorf("设置主键字段 %s 失败: %w", field.DBName, err)
+			}
+		}
+	}
+
+	// 普通字段的值来自 record.Fields
+	for i, field := range s.plan.fields {
+		value, ok := record.Fields[field.DBName]
+		if !ok {
+			continue
+		}
+		if number, isNumber := value.(json.Number); isNumber && (s.plan.customScans[i] || field.IndirectFieldType.Kind() == reflect.String) {
+			// 高精度数字原文交给 decimal 类型或字符串字段，不经过 float64
+			value = number.String()
+		} else if convert := s.converts[i]; convert != nil {
+			value = convert(value)
+		}
+		if s.plan.jsonFields[i] {
+			decoded, err := decodeJSONValue(field, value)
+			if err != nil {
+				return err
+			}
+			if decoded == nil {
+				continue
+			}
+			value = decoded
+		} else if s.plan.customScans[i] && value != nil {
+			if err := setCustomValue(ctx, structValue, field, value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := field.Set(ctx, structValue, value); err != nil {
+			return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
+		}
+	}
+
+	// 自动时间字段的值来自记录的创建时间和最后修改时间，响应中没有自动字段时保持记录字段中的值
+	for _, field := range s.plan.createdTimes {
+		if err := setRecordTime(ctx, structValue, field, field.AutoCreateTime, record.CreatedTime); err != nil {
+			return err
+		}
+	}
+	for _, field := range s.plan.updatedTimes {
+		if err := setRecordTime(ctx, structValue, field, field.AutoUpdateTime, record.LastModified); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRecordTime 按自动时间字段的类型设置毫秒时间戳，时间戳为 0 时不设置
+// time.Time 字段设置为对应的时间，整数字段按标签指定的精度设置为秒、毫秒或纳秒
+func setRecordTime(ctx context.Context, structValue reflect.Value, field *schema.Field, timeType schema.TimeType, millis int64) error {
+	if millis == 0 {
+		return nil
+	}
+	var value interface{}
+	switch timeType {
+	case schema.UnixSecond:
+		value = millis / 1000
+	case schema.UnixMillisecond:
+		value = millis
+	case schema.UnixNanosecond:
+		value = millis * int64(time.Millisecond)
+	default:
+		value = time.UnixMilli(millis)
+	}
+	if err := field.Set(ctx, structValue, value); err != nil {
+		return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
+	}
+	return nil
+}
+
+// isIntegerKind 判断类型是否为整数
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// autoNumberInt 将自动编号转换为整数，扫描整数类型的模型字段时使用
+// 自动编号可以设置前缀、日期等格式，如 "NO-20240101-007"，取末尾的数字部分；没有数字时返回 nil
+func autoNumberInt(value interface{}) interface{} {
+	text := common.FormatValue(value)
+	end := len(text)
+	start := end
+	for start > 0 && text[start-1] >= '0' && text[start-1] <= '9' {
+		start--
+	}
+	n, err := strconv.ParseInt(text[start:end], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,283 @@
+package basesql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// 表结构读取接口的路径：数据表列表和字段列表
+var (
+	schemaTablesPathPattern = regexp.MustCompile(`^/bitable/v1/apps/[^/]+/tables(\?.*)?$`)
+	schemaFieldsPathPattern = regexp.MustCompile(`^/bitable/v1/apps/[^/]+/tables/[^/]+/fields(\?.*)?$`)
+	schemaAppTokenPattern   = regexp.MustCompile(`^/bitable/v1/apps/([A-Za-z0-9_-]+)/`)
+)
+
+// schemaCache 表结构缓存
+// 缓存数据表列表和字段列表接口的成功响应，建表、删表、增删改字段的请求发出后整体失效。
+// 设置了持久化目录时，每个多维表格的缓存同时写入 <dir>/<app_token>-<scope>.json，
+// 之后创建的客户端（如下一次执行的 CLI 命令）在过期前直接使用，不再请求表结构
+type schemaCache struct {
+	ttl     time.Duration
+	dir     string // 持久化目录，为空时只缓存在内存中
+	scope   string // 应用和 API 地址的摘要，不同应用或环境访问同一多维表格时使用不同的缓存文件
+	mu      sync.RWMutex
+	entries map[string]schemaCacheEntry // 请求路径到响应的映射
+	loaded  map[string]bool             // 已读取过缓存文件的 app_token
+}
+
+// schemaCacheEntry 表结构缓存项
+type schemaCacheEntry struct {
+	resp    *APIResponse
+	expires time.Time
+}
+
+// schemaCacheFileEntry 缓存文件中的一项，只保存状态码和响应体
+type schemaCacheFileEntry struct {
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body"`
+	Expires    time.Time       `json:"expires"`
+}
+
+// newSchemaCache 创建表结构缓存
+// 参数:
+//   - ttl: 缓存过期时间
+//   - dir: 持久化目录，为空时不写入文件
+//   - appID: 应用 ID，与 baseURL 一起区分缓存文件
+//   - baseURL: 飞书开放平台 API 地址
+//
+// 返回:
+//   - *schemaCache: 表结构缓存
+func newSchemaCache(ttl time.Duration, dir, appID, baseURL string) *schemaCache {
+	sum := sha256.Sum256([]byte(appID + "\n" + baseURL))
+	return &schemaCache{
+		ttl:     ttl,
+		dir:     dir,
+		scope:   hex.EncodeToString(sum[:6]),
+		entries: make(map[string]schemaCacheEntry),
+		loaded:  make(map[string]bool),
+	}
+}
+
+// get 获取未过期的缓存响应，内存中没有时读取该多维表格的缓存文件
+func (sc *schemaCache) get(path string) (*APIResponse, bool) {
+	sc.mu.RLock()
+	entry, ok := sc.entries[path]
+	appToken := schemaCacheAppToken(path)
+	loaded := sc.dir == "" || appToken == "" || sc.loaded[appToken]
+	sc.mu.RUnlock()
+
+	if !ok && !loaded {
+		sc.mu.Lock()
+		sc.load(appToken)
+		entry, ok = sc.entries[path]
+		sc.mu.Unlock()
+	}
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+// schemaCacheAppToken 从表结构请求的路径中取出 app_token，用作缓存文件名
+func schemaCacheAppToken(path string) string {
+	if match := schemaAppTokenPattern.FindStringSubmatch(path); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// schemaCacheFile 多维表格的缓存文件路径
+// 文件名包含应用和 API 地址的摘要，不同应用的权限不同，看到的表结构也可能不同
+func (sc *schemaCache) schemaCacheFile(appToken string) string {
+	return filepath.Join(sc.dir, appToken+"-"+sc.scope+".json")
+}
+
+// load 读取多维表格的缓存文件，跳过已过期的项；文件不存在或损坏时当作没有缓存。调用方需要持有写锁
+func (sc *schemaCache) load(appToken string) {
+	if sc.loaded[appToken] {
+		return
+	}
+	sc.loaded[appToken] = true
+
+	data, err := os.ReadFile(sc.schemaCacheFile(appToken))
+	if err != nil {
+		return
+	}
+	var stored map[string]schemaCacheFileEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		common.Warnf("表结构缓存文件 %s 无法解析，已忽略: %v", sc.schemaCacheFile(appToken), err)
+		return
+	}
+	now := time.Now()
+	for path, item := range stored {
+		if _, ok := sc.entries[path]; ok || now.After(item.Expires) {
+			continue
+		}
+		sc.entries[path] = schemaCacheEntry{
+			resp:    &APIResponse{StatusCode: item.StatusCode, Body: item.Body},
+			expires: item.Expires,
+		}
+	}
+}
+
+// save 将多维表格未过期的缓存项写入缓存文件，先写入唯一命名的临时文件再重命名，
+// 并发执行的命令各自使用自己的临时文件，不会读到写了一半的文件。
+// 写入失败只记录警告，不影响请求。调用方需要持有写锁
+func (sc *schemaCache) save(appToken string) {
+	now := time.Now()
+	stored := make(map[string]schemaCacheFileEntry)
+	for path, entry := range sc.entries {
+		if schemaCacheAppToken(path) != appToken || now.After(entry.expires) {
+			continue
+		}
+		stored[path] = schemaCacheFileEntry{StatusCode: entry.resp.StatusCode, Body: entry.resp.Body, Expires: entry.expires}
+	}
+
+	data, err := json.Marshal(stored)
+	if err == nil {
+		err = os.MkdirAll(sc.dir, 0700)
+	}
+	if err == nil {
+		err = writeFileAtomic(sc.dir, appToken+"-*.tmp", sc.schemaCacheFile(appToken), data)
+	}
+	if err != nil {
+		common.Warnf("写入表结构缓存文件失败: %v", err)
+	}
+}
+
+// writeFileAtomic 将数据写入目录中的临时文件后重命名为目标文件，失败时删除临时文件
+func writeFileAtomic(dir, pattern, path string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// put 缓存响应，响应体中的业务错误码不为 0 时不缓存
+func (sc *schemaCache) put(path string, resp *APIResponse) {
+	var body struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(resp.Body, &body) != nil || body.Code != 0 {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	appToken := schemaCacheAppToken(path)
+	persist := sc.dir != "" && appToken != ""
+	if persist {
+		// 先读取已有的缓存文件，写入时保留其他表的缓存项
+		sc.load(appToken)
+	}
+	sc.entries[path] = schemaCacheEntry{resp: resp, expires: time.Now().Add(sc.ttl)}
+	if persist {
+		sc.save(appToken)
+	}
+}
+
+// invalidate 清空缓存，并删除读写过的缓存文件
+// 参数:
+//   - appTokens: 另外需要删除缓存文件的多维表格，用于本客户端还没有读取过的缓存
+func (sc *schemaCache) invalidate(appTokens ...string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.entries = make(map[string]schemaCacheEntry)
+	if sc.dir == "" {
+		return
+	}
+	for _, appToken := range appTokens {
+		if appToken != "" && !strings.ContainsAny(appToken, `/\.`) {
+			sc.loaded[appToken] = true
+		}
+	}
+	for appToken := range sc.loaded {
+		if err := os.Remove(sc.schemaCacheFile(appToken)); err != nil && !os.IsNotExist(err) {
+			common.Warnf("删除表结构缓存文件失败: %v", err)
+		}
+	}
+}
+
+// isSchemaReadRequest 判断请求是否读取表结构（数据表列表或字段列表）
+func isSchemaReadRequest(req *APIRequest) bool {
+	if req.Method != "GET" || len(req.QueryParams) > 0 {
+		return false
+	}
+	return schemaTablesPathPattern.MatchString(req.Path) || schemaFieldsPathPattern.MatchString(req.Path)
+}
+
+// isSchemaWriteRequest 判断请求是否修改表结构（建表、删表、增删改字段），记录的读写不算
+func isSchemaWriteRequest(req *APIRequest) bool {
+	return req.Method != "GET" && strings.Contains(req.Path, "/tables") && !strings.Contains(req.Path, "/records")
+}
+
+// InvalidateSchemaCache 清空表结构缓存，设置了 CacheDir 时同时删除当前多维表格的缓存文件
+// 在其他客户端或飞书界面修改了表结构后调用，下次请求会重新获取
+func (c *Client) InvalidateSchemaCache() {
+	if c.schemaCache != nil {
+		c.schemaCache.invalidate(c.config.AppToken)
+	}
+}
+
+// Warmup 预热客户端
+// 获取访问令牌，并将全部数据表及其字段列表加载到表结构缓存，未设置 SchemaCacheTTL 时只获取访问令牌；
+// 适合常驻服务在开始处理请求前调用，避免首批请求承担认证和表结构查询的延迟
+// 参数:
+//   - ctx: 上下文，用于控制请求超时和取消
+//
+// 返回:
+//   - error: 认证或请求失败时返回错误
+func (c *Client) Warmup(ctx context.Context) error {
+	if _, err := c.getAccessToken(ctx); err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+	if c.schemaCache == nil {
+		return nil
+	}
+
+	resp, err := c.DoRequest(ctx, &APIRequest{
+		Method: "GET",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", c.config.AppToken),
+	})
+	if err != nil {
+		return fmt.Errorf("获取表列表失败: %w", err)
+	}
+	var tables ListTablesAPIResponse
+	if err := json.Unmarshal(resp.Body, &tables); err != nil {
+		return fmt.Errorf("解析表列表失败: %w", err)
+	}
+	if tables.Code != 0 || tables.Data == nil {
+		return fmt.Errorf("获取表列表失败: code=%d, msg=%s", tables.Code, tables.Msg)
+	}
+
+	for _, table := range tables.Data.Items {
+		if _, err := c.DoRequest(ctx, &APIRequest{
+			Method: "GET",
+			Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/fields", c.config.AppToken, table.TableID),
+		}); err != nil {
+			return fmt.Errorf("获取表 '%s' 的字段列表失败: %w", table.Name, err)
+		}
+	}
+	return nil
+}
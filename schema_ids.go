@@ -0,0 +1,446 @@
+package basesql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// 请求路径中的多维表格 app_token
+var appTokenPathPattern = regexp.MustCompile(`^/bitable/v1/apps/([^/?]+)/`)
+
+// 飞书 API 表 ID 无效时的错误码
+const (
+	codeWrongTableID    = 1254004 // 表 ID 格式错误
+	codeTableIDNotFound = 1254041 // 表 ID 不存在
+)
+
+// schemaIDs 表名、字段名第一次解析到的 ID
+// 在飞书界面中给表或字段改名不会改变表 ID 和字段 ID：表名解析一次后一直使用表 ID，不再按名称查找；
+// 字段改名后按字段 ID 找到新名称，读写记录时在原名称和新名称之间转换，运行中的服务不受改名影响
+type schemaIDs struct {
+	mu       sync.RWMutex
+	tables   map[string]string            // 表名 -> 表 ID
+	replaced map[string]string            // 失效的表 ID -> 按表名重新解析到的表 ID
+	fields   map[string]map[string]string // 表 ID -> 字段名 -> 字段 ID
+	renames  map[string]map[string]string // 表 ID -> 原字段名 -> 当前字段名
+}
+
+// newSchemaIDs 创建空的 ID 记录
+func newSchemaIDs() *schemaIDs {
+	return &schemaIDs{
+		tables:   make(map[string]string),
+		replaced: make(map[string]string),
+		fields:   make(map[string]map[string]string),
+		renames:  make(map[string]map[string]string),
+	}
+}
+
+// tableID 获取表名之前解析到的表 ID
+func (s *schemaIDs) tableID(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.tables[name]
+	return id, ok
+}
+
+// rememberTable 记录表名解析到的表 ID
+func (s *schemaIDs) rememberTable(name, id string) {
+	s.mu.Lock()
+	s.tables[name] = id
+	s.mu.Unlock()
+}
+
+// tableNames 获取解析到该表 ID 的表名
+func (s *schemaIDs) tableNames(id string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string
+	for name, tableID := range s.tables {
+		if tableID == id {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// forgetTable 表删除后丢弃解析到该表 ID 的表名和字段记录
+func (s *schemaIDs) forgetTable(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, tableID := range s.tables {
+		if tableID == id {
+			delete(s.tables, name)
+		}
+	}
+	delete(s.fields, id)
+	delete(s.renames, id)
+}
+
+// replaceTable 表 ID 失效后改用按表名重新解析到的表 ID，旧表的字段记录一并丢弃
+func (s *schemaIDs) replaceTable(oldID, newID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, tableID := range s.tables {
+		if tableID == oldID {
+			s.tables[name] = newID
+		}
+	}
+	s.replaced[oldID] = newID
+	delete(s.fields, oldID)
+	delete(s.renames, oldID)
+}
+
+// replacement 获取失效的表 ID 对应的新表 ID
+func (s *schemaIDs) replacement(id string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	newID, ok := s.replaced[id]
+	return newID, ok
+}
+
+// rememberFields 记录字段名对应的字段 ID，并按字段 ID 找出改过名的字段
+// 第一次见到的字段名记录其字段 ID；之后字段名不在表中、但字段 ID 还在时，视为字段改名
+func (s *schemaIDs) rememberFields(tableID string, fields []*Field) {
+	current := make(map[string]string, len(fields)) // 字段 ID -> 当前字段名
+	for _, field := range fields {
+		if field != nil && field.FieldID != "" {
+			current[field.FieldID] = field.FieldName
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	known := s.fields[tableID]
+	if known == nil {
+		known = make(map[string]string, len(fields))
+		s.fields[tableID] = known
+	}
+	renames := make(map[string]string)
+	for name, fieldID := range known {
+		currentName, exists := current[fieldID]
+		switch {
+		case !exists:
+			// 字段已删除
+			delete(known, name)
+		case currentName != name:
+			renames[name] = currentName
+		}
+	}
+	for fieldID, name := range current {
+		if _, ok := known[name]; !ok {
+			known[name] = fieldID
+		}
+	}
+
+	for name, currentName := range renames {
+		if previous := s.renames[tableID][name]; previous != currentName {
+			common.Warnf("表 %s 的字段 '%s' 已改名为 '%s'，读写记录时自动转换", tableID, name, currentName)
+		}
+	}
+	if len(renames) == 0 {
+		delete(s.renames, tableID)
+		return
+	}
+	s.renames[tableID] = renames
+}
+
+// tracksFields 判断是否已记录过表的字段 ID，只有记录过才能发现字段改名
+func (s *schemaIDs) tracksFields(tableID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.fields[tableID]) > 0
+}
+
+// fieldRenames 获取表中改过名的字段，原字段名到当前字段名的映射，没有时为 nil
+func (s *schemaIDs) fieldRenames(tableID string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.renames[tableID]
+}
+
+// reset 清空全部记录，之后按名称重新解析
+func (s *schemaIDs) reset() {
+	s.mu.Lock()
+	s.tables = make(map[string]string)
+	s.replaced = make(map[string]string)
+	s.fields = make(map[string]map[string]string)
+	s.renames = make(map[string]map[string]string)
+	s.mu.Unlock()
+}
+
+// ResolveTableID 按表名或表 ID 获取表 ID
+// 表名第一次解析后记住对应的表 ID，之后直接使用，表在飞书界面中改名后仍能按原名称访问；
+// 使用 RefreshSchema 清空记录后重新按名称解析
+// 参数:
+//   - ctx: 上下文
+//   - nameOrID: 表名或表 ID，匹配规则见 ResolveTable
+//
+// 返回:
+//   - string: 表 ID
+//   - error: 获取表列表失败、表不存在或同名的表不止一张
+func (c *Client) ResolveTableID(ctx context.Context, nameOrID string) (string, error) {
+	return c.resolveTableID(ctx, c.config.AppToken, nameOrID)
+}
+
+// resolveTableID 按表名或表 ID 获取指定多维表格中的表 ID，优先使用之前解析到的表 ID
+func (c *Client) resolveTableID(ctx context.Context, appToken, nameOrID string) (string, error) {
+	if id, ok := c.schemaIDs.tableID(nameOrID); ok {
+		return id, nil
+	}
+	table, err := c.lookupTable(ctx, appToken, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	c.schemaIDs.rememberTable(nameOrID, table.TableID)
+	return table.TableID, nil
+}
+
+// lookupTable 获取表列表并按表名或表 ID 查找表
+func (c *Client) lookupTable(ctx context.Context, appToken, nameOrID string) (*Table, error) {
+	tables, err := c.listTables(ctx, appToken)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveTable(tables, nameOrID)
+}
+
+// listTables 获取指定多维表格的表列表
+func (c *Client) listTables(ctx context.Context, appToken string) ([]*Table, error) {
+	resp, err := c.DoRequest(ctx, &APIRequest{
+		Method: "GET",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables", appToken),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取表列表失败: %w", err)
+	}
+
+	var apiResp ListTablesAPIResponse
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return nil, fmt.Errorf("解析API响应失败: %w", err)
+	}
+	if apiResp.Code != 0 {
+		return nil, fmt.Errorf("API请求失败: code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	if apiResp.Data == nil {
+		return nil, fmt.Errorf("API响应中没有数据")
+	}
+	return apiResp.Data.GetTables(), nil
+}
+
+// resolveRateLimitTables 将分表限流配置中的表名或表 ID 解析为表 ID，供限流器匹配请求路径中的表 ID
+// 优先使用之前解析到的表 ID，其余的键通过一次表列表请求解析；不存在或同名的表不止一张时不出现在结果中
+func (c *Client) resolveRateLimitTables(ctx context.Context, keys []string) (map[string]string, error) {
+	ids := make(map[string]string, len(keys))
+	var tables []*Table
+	for _, key := range keys {
+		if id, ok := c.schemaIDs.tableID(key); ok {
+			ids[key] = id
+			continue
+		}
+		if tables == nil {
+			var err error
+			if tables, err = c.listTables(ctx, c.config.AppToken); err != nil {
+				return nil, err
+			}
+		}
+		if table, err := ResolveTable(tables, key); err == nil {
+			ids[key] = table.TableID
+		}
+	}
+	return ids, nil
+}
+
+// RefreshSchema 清空表名、字段名解析到的 ID 和表结构缓存
+// 之后的请求按名称重新解析；代码中已改用新的表名、字段名，或删除后重建了同名的表时调用
+func (c *Client) RefreshSchema() {
+	c.schemaIDs.reset()
+	c.InvalidateSchemaCache()
+}
+
+// retryMovedTable 表 ID 失效时按原表名重新解析并生成重试的请求
+// 表被删除后重建了同名的表时，之前解析到的表 ID 不再有效，请求返回表不存在；
+// 只处理按表名解析过的表 ID，直接使用表 ID 的请求不重试
+// 返回:
+//   - *APIRequest: 使用新表 ID 的请求
+//   - bool: 是否需要重试
+func (c *Client) retryMovedTable(ctx context.Context, req *APIRequest, resp *APIResponse, err error) (*APIRequest, bool) {
+	match := tableIDPathPattern.FindStringSubmatch(req.Path)
+	app := appTokenPathPattern.FindStringSubmatch(req.Path)
+	if match == nil || app == nil || !isTableNotFound(resp, err) {
+		return nil, false
+	}
+	oldID := match[1]
+
+	newID, ok := c.schemaIDs.replacement(oldID)
+	if !ok {
+		names := c.schemaIDs.tableNames(oldID)
+		if len(names) == 0 {
+			return nil, false
+		}
+		// 表列表可能来自缓存，重新获取
+		c.InvalidateSchemaCache()
+		table, lookupErr := c.lookupTable(ctx, app[1], names[0])
+		if lookupErr != nil || table.TableID == oldID {
+			return nil, false
+		}
+		newID = table.TableID
+		common.Warnf("表 '%s' 的 ID %s 已失效，改用重新解析到的 %s", names[0], oldID, newID)
+		c.schemaIDs.replaceTable(oldID, newID)
+	}
+
+	retry := *req
+	retry.Path = strings.Replace(req.Path, "/tables/"+oldID, "/tables/"+newID, 1)
+	return &retry, true
+}
+
+// isTableNotFound 判断请求是否因表 ID 不存在而失败
+func isTableNotFound(resp *APIResponse, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 404 || apiErr.Code == codeWrongTableID || apiErr.Code == codeTableIDNotFound
+	}
+	if err != nil || resp == nil || bytes.HasPrefix(resp.Body, []byte(`{"code":0`)) {
+		return false
+	}
+	var body struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(resp.Body, &body) != nil {
+		return false
+	}
+	return body.Code == codeWrongTableID || body.Code == codeTableIDNotFound
+}
+
+// renameRequestFields 将记录读写请求中改过名的字段换成当前字段名
+// 请求体中的字段值映射、过滤条件、排序和返回字段列表使用原字段名时，复制后替换，不修改调用方的请求
+func (c *Client) renameRequestFields(req *APIRequest) *APIRequest {
+	if req.Body == nil || !strings.Contains(req.Path, "/records") {
+		return req
+	}
+	match := tableIDPathPattern.FindStringSubmatch(req.Path)
+	if match == nil {
+		return req
+	}
+	renames := c.schemaIDs.fieldRenames(match[1])
+	if len(renames) == 0 {
+		return req
+	}
+
+	var body interface{}
+	switch v := req.Body.(type) {
+	case *CreateRecordRequest:
+		body = &CreateRecordRequest{Fields: renameFieldKeys(v.Fields, renames)}
+	case *UpdateRecordRequest:
+		body = &UpdateRecordRequest{Fields: renameFieldKeys(v.Fields, renames)}
+	case *BatchCreateRecordsRequest:
+		records := make([]*CreateRecordRequest, len(v.Records))
+		for i, record := range v.Records {
+			if record != nil {
+				records[i] = &CreateRecordRequest{Fields: renameFieldKeys(record.Fields, renames)}
+			}
+		}
+		body = &BatchCreateRecordsRequest{Records: records}
+	case *BatchUpdateRecordsRequest:
+		records := make([]*BatchUpdateRecord, len(v.Records))
+		for i, record := range v.Records {
+			if record != nil {
+				records[i] = &BatchUpdateRecord{RecordID: record.RecordID, Fields: renameFieldKeys(record.Fields, renames)}
+			}
+		}
+		body = &BatchUpdateRecordsRequest{Records: records}
+	case *ListRecordsRequest:
+		body = renameListRequest(v, renames)
+	default:
+		return req
+	}
+	renamed := *req
+	renamed.Body = body
+	return &renamed
+}
+
+// renameFieldKeys 复制字段值映射，原字段名换成当前字段名
+func renameFieldKeys(fields map[string]interface{}, renames map[string]string) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	renamed := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if current, ok := renames[name]; ok {
+			name = current
+		}
+		renamed[name] = value
+	}
+	return renamed
+}
+
+// renameListRequest 复制查询请求，过滤条件、排序和返回字段列表中的原字段名换成当前字段名
+func renameListRequest(req *ListRecordsRequest, renames map[string]string) *ListRecordsRequest {
+	rename := func(name string) string {
+		if current, ok := renames[name]; ok {
+			return current
+		}
+		return name
+	}
+
+	renamed := *req
+	if req.Filter != nil {
+		filter := *req.Filter
+		filter.Conditions = make([]*FilterCondition, len(req.Filter.Conditions))
+		for i, condition := range req.Filter.Conditions {
+			if condition != nil {
+				copied := *condition
+				copied.FieldName = rename(condition.FieldName)
+				condition = &copied
+			}
+			filter.Conditions[i] = condition
+		}
+		renamed.Filter = &filter
+	}
+	if len(req.Sort) > 0 {
+		renamed.Sort = make([]string, len(req.Sort))
+		for i, key := range req.Sort {
+			// 降序的排序条件以 - 开头
+			if strings.HasPrefix(key, "-") {
+				renamed.Sort[i] = "-" + rename(key[1:])
+			} else {
+				renamed.Sort[i] = rename(key)
+			}
+		}
+	}
+	if len(req.FieldNames) > 0 {
+		renamed.FieldNames = make([]string, len(req.FieldNames))
+		for i, name := range req.FieldNames {
+			renamed.FieldNames[i] = rename(name)
+		}
+	}
+	return &renamed
+}
+
+// restoreFieldNames 为读取到的记录中改过名的字段补上原字段名
+// 当前字段名保留，按原名称和新名称都能读到字段值
+func (c *Client) restoreFieldNames(tableID string, records []*Record) {
+	renames := c.schemaIDs.fieldRenames(tableID)
+	if len(renames) == 0 {
+		return
+	}
+	for _, record := range records {
+		if record == nil || record.Fields == nil {
+			continue
+		}
+		for name, current := range renames {
+			if value, ok := record.Fields[current]; ok {
+				if _, exists := record.Fields[name]; !exists {
+					record.Fields[name] = value
+				}
+			}
+		}
+	}
+}
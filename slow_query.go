@@ -0,0 +1,110 @@
+package basesql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+)
+
+// SlowQueryEvent 一条慢语句的执行详情，传给 Config.SlowQueryHook
+type SlowQueryEvent struct {
+	TraceID   string         // 语句的追踪 ID，上下文未设置时为空
+	Operation string         // 操作类型: query、row、raw、create、update、delete
+	Table     string         // 表名，原生 SQL 时为空
+	SQL       string         // 原生 SQL（参数已代入），模型操作时为空
+	Filter    string         // 读取记录时使用的飞书过滤条件（JSON），没有过滤条件时为空
+	Pages     int            // 读取的记录分页数
+	Rows      int64          // 返回或影响的行数
+	Duration  time.Duration  // 语句总耗时
+	APITime   time.Duration  // 飞书 API 请求的累计耗时（含重试与限流等待），其余为客户端处理耗时
+	APICalls  []APICallTrace // 期间发出的飞书 API 请求，按发出顺序排列
+	Err       error          // 执行错误，成功时为空
+}
+
+// APICallTrace 一次飞书 API 请求的耗时
+type APICallTrace struct {
+	Method   string        // 请求方法
+	Path     string        // 请求路径，不含 /open-apis 前缀
+	Duration time.Duration // 请求耗时，含重试
+	Err      error         // 请求错误，成功时为空
+}
+
+// recordRecordsPage 将读取的一页记录及其过滤条件记录到上下文中的请求记录器，上下文没有记录器时忽略
+func recordRecordsPage(ctx context.Context, req *ListRecordsRequest) {
+	recorder, ok := ctx.Value(apiCallRecorderCtxKey{}).(*apiCallRecorder)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.pages++
+	if recorder.filter == "" && req != nil && req.Filter != nil {
+		if data, err := json.Marshal(req.Filter); err == nil {
+			recorder.filter = string(data)
+		}
+	}
+}
+
+// reportSlowQuery 语句耗时超过 Config.SlowQueryThreshold 时记录警告日志并调用 Config.SlowQueryHook
+// 参数:
+//   - db: GORM 数据库实例
+//   - config: 方言器的配置
+//   - recorder: 语句的请求记录器
+//   - elapsed: 语句耗时
+//   - err: 执行错误
+func reportSlowQuery(db *gorm.DB, config *Config, recorder *apiCallRecorder, elapsed time.Duration, err error) {
+	if config == nil || config.SlowQueryThreshold <= 0 || elapsed <= config.SlowQueryThreshold {
+		return
+	}
+
+	ctx := statementContext(db)
+	event := SlowQueryEvent{
+		TraceID:   TraceIDFromContext(ctx),
+		Operation: recorder.operation,
+		Table:     recorder.table,
+		Rows:      db.RowsAffected,
+		Duration:  elapsed,
+		Err:       err,
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		event.SQL = db.Dialector.Explain(sql, db.Statement.Vars...)
+	}
+
+	recorder.mu.Lock()
+	event.Filter = recorder.filter
+	event.Pages = recorder.pages
+	calls := make([]string, len(recorder.calls))
+	for i, call := range recorder.calls {
+		event.APICalls = append(event.APICalls, APICallTrace{Method: call.method, Path: call.path, Duration: call.duration, Err: call.err})
+		event.APITime += call.duration
+		calls[i] = call.String()
+	}
+	recorder.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"operation":         event.Operation,
+		"duration_ms":       float64(elapsed) / float64(time.Millisecond),
+		"api_time_ms":       float64(event.APITime) / float64(time.Millisecond),
+		"slow_threshold_ms": float64(config.SlowQueryThreshold) / float64(time.Millisecond),
+		"pages":             event.Pages,
+		"rows":              event.Rows,
+		"api_calls":         calls,
+	}
+	if event.Table != "" {
+		fields["table"] = event.Table
+	}
+	if event.SQL != "" {
+		fields["sql"] = event.SQL
+	}
+	if event.Filter != "" {
+		fields["filter"] = event.Filter
+	}
+	common.DefaultLogger.WithContext(ctx).WithFields(fields).Warn("慢查询")
+
+	if config.SlowQueryHook != nil {
+		config.SlowQueryHook(event)
+	}
+}
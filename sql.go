@@ -76,23 +76,11 @@ func (c *SQLConverter) ConvertCreate(ctx context.Context, stmt *gorm.Statement)
 	}
 
 	// 获取字段值并进行类型转换
-	fields := make(map[string]interface{})
-	for _, field := range stmt.Schema.Fields {
-		value, ok := field.ValueOf(ctx, stmt.ReflectValue)
-		if !ok || value == nil {
-			continue
-		}
+	fields := modelFieldValues(ctx, stmt.Schema, stmt.ReflectValue, fieldMap)
 
-		// 使用字段的转换方法进行类型转换
-		if tableField, exists := fieldMap[field.DBName]; exists {
-			convertedValue := tableField.ConvertFromGoValue(value)
-			if convertedValue != nil {
-				fields[field.DBName] = convertedValue
-			}
-		} else {
-			// 如果找不到字段信息，直接使用原值（向后兼容）
-			fields[field.DBName] = value
-		}
+	// 检查校验规则，缺少必填字段时不调用 API
+	if err := dialector.validateWrite(tableName, stmt.Schema, fieldMap, fields, false); err != nil {
+		return err
 	}
 
 	// 检查是否有字段需要创建
@@ -329,7 +317,7 @@ func (c *SQLConverter) ConvertDelete(ctx context.Context, stmt *gorm.Statement)
 }
 
 // buildFilter 构建过滤条件，将 GORM 的 WHERE 条件转换为飞书多维表格的过滤条件
-// 支持的操作符：=、!=、>、>=、<、<=、LIKE
+// 支持的操作符：=、!=、>、>=、<、<=、LIKE、ILIKE，LIKE、ILIKE 以及不区分大小写的等于的下推方式见 likeMatcher
 // 参数:
 //   - exprs: GORM 的条件表达式列表
 //
@@ -401,6 +389,11 @@ func (c *SQLConverter) buildEqualCondition(eq clause.Eq) *FilterCondition {
 		return nil
 	}
 
+	// 不区分大小写时字符串的等于条件按 exprMatcher 下推
+	if m := exprMatcher(eq, c.config.caseInsensitive()); m != nil {
+		return m.pushdown
+	}
+
 	// 对于布尔值，使用实际的布尔值而不是字符串
 	var value []interface{}
 	if b, ok := eq.Value.(bool); ok {
@@ -493,16 +486,22 @@ func (c *SQLConverter) buildLessEqualCondition(lte clause.Lte) *FilterCondition
 }
 
 // buildLikeCondition 构建模糊匹配条件
+// 按通配符位置下推，服务端条件与 LIKE 语义不一致时由 residuals 在客户端补充校验
 func (c *SQLConverter) buildLikeCondition(like clause.Like) *FilterCondition {
-	column, ok := like.Column.(clause.Column)
-	if !ok || column.Name == "" {
-		return nil
-	}
-	return &FilterCondition{
-		FieldName: column.Name,
-		Operator:  "contains",
-		Value:     []interface{}{like.Value},
+	if m := exprMatcher(like, c.config.caseInsensitive()); m != nil {
+		return m.pushdown
 	}
+	return nil
+}
+
+// residuals 收集无法完全下推、需要在客户端校验的字符串匹配条件
+// 参数:
+//   - exprs: GORM 的条件表达式列表
+//
+// 返回:
+//   - []*likeMatcher: 需要客户端校验的条件
+func (c *SQLConverter) residuals(exprs []clause.Expression) []*likeMatcher {
+	return likeResiduals(exprs, c.config.caseInsensitive())
 }
 
 // buildInCondition 构建IN条件
@@ -563,13 +562,17 @@ func (c *SQLConverter) buildExprCondition(expr clause.Expr) *FilterCondition {
 		}
 	}
 
-	// 对于其他操作符，需要参数
+	// 对于其他操作符，需要参数；日期宏和相对时间条件的值直接写在 SQL 中
 	if len(vars) == 0 {
-		return nil
+		return buildDateCondition(sql)
 	}
 
 	// 处理等于操作
 	if strings.Contains(sql, " = ?") {
+		// 不区分大小写时字符串的等于条件按 exprMatcher 下推
+		if m := exprMatcher(expr, c.config.caseInsensitive()); m != nil {
+			return m.pushdown
+		}
 		fieldName = strings.TrimSpace(strings.Split(sql, " = ?")[0])
 		operator = "is"
 	} else if strings.Contains(sql, " != ?") || strings.Contains(sql, " <> ?") {
@@ -590,12 +593,12 @@ func (c *SQLConverter) buildExprCondition(expr clause.Expr) *FilterCondition {
 	} else if strings.Contains(sql, " <= ?") {
 		fieldName = strings.TrimSpace(strings.Split(sql, " <= ?")[0])
 		operator = "isLessEqual"
-	} else if strings.Contains(sql, " LIKE ?") || strings.Contains(sql, " like ?") {
-		fieldName = strings.TrimSpace(strings.Split(sql, " LIKE ?")[0])
-		if fieldName == "" {
-			fieldName = strings.TrimSpace(strings.Split(sql, " like ?")[0])
+	} else if strings.Contains(sql, " LIKE ?") || strings.Contains(sql, " like ?") ||
+		strings.Contains(sql, " ILIKE ?") || strings.Contains(sql, " ilike ?") {
+		if m := exprMatcher(expr, c.config.caseInsensitive()); m != nil {
+			return m.pushdown
 		}
-		operator = "contains"
+		return nil
 	} else {
 		// 不支持的操作符
 		return nil
@@ -0,0 +1,100 @@
+package basesql
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+)
+
+// RequestStats API 请求统计信息
+// 客户端从创建开始累计，调用方可以在操作前后各取一次快照，用 Sub 计算单次操作的开销
+type RequestStats struct {
+	APICalls          int64         `json:"api_calls"`            // 发出的 HTTP 请求数（包含重试与令牌请求）
+	BytesSent         int64         `json:"bytes_sent"`           // 请求体总字节数
+	BytesReceived     int64         `json:"bytes_received"`       // 响应体总字节数
+	CacheHits         int64         `json:"cache_hits"`           // 命中缓存的次数（复用访问令牌或表结构缓存，无需请求接口）
+	Retries           int64         `json:"retries"`              // 重试次数
+	RateLimitWaits    int64         `json:"rate_limit_waits"`     // 因限流等待的次数
+	RateLimitWaitTime time.Duration `json:"rate_limit_wait_time"` // 因限流等待的总时长
+}
+
+// Sub 计算两次快照之间的差值
+// 参数:
+//   - before: 较早的快照
+//
+// 返回:
+//   - RequestStats: 两次快照之间的增量
+func (s RequestStats) Sub(before RequestStats) RequestStats {
+	return RequestStats{
+		APICalls:          s.APICalls - before.APICalls,
+		BytesSent:         s.BytesSent - before.BytesSent,
+		BytesReceived:     s.BytesReceived - before.BytesReceived,
+		CacheHits:         s.CacheHits - before.CacheHits,
+		Retries:           s.Retries - before.Retries,
+		RateLimitWaits:    s.RateLimitWaits - before.RateLimitWaits,
+		RateLimitWaitTime: s.RateLimitWaitTime - before.RateLimitWaitTime,
+	}
+}
+
+// requestCounters 请求统计计数器，所有字段通过原子操作更新
+type requestCounters struct {
+	apiCalls          int64
+	bytesSent         int64
+	bytesReceived     int64
+	cacheHits         int64
+	retries           int64
+	rateLimitWaits    int64
+	rateLimitWaitTime int64 // 纳秒
+}
+
+// recordCall 记录一次 HTTP 请求及其收发字节数
+func (rc *requestCounters) recordCall(sent, received int) {
+	atomic.AddInt64(&rc.apiCalls, 1)
+	atomic.AddInt64(&rc.bytesSent, int64(sent))
+	atomic.AddInt64(&rc.bytesReceived, int64(received))
+}
+
+// recordCacheHit 记录一次缓存命中
+func (rc *requestCounters) recordCacheHit() {
+	atomic.AddInt64(&rc.cacheHits, 1)
+}
+
+// recordRetry 记录一次重试，若上次失败由限流引起则同时记录限流等待
+func (rc *requestCounters) recordRetry(lastErr error, delay time.Duration) {
+	atomic.AddInt64(&rc.retries, 1)
+	if isRateLimitError(lastErr) {
+		atomic.AddInt64(&rc.rateLimitWaits, 1)
+		atomic.AddInt64(&rc.rateLimitWaitTime, int64(delay))
+	}
+}
+
+// snapshot 获取计数器的当前快照
+func (rc *requestCounters) snapshot() RequestStats {
+	return RequestStats{
+		APICalls:          atomic.LoadInt64(&rc.apiCalls),
+		BytesSent:         atomic.LoadInt64(&rc.bytesSent),
+		BytesReceived:     atomic.LoadInt64(&rc.bytesReceived),
+		CacheHits:         atomic.LoadInt64(&rc.cacheHits),
+		Retries:           atomic.LoadInt64(&rc.retries),
+		RateLimitWaits:    atomic.LoadInt64(&rc.rateLimitWaits),
+		RateLimitWaitTime: time.Duration(atomic.LoadInt64(&rc.rateLimitWaitTime)),
+	}
+}
+
+// isRateLimitError 判断错误是否由限流引起（本地限流器或服务端 429）
+func isRateLimitError(err error) bool {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 429 || apiErr.Type == "rate_limit"
+	}
+	return false
+}
+
+// RequestStats 获取客户端创建以来累计的请求统计信息
+// 返回:
+//   - RequestStats: 统计信息快照
+func (c *Client) RequestStats() RequestStats {
+	return c.counters.snapshot()
+}
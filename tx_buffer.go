@@ -0,0 +1,154 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// txCreate 事务中缓冲的一次创建
+type txCreate struct {
+	db      *gorm.DB               // 执行创建的语句，提交时用于设置主键和重新读取记录
+	tableID string                 // 表 ID
+	fields  map[string]interface{} // 已转换、已校验的字段值
+	target  reflect.Value          // 模型结构体，提交后设置记录 ID，不可设置时为零值
+}
+
+// txCreateGroup 同一张表、同一个模型的缓冲创建
+type txCreateGroup struct {
+	tableID string
+	schema  *schema.Schema
+}
+
+// bufferCreate 开启 Config.TxWriteBuffer 时将事务中的创建缓冲到提交时批量写入
+// 不在事务中、未开启缓冲时返回 false，由调用方立即写入；
+// 立即写入前先提交已缓冲的创建，保持写入顺序
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - fields: 已转换、已校验的字段值
+//
+// 返回:
+//   - bool: 是否已缓冲
+//   - error: 提交已缓冲的创建时的错误
+func bufferCreate(db *gorm.DB, dialector *Dialector, tableID string, fields map[string]interface{}) (bool, error) {
+	tx, ok := db.Statement.ConnPool.(*Transaction)
+	if !ok {
+		return false, nil
+	}
+	if !dialector.Config.TxWriteBuffer {
+		return false, tx.flush()
+	}
+
+	target, _ := writeTarget(db.Statement)
+	tx.mu.Lock()
+	tx.pending = append(tx.pending, &txCreate{db: db, tableID: tableID, fields: fields, target: target})
+	tx.mu.Unlock()
+	db.RowsAffected = 1
+	return true, nil
+}
+
+// flushTxCreates 语句在事务中执行时先提交已缓冲的创建，使后续的查询、更新和删除能看到这些记录
+func flushTxCreates(db *gorm.DB) error {
+	if tx, ok := db.Statement.ConnPool.(*Transaction); ok {
+		return tx.flush()
+	}
+	return nil
+}
+
+// flush 按表分组批量写入已缓冲的创建，每批最多 500 条，一批内的记录同时写入成功或失败
+// 写入后设置各模型的主键；某一批失败时，之前的批次已经写入，剩余的创建被丢弃
+// 返回:
+//   - error: 写入错误，包含已写入和未写入的记录数
+func (tx *Transaction) flush() error {
+	tx.mu.Lock()
+	pending := tx.pending
+	tx.pending = nil
+	tx.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// 按表和模型分组，保持每组第一次出现的顺序
+	var order []txCreateGroup
+	groups := make(map[txCreateGroup][]*txCreate)
+	for _, create := range pending {
+		key := txCreateGroup{tableID: create.tableID, schema: create.db.Statement.Schema}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], create)
+	}
+
+	dialector := tx.connPool.Dialector
+	written := 0
+	for _, key := range order {
+		creates := groups[key]
+		for start := 0; start < len(creates); start += common.MaxBatchSize {
+			batch := creates[start:min(start+common.MaxBatchSize, len(creates))]
+			if err := flushTxBatch(dialector, key.tableID, batch); err != nil {
+				return fmt.Errorf("提交事务中缓冲的创建失败，已写入 %d 条，未写入 %d 条: %w", written, len(pending)-written, err)
+			}
+			written += len(batch)
+		}
+	}
+	common.Debugf("Transaction.Commit: 批量写入事务中缓冲的 %d 条创建", written)
+	return nil
+}
+
+// flushTxBatch 通过一次 batch_create 写入一批缓冲的创建，并设置模型的主键
+func flushTxBatch(dialector *Dialector, tableID string, batch []*txCreate) error {
+	first := batch[0].db
+	ctx, cancel := context.WithTimeout(statementContext(first), 30*time.Second)
+	defer cancel()
+
+	req := &BatchCreateRecordsRequest{Records: make([]*CreateRecordRequest, 0, len(batch))}
+	for _, create := range batch {
+		req.Records = append(req.Records, &CreateRecordRequest{Fields: create.fields})
+	}
+	resp, err := dialector.api().DoRequest(ctx, &APIRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/bitable/v1/apps/%s/tables/%s/records/batch_create", dialector.Config.AppToken, tableID),
+		Body:   req,
+	})
+	if err != nil {
+		return fmt.Errorf("批量创建记录失败: %w", err)
+	}
+	var created BatchCreateRecordsResponse
+	if err := repoCheckResponse(resp.Body, &created); err != nil {
+		return fmt.Errorf("批量创建记录失败: %w", err)
+	}
+
+	targets := make(map[string]reflect.Value, len(batch))
+	for i, create := range batch {
+		if i >= len(created.Records) || created.Records[i] == nil || !create.target.IsValid() {
+			continue
+		}
+		recordID := created.Records[i].RecordID
+		if primary := create.db.Statement.Schema.PrioritizedPrimaryField; primary != nil {
+			if err := primary.Set(ctx, create.target, recordID); err != nil {
+				return fmt.Errorf("设置主键值失败: %w", err)
+			}
+		}
+		targets[recordID] = create.target
+	}
+	returnRecords(first, dialector, tableID, targets)
+	return nil
+}
+
+// discard 丢弃已缓冲的创建
+// 返回:
+//   - int: 丢弃的创建数
+func (tx *Transaction) discard() int {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	n := len(tx.pending)
+	tx.pending = nil
+	return n
+}
@@ -0,0 +1,66 @@
+package basesql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm/schema"
+)
+
+// basesqlTagName 结构体标签名，如 `basesql:"unique"`
+const basesqlTagName = "basesql"
+
+// hasTagOption 判断字段的 basesql 标签是否包含指定选项
+// 标签中的多个选项以逗号分隔，如 `basesql:"unique,required"`
+func hasTagOption(field *schema.Field, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get(basesqlTagName), ",") {
+		if strings.EqualFold(strings.TrimSpace(opt), option) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUniqueFields 写入前检查声明为 unique 的字段是否已存在相同的值
+// 多维表格没有唯一约束，这里通过写入前搜索模拟；检查与写入之间不是原子的，
+// 并发写入同一值时仍可能产生重复，可以使用 `basesql dedupe` 命令清理
+// 参数:
+//   - ctx: 上下文
+//   - dialector: BaseSQL 方言实例
+//   - tableID: 表 ID
+//   - sch: 模型结构
+//   - values: 即将写入的字段值，key 为字段名
+//
+// 返回:
+//   - error: 存在重复值时返回包装 ErrUniqueViolation 的错误
+func checkUniqueFields(ctx context.Context, dialector *Dialector, tableID string, sch *schema.Schema, values map[string]interface{}) error {
+	for _, field := range sch.Fields {
+		// 业务主键同样不允许重复
+		if !hasTagOption(field, "unique") && !hasTagOption(field, businessKeyTagOption) {
+			continue
+		}
+		value := common.FormatValue(values[field.DBName])
+		if value == "" {
+			continue
+		}
+
+		resp, err := searchRecords(ctx, dialector, tableID, &ListRecordsRequest{
+			Filter: &FilterRequest{
+				Conjunction: "and",
+				Conditions: []*FilterCondition{
+					{FieldName: field.DBName, Operator: "is", Value: []interface{}{value}},
+				},
+			},
+			PageSize: 1,
+		})
+		if err != nil {
+			return fmt.Errorf("检查字段 '%s' 唯一性失败: %w", field.DBName, err)
+		}
+		if len(resp.Items) > 0 {
+			return fmt.Errorf("%w: 字段 '%s' 的值 '%s' 已存在于记录 %s", ErrUniqueViolation, field.DBName, value, resp.Items[0].RecordID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package basesql
+
+import (
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// urlDataType Link 字段在 GORM 中的数据类型，迁移和自动建表时创建超链接字段
+const urlDataType schema.DataType = "url"
+
+// urlLinkType Link 的反射类型
+var urlLinkType = reflect.TypeOf(Link{})
+
+// Link 超链接字段的值
+// 飞书的超链接字段同时保存显示文本和链接地址，读取时返回 {"text": ..., "link": ...} 对象，写入时也需要这种对象；
+// 模型中使用 string 类型只能读到显示文本，使用 Link 或 *Link 类型可以完整读写两者
+type Link struct {
+	Text string `json:"text"` // 显示文本
+	Link string `json:"link"` // 链接地址
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface，使 Link 可以直接作为模型字段
+func (Link) GormDataType() string {
+	return string(urlDataType)
+}
+
+// String 返回链接的显示形式，显示文本与链接地址不同时为 "文本 <链接>"，否则为链接地址
+func (l Link) String() string {
+	if l.Text == "" || l.Text == l.Link {
+		return l.Link
+	}
+	if l.Link == "" {
+		return l.Text
+	}
+	return l.Text + " <" + l.Link + ">"
+}
+
+// ParseLink 将超链接字段的值解析为 Link
+// 支持飞书返回的 {"text": ..., "link": ...} 对象及其数组，字符串视为显示文本与链接地址相同的链接
+// 参数:
+//   - value: 字段值
+//
+// 返回:
+//   - Link: 解析结果
+//   - bool: 值是否为超链接
+func ParseLink(value interface{}) (Link, bool) {
+	switch v := value.(type) {
+	case Link:
+		return v, true
+	case *Link:
+		if v == nil {
+			return Link{}, false
+		}
+		return *v, true
+	case string:
+		if v == "" {
+			return Link{}, false
+		}
+		return Link{Text: v, Link: v}, true
+	case map[string]interface{}:
+		text, _ := v["text"].(string)
+		link, _ := v["link"].(string)
+		if text == "" && link == "" {
+			return Link{}, false
+		}
+		return Link{Text: text, Link: link}, true
+	case []interface{}:
+		if len(v) > 0 {
+			return ParseLink(v[0])
+		}
+	}
+	return Link{}, false
+}
+
+// urlLinkValue 将记录中的值转换为 Link，扫描 Link 类型的模型字段时使用，不是超链接时返回 nil
+func urlLinkValue(value interface{}) interface{} {
+	if link, ok := ParseLink(value); ok {
+		return link
+	}
+	return nil
+}
+
+// convertFromURL 将值转换为超链接字段的写入格式 {"text": ..., "link": ...}
+// 只有链接地址时显示文本与链接地址相同
+func (f *Field) convertFromURL(value interface{}) interface{} {
+	link, ok := ParseLink(value)
+	if !ok {
+		return nil
+	}
+	if link.Text == "" {
+		link.Text = link.Link
+	}
+	return map[string]interface{}{"text": link.Text, "link": link.Link}
+}
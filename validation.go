@@ -0,0 +1,306 @@
+package basesql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm/schema"
+)
+
+// ErrValidationFailed 写入的数据不满足校验规则
+var ErrValidationFailed = errors.New("basesql: validation failed")
+
+// ValidationRule 字段校验规则
+// 可以在 Config.ValidationRules 中按表声明，也可以通过结构体标签声明，如
+// `basesql:"required,min=0,max=150,enum=男|女,pattern=^\\d+$"`（标签值中的反斜杠需要转义）
+// 标签中 pattern 必须放在最后，其后的内容（包括逗号）都视为正则表达式
+type ValidationRule struct {
+	Field    string   `json:"field" yaml:"field"`                           // 字段名
+	Required bool     `json:"required,omitempty" yaml:"required,omitempty"` // 是否必填
+	Pattern  string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`   // 值需要匹配的正则表达式
+	Min      *float64 `json:"min,omitempty" yaml:"min,omitempty"`           // 数值下限（包含）
+	Max      *float64 `json:"max,omitempty" yaml:"max,omitempty"`           // 数值上限（包含）
+	Enum     []string `json:"enum,omitempty" yaml:"enum,omitempty"`         // 允许的取值，多值字段的每个值都需要在其中
+}
+
+// ValidationError 单个字段的校验失败信息
+type ValidationError struct {
+	Field   string `json:"field"`   // 字段名
+	Rule    string `json:"rule"`    // 未通过的规则：required、pattern、min、max、enum、type（min/max 字段的值不是数字）
+	Message string `json:"message"` // 失败原因
+}
+
+// ValidationErrors 一次校验中的全部失败信息
+type ValidationErrors []ValidationError
+
+// Error 实现 error 接口
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ve := range e {
+		msgs[i] = fmt.Sprintf("%s: %s", ve.Field, ve.Message)
+	}
+	return fmt.Sprintf("%v: %s", ErrValidationFailed, strings.Join(msgs, "; "))
+}
+
+// Is 使 errors.Is(err, ErrValidationFailed) 成立
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrValidationFailed
+}
+
+// patternCache 已编译的正则表达式缓存
+var patternCache sync.Map
+
+// compilePattern 编译并缓存正则表达式
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// ValidateRecord 按规则校验一条记录的字段值
+// 参数:
+//   - rules: 校验规则
+//   - values: 字段值，key 为字段名
+//   - partial: 是否为部分更新；部分更新时只校验出现的字段，缺失的必填字段不报错
+//
+// 返回:
+//   - error: 校验失败时返回 ValidationErrors，规则本身无效时返回普通错误
+func ValidateRecord(rules []ValidationRule, values map[string]interface{}, partial bool) error {
+	var errs ValidationErrors
+	for _, rule := range rules {
+		value, present := values[rule.Field]
+		text := common.FormatValue(value)
+
+		if text == "" {
+			// 多维表格不强制必填，缺失和空值都在写入前拒绝，分别提示便于定位
+			switch {
+			case !rule.Required:
+			case present:
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "required", Message: "不能为空"})
+			case !partial:
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "required", Message: "缺少必填字段"})
+			}
+			continue
+		}
+
+		if rule.Pattern != "" {
+			re, err := compilePattern(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("字段 '%s' 的正则表达式无效: %w", rule.Field, err)
+			}
+			if !re.MatchString(text) {
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "pattern", Message: fmt.Sprintf("值 '%s' 不匹配 %s", text, rule.Pattern)})
+			}
+		}
+
+		if rule.Min != nil || rule.Max != nil {
+			n, ok := validationNumber(value)
+			switch {
+			case !ok:
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "type", Message: fmt.Sprintf("值 '%s' 不是数字", text)})
+			case rule.Min != nil && n < *rule.Min:
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "min", Message: fmt.Sprintf("值 %v 小于最小值 %v", n, *rule.Min)})
+			case rule.Max != nil && n > *rule.Max:
+				errs = append(errs, ValidationError{Field: rule.Field, Rule: "max", Message: fmt.Sprintf("值 %v 大于最大值 %v", n, *rule.Max)})
+			}
+		}
+
+		if len(rule.Enum) > 0 {
+			for _, item := range enumValues(value) {
+				if !containsAny(rule.Enum, []string{item}) {
+					errs = append(errs, ValidationError{Field: rule.Field, Rule: "enum", Message: fmt.Sprintf("值 '%s' 不在允许的取值 [%s] 中", item, strings.Join(rule.Enum, ", "))})
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validationNumber 将字段值转换为数字
+func validationNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	n, err := strconv.ParseFloat(common.FormatValue(value), 64)
+	return n, err == nil
+}
+
+// enumValues 获取用于枚举校验的值列表，多选字段返回每个选项
+func enumValues(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, common.FormatValue(item))
+		}
+		return items
+	}
+	return []string{common.FormatValue(value)}
+}
+
+// RulesFromSchema 从模型的 basesql 结构体标签中解析校验规则
+// 参数:
+//   - sch: 模型结构
+//
+// 返回:
+//   - []ValidationRule: 校验规则
+//   - error: 标签格式错误
+func RulesFromSchema(sch *schema.Schema) ([]ValidationRule, error) {
+	var rules []ValidationRule
+	for _, field := range sch.Fields {
+		tag := field.Tag.Get(basesqlTagName)
+		if tag == "" {
+			continue
+		}
+		rule, err := parseRuleTag(field.DBName, tag)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 的 basesql 标签无效: %w", field.Name, err)
+		}
+		if rule != nil {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules, nil
+}
+
+// parseRuleTag 解析单个字段的 basesql 标签，标签中没有校验规则时返回 nil
+func parseRuleTag(fieldName, tag string) (*ValidationRule, error) {
+	rule := &ValidationRule{Field: fieldName}
+	hasRule := false
+
+	rest := tag
+	for rest != "" {
+		var opt string
+		if strings.HasPrefix(strings.TrimSpace(rest), "pattern=") {
+			opt, rest = strings.TrimSpace(rest), ""
+		} else if i := strings.Index(rest, ","); i >= 0 {
+			opt, rest = strings.TrimSpace(rest[:i]), rest[i+1:]
+		} else {
+			opt, rest = strings.TrimSpace(rest), ""
+		}
+
+		key, value, _ := strings.Cut(opt, "=")
+		switch strings.ToLower(key) {
+		case "required":
+			rule.Required = true
+		case "pattern":
+			if _, err := compilePattern(value); err != nil {
+				return nil, err
+			}
+			rule.Pattern = value
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s 的值 '%s' 不是数字", key, value)
+			}
+			if strings.ToLower(key) == "min" {
+				rule.Min = &n
+			} else {
+				rule.Max = &n
+			}
+		case "enum":
+			rule.Enum = strings.Split(value, "|")
+		default:
+			// unique 等其他选项不是校验规则
+			continue
+		}
+		hasRule = true
+	}
+
+	if !hasRule {
+		return nil, nil
+	}
+	return rule, nil
+}
+
+// validationRules 获取表的校验规则：配置中按表声明的规则在前，模型标签中的规则在后
+func (d *Dialector) validationRules(table string, sch *schema.Schema) ([]ValidationRule, error) {
+	rules := append([]ValidationRule(nil), d.Config.ValidationRules[table]...)
+	if sch != nil {
+		tagRules, err := RulesFromSchema(sch)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, tagRules...)
+	}
+	return rules, nil
+}
+
+// validateWrite 写入前按校验规则检查字段值
+// 除配置和标签中的规则外，还按表字段属性检查进度、评分字段的取值范围，见 Field.ValueRange
+// 参数:
+//   - table: 表名
+//   - sch: 模型结构，为 nil 时不使用标签中的规则
+//   - tableFields: 表字段，键为字段名，获取失败时为 nil
+//   - values: 转换后的字段值，键为字段名
+//   - partial: 是否为部分更新
+//
+// 返回:
+//   - error: 校验失败时返回 ValidationErrors
+func (d *Dialector) validateWrite(table string, sch *schema.Schema, tableFields map[string]*Field, values map[string]interface{}, partial bool) error {
+	rules, err := d.validationRules(table, sch)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, rangeRules(tableFields, values)...)
+	if len(rules) == 0 {
+		return nil
+	}
+	return ValidateRecord(rules, values, partial)
+}
+
+// rangeRules 按表字段的取值范围生成校验规则，只包含本次写入的字段
+// 飞书对超出范围的进度、评分值不一定返回错误，写入前检查
+func rangeRules(tableFields map[string]*Field, values map[string]interface{}) []ValidationRule {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []ValidationRule
+	for _, name := range names {
+		field, ok := tableFields[name]
+		if !ok {
+			continue
+		}
+		if lower, upper, ok := field.ValueRange(); ok {
+			rules = append(rules, ValidationRule{Field: name, Min: &lower, Max: &upper})
+		}
+	}
+	return rules
+}
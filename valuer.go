@@ -0,0 +1,150 @@
+package basesql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+var (
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// customGoValue 将自定义类型的值转换为基础类型，再按字段类型转换
+// 实现 driver.Valuer 的值（如 decimal.Decimal、uuid.UUID）使用 Value 的结果，[]byte 视为文本；
+// 其次实现 encoding.TextMarshaler 的值使用其文本；math/big 的数字转换为十进制文本；time.Time 按日期处理，不在此列
+// 参数:
+//   - value: Go 语言类型的值
+//
+// 返回:
+//   - interface{}: 转换后的值，nil 指针返回 nil，转换失败时返回原值
+func customGoValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time, *time.Time:
+		return value
+	case big.Rat:
+		return ratText(&v)
+	case big.Float:
+		return v.Text('f', -1)
+	case big.Int:
+		return v.String()
+	case *big.Rat:
+		// TextMarshaler 的结果是分数形式，如 "1/3"，转换为十进制文本
+		if v == nil {
+			return nil
+		}
+		return ratText(v)
+	case *big.Float:
+		if v == nil {
+			return nil
+		}
+		return v.Text('f', -1)
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case driver.Valuer:
+		if isNilPointer(value) {
+			return nil
+		}
+		converted, err := v.Value()
+		if err != nil {
+			return value
+		}
+		if data, ok := converted.([]byte); ok {
+			return string(data)
+		}
+		return converted
+	case encoding.TextMarshaler:
+		if isNilPointer(value) {
+			return nil
+		}
+		text, err := v.MarshalText()
+		if err != nil {
+			return value
+		}
+		return string(text)
+	}
+	return value
+}
+
+// isNilPointer 判断值是否为 nil 指针
+func isNilPointer(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// isCustomScanField 判断模型字段的类型是否实现 sql.Scanner 或 encoding.TextUnmarshaler
+// 这类字段读取时由类型自己解析表中的值，time.Time 和 gorm serializer 字段除外
+func isCustomScanField(field *schema.Field) bool {
+	if field.Serializer != nil || field.IndirectFieldType == timeType {
+		return false
+	}
+	ptr := reflect.PtrTo(field.IndirectFieldType)
+	return ptr.Implements(scannerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// setCustomValue 由字段类型的 Scan 或 UnmarshalText 解析值后设置到结构体
+// 参数:
+//   - ctx: 上下文
+//   - structValue: 模型结构体的值，必须可设置
+//   - field: 模型字段，见 isCustomScanField
+//   - value: 按表字段类型转换后的值
+//
+// 返回:
+//   - error: 解析或设置失败
+func setCustomValue(ctx context.Context, structValue reflect.Value, field *schema.Field, value interface{}) error {
+	target := reflect.New(field.IndirectFieldType)
+	switch t := target.Interface().(type) {
+	case sql.Scanner:
+		if err := t.Scan(driverValue(value)); err != nil {
+			return fmt.Errorf("解析字段 %s 失败: %w", field.DBName, err)
+		}
+	case encoding.TextUnmarshaler:
+		text, ok := value.(string)
+		if !ok {
+			text = fmt.Sprintf("%v", value)
+		}
+		if err := t.UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("解析字段 %s 失败: %w", field.DBName, err)
+		}
+	}
+	if field.FieldType.Kind() != reflect.Ptr {
+		target = target.Elem()
+	}
+	if err := field.Set(ctx, structValue, target.Interface()); err != nil {
+		return fmt.Errorf("设置字段 %s 失败: %w", field.DBName, err)
+	}
+	return nil
+}
+
+// driverValue 将值转换为 sql.Scanner 能够处理的 driver.Value 类型
+// 整数转为 int64，json.Number 转为文本，数组和对象编码为 JSON 文本
+func driverValue(value interface{}) driver.Value {
+	switch v := value.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return v
+	case int:
+		return int64(v)
+	case json.Number:
+		return v.String()
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,83 @@
+package basesql
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ag9920/basesql/internal/common"
+	"gorm.io/gorm"
+)
+
+// returnRecords 开启 Config.ReturnRecordAfterWrite 时重新读取写入的记录并设置到模型
+// 写入接口的响应不一定包含公式、修改时间等服务端计算的字段，因此按记录 ID 批量读取最新的记录；
+// 记录已经写入，读取失败只输出警告，不作为写入失败返回
+// 参数:
+//   - db: GORM 数据库实例
+//   - dialector: BaseSQL 的方言器实例
+//   - tableID: 表 ID
+//   - targets: 记录 ID 到模型结构体的映射，结构体必须可设置
+func returnRecords(db *gorm.DB, dialector *Dialector, tableID string, targets map[string]reflect.Value) {
+	if !dialector.Config.ReturnRecordAfterWrite || len(targets) == 0 {
+		return
+	}
+	logger := common.DefaultLogger.WithContext(statementContext(db))
+
+	recordIDs := make([]string, 0, len(targets))
+	for recordID := range targets {
+		recordIDs = append(recordIDs, recordID)
+	}
+	sort.Strings(recordIDs)
+	records, err := batchGetRecords(statementContext(db), dialector, tableID, recordIDs)
+	if err != nil {
+		logger.Warnf("记录已写入，重新读取表 %s 的记录失败: %v", db.Statement.Table, err)
+		return
+	}
+
+	scanner, err := newRecordScanner(db.Statement.Schema, dialector)
+	if err != nil {
+		logger.Warnf("记录已写入，设置表 %s 的记录失败: %v", db.Statement.Table, err)
+		return
+	}
+	for _, record := range records {
+		target, ok := targets[record.RecordID]
+		if !ok {
+			continue
+		}
+		if err := scanner.scan(target, record); err != nil {
+			logger.Warnf("记录已写入，设置记录 %s 失败: %v", record.RecordID, err)
+		}
+	}
+}
+
+// writeTarget 获取单条写入对应的模型结构体，没有可设置的结构体时返回 false
+// Update 传入映射时 ReflectValue 是映射本身，此时从 Model 中获取
+func writeTarget(stmt *gorm.Statement) (reflect.Value, bool) {
+	for _, rv := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Model)} {
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct && rv.CanSet() {
+			return rv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// sliceWriteTargets 获取模型切片中带主键的元素，键为记录 ID
+func sliceWriteTargets(stmt *gorm.Statement) map[string]reflect.Value {
+	rv, ok := modelSlice(stmt)
+	if !ok || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	targets := make(map[string]reflect.Value, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elem := reflect.Indirect(rv.Index(i))
+		if elem.Kind() != reflect.Struct || !elem.CanSet() {
+			continue
+		}
+		if value, isZero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, elem); !isZero {
+			targets[common.FormatValue(value)] = elem
+		}
+	}
+	return targets
+}